@@ -2,8 +2,9 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"flag"
-	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,30 +16,69 @@ import (
 	"github.com/kubechronicle/kubechronicle/internal/admin"
 	"github.com/kubechronicle/kubechronicle/internal/api"
 	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/cloud"
 	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/deployments"
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/listener"
+	"github.com/kubechronicle/kubechronicle/internal/metrics"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/retention"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/subscriptions"
+	"github.com/kubechronicle/kubechronicle/internal/tenancy"
+	"github.com/kubechronicle/kubechronicle/internal/version"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
 	var (
-		port = flag.Int("port", 8080, "Port to listen on")
+		port        = flag.Int("port", 8080, "Port to listen on")
+		listen      = flag.String("listen", cfg.ListenAddress, "Comma-separated addresses to listen on (host:port or unix:///path); overrides -port")
+		showVersion = flag.Bool("version", false, "Print the version and exit")
 	)
 	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-api")
+		return
+	}
 
-	klog.Infof("Starting kubechronicle API server on port %d", *port)
+	klog.Infof("Starting kubechronicle API server %s on port %d", version.Get(), *port)
 
 	// Initialize store
 	if cfg.DatabaseURL == "" {
 		klog.Fatal("DATABASE_URL environment variable is required for API server")
 	}
 
-	eventStore, err := store.NewPostgreSQLStore(cfg.DatabaseURL)
+	eventStore, err := store.NewPostgreSQLStoreWithPool(cfg.DatabaseURL, cfg.DatabasePool)
 	if err != nil {
 		klog.Fatalf("Failed to initialize store: %v", err)
 	}
 	defer eventStore.Close()
+	eventStore.SetPageSizes(cfg.PageSizes)
+
+	// Event queries go to the read replica, if any, while it is up and caught up
+	if cfg.DatabaseReadURL != "" {
+		if err := eventStore.SetReadReplica(cfg.DatabaseReadURL, cfg.DatabaseReplicaMaxLag); err != nil {
+			klog.Warningf("Failed to set up read replica: %v, sending all queries to the primary", err)
+		} else {
+			klog.Infof("Sending event queries to the read replica (max lag %s)", cfg.DatabaseReplicaMaxLag)
+		}
+	}
+
+	if cfg.EncryptionKey != "" {
+		encryptor, err := encryption.NewEncryptorFromKey(cfg.EncryptionKey)
+		if err != nil {
+			klog.Fatalf("Invalid ENCRYPTION_KEY: %v", err)
+		}
+		eventStore.SetEncryptor(encryptor)
+		klog.Infof("Encryption at rest enabled, decrypt roles: %v", cfg.DecryptRoles)
+	}
 
 	// Set up authentication
 	var authenticator *auth.Authenticator
@@ -59,6 +99,75 @@ func main() {
 
 	// Create API server
 	apiServer := api.NewServer(eventStore)
+	apiServer.SetDecryptRoles(cfg.DecryptRoles)
+	apiServer.SetRecordingStore(eventStore, cfg.RecorderRoles)
+	apiServer.SetUsageStore(eventStore)
+	apiServer.SetChurnStore(eventStore, cfg.ChurnConfig)
+	apiServer.SetStatsStore(eventStore)
+	apiServer.SetComplianceStore(eventStore, cfg.ChangeWindowConfig)
+	apiServer.SetDataLossStore(eventStore)
+	apiServer.SetBatchStore(eventStore)
+	apiServer.SetPageSizes(cfg.PageSizes)
+
+	// Follow events written by other processes (e.g. the webhook) for live streaming
+	listenCtx, listenCancel := context.WithCancel(context.Background())
+	defer listenCancel()
+	go apiServer.RunNotificationListener(listenCtx, eventStore)
+
+	// Check that the JSONB fields queries often filter on are indexed
+	eventStore.StartIndexAdvisor(listenCtx, cfg.IndexAdvisor)
+
+	// Deliver events saved by the API (deployments, cloud changes) to subscriptions
+	dispatcher := subscriptions.NewDispatcher(eventStore)
+	if cfg.AlertConfig != nil {
+		// Through the egress controls of the alert config, like the webhook's deliveries
+		if err := dispatcher.SetEgress(cfg.AlertConfig.Egress); err != nil {
+			klog.Warningf("Failed to apply egress controls to subscriptions: %v", err)
+		}
+	}
+	dispatcher.Start(listenCtx)
+	savingStore := subscriptions.WrapStore(eventStore, dispatcher)
+
+	// Pseudonymize the actors of the events saved by the API, like the webhook
+	var pseudonymizer *pseudonym.Pseudonymizer
+	if cfg.PseudonymKey != "" {
+		var err error
+		pseudonymizer, err = pseudonym.New(cfg.PseudonymKey, cfg.PseudonymConfig)
+		if err != nil {
+			klog.Fatalf("Invalid PSEUDONYM_KEY: %v", err)
+		}
+		pseudonymizer.SetMappingStore(eventStore)
+		savingStore = pseudonym.WrapStore(savingStore, pseudonymizer)
+		klog.Info("Actor pseudonymization enabled")
+	}
+
+	// Record the activity of the ingest endpoints, and report that of every event source
+	sourceTracker := sources.NewTracker(sources.IngestAPI, cfg.SourceHealthConfig.ClusterName(), eventStore)
+	sourceTracker.Start(listenCtx)
+	savingStore = sources.WrapStore(savingStore, sourceTracker)
+
+	// Save heartbeats through the same store as the ingested events, to measure their delivery
+	heartbeat.NewEmitter(sources.IngestAPI, cfg.SourceHealthConfig.ClusterName(), cfg.HeartbeatInterval).Start(listenCtx, func(event *model.ChangeEvent) {
+		if err := savingStore.Save(event); err != nil {
+			klog.Warningf("Failed to save heartbeat event %s: %v", event.ID, err)
+		}
+	})
+	apiServer.SetHeartbeatStore(eventStore)
+	apiServer.SetSourceStore(eventStore, cfg.SourceHealthConfig)
+
+	// Run export jobs, writing large result sets to object storage
+	if cfg.ExportJobsConfig != nil {
+		uploader, err := export.NewS3Uploader(cfg.ExportJobsConfig.S3)
+		if err != nil {
+			klog.Fatalf("Invalid EXPORT_JOBS_CONFIG: %v", err)
+		}
+		apiServer.SetExportJobs(eventStore, uploader, cfg.ExportJobsConfig)
+		export.NewJobRunner(eventStore, eventStore, uploader, cfg.ExportJobsConfig).Start(listenCtx)
+		klog.Infof("Export jobs enabled: bucket=%s", cfg.ExportJobsConfig.S3.Bucket)
+	}
+
+	// Purge the events older than the retention, keeping those under legal hold
+	retention.NewPurger(eventStore, cfg.RetentionDays).Start(listenCtx)
 
 	// Initialize Kubernetes client for admin endpoints (optional)
 	var patternsHandler *admin.PatternsHandler
@@ -76,9 +185,20 @@ func main() {
 		klog.Warningf("Failed to initialize Kubernetes client for admin endpoints: %v. Admin pattern management will be disabled.", err)
 	} else {
 		patternsHandler = admin.NewPatternsHandler(k8sClient, namespace, configMapName)
+		// Export and import subscriptions, legal holds and the retention along with the patterns
+		patternsHandler.SetStateStores(eventStore, eventStore, eventStore)
 		klog.Info("Admin pattern management enabled")
 	}
 
+	// List freezes in the calendar from the patterns ConfigMap, or the startup config without it
+	if patternsHandler != nil {
+		apiServer.SetBlockConfigSource(patternsHandler.BlockConfig)
+	} else if cfg.BlockConfig != nil {
+		apiServer.SetBlockConfigSource(func(ctx context.Context) (*config.BlockConfig, error) {
+			return cfg.BlockConfig, nil
+		})
+	}
+
 	// Set up HTTP server
 	mux := http.NewServeMux()
 	
@@ -91,13 +211,44 @@ func main() {
 	// API endpoints (protected by auth middleware)
 	mux.HandleFunc("/kubechronicle/api/changes", apiServer.HandleListChanges)
 	mux.HandleFunc("/kubechronicle/api/changes/", apiServer.HandleGetChange)
+	mux.HandleFunc("/kubechronicle/api/changes/stream", apiServer.HandleStream)
+	mux.HandleFunc("/kubechronicle/api/changes/batch", apiServer.HandleBatchGetChanges)
 	mux.HandleFunc("/kubechronicle/api/resources/", apiServer.HandleResourceHistory)
 	mux.HandleFunc("/kubechronicle/api/users/", apiServer.HandleUserActivity)
+	mux.HandleFunc("/kubechronicle/api/groups/", apiServer.HandleGroupActivity)
+	mux.HandleFunc("/kubechronicle/api/recordings", apiServer.HandleRecordings)
+	mux.HandleFunc("/kubechronicle/api/usage", apiServer.HandleUsage)
+	mux.HandleFunc("/kubechronicle/api/churn", apiServer.HandleChurn)
+	mux.HandleFunc("/kubechronicle/api/stats", apiServer.HandleStats)
+	mux.HandleFunc("/kubechronicle/api/calendar", apiServer.HandleCalendar)
+	mux.HandleFunc("/kubechronicle/api/compliance", apiServer.HandleCompliance)
+	mux.HandleFunc("/kubechronicle/api/data-loss", apiServer.HandleDataLoss)
+	mux.HandleFunc("/kubechronicle/api/sources", apiServer.HandleSources)
+	mux.HandleFunc("/kubechronicle/api/heartbeats", apiServer.HandleHeartbeats)
+	mux.HandleFunc("/kubechronicle/api/exports", apiServer.HandleExports)
+	mux.HandleFunc("/kubechronicle/api/exports/", apiServer.HandleExport)
+
+	// CI/CD deployment webhooks (authenticated by their own secrets, not the auth middleware)
+	if cfg.DeploymentWebhookConfig != nil {
+		deploymentsHandler := deployments.NewWebhookHandler(savingStore, cfg.DeploymentWebhookConfig)
+		mux.HandleFunc("/kubechronicle/api/webhooks/github", deploymentsHandler.HandleGitHub)
+		mux.HandleFunc("/kubechronicle/api/webhooks/gitlab", deploymentsHandler.HandleGitLab)
+		klog.Info("Deployment webhooks enabled")
+	}
+
+	// Cloud provider audit events (authenticated by their own token, not the auth middleware)
+	if cfg.CloudChangeConfig != nil {
+		cloudHandler := cloud.NewHandler(savingStore, cfg.CloudChangeConfig)
+		mux.HandleFunc("/kubechronicle/api/webhooks/eks", cloudHandler.HandleEKS)
+		mux.HandleFunc("/kubechronicle/api/webhooks/gke", cloudHandler.HandleGKE)
+		mux.HandleFunc("/kubechronicle/api/webhooks/aks", cloudHandler.HandleAKS)
+		klog.Info("Cloud change events enabled")
+	}
 	
 	// Admin endpoints (require admin role)
+	adminMux := http.NewServeMux()
 	if patternsHandler != nil {
-		adminMux := http.NewServeMux()
-		adminMux.HandleFunc("/kubechronicle/api/admin/patterns/ignore", func(w http.ResponseWriter, r *http.Request) {
+		ignoreHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet {
 				patternsHandler.HandleGetIgnoreConfig(w, r)
 			} else if r.Method == http.MethodPut {
@@ -106,7 +257,7 @@ func main() {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
 		})
-		adminMux.HandleFunc("/kubechronicle/api/admin/patterns/block", func(w http.ResponseWriter, r *http.Request) {
+		blockHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet {
 				patternsHandler.HandleGetBlockConfig(w, r)
 			} else if r.Method == http.MethodPut {
@@ -115,48 +266,158 @@ func main() {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
 		})
-		
-		// Wrap admin endpoints with admin role requirement
-		if cfg.AuthConfig != nil && cfg.AuthConfig.EnableAuth {
-			mux.Handle("/kubechronicle/api/admin/", authenticator.RequireRole("admin")(adminMux))
+
+		// Optionally let cluster RBAC on the patterns ConfigMap, rather than the admin role,
+		// decide who can read and change patterns
+		if os.Getenv("PATTERNS_AUTHORIZATION") == "kubernetes" && cfg.AuthConfig != nil && cfg.AuthConfig.EnableAuth {
+			reviewer := admin.NewAccessReviewer(k8sClient, namespace, configMapName)
+			mux.Handle("/kubechronicle/api/admin/patterns/ignore", reviewer.RequireConfigMapAccess(ignoreHandler))
+			mux.Handle("/kubechronicle/api/admin/patterns/block", reviewer.RequireConfigMapAccess(blockHandler))
+			klog.Infof("Pattern endpoints authorized by SubjectAccessReview on configmap %s/%s", namespace, configMapName)
 		} else {
-			// If auth is disabled, allow all (for development)
-			mux.Handle("/kubechronicle/api/admin/", adminMux)
+			if os.Getenv("PATTERNS_AUTHORIZATION") == "kubernetes" {
+				klog.Warning("PATTERNS_AUTHORIZATION=kubernetes requires authentication to be enabled, ignoring")
+			}
+			adminMux.Handle("/kubechronicle/api/admin/patterns/ignore", ignoreHandler)
+			adminMux.Handle("/kubechronicle/api/admin/patterns/block", blockHandler)
 		}
+		adminMux.HandleFunc("/kubechronicle/api/admin/alerts", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				patternsHandler.HandleGetAlertConfig(w, r)
+			} else if r.Method == http.MethodPut {
+				patternsHandler.HandleUpdateAlertConfig(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		})
+
+		// Configuration state export/import, for promoting configuration between instances
+		adminMux.HandleFunc("/kubechronicle/api/admin/export", patternsHandler.HandleExport)
+		adminMux.HandleFunc("/kubechronicle/api/admin/import", patternsHandler.HandleImport)
+	}
+
+	// Pattern lint and dry-run (works without the patterns ConfigMap)
+	validateHandler := admin.NewValidateHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/patterns/validate", validateHandler.HandleValidate)
+
+	// Legal holds
+	holdsHandler := admin.NewHoldsHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/holds", holdsHandler.HandleHolds)
+	adminMux.HandleFunc("/kubechronicle/api/admin/holds/", holdsHandler.HandleHold)
+
+	// GDPR user-data erasure
+	erasureHandler := admin.NewErasureHandler(eventStore, pseudonymizer)
+	adminMux.HandleFunc("/kubechronicle/api/admin/erasures", erasureHandler.HandleErasures)
+
+	// Retention of events, overriding RETENTION_DAYS
+	retentionHandler := admin.NewRetentionHandler(eventStore, cfg.RetentionDays)
+	adminMux.HandleFunc("/kubechronicle/api/admin/retention", retentionHandler.HandleRetention)
+
+	// Values of the pseudonyms replacing actors at ingestion
+	pseudonymHandler := admin.NewPseudonymHandler(eventStore, pseudonymizer)
+	adminMux.HandleFunc("/kubechronicle/api/admin/pseudonyms", pseudonymHandler.HandlePseudonyms)
+
+	// Debug capture sessions of the admission requests received by the webhook
+	captureHandler := admin.NewCaptureHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/capture", captureHandler.HandleCapture)
+	adminMux.HandleFunc("/kubechronicle/api/admin/capture/requests", captureHandler.HandleCaptures)
+
+	// Dead letter queue of events whose save or alerts failed in the webhook
+	deadLettersHandler := admin.NewDeadLettersHandler(eventStore)
+	deadLettersHandler.SetDecryptRoles(cfg.DecryptRoles)
+	adminMux.HandleFunc("/kubechronicle/api/admin/dlq", deadLettersHandler.HandleDeadLetters)
+	adminMux.HandleFunc("/kubechronicle/api/admin/dlq/", deadLettersHandler.HandleDeadLetter)
+
+	// Event subscriptions of external automation
+	subscriptionsHandler := admin.NewSubscriptionsHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/subscriptions", subscriptionsHandler.HandleSubscriptions)
+	adminMux.HandleFunc("/kubechronicle/api/admin/subscriptions/", subscriptionsHandler.HandleSubscription)
+
+	// Tamper-evidence verification
+	integrityHandler := admin.NewIntegrityHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/integrity", integrityHandler.HandleVerify)
+
+	// Index advisor of the JSONB fields queries filter on
+	indexesHandler := admin.NewIndexesHandler(eventStore)
+	adminMux.HandleFunc("/kubechronicle/api/admin/indexes", indexesHandler.HandleIndexes)
+
+	// Grafana dashboards of the change history and webhook metrics
+	dashboardsHandler := admin.NewDashboardsHandler()
+	adminMux.HandleFunc("/kubechronicle/api/admin/dashboards/grafana", dashboardsHandler.HandleList)
+	adminMux.HandleFunc("/kubechronicle/api/admin/dashboards/grafana/", dashboardsHandler.HandleDashboard)
+
+	// Effective configuration (secrets redacted)
+	configHandler := admin.NewConfigHandler("api", cfg.Effective(), nil)
+	adminMux.HandleFunc("/kubechronicle/api/admin/config", configHandler.HandleGetConfig)
+
+	// Wrap admin endpoints with admin role requirement
+	if cfg.AuthConfig != nil && cfg.AuthConfig.EnableAuth {
+		mux.Handle("/kubechronicle/api/admin/", authenticator.RequireRole("admin")(adminMux))
+	} else {
+		// If auth is disabled, allow all (for development)
+		mux.Handle("/kubechronicle/api/admin/", adminMux)
 	}
 	
-	// Health check (no auth required)
+	// Health check and build version (no auth required)
 	mux.HandleFunc("/health", healthCheck)
+	mux.HandleFunc("/version", version.Handler)
+	mux.HandleFunc("/kubechronicle/api/version", version.Handler)
+
+	// Store metrics, e.g. store_query_duration_seconds and store_slow_queries_total
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/metrics", metrics.Handler())
 	
 	// Root endpoint
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
 			w.Header().Set("Content-Type", "text/plain")
-			message := "kubechronicle API server\n\nEndpoints:\n  POST /kubechronicle/api/auth/login\n  GET /kubechronicle/api/changes\n  GET /kubechronicle/api/changes/{id}\n  GET /kubechronicle/api/resources/{kind}/{namespace}/{name}/history\n  GET /kubechronicle/api/users/{username}/activity\n  GET /health\n"
+			message := "kubechronicle API server\n\nEndpoints:\n  POST /kubechronicle/api/auth/login\n  GET /kubechronicle/api/changes\n  GET /kubechronicle/api/changes/{id}\n  GET /kubechronicle/api/changes/stream\n  GET /kubechronicle/api/resources/{kind}/{namespace}/{name}/history\n  GET /kubechronicle/api/users/{username}/activity\n  GET /kubechronicle/api/groups/{group}/activity\n  GET /kubechronicle/api/usage\n  GET /kubechronicle/api/stats\n  POST /kubechronicle/api/webhooks/{github,gitlab}\n  POST /kubechronicle/api/webhooks/{eks,gke,aks}\n  GET /kubechronicle/api/version\n  GET /health\n"
 			w.Write([]byte(message))
 		} else {
 			http.NotFound(w, r)
 		}
 	})
 
-	// Apply authentication middleware
-	handler = authenticator.Middleware()(mux)
+	// Apply authentication middleware, scoping requests to the caller's tenants if configured
+	if cfg.TenancyConfig != nil {
+		if cfg.AuthConfig == nil || !cfg.AuthConfig.EnableAuth {
+			klog.Warning("TENANCY_CONFIG is set but authentication is disabled - requests are not scoped to tenants")
+		}
+		if cfg.TenancyConfig.RowLevelSecurity {
+			if err := eventStore.EnableRowLevelSecurity(context.Background()); err != nil {
+				klog.Fatalf("Failed to enable row-level security: %v", err)
+			}
+		}
+		resolver := tenancy.NewResolver(cfg.TenancyConfig)
+		apiServer.SetTenancy(resolver)
+		handler = authenticator.Middleware()(resolver.Middleware()(mux))
+		klog.Infof("Multi-tenancy enabled with %d tenants, unscoped roles: %v", len(cfg.TenancyConfig.Tenants), cfg.TenancyConfig.UnscopedRoles)
+	} else {
+		handler = authenticator.Middleware()(mux)
+	}
+
+	listeners, err := listener.Listen(listener.Addresses(*listen, *port))
+	if err != nil {
+		klog.Fatalf("Failed to start server: %v", err)
+	}
 
+	// Every response names the version of the server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *port),
-		Handler:      handler,
+		Handler:      version.Middleware(handler),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// Start server in goroutine
-	go func() {
-		klog.Infof("API server listening on :%d", *port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			klog.Fatalf("Failed to start server: %v", err)
-		}
-	}()
+	// Start server on each listener
+	klog.Infof("API server listening on %s", listener.String(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+				klog.Fatalf("Failed to start server: %v", err)
+			}
+		}(l)
+	}
 
 	// Graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -14,7 +14,16 @@ import (
 
 	"github.com/kubechronicle/kubechronicle/internal/audit"
 	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/metrics"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/subscriptions"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
 )
 
 func main() {
@@ -28,8 +37,15 @@ func main() {
 		webhookPort       = flag.Int("webhook-port", 8444, "Port for audit log webhook endpoint")
 		enableWebhook     = flag.Bool("enable-webhook", false, "Enable HTTP webhook endpoint for receiving audit logs")
 		databaseURL       = flag.String("database-url", "", "PostgreSQL connection string (or use DATABASE_URL env var)")
+		maxRequestSize    = flag.Int64("max-request-size", audit.DefaultMaxRequestSize, "Maximum audit webhook request body size in bytes (0 disables the limit)")
+		showVersion       = flag.Bool("version", false, "Print the version and exit")
 	)
 	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-audit-processor")
+		return
+	}
+	klog.Infof("Starting kubechronicle audit processor %s", version.Get())
 
 	// Load configuration
 	cfg := config.LoadConfig()
@@ -37,27 +53,114 @@ func main() {
 		cfg.DatabaseURL = *databaseURL
 	}
 
+	// Encrypt diffs and snapshots at rest and in exports
+	var encryptor *encryption.Encryptor
+	if cfg.EncryptionKey != "" {
+		var err error
+		if encryptor, err = encryption.NewEncryptorFromKey(cfg.EncryptionKey); err != nil {
+			klog.Fatalf("Invalid ENCRYPTION_KEY: %v", err)
+		}
+	}
+
 	// Initialize store
 	var storeInstance store.Store
+	var pseudonyms store.PseudonymStore
+	var deadLetters store.DeadLetterStore
+	var dispatcher *subscriptions.Dispatcher
+	var sourceTracker *sources.Tracker
 	if cfg.DatabaseURL != "" {
-		var err error
-		storeInstance, err = store.NewPostgreSQLStore(cfg.DatabaseURL)
+		pgStore, err := store.NewPostgreSQLStoreWithPool(cfg.DatabaseURL, cfg.DatabasePool)
 		if err != nil {
 			klog.Errorf("Failed to initialize store: %v, continuing without persistence", err)
 		} else {
-			defer storeInstance.Close()
+			defer pgStore.Close()
+			if encryptor != nil {
+				pgStore.SetEncryptor(encryptor)
+				klog.Info("Encryption at rest enabled")
+			}
+			storeInstance = pgStore
+			pseudonyms = pgStore
+			deadLetters = pgStore
+			dispatcher = subscriptions.NewDispatcher(pgStore)
+			sourceTracker = sources.NewTracker(sources.AuditProcessor, cfg.SourceHealthConfig.ClusterName(), pgStore)
 		}
 	} else {
-		klog.Warning("No database URL provided, exec events will not be persisted")
+		klog.Warning("No database URL provided, audit events will not be persisted")
+	}
+
+	// Initialize export to an immutable log. Unlike alerting, a broken export
+	// configuration is fatal, since the audit trail would silently be incomplete.
+	exportPipeline, err := export.NewPipeline(cfg.ExportConfig)
+	if err != nil {
+		klog.Fatalf("Failed to initialize export: %v", err)
+	}
+	exportPipeline.SetEncryptor(encryptor)
+	if deadLetters != nil {
+		exportPipeline.SetDeadLetterStore(deadLetters)
+	}
+	storeInstance = export.WrapStore(storeInstance, exportPipeline)
+	storeInstance = subscriptions.WrapStore(storeInstance, dispatcher)
+
+	// Pseudonymize actors before events are stored, exported and alerted on
+	var pseudonymizer *pseudonym.Pseudonymizer
+	if cfg.PseudonymKey != "" {
+		pseudonymizer, err = pseudonym.New(cfg.PseudonymKey, cfg.PseudonymConfig)
+		if err != nil {
+			klog.Fatalf("Invalid PSEUDONYM_KEY: %v", err)
+		}
+		if pseudonyms != nil {
+			pseudonymizer.SetMappingStore(pseudonyms)
+		}
+		storeInstance = pseudonym.WrapStore(storeInstance, pseudonymizer)
+		klog.Info("Actor pseudonymization enabled")
+	}
+
+	// Record the activity of the audit processor, so a silent audit log is noticed
+	storeInstance = sources.WrapStore(storeInstance, sourceTracker)
+
+	// Initialize alerting router. Exec events are alerted if they match the "exec"
+	// rules of the alert config, node maintenance and credential issuance events if
+	// their operation is alerted.
+	var alertRouter *alerting.Router
+	if cfg.AlertConfig != nil {
+		alertRouter, err = alerting.NewRouter(cfg.AlertConfig)
+		if err != nil {
+			klog.Warningf("Failed to initialize alerting: %v, continuing without alerts", err)
+		} else if alertRouter != nil && cfg.AlertConfig.Exec == nil {
+			klog.Info("Alert config has no exec rules, exec events will not be alerted")
+		} else if alertRouter != nil {
+			klog.Info("Audit event alerting enabled")
+		}
+		// Subscription deliveries go through the same egress controls as alerts
+		if err := dispatcher.SetEgress(cfg.AlertConfig.Egress); err != nil {
+			klog.Warningf("Failed to apply egress controls to subscriptions: %v", err)
+		}
 	}
 
 	// Create audit service
 	auditService := audit.NewService(storeInstance)
+	auditService.SetMaxRequestSize(*maxRequestSize)
+	auditService.SetAlertRouter(alertRouter)
+	auditService.SetPseudonymizer(pseudonymizer)
+	if storeInstance != nil {
+		// Send heartbeats through the event queue, to measure the delivery of events
+		auditService.SetHeartbeat(heartbeat.NewEmitter(sources.AuditProcessor, cfg.SourceHealthConfig.ClusterName(), cfg.HeartbeatInterval))
+	}
+	if cfg.ExecRiskConfig != nil {
+		riskScorer, err := audit.NewRiskScorer(cfg.ExecRiskConfig)
+		if err != nil {
+			klog.Fatalf("Invalid EXEC_RISK_CONFIG: %v", err)
+		}
+		auditService.SetRiskScorer(riskScorer)
+	}
 
 	// Start event processing worker
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	auditService.Start(ctx)
+	exportPipeline.Start(ctx)
+	dispatcher.Start(ctx)
+	sourceTracker.Start(ctx)
 
 	// Setup signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -84,14 +187,19 @@ func main() {
 
 	// Start webhook server if enabled
 	if *enableWebhook {
+		// Counters such as audit_oversized_requests_total are served at /debug/vars by the expvar package,
+		// and at /metrics in the Prometheus text format
 		http.HandleFunc("/audit", auditService.HandleAuditWebhook)
+		http.Handle("/metrics", metrics.Handler())
 		http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 		})
+		http.HandleFunc("/version", version.Handler)
 
 		server := &http.Server{
 			Addr:         fmt.Sprintf(":%d", *webhookPort),
+			Handler:      version.Middleware(http.DefaultServeMux),
 			ReadTimeout:  10 * time.Second,
 			WriteTimeout: 10 * time.Second,
 		}
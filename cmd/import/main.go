@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/backfill"
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+)
+
+func main() {
+	var (
+		databaseURL = flag.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (or use DATABASE_URL env var)")
+		batchSize   = flag.Int("batch-size", backfill.DefaultBatchSize, "Events loaded per COPY batch")
+		quiet       = flag.Bool("quiet", false, "Don't report progress after every batch")
+		showVersion = flag.Bool("version", false, "Print the version and exit")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s -database-url <url> [-batch-size 1000] [file.ndjson ...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Imports NDJSON change events, one per line, from the files or stdin.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-import")
+		return
+	}
+
+	if *databaseURL == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	eventStore, err := store.NewPostgreSQLStore(*databaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
+		os.Exit(2)
+	}
+	defer eventStore.Close()
+	if key := os.Getenv("ENCRYPTION_KEY"); key != "" {
+		encryptor, err := encryption.NewEncryptorFromKey(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid ENCRYPTION_KEY: %v\n", err)
+			os.Exit(2)
+		}
+		eventStore.SetEncryptor(encryptor)
+	}
+
+	// Stop between batches on Ctrl-C, reporting what was imported so far
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	total := &backfill.Result{}
+	var importErr error
+	for _, file := range files {
+		var result *backfill.Result
+		result, importErr = importFile(ctx, eventStore, file, *batchSize, *quiet)
+		if result != nil {
+			total.Lines += result.Lines
+			total.Imported += result.Imported
+			total.Duplicates += result.Duplicates
+			total.Duration += result.Duration
+		}
+		if importErr != nil {
+			importErr = fmt.Errorf("%s: %w", file, importErr)
+			break
+		}
+	}
+
+	out, _ := json.MarshalIndent(total, "", "  ")
+	fmt.Println(string(out))
+	if importErr != nil {
+		fmt.Fprintf(os.Stderr, "Import stopped: %v\n", importErr)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Import finished: %d events imported, %d duplicates skipped in %s\n",
+		total.Imported, total.Duplicates, total.Duration.Round(time.Millisecond))
+}
+
+// importFile imports the events of one NDJSON file, or of stdin for "-".
+func importFile(ctx context.Context, s backfill.Store, file string, batchSize int, quiet bool) (*backfill.Result, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	opts := backfill.Options{BatchSize: batchSize}
+	if !quiet {
+		opts.Progress = func(result *backfill.Result) {
+			rate := float64(result.Lines) / result.Duration.Seconds()
+			fmt.Fprintf(os.Stderr, "%s: %d events read, %d imported, %d duplicates (%.0f events/s)\n",
+				file, result.Lines, result.Imported, result.Duplicates, rate)
+		}
+	}
+	return backfill.Import(ctx, s, r, opts)
+}
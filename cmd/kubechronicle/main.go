@@ -0,0 +1,219 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/configcheck"
+	"github.com/kubechronicle/kubechronicle/internal/install"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+)
+
+const usage = "Usage: %[1]s install manifest|alerts [flags]\n       %[1]s config validate [flags]\n       %[1]s backup|restore [flags]\n       %[1]s version\n"
+
+func main() {
+	if len(os.Args) == 2 && (os.Args[1] == "version" || os.Args[1] == "-version" || os.Args[1] == "--version") {
+		version.Print("kubechronicle")
+		return
+	}
+	if len(os.Args) >= 2 && (os.Args[1] == "backup" || os.Args[1] == "restore") {
+		run := backupEvents
+		if os.Args[1] == "restore" {
+			run = restoreEvents
+		}
+		if err := run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running %s: %v\n", os.Args[1], err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] + " " + os.Args[2] {
+	case "install manifest":
+		err = installManifest(os.Args[3:])
+	case "install alerts":
+		err = installAlerts(os.Args[3:])
+	case "config validate":
+		var ok bool
+		ok, err = validateConfig(os.Args[3:])
+		if err == nil && !ok {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running %s %s: %v\n", os.Args[1], os.Args[2], err)
+		os.Exit(1)
+	}
+}
+
+// installManifest writes the deployment manifest for the configuration given by args.
+func installManifest(args []string) error {
+	cfg := install.DefaultConfig()
+
+	fs := flag.NewFlagSet("install manifest", flag.ExitOnError)
+	fs.StringVar(&cfg.Namespace, "namespace", cfg.Namespace, "Namespace to install into")
+	fs.StringVar(&cfg.ImageRegistry, "registry", cfg.ImageRegistry, "Registry prefix of the images, e.g. registry.example.com/")
+	fs.StringVar(&cfg.ImageTag, "tag", cfg.ImageTag, "Image tag")
+	pullSecrets := fs.String("image-pull-secrets", "", "Comma-separated image pull secrets")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level of all components")
+	fs.StringVar(&cfg.DatabaseSecret, "database-secret", cfg.DatabaseSecret, "Secret holding the PostgreSQL connection string under the url key")
+	replicas := fs.Int("webhook-replicas", int(cfg.WebhookReplicas), "Webhook replicas")
+	fs.StringVar(&cfg.WebhookTLSSecret, "webhook-tls-secret", cfg.WebhookTLSSecret, "Secret with the webhook's tls.crt and tls.key")
+	caFile := fs.String("ca-file", "", "PEM CA certificate of the webhook, set as the caBundle")
+	fs.StringVar(&cfg.FailurePolicy, "failure-policy", cfg.FailurePolicy, "Webhook failure policy (Ignore or Fail)")
+	fs.StringVar(&cfg.PatternsName, "patterns-configmap", cfg.PatternsName, "Patterns ConfigMap name")
+	overlays := fs.String("patterns-overlays", "", "Comma-separated overlay pattern ConfigMaps")
+	fs.BoolVar(&cfg.RequirePersistence, "require-persistence", cfg.RequirePersistence, "Fail webhook startup and readiness when the database is unreachable")
+	apiReplicas := fs.Int("api-replicas", int(cfg.APIReplicas), "API replicas")
+	fs.BoolVar(&cfg.AuthEnabled, "auth", cfg.AuthEnabled, "Enable API authentication")
+	fs.StringVar(&cfg.AuthSecret, "auth-secret", cfg.AuthSecret, "Secret holding JWT_SECRET and AUTH_USERS")
+	fs.StringVar(&cfg.PatternsAuthorization, "patterns-authorization", cfg.PatternsAuthorization, "Pattern endpoint authorization (kubernetes, or empty)")
+	fs.BoolVar(&cfg.AuditProcessor, "audit-processor", cfg.AuditProcessor, "Include the audit processor")
+	output := fs.String("o", "", "Output file (default stdout)")
+	fs.Parse(args)
+
+	cfg.WebhookReplicas = int32(*replicas)
+	cfg.APIReplicas = int32(*apiReplicas)
+	cfg.ImagePullSecrets = splitList(*pullSecrets)
+	cfg.PatternsOverlays = splitList(*overlays)
+	if *caFile != "" {
+		ca, err := os.ReadFile(*caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		cfg.CACert = ca
+	}
+
+	manifest, err := install.Manifest(cfg)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, manifest)
+}
+
+// installAlerts writes the PrometheusRule of recommended alerts for the configuration given by args.
+func installAlerts(args []string) error {
+	cfg := install.DefaultAlertConfig()
+
+	fs := flag.NewFlagSet("install alerts", flag.ExitOnError)
+	fs.StringVar(&cfg.Namespace, "namespace", cfg.Namespace, "Namespace kubechronicle is installed in")
+	fs.StringVar(&cfg.Name, "name", cfg.Name, "PrometheusRule name")
+	ruleLabels := fs.String("labels", "", "Comma-separated key=value labels of the PrometheusRule, e.g. release=prometheus")
+	fs.Float64Var(&cfg.QueueSaturation, "queue-saturation", cfg.QueueSaturation, "Fraction (0-1) of the event queue in use to alert at")
+	fs.Float64Var(&cfg.SlowResponses, "slow-responses", cfg.SlowResponses, "Fraction (0-1) of admission responses over the latency budget to alert at")
+	certExpiry := fs.Duration("cert-expiry", cfg.CertExpiry, "Remaining validity of the webhook certificate to alert at")
+	output := fs.String("o", "", "Output file (default stdout)")
+	fs.Parse(args)
+
+	cfg.CertExpiry = *certExpiry
+	for _, item := range splitList(*ruleLabels) {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid label %q, expected key=value", item)
+		}
+		if cfg.Labels == nil {
+			cfg.Labels = make(map[string]string)
+		}
+		cfg.Labels[key] = value
+	}
+
+	manifest, err := install.AlertManifest(cfg)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, manifest)
+}
+
+// validateConfig reports the problems of the configuration in the environment and, if given,
+// the patterns ConfigMap. It returns false if strict mode is enabled and there are errors.
+func validateConfig(args []string) (bool, error) {
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "Exit non-zero if there are errors")
+	patternsDir := fs.String("patterns-dir", os.Getenv("PATTERNS_CONFIGMAP_PATH"), "Directory of the mounted patterns ConfigMap")
+	configMapFile := fs.String("configmap", "", "Patterns ConfigMap manifest (YAML or JSON)")
+	fs.Parse(args)
+
+	problems := configcheck.Check(config.LoadConfig())
+
+	if *patternsDir != "" {
+		data, err := readPatternsDir(*patternsDir)
+		if err != nil {
+			return false, err
+		}
+		problems = append(problems, configcheck.CheckPatterns(data)...)
+	}
+	if *configMapFile != "" {
+		content, err := os.ReadFile(*configMapFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read ConfigMap: %w", err)
+		}
+		var configMap corev1.ConfigMap
+		if err := yaml.Unmarshal(content, &configMap); err != nil {
+			return false, fmt.Errorf("failed to parse ConfigMap: %w", err)
+		}
+		problems = append(problems, configcheck.CheckPatterns(configMap.Data)...)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	errors := configcheck.Errors(problems)
+	fmt.Printf("%d errors, %d warnings\n", errors, len(problems)-errors)
+	return !*strict || errors == 0, nil
+}
+
+// readPatternsDir reads a mounted ConfigMap directory into ConfigMap data, skipping the hidden
+// entries the kubelet adds.
+func readPatternsDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patterns directory: %w", err)
+	}
+	data := make(map[string]string)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		data[entry.Name()] = string(content)
+	}
+	return data, nil
+}
+
+// writeOutput writes a generated manifest to the output file, or stdout if empty.
+func writeOutput(output string, manifest []byte) error {
+	if output == "" {
+		_, err := os.Stdout.Write(manifest)
+		return err
+	}
+	return os.WriteFile(output, manifest, 0o644)
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kubechronicle/kubechronicle/internal/version"
 )
 
 func main() {
@@ -12,6 +14,10 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Usage: %s <password>\n", os.Args[0])
 		os.Exit(1)
 	}
+	if os.Args[1] == "-version" || os.Args[1] == "--version" {
+		version.Print("kubechronicle-password-hash")
+		return
+	}
 
 	password := os.Args[1]
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/replay"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+func main() {
+	var (
+		databaseURL = flag.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (or use DATABASE_URL env var)")
+		alertConfig = flag.String("alert-config", os.Getenv("ALERT_CONFIG"), "Alert channels as JSON, in the ALERT_CONFIG format (or use ALERT_CONFIG env var)")
+		channels    = flag.String("channels", "", "Comma-separated channels to send to, e.g. slack,webhook (default: all configured)")
+		since       = flag.Duration("since", 24*time.Hour, "Replay events from this long ago")
+		until       = flag.Duration("until", 0, "Replay events up to this long ago (default: now)")
+		namespace   = flag.String("namespace", "", "Only replay events in this namespace")
+		kind        = flag.String("kind", "", "Only replay events for this resource kind")
+		name        = flag.String("name", "", "Only replay events for resources with this name")
+		username    = flag.String("user", "", "Only replay events made by this user")
+		operation   = flag.String("operation", "", "Only replay events with this operation (CREATE, UPDATE, DELETE, EXEC)")
+		blocked     = flag.Bool("blocked", false, "Only replay blocked requests")
+		limit       = flag.Int("limit", 0, "Maximum number of events to replay (default: all matching)")
+		interval    = flag.Duration("interval", 200*time.Millisecond, "Pause between events, to stay below channel rate limits")
+		dryRun      = flag.Bool("dry-run", false, "Count the events that would be sent without sending them")
+		showVersion = flag.Bool("version", false, "Print the version and exit")
+	)
+	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-replay")
+		return
+	}
+
+	if *databaseURL == "" || *alertConfig == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s -database-url <url> -alert-config <json> [-since 24h] [-channels slack] [-dry-run]\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	var cfg alerting.Config
+	if err := json.Unmarshal([]byte(*alertConfig), &cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid alert config: %v\n", err)
+		os.Exit(2)
+	}
+	if err := cfg.ResolveSecrets(os.Getenv(alerting.SecretKey)); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid alert config: %v\n", err)
+		os.Exit(2)
+	}
+	router, err := alerting.NewRouter(&cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing alerting: %v\n", err)
+		os.Exit(2)
+	}
+
+	eventStore, err := store.NewPostgreSQLStore(*databaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
+		os.Exit(2)
+	}
+	defer eventStore.Close()
+	if key := os.Getenv("ENCRYPTION_KEY"); key != "" {
+		encryptor, err := encryption.NewEncryptorFromKey(key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid ENCRYPTION_KEY: %v\n", err)
+			os.Exit(2)
+		}
+		eventStore.SetEncryptor(encryptor)
+	}
+
+	now := time.Now()
+	startTime := now.Add(-*since)
+	endTime := now.Add(-*until)
+	filters := store.QueryFilters{
+		Namespace:    *namespace,
+		ResourceKind: *kind,
+		Name:         *name,
+		Username:     *username,
+		Operation:    *operation,
+		StartTime:    &startTime,
+		EndTime:      &endTime,
+	}
+	if *blocked {
+		allowed := false
+		filters.Allowed = &allowed
+	}
+
+	opts := replay.Options{
+		Filters:  filters,
+		Limit:    *limit,
+		Interval: *interval,
+		DryRun:   *dryRun,
+	}
+	for _, channel := range strings.Split(*channels, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			opts.Channels = append(opts.Channels, channel)
+		}
+	}
+
+	// Stop between events on Ctrl-C, reporting what was sent so far
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := replay.Run(ctx, eventStore, router, opts)
+	if result != nil {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Replay stopped: %v\n", err)
+		os.Exit(2)
+	}
+	if len(result.Failures) > 0 {
+		fmt.Fprintf(os.Stderr, "Replay finished with %d failed events\n", len(result.Failures))
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Replay finished: %d events sent\n", result.Sent)
+}
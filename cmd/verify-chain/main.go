@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+)
+
+func main() {
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (or use DATABASE_URL env var)")
+	expectedHead := flag.String("expected-head", "", "Head hash recorded at a previous verification; fails if it is no longer part of the chain")
+	showVersion := flag.Bool("version", false, "Print the version and exit")
+	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-verify-chain")
+		return
+	}
+
+	if *databaseURL == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s -database-url <url> [-expected-head <hash>]\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	eventStore, err := store.NewPostgreSQLStore(*databaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
+		os.Exit(2)
+	}
+	defer eventStore.Close()
+
+	result, err := eventStore.VerifyChain(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error verifying chain: %v\n", err)
+		os.Exit(2)
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+
+	if !result.Valid {
+		fmt.Fprintf(os.Stderr, "Integrity check FAILED at entry %d (event %s): %s\n", result.FailedSeq, result.FailedEventID, result.Reason)
+		os.Exit(1)
+	}
+	if *expectedHead != "" {
+		found, err := eventStore.ChainContains(context.Background(), *expectedHead)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking expected head: %v\n", err)
+			os.Exit(2)
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Integrity check FAILED: expected head %s is not part of the chain\n", *expectedHead)
+			os.Exit(1)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Integrity check passed: %d events verified\n", result.EventsChecked)
+}
@@ -2,42 +2,110 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"expvar"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
+	"github.com/kubechronicle/kubechronicle/internal/admin"
 	"github.com/kubechronicle/kubechronicle/internal/admission"
-	"github.com/kubechronicle/kubechronicle/internal/alerting"
+	"github.com/kubechronicle/kubechronicle/internal/chaos"
 	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/listener"
+	"github.com/kubechronicle/kubechronicle/internal/metrics"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/spool"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/subscriptions"
+	"github.com/kubechronicle/kubechronicle/internal/version"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+	_ "github.com/kubechronicle/kubechronicle/pkg/plugin/enrich" // Built-in geoip, team and cost-center enrichers
+	_ "github.com/kubechronicle/kubechronicle/pkg/plugin/opa"    // Built-in OPA policy plugin
+	_ "github.com/kubechronicle/kubechronicle/pkg/plugin/wasm"   // Built-in WASM policy plugin
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
 	var (
-		port     = flag.Int("port", cfg.WebhookPort, "Port to listen on")
-		certPath = flag.String("cert", cfg.TLSCertPath, "Path to TLS certificate")
-		keyPath  = flag.String("key", cfg.TLSKeyPath, "Path to TLS private key")
+		port        = flag.Int("port", cfg.WebhookPort, "Port to listen on")
+		listen      = flag.String("listen", cfg.ListenAddress, "Comma-separated addresses to listen on (host:port or unix:///path); overrides -port")
+		certPath    = flag.String("cert", cfg.TLSCertPath, "Path to TLS certificate")
+		keyPath     = flag.String("key", cfg.TLSKeyPath, "Path to TLS private key")
+		showVersion = flag.Bool("version", false, "Print the version and exit")
 	)
+
+	// Fault injection for resilience testing; never set these in production
+	chaosConfig := &chaos.Config{}
+	flag.DurationVar(&chaosConfig.StoreLatency, "chaos-store-latency", 0, "Delay every store write by this duration (testing only)")
+	flag.Float64Var(&chaosConfig.StoreErrorRate, "chaos-store-error-rate", 0, "Fail this fraction (0-1) of store writes (testing only)")
+	flag.Float64Var(&chaosConfig.AlertErrorRate, "chaos-alert-error-rate", 0, "Fail this fraction (0-1) of alert sends (testing only)")
+	flag.IntVar(&chaosConfig.QueueCapacity, "chaos-queue-capacity", 0, "Shrink the event queue to this many events (testing only)")
 	flag.Parse()
+	if *showVersion {
+		version.Print("kubechronicle-webhook")
+		return
+	}
 
-	klog.Infof("Starting kubechronicle webhook on port %d", *port)
+	klog.Infof("Starting kubechronicle webhook %s on port %d", version.Get(), *port)
 	klog.Infof("Certificate: %s, Key: %s", *certPath, *keyPath)
 
+	// Minimum TLS version, cipher suites and client certificate verification
+	tlsConfig, err := cfg.ServerTLSConfig()
+	if err != nil {
+		klog.Fatalf("Invalid TLS configuration: %v", err)
+	}
+	if tlsConfig.ClientCAs != nil {
+		klog.Infof("Verifying client certificates against %s (%v)", cfg.TLSClientCAPath, tlsConfig.ClientAuth)
+	}
+	admission.PublishCertExpiry(*certPath, *keyPath)
+
+	// Encrypt diffs and snapshots at rest and in exports
+	var encryptor *encryption.Encryptor
+	if cfg.EncryptionKey != "" {
+		var err error
+		if encryptor, err = encryption.NewEncryptorFromKey(cfg.EncryptionKey); err != nil {
+			klog.Fatalf("Invalid ENCRYPTION_KEY: %v", err)
+		}
+	}
+
 	// Initialize store
 	var eventStore store.Store
+	var deadLetterStore store.DeadLetterStore
+	var pgStore *store.PostgreSQLStore
+	if cfg.RequirePersistence && cfg.DatabaseURL == "" {
+		klog.Fatal("REQUIRE_PERSISTENCE is set but DATABASE_URL is empty")
+	}
 	if cfg.DatabaseURL != "" {
-		var err error
-		eventStore, err = store.NewPostgreSQLStore(cfg.DatabaseURL)
+		pgStore, err = store.NewPostgreSQLStoreWithPool(cfg.DatabaseURL, cfg.DatabasePool)
 		if err != nil {
+			if cfg.RequirePersistence {
+				klog.Fatalf("Failed to initialize store: %v (REQUIRE_PERSISTENCE is set)", err)
+			}
 			klog.Warningf("Failed to initialize store: %v, continuing without persistence", err)
+			pgStore = nil
+		} else {
+			if encryptor != nil {
+				pgStore.SetEncryptor(encryptor)
+				klog.Info("Encryption at rest enabled")
+			}
+			eventStore = pgStore
+			deadLetterStore = pgStore
 		}
 	}
 
@@ -53,6 +121,53 @@ func main() {
 		}
 	}
 
+	// Initialize export to an immutable log. Unlike alerting, a broken export
+	// configuration is fatal, since the audit trail would silently be incomplete.
+	exportPipeline, err := export.NewPipeline(cfg.ExportConfig)
+	if err != nil {
+		klog.Fatalf("Failed to initialize export: %v", err)
+	}
+	exportPipeline.SetEncryptor(encryptor)
+	if deadLetterStore != nil {
+		exportPipeline.SetDeadLetterStore(deadLetterStore)
+	}
+	eventStore = export.WrapStore(eventStore, exportPipeline)
+
+	// Deliver saved events to the subscriptions of external automation
+	// through the alert config's egress controls
+	var dispatcher *subscriptions.Dispatcher
+	if pgStore != nil {
+		dispatcher = subscriptions.NewDispatcher(pgStore)
+		if cfg.AlertConfig != nil {
+			if err := dispatcher.SetEgress(cfg.AlertConfig.Egress); err != nil {
+				klog.Warningf("Failed to apply egress controls to subscriptions: %v", err)
+			}
+		}
+	}
+	eventStore = subscriptions.WrapStore(eventStore, dispatcher)
+
+	// Pseudonymize actors before events are stored, exported and alerted on
+	var pseudonymizer *pseudonym.Pseudonymizer
+	if cfg.PseudonymKey != "" {
+		var err error
+		pseudonymizer, err = pseudonym.New(cfg.PseudonymKey, cfg.PseudonymConfig)
+		if err != nil {
+			klog.Fatalf("Invalid PSEUDONYM_KEY: %v", err)
+		}
+		if pgStore != nil {
+			pseudonymizer.SetMappingStore(pgStore)
+		}
+		eventStore = pseudonym.WrapStore(eventStore, pseudonymizer)
+		klog.Info("Actor pseudonymization enabled")
+	}
+
+	// Record the activity of the webhook, so a webhook that stops receiving requests is noticed
+	var sourceTracker *sources.Tracker
+	if pgStore != nil {
+		sourceTracker = sources.NewTracker(sources.Webhook, cfg.SourceHealthConfig.ClusterName(), pgStore)
+	}
+	eventStore = sources.WrapStore(eventStore, sourceTracker)
+
 	// Log configuration
 	if cfg.IgnoreConfig != nil {
 		klog.Infof("Ignore config enabled: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v",
@@ -69,32 +184,170 @@ func main() {
 
 	// Create admission handler
 	handler := admission.NewHandler(eventStore, alertRouter, cfg.IgnoreConfig, cfg.BlockConfig)
+	if cfg.SamplingConfig != nil {
+		handler.SetSamplingConfig(cfg.SamplingConfig)
+	}
+	if cfg.QuotaConfig != nil {
+		handler.SetQuotaConfig(cfg.QuotaConfig)
+	}
+	if err := handler.SetPlugins(cfg.PluginConfigs); err != nil {
+		klog.Fatalf("Invalid PLUGIN_CONFIG: %v", err)
+	}
+	handler.SetSnapshotUpdates(cfg.SnapshotUpdates)
+	handler.SetSnapshotConfig(cfg.SnapshotConfig)
+	handler.SetDeleteCollection(cfg.DeleteCollectionMinDeletes, cfg.DeleteCollectionWindow)
+	handler.SetNamespaceCascade(cfg.NamespaceCascadeWindow)
+	handler.SetImageProvenance(cfg.ImageProvenance)
+	if cfg.WarnConfig != nil {
+		handler.SetWarnConfig(cfg.WarnConfig)
+	}
+	if cfg.MessageCatalog != nil {
+		handler.SetMessageCatalog(cfg.MessageCatalog)
+	}
+	if err := chaosConfig.Validate(); err != nil {
+		klog.Fatalf("Invalid fault injection flags: %v", err)
+	}
+	if deadLetterStore != nil {
+		handler.SetDeadLetterStore(deadLetterStore)
+	}
+	if dispatcher != nil {
+		handler.SetEgressTarget(dispatcher)
+	}
+	if exportPipeline != nil {
+		handler.SetExportPipeline(exportPipeline)
+	}
+	if cfg.SpillDir != "" {
+		eventSpool, err := spool.New(cfg.SpillDir)
+		if err != nil {
+			klog.Fatalf("Invalid SPILL_DIR: %v", err)
+		}
+		handler.SetSpool(eventSpool)
+		klog.Infof("Spooling events to %s while the store is unavailable", cfg.SpillDir)
+	}
+	// Pseudonymize in the worker too, so events the store fails to save are spooled,
+	// dead-lettered and alerted on with pseudonyms
+	handler.SetPseudonymizer(pseudonymizer)
+	if chaosConfig.Enabled() {
+		klog.Warningf("FAULT INJECTION ENABLED, do not use in production: store_latency=%s, store_error_rate=%v, alert_error_rate=%v, queue_capacity=%d",
+			chaosConfig.StoreLatency, chaosConfig.StoreErrorRate, chaosConfig.AlertErrorRate, chaosConfig.QueueCapacity)
+		handler.SetChaosConfig(chaosConfig)
+	}
+
+	// Kubernetes client, when running in-cluster, for watching the patterns ConfigMap and
+	// the webhook's own configuration
+	var clientset kubernetes.Interface
+	if restConfig, err := rest.InClusterConfig(); err != nil {
+		klog.Warningf("Not running in-cluster (%v), polling mounted patterns and not monitoring the webhook configuration", err)
+	} else if client, err := kubernetes.NewForConfig(restConfig); err != nil {
+		klog.Warningf("Failed to create Kubernetes client: %v, polling mounted patterns and not monitoring the webhook configuration", err)
+	} else {
+		clientset = client
+	}
+
+	// Watch the patterns ConfigMap when running in-cluster, so changes apply within
+	// seconds instead of waiting for the kubelet to refresh the mounted files
+	if configMapName := os.Getenv("PATTERNS_CONFIGMAP_NAME"); configMapName != "" && clientset != nil {
+		namespace := os.Getenv("NAMESPACE")
+		if namespace == "" {
+			namespace = "kubechronicle"
+		}
+		// Overlays (e.g. per-team ConfigMaps) add patterns on top of the base ConfigMap
+		var overlays []string
+		for _, name := range strings.Split(os.Getenv("PATTERNS_CONFIGMAP_OVERLAYS"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				overlays = append(overlays, name)
+			}
+		}
+		// Each overlay only applies to the namespaces declared for it, e.g. {"team-a-patterns": ["team-a-*"]}
+		var overlayNamespaces map[string][]string
+		if raw := strings.TrimSpace(os.Getenv("PATTERNS_CONFIGMAP_OVERLAY_NAMESPACES")); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &overlayNamespaces); err != nil {
+				klog.Fatalf("Invalid PATTERNS_CONFIGMAP_OVERLAY_NAMESPACES: %v", err)
+			}
+		}
+		handler.SetConfigMapWatch(clientset, namespace, configMapName, overlays, overlayNamespaces)
+	}
+
+	// Record and alert on the webhook being disabled or narrowed, ignore patterns being
+	// added and the TLS certificate nearing expiry
+	webhookConfigName := os.Getenv("WEBHOOK_CONFIGURATION_NAME")
+	if webhookConfigName == "" {
+		webhookConfigName = "kubechronicle-webhook"
+	}
+	handler.SetSelfMonitor(clientset, webhookConfigName, *certPath)
+	// Record the reclaim policy of the volumes bound to deleted claims
+	if cfg.WatchVolumes {
+		if clientset != nil {
+			handler.SetVolumeWatch(clientset)
+		} else {
+			klog.Warningf("WATCH_PERSISTENT_VOLUMES requires running in-cluster, the reclaim policy of deleted claims is unknown")
+		}
+	}
+	if cfg.LimitRangeImpact {
+		if clientset != nil {
+			handler.SetLimitRangeImpact(clientset)
+		} else {
+			klog.Warningf("LIMIT_RANGE_IMPACT requires running in-cluster, the impact of LimitRanges is not recorded")
+		}
+	}
+	if pgStore != nil {
+		handler.SetSourceMonitor(pgStore, cfg.SourceHealthConfig)
+		// Capture sampled admission requests while a session started through the admin API runs
+		handler.SetCaptureStore(pgStore)
+		// Send heartbeats through the event queue, to measure the delivery of events
+		handler.SetHeartbeat(heartbeat.NewEmitter(sources.Webhook, cfg.SourceHealthConfig.ClusterName(), cfg.HeartbeatInterval))
+	}
 
 	// Start async event processor
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	handler.Start(ctx)
+	exportPipeline.Start(ctx)
+	dispatcher.Start(ctx)
+	sourceTracker.Start(ctx)
 
 	// Set up HTTP server
 	mux := http.NewServeMux()
 	mux.HandleFunc("/validate", handler.HandleAdmissionReview)
 	mux.HandleFunc("/health", healthCheck)
+	mux.HandleFunc("/ready", readyCheck(cfg.RequirePersistence, pgStore))
+	mux.HandleFunc("/version", version.Handler)
+	mux.Handle("/debug/vars", expvar.Handler()) // Counters, e.g. webhook_oversized_objects_total, webhook_config_reloads_total
+	mux.Handle("/metrics", metrics.Handler())   // The same counters in the Prometheus text format
+
+	// Effective and runtime configuration (secrets redacted). The webhook port has no other
+	// authentication, so it is only served to clients with a verified certificate.
+	if tlsConfig.ClientCAs != nil {
+		configHandler := admin.NewConfigHandler("webhook", cfg.Effective(), func() interface{} {
+			return handler.RuntimeConfig()
+		})
+		mux.Handle("/api/admin/config", admin.RequireClientCert(http.HandlerFunc(configHandler.HandleGetConfig)))
+	} else {
+		klog.Info("Not serving /api/admin/config: it requires client certificates (TLS_CLIENT_CA_PATH)")
+	}
+
+	listeners, err := listener.Listen(listener.Addresses(*listen, *port))
+	if err != nil {
+		klog.Fatalf("Failed to start server: %v", err)
+	}
 
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *port),
-		Handler:      mux,
+		Handler:      version.Middleware(mux),
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// Start server in goroutine
-	go func() {
-		klog.Infof("Webhook server listening on :%d", *port)
-		if err := server.ListenAndServeTLS(*certPath, *keyPath); err != nil && err != http.ErrServerClosed {
-			klog.Fatalf("Failed to start server: %v", err)
-		}
-	}()
+	// Start server on each listener
+	klog.Infof("Webhook server listening on %s", listener.String(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if err := server.ServeTLS(l, *certPath, *keyPath); err != nil && err != http.ErrServerClosed {
+				klog.Fatalf("Failed to start server: %v", err)
+			}
+		}(l)
+	}
 
 	// Graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -126,3 +379,20 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
+
+// readyCheck provides the readiness endpoint. When persistence is required, the webhook is
+// unready while the store can't be reached, so the API server stops sending it requests.
+func readyCheck(requirePersistence bool, pgStore *store.PostgreSQLStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if requirePersistence {
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			defer cancel()
+			if err := pgStore.HealthCheck(ctx); err != nil {
+				http.Error(w, fmt.Sprintf("Store unavailable: %v", err), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}
+}
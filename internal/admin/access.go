@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+)
+
+// accessReviewGroupPrefix is prepended to kubechronicle roles to form the groups of a
+// SubjectAccessReview, e.g. the admin role becomes the group "kubechronicle:admin".
+const accessReviewGroupPrefix = "kubechronicle:"
+
+// AccessReviewer authorizes admin requests with Kubernetes SubjectAccessReviews against
+// the patterns ConfigMap, so existing cluster RBAC governs who can read and change policy.
+type AccessReviewer struct {
+	clientset     kubernetes.Interface
+	namespace     string
+	configMapName string
+}
+
+// NewAccessReviewer creates a new access reviewer for the patterns ConfigMap.
+func NewAccessReviewer(clientset kubernetes.Interface, namespace, configMapName string) *AccessReviewer {
+	return &AccessReviewer{
+		clientset:     clientset,
+		namespace:     namespace,
+		configMapName: configMapName,
+	}
+}
+
+// Authorize reports whether the user may perform verb (e.g. "update") on the patterns ConfigMap,
+// along with the reason given by the authorizer.
+func (a *AccessReviewer) Authorize(ctx context.Context, user *auth.User, verb string) (bool, string, error) {
+	groups := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		groups = append(groups, accessReviewGroupPrefix+role)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: groups,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: a.namespace,
+				Verb:      verb,
+				Resource:  "configmaps",
+				Name:      a.configMapName,
+			},
+		},
+	}
+
+	result, err := a.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to create SubjectAccessReview: %w", err)
+	}
+	return result.Status.Allowed, result.Status.Reason, nil
+}
+
+// RequireConfigMapAccess returns a middleware that authorizes requests with a SubjectAccessReview:
+// GET requires "get" and other methods require "update" on the patterns ConfigMap.
+func (a *AccessReviewer) RequireConfigMapAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, ok := auth.GetUser(r)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		verb := "update"
+		if r.Method == http.MethodGet {
+			verb = "get"
+		}
+
+		allowed, reason, err := a.Authorize(r.Context(), user, verb)
+		if err != nil {
+			klog.Errorf("Failed to authorize %s for user %s: %v", r.URL.Path, user.Username, err)
+			http.Error(w, fmt.Sprintf("Failed to authorize request: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			klog.Warningf("Denied %s %s for user %s: cannot %s configmap %s/%s (%s)",
+				r.Method, r.URL.Path, user.Username, verb, a.namespace, a.configMapName, reason)
+			http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
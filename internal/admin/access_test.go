@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+)
+
+// newReviewClientset returns a fake clientset that answers SubjectAccessReviews with allowed
+// and records the last review spec it received.
+func newReviewClientset(allowed bool, spec *authorizationv1.SubjectAccessReviewSpec) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		*spec = review.Spec
+		review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: allowed, Reason: "test"}
+		return true, review, nil
+	})
+	return clientset
+}
+
+func TestRequireConfigMapAccess(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		user         *auth.User
+		allowed      bool
+		expectedCode int
+		expectedVerb string
+	}{
+		{
+			name:         "get allowed",
+			method:       http.MethodGet,
+			user:         &auth.User{Username: "alice", Roles: []string{"viewer"}},
+			allowed:      true,
+			expectedCode: http.StatusOK,
+			expectedVerb: "get",
+		},
+		{
+			name:         "update allowed",
+			method:       http.MethodPut,
+			user:         &auth.User{Username: "alice", Roles: []string{"admin"}},
+			allowed:      true,
+			expectedCode: http.StatusOK,
+			expectedVerb: "update",
+		},
+		{
+			name:         "update denied",
+			method:       http.MethodPut,
+			user:         &auth.User{Username: "bob", Roles: []string{"viewer"}},
+			allowed:      false,
+			expectedCode: http.StatusForbidden,
+			expectedVerb: "update",
+		},
+		{
+			name:         "no user",
+			method:       http.MethodGet,
+			allowed:      true,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "options passes through",
+			method:       http.MethodOptions,
+			allowed:      false,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spec authorizationv1.SubjectAccessReviewSpec
+			reviewer := NewAccessReviewer(newReviewClientset(tt.allowed, &spec), "kubechronicle", "kubechronicle-patterns")
+			handler := reviewer.RequireConfigMapAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/kubechronicle/api/admin/patterns/ignore", nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), "user", tt.user))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedVerb == "" {
+				return
+			}
+			attrs := spec.ResourceAttributes
+			if attrs == nil {
+				t.Fatal("Expected a SubjectAccessReview to be created")
+			}
+			if attrs.Verb != tt.expectedVerb {
+				t.Errorf("Expected verb %q, got %q", tt.expectedVerb, attrs.Verb)
+			}
+			if attrs.Namespace != "kubechronicle" || attrs.Resource != "configmaps" || attrs.Name != "kubechronicle-patterns" {
+				t.Errorf("Unexpected resource attributes: %+v", attrs)
+			}
+			if spec.User != tt.user.Username {
+				t.Errorf("Expected user %q, got %q", tt.user.Username, spec.User)
+			}
+		})
+	}
+}
+
+func TestAuthorize_PrefixesRoleGroups(t *testing.T) {
+	var spec authorizationv1.SubjectAccessReviewSpec
+	reviewer := NewAccessReviewer(newReviewClientset(true, &spec), "default", "test-patterns")
+
+	user := &auth.User{Username: "alice", Roles: []string{"admin", "viewer"}}
+	allowed, _, err := reviewer.Authorize(context.Background(), user, "get")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Error("Expected access to be allowed")
+	}
+
+	expected := []string{"kubechronicle:admin", "kubechronicle:viewer"}
+	if len(spec.Groups) != len(expected) {
+		t.Fatalf("Expected groups %v, got %v", expected, spec.Groups)
+	}
+	for i, group := range expected {
+		if spec.Groups[i] != group {
+			t.Errorf("Expected group %q, got %q", group, spec.Groups[i])
+		}
+	}
+}
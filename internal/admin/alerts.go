@@ -0,0 +1,204 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// HandleGetAlertConfig handles GET /api/admin/alerts. Secrets are redacted.
+func (h *PatternsHandler) HandleGetAlertConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	alertConfig, err := h.getAlertConfig(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get alert config: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alertConfig.Redacted())
+}
+
+// HandleUpdateAlertConfig handles PUT /api/admin/alerts.
+// Secrets sent back in redacted form keep their current values.
+func (h *PatternsHandler) HandleUpdateAlertConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var alertConfig alerting.Config
+	if err := json.NewDecoder(r.Body).Decode(&alertConfig); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	current, err := h.getAlertConfig(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get alert config: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	alertConfig.RestoreRedacted(current)
+	if secrets := alertConfig.RedactedSecrets(); len(secrets) > 0 {
+		http.Error(w, fmt.Sprintf("Alert secrets %s are redacted and not set; set them in the request", strings.Join(secrets, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	// Validate by building the router the webhook will build on reload
+	if _, err := alerting.NewRouter(&alertConfig); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid alert configuration: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.updateAlertConfig(r.Context(), &alertConfig); err != nil {
+		klog.Errorf("Failed to update alert config: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to update configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alertConfig.Redacted())
+}
+
+// getAlertConfig reads the alert config from the ConfigMap, with the secrets it references
+// from the alert Secret. A missing key yields an empty config.
+func (h *PatternsHandler) getAlertConfig(ctx context.Context) (*alerting.Config, error) {
+	configMap, err := h.getConfigMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return h.parseAlertConfig(ctx, configMap.Data["ALERT_CONFIG"])
+}
+
+// parseAlertConfig parses the ALERT_CONFIG of the ConfigMap, resolving the secrets it
+// references. An empty value yields an empty config.
+func (h *PatternsHandler) parseAlertConfig(ctx context.Context, alertJSON string) (*alerting.Config, error) {
+	alertConfig := &alerting.Config{}
+	if alertJSON == "" {
+		return alertConfig, nil
+	}
+	if err := json.Unmarshal([]byte(alertJSON), alertConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse alert config: %w", err)
+	}
+	if alertConfig.SecretRef == nil {
+		return alertConfig, nil
+	}
+
+	secret, err := h.clientset.CoreV1().Secrets(h.namespace).Get(ctx, alertConfig.SecretRef.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get alert Secret: %w", err)
+	}
+	var secretJSON string
+	if secret != nil {
+		secretJSON = string(secret.Data[alerting.SecretKey])
+	}
+	// Secrets missing from the Secret stay redacted, so they must be sent again to update the config
+	if err := alertConfig.ResolveSecrets(secretJSON); err != nil {
+		klog.Warningf("Alert config is incomplete: %v", err)
+	}
+	return alertConfig, nil
+}
+
+// updateAlertConfig writes the alert config to the ConfigMap, its secrets to the alert Secret.
+func (h *PatternsHandler) updateAlertConfig(ctx context.Context, alertConfig *alerting.Config) error {
+	stored, err := h.storeAlertSecrets(ctx, alertConfig, false)
+	if err != nil {
+		return err
+	}
+
+	cm, err := h.getConfigMap(ctx)
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	alertJSON, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert config: %w", err)
+	}
+	cm.Data["ALERT_CONFIG"] = string(alertJSON)
+
+	_, err = h.clientset.CoreV1().ConfigMaps(h.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update ConfigMap: %w", err)
+	}
+
+	return nil
+}
+
+// alertSecretName returns the name of the Secret holding alert secrets: the patterns
+// ConfigMap's name with an "-alerts" suffix.
+func (h *PatternsHandler) alertSecretName() string {
+	return h.configMapName + "-alerts"
+}
+
+// storeAlertSecrets writes the alert config with its secrets to the alert Secret, unless
+// dryRun, and returns the config to write to the ConfigMap: redacted, and referencing the
+// Secret. A config without secrets is returned as is and leaves the Secret unchanged.
+func (h *PatternsHandler) storeAlertSecrets(ctx context.Context, alertConfig *alerting.Config, dryRun bool) (*alerting.Config, error) {
+	stored := *alertConfig
+	stored.SecretRef = nil
+	if len(stored.Redacted().RedactedSecrets()) == 0 {
+		return &stored, nil
+	}
+
+	secretJSON, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal alert secrets: %w", err)
+	}
+	ref := &alerting.SecretRef{Name: h.alertSecretName()}
+	if !dryRun {
+		secrets := h.clientset.CoreV1().Secrets(h.namespace)
+		secret, err := secrets.Get(ctx, ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			secret, err = secrets.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: ref.Name, Namespace: h.namespace},
+				Data:       map[string][]byte{alerting.SecretKey: secretJSON},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create alert Secret: %w", err)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get alert Secret: %w", err)
+		} else {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+			secret.Data[alerting.SecretKey] = secretJSON
+			if secret, err = secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+				return nil, fmt.Errorf("failed to update alert Secret: %w", err)
+			}
+		}
+		ref.Version = secret.ResourceVersion
+	}
+
+	redacted := stored.Redacted()
+	redacted.SecretRef = ref
+	return redacted, nil
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+func newAlertsTestHandler(t *testing.T, alertConfig *alerting.Config) (*PatternsHandler, *fake.Clientset) {
+	t.Helper()
+	clientset := fake.NewSimpleClientset()
+	data := map[string]string{}
+	if alertConfig != nil {
+		alertJSON, _ := json.Marshal(alertConfig)
+		data["ALERT_CONFIG"] = string(alertJSON)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-patterns", Namespace: "default"},
+		Data:       data,
+	}
+	clientset.CoreV1().ConfigMaps("default").Create(context.Background(), cm, metav1.CreateOptions{})
+	return NewPatternsHandler(clientset, "default", "test-patterns"), clientset
+}
+
+// storedAlertConfig returns the alert config of the ConfigMap and the one of the alert Secret.
+func storedAlertConfig(t *testing.T, clientset *fake.Clientset) (stored, secrets *alerting.Config) {
+	t.Helper()
+	cm, err := clientset.CoreV1().ConfigMaps("default").Get(context.Background(), "test-patterns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	stored = &alerting.Config{}
+	if err := json.Unmarshal([]byte(cm.Data["ALERT_CONFIG"]), stored); err != nil {
+		t.Fatalf("Failed to parse stored config: %v", err)
+	}
+	secret, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "test-patterns-alerts", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get alert Secret: %v", err)
+	}
+	secrets = &alerting.Config{}
+	if err := json.Unmarshal(secret.Data[alerting.SecretKey], secrets); err != nil {
+		t.Fatalf("Failed to parse alert secrets: %v", err)
+	}
+	return stored, secrets
+}
+
+func TestHandleGetAlertConfig_RedactsSecrets(t *testing.T) {
+	handler, _ := newAlertsTestHandler(t, &alerting.Config{
+		Telegram: &alerting.TelegramConfig{BotToken: "bot-secret", ChatIDs: []string{"123"}},
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleGetAlertConfig(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/alerts", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "bot-secret") {
+		t.Errorf("Response should not contain the bot token: %s", w.Body.String())
+	}
+
+	var result alerting.Config
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Telegram == nil || result.Telegram.BotToken != alerting.RedactedValue || len(result.Telegram.ChatIDs) != 1 {
+		t.Errorf("Unexpected telegram config: %+v", result.Telegram)
+	}
+}
+
+func TestHandleGetAlertConfig_Empty(t *testing.T) {
+	handler, _ := newAlertsTestHandler(t, nil)
+
+	w := httptest.NewRecorder()
+	handler.HandleGetAlertConfig(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/alerts", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleUpdateAlertConfig_KeepsRedactedSecrets(t *testing.T) {
+	handler, clientset := newAlertsTestHandler(t, &alerting.Config{
+		Telegram: &alerting.TelegramConfig{BotToken: "bot-secret", ChatIDs: []string{"123"}},
+	})
+
+	// The client edits the redacted config it read and writes it back
+	body := `{"telegram": {"bot_token": "xxxxx", "chat_ids": ["123", "456"]}, "operations": ["DELETE"]}`
+	w := httptest.NewRecorder()
+	handler.HandleUpdateAlertConfig(w, httptest.NewRequest(http.MethodPut, "/kubechronicle/api/admin/alerts", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The token moves from the ConfigMap to the alert Secret, which the ConfigMap references
+	stored, secrets := storedAlertConfig(t, clientset)
+	if stored.Telegram.BotToken != alerting.RedactedValue || stored.SecretRef == nil || stored.SecretRef.Name != "test-patterns-alerts" {
+		t.Errorf("Expected the ConfigMap to reference the alert Secret, got %+v %+v", stored, stored.Telegram)
+	}
+	if secrets.Telegram.BotToken != "bot-secret" {
+		t.Errorf("Stored bot token = %q, want the original token", secrets.Telegram.BotToken)
+	}
+	if len(stored.Telegram.ChatIDs) != 2 || len(stored.Operations) != 1 {
+		t.Errorf("Stored config was not updated: %+v", stored)
+	}
+
+	// Later updates keep the token from the Secret
+	w = httptest.NewRecorder()
+	handler.HandleUpdateAlertConfig(w, httptest.NewRequest(http.MethodPut, "/kubechronicle/api/admin/alerts", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, secrets := storedAlertConfig(t, clientset); secrets.Telegram.BotToken != "bot-secret" {
+		t.Errorf("Stored bot token = %q after a second update, want the original token", secrets.Telegram.BotToken)
+	}
+}
+
+func TestHandleUpdateAlertConfig_Invalid(t *testing.T) {
+	handler, _ := newAlertsTestHandler(t, nil)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"redacted secret without a current value", `{"slack": {"webhook_url": "xxxxx"}}`},
+		{"invalid email port", `{"email": {"smtp_host": "smtp.example.com", "smtp_port": 0, "from": "a@example.com", "to": ["b@example.com"]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.HandleUpdateAlertConfig(w, httptest.NewRequest(http.MethodPut, "/kubechronicle/api/admin/alerts", bytes.NewBufferString(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Limits of capture sessions.
+const (
+	defaultCaptureDuration = 15 * time.Minute
+	maxCaptureDuration     = 24 * time.Hour
+	defaultMaxCaptures     = 1000
+	maxMaxCaptures         = 10000
+	defaultCapturesLimit   = 100
+)
+
+// CaptureHandler handles the admin endpoints starting and stopping the debug sessions
+// during which the webhook captures a sample of the admission requests it receives.
+type CaptureHandler struct {
+	store store.CaptureStore
+}
+
+// NewCaptureHandler creates a new capture handler.
+func NewCaptureHandler(store store.CaptureStore) *CaptureHandler {
+	return &CaptureHandler{
+		store: store,
+	}
+}
+
+// StartCaptureRequest represents a request to start a capture session.
+type StartCaptureRequest struct {
+	Percent     float64  `json:"percent"`                // Of the admission requests captured, in (0, 100]
+	Duration    string   `json:"duration,omitempty"`     // Defaults to 15m, at most 24h
+	Namespaces  []string `json:"namespaces,omitempty"`   // Patterns of the namespaces captured; empty captures all
+	MaxCaptures int      `json:"max_captures,omitempty"` // Defaults to 1000, at most 10000
+	Reason      string   `json:"reason"`
+}
+
+// HandleCapture handles GET /api/admin/capture, returning the latest capture session,
+// POST /api/admin/capture, starting a session in place of the running one, and
+// DELETE /api/admin/capture, stopping the running session.
+func (h *CaptureHandler) HandleCapture(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		h.handleGetSession(w, r)
+	case http.MethodPost:
+		h.handleStartSession(w, r)
+	case http.MethodDelete:
+		h.handleStopSession(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleCaptures handles GET /api/admin/capture/requests?session={id}&limit={n}, returning
+// the requests captured by a session, the latest by default, and DELETE
+// /api/admin/capture/requests, deleting the ended sessions with their captures.
+func (h *CaptureHandler) HandleCaptures(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		h.handleListCaptures(w, r)
+	case http.MethodDelete:
+		h.handleDeleteCaptures(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetSession returns the latest capture session.
+func (h *CaptureHandler) handleGetSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.store.GetCaptureSession(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get capture session: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get capture session: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if session == nil {
+		http.Error(w, "No capture session was started", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// handleStartSession starts a capture session.
+func (h *CaptureHandler) handleStartSession(w http.ResponseWriter, r *http.Request) {
+	var req StartCaptureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Percent <= 0 || req.Percent > 100 {
+		http.Error(w, "The percent of requests captured must be greater than 0 and at most 100", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "A reason is required for a capture session", http.StatusBadRequest)
+		return
+	}
+	duration := defaultCaptureDuration
+	if req.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid duration: %q", req.Duration), http.StatusBadRequest)
+			return
+		}
+		if duration > maxCaptureDuration {
+			http.Error(w, fmt.Sprintf("The duration is at most %s", maxCaptureDuration), http.StatusBadRequest)
+			return
+		}
+	}
+	maxCaptures := req.MaxCaptures
+	switch {
+	case maxCaptures == 0:
+		maxCaptures = defaultMaxCaptures
+	case maxCaptures < 0 || maxCaptures > maxMaxCaptures:
+		http.Error(w, fmt.Sprintf("The maximum captures must be between 1 and %d", maxMaxCaptures), http.StatusBadRequest)
+		return
+	}
+
+	session := &store.CaptureSession{
+		Percent:     req.Percent,
+		Namespaces:  req.Namespaces,
+		MaxCaptures: maxCaptures,
+		Reason:      req.Reason,
+		StartedBy:   requestUsername(r),
+		ExpiresAt:   time.Now().Add(duration),
+	}
+	if err := h.store.StartCaptureSession(r.Context(), session); err != nil {
+		klog.Errorf("Failed to start capture session: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to start capture session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Capture session %d started by %s for %s: %s", session.ID, session.StartedBy, duration, session.Reason)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// handleStopSession stops the running capture session.
+func (h *CaptureHandler) handleStopSession(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.StopCaptureSession(r.Context(), requestUsername(r)); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "No capture session is running", http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to stop capture session: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to stop capture session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Capture session stopped by %s", requestUsername(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListCaptures lists the requests captured by a session.
+func (h *CaptureHandler) handleListCaptures(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultCapturesLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxMaxCaptures {
+			http.Error(w, fmt.Sprintf("Invalid limit: %q", limitStr), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var sessionID int64
+	if idStr := query.Get("session"); idStr != "" {
+		var err error
+		if sessionID, err = strconv.ParseInt(idStr, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid session ID: %q", idStr), http.StatusBadRequest)
+			return
+		}
+	} else {
+		session, err := h.store.GetCaptureSession(r.Context())
+		if err != nil {
+			klog.Errorf("Failed to get capture session: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to get capture session: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if session == nil {
+			http.Error(w, "No capture session was started", http.StatusNotFound)
+			return
+		}
+		sessionID = session.ID
+	}
+
+	captures, err := h.store.ListCaptures(r.Context(), sessionID, limit)
+	if err != nil {
+		klog.Errorf("Failed to list admission captures: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list admission captures: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(captures)
+}
+
+// handleDeleteCaptures deletes the ended capture sessions with their captures.
+func (h *CaptureHandler) handleDeleteCaptures(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.store.DeleteCaptures(r.Context(), time.Now())
+	if err != nil {
+		klog.Errorf("Failed to delete admission captures: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to delete admission captures: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("%d admission captures deleted by %s", deleted, requestUsername(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted})
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *CaptureHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
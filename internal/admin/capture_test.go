@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeCaptureStore is an in-memory store.CaptureStore for handler tests.
+type fakeCaptureStore struct {
+	sessions []*store.CaptureSession
+	captures []*store.AdmissionCapture
+}
+
+func (f *fakeCaptureStore) StartCaptureSession(ctx context.Context, session *store.CaptureSession) error {
+	f.StopCaptureSession(ctx, session.StartedBy)
+	session.ID = int64(len(f.sessions) + 1)
+	session.StartedAt = time.Now()
+	f.sessions = append(f.sessions, session)
+	return nil
+}
+
+func (f *fakeCaptureStore) StopCaptureSession(ctx context.Context, stoppedBy string) error {
+	for _, s := range f.sessions {
+		if s.Active(time.Now()) {
+			now := time.Now()
+			s.StoppedAt = &now
+			s.StoppedBy = stoppedBy
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (f *fakeCaptureStore) GetCaptureSession(ctx context.Context) (*store.CaptureSession, error) {
+	if len(f.sessions) == 0 {
+		return nil, nil
+	}
+	return f.sessions[len(f.sessions)-1], nil
+}
+
+func (f *fakeCaptureStore) SaveCapture(ctx context.Context, capture *store.AdmissionCapture) (bool, error) {
+	f.captures = append(f.captures, capture)
+	return true, nil
+}
+
+func (f *fakeCaptureStore) ListCaptures(ctx context.Context, sessionID int64, limit int) ([]*store.AdmissionCapture, error) {
+	captures := []*store.AdmissionCapture{}
+	for _, c := range f.captures {
+		if c.SessionID == sessionID && len(captures) < limit {
+			captures = append(captures, c)
+		}
+	}
+	return captures, nil
+}
+
+func (f *fakeCaptureStore) DeleteCaptures(ctx context.Context, before time.Time) (int64, error) {
+	return int64(len(f.captures)), nil
+}
+
+func TestCaptureHandler_StartListStop(t *testing.T) {
+	fake := &fakeCaptureStore{}
+	handler := NewCaptureHandler(fake)
+
+	// No session yet
+	w := httptest.NewRecorder()
+	handler.HandleCapture(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/capture", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 without a session, got %d", w.Code)
+	}
+
+	body, _ := json.Marshal(StartCaptureRequest{Percent: 5, Duration: "30m", Namespaces: []string{"payments-*"}, Reason: "Diff of CRDs"})
+	w = httptest.NewRecorder()
+	handler.HandleCapture(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/capture", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var started store.CaptureSession
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if started.ID != 1 || started.MaxCaptures != defaultMaxCaptures || started.StartedBy != "anonymous" {
+		t.Errorf("Unexpected started session: %+v", started)
+	}
+	if d := time.Until(started.ExpiresAt); d < 29*time.Minute || d > 30*time.Minute {
+		t.Errorf("Expected the session to expire in 30m, got %s", d)
+	}
+
+	fake.captures = []*store.AdmissionCapture{{ID: 1, SessionID: 1, UID: "a"}, {ID: 2, SessionID: 2, UID: "b"}}
+	w = httptest.NewRecorder()
+	handler.HandleCaptures(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/capture/requests", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var captures []*store.AdmissionCapture
+	if err := json.Unmarshal(w.Body.Bytes(), &captures); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(captures) != 1 || captures[0].UID != "a" {
+		t.Errorf("Expected the capture of the latest session, got %+v", captures)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleCapture(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/capture", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	handler.HandleCapture(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/capture", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 stopping a stopped session, got %d", w.Code)
+	}
+}
+
+func TestCaptureHandler_StartValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		req  StartCaptureRequest
+	}{
+		{"no percent", StartCaptureRequest{Reason: "debug"}},
+		{"percent over 100", StartCaptureRequest{Percent: 150, Reason: "debug"}},
+		{"no reason", StartCaptureRequest{Percent: 10}},
+		{"invalid duration", StartCaptureRequest{Percent: 10, Duration: "soon", Reason: "debug"}},
+		{"duration over 24h", StartCaptureRequest{Percent: 10, Duration: "48h", Reason: "debug"}},
+		{"too many captures", StartCaptureRequest{Percent: 10, MaxCaptures: 50000, Reason: "debug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewCaptureHandler(&fakeCaptureStore{})
+			body, _ := json.Marshal(tt.req)
+			w := httptest.NewRecorder()
+			handler.HandleCapture(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/capture", bytes.NewReader(body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
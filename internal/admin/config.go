@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+// ConfigHandler handles the admin endpoint for inspecting a component's effective configuration.
+type ConfigHandler struct {
+	component string
+	config    *config.EffectiveConfig
+	runtime   func() interface{}
+}
+
+// ConfigResponse is the effective configuration of a component.
+type ConfigResponse struct {
+	Component string                  `json:"component"`
+	Config    *config.EffectiveConfig `json:"config"`
+	Runtime   interface{}             `json:"runtime,omitempty"`
+}
+
+// NewConfigHandler creates a new config handler. runtime, if set, is called on every
+// request to report state that changes while the component runs (e.g. reloaded rules).
+func NewConfigHandler(component string, cfg *config.EffectiveConfig, runtime func() interface{}) *ConfigHandler {
+	return &ConfigHandler{
+		component: component,
+		config:    cfg,
+		runtime:   runtime,
+	}
+}
+
+// RequireClientCert serves next only to clients presenting a certificate verified against
+// the server's client CAs, for admin endpoints served outside the authenticated API.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "A verified client certificate is required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleGetConfig handles GET /api/admin/config.
+func (h *ConfigHandler) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		response := ConfigResponse{
+			Component: h.component,
+			Config:    h.config,
+		}
+		if h.runtime != nil {
+			response.Runtime = h.runtime()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
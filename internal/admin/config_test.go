@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+func TestConfigHandler_GetConfig(t *testing.T) {
+	calls := 0
+	handler := NewConfigHandler("webhook", &config.EffectiveConfig{LogLevel: "info"}, func() interface{} {
+		calls++
+		return map[string]int{"queue_length": calls}
+	})
+
+	for i := 1; i <= 2; i++ {
+		w := httptest.NewRecorder()
+		handler.HandleGetConfig(w, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("HandleGetConfig() status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var response struct {
+			Component string                 `json:"component"`
+			Config    config.EffectiveConfig `json:"config"`
+			Runtime   map[string]int         `json:"runtime"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Component != "webhook" || response.Config.LogLevel != "info" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+		// Runtime state is read on every request
+		if response.Runtime["queue_length"] != i {
+			t.Errorf("Runtime queue_length = %d, want %d", response.Runtime["queue_length"], i)
+		}
+	}
+}
+
+func TestConfigHandler_NoRuntime(t *testing.T) {
+	handler := NewConfigHandler("api", &config.EffectiveConfig{}, nil)
+
+	w := httptest.NewRecorder()
+	handler.HandleGetConfig(w, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if _, ok := response["runtime"]; ok {
+		t.Error("Response should omit runtime when none is configured")
+	}
+}
+
+func TestConfigHandler_MethodNotAllowed(t *testing.T) {
+	handler := NewConfigHandler("api", &config.EffectiveConfig{}, nil)
+
+	w := httptest.NewRecorder()
+	handler.HandleGetConfig(w, httptest.NewRequest(http.MethodPost, "/api/admin/config", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HandleGetConfig() status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	handler := RequireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Without TLS, or with TLS but no verified certificate
+	for _, state := range []*tls.ConnectionState{nil, {}} {
+		r := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+		r.TLS = state
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 without a verified client certificate, got %d", w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/config", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with a verified client certificate, got %d", w.Code)
+	}
+}
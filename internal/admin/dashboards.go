@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// grafanaDashboards holds the Grafana dashboards of kubechronicle, one JSON file per dashboard
+// named after its uid. They are embedded so they match the metrics and schema of this build.
+//
+//go:embed dashboards/*.json
+var grafanaDashboards embed.FS
+
+// DashboardInfo describes a Grafana dashboard served by the API.
+type DashboardInfo struct {
+	UID   string   `json:"uid"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags,omitempty"`
+	URL   string   `json:"url"` // Path of the dashboard JSON to import into Grafana
+}
+
+// DashboardsHandler handles the admin endpoints serving the Grafana dashboards.
+type DashboardsHandler struct{}
+
+// NewDashboardsHandler creates a new dashboards handler.
+func NewDashboardsHandler() *DashboardsHandler {
+	return &DashboardsHandler{}
+}
+
+// HandleList handles GET /api/admin/dashboards/grafana, listing the dashboards.
+func (h *DashboardsHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dashboards, err := listDashboards()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to list dashboards: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboards)
+}
+
+// HandleDashboard handles GET /api/admin/dashboards/grafana/{uid}, returning the dashboard JSON
+// to import into Grafana.
+func (h *DashboardsHandler) HandleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/admin/dashboards/grafana/"), ".json")
+	if uid == "" || strings.Contains(uid, "/") {
+		http.Error(w, fmt.Sprintf("Invalid dashboard: %q", uid), http.StatusBadRequest)
+		return
+	}
+	content, err := grafanaDashboards.ReadFile(path.Join("dashboards", uid+".json"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Dashboard %q not found", uid), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(content)
+}
+
+// listDashboards returns the embedded dashboards, sorted by uid.
+func listDashboards() ([]DashboardInfo, error) {
+	entries, err := grafanaDashboards.ReadDir("dashboards")
+	if err != nil {
+		return nil, err
+	}
+
+	dashboards := make([]DashboardInfo, 0, len(entries))
+	for _, entry := range entries {
+		content, err := grafanaDashboards.ReadFile(path.Join("dashboards", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var info DashboardInfo
+		if err := json.Unmarshal(content, &info); err != nil {
+			return nil, fmt.Errorf("invalid dashboard %s: %w", entry.Name(), err)
+		}
+		info.URL = "/kubechronicle/api/admin/dashboards/grafana/" + info.UID
+		dashboards = append(dashboards, info)
+	}
+	sort.Slice(dashboards, func(i, j int) bool { return dashboards[i].UID < dashboards[j].UID })
+	return dashboards, nil
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *DashboardsHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	// Registers the webhook's metrics, which the dashboards must reference by their current names
+	_ "github.com/kubechronicle/kubechronicle/internal/admission"
+)
+
+func TestDashboardsHandler_List(t *testing.T) {
+	handler := NewDashboardsHandler()
+	w := httptest.NewRecorder()
+	handler.HandleList(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dashboards/grafana", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	var dashboards []DashboardInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &dashboards); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(dashboards) != 2 || dashboards[0].UID != "kubechronicle-changes" || dashboards[1].UID != "kubechronicle-webhook" {
+		t.Fatalf("Dashboards = %+v", dashboards)
+	}
+	if dashboards[1].Title != "kubechronicle / Webhook" || dashboards[1].URL != "/kubechronicle/api/admin/dashboards/grafana/kubechronicle-webhook" {
+		t.Errorf("Unexpected dashboard: %+v", dashboards[1])
+	}
+}
+
+func TestDashboardsHandler_Dashboard(t *testing.T) {
+	handler := NewDashboardsHandler()
+	for _, path := range []string{"kubechronicle-changes", "kubechronicle-webhook.json"} {
+		w := httptest.NewRecorder()
+		handler.HandleDashboard(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dashboards/grafana/"+path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status code = %d, want %d", path, w.Code, http.StatusOK)
+		}
+		var dashboard struct {
+			UID    string            `json:"uid"`
+			Panels []json.RawMessage `json:"panels"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &dashboard); err != nil {
+			t.Fatalf("%s: invalid dashboard JSON: %v", path, err)
+		}
+		if dashboard.UID == "" || len(dashboard.Panels) == 0 {
+			t.Errorf("%s: dashboard without uid or panels", path)
+		}
+	}
+
+	for path, want := range map[string]int{
+		"missing":             http.StatusNotFound,
+		"../admin/dashboards": http.StatusBadRequest,
+		"":                    http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		handler.HandleDashboard(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dashboards/grafana/"+path, nil))
+		if w.Code != want {
+			t.Errorf("%q: status code = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleDashboard(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/dashboards/grafana/kubechronicle-webhook", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status code = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestDashboards_MetricNames(t *testing.T) {
+	metric := regexp.MustCompile(`\bwebhook_[a-z_]+`)
+	content, err := grafanaDashboards.ReadFile("dashboards/kubechronicle-webhook.json")
+	if err != nil {
+		t.Fatalf("Failed to read dashboard: %v", err)
+	}
+	names := metric.FindAllString(string(content), -1)
+	if len(names) == 0 {
+		t.Fatal("Webhook dashboard references no metrics")
+	}
+	for _, name := range names {
+		if expvar.Get(name) == nil {
+			t.Errorf("Webhook dashboard references %s, which the webhook doesn't publish", name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// deadLettersPath is the path of the dead letter queue endpoints.
+const deadLettersPath = "/kubechronicle/api/admin/dlq"
+
+// DeadLettersHandler handles admin endpoints for inspecting, requeueing and purging
+// events whose save or alert delivery failed after all retries.
+type DeadLettersHandler struct {
+	store        store.DeadLetterStore
+	decryptRoles []string // Roles allowed to see encrypted payloads; empty allows all
+}
+
+// NewDeadLettersHandler creates a new dead letter queue handler.
+func NewDeadLettersHandler(store store.DeadLetterStore) *DeadLettersHandler {
+	return &DeadLettersHandler{
+		store: store,
+	}
+}
+
+// SetDecryptRoles restricts which roles see the diff and snapshot of events that are
+// encrypted at rest, as for the change endpoints.
+func (h *DeadLettersHandler) SetDecryptRoles(roles []string) {
+	h.decryptRoles = roles
+}
+
+// PurgeResponse reports how many dead letters were purged.
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+// HandleDeadLetters handles GET and DELETE /api/admin/dlq, which list and purge dead
+// letters, optionally of a single kind (?kind=store, ?kind=alert or ?kind=export).
+func (h *DeadLettersHandler) HandleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != store.DeadLetterKindStore && kind != store.DeadLetterKindAlert && kind != store.DeadLetterKindExport {
+		http.Error(w, fmt.Sprintf("Invalid kind %q (supported: store, alert, export)", kind), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 1000 {
+				http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+				return
+			}
+		}
+		deadLetters, err := h.store.ListDeadLetters(r.Context(), kind, limit)
+		if err != nil {
+			klog.Errorf("Failed to list dead letters: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to list dead letters: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(deadLetters)
+	case http.MethodDelete:
+		purged, err := h.store.PurgeDeadLetters(r.Context(), kind)
+		if err != nil {
+			klog.Errorf("Failed to purge dead letters: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to purge dead letters: %v", err), http.StatusInternalServerError)
+			return
+		}
+		klog.Infof("%d dead letters purged by %s (kind: %q)", purged, requestUsername(r), kind)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PurgeResponse{Purged: purged})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeadLetter handles GET and DELETE /api/admin/dlq/{id}, which inspect and purge a
+// dead letter, and POST /api/admin/dlq/{id}/requeue, which makes the webhook retry it.
+func (h *DeadLettersHandler) HandleDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+
+	idStr, requeue := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, deadLettersPath+"/"), "/requeue")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dead letter ID: %q", idStr), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case requeue && r.Method == http.MethodPost:
+		h.handleRequeue(w, r, id)
+	case !requeue && r.Method == http.MethodGet:
+		h.handleGet(w, r, id)
+	case !requeue && r.Method == http.MethodDelete:
+		h.handleDelete(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGet returns a dead letter with its full event.
+func (h *DeadLettersHandler) handleGet(w http.ResponseWriter, r *http.Request, id int64) {
+	dl, err := h.store.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Dead letter %d not found", id), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to get dead letter %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to get dead letter: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if dl.Event.Encrypted && !h.canDecrypt(r) {
+		dl.Event.Diff = nil
+		dl.Event.ObjectSnapshot = nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dl)
+}
+
+// handleDelete purges a single dead letter.
+func (h *DeadLettersHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.store.DeleteDeadLetter(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Dead letter %d not found", id), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to delete dead letter %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to delete dead letter: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Dead letter %d purged by %s", id, requestUsername(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRequeue marks a dead letter to be retried by the webhook, which picks it up
+// within 30 seconds. A dead letter that fails again reappears with a new ID.
+func (h *DeadLettersHandler) handleRequeue(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.store.RequeueDeadLetter(r.Context(), id, requestUsername(r)); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Dead letter %d not found or already requeued", id), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to requeue dead letter %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to requeue dead letter: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Dead letter %d requeued by %s", id, requestUsername(r))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// canDecrypt reports whether the request's user may see encrypted payloads.
+func (h *DeadLettersHandler) canDecrypt(r *http.Request) bool {
+	if len(h.decryptRoles) == 0 {
+		return true
+	}
+	user, ok := auth.GetUser(r)
+	if !ok {
+		return true // Authentication disabled
+	}
+	for _, role := range user.Roles {
+		for _, allowed := range h.decryptRoles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *DeadLettersHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
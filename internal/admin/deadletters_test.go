@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeDeadLetterStore is an in-memory store.DeadLetterStore for handler tests.
+type fakeDeadLetterStore struct {
+	deadLetters []*store.DeadLetter
+}
+
+func (f *fakeDeadLetterStore) AddDeadLetter(ctx context.Context, dl *store.DeadLetter) error {
+	dl.ID = int64(len(f.deadLetters) + 1)
+	dl.CreatedAt = time.Now()
+	f.deadLetters = append(f.deadLetters, dl)
+	return nil
+}
+
+func (f *fakeDeadLetterStore) ListDeadLetters(ctx context.Context, kind string, limit int) ([]*store.DeadLetter, error) {
+	deadLetters := []*store.DeadLetter{}
+	for _, dl := range f.deadLetters {
+		if kind == "" || dl.Kind == kind {
+			deadLetters = append(deadLetters, dl)
+		}
+	}
+	return deadLetters, nil
+}
+
+func (f *fakeDeadLetterStore) GetDeadLetter(ctx context.Context, id int64) (*store.DeadLetter, error) {
+	for _, dl := range f.deadLetters {
+		if dl.ID == id {
+			copied := *dl
+			event := *dl.Event
+			copied.Event = &event
+			return &copied, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (f *fakeDeadLetterStore) RequeueDeadLetter(ctx context.Context, id int64, requeuedBy string) error {
+	for _, dl := range f.deadLetters {
+		if dl.ID == id && dl.RequeuedAt == nil {
+			now := time.Now()
+			dl.RequeuedAt = &now
+			dl.RequeuedBy = requeuedBy
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (f *fakeDeadLetterStore) ClaimRequeuedDeadLetters(ctx context.Context, limit int) ([]*store.DeadLetter, error) {
+	return nil, nil
+}
+
+func (f *fakeDeadLetterStore) DeleteDeadLetter(ctx context.Context, id int64) error {
+	for i, dl := range f.deadLetters {
+		if dl.ID == id {
+			f.deadLetters = append(f.deadLetters[:i], f.deadLetters[i+1:]...)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (f *fakeDeadLetterStore) PurgeDeadLetters(ctx context.Context, kind string) (int64, error) {
+	kept := []*store.DeadLetter{}
+	for _, dl := range f.deadLetters {
+		if kind != "" && dl.Kind != kind {
+			kept = append(kept, dl)
+		}
+	}
+	purged := int64(len(f.deadLetters) - len(kept))
+	f.deadLetters = kept
+	return purged, nil
+}
+
+// newFakeDeadLetters returns a store with a store dead letter (ID 1) and an encrypted alert dead letter (ID 2).
+func newFakeDeadLetters() *fakeDeadLetterStore {
+	fake := &fakeDeadLetterStore{}
+	fake.AddDeadLetter(context.Background(), &store.DeadLetter{
+		Kind: store.DeadLetterKindStore, Event: &model.ChangeEvent{ID: "e1"}, Error: "timeout", Attempts: 3,
+	})
+	fake.AddDeadLetter(context.Background(), &store.DeadLetter{
+		Kind: store.DeadLetterKindAlert, Channel: "slack", Error: "status 500", Attempts: 3,
+		Event: &model.ChangeEvent{ID: "e2", Encrypted: true, Diff: []model.PatchOp{{Op: "replace", Path: "/data/password"}}},
+	})
+	return fake
+}
+
+func TestDeadLettersHandler_List(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"all kinds", "", http.StatusOK, 2},
+		{"alert kind", "?kind=alert", http.StatusOK, 1},
+		{"invalid kind", "?kind=backup", http.StatusBadRequest, 0},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewDeadLettersHandler(newFakeDeadLetters())
+			w := httptest.NewRecorder()
+			handler.HandleDeadLetters(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dlq"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var deadLetters []store.DeadLetter
+			if err := json.Unmarshal(w.Body.Bytes(), &deadLetters); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(deadLetters) != tt.expectedCount {
+				t.Errorf("Expected %d dead letters, got %d", tt.expectedCount, len(deadLetters))
+			}
+		})
+	}
+}
+
+func TestDeadLettersHandler_InspectRequeueDelete(t *testing.T) {
+	fake := newFakeDeadLetters()
+	handler := NewDeadLettersHandler(fake)
+
+	w := httptest.NewRecorder()
+	handler.HandleDeadLetter(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dlq/2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var dl store.DeadLetter
+	if err := json.Unmarshal(w.Body.Bytes(), &dl); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if dl.Channel != "slack" || dl.Event.ID != "e2" || len(dl.Event.Diff) != 1 {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+
+	// Requeue once; a second requeue finds nothing waiting
+	for _, expected := range []int{http.StatusAccepted, http.StatusNotFound} {
+		w = httptest.NewRecorder()
+		handler.HandleDeadLetter(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/dlq/2/requeue", nil))
+		if w.Code != expected {
+			t.Errorf("Requeue: expected status %d, got %d: %s", expected, w.Code, w.Body.String())
+		}
+	}
+	if fake.deadLetters[1].RequeuedBy != "anonymous" {
+		t.Errorf("RequeuedBy = %q, want anonymous", fake.deadLetters[1].RequeuedBy)
+	}
+
+	// Delete, then the dead letter is gone
+	for _, expected := range []int{http.StatusNoContent, http.StatusNotFound} {
+		w = httptest.NewRecorder()
+		handler.HandleDeadLetter(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/dlq/1", nil))
+		if w.Code != expected {
+			t.Errorf("Delete: expected status %d, got %d: %s", expected, w.Code, w.Body.String())
+		}
+	}
+
+	// Invalid IDs and methods
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{http.MethodGet, "/kubechronicle/api/admin/dlq/abc", http.StatusBadRequest},
+		{http.MethodGet, "/kubechronicle/api/admin/dlq/99", http.StatusNotFound},
+		{http.MethodGet, "/kubechronicle/api/admin/dlq/2/requeue", http.StatusMethodNotAllowed},
+		{http.MethodPut, "/kubechronicle/api/admin/dlq/2", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w = httptest.NewRecorder()
+		handler.HandleDeadLetter(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.expectedStatus {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestDeadLettersHandler_RedactsEncryptedPayload(t *testing.T) {
+	handler := NewDeadLettersHandler(newFakeDeadLetters())
+	handler.SetDecryptRoles([]string{"auditor"})
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/dlq/2", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user", &auth.User{Username: "alice", Roles: []string{"admin"}}))
+	w := httptest.NewRecorder()
+	handler.HandleDeadLetter(w, req)
+
+	var dl store.DeadLetter
+	if err := json.Unmarshal(w.Body.Bytes(), &dl); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(dl.Event.Diff) != 0 || !dl.Event.Encrypted {
+		t.Errorf("Encrypted diff should be removed for users without a decrypt role: %+v", dl.Event)
+	}
+}
+
+func TestDeadLettersHandler_Purge(t *testing.T) {
+	fake := newFakeDeadLetters()
+	handler := NewDeadLettersHandler(fake)
+
+	w := httptest.NewRecorder()
+	handler.HandleDeadLetters(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/dlq?kind=store", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp PurgeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Purged != 1 || len(fake.deadLetters) != 1 || fake.deadLetters[0].Kind != store.DeadLetterKindAlert {
+		t.Errorf("Expected only the store dead letter to be purged, got %d purged, %d left", resp.Purged, len(fake.deadLetters))
+	}
+}
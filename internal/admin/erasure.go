@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// ErasureHandler handles admin endpoints for GDPR user-data erasure.
+type ErasureHandler struct {
+	store         store.ErasureStore
+	pseudonymizer *pseudonym.Pseudonymizer // Resolves usernames to their ingestion pseudonyms; nil without a key
+}
+
+// NewErasureHandler creates a new erasure handler. pseudonymizer may be nil, in which case
+// usernames are erased as given.
+func NewErasureHandler(store store.ErasureStore, pseudonymizer *pseudonym.Pseudonymizer) *ErasureHandler {
+	return &ErasureHandler{
+		store:         store,
+		pseudonymizer: pseudonymizer,
+	}
+}
+
+// ErasureRequest represents a request to erase a user's data.
+type ErasureRequest struct {
+	Username string `json:"username"`
+}
+
+// HandleErasures handles GET and POST /api/admin/erasures.
+func (h *ErasureHandler) HandleErasures(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		records, err := h.store.ListErasures(r.Context())
+		if err != nil {
+			klog.Errorf("Failed to list erasures: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to list erasures: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(records)
+	case http.MethodPost:
+		h.handleErase(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleErase pseudonymizes all events of the requested user. With actor pseudonymization,
+// the events recorded since it was enabled carry the user's pseudonym, which is erased along
+// with the username.
+func (h *ErasureHandler) handleErase(w http.ResponseWriter, r *http.Request) {
+	var req ErasureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Username) == "" {
+		http.Error(w, "A username is required", http.StatusBadRequest)
+		return
+	}
+
+	usernames := []string{req.Username}
+	if h.pseudonymizer != nil {
+		if stored := h.pseudonymizer.StoredUsername(req.Username); stored != req.Username {
+			usernames = append(usernames, stored)
+		}
+	}
+
+	record, err := h.store.PseudonymizeUser(r.Context(), usernames, requestUsername(r))
+	if err != nil {
+		klog.Errorf("Failed to erase user data: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to erase user data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeErasureStore is an in-memory store.ErasureStore for handler tests.
+type fakeErasureStore struct {
+	erasedUsernames [][]string
+	records         []*store.ErasureRecord
+}
+
+func (f *fakeErasureStore) PseudonymizeUser(ctx context.Context, usernames []string, requestedBy string) (*store.ErasureRecord, error) {
+	f.erasedUsernames = append(f.erasedUsernames, usernames)
+	record := &store.ErasureRecord{
+		ID:            int64(len(f.records) + 1),
+		Pseudonym:     "erased-user-test",
+		EventsUpdated: 3,
+		RequestedBy:   requestedBy,
+		CreatedAt:     time.Now(),
+	}
+	f.records = append(f.records, record)
+	return record, nil
+}
+
+func (f *fakeErasureStore) ListErasures(ctx context.Context) ([]*store.ErasureRecord, error) {
+	return f.records, nil
+}
+
+func TestErasureHandler_Erase(t *testing.T) {
+	fake := &fakeErasureStore{}
+	handler := NewErasureHandler(fake, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/erasures", bytes.NewBufferString(`{"username":"alice@example.com"}`))
+	w := httptest.NewRecorder()
+	handler.HandleErasures(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(fake.erasedUsernames) != 1 || len(fake.erasedUsernames[0]) != 1 || fake.erasedUsernames[0][0] != "alice@example.com" {
+		t.Errorf("Unexpected erased usernames: %v", fake.erasedUsernames)
+	}
+
+	var record store.ErasureRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if record.Pseudonym != "erased-user-test" || record.EventsUpdated != 3 {
+		t.Errorf("Unexpected erasure record: %+v", record)
+	}
+	if bytes.Contains(w.Body.Bytes(), []byte("alice@example.com")) {
+		t.Error("Erasure record should not contain the erased username")
+	}
+}
+
+func TestErasureHandler_MissingUsername(t *testing.T) {
+	handler := NewErasureHandler(&fakeErasureStore{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/erasures", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+	handler.HandleErasures(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestErasureHandler_Pseudonymized(t *testing.T) {
+	pseudonymizer, err := pseudonym.New(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), nil)
+	if err != nil {
+		t.Fatalf("pseudonym.New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		want     []string
+	}{
+		{"username and its pseudonym", "alice@example.com", []string{"alice@example.com", pseudonymizer.Pseudonym("alice@example.com")}},
+		{"pseudonym", pseudonymizer.Pseudonym("alice@example.com"), []string{pseudonymizer.Pseudonym("alice@example.com")}},
+		{"excluded user", "system:serviceaccount:ci:deployer", []string{"system:serviceaccount:ci:deployer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeErasureStore{}
+			handler := NewErasureHandler(fake, pseudonymizer)
+
+			body, _ := json.Marshal(ErasureRequest{Username: tt.username})
+			req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/erasures", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			handler.HandleErasures(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if len(fake.erasedUsernames) != 1 {
+				t.Fatalf("Expected 1 erasure, got %d", len(fake.erasedUsernames))
+			}
+			got := fake.erasedUsernames[0]
+			if len(got) != len(tt.want) {
+				t.Fatalf("Erased usernames = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Erased usernames = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// HoldsHandler handles admin endpoints for managing legal holds on events.
+type HoldsHandler struct {
+	store store.LegalHoldStore
+}
+
+// NewHoldsHandler creates a new legal holds handler.
+func NewHoldsHandler(store store.LegalHoldStore) *HoldsHandler {
+	return &HoldsHandler{
+		store: store,
+	}
+}
+
+// CreateHoldRequest represents a request to place a legal hold.
+type CreateHoldRequest struct {
+	Reason  string             `json:"reason"`
+	Filters store.QueryFilters `json:"filters"`
+}
+
+// HandleHolds handles GET and POST /api/admin/holds.
+func (h *HoldsHandler) HandleHolds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		h.handleListHolds(w, r)
+	case http.MethodPost:
+		h.handleCreateHold(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleHold handles DELETE /api/admin/holds/{id}, which releases the hold.
+// Released holds are kept for auditing and can be listed with include_released=true.
+func (h *HoldsHandler) HandleHold(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/admin/holds/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid hold ID: %q", idStr), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.ReleaseLegalHold(r.Context(), id, requestUsername(r)); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Active legal hold %d not found", id), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to release legal hold %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to release legal hold: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Legal hold %d released by %s", id, requestUsername(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListHolds lists legal holds.
+func (h *HoldsHandler) handleListHolds(w http.ResponseWriter, r *http.Request) {
+	includeReleased, _ := strconv.ParseBool(r.URL.Query().Get("include_released"))
+
+	holds, err := h.store.ListLegalHolds(r.Context(), includeReleased)
+	if err != nil {
+		klog.Errorf("Failed to list legal holds: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list legal holds: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(holds)
+}
+
+// handleCreateHold places a new legal hold.
+func (h *HoldsHandler) handleCreateHold(w http.ResponseWriter, r *http.Request) {
+	var req CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "A reason is required for a legal hold", http.StatusBadRequest)
+		return
+	}
+
+	hold := &store.LegalHold{
+		Reason:    req.Reason,
+		Filters:   req.Filters,
+		CreatedBy: requestUsername(r),
+	}
+	if err := h.store.CreateLegalHold(r.Context(), hold); err != nil {
+		klog.Errorf("Failed to create legal hold: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create legal hold: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Legal hold %d placed by %s: %s", hold.ID, hold.CreatedBy, hold.Reason)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *HoldsHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
+
+// requestUsername returns the authenticated username, or "anonymous" when auth is disabled.
+func requestUsername(r *http.Request) string {
+	if user, ok := auth.GetUser(r); ok {
+		return user.Username
+	}
+	return "anonymous"
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeHoldStore is an in-memory store.LegalHoldStore for handler tests.
+type fakeHoldStore struct {
+	holds []*store.LegalHold
+}
+
+func (f *fakeHoldStore) CreateLegalHold(ctx context.Context, hold *store.LegalHold) error {
+	hold.ID = int64(len(f.holds) + 1)
+	hold.CreatedAt = time.Now()
+	f.holds = append(f.holds, hold)
+	return nil
+}
+
+func (f *fakeHoldStore) ListLegalHolds(ctx context.Context, includeReleased bool) ([]*store.LegalHold, error) {
+	holds := []*store.LegalHold{}
+	for _, h := range f.holds {
+		if includeReleased || h.Active() {
+			holds = append(holds, h)
+		}
+	}
+	return holds, nil
+}
+
+func (f *fakeHoldStore) ReleaseLegalHold(ctx context.Context, id int64, releasedBy string) error {
+	for _, h := range f.holds {
+		if h.ID == id && h.Active() {
+			now := time.Now()
+			h.ReleasedAt = &now
+			h.ReleasedBy = releasedBy
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func TestHoldsHandler_CreateListRelease(t *testing.T) {
+	fake := &fakeHoldStore{}
+	handler := NewHoldsHandler(fake)
+
+	body, _ := json.Marshal(CreateHoldRequest{
+		Reason:  "Case 2024-17",
+		Filters: store.QueryFilters{Namespace: "payments"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/holds", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.HandleHolds(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created store.LegalHold
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if created.ID != 1 || created.Filters.Namespace != "payments" || created.CreatedBy != "anonymous" {
+		t.Errorf("Unexpected created hold: %+v", created)
+	}
+
+	// Release the hold
+	req = httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/holds/1", nil)
+	w = httptest.NewRecorder()
+	handler.HandleHold(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Active list is now empty, full list has the released hold
+	req = httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/holds", nil)
+	w = httptest.NewRecorder()
+	handler.HandleHolds(w, req)
+	var active []store.LegalHold
+	json.Unmarshal(w.Body.Bytes(), &active)
+	if len(active) != 0 {
+		t.Errorf("Expected no active holds, got %d", len(active))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/holds?include_released=true", nil)
+	w = httptest.NewRecorder()
+	handler.HandleHolds(w, req)
+	var all []store.LegalHold
+	json.Unmarshal(w.Body.Bytes(), &all)
+	if len(all) != 1 || all[0].ReleasedAt == nil {
+		t.Errorf("Expected one released hold, got %+v", all)
+	}
+}
+
+func TestHoldsHandler_CreateRequiresReason(t *testing.T) {
+	handler := NewHoldsHandler(&fakeHoldStore{})
+
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/holds", bytes.NewBufferString(`{"filters":{}}`))
+	w := httptest.NewRecorder()
+	handler.HandleHolds(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHoldsHandler_ReleaseNotFound(t *testing.T) {
+	handler := NewHoldsHandler(&fakeHoldStore{})
+
+	req := httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/holds/42", nil)
+	w := httptest.NewRecorder()
+	handler.HandleHold(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/holds/abc", nil)
+	w = httptest.NewRecorder()
+	handler.HandleHold(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid ID, got %d", w.Code)
+	}
+}
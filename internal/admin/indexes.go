@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// IndexesHandler handles the admin endpoint of the index advisor, which reports how often
+// API queries filter on JSONB fields and whether those fields are indexed.
+type IndexesHandler struct {
+	store store.IndexAdvisorStore
+}
+
+// NewIndexesHandler creates a new indexes handler.
+func NewIndexesHandler(store store.IndexAdvisorStore) *IndexesHandler {
+	return &IndexesHandler{
+		store: store,
+	}
+}
+
+// CreateIndexRequest represents a request to create the index of a JSONB field.
+type CreateIndexRequest struct {
+	Field string `json:"field"`
+}
+
+// HandleIndexes handles GET and POST /api/admin/indexes.
+func (h *IndexesHandler) HandleIndexes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		h.handleAdvise(w, r)
+	case http.MethodPost:
+		h.handleCreate(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdvise lists the indexes of the JSONB fields filtered on by at least min_queries
+// queries (default 0, all of them).
+func (h *IndexesHandler) handleAdvise(w http.ResponseWriter, r *http.Request) {
+	var minQueries int64
+	if value := r.URL.Query().Get("min_queries"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("Invalid min_queries: %q", value), http.StatusBadRequest)
+			return
+		}
+		minQueries = n
+	}
+
+	advice, err := h.store.AdviseIndexes(r.Context(), minQueries)
+	if err != nil {
+		klog.Errorf("Failed to advise indexes: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to advise indexes: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advice)
+}
+
+// handleCreate starts creating the index of a field. Building an index on a large table
+// outlasts a request, so it is created in the background and listed once it exists.
+func (h *IndexesHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req CreateIndexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	advice, err := h.store.AdviseIndexes(r.Context(), 0)
+	if err != nil {
+		klog.Errorf("Failed to advise indexes: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to advise indexes: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var index *store.IndexAdvice
+	for _, a := range advice {
+		if a.Field == req.Field {
+			index = a
+		}
+	}
+	if index == nil {
+		http.Error(w, fmt.Sprintf("Unknown field %q", req.Field), http.StatusBadRequest)
+		return
+	}
+	if index.Status == store.IndexExists {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(index)
+		return
+	}
+
+	username := requestUsername(r)
+	klog.Infof("Index %s of %s requested by %s", index.Index, index.Field, username)
+	go func() {
+		start := time.Now()
+		if err := h.store.CreateIndex(context.Background(), index.Field); err != nil {
+			klog.Errorf("Failed to create index %s requested by %s: %v", index.Index, username, err)
+			return
+		}
+		klog.Infof("Created index %s in %v", index.Index, time.Since(start).Round(time.Second))
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(index)
+}
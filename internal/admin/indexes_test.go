@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeIndexAdvisorStore is a store.IndexAdvisorStore with fixed advice, recording the
+// indexes created.
+type fakeIndexAdvisorStore struct {
+	advice     []*store.IndexAdvice
+	minQueries int64
+	created    chan string
+}
+
+func (f *fakeIndexAdvisorStore) AdviseIndexes(ctx context.Context, minQueries int64) ([]*store.IndexAdvice, error) {
+	f.minQueries = minQueries
+	return f.advice, nil
+}
+
+func (f *fakeIndexAdvisorStore) CreateIndex(ctx context.Context, field string) error {
+	f.created <- field
+	return nil
+}
+
+func newFakeIndexAdvisorStore() *fakeIndexAdvisorStore {
+	return &fakeIndexAdvisorStore{
+		advice: []*store.IndexAdvice{
+			{Field: "actor.groups", Index: "idx_change_events_actor_groups_gin", Queries: 40, Status: store.IndexExists},
+			{Field: "node_maintenance.node_name", Index: "idx_change_events_node_name", Queries: 12, Status: store.IndexMissing},
+		},
+		created: make(chan string, 1),
+	}
+}
+
+func TestIndexesHandler_Advise(t *testing.T) {
+	fake := newFakeIndexAdvisorStore()
+	handler := NewIndexesHandler(fake)
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/indexes?min_queries=10", nil)
+	w := httptest.NewRecorder()
+	handler.HandleIndexes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var advice []store.IndexAdvice
+	if err := json.NewDecoder(w.Body).Decode(&advice); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(advice) != 2 || fake.minQueries != 10 {
+		t.Errorf("Got %+v with min queries %d", advice, fake.minQueries)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/indexes?min_queries=many", nil)
+	w = httptest.NewRecorder()
+	handler.HandleIndexes(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid min_queries: expected status 400, got %d", w.Code)
+	}
+}
+
+func TestIndexesHandler_Create(t *testing.T) {
+	tests := []struct {
+		name        string
+		field       string
+		wantStatus  int
+		wantCreated bool
+	}{
+		{"missing index", "node_maintenance.node_name", http.StatusAccepted, true},
+		{"existing index", "actor.groups", http.StatusOK, false},
+		{"unknown field", "source.tool", http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeIndexAdvisorStore()
+			handler := NewIndexesHandler(fake)
+
+			req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/indexes", strings.NewReader(`{"field":"`+tt.field+`"}`))
+			w := httptest.NewRecorder()
+			handler.HandleIndexes(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantCreated {
+				if field := <-fake.created; field != tt.field {
+					t.Errorf("Created the index of %q, want %q", field, tt.field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// IntegrityHandler handles the admin endpoint for verifying the event hash chain.
+type IntegrityHandler struct {
+	store store.IntegrityStore
+}
+
+// NewIntegrityHandler creates a new integrity handler.
+func NewIntegrityHandler(store store.IntegrityStore) *IntegrityHandler {
+	return &IntegrityHandler{
+		store: store,
+	}
+}
+
+// HandleVerify handles GET /api/admin/integrity, which verifies the whole chain.
+// A broken chain is reported in the body with "valid": false, not as an HTTP error.
+func (h *IntegrityHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		result, err := h.store.VerifyChain(r.Context())
+		if err != nil {
+			klog.Errorf("Failed to verify integrity chain: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to verify integrity chain: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !result.Valid {
+			klog.Warningf("Integrity chain verification failed at entry %d (event %s): %s", result.FailedSeq, result.FailedEventID, result.Reason)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeIntegrityStore is a store.IntegrityStore returning a fixed result.
+type fakeIntegrityStore struct {
+	result *store.ChainVerification
+	err    error
+}
+
+func (f *fakeIntegrityStore) VerifyChain(ctx context.Context) (*store.ChainVerification, error) {
+	return f.result, f.err
+}
+
+func TestIntegrityHandler_Verify(t *testing.T) {
+	handler := NewIntegrityHandler(&fakeIntegrityStore{result: &store.ChainVerification{
+		Valid:         false,
+		EventsChecked: 4,
+		FailedSeq:     5,
+		FailedEventID: "UPDATE-Deployment-app-1",
+		Reason:        "event contents do not match the recorded hash",
+	}})
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/integrity", nil)
+	w := httptest.NewRecorder()
+	handler.HandleVerify(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result store.ChainVerification
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Valid || result.FailedSeq != 5 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestIntegrityHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		err        error
+		wantStatus int
+	}{
+		{"store error", http.MethodGet, errors.New("db down"), http.StatusInternalServerError},
+		{"method not allowed", http.MethodPost, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewIntegrityHandler(&fakeIntegrityStore{err: tt.err})
+			req := httptest.NewRequest(tt.method, "/kubechronicle/api/admin/integrity", nil)
+			w := httptest.NewRecorder()
+			handler.HandleVerify(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -19,6 +20,7 @@ type PatternsHandler struct {
 	clientset  kubernetes.Interface
 	namespace  string
 	configMapName string
+	stateStores   *stateStores // Database sections of export and import; nil omits them
 }
 
 // NewPatternsHandler creates a new patterns handler.
@@ -134,6 +136,26 @@ func (h *PatternsHandler) HandleGetBlockConfig(w http.ResponseWriter, r *http.Re
 	json.NewEncoder(w).Encode(blockConfig)
 }
 
+// BlockConfig returns the block config of the patterns ConfigMap, or an empty config if the
+// ConfigMap or its BLOCK_CONFIG key doesn't exist.
+func (h *PatternsHandler) BlockConfig(ctx context.Context) (*config.BlockConfig, error) {
+	configMap, err := h.clientset.CoreV1().ConfigMaps(h.namespace).Get(ctx, h.configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &config.BlockConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap: %w", err)
+	}
+
+	var blockConfig config.BlockConfig
+	if blockJSON := configMap.Data["BLOCK_CONFIG"]; blockJSON != "" {
+		if err := json.Unmarshal([]byte(blockJSON), &blockConfig); err != nil {
+			return nil, fmt.Errorf("failed to parse block config: %w", err)
+		}
+	}
+	return &blockConfig, nil
+}
+
 // HandleUpdateBlockConfig handles PUT /api/admin/patterns/block.
 func (h *PatternsHandler) HandleUpdateBlockConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
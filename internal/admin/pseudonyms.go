@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// PseudonymHandler handles the admin endpoint mapping the pseudonyms that replace actor
+// fields at ingestion to their values, and back.
+type PseudonymHandler struct {
+	store         store.PseudonymStore
+	pseudonymizer *pseudonym.Pseudonymizer // Computes the pseudonyms of values; nil without a key
+}
+
+// NewPseudonymHandler creates a new pseudonym handler. pseudonymizer may be nil, in which
+// case values can't be looked up.
+func NewPseudonymHandler(store store.PseudonymStore, pseudonymizer *pseudonym.Pseudonymizer) *PseudonymHandler {
+	return &PseudonymHandler{
+		store:         store,
+		pseudonymizer: pseudonymizer,
+	}
+}
+
+// HandlePseudonyms handles GET /api/admin/pseudonyms?pseudonym={pseudonym}, returning the
+// value a pseudonym replaced, and GET /api/admin/pseudonyms?value={value}, returning the
+// pseudonym of a value, e.g. to query or erase a user's events. Lookups are logged.
+func (h *PseudonymHandler) HandlePseudonyms(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	case http.MethodGet:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var result *store.Pseudonym
+	switch {
+	case query.Get("pseudonym") != "":
+		var err error
+		result, err = h.store.ResolvePseudonym(r.Context(), query.Get("pseudonym"))
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, "Pseudonym not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			klog.Errorf("Failed to resolve pseudonym: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to resolve pseudonym: %v", err), http.StatusInternalServerError)
+			return
+		}
+		klog.Infof("Pseudonym %s resolved by %s", result.Pseudonym, requestUsername(r))
+	case query.Get("value") != "":
+		if h.pseudonymizer == nil {
+			http.Error(w, "Looking up values requires PSEUDONYM_KEY", http.StatusNotImplemented)
+			return
+		}
+		result = &store.Pseudonym{Pseudonym: h.pseudonymizer.Pseudonym(query.Get("value")), Value: query.Get("value")}
+		klog.Infof("Pseudonym %s looked up by %s", result.Pseudonym, requestUsername(r))
+	default:
+		http.Error(w, "A pseudonym or value is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakePseudonymStore is an in-memory store.PseudonymStore for handler tests.
+type fakePseudonymStore map[string]string
+
+func (f fakePseudonymStore) SavePseudonyms(ctx context.Context, pseudonyms []*store.Pseudonym) error {
+	for _, p := range pseudonyms {
+		f[p.Pseudonym] = p.Value
+	}
+	return nil
+}
+
+func (f fakePseudonymStore) ResolvePseudonym(ctx context.Context, p string) (*store.Pseudonym, error) {
+	value, ok := f[p]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &store.Pseudonym{Pseudonym: p, Field: "username", Value: value}, nil
+}
+
+func TestPseudonymHandler(t *testing.T) {
+	pseudonymizer, err := pseudonym.New(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := pseudonymizer.Pseudonym("alice@example.com")
+	handler := NewPseudonymHandler(fakePseudonymStore{alice: "alice@example.com"}, pseudonymizer)
+
+	tests := []struct {
+		query         string
+		wantStatus    int
+		wantPseudonym string
+		wantValue     string
+	}{
+		{"pseudonym=" + alice, http.StatusOK, alice, "alice@example.com"},
+		{"value=alice@example.com", http.StatusOK, alice, "alice@example.com"},
+		{"pseudonym=pseudo-unknown", http.StatusNotFound, "", ""},
+		{"", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/pseudonyms?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		handler.HandlePseudonyms(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.query, w.Code, tt.wantStatus, w.Body.String())
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var result store.Pseudonym
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if result.Pseudonym != tt.wantPseudonym || result.Value != tt.wantValue {
+			t.Errorf("%s: got %+v", tt.query, result)
+		}
+	}
+
+	// Without a key, values can't be looked up
+	handler = NewPseudonymHandler(fakePseudonymStore{}, nil)
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/pseudonyms?value=alice@example.com", nil)
+	w := httptest.NewRecorder()
+	handler.HandlePseudonyms(w, req)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Value lookup without a key: status = %d, want 501", w.Code)
+	}
+}
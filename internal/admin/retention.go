@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// RetentionHandler handles the admin endpoint setting the retention of events.
+type RetentionHandler struct {
+	store       store.RetentionStore
+	defaultDays int
+}
+
+// NewRetentionHandler creates a new retention handler. defaultDays is the retention in
+// effect when none is set through the endpoint (RETENTION_DAYS).
+func NewRetentionHandler(store store.RetentionStore, defaultDays int) *RetentionHandler {
+	return &RetentionHandler{
+		store:       store,
+		defaultDays: defaultDays,
+	}
+}
+
+// RetentionResponse is the retention in effect.
+type RetentionResponse struct {
+	Days        int        `json:"days"`   // 0 keeps events forever
+	Source      string     `json:"source"` // "api" when set through the endpoint, else "env"
+	DefaultDays int        `json:"default_days"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	PurgeBefore *time.Time `json:"purge_before,omitempty"` // Events stored before are purged, unless held
+}
+
+// SetRetentionRequest represents a request to set the retention.
+type SetRetentionRequest struct {
+	Days *int `json:"days"`
+}
+
+// HandleRetention handles GET, PUT and DELETE /api/admin/retention. DELETE restores the
+// default retention.
+func (h *RetentionHandler) HandleRetention(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		h.handleGetRetention(w, r)
+	case http.MethodPut:
+		h.handleSetRetention(w, r)
+	case http.MethodDelete:
+		if err := h.store.DeleteRetentionPolicy(r.Context()); err != nil {
+			klog.Errorf("Failed to reset retention: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to reset retention: %v", err), http.StatusInternalServerError)
+			return
+		}
+		klog.Infof("Retention reset to the default of %d days by %s", h.defaultDays, requestUsername(r))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetRetention returns the retention in effect.
+func (h *RetentionHandler) handleGetRetention(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.store.GetRetentionPolicy(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get retention: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get retention: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h.sendRetention(w, http.StatusOK, policy)
+}
+
+// handleSetRetention sets the retention, overriding the default.
+func (h *RetentionHandler) handleSetRetention(w http.ResponseWriter, r *http.Request) {
+	var req SetRetentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Days == nil || *req.Days < 0 {
+		http.Error(w, "days must be a number of days, or 0 to keep events forever", http.StatusBadRequest)
+		return
+	}
+
+	policy := &store.RetentionPolicy{Days: *req.Days, UpdatedBy: requestUsername(r)}
+	if err := h.store.SetRetentionPolicy(r.Context(), policy); err != nil {
+		klog.Errorf("Failed to set retention: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to set retention: %v", err), http.StatusInternalServerError)
+		return
+	}
+	klog.Infof("Retention set to %d days by %s", policy.Days, policy.UpdatedBy)
+	h.sendRetention(w, http.StatusOK, policy)
+}
+
+// sendRetention writes the retention in effect given the one set through the endpoint, if any.
+func (h *RetentionHandler) sendRetention(w http.ResponseWriter, status int, policy *store.RetentionPolicy) {
+	response := RetentionResponse{Days: h.defaultDays, Source: "env", DefaultDays: h.defaultDays}
+	if policy != nil {
+		updatedAt := policy.UpdatedAt
+		response.Days, response.Source = policy.Days, "api"
+		response.UpdatedBy, response.UpdatedAt = policy.UpdatedBy, &updatedAt
+	}
+	if response.Days > 0 {
+		purgeBefore := time.Now().UTC().AddDate(0, 0, -response.Days)
+		response.PurgeBefore = &purgeBefore
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *RetentionHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
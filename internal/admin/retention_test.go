@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeRetentionStore is an in-memory store.RetentionStore for handler tests.
+type fakeRetentionStore struct {
+	policy *store.RetentionPolicy
+}
+
+func (f *fakeRetentionStore) GetRetentionPolicy(ctx context.Context) (*store.RetentionPolicy, error) {
+	return f.policy, nil
+}
+
+func (f *fakeRetentionStore) SetRetentionPolicy(ctx context.Context, policy *store.RetentionPolicy) error {
+	policy.UpdatedAt = time.Now()
+	f.policy = policy
+	return nil
+}
+
+func (f *fakeRetentionStore) DeleteRetentionPolicy(ctx context.Context) error {
+	f.policy = nil
+	return nil
+}
+
+func (f *fakeRetentionStore) PurgeEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return 0, nil
+}
+
+func TestRetentionHandler(t *testing.T) {
+	fake := &fakeRetentionStore{}
+	handler := NewRetentionHandler(fake, 90)
+	get := func() RetentionResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.HandleRetention(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/retention", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response RetentionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	if response := get(); response.Days != 90 || response.Source != "env" || response.PurgeBefore == nil {
+		t.Errorf("Expected the default retention, got %+v", response)
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleRetention(w, httptest.NewRequest(http.MethodPut, "/kubechronicle/api/admin/retention", strings.NewReader(`{"days": 0}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if response := get(); response.Days != 0 || response.Source != "api" || response.UpdatedBy != "anonymous" || response.PurgeBefore != nil {
+		t.Errorf("Expected events kept forever, got %+v", response)
+	}
+
+	// Resetting restores the default
+	w = httptest.NewRecorder()
+	handler.HandleRetention(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/retention", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if response := get(); response.Days != 90 || response.Source != "env" {
+		t.Errorf("Expected the default retention, got %+v", response)
+	}
+}
+
+func TestRetentionHandler_InvalidRequest(t *testing.T) {
+	handler := NewRetentionHandler(&fakeRetentionStore{}, 0)
+	for _, body := range []string{`{"days": -1}`, `{}`, `not json`} {
+		w := httptest.NewRecorder()
+		handler.HandleRetention(w, httptest.NewRequest(http.MethodPut, "/kubechronicle/api/admin/retention", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleRetention(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/retention", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", w.Code)
+	}
+}
@@ -0,0 +1,448 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/admission"
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// StateVersion is the version of the configuration state document. Imports of other
+// versions are rejected.
+const StateVersion = 1
+
+// StateDocument is the configuration state of an instance, for promoting configuration
+// between instances (e.g. from staging to production). Sections that are omitted on
+// import are left unchanged.
+type StateDocument struct {
+	Version      int                  `json:"version"`
+	ExportedAt   time.Time            `json:"exported_at"`
+	IgnoreConfig *config.IgnoreConfig `json:"ignore_config,omitempty"`
+	BlockConfig  *config.BlockConfig  `json:"block_config,omitempty"`
+	WarnConfig   *config.WarnConfig   `json:"warn_config,omitempty"`
+	AlertConfig  *alerting.Config     `json:"alert_config,omitempty"` // Secrets redacted
+
+	// Database sections, exported when the handler has their stores (see SetStateStores)
+	Subscriptions []*CreateSubscriptionRequest `json:"subscriptions"`       // Secrets redacted
+	LegalHolds    []*CreateHoldRequest         `json:"legal_holds"`         // Active holds
+	Retention     *SetRetentionRequest         `json:"retention,omitempty"` // Only when set through the API
+}
+
+// stateStores are the stores of the database sections of the configuration state.
+type stateStores struct {
+	subscriptions store.SubscriptionStore
+	holds         store.LegalHoldStore
+	retention     store.RetentionStore
+}
+
+// SetStateStores makes export and import include subscriptions, legal holds and the
+// retention policy. Without it, documents with these sections are rejected on import.
+func (h *PatternsHandler) SetStateStores(subscriptions store.SubscriptionStore, holds store.LegalHoldStore, retention store.RetentionStore) {
+	h.stateStores = &stateStores{
+		subscriptions: subscriptions,
+		holds:         holds,
+		retention:     retention,
+	}
+}
+
+// ImportResponse reports the sections of an import that were (or, for a dry run, would be) applied.
+type ImportResponse struct {
+	DryRun  bool                     `json:"dry_run"`
+	Applied []string                 `json:"applied"`
+	Issues  []admission.PatternIssue `json:"issues"` // Pattern lint warnings
+}
+
+// HandleExport handles GET /api/admin/export, which returns the configuration state of
+// this instance. Alert channel secrets are redacted.
+func (h *PatternsHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	configMap, err := h.getConfigMap(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get ConfigMap: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	doc := StateDocument{
+		Version:      StateVersion,
+		ExportedAt:   time.Now().UTC(),
+		IgnoreConfig: &config.IgnoreConfig{},
+		BlockConfig:  &config.BlockConfig{},
+		WarnConfig:   &config.WarnConfig{},
+		AlertConfig:  &alerting.Config{},
+	}
+	for key, target := range map[string]interface{}{
+		"IGNORE_CONFIG": doc.IgnoreConfig,
+		"BLOCK_CONFIG":  doc.BlockConfig,
+		"WARN_CONFIG":   doc.WarnConfig,
+		"ALERT_CONFIG":  doc.AlertConfig,
+	} {
+		if value := configMap.Data[key]; value != "" {
+			if err := json.Unmarshal([]byte(value), target); err != nil {
+				klog.Errorf("Failed to parse %s: %v", key, err)
+				http.Error(w, fmt.Sprintf("Failed to parse configuration %s: %v", key, err), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	// Secrets are redacted either way, and the Secret holding them is specific to this instance
+	doc.AlertConfig = doc.AlertConfig.Redacted()
+	doc.AlertConfig.SecretRef = nil
+
+	if err := h.exportRecords(r.Context(), &doc); err != nil {
+		klog.Errorf("Failed to export configuration: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="kubechronicle-config.json"`)
+	json.NewEncoder(w).Encode(doc)
+}
+
+// HandleImport handles POST /api/admin/import, which applies a configuration state document
+// exported by this or another instance. All sections are validated before any is written,
+// and they are written in a single ConfigMap update. Redacted alert secrets keep the values
+// of this instance; importing a redacted secret this instance doesn't have is rejected.
+// The dry_run query parameter validates the document without applying it.
+func (h *PatternsHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var doc StateDocument
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if doc.Version != StateVersion {
+		http.Error(w, fmt.Sprintf("Unsupported document version %d, expected %d", doc.Version, StateVersion), http.StatusBadRequest)
+		return
+	}
+
+	response := ImportResponse{
+		DryRun:  r.URL.Query().Get("dry_run") == "true",
+		Applied: []string{},
+		Issues:  []admission.PatternIssue{},
+	}
+	var invalid []string
+	for _, issue := range admission.LintPatterns(doc.IgnoreConfig, doc.BlockConfig) {
+		if issue.Severity == admission.SeverityError {
+			invalid = append(invalid, fmt.Sprintf("%s %q: %s", issue.Field, issue.Pattern, issue.Message))
+		} else {
+			response.Issues = append(response.Issues, issue)
+		}
+	}
+	if len(invalid) > 0 {
+		http.Error(w, fmt.Sprintf("Invalid patterns: %s", strings.Join(invalid, "; ")), http.StatusBadRequest)
+		return
+	}
+
+	records, invalid, err := h.planRecords(r.Context(), &doc)
+	if err != nil {
+		klog.Errorf("Failed to get configuration: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(invalid) > 0 {
+		http.Error(w, fmt.Sprintf("Invalid configuration: %s", strings.Join(invalid, "; ")), http.StatusBadRequest)
+		return
+	}
+
+	cm, err := h.getConfigMap(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to get ConfigMap: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to get configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if doc.AlertConfig != nil {
+		current, err := h.parseAlertConfig(r.Context(), cm.Data["ALERT_CONFIG"])
+		if err != nil {
+			klog.Errorf("Failed to get alert config: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to parse configuration ALERT_CONFIG: %v", err), http.StatusInternalServerError)
+			return
+		}
+		doc.AlertConfig.RestoreRedacted(current)
+		if secrets := doc.AlertConfig.RedactedSecrets(); len(secrets) > 0 {
+			http.Error(w, fmt.Sprintf("Alert secrets %s are redacted and not set on this instance; set them in the document", strings.Join(secrets, ", ")), http.StatusBadRequest)
+			return
+		}
+		if _, err := alerting.NewRouter(doc.AlertConfig); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid alert configuration: %v", err), http.StatusBadRequest)
+			return
+		}
+		// Secrets go to the alert Secret, and the ConfigMap references it
+		if doc.AlertConfig, err = h.storeAlertSecrets(r.Context(), doc.AlertConfig, response.DryRun); err != nil {
+			klog.Errorf("Failed to import alert secrets: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to update configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if doc.BlockConfig != nil && doc.BlockConfig.Message == "" {
+		doc.BlockConfig.Message = "Resource blocked by kubechronicle policy"
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	for _, section := range []struct {
+		key   string
+		value interface{}
+		set   bool
+	}{
+		{"IGNORE_CONFIG", doc.IgnoreConfig, doc.IgnoreConfig != nil},
+		{"BLOCK_CONFIG", doc.BlockConfig, doc.BlockConfig != nil},
+		{"WARN_CONFIG", doc.WarnConfig, doc.WarnConfig != nil},
+		{"ALERT_CONFIG", doc.AlertConfig, doc.AlertConfig != nil},
+	} {
+		if !section.set {
+			continue
+		}
+		content, err := json.Marshal(section.value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to marshal %s: %v", section.key, err), http.StatusInternalServerError)
+			return
+		}
+		cm.Data[section.key] = string(content)
+		response.Applied = append(response.Applied, section.key)
+	}
+
+	configMapChanged := len(response.Applied) > 0
+	response.Applied = append(response.Applied, records.sections...)
+
+	if !response.DryRun && configMapChanged {
+		if _, err := h.clientset.CoreV1().ConfigMaps(h.namespace).Update(r.Context(), cm, metav1.UpdateOptions{}); err != nil {
+			klog.Errorf("Failed to import configuration: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to update configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !response.DryRun && len(response.Applied) > 0 {
+		if err := h.applyRecords(r.Context(), records, requestUsername(r)); err != nil {
+			klog.Errorf("Failed to import configuration: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to update configuration: %v", err), http.StatusInternalServerError)
+			return
+		}
+		klog.Infof("Imported configuration %v exported at %s", response.Applied, doc.ExportedAt.Format(time.RFC3339))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// exportRecords adds the database sections to an exported document, if the handler has their stores.
+func (h *PatternsHandler) exportRecords(ctx context.Context, doc *StateDocument) error {
+	if h.stateStores == nil {
+		return nil
+	}
+
+	subs, err := h.stateStores.subscriptions.ListSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	doc.Subscriptions = []*CreateSubscriptionRequest{}
+	for _, sub := range subs {
+		doc.Subscriptions = append(doc.Subscriptions, &CreateSubscriptionRequest{
+			Name:   sub.Name,
+			URL:    sub.URL,
+			Secret: alerting.RedactedValue,
+			Format: sub.Format,
+			Filter: sub.Filter,
+		})
+	}
+
+	holds, err := h.stateStores.holds.ListLegalHolds(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	doc.LegalHolds = []*CreateHoldRequest{}
+	for _, hold := range holds {
+		doc.LegalHolds = append(doc.LegalHolds, &CreateHoldRequest{Reason: hold.Reason, Filters: hold.Filters})
+	}
+
+	policy, err := h.stateStores.retention.GetRetentionPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get retention: %w", err)
+	}
+	if policy != nil {
+		days := policy.Days
+		doc.Retention = &SetRetentionRequest{Days: &days}
+	}
+	return nil
+}
+
+// recordImport is the changes of an import to the database sections.
+type recordImport struct {
+	sections            []string // Sections of the document applied
+	createSubscriptions []*store.Subscription
+	deleteSubscriptions []int64
+	createHolds         []*store.LegalHold
+	retention           *store.RetentionPolicy
+}
+
+// planRecords validates the database sections of an imported document against the current
+// state, returning the changes to apply and the reasons the document is invalid, if any.
+// Subscriptions replace the current ones, keeping those that are unchanged; a redacted
+// secret keeps the secret of the current subscription of the same name. Legal holds are
+// only added: releasing a hold lets its events be purged, which an import must not do.
+func (h *PatternsHandler) planRecords(ctx context.Context, doc *StateDocument) (*recordImport, []string, error) {
+	plan := &recordImport{}
+	if doc.Subscriptions == nil && doc.LegalHolds == nil && doc.Retention == nil {
+		return plan, nil, nil
+	}
+	if h.stateStores == nil {
+		return nil, []string{"subscriptions, legal holds and retention cannot be imported without a database"}, nil
+	}
+
+	var invalid []string
+	if doc.Subscriptions != nil {
+		current, err := h.stateStores.subscriptions.ListSubscriptions(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+		byName := make(map[string]*store.Subscription)
+		for _, sub := range current {
+			if byName[sub.Name] == nil {
+				byName[sub.Name] = sub
+			}
+		}
+
+		kept := make(map[int64]bool)
+		for _, req := range doc.Subscriptions {
+			if err := validateSubscription(req); err != nil {
+				invalid = append(invalid, fmt.Sprintf("subscription %q: %v", req.Name, err))
+				continue
+			}
+			sub := &store.Subscription{Name: req.Name, URL: req.URL, Secret: req.Secret, Format: req.Format, Filter: req.Filter}
+			existing := byName[req.Name]
+			if sub.Secret == alerting.RedactedValue {
+				if existing == nil {
+					invalid = append(invalid, fmt.Sprintf("subscription %q: the secret is redacted and not set on this instance; set it in the document", req.Name))
+					continue
+				}
+				sub.Secret = existing.Secret
+			}
+			if existing != nil && !kept[existing.ID] && existing.URL == sub.URL && existing.Secret == sub.Secret &&
+				existing.Format == sub.Format && sameJSON(existing.Filter, sub.Filter) {
+				kept[existing.ID] = true
+				continue
+			}
+			if sub.Secret == "" {
+				secret, err := newSubscriptionSecret()
+				if err != nil {
+					return nil, nil, err
+				}
+				sub.Secret = secret
+			}
+			plan.createSubscriptions = append(plan.createSubscriptions, sub)
+		}
+		for _, sub := range current {
+			if !kept[sub.ID] {
+				plan.deleteSubscriptions = append(plan.deleteSubscriptions, sub.ID)
+			}
+		}
+		plan.sections = append(plan.sections, "subscriptions")
+	}
+
+	if doc.LegalHolds != nil {
+		current, err := h.stateStores.holds.ListLegalHolds(ctx, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list legal holds: %w", err)
+		}
+		for _, req := range doc.LegalHolds {
+			if strings.TrimSpace(req.Reason) == "" {
+				invalid = append(invalid, "legal hold: a reason is required")
+				continue
+			}
+			exists := false
+			for _, hold := range current {
+				if hold.Reason == req.Reason && sameJSON(hold.Filters, req.Filters) {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				plan.createHolds = append(plan.createHolds, &store.LegalHold{Reason: req.Reason, Filters: req.Filters})
+			}
+		}
+		plan.sections = append(plan.sections, "legal_holds")
+	}
+
+	if doc.Retention != nil {
+		if doc.Retention.Days == nil || *doc.Retention.Days < 0 {
+			invalid = append(invalid, "retention: days must be a number of days, or 0 to keep events forever")
+		} else {
+			plan.retention = &store.RetentionPolicy{Days: *doc.Retention.Days}
+			plan.sections = append(plan.sections, "retention")
+		}
+	}
+	return plan, invalid, nil
+}
+
+// applyRecords applies the changes of an import to the database sections.
+func (h *PatternsHandler) applyRecords(ctx context.Context, plan *recordImport, username string) error {
+	for _, id := range plan.deleteSubscriptions {
+		if err := h.stateStores.subscriptions.DeleteSubscription(ctx, id); err != nil && err != store.ErrNotFound {
+			return fmt.Errorf("failed to delete subscription %d: %w", id, err)
+		}
+	}
+	for _, sub := range plan.createSubscriptions {
+		sub.CreatedBy = username
+		if err := h.stateStores.subscriptions.CreateSubscription(ctx, sub); err != nil {
+			return fmt.Errorf("failed to create subscription %q: %w", sub.Name, err)
+		}
+	}
+	for _, hold := range plan.createHolds {
+		hold.CreatedBy = username
+		if err := h.stateStores.holds.CreateLegalHold(ctx, hold); err != nil {
+			return fmt.Errorf("failed to create legal hold %q: %w", hold.Reason, err)
+		}
+	}
+	if plan.retention != nil {
+		plan.retention.UpdatedBy = username
+		if err := h.stateStores.retention.SetRetentionPolicy(ctx, plan.retention); err != nil {
+			return fmt.Errorf("failed to set retention: %w", err)
+		}
+	}
+	return nil
+}
+
+// sameJSON reports whether a and b encode to the same JSON, so that filters read from the
+// database compare equal to the ones decoded from a document whatever their empty lists.
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
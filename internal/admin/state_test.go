@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+func newStateTestHandler(t *testing.T, data map[string]string) (*PatternsHandler, *fake.Clientset) {
+	t.Helper()
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-patterns", Namespace: "default"},
+		Data:       data,
+	})
+	return NewPatternsHandler(clientset, "default", "test-patterns"), clientset
+}
+
+func configMapData(t *testing.T, clientset *fake.Clientset) map[string]string {
+	t.Helper()
+	cm, err := clientset.CoreV1().ConfigMaps("default").Get(context.Background(), "test-patterns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	return cm.Data
+}
+
+func TestHandleExport(t *testing.T) {
+	handler, _ := newStateTestHandler(t, map[string]string{
+		"IGNORE_CONFIG": `{"namespace_patterns":["kube-*"]}`,
+		"BLOCK_CONFIG":  `{"operation_patterns":["DELETE"],"message":"no"}`,
+		"WARN_CONFIG":   `{"rules":[]}`,
+		"ALERT_CONFIG":  `{"telegram":{"bot_token":"bot-secret","chat_ids":["123"]}}`,
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleExport(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/export", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "bot-secret") {
+		t.Errorf("Export should not contain the bot token: %s", w.Body.String())
+	}
+
+	var doc StateDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if doc.Version != StateVersion || doc.ExportedAt.IsZero() {
+		t.Errorf("Unexpected version %d exported at %v", doc.Version, doc.ExportedAt)
+	}
+	if len(doc.IgnoreConfig.NamespacePatterns) != 1 || doc.BlockConfig.Message != "no" {
+		t.Errorf("Unexpected patterns: %+v %+v", doc.IgnoreConfig, doc.BlockConfig)
+	}
+	if doc.AlertConfig.Telegram == nil || doc.AlertConfig.Telegram.BotToken != alerting.RedactedValue {
+		t.Errorf("Unexpected alert config: %+v", doc.AlertConfig.Telegram)
+	}
+}
+
+func TestHandleImport_RoundTrip(t *testing.T) {
+	// Export from staging...
+	staging, _ := newStateTestHandler(t, map[string]string{
+		"IGNORE_CONFIG": `{"namespace_patterns":["kube-*"]}`,
+		"ALERT_CONFIG":  `{"telegram":{"bot_token":"staging-secret","chat_ids":["123"]},"operations":["DELETE"]}`,
+	})
+	w := httptest.NewRecorder()
+	staging.HandleExport(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/export", nil))
+
+	// ...and import into production, which keeps its own bot token
+	prod, clientset := newStateTestHandler(t, map[string]string{
+		"ALERT_CONFIG": `{"telegram":{"bot_token":"prod-secret","chat_ids":["999"]}}`,
+	})
+	w2 := httptest.NewRecorder()
+	prod.HandleImport(w2, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import", bytes.NewReader(w.Body.Bytes())))
+
+	if w2.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+	var response ImportResponse
+	json.Unmarshal(w2.Body.Bytes(), &response)
+	if len(response.Applied) != 4 || response.DryRun {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	data := configMapData(t, clientset)
+	alertConfig, secrets := storedAlertConfig(t, clientset)
+	if alertConfig.Telegram.BotToken != alerting.RedactedValue || alertConfig.Telegram.ChatIDs[0] != "123" || len(alertConfig.Operations) != 1 {
+		t.Errorf("Unexpected imported alert config: %+v %+v", alertConfig, alertConfig.Telegram)
+	}
+	if secrets.Telegram.BotToken != "prod-secret" {
+		t.Errorf("Stored bot token = %q, want the production token", secrets.Telegram.BotToken)
+	}
+	if !strings.Contains(data["IGNORE_CONFIG"], "kube-*") {
+		t.Errorf("Ignore config not imported: %s", data["IGNORE_CONFIG"])
+	}
+}
+
+func TestHandleImport_OmittedSectionsUnchanged(t *testing.T) {
+	handler, clientset := newStateTestHandler(t, map[string]string{
+		"IGNORE_CONFIG": `{"namespace_patterns":["kube-*"]}`,
+	})
+
+	body := `{"version": 1, "block_config": {"operation_patterns": ["DELETE"]}}`
+	w := httptest.NewRecorder()
+	handler.HandleImport(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := configMapData(t, clientset)
+	if data["IGNORE_CONFIG"] != `{"namespace_patterns":["kube-*"]}` {
+		t.Errorf("Ignore config changed: %s", data["IGNORE_CONFIG"])
+	}
+	if !strings.Contains(data["BLOCK_CONFIG"], "Resource blocked by kubechronicle policy") {
+		t.Errorf("Block config should get the default message: %s", data["BLOCK_CONFIG"])
+	}
+}
+
+func TestHandleImport_DryRun(t *testing.T) {
+	handler, clientset := newStateTestHandler(t, map[string]string{})
+
+	body := `{"version": 1, "ignore_config": {"namespace_patterns": ["kube-*"]}}`
+	w := httptest.NewRecorder()
+	handler.HandleImport(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import?dry_run=true", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response ImportResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if !response.DryRun || len(response.Applied) != 1 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	if _, ok := configMapData(t, clientset)["IGNORE_CONFIG"]; ok {
+		t.Error("Dry run should not change the ConfigMap")
+	}
+}
+
+func TestHandleImport_Rejected(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unsupported version", `{"version": 2}`, "Unsupported document version 2"},
+		{"unknown section", `{"version": 1, "silences": []}`, "unknown field"},
+		{"invalid pattern", `{"version": 1, "block_config": {"operation_patterns": ["DESTROY"]}}`, "unknown operation"},
+		{"redacted secret without a current value", `{"version": 1, "alert_config": {"slack": {"webhook_url": "xxxxx"}}}`, "slack.webhook_url"},
+		{"database sections without a database", `{"version": 1, "legal_holds": [{"reason": "Case 1"}]}`, "without a database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, clientset := newStateTestHandler(t, map[string]string{"BLOCK_CONFIG": `{}`})
+			w := httptest.NewRecorder()
+			handler.HandleImport(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import", bytes.NewBufferString(tt.body)))
+
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("Expected 400 containing %q, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if data := configMapData(t, clientset); data["BLOCK_CONFIG"] != `{}` || len(data) != 1 {
+				t.Errorf("Rejected import changed the ConfigMap: %v", data)
+			}
+		})
+	}
+}
+
+func TestHandleImport_Records(t *testing.T) {
+	// Export from staging...
+	staging, _ := newStateTestHandler(t, map[string]string{})
+	stagingSubs := &fakeSubscriptionStore{}
+	stagingSubs.CreateSubscription(context.Background(), &store.Subscription{Name: "incidents", URL: "https://staging.example.com/hook", Secret: "staging-secret"})
+	stagingHolds := &fakeHoldStore{}
+	stagingHolds.CreateLegalHold(context.Background(), &store.LegalHold{Reason: "Case 2024-17", Filters: store.QueryFilters{Namespace: "payments"}})
+	days := 30
+	staging.SetStateStores(stagingSubs, stagingHolds, &fakeRetentionStore{policy: &store.RetentionPolicy{Days: days}})
+
+	w := httptest.NewRecorder()
+	staging.HandleExport(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/export", nil))
+	if strings.Contains(w.Body.String(), "staging-secret") {
+		t.Fatalf("Export should not contain subscription secrets: %s", w.Body.String())
+	}
+
+	// ...and import into production, which keeps its own secret and holds
+	prod, _ := newStateTestHandler(t, map[string]string{})
+	prodSubs := &fakeSubscriptionStore{}
+	prodSubs.CreateSubscription(context.Background(), &store.Subscription{Name: "incidents", URL: "https://prod.example.com/hook", Secret: "prod-secret"})
+	prodSubs.CreateSubscription(context.Background(), &store.Subscription{Name: "legacy", URL: "https://legacy.example.com/hook", Secret: "legacy-secret"})
+	prodHolds := &fakeHoldStore{}
+	prodHolds.CreateLegalHold(context.Background(), &store.LegalHold{Reason: "Case 2023-02"})
+	prodRetention := &fakeRetentionStore{}
+	prod.SetStateStores(prodSubs, prodHolds, prodRetention)
+
+	w2 := httptest.NewRecorder()
+	prod.HandleImport(w2, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import", bytes.NewReader(w.Body.Bytes())))
+	if w2.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+	var response ImportResponse
+	json.Unmarshal(w2.Body.Bytes(), &response)
+	if !strings.Contains(strings.Join(response.Applied, ","), "subscriptions,legal_holds,retention") {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	if len(prodSubs.subs) != 1 || prodSubs.subs[0].URL != "https://staging.example.com/hook" || prodSubs.subs[0].Secret != "prod-secret" {
+		t.Errorf("Expected the staging subscription with the production secret, got %+v", prodSubs.subs)
+	}
+	// Holds are only added
+	if len(prodHolds.holds) != 2 || prodHolds.holds[1].Reason != "Case 2024-17" || prodHolds.holds[1].Filters.Namespace != "payments" {
+		t.Errorf("Expected the staging hold to be added, got %+v", prodHolds.holds)
+	}
+	if prodRetention.policy == nil || prodRetention.policy.Days != 30 {
+		t.Errorf("Expected the retention to be imported, got %+v", prodRetention.policy)
+	}
+
+	// Importing again changes nothing
+	imported := *prodSubs.subs[0]
+	prod.HandleImport(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/import", bytes.NewReader(w.Body.Bytes())))
+	if len(prodSubs.subs) != 1 || prodSubs.subs[0].CreatedAt != imported.CreatedAt || len(prodHolds.holds) != 2 {
+		t.Errorf("Re-importing should keep unchanged subscriptions and holds, got %+v %+v", prodSubs.subs, prodHolds.holds)
+	}
+}
@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// SubscriptionsHandler handles admin endpoints for managing event subscriptions of
+// external automation.
+type SubscriptionsHandler struct {
+	store store.SubscriptionStore
+}
+
+// NewSubscriptionsHandler creates a new subscriptions handler.
+func NewSubscriptionsHandler(store store.SubscriptionStore) *SubscriptionsHandler {
+	return &SubscriptionsHandler{
+		store: store,
+	}
+}
+
+// CreateSubscriptionRequest represents a request to subscribe a callback URL to events.
+type CreateSubscriptionRequest struct {
+	Name   string                   `json:"name"`
+	URL    string                   `json:"url"`
+	Secret string                   `json:"secret,omitempty"` // Generated if empty
+	Format string                   `json:"format,omitempty"` // "json" (default) or "cloudevents"
+	Filter store.SubscriptionFilter `json:"filter"`
+}
+
+// HandleSubscriptions handles GET and POST /api/admin/subscriptions.
+func (h *SubscriptionsHandler) HandleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleOptions(w, r)
+	case http.MethodGet:
+		h.handleListSubscriptions(w, r)
+	case http.MethodPost:
+		h.handleCreateSubscription(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleSubscription handles DELETE /api/admin/subscriptions/{id}, which stops deliveries.
+func (h *SubscriptionsHandler) HandleSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		h.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/admin/subscriptions/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid subscription ID: %q", idStr), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.DeleteSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			http.Error(w, fmt.Sprintf("Subscription %d not found", id), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Failed to delete subscription %d: %v", id, err)
+		http.Error(w, fmt.Sprintf("Failed to delete subscription: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Subscription %d deleted by %s", id, requestUsername(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListSubscriptions lists subscriptions with their secrets redacted.
+func (h *SubscriptionsHandler) handleListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.store.ListSubscriptions(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to list subscriptions: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to list subscriptions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, sub := range subs {
+		sub.Secret = alerting.RedactedValue
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// handleCreateSubscription registers a new subscription. The response is the only time
+// the secret is returned.
+func (h *SubscriptionsHandler) handleCreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := validateSubscription(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid subscription: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSubscriptionSecret(); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to create subscription: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sub := &store.Subscription{
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Format:    req.Format,
+		Filter:    req.Filter,
+		CreatedBy: requestUsername(r),
+	}
+	if err := h.store.CreateSubscription(r.Context(), sub); err != nil {
+		klog.Errorf("Failed to create subscription: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create subscription: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Subscription %d (%s) to %s created by %s", sub.ID, sub.Name, sub.URL, sub.CreatedBy)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// validateSubscription checks the name, callback URL and format of a subscription.
+func validateSubscription(req *CreateSubscriptionRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("a name is required")
+	}
+	if err := validateCallbackURL(req.URL); err != nil {
+		return fmt.Errorf("invalid callback URL: %w", err)
+	}
+	if !model.ValidFormat(req.Format) {
+		return fmt.Errorf("unknown format %q, must be json or cloudevents", req.Format)
+	}
+	return nil
+}
+
+// newSubscriptionSecret generates the signing secret of a subscription created without one.
+func newSubscriptionSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// validateCallbackURL checks that a callback URL is an absolute http(s) URL.
+func validateCallbackURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("missing host")
+	}
+	return nil
+}
+
+// handleOptions handles CORS preflight requests.
+func (h *SubscriptionsHandler) handleOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// fakeSubscriptionStore is an in-memory store.SubscriptionStore for handler tests.
+type fakeSubscriptionStore struct {
+	subs []*store.Subscription
+}
+
+func (f *fakeSubscriptionStore) CreateSubscription(ctx context.Context, sub *store.Subscription) error {
+	sub.ID = int64(len(f.subs) + 1)
+	sub.CreatedAt = time.Now()
+	stored := *sub
+	f.subs = append(f.subs, &stored)
+	return nil
+}
+
+func (f *fakeSubscriptionStore) ListSubscriptions(ctx context.Context) ([]*store.Subscription, error) {
+	subs := []*store.Subscription{}
+	for _, sub := range f.subs {
+		copied := *sub
+		subs = append(subs, &copied)
+	}
+	return subs, nil
+}
+
+func (f *fakeSubscriptionStore) DeleteSubscription(ctx context.Context, id int64) error {
+	for i, sub := range f.subs {
+		if sub.ID == id {
+			f.subs = append(f.subs[:i], f.subs[i+1:]...)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (f *fakeSubscriptionStore) RecordSubscriptionDelivery(ctx context.Context, id int64, deliveryErr error) error {
+	return nil
+}
+
+func TestSubscriptionsHandler_CreateListDelete(t *testing.T) {
+	fake := &fakeSubscriptionStore{}
+	handler := NewSubscriptionsHandler(fake)
+
+	body, _ := json.Marshal(CreateSubscriptionRequest{
+		Name:   "incidents",
+		URL:    "https://automation.example.com/hooks/kubechronicle",
+		Filter: store.SubscriptionFilter{Namespaces: []string{"prod-*"}, Operations: []string{"DELETE"}},
+	})
+	w := httptest.NewRecorder()
+	handler.HandleSubscriptions(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/subscriptions", bytes.NewReader(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created store.Subscription
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if len(created.Secret) != 64 || created.CreatedBy != "anonymous" {
+		t.Errorf("Expected a generated secret and creator, got %+v", created)
+	}
+	if fake.subs[0].Secret != created.Secret {
+		t.Error("The returned secret should be the stored one")
+	}
+
+	// Listing never returns the secret
+	w = httptest.NewRecorder()
+	handler.HandleSubscriptions(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/admin/subscriptions", nil))
+	if strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("List should not contain the secret: %s", w.Body.String())
+	}
+	var subs []store.Subscription
+	json.Unmarshal(w.Body.Bytes(), &subs)
+	if len(subs) != 1 || subs[0].Secret != alerting.RedactedValue || subs[0].Filter.Namespaces[0] != "prod-*" {
+		t.Errorf("Unexpected subscriptions: %+v", subs)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleSubscription(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/subscriptions/1", nil))
+	if w.Code != http.StatusNoContent || len(fake.subs) != 0 {
+		t.Errorf("Expected the subscription to be deleted, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleSubscription(w, httptest.NewRequest(http.MethodDelete, "/kubechronicle/api/admin/subscriptions/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestSubscriptionsHandler_CreateKeepsGivenSecret(t *testing.T) {
+	fake := &fakeSubscriptionStore{}
+	handler := NewSubscriptionsHandler(fake)
+
+	body := `{"name": "canary", "url": "http://canary.example.com/restart", "secret": "shared"}`
+	w := httptest.NewRecorder()
+	handler.HandleSubscriptions(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/subscriptions", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusCreated || fake.subs[0].Secret != "shared" {
+		t.Errorf("Expected the given secret to be stored, got %d: %+v", w.Code, fake.subs)
+	}
+}
+
+func TestSubscriptionsHandler_CreateValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing name", `{"url": "https://example.com/hook"}`},
+		{"missing URL", `{"name": "x"}`},
+		{"relative URL", `{"name": "x", "url": "/hook"}`},
+		{"unsupported scheme", `{"name": "x", "url": "ftp://example.com/hook"}`},
+		{"unknown format", `{"name": "x", "url": "https://example.com/hook", "format": "xml"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSubscriptionStore{}
+			w := httptest.NewRecorder()
+			NewSubscriptionsHandler(fake).HandleSubscriptions(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/subscriptions", bytes.NewBufferString(tt.body)))
+			if w.Code != http.StatusBadRequest || len(fake.subs) != 0 {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/admission"
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Defaults for the recent events a pattern dry-run is evaluated against.
+const (
+	defaultValidateWindow = 24 * time.Hour
+	maxValidateEvents     = 10000
+	validatePageSize      = 1000 // Page size requested from QueryEvents, which may return smaller pages
+)
+
+// ValidateHandler handles the admin endpoint for linting and dry-running pattern updates.
+type ValidateHandler struct {
+	store store.Store
+}
+
+// NewValidateHandler creates a new pattern validation handler.
+// If store is nil, patterns are linted but not evaluated against recent events.
+func NewValidateHandler(store store.Store) *ValidateHandler {
+	return &ValidateHandler{
+		store: store,
+	}
+}
+
+// ValidateRequest contains the pattern configs to validate. Either may be omitted.
+type ValidateRequest struct {
+	IgnoreConfig *config.IgnoreConfig `json:"ignore_config,omitempty"`
+	BlockConfig  *config.BlockConfig  `json:"block_config,omitempty"`
+}
+
+// ValidateResponse reports the issues found and how many recent events each pattern matches.
+type ValidateResponse struct {
+	Valid         bool                       `json:"valid"` // No error-severity issues
+	Issues        []admission.PatternIssue   `json:"issues"`
+	Matches       []admission.PatternMatches `json:"matches,omitempty"`
+	Since         *time.Time                 `json:"since,omitempty"`
+	EventsChecked int                        `json:"events_checked"`
+	Truncated     bool                       `json:"truncated,omitempty"` // More events than maxValidateEvents in the window
+}
+
+// HandleValidate handles POST /api/admin/patterns/validate.
+// The window query parameter (e.g. "1h", default "24h") selects the recent events to dry-run against.
+func (h *ValidateHandler) HandleValidate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.WriteHeader(http.StatusOK)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	window := defaultValidateWindow
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid window: %q", value), http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	response := ValidateResponse{
+		Valid:  true,
+		Issues: admission.LintPatterns(req.IgnoreConfig, req.BlockConfig),
+	}
+	for _, issue := range response.Issues {
+		if issue.Severity == admission.SeverityError {
+			response.Valid = false
+		}
+	}
+
+	if h.store != nil {
+		since := time.Now().Add(-window).UTC()
+		events, total, err := h.recentEvents(r.Context(), since)
+		if err != nil {
+			klog.Errorf("Failed to query recent events: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to query recent events: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		response.Matches = admission.CountMatches(req.IgnoreConfig, req.BlockConfig, events)
+		response.Since = &since
+		response.EventsChecked = len(events)
+		response.Truncated = total > len(events)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// recentEvents returns up to maxValidateEvents of the newest events since the given time,
+// without their heavy fields, along with the total number of events in the window.
+func (h *ValidateHandler) recentEvents(ctx context.Context, since time.Time) ([]*model.ChangeEvent, int, error) {
+	filters := store.QueryFilters{
+		StartTime: &since,
+		Fields:    []string{"id"}, // Patterns only need metadata
+	}
+
+	var events []*model.ChangeEvent
+	var asOf *time.Time // Pages after the first are read as of the first, so they don't shift
+	total := 0
+	for len(events) < maxValidateEvents {
+		result, err := h.store.QueryEvents(ctx, filters, store.PaginationParams{
+			Limit:  validatePageSize,
+			Offset: len(events),
+			AsOf:   asOf,
+		}, store.SortOrderDesc)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, result.Events...)
+		total = result.Total
+		if asOf == nil && !result.AsOf.IsZero() {
+			asOf = &result.AsOf
+		}
+		if len(result.Events) == 0 || len(events) >= result.Total {
+			break
+		}
+	}
+	return events, total, nil
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeEventStore is a store.Store serving QueryEvents from a fixed list of events.
+type fakeEventStore struct {
+	store.Store
+	events  []*model.ChangeEvent
+	filters store.QueryFilters
+}
+
+func (f *fakeEventStore) QueryEvents(ctx context.Context, filters store.QueryFilters, pagination store.PaginationParams, sortOrder store.SortOrder) (*store.QueryResult, error) {
+	f.filters = filters
+	start := pagination.Offset
+	if start > len(f.events) {
+		start = len(f.events)
+	}
+	end := start + pagination.Limit
+	if end > len(f.events) {
+		end = len(f.events)
+	}
+	return &store.QueryResult{Events: f.events[start:end], Total: len(f.events)}, nil
+}
+
+func TestValidateHandler_Validate(t *testing.T) {
+	fakeStore := &fakeEventStore{events: []*model.ChangeEvent{
+		{Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "kube-system", Name: "coredns"},
+		{Operation: "DELETE", ResourceKind: "Deployment", Namespace: "production", Name: "api"},
+	}}
+	handler := NewValidateHandler(fakeStore)
+
+	body := `{"ignore_config": {"namespace_patterns": ["kube-*"]}, "block_config": {"namespace_patterns": ["*"]}}`
+	w := httptest.NewRecorder()
+	handler.HandleValidate(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/patterns/validate?window=1h", bytes.NewBufferString(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response ValidateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Valid {
+		t.Error("Blocking every namespace should make the config invalid")
+	}
+	if response.EventsChecked != 2 || response.Since == nil {
+		t.Errorf("EventsChecked = %d, Since = %v, want 2 events and a window start", response.EventsChecked, response.Since)
+	}
+	if len(response.Matches) != 2 || response.Matches[0].Events != 1 || response.Matches[1].Events != 2 {
+		t.Errorf("Unexpected matches: %+v", response.Matches)
+	}
+	if len(fakeStore.filters.Fields) != 1 || fakeStore.filters.StartTime == nil {
+		t.Errorf("Recent events should be queried without heavy fields within the window, got %+v", fakeStore.filters)
+	}
+}
+
+func TestValidateHandler_PagesThroughEvents(t *testing.T) {
+	events := make([]*model.ChangeEvent, maxValidateEvents+5)
+	for i := range events {
+		events[i] = &model.ChangeEvent{Operation: "UPDATE", Namespace: fmt.Sprintf("ns-%d", i)}
+	}
+	handler := NewValidateHandler(&fakeEventStore{events: events})
+
+	w := httptest.NewRecorder()
+	handler.HandleValidate(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/patterns/validate", bytes.NewBufferString(`{"ignore_config": {"namespace_patterns": ["ns-*"]}}`)))
+
+	var response ValidateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.EventsChecked != maxValidateEvents || !response.Truncated {
+		t.Errorf("EventsChecked = %d, Truncated = %v, want %d and true", response.EventsChecked, response.Truncated, maxValidateEvents)
+	}
+	if response.Matches[0].Events != maxValidateEvents {
+		t.Errorf("Matches = %d, want %d", response.Matches[0].Events, maxValidateEvents)
+	}
+}
+
+func TestValidateHandler_WithoutStore(t *testing.T) {
+	handler := NewValidateHandler(nil)
+
+	w := httptest.NewRecorder()
+	handler.HandleValidate(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/admin/patterns/validate", bytes.NewBufferString(`{"ignore_config": {"name_patterns": ["test-*"]}}`)))
+
+	var response ValidateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !response.Valid || response.Matches != nil {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestValidateHandler_BadRequest(t *testing.T) {
+	handler := NewValidateHandler(nil)
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		status int
+	}{
+		{"invalid JSON", http.MethodPost, "/kubechronicle/api/admin/patterns/validate", "{", http.StatusBadRequest},
+		{"invalid window", http.MethodPost, "/kubechronicle/api/admin/patterns/validate?window=yesterday", "{}", http.StatusBadRequest},
+		{"wrong method", http.MethodGet, "/kubechronicle/api/admin/patterns/validate", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.HandleValidate(w, httptest.NewRequest(tt.method, tt.url, bytes.NewBufferString(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/diff"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// captureRefreshInterval is how often the capture session is reloaded from the store, so a
+// session started or stopped through the admin API applies to every replica.
+const captureRefreshInterval = 30 * time.Second
+
+// CaptureRetention is how long the captures of ended sessions are kept.
+const CaptureRetention = 7 * 24 * time.Hour
+
+// captureTimeout bounds storing a capture.
+const captureTimeout = 5 * time.Second
+
+// lastAppliedAnnotation holds a copy of the object applied with kubectl, Secret data included.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Capture counters: admission requests captured, and captures dropped because the capture
+// queue was full.
+var (
+	capturedRequests = expvar.NewInt("webhook_captured_requests_total")
+	droppedCaptures  = expvar.NewInt("webhook_dropped_captures_total")
+)
+
+// pendingCapture is a sampled admission request waiting to be redacted and stored.
+type pendingCapture struct {
+	sessionID   int64
+	request     *admissionv1.AdmissionRequest
+	decodeError string
+}
+
+// requestCapture samples admission requests for the running capture session.
+type requestCapture struct {
+	store         store.CaptureStore
+	queue         chan pendingCapture
+	pseudonymizer *pseudonym.Pseudonymizer // Pseudonymizes the requester; nil keeps them
+
+	mu         sync.RWMutex
+	session    *store.CaptureSession // Running session; nil captures nothing
+	namespaces patternSet            // Compiled session namespaces
+}
+
+// SetCaptureStore enables debug capture sessions: while a session started through the admin
+// API runs, Start stores the sampled percentage of the admission requests received, with
+// Secret values hashed and credentials left out. Must be called before Start.
+func (h *Handler) SetCaptureStore(captures store.CaptureStore) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.capture = &requestCapture{store: captures, queue: make(chan pendingCapture, 100)}
+}
+
+// runCapture follows the capture session and stores the captured requests until ctx is done.
+// The captures of sessions ended for longer than CaptureRetention are deleted.
+func (h *Handler) runCapture(ctx context.Context) {
+	capture := h.capture
+	capture.pseudonymizer = h.pseudonymizer
+	go capture.write(ctx)
+
+	capture.refresh(ctx, time.Now())
+	ticker := time.NewTicker(captureRefreshInterval)
+	defer ticker.Stop()
+	lastPurge := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			capture.refresh(ctx, now)
+			if now.Sub(lastPurge) >= time.Hour {
+				lastPurge = now
+				if deleted, err := capture.store.DeleteCaptures(ctx, now.Add(-CaptureRetention)); err != nil {
+					klog.Warningf("Failed to delete old admission captures: %v", err)
+				} else if deleted > 0 {
+					klog.Infof("Deleted %d admission captures older than %s", deleted, CaptureRetention)
+				}
+			}
+		}
+	}
+}
+
+// refresh reloads the capture session, keeping it only while it runs.
+func (c *requestCapture) refresh(ctx context.Context, now time.Time) {
+	session, err := c.store.GetCaptureSession(ctx)
+	if err != nil {
+		klog.Warningf("Failed to load the capture session: %v", err)
+		return
+	}
+	if session != nil && !session.Active(now) {
+		session = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if session != nil && (c.session == nil || c.session.ID != session.ID) {
+		klog.Infof("Capture session %d started by %s: %.1f%% of admission requests until %s",
+			session.ID, session.StartedBy, session.Percent, session.ExpiresAt.Format(time.RFC3339))
+	} else if session == nil && c.session != nil {
+		klog.Infof("Capture session %d ended", c.session.ID)
+	}
+	c.session = session
+	if session != nil {
+		c.namespaces = compilePatterns(session.Namespaces)
+	}
+}
+
+// sample returns the running session if the request of namespace is sampled, or nil.
+func (c *requestCapture) sample(namespace string, now time.Time) *store.CaptureSession {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	session := c.session
+	if session == nil || !now.Before(session.ExpiresAt) {
+		return nil
+	}
+	if len(c.namespaces) > 0 {
+		if _, ok := c.namespaces.match(namespace); !ok {
+			return nil
+		}
+	}
+	if rand.Float64()*100 >= session.Percent {
+		return nil
+	}
+	return session
+}
+
+// captureRequest queues a sampled admission request for storage. It doesn't block; the
+// request is redacted and stored by the capture writer.
+func (h *Handler) captureRequest(req *admissionv1.AdmissionRequest, decodeErr error) {
+	if req == nil {
+		return
+	}
+	session := h.capture.sample(req.Namespace, time.Now())
+	if session == nil {
+		return
+	}
+	pending := pendingCapture{sessionID: session.ID, request: req}
+	if decodeErr != nil {
+		pending.decodeError = decodeErr.Error()
+	}
+	select {
+	case h.capture.queue <- pending:
+	default:
+		droppedCaptures.Add(1)
+	}
+}
+
+// write stores the queued captures until ctx is done.
+func (c *requestCapture) write(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pending := <-c.queue:
+			c.save(ctx, pending)
+		}
+	}
+}
+
+// save redacts and stores a capture.
+func (c *requestCapture) save(ctx context.Context, pending pendingCapture) {
+	req := pending.request
+	username, err := c.requester(req)
+	if err != nil {
+		klog.Warningf("Failed to pseudonymize the requester of admission request %s, not capturing it: %v", req.UID, err)
+		return
+	}
+	redacted, err := redactRequest(req, username)
+	if err != nil {
+		klog.Warningf("Failed to redact admission request %s for capture: %v", req.UID, err)
+		return
+	}
+	capture := &store.AdmissionCapture{
+		SessionID:   pending.sessionID,
+		UID:         string(req.UID),
+		Operation:   string(req.Operation),
+		Kind:        req.Kind.Kind,
+		Namespace:   req.Namespace,
+		Name:        req.Name,
+		Username:    username,
+		DecodeError: pending.decodeError,
+		Request:     redacted,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, captureTimeout)
+	defer cancel()
+	saved, err := c.store.SaveCapture(ctx, capture)
+	switch {
+	case err != nil:
+		klog.Warningf("Failed to store the capture of admission request %s: %v", req.UID, err)
+	case saved:
+		capturedRequests.Add(1)
+	default:
+		// The session ended, or reached its maximum captures, since the last refresh
+		c.mu.Lock()
+		if c.session != nil && c.session.ID == pending.sessionID {
+			c.session = nil
+		}
+		c.mu.Unlock()
+	}
+}
+
+// requester returns the username of a request, pseudonymized like the actors of events.
+func (c *requestCapture) requester(req *admissionv1.AdmissionRequest) (string, error) {
+	if c.pseudonymizer == nil {
+		return req.UserInfo.Username, nil
+	}
+	event := &model.ChangeEvent{Actor: model.Actor{Username: req.UserInfo.Username}}
+	if err := c.pseudonymizer.Apply(event); err != nil {
+		return "", err
+	}
+	return event.Actor.Username, nil
+}
+
+// redactRequest returns an admission request as JSON with the values of Secrets hashed, the
+// last-applied-configuration annotation and managed fields of its objects removed, and the
+// extra user info, which may hold credential IDs, left out. The username is replaced with
+// username; if they differ, the requester is pseudonymized and their UID is left out too.
+func redactRequest(req *admissionv1.AdmissionRequest, username string) (json.RawMessage, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var request map[string]interface{}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+
+	if userInfo, ok := request["userInfo"].(map[string]interface{}); ok {
+		delete(userInfo, "extra")
+		if username != req.UserInfo.Username {
+			userInfo["username"] = username
+			delete(userInfo, "uid")
+		}
+	}
+	for _, field := range []string{"object", "oldObject"} {
+		obj, ok := request[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "managedFields")
+			if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+				delete(annotations, lastAppliedAnnotation)
+			}
+		}
+		if req.Kind.Kind == "Secret" {
+			request[field] = diff.HashSecretValues(obj)
+		}
+	}
+	return json.Marshal(request)
+}
@@ -0,0 +1,215 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeCaptureStore is a store.CaptureStore of a fixed session, keeping the captures saved.
+type fakeCaptureStore struct {
+	session  *store.CaptureSession
+	captures []*store.AdmissionCapture
+}
+
+func (f *fakeCaptureStore) StartCaptureSession(ctx context.Context, session *store.CaptureSession) error {
+	f.session = session
+	return nil
+}
+
+func (f *fakeCaptureStore) StopCaptureSession(ctx context.Context, stoppedBy string) error {
+	return nil
+}
+
+func (f *fakeCaptureStore) GetCaptureSession(ctx context.Context) (*store.CaptureSession, error) {
+	return f.session, nil
+}
+
+func (f *fakeCaptureStore) SaveCapture(ctx context.Context, capture *store.AdmissionCapture) (bool, error) {
+	if !f.session.Active(time.Now()) || capture.SessionID != f.session.ID {
+		return false, nil
+	}
+	f.captures = append(f.captures, capture)
+	f.session.Captures++
+	return true, nil
+}
+
+func (f *fakeCaptureStore) ListCaptures(ctx context.Context, sessionID int64, limit int) ([]*store.AdmissionCapture, error) {
+	return f.captures, nil
+}
+
+func (f *fakeCaptureStore) DeleteCaptures(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRequestCapture_Sample(t *testing.T) {
+	now := time.Now()
+	captures := &fakeCaptureStore{}
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetCaptureStore(captures)
+	capture := handler.capture
+
+	// No session captures nothing
+	capture.refresh(context.Background(), now)
+	if capture.sample("payments", now) != nil {
+		t.Error("Expected no capture without a session")
+	}
+
+	captures.session = &store.CaptureSession{ID: 1, Percent: 100, Namespaces: []string{"payments-*"}, MaxCaptures: 10, ExpiresAt: now.Add(time.Hour)}
+	capture.refresh(context.Background(), now)
+	if capture.sample("payments-eu", now) == nil {
+		t.Error("Expected a request of a captured namespace to be sampled at 100%")
+	}
+	if capture.sample("default", now) != nil {
+		t.Error("Expected a request of another namespace not to be sampled")
+	}
+	if capture.sample("payments-eu", now.Add(2*time.Hour)) != nil {
+		t.Error("Expected no capture after the session expired")
+	}
+
+	captures.session = &store.CaptureSession{ID: 2, Percent: 0.0001, MaxCaptures: 10, ExpiresAt: now.Add(time.Hour)}
+	capture.refresh(context.Background(), now)
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if capture.sample("default", now) != nil {
+			sampled++
+		}
+	}
+	if sampled > 5 {
+		t.Errorf("Expected about no request sampled at 0.0001%%, got %d of 1000", sampled)
+	}
+
+	// A stopped session is dropped on refresh
+	stoppedAt := now
+	captures.session.StoppedAt = &stoppedAt
+	capture.refresh(context.Background(), now)
+	if capture.session != nil {
+		t.Errorf("Expected the stopped session to be dropped, got %+v", capture.session)
+	}
+}
+
+func TestRequestCapture_Save(t *testing.T) {
+	now := time.Now()
+	captures := &fakeCaptureStore{session: &store.CaptureSession{ID: 1, Percent: 100, MaxCaptures: 1, ExpiresAt: now.Add(time.Hour)}}
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetCaptureStore(captures)
+	handler.capture.refresh(context.Background(), now)
+
+	req := &admissionv1.AdmissionRequest{
+		UID:       "uid-1",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Namespace: "default",
+		Name:      "settings",
+		Operation: admissionv1.Create,
+	}
+	handler.captureRequest(req, nil)
+	handler.captureRequest(req, nil)
+	if len(handler.capture.queue) != 2 {
+		t.Fatalf("Expected 2 queued captures, got %d", len(handler.capture.queue))
+	}
+	handler.capture.save(context.Background(), <-handler.capture.queue)
+	handler.capture.save(context.Background(), <-handler.capture.queue)
+
+	if len(captures.captures) != 1 {
+		t.Fatalf("Expected 1 capture stored, got %d", len(captures.captures))
+	}
+	if c := captures.captures[0]; c.UID != "uid-1" || c.Kind != "ConfigMap" || c.Operation != "CREATE" || c.Name != "settings" {
+		t.Errorf("Unexpected capture: %+v", c)
+	}
+	// The session reached its maximum captures
+	if handler.capture.sample("default", now) != nil {
+		t.Error("Expected no capture after the session reached its maximum")
+	}
+}
+
+func TestRequestCapture_Pseudonymize(t *testing.T) {
+	pseudonymizer, err := pseudonym.New("MDEyMzQ1Njc4OWFiY2RlZg==", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mappings := &fakeMappings{}
+	pseudonymizer.SetMappingStore(mappings)
+	captures := &fakeCaptureStore{session: &store.CaptureSession{ID: 1, Percent: 100, MaxCaptures: 10, ExpiresAt: time.Now().Add(time.Hour)}}
+	capture := &requestCapture{store: captures, pseudonymizer: pseudonymizer}
+
+	req := &admissionv1.AdmissionRequest{
+		UID:      "uid-1",
+		Kind:     metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		UserInfo: authenticationv1.UserInfo{Username: "alice@example.com", UID: "4f1c"},
+	}
+	capture.save(context.Background(), pendingCapture{sessionID: 1, request: req})
+	if len(captures.captures) != 1 {
+		t.Fatalf("Expected 1 capture stored, got %d", len(captures.captures))
+	}
+	c := captures.captures[0]
+	if want := pseudonymizer.Pseudonym("alice@example.com"); c.Username != want {
+		t.Errorf("Username = %q, want %q", c.Username, want)
+	}
+	for _, leaked := range []string{"alice@example.com", "4f1c"} {
+		if strings.Contains(string(c.Request), leaked) {
+			t.Errorf("Expected %q to be left out of %s", leaked, c.Request)
+		}
+	}
+
+	// Requests whose requester can't be pseudonymized aren't captured
+	mappings.err = store.ErrUnavailable
+	req.UserInfo.Username = "bob@example.com"
+	capture.save(context.Background(), pendingCapture{sessionID: 1, request: req})
+	if len(captures.captures) != 1 {
+		t.Errorf("Expected the request not to be captured, got %d captures", len(captures.captures))
+	}
+}
+
+func TestRedactRequest(t *testing.T) {
+	secret := `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"db","namespace":"default",` +
+		`"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"data\":{\"password\":\"aHVudGVyMg==\"}}","team":"payments"},` +
+		`"managedFields":[{"manager":"kubectl"}]},"data":{"password":"aHVudGVyMg=="}}`
+	req := &admissionv1.AdmissionRequest{
+		UID:       "uid-1",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		Namespace: "default",
+		Name:      "db",
+		Operation: admissionv1.Update,
+		UserInfo: authenticationv1.UserInfo{
+			Username: "alice",
+			Extra:    map[string]authenticationv1.ExtraValue{"authentication.kubernetes.io/credential-id": {"X509SHA256=abc"}},
+		},
+		Object:    runtime.RawExtension{Raw: []byte(secret)},
+		OldObject: runtime.RawExtension{Raw: []byte(secret)},
+	}
+
+	redacted, err := redactRequest(req, "alice")
+	if err != nil {
+		t.Fatalf("redactRequest() error = %v", err)
+	}
+	for _, leaked := range []string{"aHVudGVyMg==", "last-applied-configuration", "managedFields", "credential-id"} {
+		if strings.Contains(string(redacted), leaked) {
+			t.Errorf("Expected %q to be redacted from %s", leaked, redacted)
+		}
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(redacted, &request); err != nil {
+		t.Fatalf("Failed to parse the redacted request: %v", err)
+	}
+	if request["uid"] != "uid-1" || request["userInfo"].(map[string]interface{})["username"] != "alice" {
+		t.Errorf("Expected the request fields to be kept, got %s", redacted)
+	}
+	annotations := request["object"].(map[string]interface{})["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if annotations["team"] != "payments" {
+		t.Errorf("Expected the other annotations to be kept, got %v", annotations)
+	}
+	if _, ok := request["object"].(map[string]interface{})["data"].(map[string]interface{})["password"]; !ok {
+		t.Errorf("Expected the Secret keys to be kept, got %s", redacted)
+	}
+}
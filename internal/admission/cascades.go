@@ -0,0 +1,171 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// maxCascadeDuration is how long the objects deleted in a deleted namespace are counted at
+// most, for namespaces whose finalization never completes.
+const maxCascadeDuration = time.Hour
+
+// notableKinds are the kinds of the objects a namespace cascade names, besides counting them:
+// those holding credentials or data.
+var notableKinds = map[string]bool{"Secret": true, "PersistentVolumeClaim": true}
+
+// namespaceCascades counts the NAMESPACE_CASCADE events queued.
+var namespaceCascades = expvar.NewInt("webhook_namespace_cascades_total")
+
+// namespaceCascade counts the objects deleted in a deleted namespace.
+type namespaceCascade struct {
+	deletion   *model.ChangeEvent // DELETE of the namespace
+	summary    *model.NamespaceCascade
+	startedAt  time.Time // When the namespace's DELETE was observed
+	observedAt time.Time // When the last DELETE of the cascade was observed
+}
+
+// cascadeTracker follows the deletion cascades of the namespaces deleted.
+type cascadeTracker struct {
+	window time.Duration
+
+	mu         sync.Mutex
+	namespaces map[string]*namespaceCascade
+}
+
+// SetNamespaceCascade makes the handler summarize the objects deleted in each namespace
+// deleted, once no object of it was deleted for window, in a NAMESPACE_CASCADE event counting
+// them per kind and naming the Secrets and PersistentVolumeClaims. The objects' DELETEs are
+// recorded as usual. A non-positive window disables the summaries. Must be called before
+// Start.
+func (h *Handler) SetNamespaceCascade(window time.Duration) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	if window <= 0 {
+		h.cascades = nil
+		return
+	}
+	h.cascades = &cascadeTracker{window: window, namespaces: map[string]*namespaceCascade{}}
+}
+
+// observe starts the cascade of a deleted namespace, and counts the objects deleted in the
+// namespaces whose cascade is open.
+func (t *cascadeTracker) observe(event *model.ChangeEvent, now time.Time) {
+	if t == nil || event.Operation != "DELETE" || !event.Allowed {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if event.ResourceKind == "Namespace" {
+		if cascade := t.namespaces[event.Name]; cascade != nil {
+			// Deleted again, e.g. once its finalizers are done
+			cascade.observedAt = now
+			return
+		}
+		t.namespaces[event.Name] = &namespaceCascade{
+			deletion: event,
+			summary: &model.NamespaceCascade{
+				DeletionID: event.ID,
+				Kinds:      map[string]int{},
+				DeletedAt:  event.Timestamp,
+			},
+			startedAt:  now,
+			observedAt: now,
+		}
+		return
+	}
+
+	cascade := t.namespaces[event.Namespace]
+	if cascade == nil {
+		return
+	}
+	summary := cascade.summary
+	summary.Total++
+	summary.Kinds[event.ResourceKind]++
+	if notableKinds[event.ResourceKind] {
+		if len(summary.Notable[event.ResourceKind]) < model.MaxNamespaceCascadeNotable {
+			if summary.Notable == nil {
+				summary.Notable = map[string][]string{}
+			}
+			summary.Notable[event.ResourceKind] = append(summary.Notable[event.ResourceKind], event.Name)
+		} else {
+			summary.Truncated = true
+		}
+	}
+	if event.Timestamp.After(summary.LastDeletedAt) {
+		summary.LastDeletedAt = event.Timestamp
+	}
+	cascade.observedAt = now
+}
+
+// flush closes the cascades without deletions for the window before now, or open for longer
+// than maxCascadeDuration, or all cascades if all is set, and returns their NAMESPACE_CASCADE
+// events.
+func (t *cascadeTracker) flush(now time.Time, all bool) []*model.ChangeEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var events []*model.ChangeEvent
+	for namespace, cascade := range t.namespaces {
+		if !all && now.Sub(cascade.observedAt) < t.window && now.Sub(cascade.startedAt) < maxCascadeDuration {
+			continue
+		}
+		delete(t.namespaces, namespace)
+		events = append(events, cascade.event())
+	}
+	return events
+}
+
+// event returns the NAMESPACE_CASCADE event of the cascade, recorded at the time the
+// namespace was deleted.
+func (c *namespaceCascade) event() *model.ChangeEvent {
+	deletion := c.deletion
+	event := &model.ChangeEvent{
+		Timestamp:        deletion.Timestamp,
+		Operation:        model.OperationNamespaceCascade,
+		ResourceKind:     "Namespace",
+		Namespace:        deletion.Name,
+		Name:             deletion.Name,
+		Actor:            deletion.Actor,
+		Source:           deletion.Source,
+		Allowed:          true,
+		NamespaceCascade: c.summary,
+	}
+	event.ID = model.NewEventID(event.Timestamp)
+	return event
+}
+
+// runNamespaceCascades queues the NAMESPACE_CASCADE events of the cascades as they end,
+// until ctx is done. Cascades still open then are summarized as they are.
+func (h *Handler) runNamespaceCascades(ctx context.Context) {
+	ticker := time.NewTicker(h.cascades.window / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			for _, event := range h.cascades.flush(time.Now(), true) {
+				h.queueNamespaceCascade(event)
+			}
+			return
+		case now := <-ticker.C:
+			for _, event := range h.cascades.flush(now, false) {
+				h.queueNamespaceCascade(event)
+			}
+		}
+	}
+}
+
+// queueNamespaceCascade queues a NAMESPACE_CASCADE event, which is processed, saved and
+// alerted like the changes admitted.
+func (h *Handler) queueNamespaceCascade(event *model.ChangeEvent) {
+	klog.Infof("Namespace %s deleted by %s with %d objects, summarized in %s",
+		event.Name, event.Actor.Username, event.NamespaceCascade.Total, event.ID)
+	if h.queueGenerated(event) {
+		namespaceCascades.Add(1)
+	}
+}
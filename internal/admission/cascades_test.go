@@ -0,0 +1,100 @@
+package admission
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestCascadeTracker(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetNamespaceCascade(time.Minute)
+	tracker := handler.cascades
+
+	deleteEvent := func(kind, namespace, name string) *model.ChangeEvent {
+		return &model.ChangeEvent{
+			ID:           kind + "/" + name,
+			Timestamp:    now,
+			Operation:    "DELETE",
+			ResourceKind: kind,
+			Namespace:    namespace,
+			Name:         name,
+			Actor:        model.Actor{Username: "alice"},
+			Allowed:      true,
+		}
+	}
+
+	// Deleted before the namespace, so not part of its cascade
+	tracker.observe(deleteEvent("Pod", "shop", "early"), now)
+	tracker.observe(deleteEvent("Namespace", "", "shop"), now)
+	for i := 0; i < 3; i++ {
+		tracker.observe(deleteEvent("Pod", "shop", fmt.Sprintf("pod-%d", i)), now.Add(time.Second))
+	}
+	for i := 0; i < model.MaxNamespaceCascadeNotable+1; i++ {
+		tracker.observe(deleteEvent("Secret", "shop", fmt.Sprintf("secret-%d", i)), now.Add(2*time.Second))
+	}
+	tracker.observe(deleteEvent("PersistentVolumeClaim", "shop", "data"), now.Add(3*time.Second))
+	tracker.observe(deleteEvent("Pod", "dev", "other"), now.Add(3*time.Second))
+	blocked := deleteEvent("Pod", "shop", "blocked")
+	blocked.Allowed = false
+	tracker.observe(blocked, now.Add(3*time.Second))
+
+	if events := tracker.flush(now.Add(30*time.Second), false); len(events) != 0 {
+		t.Fatalf("Expected the cascade still open, got %v", events)
+	}
+	events := tracker.flush(now.Add(2*time.Minute), false)
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 namespace cascade, got %d", len(events))
+	}
+	event := events[0]
+	if event.Operation != model.OperationNamespaceCascade || event.ResourceKind != "Namespace" || event.Namespace != "shop" || event.Name != "shop" || event.Actor.Username != "alice" {
+		t.Errorf("Unexpected namespace cascade: %+v", event)
+	}
+	cascade := event.NamespaceCascade
+	if cascade.DeletionID != "Namespace/shop" || cascade.Total != 3+model.MaxNamespaceCascadeNotable+1+1 {
+		t.Errorf("Unexpected namespace cascade summary: %+v", cascade)
+	}
+	if cascade.Kinds["Pod"] != 3 || cascade.Kinds["Secret"] != model.MaxNamespaceCascadeNotable+1 || cascade.Kinds["PersistentVolumeClaim"] != 1 {
+		t.Errorf("Unexpected counts per kind: %v", cascade.Kinds)
+	}
+	if len(cascade.Notable["Secret"]) != model.MaxNamespaceCascadeNotable || !cascade.Truncated || len(cascade.Notable["PersistentVolumeClaim"]) != 1 {
+		t.Errorf("Unexpected notable objects: %d Secrets, truncated %v, PVCs %v",
+			len(cascade.Notable["Secret"]), cascade.Truncated, cascade.Notable["PersistentVolumeClaim"])
+	}
+	if _, ok := cascade.Notable["Pod"]; ok {
+		t.Error("Expected the Pods not to be named")
+	}
+	if len(tracker.namespaces) != 0 {
+		t.Errorf("Expected every cascade closed, got %d", len(tracker.namespaces))
+	}
+}
+
+func TestCascadeTracker_MaxDuration(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetNamespaceCascade(time.Minute)
+	tracker := handler.cascades
+
+	tracker.observe(&model.ChangeEvent{Operation: "DELETE", ResourceKind: "Namespace", Name: "stuck", Allowed: true}, now)
+	for elapsed := 30 * time.Second; elapsed < maxCascadeDuration; elapsed += 30 * time.Second {
+		tracker.observe(&model.ChangeEvent{Operation: "DELETE", ResourceKind: "Pod", Namespace: "stuck", Allowed: true}, now.Add(elapsed))
+		if events := tracker.flush(now.Add(elapsed), false); len(events) != 0 {
+			t.Fatalf("Expected the cascade open after %s", elapsed)
+		}
+	}
+	if events := tracker.flush(now.Add(maxCascadeDuration), false); len(events) != 1 {
+		t.Fatalf("Expected the cascade closed after %s, got %d events", maxCascadeDuration, len(events))
+	}
+}
+
+func TestCascadeTracker_Disabled(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetNamespaceCascade(0)
+	if handler.cascades != nil {
+		t.Fatal("Expected namespace cascades disabled")
+	}
+	handler.cascades.observe(&model.ChangeEvent{Operation: "DELETE", ResourceKind: "Namespace", Name: "shop", Allowed: true}, time.Now())
+}
@@ -0,0 +1,30 @@
+package admission
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// certExpiry is the expiry of the served TLS certificate, in seconds since the epoch.
+var certExpiry = expvar.NewInt("webhook_tls_cert_expiry_timestamp_seconds")
+
+// PublishCertExpiry publishes the expiry of the TLS certificate the server is started with,
+// so an expiring certificate can be alerted on before the API server fails to call the webhook.
+func PublishCertExpiry(certPath, keyPath string) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		klog.Warningf("Failed to load TLS certificate to publish its expiry: %v", err)
+		return
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		klog.Warningf("Failed to parse TLS certificate to publish its expiry: %v", err)
+		return
+	}
+	certExpiry.Set(cert.NotAfter.Unix())
+	klog.Infof("TLS certificate expires at %s", cert.NotAfter.Format(time.RFC3339))
+}
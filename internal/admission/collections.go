@@ -0,0 +1,189 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// deleteCollections counts the DELETE_COLLECTION events queued.
+var deleteCollections = expvar.NewInt("webhook_delete_collections_total")
+
+// deleteGroup is a burst of DELETEs of one kind in one namespace by the same actor.
+type deleteGroup struct {
+	first     *model.ChangeEvent   // The DELETE_COLLECTION event is modeled on the first DELETE
+	held      []*model.ChangeEvent // DELETEs whose alerts wait until the group is known not to be a collection
+	children  []string
+	count     int
+	truncated bool
+	lastAt    time.Time // Timestamp of the last DELETE
+	addedAt   time.Time // When the last DELETE was added
+}
+
+// deleteCollector groups bursts of DELETEs, such as those of a delete collection request or
+// a namespace teardown, which the API server sends the webhook one object at a time.
+type deleteCollector struct {
+	minDeletes int
+	window     time.Duration
+
+	mu     sync.Mutex
+	groups map[string]*deleteGroup // By actor, kind and namespace
+}
+
+// SetDeleteCollection makes the handler group the DELETEs of one kind in one namespace by
+// the same actor, each within window of the previous one: once there are minDeletes of them,
+// their alerts are replaced by the alert of a DELETE_COLLECTION event referencing them,
+// recorded when the burst ends. The DELETEs are recorded as usual, and alerted, with a delay
+// of window, if there are fewer. minDeletes below 2 disables grouping. Must be called before
+// Start.
+func (h *Handler) SetDeleteCollection(minDeletes int, window time.Duration) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	if minDeletes < 2 || window <= 0 {
+		h.deletes = nil
+		return
+	}
+	h.deletes = &deleteCollector{minDeletes: minDeletes, window: window, groups: map[string]*deleteGroup{}}
+}
+
+// add adds an allowed DELETE to its group. It reports whether the event's alert is held
+// back or replaced by that of the group; the alerts of other events are sent right away.
+func (c *deleteCollector) add(event *model.ChangeEvent, now time.Time) bool {
+	if c == nil || event.Operation != "DELETE" || !event.Allowed {
+		return false
+	}
+	key := event.Actor.Username + "\x00" + event.ResourceKind + "\x00" + event.Namespace
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	group := c.groups[key]
+	if group == nil {
+		group = &deleteGroup{first: event}
+		c.groups[key] = group
+	}
+	group.count++
+	if len(group.children) < model.MaxDeleteCollectionChildren {
+		group.children = append(group.children, event.ID)
+	} else {
+		group.truncated = true
+	}
+	if group.count < c.minDeletes {
+		group.held = append(group.held, event)
+	} else {
+		group.held = nil // Alerted as a collection
+	}
+	if event.Timestamp.After(group.lastAt) {
+		group.lastAt = event.Timestamp
+	}
+	group.addedAt = now
+	return true
+}
+
+// flush closes the groups that received no DELETE for the window before now, or all groups
+// if all is set. It returns the DELETE_COLLECTION events of the groups large enough, and the
+// held DELETEs of the others, to alert.
+func (c *deleteCollector) flush(now time.Time, all bool) (collections, alerts []*model.ChangeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, group := range c.groups {
+		if !all && now.Sub(group.addedAt) < c.window {
+			continue
+		}
+		delete(c.groups, key)
+		if group.count < c.minDeletes {
+			alerts = append(alerts, group.held...)
+			continue
+		}
+		collections = append(collections, group.collection())
+	}
+	return collections, alerts
+}
+
+// collection returns the DELETE_COLLECTION event of the group.
+func (g *deleteGroup) collection() *model.ChangeEvent {
+	first := g.first
+	event := &model.ChangeEvent{
+		Timestamp:    first.Timestamp,
+		Operation:    model.OperationDeleteCollection,
+		ResourceKind: first.ResourceKind,
+		Namespace:    first.Namespace,
+		Name:         "*",
+		Actor:        first.Actor,
+		Source:       first.Source,
+		Allowed:      true,
+		DeleteCollection: &model.DeleteCollection{
+			Count:          g.count,
+			Children:       g.children,
+			Truncated:      g.truncated,
+			FirstDeletedAt: first.Timestamp,
+			LastDeletedAt:  g.lastAt,
+		},
+	}
+	event.ID = model.NewEventID(event.Timestamp)
+	return event
+}
+
+// runDeleteCollections closes the groups of DELETEs as their bursts end, until ctx is done:
+// the DELETE_COLLECTION events are queued to be recorded and alerted, and the held alerts of
+// smaller groups are sent. The groups still open when ctx is done are closed the same way.
+func (h *Handler) runDeleteCollections(ctx context.Context) {
+	ticker := time.NewTicker(h.deletes.window / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeDeleteCollections(time.Now(), true)
+			return
+		case now := <-ticker.C:
+			h.closeDeleteCollections(now, false)
+		}
+	}
+}
+
+// closeDeleteCollections flushes the delete collector, queueing the DELETE_COLLECTION events
+// and sending the held alerts.
+func (h *Handler) closeDeleteCollections(now time.Time, all bool) {
+	collections, alerts := h.deletes.flush(now, all)
+	h.sendHeldAlerts(alerts)
+	for _, event := range collections {
+		h.queueDeleteCollection(event)
+	}
+}
+
+// sendHeldAlerts sends the alerts held back by the delete collector.
+func (h *Handler) sendHeldAlerts(events []*model.ChangeEvent) {
+	alertRouter := h.getAlertRouter()
+	for _, event := range events {
+		alertRouter.Send(event)
+	}
+}
+
+// queueDeleteCollection queues a DELETE_COLLECTION event, which is processed, saved and
+// alerted like the changes admitted.
+func (h *Handler) queueDeleteCollection(event *model.ChangeEvent) {
+	klog.Infof("Grouped %d DELETEs of %s in namespace %q by %s into delete collection %s",
+		event.DeleteCollection.Count, event.ResourceKind, event.Namespace, event.Actor.Username, event.ID)
+	if h.queueGenerated(event) {
+		deleteCollections.Add(1)
+	}
+}
+
+// queueGenerated queues an event generated by the webhook from the changes admitted, such as
+// a DELETE_COLLECTION event. It reports whether the event was queued; it is dropped, like a
+// change would be, when the queue is full.
+func (h *Handler) queueGenerated(event *model.ChangeEvent) bool {
+	select {
+	case h.queue <- &queuedEvent{event: event}:
+		queueLength.Set(int64(len(h.queue)))
+		return true
+	default:
+		droppedEvents.Add(1)
+		klog.Warningf("Event queue full, dropping %s event: %s", event.Operation, event.ID)
+		return false
+	}
+}
@@ -0,0 +1,123 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestDeleteCollector(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetDeleteCollection(3, 5*time.Second)
+	collector := handler.deletes
+
+	deleteEvent := func(i int, username, namespace string) *model.ChangeEvent {
+		return &model.ChangeEvent{
+			ID:           fmt.Sprintf("%s-%d", namespace, i),
+			Timestamp:    now.Add(time.Duration(i) * time.Second),
+			Operation:    "DELETE",
+			ResourceKind: "Pod",
+			Namespace:    namespace,
+			Name:         fmt.Sprintf("pod-%d", i),
+			Actor:        model.Actor{Username: username},
+			Allowed:      true,
+		}
+	}
+
+	// A teardown of 4 pods, and 2 pods deleted by hand in another namespace
+	for i := 0; i < 4; i++ {
+		if !collector.add(deleteEvent(i, "system:serviceaccount:kube-system:namespace-controller", "shop"), now.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("Expected DELETE %d to be held", i)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		collector.add(deleteEvent(i, "alice", "dev"), now.Add(time.Duration(i)*time.Second))
+	}
+	if collector.add(&model.ChangeEvent{Operation: "CREATE", Allowed: true}, now) {
+		t.Error("Expected a CREATE not to be held")
+	}
+	if collector.add(&model.ChangeEvent{Operation: "DELETE", Allowed: false}, now) {
+		t.Error("Expected a blocked DELETE not to be held")
+	}
+
+	// The bursts haven't ended yet
+	if collections, alerts := collector.flush(now.Add(4*time.Second), false); len(collections) != 0 || len(alerts) != 0 {
+		t.Fatalf("Expected nothing flushed during the bursts, got %v and %v", collections, alerts)
+	}
+
+	collections, alerts := collector.flush(now.Add(10*time.Second), false)
+	if len(collections) != 1 {
+		t.Fatalf("Expected 1 delete collection, got %d", len(collections))
+	}
+	collection := collections[0]
+	if collection.Operation != model.OperationDeleteCollection || collection.ResourceKind != "Pod" || collection.Namespace != "shop" || collection.Name != "*" {
+		t.Errorf("Unexpected delete collection: %+v", collection)
+	}
+	dc := collection.DeleteCollection
+	if dc.Count != 4 || len(dc.Children) != 4 || dc.Children[0] != "shop-0" || !dc.FirstDeletedAt.Equal(now) || !dc.LastDeletedAt.Equal(now.Add(3*time.Second)) {
+		t.Errorf("Unexpected delete collection references: %+v", dc)
+	}
+	if len(alerts) != 2 || alerts[0].Namespace != "dev" {
+		t.Errorf("Expected the 2 held DELETEs of dev to be alerted, got %v", alerts)
+	}
+	if len(collector.groups) != 0 {
+		t.Errorf("Expected every group closed, got %d", len(collector.groups))
+	}
+}
+
+func TestDeleteCollector_Disabled(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetDeleteCollection(0, 5*time.Second)
+	if handler.deletes != nil {
+		t.Fatal("Expected grouping disabled")
+	}
+	if handler.deletes.add(&model.ChangeEvent{Operation: "DELETE", Allowed: true}, time.Now()) {
+		t.Error("Expected no DELETE held with grouping disabled")
+	}
+}
+
+func TestHandler_QueueDeleteCollection(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetDeleteCollection(2, time.Second)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		handler.deletes.add(&model.ChangeEvent{ID: fmt.Sprint(i), Operation: "DELETE", ResourceKind: "ConfigMap", Allowed: true, Timestamp: now}, now)
+	}
+	collections, _ := handler.deletes.flush(now.Add(time.Second), false)
+	handler.queueDeleteCollection(collections[0])
+
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected the delete collection queued, got %d events", len(handler.queue))
+	}
+	if item := <-handler.queue; item.event.DeleteCollection.Count != 2 {
+		t.Errorf("Unexpected queued event: %+v", item.event)
+	}
+}
+
+func TestHandler_DeleteCollectionsOnShutdown(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetDeleteCollection(2, time.Hour)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		handler.deletes.add(&model.ChangeEvent{ID: fmt.Sprint(i), Operation: "DELETE", ResourceKind: "Secret", Allowed: true, Timestamp: now}, now)
+	}
+
+	// The burst is still open when the webhook shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler.runDeleteCollections(ctx)
+
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected the open delete collection queued on shutdown, got %d events", len(handler.queue))
+	}
+	if item := <-handler.queue; item.event.Operation != model.OperationDeleteCollection || item.event.DeleteCollection.Count != 3 {
+		t.Errorf("Unexpected queued event: %+v", item.event)
+	}
+	if len(handler.deletes.groups) != 0 {
+		t.Errorf("Expected every group closed, got %d", len(handler.deletes.groups))
+	}
+}
@@ -0,0 +1,175 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/chaos"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// storeAttempts is the number of times an event is saved before it is dead-lettered.
+const storeAttempts = 3
+
+// requeueBatchSize is the maximum number of requeued dead letters retried per interval.
+const requeueBatchSize = 100
+
+var (
+	storeRetryBackoff = 100 * time.Millisecond // Initial save retry delay, doubled after each attempt
+	requeueInterval   = 30 * time.Second       // How often requeued dead letters are retried
+)
+
+// deadLettersAdded counts events dead-lettered after their save or an alert failed on every attempt.
+var deadLettersAdded = expvar.NewInt("webhook_dead_letters_total")
+
+// SetDeadLetterStore keeps events whose save or alert delivery failed after all retries
+// in deadLetters, and makes Start retry the dead letters requeued through the API.
+// Must be called before Start.
+func (h *Handler) SetDeadLetterStore(deadLetters store.DeadLetterStore) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.deadLetters = deadLetters
+}
+
+// SetExportPipeline exports the dead letters of failed exports requeued through the API
+// again with pipeline. Must be called before Start.
+func (h *Handler) SetExportPipeline(pipeline *export.Pipeline) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.exports = pipeline
+}
+
+// configureAlertRouter applies the message catalog, the injected faults and dead-lettering
+// of failed alerts to a newly created alert router.
+func (h *Handler) configureAlertRouter(router *alerting.Router) {
+	router.SetMessageCatalog(h.messages)
+	router.WrapSenders(func(s alerting.Sender) alerting.Sender { return chaos.WrapSender(s, h.chaosConfig) })
+	router.SetFailureHandler(h.alertFailed)
+}
+
+// saveWithRetry saves an event, retrying with exponential backoff. Saves short-circuited
+// because the store is unavailable are not retried.
+func (h *Handler) saveWithRetry(event *model.ChangeEvent) error {
+	backoff := storeRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := h.store.Save(event)
+		if err == nil || errors.Is(err, store.ErrDuplicate) {
+			return err
+		}
+		// Retrying is pointless while the store short-circuits saves
+		if attempt >= storeAttempts || errors.Is(err, store.ErrUnavailable) {
+			storeErrors.Add(1)
+			return err
+		}
+		klog.Warningf("Failed to save change event %s (attempt %d/%d): %v", event.ID, attempt, storeAttempts, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// alertFailed dead-letters an alert that failed on every attempt.
+func (h *Handler) alertFailed(event *model.ChangeEvent, channel string, err error, attempts int) {
+	h.addDeadLetter(&store.DeadLetter{Kind: store.DeadLetterKindAlert, Channel: channel, Event: event, Error: err.Error(), Attempts: attempts})
+}
+
+// addDeadLetter persists a dead letter, if a dead letter store is set.
+func (h *Handler) addDeadLetter(dl *store.DeadLetter) {
+	h.configMutex.RLock()
+	deadLetters := h.deadLetters
+	h.configMutex.RUnlock()
+	if deadLetters == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := deadLetters.AddDeadLetter(ctx, dl); err != nil {
+		klog.Errorf("Failed to dead-letter %s failure of event %s, dropping it: %v", dl.Kind, dl.Event.ID, err)
+		return
+	}
+	deadLettersAdded.Add(1)
+	klog.Warningf("Dead-lettered %s failure of event %s as entry %d", dl.Kind, dl.Event.ID, dl.ID)
+}
+
+// retryRequeuedDeadLetters periodically retries the dead letters requeued through the API.
+func (h *Handler) retryRequeuedDeadLetters(ctx context.Context) {
+	ticker := time.NewTicker(requeueInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			claimed, err := h.deadLetters.ClaimRequeuedDeadLetters(claimCtx, requeueBatchSize)
+			cancel()
+			if err != nil {
+				klog.Errorf("Failed to claim requeued dead letters: %v", err)
+				continue
+			}
+			for _, dl := range claimed {
+				h.retryDeadLetter(dl)
+			}
+		}
+	}
+}
+
+// retryDeadLetter retries the save, alert delivery or export of a claimed dead letter. A dead
+// letter that fails again is dead-lettered anew with its attempts added up.
+func (h *Handler) retryDeadLetter(dl *store.DeadLetter) {
+	switch dl.Kind {
+	case store.DeadLetterKindStore:
+		if h.store == nil {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Event: dl.Event, Error: "no store configured", Attempts: dl.Attempts})
+			return
+		}
+		if err := h.saveWithRetry(dl.Event); err != nil && !errors.Is(err, store.ErrDuplicate) {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Event: dl.Event, Error: err.Error(), Attempts: dl.Attempts + storeAttempts})
+			return
+		}
+	case store.DeadLetterKindAlert:
+		router := h.getAlertRouter()
+		if !containsString(router.Channels(), dl.Channel) {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Channel: dl.Channel, Event: dl.Event,
+				Error: fmt.Sprintf("alert channel %s is not configured", dl.Channel), Attempts: dl.Attempts})
+			return
+		}
+		if err := router.Deliver(dl.Event, []string{dl.Channel}); err != nil {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Channel: dl.Channel, Event: dl.Event, Error: err.Error(), Attempts: dl.Attempts + 1})
+			return
+		}
+	case store.DeadLetterKindExport:
+		if h.exports == nil {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Channel: dl.Channel, Event: dl.Event, Error: "export is not configured", Attempts: dl.Attempts})
+			return
+		}
+		// Failing again dead-letters the event from the pipeline
+		if !h.exports.Requeue(dl.Event) {
+			h.addDeadLetter(&store.DeadLetter{Kind: dl.Kind, Channel: dl.Channel, Event: dl.Event, Error: "export queue full", Attempts: dl.Attempts})
+			return
+		}
+	default:
+		klog.Errorf("Dropping dead letter %d of unknown kind %q", dl.ID, dl.Kind)
+		return
+	}
+	klog.Infof("Requeued dead letter %d (%s) of event %s succeeded", dl.ID, dl.Kind, dl.Event.ID)
+}
+
+// containsString reports whether value is one of values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeDeadLetterStore records dead letters and serves requeued ones. It only
+// implements the methods used by the webhook.
+type fakeDeadLetterStore struct {
+	store.DeadLetterStore
+	mu       sync.Mutex
+	added    []*store.DeadLetter
+	requeued []*store.DeadLetter
+}
+
+func (f *fakeDeadLetterStore) AddDeadLetter(ctx context.Context, dl *store.DeadLetter) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dl.ID = int64(len(f.added) + 1)
+	f.added = append(f.added, dl)
+	return nil
+}
+
+func (f *fakeDeadLetterStore) ClaimRequeuedDeadLetters(ctx context.Context, limit int) ([]*store.DeadLetter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	claimed := f.requeued
+	f.requeued = nil
+	return claimed, nil
+}
+
+func (f *fakeDeadLetterStore) addedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.added)
+}
+
+func TestHandler_SaveFailureIsDeadLettered(t *testing.T) {
+	defer func(backoff time.Duration) { storeRetryBackoff = backoff }(storeRetryBackoff)
+	storeRetryBackoff = time.Millisecond
+
+	handler := NewHandler(&mockStore{saveError: errors.New("connection reset")}, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	handler.SetDeadLetterStore(deadLetters)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.Start(ctx)
+	handler.queue <- &queuedEvent{event: &model.ChangeEvent{ID: "e1"}}
+
+	if !waitFor(t, func() bool { return deadLetters.addedCount() == 1 }) {
+		t.Fatal("Failed save should be dead-lettered")
+	}
+	dl := deadLetters.added[0]
+	if dl.Kind != store.DeadLetterKindStore || dl.Event.ID != "e1" || dl.Attempts != storeAttempts || dl.Error != "connection reset" {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+}
+
+// countingStore counts saves, failing them with err.
+type countingStore struct {
+	mockStore
+	saves int
+	err   error
+}
+
+func (c *countingStore) Save(event *model.ChangeEvent) error {
+	c.saves++
+	return c.err
+}
+
+func TestHandler_DuplicatesAreNotRetried(t *testing.T) {
+	eventStore := &countingStore{err: store.ErrDuplicate}
+	handler := NewHandler(eventStore, nil, nil, nil)
+
+	before := storeErrors.Value()
+	if err := handler.saveWithRetry(&model.ChangeEvent{ID: "e1"}); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("saveWithRetry() error = %v, want ErrDuplicate", err)
+	}
+	if eventStore.saves != 1 || storeErrors.Value() != before {
+		t.Errorf("Expected a single save and no store error, got %d saves and %d errors", eventStore.saves, storeErrors.Value()-before)
+	}
+}
+
+func TestHandler_AlertFailureIsDeadLettered(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	handler.SetDeadLetterStore(deadLetters)
+
+	handler.alertFailed(&model.ChangeEvent{ID: "e1"}, "slack", errors.New("status 500"), 3)
+
+	if len(deadLetters.added) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(deadLetters.added))
+	}
+	if dl := deadLetters.added[0]; dl.Kind != store.DeadLetterKindAlert || dl.Channel != "slack" || dl.Attempts != 3 {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+}
+
+func TestHandler_RetryDeadLetter(t *testing.T) {
+	defer func(backoff time.Duration) { storeRetryBackoff = backoff }(storeRetryBackoff)
+	storeRetryBackoff = time.Millisecond
+
+	tests := []struct {
+		name             string
+		store            *mockStore
+		deadLetter       *store.DeadLetter
+		expectedSaved    int
+		expectedAdded    int
+		expectedAttempts int
+	}{
+		{
+			name:          "store dead letter saved",
+			store:         &mockStore{},
+			deadLetter:    &store.DeadLetter{ID: 7, Kind: store.DeadLetterKindStore, Event: &model.ChangeEvent{ID: "e1"}, Attempts: 3},
+			expectedSaved: 1,
+		},
+		{
+			name:             "store dead letter failing again",
+			store:            &mockStore{saveError: errors.New("still down")},
+			deadLetter:       &store.DeadLetter{ID: 7, Kind: store.DeadLetterKindStore, Event: &model.ChangeEvent{ID: "e1"}, Attempts: 3},
+			expectedAdded:    1,
+			expectedAttempts: 3 + storeAttempts,
+		},
+		{
+			name:             "alert channel no longer configured",
+			store:            &mockStore{},
+			deadLetter:       &store.DeadLetter{ID: 8, Kind: store.DeadLetterKindAlert, Channel: "slack", Event: &model.ChangeEvent{ID: "e2"}, Attempts: 3},
+			expectedAdded:    1,
+			expectedAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(tt.store, nil, nil, nil)
+			deadLetters := &fakeDeadLetterStore{}
+			handler.SetDeadLetterStore(deadLetters)
+
+			handler.retryDeadLetter(tt.deadLetter)
+
+			if len(tt.store.savedEvents) != tt.expectedSaved {
+				t.Errorf("saved %d events, want %d", len(tt.store.savedEvents), tt.expectedSaved)
+			}
+			if len(deadLetters.added) != tt.expectedAdded {
+				t.Fatalf("dead-lettered %d times, want %d", len(deadLetters.added), tt.expectedAdded)
+			}
+			if tt.expectedAdded > 0 && deadLetters.added[0].Attempts != tt.expectedAttempts {
+				t.Errorf("attempts = %d, want %d", deadLetters.added[0].Attempts, tt.expectedAttempts)
+			}
+		})
+	}
+}
+
+func TestHandler_RetryRequeuedDeadLetters(t *testing.T) {
+	defer func(interval time.Duration) { requeueInterval = interval }(requeueInterval)
+	requeueInterval = 10 * time.Millisecond
+
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{
+		requeued: []*store.DeadLetter{{ID: 1, Kind: store.DeadLetterKindStore, Event: &model.ChangeEvent{ID: "e1"}}},
+	}
+	handler.SetDeadLetterStore(deadLetters)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.Start(ctx)
+	claimed := waitFor(t, func() bool {
+		deadLetters.mu.Lock()
+		defer deadLetters.mu.Unlock()
+		return deadLetters.requeued == nil
+	})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if !claimed {
+		t.Fatal("Requeued dead letters should be claimed")
+	}
+	if deadLetters.addedCount() != 0 {
+		t.Error("A successfully retried dead letter should not be dead-lettered again")
+	}
+}
@@ -1,26 +1,70 @@
 package admission
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 
+	"github.com/kubechronicle/kubechronicle/internal/config"
 	"github.com/kubechronicle/kubechronicle/internal/diff"
 	"github.com/kubechronicle/kubechronicle/internal/model"
 )
 
 // Decoder extracts information from Kubernetes AdmissionRequest.
-type Decoder struct{}
+type Decoder struct {
+	snapshotUpdates bool            // Store the object before UPDATEs as well as DELETEs
+	snapshotRules   []*snapshotRule // Fields of the snapshots of matching kinds
+
+	volumes corelisters.PersistentVolumeLister // Volumes bound to deleted claims; nil leaves their reclaim policy unknown
+}
 
 // NewDecoder creates a new decoder.
 func NewDecoder() *Decoder {
 	return &Decoder{}
 }
 
+// SetSnapshotUpdates makes DecodePayload store the filtered object before an UPDATE in its
+// snapshot, so the objects before and after the change can be shown side by side.
+func (d *Decoder) SetSnapshotUpdates(enabled bool) {
+	d.snapshotUpdates = enabled
+}
+
+// SetSnapshotConfig selects the fields stored in the snapshots of the resource kinds matched
+// by cfg's rules. Snapshots of other kinds hold the object without noise fields.
+func (d *Decoder) SetSnapshotConfig(cfg *config.SnapshotConfig) {
+	d.snapshotRules = newSnapshotRules(cfg)
+}
+
+// objectMeta holds the parts of an object's metadata needed before the full object is decoded.
+type objectMeta struct {
+	Name            string            `json:"name"`
+	Labels          map[string]string `json:"labels"`
+	ResourceVersion string            `json:"resourceVersion"`
+}
+
 // DecodeRequest extracts all required information from an AdmissionRequest.
+// It is equivalent to DecodeMetadata followed by DecodePayload.
 func (d *Decoder) DecodeRequest(req *admissionv1.AdmissionRequest) (*model.ChangeEvent, error) {
+	event, err := d.DecodeMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.DecodePayload(event, req.OldObject.Raw, req.Object.Raw); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// DecodeMetadata extracts the information needed to evaluate ignore and block
+// patterns without decoding the full objects. Only the objects' metadata is read,
+// so this stays cheap for large objects.
+func (d *Decoder) DecodeMetadata(req *admissionv1.AdmissionRequest) (*model.ChangeEvent, error) {
 	event := &model.ChangeEvent{
 		Operation:    string(req.Operation),
 		ResourceKind: req.Kind.Kind,
@@ -52,65 +96,144 @@ func (d *Decoder) DecodeRequest(req *admissionv1.AdmissionRequest) (*model.Chang
 		}
 	}
 
-	// Decode oldObject (for UPDATE/DELETE)
-	var oldObj map[string]interface{}
-	if req.OldObject.Raw != nil {
-		if err := json.Unmarshal(req.OldObject.Raw, &oldObj); err != nil {
+	// For DELETE operations, extract name from oldObject if req.Name is empty
+	if req.Operation == admissionv1.Delete && event.Name == "" && req.OldObject.Raw != nil {
+		meta, err := decodeObjectMeta(req.OldObject.Raw)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal oldObject: %w", err)
 		}
+		event.Name = meta.Name
+	}
 
-		// For DELETE operations, extract name from oldObject if req.Name is empty
-		if req.Operation == admissionv1.Delete && event.Name == "" {
-			if metadata, ok := oldObj["metadata"].(map[string]interface{}); ok {
-				if name, ok := metadata["name"].(string); ok && name != "" {
-					event.Name = name
-				}
-			}
+	event.Fingerprint = requestFingerprint(req)
+
+	return event, nil
+}
+
+// requestFingerprint identifies an admission request by its UID and the resourceVersion of
+// the object it changes, which stay the same when the API server retries the webhook call,
+// so the store records the change once. It is empty for requests without a UID.
+func requestFingerprint(req *admissionv1.AdmissionRequest) string {
+	if req.UID == "" {
+		return ""
+	}
+	// The object of an UPDATE carries the resourceVersion it replaces; a DELETE has only the old object
+	raw := req.Object.Raw
+	if raw == nil {
+		raw = req.OldObject.Raw
+	}
+	var resourceVersion string
+	if raw != nil {
+		if meta, err := decodeObjectMeta(raw); err == nil {
+			resourceVersion = meta.ResourceVersion
+		}
+	}
+	sum := sha256.Sum256([]byte(string(req.UID) + "\x00" + resourceVersion))
+	return hex.EncodeToString(sum[:])
+}
+
+// DecodePayload decodes the raw old and new objects of an event and fills in its
+// snapshot (DELETE, and UPDATE with SetSnapshotUpdates), diff (UPDATE), volume deletion
+// (DELETE of a PersistentVolume or PersistentVolumeClaim), CRD change (UPDATE of a
+// CustomResourceDefinition), exposure change (Ingress, NetworkPolicy and Service), quota
+// impact (ResourceQuota) and pod security regression (UPDATE of a workload). It is expensive
+// for large objects and runs off the admission path, in the async worker.
+func (d *Decoder) DecodePayload(event *model.ChangeEvent, oldRaw, newRaw []byte) error {
+	// Decode oldObject (for UPDATE/DELETE)
+	var oldObj map[string]interface{}
+	if oldRaw != nil {
+		if err := json.Unmarshal(oldRaw, &oldObj); err != nil {
+			return fmt.Errorf("failed to unmarshal oldObject: %w", err)
 		}
 
-		// For DELETE, store filtered snapshot (remove noise fields)
-		if req.Operation == admissionv1.Delete {
+		// For DELETE, and UPDATE if enabled, store filtered snapshot (remove noise fields)
+		if event.Operation == string(admissionv1.Delete) || (d.snapshotUpdates && event.Operation == string(admissionv1.Update)) {
 			event.ObjectSnapshot = d.filterSnapshot(oldObj, event.ResourceKind)
 		}
+
+		// Flag the deletions of volumes and claims that may lose data
+		if event.Operation == string(admissionv1.Delete) {
+			event.VolumeDeletion = d.volumeDeletion(event.ResourceKind, oldRaw)
+		}
 	}
 
 	// Decode object (for CREATE/UPDATE)
 	var newObj map[string]interface{}
-	if req.Object.Raw != nil {
-		if err := json.Unmarshal(req.Object.Raw, &newObj); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal object: %w", err)
+	if newRaw != nil {
+		if err := json.Unmarshal(newRaw, &newObj); err != nil {
+			return fmt.Errorf("failed to unmarshal object: %w", err)
 		}
 	}
 
+	// Summarize the versions and schemas changed by CRD updates, unreadable as patches
+	if event.ResourceKind == "CustomResourceDefinition" && event.Operation == string(admissionv1.Update) && oldObj != nil && newObj != nil {
+		event.CRDChange = diff.CRDChanges(oldObj, newObj)
+	}
+
+	// Flag the changes opening network exposure as security relevant
+	event.ExposureChange = exposureChange(event.ResourceKind, oldRaw, newRaw)
+
+	// Record the resources over quota once a ResourceQuota is lowered below their usage
+	if event.ResourceKind == "ResourceQuota" {
+		event.QuotaImpact = quotaImpact(oldRaw, newRaw)
+	}
+
+	// Flag the workload updates weakening the security of their pods
+	if event.Operation == string(admissionv1.Update) {
+		event.PodSecurityRegression = podSecurityRegression(event.ResourceKind, oldRaw, newRaw)
+	}
+
 	// Compute diff for UPDATE operations
-	if req.Operation == admissionv1.Update && oldObj != nil && newObj != nil {
+	if event.Operation == string(admissionv1.Update) && oldObj != nil && newObj != nil {
 		patches, err := diff.ComputeDiff(oldObj, newObj, event.ResourceKind)
 		if err != nil {
 			// Error computing diff - continue without diff rather than failing
 			// This ensures we still record the event even if diff computation fails
 			event.Diff = nil
-			// Note: Error logging happens in handler if needed
 		} else {
 			event.Diff = patches
 		}
 	}
 
-	return event, nil
+	return nil
+}
+
+// decodeObjectMeta reads the metadata of a raw object. It stops as soon as the
+// metadata field has been read, skipping the rest of the object (e.g. spec, data).
+func decodeObjectMeta(raw []byte) (*objectMeta, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected object, got %v", tok)
+	}
+
+	meta := &objectMeta{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key == "metadata" {
+			if err := dec.Decode(meta); err != nil {
+				return nil, err
+			}
+			return meta, nil
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return meta, nil
 }
 
 // detectSourceTool attempts to identify the tool that made the change.
 func (d *Decoder) detectSourceTool(req *admissionv1.AdmissionRequest) string {
-	// Check for Helm annotation in object
+	// Check for Helm label in object
 	if req.Object.Raw != nil {
-		var obj map[string]interface{}
-		if err := json.Unmarshal(req.Object.Raw, &obj); err == nil {
-			if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
-				if labels, ok := metadata["labels"].(map[string]interface{}); ok {
-					if managedBy, ok := labels["app.kubernetes.io/managed-by"].(string); ok && managedBy == "Helm" {
-						return "helm"
-					}
-				}
-			}
+		if meta, err := decodeObjectMeta(req.Object.Raw); err == nil && meta.Labels["app.kubernetes.io/managed-by"] == "Helm" {
+			return "helm"
 		}
 	}
 
@@ -160,11 +283,19 @@ func (d *Decoder) GetOldObjectRaw(req *admissionv1.AdmissionRequest) []byte {
 	return req.OldObject.Raw
 }
 
-// filterSnapshot filters out ignored fields from a DELETE snapshot.
-// This reduces storage size by removing Kubernetes noise fields.
+// filterSnapshot filters out ignored fields from a DELETE or UPDATE snapshot.
+// This reduces storage size by removing Kubernetes noise fields, and the fields left out by
+// the snapshot rule of the resource kind, if any.
 func (d *Decoder) filterSnapshot(obj map[string]interface{}, resourceKind string) map[string]interface{} {
-	// Use the same filtering logic as diff computation
-	filtered := diff.FilterIgnoredFields(obj, "").(map[string]interface{})
+	filtered := obj
+	rule := matchSnapshotRule(d.snapshotRules, resourceKind)
+	if rule == nil || !rule.keepNoise {
+		// Use the same filtering logic as diff computation
+		filtered = diff.FilterIgnoredFields(obj, "").(map[string]interface{})
+	}
+	if rule != nil {
+		filtered = rule.apply(filtered)
+	}
 
 	// Hash Secret values if this is a Secret resource
 	if resourceKind == "Secret" {
@@ -2,12 +2,16 @@ package admission
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
 )
 
 func TestNewDecoder(t *testing.T) {
@@ -175,6 +179,58 @@ func TestDecodeRequest_UPDATE(t *testing.T) {
 	}
 }
 
+func TestDecodePayload_SnapshotUpdates(t *testing.T) {
+	oldRaw := []byte(`{"metadata": {"name": "test", "resourceVersion": "1"}, "spec": {"replicas": 1}}`)
+	newRaw := []byte(`{"metadata": {"name": "test", "resourceVersion": "2"}, "spec": {"replicas": 3}}`)
+
+	decoder := NewDecoder()
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment"}
+	if err := decoder.DecodePayload(event, oldRaw, newRaw); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if event.ObjectSnapshot != nil {
+		t.Error("Expected no ObjectSnapshot for UPDATE by default")
+	}
+
+	decoder.SetSnapshotUpdates(true)
+	event = &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment"}
+	if err := decoder.DecodePayload(event, oldRaw, newRaw); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "test"},
+		"spec":     map[string]interface{}{"replicas": float64(1)},
+	}
+	if !reflect.DeepEqual(event.ObjectSnapshot, want) {
+		t.Errorf("ObjectSnapshot = %v, want the filtered old object %v", event.ObjectSnapshot, want)
+	}
+	if len(event.Diff) != 1 {
+		t.Errorf("Expected the diff to be computed as well, got %v", event.Diff)
+	}
+}
+
+func TestDecodePayload_CRDChange(t *testing.T) {
+	oldRaw := []byte(`{"metadata": {"name": "widgets.example.com"}, "spec": {"versions": [{"name": "v1", "served": true, "storage": true}]}}`)
+	newRaw := []byte(`{"metadata": {"name": "widgets.example.com"}, "spec": {"versions": [{"name": "v1", "served": true, "storage": false}, {"name": "v2", "served": true, "storage": true}]}}`)
+
+	decoder := NewDecoder()
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "CustomResourceDefinition"}
+	if err := decoder.DecodePayload(event, oldRaw, newRaw); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if event.CRDChange == nil || len(event.CRDChange.AddedVersions) != 1 || event.CRDChange.NewStorageVersion != "v2" {
+		t.Errorf("Expected v2 added as the storage version, got %+v", event.CRDChange)
+	}
+
+	event = &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment"}
+	if err := decoder.DecodePayload(event, oldRaw, newRaw); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if event.CRDChange != nil {
+		t.Errorf("Expected no CRD change for other kinds, got %+v", event.CRDChange)
+	}
+}
+
 func TestDecodeRequest_DELETE(t *testing.T) {
 	decoder := NewDecoder()
 
@@ -454,3 +510,91 @@ func TestDecodeRequest_InvalidJSON(t *testing.T) {
 		t.Error("DecodeRequest() should return error for invalid JSON")
 	}
 }
+
+func TestDecodeObjectMeta_StopsAfterMetadata(t *testing.T) {
+	// Everything after metadata is skipped, so trailing garbage is not even parsed
+	raw := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"big","labels":{"app.kubernetes.io/managed-by":"Helm"}},"data":{"key": not-json`)
+
+	meta, err := decodeObjectMeta(raw)
+	if err != nil {
+		t.Fatalf("decodeObjectMeta() error = %v", err)
+	}
+	if meta.Name != "big" || meta.Labels["app.kubernetes.io/managed-by"] != "Helm" {
+		t.Errorf("decodeObjectMeta() = %+v", meta)
+	}
+}
+
+func TestDecodeMetadata_SkipsDiff(t *testing.T) {
+	decoder := NewDecoder()
+	req := &admissionv1.AdmissionRequest{
+		Operation: admissionv1.Update,
+		Kind:      metav1.GroupVersionKind{Kind: "Deployment"},
+		Name:      "app",
+		OldObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"spec":{"replicas":1}}`)},
+		Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"spec":{"replicas":2}}`)},
+	}
+
+	event, err := decoder.DecodeMetadata(req)
+	if err != nil {
+		t.Fatalf("DecodeMetadata() error = %v", err)
+	}
+	if event.Diff != nil {
+		t.Error("DecodeMetadata() should not compute the diff")
+	}
+
+	if err := decoder.DecodePayload(event, req.OldObject.Raw, req.Object.Raw); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if len(event.Diff) == 0 {
+		t.Error("DecodePayload() should compute the diff")
+	}
+}
+
+func TestDecodeMetadata_Fingerprint(t *testing.T) {
+	decoder := NewDecoder()
+	update := func(uid, resourceVersion string) *admissionv1.AdmissionRequest {
+		return &admissionv1.AdmissionRequest{
+			UID:       types.UID(uid),
+			Operation: admissionv1.Update,
+			Kind:      metav1.GroupVersionKind{Kind: "ConfigMap"},
+			Name:      "app",
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app","resourceVersion":"` + resourceVersion + `"}}`)},
+		}
+	}
+	fingerprint := func(req *admissionv1.AdmissionRequest) string {
+		event, err := decoder.DecodeMetadata(req)
+		if err != nil {
+			t.Fatalf("DecodeMetadata() error = %v", err)
+		}
+		return event.Fingerprint
+	}
+
+	first := fingerprint(update("uid-1", "42"))
+	if len(first) != 64 {
+		t.Fatalf("Fingerprint = %q, want a SHA-256 hash", first)
+	}
+	if retry := fingerprint(update("uid-1", "42")); retry != first {
+		t.Errorf("Retried request fingerprint = %q, want %q", retry, first)
+	}
+	if other := fingerprint(update("uid-2", "42")); other == first {
+		t.Error("Requests with different UIDs should have different fingerprints")
+	}
+	if other := fingerprint(update("uid-1", "43")); other == first {
+		t.Error("Requests with different resourceVersions should have different fingerprints")
+	}
+
+	// DELETEs are identified by the old object
+	del := &admissionv1.AdmissionRequest{
+		UID:       "uid-3",
+		Operation: admissionv1.Delete,
+		Name:      "app",
+		OldObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app","resourceVersion":"42"}}`)},
+	}
+	if fingerprint(del) == "" {
+		t.Error("DELETE should have a fingerprint")
+	}
+
+	if empty := fingerprint(update("", "42")); empty != "" {
+		t.Errorf("Fingerprint without a request UID = %q, want none", empty)
+	}
+}
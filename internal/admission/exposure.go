@@ -0,0 +1,282 @@
+package admission
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Severities of exposure findings.
+const (
+	exposureWarning  = "warning"
+	exposureCritical = "critical"
+)
+
+// exposureChanges counts the changes opening network exposure, per severity.
+var exposureChanges = expvar.NewMap("webhook_exposure_changes_total")
+
+// exposureChange returns how the change of an Ingress, NetworkPolicy or Service, given its raw
+// old and new objects, opens network exposure, or nil if it doesn't or is of another kind.
+func exposureChange(resourceKind string, oldRaw, newRaw []byte) *model.ExposureChange {
+	var findings []model.ExposureFinding
+	var err error
+	switch resourceKind {
+	case "Ingress":
+		findings, err = ingressExposure(oldRaw, newRaw)
+	case "NetworkPolicy":
+		findings, err = networkPolicyExposure(oldRaw, newRaw)
+	case "Service":
+		findings, err = serviceExposure(oldRaw, newRaw)
+	default:
+		return nil
+	}
+	if err != nil {
+		klog.V(2).Infof("Failed to decode %s to check its exposure: %v", resourceKind, err)
+		return nil
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	change := &model.ExposureChange{Severity: exposureWarning, Findings: findings}
+	for _, finding := range findings {
+		if finding.Severity == exposureCritical {
+			change.Severity = exposureCritical
+		}
+	}
+	exposureChanges.Add(change.Severity, 1)
+	return change
+}
+
+// decodeRaw decodes raw into obj, leaving it unset when raw is nil.
+func decodeRaw(raw []byte, obj interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, obj)
+}
+
+// ingressExposure flags the hosts an Ingress routes that it didn't, the default backend it
+// gained, and the hosts it no longer terminates TLS for. Catch-all and wildcard hosts are
+// critical.
+func ingressExposure(oldRaw, newRaw []byte) ([]model.ExposureFinding, error) {
+	if newRaw == nil {
+		return nil, nil
+	}
+	var oldIngress, newIngress networkingv1.Ingress
+	if err := decodeRaw(oldRaw, &oldIngress); err != nil {
+		return nil, err
+	}
+	if err := decodeRaw(newRaw, &newIngress); err != nil {
+		return nil, err
+	}
+
+	var findings []model.ExposureFinding
+	oldHosts, newHosts := ingressHosts(&oldIngress), ingressHosts(&newIngress)
+	for _, rule := range newIngress.Spec.Rules {
+		host := rule.Host
+		if oldHosts[host] {
+			continue
+		}
+		oldHosts[host] = true // Flag hosts of several rules once
+		switch {
+		case host == "":
+			findings = append(findings, model.ExposureFinding{Check: "ingress_host", Severity: exposureCritical,
+				Message: "rule without host added, routing requests for any host"})
+		case strings.HasPrefix(host, "*."):
+			findings = append(findings, model.ExposureFinding{Check: "ingress_host", Severity: exposureCritical,
+				Message: fmt.Sprintf("wildcard host %s added", host)})
+		default:
+			findings = append(findings, model.ExposureFinding{Check: "ingress_host", Severity: exposureWarning,
+				Message: fmt.Sprintf("host %s added", host)})
+		}
+	}
+	if newIngress.Spec.DefaultBackend != nil && oldIngress.Spec.DefaultBackend == nil {
+		findings = append(findings, model.ExposureFinding{Check: "ingress_default_backend", Severity: exposureCritical,
+			Message: "default backend added, routing requests matching no rule"})
+	}
+
+	newTLS := map[string]bool{}
+	for _, tls := range newIngress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			newTLS[host] = true
+		}
+	}
+	for _, tls := range oldIngress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			if newHosts[host] && !newTLS[host] {
+				newTLS[host] = true // Flag hosts of several TLS entries once
+				findings = append(findings, model.ExposureFinding{Check: "ingress_tls_removed", Severity: exposureWarning,
+					Message: fmt.Sprintf("TLS removed for host %s", host)})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// ingressHosts returns the hosts of the rules of an Ingress, "" for rules without host.
+func ingressHosts(ingress *networkingv1.Ingress) map[string]bool {
+	hosts := map[string]bool{}
+	for _, rule := range ingress.Spec.Rules {
+		hosts[rule.Host] = true
+	}
+	return hosts
+}
+
+// networkPolicyExposure flags the deletion of a NetworkPolicy, the policy types it no longer
+// isolates pods for, and the rules it gained. Rules allowing any peer are critical, even in
+// a new policy; other rules only relax an existing policy.
+func networkPolicyExposure(oldRaw, newRaw []byte) ([]model.ExposureFinding, error) {
+	if newRaw == nil {
+		if oldRaw == nil {
+			return nil, nil
+		}
+		return []model.ExposureFinding{{Check: "network_policy_deleted", Severity: exposureWarning,
+			Message: "NetworkPolicy deleted, the pods it selected may no longer be isolated"}}, nil
+	}
+	var oldPolicy, newPolicy networkingv1.NetworkPolicy
+	if err := decodeRaw(oldRaw, &oldPolicy); err != nil {
+		return nil, err
+	}
+	if err := decodeRaw(newRaw, &newPolicy); err != nil {
+		return nil, err
+	}
+
+	var findings []model.ExposureFinding
+	if oldRaw != nil {
+		newTypes := policyTypes(&newPolicy)
+		for policyType := range policyTypes(&oldPolicy) {
+			if !newTypes[policyType] {
+				findings = append(findings, model.ExposureFinding{Check: "network_policy_type_removed", Severity: exposureCritical,
+					Message: fmt.Sprintf("%s policy type removed, the pods selected are no longer isolated for it", policyType)})
+			}
+		}
+	}
+
+	for _, rule := range newPolicy.Spec.Ingress {
+		if containsRule(oldPolicy.Spec.Ingress, rule) {
+			continue
+		}
+		if finding, ok := ruleExposure("ingress", "sources", rule.From, oldRaw != nil); ok {
+			findings = append(findings, finding)
+		}
+	}
+	for _, rule := range newPolicy.Spec.Egress {
+		if containsEgressRule(oldPolicy.Spec.Egress, rule) {
+			continue
+		}
+		if finding, ok := ruleExposure("egress", "destinations", rule.To, oldRaw != nil); ok {
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// ruleExposure returns the finding of an ingress or egress rule added to a NetworkPolicy,
+// given its peers, if it allows any peer or relaxes an existing policy.
+func ruleExposure(direction, peersName string, peers []networkingv1.NetworkPolicyPeer, existing bool) (model.ExposureFinding, bool) {
+	if allowsAnyPeer(peers) {
+		return model.ExposureFinding{Check: "network_policy_allow_all", Severity: exposureCritical,
+			Message: fmt.Sprintf("%s rule allowing all %s added", direction, peersName)}, true
+	}
+	if !existing {
+		return model.ExposureFinding{}, false
+	}
+	return model.ExposureFinding{Check: "network_policy_rule_added", Severity: exposureWarning,
+		Message: fmt.Sprintf("%s rule added", direction)}, true
+}
+
+// allowsAnyPeer reports whether a rule with peers allows traffic with any address: it has no
+// peers, or an IP block of every IPv4 or IPv6 address.
+func allowsAnyPeer(peers []networkingv1.NetworkPolicyPeer) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if peer.IPBlock != nil && len(peer.IPBlock.Except) == 0 && (peer.IPBlock.CIDR == "0.0.0.0/0" || peer.IPBlock.CIDR == "::/0") {
+			return true
+		}
+	}
+	return false
+}
+
+// policyTypes returns the policy types a NetworkPolicy isolates pods for. Without explicit
+// types, it isolates them for Ingress, and for Egress if it has egress rules.
+func policyTypes(policy *networkingv1.NetworkPolicy) map[networkingv1.PolicyType]bool {
+	types := map[networkingv1.PolicyType]bool{}
+	if len(policy.Spec.PolicyTypes) == 0 {
+		types[networkingv1.PolicyTypeIngress] = true
+		if len(policy.Spec.Egress) > 0 {
+			types[networkingv1.PolicyTypeEgress] = true
+		}
+		return types
+	}
+	for _, policyType := range policy.Spec.PolicyTypes {
+		types[policyType] = true
+	}
+	return types
+}
+
+// containsRule reports whether rules has rule.
+func containsRule(rules []networkingv1.NetworkPolicyIngressRule, rule networkingv1.NetworkPolicyIngressRule) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsEgressRule reports whether rules has rule.
+func containsEgressRule(rules []networkingv1.NetworkPolicyEgressRule, rule networkingv1.NetworkPolicyEgressRule) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceExposure flags a Service made reachable from outside the cluster: created or
+// changed to type LoadBalancer (critical) or NodePort.
+func serviceExposure(oldRaw, newRaw []byte) ([]model.ExposureFinding, error) {
+	if newRaw == nil {
+		return nil, nil
+	}
+	var oldService, newService corev1.Service
+	if err := decodeRaw(oldRaw, &oldService); err != nil {
+		return nil, err
+	}
+	if err := decodeRaw(newRaw, &newService); err != nil {
+		return nil, err
+	}
+
+	oldType, newType := oldService.Spec.Type, newService.Spec.Type
+	if oldType == "" && oldRaw != nil {
+		oldType = corev1.ServiceTypeClusterIP
+	}
+	severity := exposureWarning
+	switch {
+	case newType == oldType:
+		return nil, nil
+	case newType == corev1.ServiceTypeLoadBalancer:
+		severity = exposureCritical
+	case newType == corev1.ServiceTypeNodePort && oldType != corev1.ServiceTypeLoadBalancer:
+	default:
+		return nil, nil // Not exposed, or less than before
+	}
+
+	message := fmt.Sprintf("%s Service created", newType)
+	if oldRaw != nil {
+		message = fmt.Sprintf("type changed from %s to %s", oldType, newType)
+	}
+	return []model.ExposureFinding{{Check: "service_type", Severity: severity, Message: message}}, nil
+}
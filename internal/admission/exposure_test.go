@@ -0,0 +1,103 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestExposureChange_Ingress(t *testing.T) {
+	oldIngress := `{"kind":"Ingress","spec":{"tls":[{"hosts":["shop.example.com"]}],"rules":[{"host":"shop.example.com"}]}}`
+	newIngress := `{"kind":"Ingress","spec":{"rules":[{"host":"shop.example.com"},{"host":"admin.example.com"},{"host":"*.example.com"}]}}`
+
+	change := exposureChange("Ingress", []byte(oldIngress), []byte(newIngress))
+	if change == nil || change.Severity != exposureCritical {
+		t.Fatalf("Expected a critical exposure change, got %+v", change)
+	}
+	want := []model.ExposureFinding{
+		{Check: "ingress_host", Severity: exposureWarning, Message: "host admin.example.com added"},
+		{Check: "ingress_host", Severity: exposureCritical, Message: "wildcard host *.example.com added"},
+		{Check: "ingress_tls_removed", Severity: exposureWarning, Message: "TLS removed for host shop.example.com"},
+	}
+	if len(change.Findings) != len(want) {
+		t.Fatalf("Expected %d findings, got %+v", len(want), change.Findings)
+	}
+	for i, finding := range change.Findings {
+		if finding != want[i] {
+			t.Errorf("Finding %d = %+v, want %+v", i, finding, want[i])
+		}
+	}
+
+	if change := exposureChange("Ingress", []byte(newIngress), []byte(newIngress)); change != nil {
+		t.Errorf("Expected no exposure change for an unchanged Ingress, got %+v", change)
+	}
+	if change := exposureChange("Ingress", []byte(newIngress), nil); change != nil {
+		t.Errorf("Expected no exposure change for a deleted Ingress, got %+v", change)
+	}
+}
+
+func TestExposureChange_NetworkPolicy(t *testing.T) {
+	oldPolicy := `{"kind":"NetworkPolicy","spec":{"policyTypes":["Ingress","Egress"],"ingress":[{"from":[{"podSelector":{"matchLabels":{"app":"web"}}}]}]}}`
+	newPolicy := `{"kind":"NetworkPolicy","spec":{"policyTypes":["Ingress"],"ingress":[{"from":[{"podSelector":{"matchLabels":{"app":"web"}}}]},` +
+		`{"from":[{"namespaceSelector":{}}]},{"from":[{"ipBlock":{"cidr":"0.0.0.0/0"}}]}]}}`
+
+	change := exposureChange("NetworkPolicy", []byte(oldPolicy), []byte(newPolicy))
+	if change == nil || change.Severity != exposureCritical {
+		t.Fatalf("Expected a critical exposure change, got %+v", change)
+	}
+	checks := []string{"network_policy_type_removed", "network_policy_rule_added", "network_policy_allow_all"}
+	if len(change.Findings) != len(checks) {
+		t.Fatalf("Expected findings %v, got %+v", checks, change.Findings)
+	}
+	for i, finding := range change.Findings {
+		if finding.Check != checks[i] {
+			t.Errorf("Finding %d = %+v, want %s", i, finding, checks[i])
+		}
+	}
+
+	// A new policy isolates pods, unless it allows everything
+	if change := exposureChange("NetworkPolicy", nil, []byte(oldPolicy)); change != nil {
+		t.Errorf("Expected no exposure change for a new restrictive policy, got %+v", change)
+	}
+	allowAll := `{"kind":"NetworkPolicy","spec":{"ingress":[{}]}}`
+	if change := exposureChange("NetworkPolicy", nil, []byte(allowAll)); change == nil || change.Findings[0].Check != "network_policy_allow_all" {
+		t.Errorf("Expected a new allow-all policy to be flagged, got %+v", change)
+	}
+	if change := exposureChange("NetworkPolicy", []byte(oldPolicy), nil); change == nil || change.Findings[0].Check != "network_policy_deleted" || change.Severity != exposureWarning {
+		t.Errorf("Expected a deleted policy to be flagged, got %+v", change)
+	}
+}
+
+func TestExposureChange_Service(t *testing.T) {
+	tests := []struct {
+		name         string
+		old, new     string
+		wantSeverity string // Empty for no exposure change
+		wantMessage  string
+	}{
+		{"to LoadBalancer", `{"spec":{}}`, `{"spec":{"type":"LoadBalancer"}}`, exposureCritical, "type changed from ClusterIP to LoadBalancer"},
+		{"to NodePort", `{"spec":{"type":"ClusterIP"}}`, `{"spec":{"type":"NodePort"}}`, exposureWarning, "type changed from ClusterIP to NodePort"},
+		{"new NodePort", "", `{"spec":{"type":"NodePort"}}`, exposureWarning, "NodePort Service created"},
+		{"LoadBalancer to NodePort", `{"spec":{"type":"LoadBalancer"}}`, `{"spec":{"type":"NodePort"}}`, "", ""},
+		{"to ClusterIP", `{"spec":{"type":"NodePort"}}`, `{"spec":{"type":"ClusterIP"}}`, "", ""},
+		{"unchanged", `{"spec":{"type":"LoadBalancer"}}`, `{"spec":{"type":"LoadBalancer"}}`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldRaw []byte
+			if tt.old != "" {
+				oldRaw = []byte(tt.old)
+			}
+			change := exposureChange("Service", oldRaw, []byte(tt.new))
+			if tt.wantSeverity == "" {
+				if change != nil {
+					t.Errorf("Expected no exposure change, got %+v", change)
+				}
+				return
+			}
+			if change == nil || change.Severity != tt.wantSeverity || change.Findings[0].Message != tt.wantMessage {
+				t.Errorf("Expected a %s change %q, got %+v", tt.wantSeverity, tt.wantMessage, change)
+			}
+		})
+	}
+}
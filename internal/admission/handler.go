@@ -4,48 +4,207 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
 	"k8s.io/klog/v2"
 
-	"github.com/kubechronicle/kubechronicle/internal/alerting"
+	"github.com/kubechronicle/kubechronicle/internal/chaos"
 	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
 	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/spool"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
 )
 
 // Handler processes Kubernetes admission requests.
 type Handler struct {
-	decoder      *Decoder
-	store        store.Store
-	alertRouter  *alerting.Router
-	ignoreConfig *config.IgnoreConfig
-	blockConfig  *config.BlockConfig
-	queue        chan *model.ChangeEvent
-	configPath   string // Path to ConfigMap mount (optional, for dynamic reloading)
-	configMutex  sync.RWMutex // Protects config updates
-	lastReload   time.Time
+	decoder       *Decoder
+	store         store.Store
+	alertRouter   *alerting.Router
+	ignoreConfig  *config.IgnoreConfig
+	blockConfig   *config.BlockConfig
+	ignoreMatcher ignoreMatchers // Pre-compiled ignoreConfig, then overlay ignore configs
+	blockMatcher  blockMatchers  // Pre-compiled blockConfig, then overlay block configs
+	sampler       *sampler       // Sampling rules for high-churn resources; nil records everything
+	quotas        *quotaEnforcer // Soft limits on recorded events per namespace; nil limits nothing
+	warnMatcher   *warnMatcher   // Warn rules returned as admission warnings; nil warns about nothing
+	messages      *i18n.Catalog  // Locales of denial messages and alerts; nil returns them in English
+	egress        EgressTarget   // Follows the egress controls of reloaded alert configs; nil if none
+	queue         chan *queuedEvent
+	configPath    string       // Path to ConfigMap mount (optional, for dynamic reloading)
+	configMutex   sync.RWMutex // Protects config updates
+	lastReload    time.Time
+	latencyBudget time.Duration // Deadline of the admission path: slower responses are logged and skip optional stages
+	maxObjectSize int           // Objects larger than this (bytes) are recorded without diff or snapshot; 0 disables
+	maxBodySize   int64         // Request bodies larger than this (bytes) are rejected with 413; 0 disables
+
+	samplingConfig  *config.SamplingConfig // Config sampler was compiled from, reported by RuntimeConfig
+	warnConfig      *config.WarnConfig     // Config warnMatcher was compiled from, reported by RuntimeConfig
+	quotaConfig     *config.QuotaConfig    // Config quotas was compiled from, reported by RuntimeConfig
+	alertConfigRaw  string                 // Last alert config loaded from the ConfigMap, to detect changes
+	ignoreConfigRaw string                 // Last ignore config loaded from the ConfigMap
+	blockConfigRaw  string                 // Last block config loaded from the ConfigMap
+	warnConfigRaw   string                 // Last warn config loaded from the ConfigMap
+
+	configWatch *configMapWatch // Patterns ConfigMap to watch instead of polling configPath; nil polls
+	overlays    []*patternLayer // Overlay pattern ConfigMaps, merged on top of the base config in order
+	selfMonitor *selfMonitor    // Webhook configuration, patterns ConfigMaps and certificate to monitor; nil monitors nothing
+	sourceMonitor *sourceMonitor // Activity of the event sources to check; nil checks nothing
+	heartbeat     *heartbeat.Emitter // Heartbeats queued periodically; nil queues none
+	capture       *requestCapture    // Samples admission requests during debug capture sessions; nil captures nothing
+	deletes       *deleteCollector   // Groups bursts of DELETEs into delete collections; nil groups nothing
+	cascades      *cascadeTracker    // Summarizes the objects deleted with namespaces; nil summarizes nothing
+	volumeWatch   informers.SharedInformerFactory // Watches PersistentVolumes for the decoder; nil doesn't watch them
+	provenance    *provenanceChecker // Checks the images deployed by workload changes; nil checks none
+	limitRanges   *limitRangeChecker // Finds the containers outside the limits of LimitRanges changed; nil finds none
+
+	plugins *pluginPipeline // Compiled-in plugins run at the pipeline's registration points; nil runs none
+
+	chaosConfig *chaos.Config         // Faults injected for resilience testing; nil injects nothing
+	deadLetters store.DeadLetterStore // Keeps events whose save or alerts failed after all retries; nil drops them
+	spool       *spool.Spool          // Keeps events on disk while the store is unavailable; nil dead-letters them
+	pseudonymizer *pseudonym.Pseudonymizer // Pseudonymizes actors before events leave the worker; nil keeps them
+	exports     *export.Pipeline      // Exports the requeued dead letters of failed exports; nil dead-letters them again
+}
+
+// oversizedObjects counts events whose objects exceeded the size limit.
+var oversizedObjects = expvar.NewInt("webhook_oversized_objects_total")
+
+// oversizedRequests counts admission requests rejected because their body exceeded the size limit.
+var oversizedRequests = expvar.NewInt("webhook_oversized_requests_total")
+
+// Degradation counters: events dropped because the queue was full, and events the store failed to save.
+var (
+	droppedEvents = expvar.NewInt("webhook_dropped_events_total")
+	storeErrors   = expvar.NewInt("webhook_store_errors_total")
+)
+
+// Queue gauges: events waiting for the async worker, and the size of the queue.
+var (
+	queueLength   = expvar.NewInt("webhook_queue_length")
+	queueCapacity = expvar.NewInt("webhook_queue_capacity")
+)
+
+// Latency counters: admission responses sent, and those slower than the latency budget.
+var (
+	responses     = expvar.NewInt("webhook_responses_total")
+	slowResponses = expvar.NewInt("webhook_slow_responses_total")
+)
+
+// Config reload counters: reloads that changed the active config, and invalid configs that were rejected.
+var (
+	configReloads      = expvar.NewInt("webhook_config_reloads_total")
+	configReloadErrors = expvar.NewInt("webhook_config_reload_errors_total")
+)
+
+// queuedEvent is an event waiting to be processed by the async worker.
+// Only the raw objects are kept from the admission request; the worker decodes
+// them to fill in the diff and snapshot.
+type queuedEvent struct {
+	event     *model.ChangeEvent
+	oldObject []byte
+	object    []byte
+}
+
+// newQueuedEvent captures the raw objects of an admission request for async processing.
+// If either object is larger than maxObjectSize, the objects are dropped and the event
+// is marked as size exceeded, so only its metadata is recorded.
+func newQueuedEvent(event *model.ChangeEvent, req *admissionv1.AdmissionRequest, maxObjectSize int) *queuedEvent {
+	size := len(req.OldObject.Raw)
+	if len(req.Object.Raw) > size {
+		size = len(req.Object.Raw)
+	}
+	if maxObjectSize > 0 && size > maxObjectSize {
+		event.SizeExceeded = true
+		event.ObjectSize = size
+		oversizedObjects.Add(1)
+		klog.Warningf("Object %s/%s in namespace %s is %d bytes (limit: %d), recording metadata only",
+			event.ResourceKind, event.Name, event.Namespace, size, maxObjectSize)
+		return &queuedEvent{event: event}
+	}
+
+	return &queuedEvent{
+		event:     event,
+		oldObject: req.OldObject.Raw,
+		object:    req.Object.Raw,
+	}
 }
 
 // NewHandler creates a new admission handler.
 func NewHandler(store store.Store, alertRouter *alerting.Router, ignoreConfig *config.IgnoreConfig, blockConfig *config.BlockConfig) *Handler {
-	return &Handler{
-		decoder:      NewDecoder(),
-		store:        store,
-		alertRouter:  alertRouter,
-		ignoreConfig: ignoreConfig,
-		blockConfig:  blockConfig,
-		queue:        make(chan *model.ChangeEvent, 1000), // Buffered channel for async processing
-		configPath:   getEnv("PATTERNS_CONFIGMAP_PATH", "/etc/patterns"), // Default mount path
-		lastReload:   time.Now(),
+	h := &Handler{
+		decoder:       NewDecoder(),
+		store:         store,
+		alertRouter:   alertRouter,
+		ignoreConfig:  ignoreConfig,
+		blockConfig:   blockConfig,
+		ignoreMatcher: ignoreMatchers{newIgnoreMatcher(ignoreConfig)},
+		blockMatcher:  blockMatchers{newBlockMatcher(blockConfig)},
+		queue:         make(chan *queuedEvent, 1000),                      // Buffered channel for async processing
+		configPath:    getEnv("PATTERNS_CONFIGMAP_PATH", "/etc/patterns"), // Default mount path
+		lastReload:    time.Now(),
+		latencyBudget: parseLatencyBudget(getEnv("WEBHOOK_LATENCY_BUDGET", "")),
+		maxObjectSize: parseMaxObjectSize(getEnv("WEBHOOK_MAX_OBJECT_SIZE", "")),
+		maxBodySize:   parseMaxRequestSize(getEnv("WEBHOOK_MAX_REQUEST_SIZE", "")),
 	}
+	h.configureAlertRouter(alertRouter)
+	return h
+}
+
+// parseLatencyBudget parses the admission latency budget (default: 100ms).
+func parseLatencyBudget(value string) time.Duration {
+	if value == "" {
+		return 100 * time.Millisecond
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil || budget <= 0 {
+		klog.Warningf("Invalid WEBHOOK_LATENCY_BUDGET %q, using 100ms", value)
+		return 100 * time.Millisecond
+	}
+	return budget
+}
+
+// parseMaxObjectSize parses the maximum object size in bytes (default: 1MiB, 0 disables the limit).
+func parseMaxObjectSize(value string) int {
+	if value == "" {
+		return 1024 * 1024
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		klog.Warningf("Invalid WEBHOOK_MAX_OBJECT_SIZE %q, using 1048576", value)
+		return 1024 * 1024
+	}
+	return size
+}
+
+// parseMaxRequestSize parses the maximum request body size in bytes (default: 10MiB, 0 disables the limit).
+// An AdmissionReview carries both the old and new object, so it must fit two objects of maxObjectSize.
+func parseMaxRequestSize(value string) int64 {
+	if value == "" {
+		return 10 * 1024 * 1024
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		klog.Warningf("Invalid WEBHOOK_MAX_REQUEST_SIZE %q, using 10485760", value)
+		return 10 * 1024 * 1024
+	}
+	return size
 }
 
 // getEnv gets an environment variable or returns a default value.
@@ -71,48 +230,139 @@ func (h *Handler) reloadConfigPeriodically(ctx context.Context) {
 	}
 }
 
-// reloadConfig reloads ignore and block config from mounted ConfigMap files.
+// reloadConfig reloads ignore, block, warn and alert config from mounted ConfigMap files.
 func (h *Handler) reloadConfig() {
+	data := make(map[string]string)
+	// The alert secrets are read too when their Secret is projected into the same directory
+	for _, key := range append(configKeys, alerting.SecretKey) {
+		path := fmt.Sprintf("%s/%s", h.configPath, key)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// File doesn't exist or can't be read - that's OK, might be using env vars
+			klog.V(4).Infof("Could not read %s from %s: %v", key, path, err)
+			continue
+		}
+		data[key] = string(content)
+	}
+	h.applyConfigData(data)
+}
+
+// configKeys are the ConfigMap keys the webhook reloads.
+var configKeys = []string{"IGNORE_CONFIG", "BLOCK_CONFIG", "WARN_CONFIG", "ALERT_CONFIG"}
+
+// applyConfigData applies ignore, block, warn and alert config from ConfigMap data. Missing keys
+// keep the current config, and each config is only applied when its content changes.
+// Invalid content keeps the current config and is not retried until it changes.
+func (h *Handler) applyConfigData(data map[string]string) {
 	h.configMutex.Lock()
 	defer h.configMutex.Unlock()
-	
-	ignorePath := fmt.Sprintf("%s/IGNORE_CONFIG", h.configPath)
-	blockPath := fmt.Sprintf("%s/BLOCK_CONFIG", h.configPath)
-	
+
+	reloaded := false
+
 	// Reload ignore config
-	if data, err := os.ReadFile(ignorePath); err == nil {
-		var ignoreConfig config.IgnoreConfig
-		if err := json.Unmarshal(data, &ignoreConfig); err == nil {
-			h.ignoreConfig = &ignoreConfig
-			klog.V(2).Infof("Reloaded ignore config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v",
+	if raw, ok := data["IGNORE_CONFIG"]; ok && strings.TrimSpace(raw) != h.ignoreConfigRaw {
+		h.ignoreConfigRaw = strings.TrimSpace(raw)
+		if ignoreConfig, err := parseIgnoreConfig(raw); err == nil {
+			h.ignoreConfig = ignoreConfig
+			klog.Infof("Reloaded ignore config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v",
 				ignoreConfig.NamespacePatterns, ignoreConfig.NamePatterns, ignoreConfig.ResourceKindPatterns)
-			h.lastReload = time.Now()
+			reloaded = true
 		} else {
-			klog.V(3).Infof("Failed to parse ignore config from file: %v", err)
+			klog.Errorf("Failed to parse ignore config, keeping current patterns: %v", err)
+			configReloadErrors.Add(1)
 		}
-	} else {
-		// File doesn't exist or can't be read - that's OK, might be using env vars
-		klog.V(4).Infof("Could not read ignore config from %s: %v", ignorePath, err)
 	}
-	
+
 	// Reload block config
-	if data, err := os.ReadFile(blockPath); err == nil {
-		var blockConfig config.BlockConfig
-		if err := json.Unmarshal(data, &blockConfig); err == nil {
-			if blockConfig.Message == "" {
-				blockConfig.Message = "Resource blocked by kubechronicle policy"
-			}
-			h.blockConfig = &blockConfig
-			klog.V(2).Infof("Reloaded block config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v, operation_patterns=%v",
+	if raw, ok := data["BLOCK_CONFIG"]; ok && strings.TrimSpace(raw) != h.blockConfigRaw {
+		h.blockConfigRaw = strings.TrimSpace(raw)
+		if blockConfig, err := parseBlockConfig(raw); err == nil {
+			h.blockConfig = blockConfig
+			klog.Infof("Reloaded block config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v, operation_patterns=%v",
 				blockConfig.NamespacePatterns, blockConfig.NamePatterns, blockConfig.ResourceKindPatterns, blockConfig.OperationPatterns)
-			h.lastReload = time.Now()
+			reloaded = true
 		} else {
-			klog.V(3).Infof("Failed to parse block config from file: %v", err)
+			klog.Errorf("Failed to parse block config, keeping current patterns: %v", err)
+			configReloadErrors.Add(1)
 		}
-	} else {
-		// File doesn't exist or can't be read - that's OK, might be using env vars
-		klog.V(4).Infof("Could not read block config from %s: %v", blockPath, err)
 	}
+
+	// Reload warn config
+	if raw, ok := data["WARN_CONFIG"]; ok && strings.TrimSpace(raw) != h.warnConfigRaw {
+		h.warnConfigRaw = strings.TrimSpace(raw)
+		var warnConfig config.WarnConfig
+		if err := json.Unmarshal([]byte(raw), &warnConfig); err == nil {
+			h.warnConfig = &warnConfig
+			h.warnMatcher = newWarnMatcher(&warnConfig)
+			klog.Infof("Reloaded warn config: %d rules", len(warnConfig.Rules))
+			reloaded = true
+		} else {
+			klog.Errorf("Failed to parse warn config, keeping current rules: %v", err)
+			configReloadErrors.Add(1)
+		}
+	}
+
+	// Reload alert config. An invalid config keeps the current router so alerts are not lost.
+	// Secrets kept in a Secret are part of the content, so changing only them reloads it too.
+	raw := strings.TrimSpace(data["ALERT_CONFIG"])
+	if content := raw + "\n" + data[alerting.SecretKey]; raw != "" && content != h.alertConfigRaw {
+		h.alertConfigRaw = content
+		var alertConfig alerting.Config
+		if err := json.Unmarshal([]byte(raw), &alertConfig); err != nil {
+			klog.Errorf("Failed to parse alert config, keeping current alert channels: %v", err)
+			configReloadErrors.Add(1)
+		} else if err := alertConfig.ResolveSecrets(h.alertSecrets(alertConfig.SecretRef, data)); err != nil {
+			klog.Errorf("Failed to load alert secrets, keeping current alert channels: %v", err)
+			configReloadErrors.Add(1)
+		} else if alertRouter, err := alerting.NewRouter(&alertConfig); err != nil {
+			klog.Errorf("Failed to reload alert config, keeping current alert channels: %v", err)
+			configReloadErrors.Add(1)
+		} else {
+			h.configureAlertRouter(alertRouter)
+			h.alertRouter = alertRouter
+			if h.egress != nil {
+				if err := h.egress.SetEgress(alertConfig.Egress); err != nil {
+					klog.Errorf("Failed to apply the alert config's egress controls to subscriptions: %v", err)
+				}
+			}
+			klog.Infof("Reloaded alert config: channels=%v", alertRouter.Channels())
+			reloaded = true
+		}
+	}
+
+	if reloaded {
+		h.compileMatchers()
+		h.lastReload = time.Now()
+		configReloads.Add(1)
+	}
+}
+
+// parseIgnoreConfig parses an ignore config from ConfigMap data.
+func parseIgnoreConfig(raw string) (*config.IgnoreConfig, error) {
+	var ignoreConfig config.IgnoreConfig
+	if err := json.Unmarshal([]byte(raw), &ignoreConfig); err != nil {
+		return nil, err
+	}
+	return &ignoreConfig, nil
+}
+
+// parseBlockConfig parses a block config from ConfigMap data, defaulting the message.
+func parseBlockConfig(raw string) (*config.BlockConfig, error) {
+	var blockConfig config.BlockConfig
+	if err := json.Unmarshal([]byte(raw), &blockConfig); err != nil {
+		return nil, err
+	}
+	if blockConfig.Message == "" {
+		blockConfig.Message = defaultBlockMessage
+	}
+	return &blockConfig, nil
+}
+
+// getAlertRouter returns the current alert router (thread-safe).
+func (h *Handler) getAlertRouter() *alerting.Router {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.alertRouter
 }
 
 // getIgnoreConfig returns the current ignore config (thread-safe).
@@ -129,13 +379,201 @@ func (h *Handler) getBlockConfig() *config.BlockConfig {
 	return h.blockConfig
 }
 
+// getMatchers returns the current pre-compiled ignore and block matchers (thread-safe).
+func (h *Handler) getMatchers() (ignoreMatchers, blockMatchers) {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.ignoreMatcher, h.blockMatcher
+}
+
+// SetSamplingConfig sets the sampling rules for high-churn resources.
+func (h *Handler) SetSamplingConfig(samplingConfig *config.SamplingConfig) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.samplingConfig = samplingConfig
+	h.sampler = newSampler(samplingConfig)
+}
+
+// SetQuotaConfig sets the soft limits on the events recorded per namespace. Counts restart
+// from zero.
+func (h *Handler) SetQuotaConfig(quotaConfig *config.QuotaConfig) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.quotaConfig = quotaConfig
+	h.quotas = newQuotaEnforcer(quotaConfig)
+}
+
+// SetSnapshotUpdates makes the handler store the object before each UPDATE, as it does for
+// DELETE. It must be called before Start.
+func (h *Handler) SetSnapshotUpdates(enabled bool) {
+	h.decoder.SetSnapshotUpdates(enabled)
+}
+
+// SetSnapshotConfig selects the fields stored in the snapshots of matching resource kinds.
+// It must be called before Start.
+func (h *Handler) SetSnapshotConfig(snapshotConfig *config.SnapshotConfig) {
+	h.decoder.SetSnapshotConfig(snapshotConfig)
+}
+
+// SetWarnConfig sets the warn rules returned as admission warnings.
+func (h *Handler) SetWarnConfig(warnConfig *config.WarnConfig) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.warnConfig = warnConfig
+	h.warnMatcher = newWarnMatcher(warnConfig)
+}
+
+// SetChaosConfig injects faults into the store, alert senders and event queue, to verify
+// that admission keeps failing open and degradation is noticed. It must be called before Start.
+func (h *Handler) SetChaosConfig(chaosConfig *chaos.Config) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.chaosConfig = chaosConfig
+	h.store = chaos.WrapStore(h.store, chaosConfig)
+	h.alertRouter.WrapSenders(func(s alerting.Sender) alerting.Sender { return chaos.WrapSender(s, chaosConfig) })
+	if chaosConfig != nil && chaosConfig.QueueCapacity > 0 {
+		h.queue = make(chan *queuedEvent, chaosConfig.QueueCapacity)
+	}
+}
+
+// SetMessageCatalog localizes denial messages and alerts in the locale of the namespace of
+// each request. It must be called before SetChaosConfig.
+func (h *Handler) SetMessageCatalog(catalog *i18n.Catalog) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.messages = catalog
+	h.alertRouter.SetMessageCatalog(catalog)
+}
+
+// EgressTarget is a component whose HTTP requests follow the egress controls of the alert
+// configuration, such as the subscription dispatcher.
+type EgressTarget interface {
+	SetEgress(cfg *alerting.EgressConfig) error
+}
+
+// SetEgressTarget applies the egress controls of the alert configs reloaded from the
+// ConfigMap to target. Must be called before SetConfigMapWatch.
+func (h *Handler) SetEgressTarget(target EgressTarget) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.egress = target
+}
+
+// getMessageCatalog returns the current message catalog (thread-safe).
+func (h *Handler) getMessageCatalog() *i18n.Catalog {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.messages
+}
+
+// getWarnMatcher returns the current warn matcher (thread-safe).
+func (h *Handler) getWarnMatcher() *warnMatcher {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.warnMatcher
+}
+
+// getSampler returns the current sampler (thread-safe).
+func (h *Handler) getSampler() *sampler {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.sampler
+}
+
+// getQuotas returns the current quota enforcer (thread-safe).
+func (h *Handler) getQuotas() *quotaEnforcer {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.quotas
+}
+
+// RuntimeConfig is the configuration the webhook is currently running with,
+// including rules reloaded from the ConfigMap.
+type RuntimeConfig struct {
+	IgnoreConfig   *config.IgnoreConfig   `json:"ignore_config"`
+	BlockConfig    *config.BlockConfig    `json:"block_config"`
+	SamplingConfig *config.SamplingConfig `json:"sampling_config"`
+	WarnConfig     *config.WarnConfig     `json:"warn_config"`
+	ConfigPath     string                 `json:"config_path"`
+	LastReload     time.Time              `json:"last_reload"`
+	QueueLength    int                    `json:"queue_length"`
+	QueueCapacity  int                    `json:"queue_capacity"`
+	LatencyBudget  string                 `json:"latency_budget"`
+	MaxObjectSize  int                    `json:"max_object_size"`
+	MaxRequestSize int64                  `json:"max_request_size"`
+	AlertChannels  []string               `json:"alert_channels"`
+	ConfigMap      string                 `json:"config_map,omitempty"` // Watched patterns ConfigMap, if any
+	Overlays       []PatternOverlay       `json:"overlays,omitempty"`
+	Chaos          *chaos.Config          `json:"chaos,omitempty"` // Injected faults, if any
+	SpoolDir       string                 `json:"spool_dir,omitempty"`
+	Plugins        []string               `json:"plugins,omitempty"` // Compiled-in plugins enabled, in order
+
+	QuotaConfig *config.QuotaConfig `json:"quota_config,omitempty"`
+	Quotas      []QuotaStatus       `json:"quotas,omitempty"` // Usage of the quotas in the current window
+}
+
+// RuntimeConfig returns a snapshot of the current runtime configuration (thread-safe).
+func (h *Handler) RuntimeConfig() *RuntimeConfig {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return &RuntimeConfig{
+		IgnoreConfig:   h.ignoreConfig,
+		BlockConfig:    h.blockConfig,
+		SamplingConfig: h.samplingConfig,
+		WarnConfig:     h.warnConfig,
+		ConfigPath:     h.configPath,
+		LastReload:     h.lastReload,
+		QueueLength:    len(h.queue),
+		QueueCapacity:  cap(h.queue),
+		LatencyBudget:  h.latencyBudget.String(),
+		MaxObjectSize:  h.maxObjectSize,
+		MaxRequestSize: h.maxBodySize,
+		AlertChannels:  h.alertRouter.Channels(),
+		ConfigMap:      h.configWatch.String(),
+		Overlays:       h.overlaySnapshot(),
+		Chaos:          h.chaosConfig,
+		SpoolDir:       h.spoolDir(),
+		Plugins:        h.plugins.pluginNames(),
+		QuotaConfig:    h.quotaConfig,
+		Quotas:         h.quotas.status(),
+	}
+}
+
 // Start starts the async event processing worker and config reloader.
 func (h *Handler) Start(ctx context.Context) {
+	queueCapacity.Set(int64(cap(h.queue)))
 	go h.processEvents(ctx)
-	// Start config reloader if ConfigMap is mounted
-	if h.configPath != "" {
+	if h.deadLetters != nil {
+		go h.retryRequeuedDeadLetters(ctx)
+	}
+	if h.spool != nil && h.store != nil {
+		go h.drainSpoolPeriodically(ctx)
+	}
+	// Watch the patterns ConfigMap if configured, otherwise poll the mounted files
+	if h.configWatch != nil {
+		go h.watchConfigMap(ctx)
+	} else if h.configPath != "" {
 		go h.reloadConfigPeriodically(ctx)
 	}
+	if h.selfMonitor != nil {
+		go h.runSelfMonitor(ctx)
+	}
+	if h.sourceMonitor != nil {
+		go h.runSourceMonitor(ctx)
+	}
+	h.startHeartbeat(ctx)
+	if h.capture != nil {
+		go h.runCapture(ctx)
+	}
+	if h.deletes != nil {
+		go h.runDeleteCollections(ctx)
+	}
+	if h.cascades != nil {
+		go h.runNamespaceCascades(ctx)
+	}
+	if h.volumeWatch != nil {
+		go h.watchVolumes(ctx)
+	}
 }
 
 // processEvents processes change events asynchronously.
@@ -144,21 +582,62 @@ func (h *Handler) processEvents(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case event := <-h.queue:
+		case item := <-h.queue:
+			queueLength.Set(int64(len(h.queue)))
+			event := item.event
+
+			// Decode the full objects here rather than in the admission path
+			if item.oldObject != nil || item.object != nil {
+				if err := h.decoder.DecodePayload(event, item.oldObject, item.object); err != nil {
+					klog.Errorf("Failed to decode payload of change event %s, saving without diff: %v", event.ID, err)
+				}
+			}
+			if h.provenance != nil {
+				h.provenance.check(ctx, event, item.oldObject, item.object)
+			}
+			if h.limitRanges != nil {
+				h.limitRanges.check(ctx, event, item.oldObject, item.object)
+			}
+
+			// Run the pre-persist plugins, which may enrich or drop the event
+			plugins := h.getPlugins()
+			if !plugins.runPrePersist(ctx, event) {
+				continue
+			}
+			plugins.runEnrichers(ctx, event)
+
+			// Pseudonymize before the event can reach the store, the spool, dead letters or alerts
+			if !h.pseudonymize(event) {
+				continue
+			}
+
 			// Save to store
 			if h.store != nil {
-				if err := h.store.Save(event); err != nil {
-					klog.Errorf("Failed to save change event %s: %v", event.ID, err)
-				} else {
+				err := h.saveWithRetry(event)
+				switch {
+				case err == nil:
 					klog.Infof("Saved change event %s: %s %s/%s", event.ID, event.Operation, event.ResourceKind, event.Name)
+				case errors.Is(err, store.ErrDuplicate):
+					// A retried admission request, already saved and alerted on
+					continue
+				case errors.Is(err, store.ErrUnavailable) && h.spoolEvent(event):
+					// Saved from the spool once the store is reachable again
+				default:
+					klog.Errorf("Failed to save change event %s after %d attempts: %v", event.ID, storeAttempts, err)
+					h.addDeadLetter(&store.DeadLetter{Kind: store.DeadLetterKindStore, Event: event, Error: err.Error(), Attempts: storeAttempts})
 				}
 			} else {
 				klog.V(2).Infof("Change event (no store): %+v", event)
 			}
 
-			// Send alerts
-			if h.alertRouter != nil {
-				h.alertRouter.Send(event)
+			h.cascades.observe(event, time.Now())
+
+			// Send alerts, unless held back until it's known whether the event is part of a delete collection
+			if h.deletes.add(event, time.Now()) {
+				continue
+			}
+			if alertRouter := h.getAlertRouter(); alertRouter != nil {
+				alertRouter.Send(event)
 			}
 		}
 	}
@@ -166,9 +645,14 @@ func (h *Handler) processEvents(ctx context.Context) {
 
 // HandleAdmissionReview handles an AdmissionReview request and returns a response.
 // This function always allows requests (observe-only) and processes them asynchronously.
+// Allowed requests matching warn rules are returned with admission warnings.
 func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
+	// Plugins see the latency budget as their deadline, and optional stages are skipped once it's spent
+	budgetCtx, cancel := context.WithDeadline(r.Context(), startTime.Add(h.latencyBudget))
+	defer cancel()
+
 	// Ensure we only accept POST requests
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -178,8 +662,19 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 	// Read request body
 	var body []byte
 	if r.Body != nil {
+		// Bound the body so oversized requests cannot exhaust the webhook's memory
+		if h.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+		}
 		var err error
 		body, err = readBody(r)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			oversizedRequests.Add(1)
+			klog.Warningf("Rejecting admission request from %s: body exceeds %d bytes", r.RemoteAddr, maxBytesErr.Limit)
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		if err != nil {
 			klog.Errorf("Failed to read request body: %v", err)
 			h.sendErrorResponse(w, fmt.Errorf("failed to read body: %w", err))
@@ -195,9 +690,10 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Extract change event to check for blocking
-	// We need to decode before responding to check block patterns
-	event, err := h.decoder.DecodeRequest(review.Request)
+	// Extract the metadata needed to check block and ignore patterns.
+	// The full objects are decoded later by the async worker.
+	event, err := h.decoder.DecodeMetadata(review.Request)
+	h.captureRequest(review.Request, err)
 	if err != nil {
 		klog.Errorf("Failed to decode request: %v", err)
 		// On decode error, fail-open (allow the request)
@@ -220,6 +716,7 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 	// Get current config (may have been reloaded)
 	ignoreConfig := h.getIgnoreConfig()
 	blockConfig := h.getBlockConfig()
+	ignoreMatcher, blockMatcher := h.getMatchers()
 	
 	// Debug: Log event details and config state for troubleshooting
 	klog.V(2).Infof("Processing event: operation=%s, kind=%s, name=%s, namespace=%s, ignoreConfig=%v, blockConfig=%v",
@@ -234,14 +731,24 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 			blockConfig.NamespacePatterns, blockConfig.NamePatterns, blockConfig.ResourceKindPatterns, blockConfig.OperationPatterns)
 	}
 
+	// Run the pre-decision plugins, which may enrich the event or deny the request
+	plugins := h.getPlugins()
+	blocked, pluginWarnings := plugins.runPreDecision(budgetCtx, review.Request, event)
+
 	// Check if this event should be blocked
-	shouldBlock, blockPattern, blockMessage := ShouldBlock(event, blockConfig)
-	if shouldBlock {
+	if blocked == nil {
+		blocked = blockMatcher.match(event)
+	}
+	if blocked != nil {
+		blockPattern := blocked.pattern
+		blockMessage := blocked.localized(h.getMessageCatalog().ForNamespace(event.Namespace))
+
 		// Set timestamp and ID for tracking blocked events
 		event.Timestamp = time.Now()
-		event.ID = generateEventID(event)
+		event.ID = model.NewEventID(event.Timestamp)
 		event.Allowed = false
 		event.BlockPattern = blockPattern
+		event.BlockRule = blocked.rule
 
 		klog.Warningf("Blocking %s: %s/%s in namespace %s (user: %s, source: %s) - pattern: %s, message: %s",
 			event.Operation,
@@ -258,9 +765,11 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 		// This allows tracking of blocked attempts
 		if h.store != nil {
 			select {
-			case h.queue <- event:
+			case h.queue <- newQueuedEvent(event, review.Request, h.maxObjectSize):
 				// Successfully queued for async save
+				queueLength.Set(int64(len(h.queue)))
 			default:
+				droppedEvents.Add(1)
 				klog.Warningf("Event queue full, dropping blocked event: %s", event.ID)
 			}
 		}
@@ -279,16 +788,27 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 					Reason:  metav1.StatusReasonForbidden,
 					Code:    http.StatusForbidden,
 				},
+				Warnings: pluginWarnings,
 			},
 		}
+		plugins.runPostDecision(budgetCtx, review.Request, event, response.Response)
 		if err := h.sendResponse(w, response); err != nil {
 			klog.Errorf("Failed to send block response: %v", err)
 		}
 		return
 	}
 
+	// Soft policy hints are returned even for ignored and sampled-out events, within the budget
+	warnings := pluginWarnings
+	if budgetCtx.Err() == nil {
+		warnings = append(warnings, h.getWarnMatcher().warnings(event, review.Request)...)
+	} else {
+		budgetSkips.Add(1)
+		klog.V(2).Infof("Latency budget spent, skipping the warn rules on %s %s/%s", event.Operation, event.ResourceKind, event.Name)
+	}
+
 	// Check if this event should be ignored (but still allowed)
-	shouldIgnore := ShouldIgnore(event, ignoreConfig)
+	shouldIgnore := ignoreMatcher.matches(event)
 	if shouldIgnore {
 		klog.Infof("Ignoring %s: %s/%s in namespace %s (matches ignore pattern)",
 			event.Operation,
@@ -303,19 +823,58 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 				Kind:       "AdmissionReview",
 			},
 			Response: &admissionv1.AdmissionResponse{
-				UID:     review.Request.UID,
-				Allowed: true,
+				UID:      review.Request.UID,
+				Allowed:  true,
+				Warnings: warnings,
+			},
+		}
+		plugins.runPostDecision(budgetCtx, review.Request, event, response.Response)
+		if err := h.sendResponse(w, response); err != nil {
+			klog.Errorf("Failed to send response: %v", err)
+		}
+		return
+	}
+
+	// Check if this event is sampled out (high-churn resources, and namespaces past their
+	// quota, are only partially recorded)
+	shouldRecord, sampleRate := h.getSampler().sample(event)
+	if shouldRecord {
+		var quotaRate int
+		shouldRecord, quotaRate = h.getQuotas().sample(event)
+		sampleRate *= quotaRate
+	}
+	if !shouldRecord {
+		klog.V(3).Infof("Sampling out %s: %s/%s in namespace %s (rate: 1/%d)",
+			event.Operation,
+			event.ResourceKind,
+			event.Name,
+			event.Namespace,
+			sampleRate,
+		)
+		response := &admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "admission.k8s.io/v1",
+				Kind:       "AdmissionReview",
+			},
+			Response: &admissionv1.AdmissionResponse{
+				UID:      review.Request.UID,
+				Allowed:  true,
+				Warnings: warnings,
 			},
 		}
+		plugins.runPostDecision(budgetCtx, review.Request, event, response.Response)
 		if err := h.sendResponse(w, response); err != nil {
 			klog.Errorf("Failed to send response: %v", err)
 		}
 		return
 	}
+	if sampleRate > 1 {
+		event.SampleRate = sampleRate // Each recorded event stands for sampleRate events
+	}
 
 	// Set timestamp and ID for tracking
 	event.Timestamp = time.Now()
-	event.ID = generateEventID(event)
+	event.ID = model.NewEventID(event.Timestamp)
 	event.Allowed = true    // Operation was allowed
 	event.BlockPattern = "" // No block pattern matched
 
@@ -331,10 +890,12 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 
 	// Queue for async processing (non-blocking)
 	select {
-	case h.queue <- event:
+	case h.queue <- newQueuedEvent(event, review.Request, h.maxObjectSize):
 		// Successfully queued
+		queueLength.Set(int64(len(h.queue)))
 	default:
 		// Queue full, log warning but don't block
+		droppedEvents.Add(1)
 		klog.Warningf("Event queue full, dropping event: %s", event.ID)
 	}
 
@@ -345,12 +906,14 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 			Kind:       "AdmissionReview",
 		},
 		Response: &admissionv1.AdmissionResponse{
-			UID:     review.Request.UID,
-			Allowed: true,
+			UID:      review.Request.UID,
+			Allowed:  true,
+			Warnings: warnings,
 		},
 	}
 
 	// Send response
+	plugins.runPostDecision(budgetCtx, review.Request, event, response.Response)
 	if err := h.sendResponse(w, response); err != nil {
 		klog.Errorf("Failed to send response: %v", err)
 		return
@@ -358,8 +921,10 @@ func (h *Handler) HandleAdmissionReview(w http.ResponseWriter, r *http.Request)
 
 	// Log performance
 	duration := time.Since(startTime)
-	if duration > 100*time.Millisecond {
-		klog.Warningf("Webhook response took %v (target: <100ms)", duration)
+	responses.Add(1)
+	if duration > h.latencyBudget {
+		slowResponses.Add(1)
+		klog.Warningf("Webhook response took %v (budget: %v)", duration, h.latencyBudget)
 	} else {
 		klog.V(3).Infof("Webhook response took %v", duration)
 	}
@@ -405,14 +970,3 @@ func readBody(r *http.Request) ([]byte, error) {
 	r.Body = io.NopCloser(bytes.NewBuffer(body))
 	return body, nil
 }
-
-// generateEventID generates a unique ID for a change event.
-func generateEventID(event *model.ChangeEvent) string {
-	// Simple ID generation: timestamp + resource identifier
-	return fmt.Sprintf("%s-%s-%s-%d",
-		event.Operation,
-		event.ResourceKind,
-		event.Name,
-		event.Timestamp.UnixNano(),
-	)
-}
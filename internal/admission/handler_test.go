@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
+	"github.com/kubechronicle/kubechronicle/internal/chaos"
 	"github.com/kubechronicle/kubechronicle/internal/config"
 	"github.com/kubechronicle/kubechronicle/internal/model"
 	"github.com/kubechronicle/kubechronicle/internal/store"
@@ -89,6 +92,23 @@ func (m *mockStore) GetUserActivity(ctx context.Context, username string, pagina
 	}, nil
 }
 
+func (m *mockStore) GetGroupActivity(ctx context.Context, group string, pagination store.PaginationParams, sortOrder store.SortOrder) (*store.QueryResult, error) {
+	// Simple mock implementation - filter saved events by group
+	var events []*model.ChangeEvent
+	for _, event := range m.savedEvents {
+		for _, g := range event.Actor.Groups {
+			if g == group {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return &store.QueryResult{
+		Events: events,
+		Total:  len(events),
+	}, nil
+}
+
 func TestNewHandler(t *testing.T) {
 	store := &mockStore{}
 	handler := NewHandler(store, nil, nil, nil)
@@ -152,6 +172,7 @@ func TestHandler_HandleAdmissionReview_Success(t *testing.T) {
 		t.Fatalf("Failed to marshal review: %v", err)
 	}
 
+	sent := responses.Value()
 	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 
@@ -175,6 +196,32 @@ func TestHandler_HandleAdmissionReview_Success(t *testing.T) {
 	if response.Response.UID != "test-uid" {
 		t.Errorf("Response.UID = %s, want test-uid", response.Response.UID)
 	}
+	if responses.Value() != sent+1 {
+		t.Error("Response should be counted in webhook_responses_total")
+	}
+	if queueCapacity.Value() != 1000 {
+		t.Errorf("webhook_queue_capacity = %d, want 1000", queueCapacity.Value())
+	}
+}
+
+func TestHandler_HandleAdmissionReview_SlowResponse(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.latencyBudget = time.Nanosecond
+
+	slow := slowResponses.Value()
+	body := []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","request":{"uid":"test-uid","operation":"CREATE","kind":{"kind":"ConfigMap"},"name":"test"}}`)
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if slowResponses.Value() != slow+1 {
+		t.Error("Response over the latency budget should be counted in webhook_slow_responses_total")
+	}
+	if queueLength.Value() != int64(len(handler.queue)) {
+		t.Errorf("webhook_queue_length = %d, want %d", queueLength.Value(), len(handler.queue))
+	}
 }
 
 func TestHandler_HandleAdmissionReview_WrongMethod(t *testing.T) {
@@ -261,7 +308,7 @@ func TestHandler_ProcessEvents_WithStore(t *testing.T) {
 
 	// Send event to queue
 	select {
-	case handler.queue <- event:
+	case handler.queue <- &queuedEvent{event: event}:
 	case <-time.After(1 * time.Second):
 		t.Fatal("Failed to send event to queue")
 	}
@@ -294,7 +341,7 @@ func TestHandler_ProcessEvents_WithoutStore(t *testing.T) {
 
 	// Send event to queue
 	select {
-	case handler.queue <- event:
+	case handler.queue <- &queuedEvent{event: event}:
 	case <-time.After(1 * time.Second):
 		t.Fatal("Failed to send event to queue")
 	}
@@ -321,7 +368,7 @@ func TestHandler_ProcessEvents_StoreError(t *testing.T) {
 
 	// Send event to queue
 	select {
-	case handler.queue <- event:
+	case handler.queue <- &queuedEvent{event: event}:
 	case <-time.After(1 * time.Second):
 		t.Fatal("Failed to send event to queue")
 	}
@@ -349,44 +396,6 @@ func TestHandler_ProcessEvents_ContextCancel(t *testing.T) {
 	// This is expected behavior
 }
 
-func TestGenerateEventID(t *testing.T) {
-	event := &model.ChangeEvent{
-		Operation:    "CREATE",
-		ResourceKind: "Deployment",
-		Name:         "test",
-		Timestamp:    time.Now(),
-	}
-
-	id1 := generateEventID(event)
-	if id1 == "" {
-		t.Error("generateEventID() returned empty string")
-	}
-
-	// Same event should produce same ID (if timestamp is same)
-	event2 := &model.ChangeEvent{
-		Operation:    "CREATE",
-		ResourceKind: "Deployment",
-		Name:         "test",
-		Timestamp:    event.Timestamp,
-	}
-	id2 := generateEventID(event2)
-	if id1 != id2 {
-		t.Error("Same event should produce same ID")
-	}
-
-	// Different operation should produce different ID
-	event3 := &model.ChangeEvent{
-		Operation:    "UPDATE",
-		ResourceKind: "Deployment",
-		Name:         "test",
-		Timestamp:    event.Timestamp,
-	}
-	id3 := generateEventID(event3)
-	if id1 == id3 {
-		t.Error("Different operations should produce different IDs")
-	}
-}
-
 // mockError is a simple error implementation for testing
 type mockError struct {
 	message string
@@ -415,7 +424,7 @@ func TestHandler_HandleAdmissionReview_QueueFull(t *testing.T) {
 	// Fill the queue
 	for i := 0; i < 1001; i++ {
 		select {
-		case handler.queue <- &model.ChangeEvent{ID: "test"}:
+		case handler.queue <- &queuedEvent{event: &model.ChangeEvent{ID: "test"}}:
 		default:
 			// Queue is full
 		}
@@ -487,6 +496,46 @@ func TestReadBody_NilBody(t *testing.T) {
 	}
 }
 
+func TestHandler_HandleAdmissionReview_BodyTooLarge(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	handler.maxBodySize = 64
+
+	rejected := oversizedRequests.Value()
+	body := []byte(`{"request":{"uid":"test-uid","object":{"data":"` + strings.Repeat("x", 128) + `"}}}`)
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Status code = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if oversizedRequests.Value() != rejected+1 {
+		t.Error("Oversized request should be counted in webhook_oversized_requests_total")
+	}
+	if len(handler.queue) != 0 {
+		t.Error("Oversized request should not be queued")
+	}
+}
+
+func TestParseMaxRequestSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+	}{
+		{"", 10 * 1024 * 1024},
+		{"0", 0},
+		{"2097152", 2097152},
+		{"-1", 10 * 1024 * 1024},
+		{"large", 10 * 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		if got := parseMaxRequestSize(tt.value); got != tt.expected {
+			t.Errorf("parseMaxRequestSize(%q) = %d, want %d", tt.value, got, tt.expected)
+		}
+	}
+}
+
 func TestHandler_SendErrorResponse(t *testing.T) {
 	handler := NewHandler(nil, nil, nil, nil)
 	w := httptest.NewRecorder()
@@ -926,3 +975,325 @@ func TestHandler_GetConfig_ThreadSafety(t *testing.T) {
 		t.Fatal("Block config should still be accessible after concurrent access")
 	}
 }
+
+func TestHandler_HandleAdmissionReview_DiffComputedAsync(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Update,
+			Kind:      metav1.GroupVersionKind{Kind: "Deployment"},
+			Namespace: "default",
+			Name:      "app",
+			UserInfo:  authenticationv1.UserInfo{Username: "user@example.com"},
+			OldObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"spec":{"replicas":1}}`)},
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"spec":{"replicas":3}}`)},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	// The response is sent before the worker has decoded the objects
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected 1 queued event, got %d", len(handler.queue))
+	}
+
+	handler.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if len(mockStore.savedEvents) != 1 {
+		t.Fatalf("Expected 1 saved event, got %d", len(mockStore.savedEvents))
+	}
+	if len(mockStore.savedEvents[0].Diff) == 0 {
+		t.Error("Saved event should have a diff computed by the worker")
+	}
+}
+
+func TestHandler_HandleAdmissionReview_OversizedObject(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	handler.maxObjectSize = 64
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	largeData := strings.Repeat("x", 128)
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Update,
+			Kind:      metav1.GroupVersionKind{Kind: "Secret"},
+			Namespace: "default",
+			Name:      "sh.helm.release.v1.app.v2",
+			UserInfo:  authenticationv1.UserInfo{Username: "user@example.com"},
+			OldObject: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"data":{"release":"a"}}`)},
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"},"data":{"release":"` + largeData + `"}}`)},
+		},
+	}
+	body, _ := json.Marshal(review)
+	before := oversizedObjects.Value()
+
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	handler.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if len(mockStore.savedEvents) != 1 {
+		t.Fatalf("Expected 1 saved event, got %d", len(mockStore.savedEvents))
+	}
+	event := mockStore.savedEvents[0]
+	if !event.SizeExceeded {
+		t.Error("Saved event should be marked as size exceeded")
+	}
+	if event.ObjectSize != len(review.Request.Object.Raw) {
+		t.Errorf("ObjectSize = %d, want %d", event.ObjectSize, len(review.Request.Object.Raw))
+	}
+	if event.Diff != nil {
+		t.Error("Oversized event should not have a diff")
+	}
+	if event.Name != "sh.helm.release.v1.app.v2" || event.ResourceKind != "Secret" {
+		t.Errorf("Oversized event should keep its metadata, got %s/%s", event.ResourceKind, event.Name)
+	}
+	if got := oversizedObjects.Value() - before; got != 1 {
+		t.Errorf("Oversized objects counter increased by %d, want 1", got)
+	}
+}
+
+func TestHandler_HandleAdmissionReview_BlockRule(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, &config.BlockConfig{
+		Rules: []config.BlockRule{{
+			Name:              "freeze-production",
+			NamespacePatterns: []string{"production"},
+			Owner:             "platform-team",
+			Reason:            "Quarterly release freeze",
+		}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Update,
+			Kind:      metav1.GroupVersionKind{Kind: "Deployment"},
+			Namespace: "production",
+			Name:      "app",
+			UserInfo:  authenticationv1.UserInfo{Username: "user@example.com"},
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"app"}}`)},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	var response admissionv1.AdmissionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Response.Allowed {
+		t.Fatal("Request matching a block rule should be denied")
+	}
+	expected := "Resource blocked by kubechronicle policy (rule: freeze-production, owner: platform-team, reason: Quarterly release freeze)"
+	if response.Response.Result.Message != expected {
+		t.Errorf("Denial message = %q, want %q", response.Response.Result.Message, expected)
+	}
+
+	handler.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if len(mockStore.savedEvents) != 1 {
+		t.Fatalf("Expected 1 saved event, got %d", len(mockStore.savedEvents))
+	}
+	event := mockStore.savedEvents[0]
+	if event.BlockRule == nil || event.BlockRule.Name != "freeze-production" || event.BlockRule.Owner != "platform-team" {
+		t.Errorf("Blocked event should record the block rule, got %+v", event.BlockRule)
+	}
+	if event.BlockPattern != "production" {
+		t.Errorf("BlockPattern = %q, want %q", event.BlockPattern, "production")
+	}
+}
+
+func TestHandler_HandleAdmissionReview_Warnings(t *testing.T) {
+	handler := NewHandler(nil, nil, &config.IgnoreConfig{NamespacePatterns: []string{"kube-*"}}, nil)
+	handler.SetWarnConfig(&config.WarnConfig{Rules: []config.WarnRule{
+		{Name: "freeze-notice", NamespacePatterns: []string{"payments"}, Message: "payments is frozen starting Friday"},
+		{Name: "owner-label", RequiredLabels: []string{"owner"}},
+	}})
+
+	tests := []struct {
+		name      string
+		namespace string
+		object    string
+		expected  []string
+	}{
+		{"both rules", "payments", `{"metadata":{"name":"app"}}`, []string{"payments is frozen starting Friday", "Missing required labels: owner"}},
+		{"label present", "payments", `{"metadata":{"name":"app","labels":{"owner":"team-a"}}}`, []string{"payments is frozen starting Friday"}},
+		{"no warnings", "default", `{"metadata":{"name":"app","labels":{"owner":"team-a"}}}`, nil},
+		{"ignored events still warn", "kube-system", `{"metadata":{"name":"app"}}`, []string{"Missing required labels: owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := &admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "admission.k8s.io/v1",
+					Kind:       "AdmissionReview",
+				},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "test-uid",
+					Operation: admissionv1.Create,
+					Kind:      metav1.GroupVersionKind{Kind: "Deployment"},
+					Namespace: tt.namespace,
+					Name:      "app",
+					Object:    runtime.RawExtension{Raw: []byte(tt.object)},
+				},
+			}
+			body, _ := json.Marshal(review)
+
+			w := httptest.NewRecorder()
+			handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+			var response admissionv1.AdmissionReview
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if !response.Response.Allowed {
+				t.Error("Warn rules should not deny the request")
+			}
+			if !reflect.DeepEqual(response.Response.Warnings, tt.expected) {
+				t.Errorf("Warnings = %q, want %q", response.Response.Warnings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHandler_RuntimeConfig(t *testing.T) {
+	ignoreConfig := &config.IgnoreConfig{NamespacePatterns: []string{"kube-*"}}
+	handler := NewHandler(nil, nil, ignoreConfig, nil)
+	handler.SetSamplingConfig(&config.SamplingConfig{Rules: []config.SamplingRule{{ResourceKindPatterns: []string{"Endpoints"}, Rate: 100}}})
+	handler.queue <- &queuedEvent{event: &model.ChangeEvent{}}
+
+	runtime := handler.RuntimeConfig()
+
+	if runtime.IgnoreConfig != ignoreConfig {
+		t.Error("RuntimeConfig should report the active ignore config")
+	}
+	if runtime.SamplingConfig == nil || runtime.SamplingConfig.Rules[0].Rate != 100 {
+		t.Errorf("RuntimeConfig should report the sampling config, got %+v", runtime.SamplingConfig)
+	}
+	if runtime.QueueLength != 1 || runtime.QueueCapacity != 1000 {
+		t.Errorf("Queue = %d/%d, want 1/1000", runtime.QueueLength, runtime.QueueCapacity)
+	}
+	if len(runtime.AlertChannels) != 0 {
+		t.Errorf("AlertChannels = %v, want none", runtime.AlertChannels)
+	}
+}
+
+func TestHandler_ReloadConfig_AlertConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	alertPath := filepath.Join(tmpDir, "ALERT_CONFIG")
+
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.configPath = tmpDir
+
+	// Valid config enables the channel
+	if err := os.WriteFile(alertPath, []byte(`{"slack": {"webhook_url": "https://hooks.slack.com/services/test"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write alert config: %v", err)
+	}
+	handler.reloadConfig()
+	if channels := handler.getAlertRouter().Channels(); len(channels) != 1 || channels[0] != "slack" {
+		t.Fatalf("Alert channels = %v, want [slack]", channels)
+	}
+	router := handler.getAlertRouter()
+
+	// Unchanged file keeps the same router
+	handler.reloadConfig()
+	if handler.getAlertRouter() != router {
+		t.Error("Alert router should not be rebuilt when the config is unchanged")
+	}
+
+	// Invalid config keeps the current channels
+	if err := os.WriteFile(alertPath, []byte(`{"email": {"smtp_host": "smtp.example.com", "to": ["a@example.com"]}}`), 0644); err != nil {
+		t.Fatalf("Failed to write alert config: %v", err)
+	}
+	handler.reloadConfig()
+	if handler.getAlertRouter() != router {
+		t.Error("Invalid alert config should keep the current router")
+	}
+
+	// A config without channels disables alerting
+	if err := os.WriteFile(alertPath, []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write alert config: %v", err)
+	}
+	handler.reloadConfig()
+	if handler.getAlertRouter() != nil {
+		t.Error("Alert config without channels should disable alerting")
+	}
+}
+
+func TestHandler_SetChaosConfig(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	handler.SetChaosConfig(&chaos.Config{StoreErrorRate: 1, QueueCapacity: 1})
+
+	if cap(handler.queue) != 1 {
+		t.Fatalf("queue capacity = %d, want 1", cap(handler.queue))
+	}
+	if handler.RuntimeConfig().Chaos == nil {
+		t.Error("RuntimeConfig should report the injected faults")
+	}
+
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Create,
+			Kind:      metav1.GroupVersionKind{Kind: "ConfigMap"},
+			UserInfo:  authenticationv1.UserInfo{Username: "user@example.com"},
+			Object:    runtime.RawExtension{Raw: []byte(`{"metadata": {"name": "test", "namespace": "default"}}`)},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	// The second request finds the shrunk queue full, and is still allowed
+	dropped := droppedEvents.Value()
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Status code = %d, want %d (fail-open)", w.Code, http.StatusOK)
+		}
+	}
+	if got := droppedEvents.Value() - dropped; got != 1 {
+		t.Errorf("dropped events = %d, want 1", got)
+	}
+
+	// The queued event fails to save
+	failed := storeErrors.Value()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.Start(ctx)
+	if !waitFor(t, func() bool { return storeErrors.Value()-failed == 1 }) {
+		t.Errorf("store errors = %d, want 1", storeErrors.Value()-failed)
+	}
+}
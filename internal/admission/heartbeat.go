@@ -0,0 +1,33 @@
+package admission
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// SetHeartbeat makes Start queue a heartbeat event from emitter every interval, which is
+// processed and saved like the changes admitted, so the delivery of events is measured end
+// to end. Heartbeats aren't alerted. Must be called before Start.
+func (h *Handler) SetHeartbeat(emitter *heartbeat.Emitter) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.heartbeat = emitter
+}
+
+// startHeartbeat queues heartbeats until ctx is done. A heartbeat dropped because the queue
+// is full is lost, like a change would be.
+func (h *Handler) startHeartbeat(ctx context.Context) {
+	h.heartbeat.Start(ctx, func(event *model.ChangeEvent) {
+		select {
+		case h.queue <- &queuedEvent{event: event}:
+			queueLength.Set(int64(len(h.queue)))
+		default:
+			droppedEvents.Add(1)
+			klog.Warningf("Event queue full, dropping heartbeat event: %s", event.ID)
+		}
+	})
+}
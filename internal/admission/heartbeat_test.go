@@ -0,0 +1,28 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestHandler_Heartbeat(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetHeartbeat(heartbeat.NewEmitter("webhook", "prod", 10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.startHeartbeat(ctx)
+
+	select {
+	case item := <-handler.queue:
+		if item.event.Operation != model.OperationHeartbeat || item.event.Heartbeat.Sequence != 1 {
+			t.Errorf("Expected the first heartbeat queued, got %+v", item.event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a heartbeat")
+	}
+}
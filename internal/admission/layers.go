@@ -0,0 +1,134 @@
+package admission
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+// patternLayer is an overlay pattern ConfigMap, e.g. a team's own ignore and block rules.
+// Overlays can only add rules: their ignore and block configs are checked alongside the
+// base configs, and only for the namespaces declared for the overlay.
+type patternLayer struct {
+	name         string
+	namespaces   []string // Namespace patterns the overlay's rules apply to; none applies them nowhere
+	ignoreConfig *config.IgnoreConfig
+	blockConfig  *config.BlockConfig
+	ignoreRaw    string // Last ignore config loaded, to detect changes
+	blockRaw     string // Last block config loaded, to detect changes
+}
+
+// PatternOverlay is the config of an overlay pattern ConfigMap, as reported by RuntimeConfig.
+type PatternOverlay struct {
+	Name         string               `json:"name"`
+	Namespaces   []string             `json:"namespaces"`
+	IgnoreConfig *config.IgnoreConfig `json:"ignore_config"`
+	BlockConfig  *config.BlockConfig  `json:"block_config"`
+}
+
+// getOverlay returns the overlay layer with the given name, or nil (caller holds configMutex).
+func (h *Handler) getOverlay(name string) *patternLayer {
+	for _, layer := range h.overlays {
+		if layer.name == name {
+			return layer
+		}
+	}
+	return nil
+}
+
+// applyOverlayData applies ignore and block config from an overlay ConfigMap's data.
+// Like applyConfigData, each config is only applied when its content changes, and invalid
+// content keeps the overlay's current config. Overlays cannot change alert config.
+func (h *Handler) applyOverlayData(name string, data map[string]string) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+
+	layer := h.getOverlay(name)
+	if layer == nil {
+		return
+	}
+
+	reloaded := false
+
+	raw := strings.TrimSpace(data["IGNORE_CONFIG"])
+	if raw != layer.ignoreRaw {
+		layer.ignoreRaw = raw
+		if raw == "" {
+			layer.ignoreConfig = nil
+			reloaded = true
+		} else if ignoreConfig, err := parseIgnoreConfig(raw); err == nil {
+			layer.ignoreConfig = ignoreConfig
+			klog.Infof("Reloaded ignore config from overlay %s: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v",
+				name, ignoreConfig.NamespacePatterns, ignoreConfig.NamePatterns, ignoreConfig.ResourceKindPatterns)
+			reloaded = true
+		} else {
+			klog.Errorf("Failed to parse ignore config from overlay %s, keeping its current patterns: %v", name, err)
+			configReloadErrors.Add(1)
+		}
+	}
+
+	raw = strings.TrimSpace(data["BLOCK_CONFIG"])
+	if raw != layer.blockRaw {
+		layer.blockRaw = raw
+		if raw == "" {
+			layer.blockConfig = nil
+			reloaded = true
+		} else if blockConfig, err := parseBlockConfig(raw); err == nil {
+			layer.blockConfig = blockConfig
+			klog.Infof("Reloaded block config from overlay %s: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v, operation_patterns=%v",
+				name, blockConfig.NamespacePatterns, blockConfig.NamePatterns, blockConfig.ResourceKindPatterns, blockConfig.OperationPatterns)
+			reloaded = true
+		} else {
+			klog.Errorf("Failed to parse block config from overlay %s, keeping its current patterns: %v", name, err)
+			configReloadErrors.Add(1)
+		}
+	}
+
+	if _, ok := data["ALERT_CONFIG"]; ok {
+		klog.V(2).Infof("Ignoring ALERT_CONFIG in overlay %s, alerts are only configured in the base ConfigMap", name)
+	}
+
+	if reloaded {
+		h.compileMatchers()
+		h.lastReload = time.Now()
+		configReloads.Add(1)
+	}
+}
+
+// compileMatchers recompiles the matchers from the base config and overlays (caller holds configMutex).
+// Overlay patterns only apply to the namespaces declared for the overlay.
+func (h *Handler) compileMatchers() {
+	ignoreMatcher := ignoreMatchers{newIgnoreMatcher(h.ignoreConfig)}
+	blockMatcher := blockMatchers{newBlockMatcher(h.blockConfig)}
+	for _, layer := range h.overlays {
+		// An overlay without declared namespaces gets an empty scope, which matches nothing
+		scope := compilePatterns(layer.namespaces)
+		if m := newIgnoreMatcher(layer.ignoreConfig); m != nil {
+			m.scope = scope
+			ignoreMatcher = append(ignoreMatcher, m)
+		}
+		if m := newBlockMatcher(layer.blockConfig); m != nil {
+			m.scope = scope
+			blockMatcher = append(blockMatcher, m)
+		}
+	}
+	h.ignoreMatcher = ignoreMatcher
+	h.blockMatcher = blockMatcher
+}
+
+// overlaySnapshot returns the current config of each overlay (caller holds configMutex).
+func (h *Handler) overlaySnapshot() []PatternOverlay {
+	var overlays []PatternOverlay
+	for _, layer := range h.overlays {
+		overlays = append(overlays, PatternOverlay{
+			Name:         layer.name,
+			Namespaces:   layer.namespaces,
+			IgnoreConfig: layer.ignoreConfig,
+			BlockConfig:  layer.blockConfig,
+		})
+	}
+	return overlays
+}
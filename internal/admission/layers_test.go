@@ -0,0 +1,127 @@
+package admission
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestHandler_ApplyOverlayData(t *testing.T) {
+	base := &config.BlockConfig{NamespacePatterns: []string{"production"}, OperationPatterns: []string{"DELETE"}, Message: "platform"}
+	handler := NewHandler(nil, nil, &config.IgnoreConfig{NamespacePatterns: []string{"kube-*"}}, base)
+	handler.SetConfigMapWatch(fake.NewSimpleClientset(), "default", "base", []string{"team-a", "team-b"},
+		map[string][]string{"team-a": {"team-a-*"}})
+
+	handler.applyOverlayData("team-a", map[string]string{
+		"IGNORE_CONFIG": `{"name_patterns": ["*-canary"]}`,
+		"BLOCK_CONFIG":  `{"namespace_patterns": ["production", "team-a-prod"], "operation_patterns": ["UPDATE", "DELETE"], "message": "team-a"}`,
+	})
+	// team-b declares no namespaces, so its patterns apply to none
+	handler.applyOverlayData("team-b", map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["*"]}`})
+	handler.applyOverlayData("unknown", map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["*"]}`})
+
+	ignoreMatcher, blockMatcher := handler.getMatchers()
+
+	tests := []struct {
+		name            string
+		event           *model.ChangeEvent
+		expectedIgnored bool
+		expectedBlocked bool
+		expectedMessage string
+	}{
+		{"base ignore", &model.ChangeEvent{Operation: "CREATE", Namespace: "kube-system", Name: "x"}, true, false, ""},
+		{"overlay ignore", &model.ChangeEvent{Operation: "CREATE", Namespace: "team-a-dev", Name: "web-canary"}, true, false, ""},
+		{"overlay ignore outside its namespaces", &model.ChangeEvent{Operation: "CREATE", Namespace: "default", Name: "web-canary"}, false, false, ""},
+		{"overlays without namespaces and unknown overlays ignored", &model.ChangeEvent{Operation: "CREATE", Namespace: "default", Name: "web"}, false, false, ""},
+		{"overlay without namespaces on cluster-scoped resources", &model.ChangeEvent{Operation: "CREATE", Name: "web"}, false, false, ""},
+		{"base block takes precedence", &model.ChangeEvent{Operation: "DELETE", Namespace: "production", Name: "web"}, false, true, "platform"},
+		{"overlay block outside its namespaces", &model.ChangeEvent{Operation: "UPDATE", Namespace: "production", Name: "web"}, false, false, ""},
+		{"overlay block", &model.ChangeEvent{Operation: "UPDATE", Namespace: "team-a-prod", Name: "web"}, false, true, "team-a"},
+		{"overlay operations do not extend base", &model.ChangeEvent{Operation: "CREATE", Namespace: "production", Name: "web"}, false, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ignored := ignoreMatcher.matches(tt.event); ignored != tt.expectedIgnored {
+				t.Errorf("ignored = %v, want %v", ignored, tt.expectedIgnored)
+			}
+			blocked, message := false, ""
+			if result := blockMatcher.match(tt.event); result != nil {
+				blocked, message = true, result.message
+			}
+			if blocked != tt.expectedBlocked || message != tt.expectedMessage {
+				t.Errorf("blocked = %v (%q), want %v (%q)", blocked, message, tt.expectedBlocked, tt.expectedMessage)
+			}
+		})
+	}
+
+	// Removing an overlay drops its patterns but keeps the base config
+	handler.applyOverlayData("team-a", nil)
+	ignoreMatcher, blockMatcher = handler.getMatchers()
+	if ignoreMatcher.matches(&model.ChangeEvent{Namespace: "team-a-dev", Name: "web-canary"}) {
+		t.Error("Removed overlay ignore patterns should not match")
+	}
+	if blockMatcher.match(&model.ChangeEvent{Operation: "UPDATE", Namespace: "team-a-prod"}) != nil {
+		t.Error("Removed overlay block patterns should not match")
+	}
+	if blockMatcher.match(&model.ChangeEvent{Operation: "DELETE", Namespace: "production"}) == nil {
+		t.Error("Base block config should still apply")
+	}
+
+	runtime := handler.RuntimeConfig()
+	if len(runtime.Overlays) != 2 || runtime.Overlays[0].Name != "team-a" || runtime.Overlays[1].Name != "team-b" ||
+		!reflect.DeepEqual(runtime.Overlays[0].Namespaces, []string{"team-a-*"}) {
+		t.Errorf("RuntimeConfig overlays = %+v, want team-a and team-b", runtime.Overlays)
+	}
+}
+
+func TestHandler_WatchConfigMap_Overlays(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "default"},
+			Data:       map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*"]}`},
+		},
+	)
+
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetConfigMapWatch(clientset, "default", "base", []string{"team-a"}, map[string][]string{"team-a": {"team-a-*"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.watchConfigMap(ctx)
+
+	// An overlay created later is picked up
+	_, err := clientset.CoreV1().ConfigMaps("default").Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "default"},
+		Data:       map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["team-a-dev"]}`},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create overlay ConfigMap: %v", err)
+	}
+
+	if !waitFor(t, func() bool {
+		ignoreMatcher, _ := handler.getMatchers()
+		return ignoreMatcher.matches(&model.ChangeEvent{Namespace: "kube-system"}) &&
+			ignoreMatcher.matches(&model.ChangeEvent{Namespace: "team-a-dev"})
+	}) {
+		t.Fatal("Base and overlay ignore patterns should both apply")
+	}
+
+	// Deleting the overlay removes its patterns
+	if err := clientset.CoreV1().ConfigMaps("default").Delete(ctx, "team-a", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete overlay ConfigMap: %v", err)
+	}
+	if !waitFor(t, func() bool {
+		ignoreMatcher, _ := handler.getMatchers()
+		return !ignoreMatcher.matches(&model.ChangeEvent{Namespace: "team-a-dev"})
+	}) {
+		t.Error("Deleted overlay patterns should no longer apply")
+	}
+}
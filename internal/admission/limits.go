@@ -0,0 +1,200 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// limitRangePodsTimeout bounds the listing of the pods of a LimitRange's namespace.
+const limitRangePodsTimeout = 10 * time.Second
+
+// quotaImpacts counts the ResourceQuota and LimitRange changes impacting their namespace.
+var quotaImpacts = expvar.NewInt("webhook_quota_impacts_total")
+
+// quotaImpact returns the resources of a ResourceQuota, given its raw old and new objects,
+// whose new hard limit is below their current usage when it wasn't before, or nil if there
+// are none. A new quota has no usage yet.
+func quotaImpact(oldRaw, newRaw []byte) *model.QuotaImpact {
+	if newRaw == nil {
+		return nil
+	}
+	var oldQuota, newQuota corev1.ResourceQuota
+	if err := decodeRaw(oldRaw, &oldQuota); err != nil {
+		klog.V(2).Infof("Failed to decode ResourceQuota to check its impact: %v", err)
+		return nil
+	}
+	if err := decodeRaw(newRaw, &newQuota); err != nil {
+		klog.V(2).Infof("Failed to decode ResourceQuota to check its impact: %v", err)
+		return nil
+	}
+	// The API server keeps the status of the quota on updates of its spec
+	used := newQuota.Status.Used
+	if len(used) == 0 {
+		used = oldQuota.Status.Used
+	}
+
+	impact := &model.QuotaImpact{}
+	var exceeded []string
+	for _, name := range resourceNames(newQuota.Spec.Hard) {
+		hard := newQuota.Spec.Hard[name]
+		usage, ok := used[name]
+		if !ok || hard.Cmp(usage) >= 0 {
+			continue
+		}
+		oldHard, limited := oldQuota.Spec.Hard[name]
+		if limited && oldHard.Cmp(usage) < 0 {
+			continue // Already exceeded
+		}
+		resource := model.QuotaResourceImpact{Resource: string(name), Hard: hard.String(), Used: usage.String()}
+		if limited {
+			resource.OldHard = oldHard.String()
+		}
+		impact.Resources = append(impact.Resources, resource)
+		exceeded = append(exceeded, string(name))
+	}
+	if len(impact.Resources) == 0 {
+		return nil
+	}
+	impact.Summary = strings.Join(exceeded, ", ") + " over quota"
+	quotaImpacts.Add(1)
+	return impact
+}
+
+// limitRangeChecker lists the pods of the namespace of the LimitRanges changed, to find the
+// containers outside their new limits.
+type limitRangeChecker struct {
+	client kubernetes.Interface
+}
+
+// SetLimitRangeImpact makes the event workers list the pods of the namespace of the
+// LimitRanges created or updated, recording the containers outside the new limits on the
+// events. Requires listing pods. Must be called before Start.
+func (h *Handler) SetLimitRangeImpact(clientset kubernetes.Interface) {
+	h.limitRanges = &limitRangeChecker{client: clientset}
+}
+
+// check records on event the containers of the running pods of its namespace outside the
+// limits of a LimitRange created or updated, that were within the old limits.
+func (c *limitRangeChecker) check(ctx context.Context, event *model.ChangeEvent, oldRaw, newRaw []byte) {
+	if event.ResourceKind != "LimitRange" || newRaw == nil || !event.Allowed {
+		return
+	}
+	var oldRange, newRange corev1.LimitRange
+	if err := decodeRaw(oldRaw, &oldRange); err != nil {
+		klog.V(2).Infof("Failed to decode LimitRange to check its impact: %v", err)
+		return
+	}
+	if err := decodeRaw(newRaw, &newRange); err != nil {
+		klog.V(2).Infof("Failed to decode LimitRange to check its impact: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limitRangePodsTimeout)
+	defer cancel()
+	pods, err := c.client.CoreV1().Pods(event.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("Failed to list the pods of namespace %s to check the impact of LimitRange %s: %v", event.Namespace, event.Name, err)
+		return
+	}
+	if impact := limitRangeImpact(&oldRange, &newRange, pods.Items); impact != nil {
+		event.QuotaImpact = impact
+		quotaImpacts.Add(1)
+	}
+}
+
+// limitRangeImpact returns the containers of pods outside the container limits of
+// newRange that were within those of oldRange, or nil if there are none.
+func limitRangeImpact(oldRange, newRange *corev1.LimitRange, pods []corev1.Pod) *model.QuotaImpact {
+	outside := map[string]bool{}
+	for _, violation := range containerViolations(oldRange, pods) {
+		outside[violationKey(violation)] = true
+	}
+
+	impact := &model.QuotaImpact{}
+	containers, impactedPods := map[string]bool{}, map[string]bool{}
+	for _, violation := range containerViolations(newRange, pods) {
+		if outside[violationKey(violation)] {
+			continue
+		}
+		containers[violation.Pod+"/"+violation.Container] = true
+		impactedPods[violation.Pod] = true
+		if len(impact.Containers) < model.MaxLimitRangeImpacts {
+			impact.Containers = append(impact.Containers, violation)
+		} else {
+			impact.Truncated = true
+		}
+	}
+	if len(impact.Containers) == 0 {
+		return nil
+	}
+	impact.Summary = fmt.Sprintf("%s of %s outside the limits", countOf(len(containers), "container"), countOf(len(impactedPods), "pod"))
+	return impact
+}
+
+// containerViolations returns the limits of the containers of pods above the maximum of the
+// container limits of limitRange, and their requests below its minimum. Pods that
+// terminated are left out.
+func containerViolations(limitRange *corev1.LimitRange, pods []corev1.Pod) []model.LimitRangeImpact {
+	var violations []model.LimitRangeImpact
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			for _, item := range limitRange.Spec.Limits {
+				if item.Type != corev1.LimitTypeContainer {
+					continue
+				}
+				for _, name := range resourceNames(item.Max) {
+					limit, ok := container.Resources.Limits[name]
+					if bound := item.Max[name]; ok && limit.Cmp(bound) > 0 {
+						violations = append(violations, model.LimitRangeImpact{Pod: pod.Name, Container: container.Name,
+							Resource: string(name), Constraint: "max", Value: limit.String(), Bound: bound.String()})
+					}
+				}
+				for _, name := range resourceNames(item.Min) {
+					request, ok := container.Resources.Requests[name]
+					if bound := item.Min[name]; ok && request.Cmp(bound) < 0 {
+						violations = append(violations, model.LimitRangeImpact{Pod: pod.Name, Container: container.Name,
+							Resource: string(name), Constraint: "min", Value: request.String(), Bound: bound.String()})
+					}
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// resourceNames returns the names of the resources of list, sorted.
+func resourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// violationKey identifies the limit a container violates, whatever its bound.
+func violationKey(v model.LimitRangeImpact) string {
+	return v.Pod + "/" + v.Container + "/" + v.Resource + "/" + v.Constraint
+}
+
+// countOf returns n with noun, plural unless n is 1, e.g. "3 pods".
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
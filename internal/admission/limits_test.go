@@ -0,0 +1,85 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestQuotaImpact(t *testing.T) {
+	oldQuota := `{"kind":"ResourceQuota","spec":{"hard":{"pods":"20","requests.cpu":"4","requests.memory":"4Gi"}},"status":{"used":{"pods":"12","requests.cpu":"5","requests.memory":"3Gi"}}}`
+	newQuota := `{"kind":"ResourceQuota","spec":{"hard":{"pods":"10","requests.cpu":"2","requests.memory":"8Gi","limits.cpu":"1"}},"status":{"used":{"pods":"12","requests.cpu":"5","requests.memory":"3Gi","limits.cpu":"2"}}}`
+
+	impact := quotaImpact([]byte(oldQuota), []byte(newQuota))
+	if impact == nil {
+		t.Fatal("Expected a quota impact")
+	}
+	// requests.cpu was already over quota
+	want := []model.QuotaResourceImpact{
+		{Resource: "limits.cpu", Hard: "1", Used: "2"},
+		{Resource: "pods", OldHard: "20", Hard: "10", Used: "12"},
+	}
+	if len(impact.Resources) != len(want) || impact.Resources[0] != want[0] || impact.Resources[1] != want[1] {
+		t.Errorf("Resources = %+v, want %+v", impact.Resources, want)
+	}
+	if impact.Summary != "limits.cpu, pods over quota" {
+		t.Errorf("Summary = %q", impact.Summary)
+	}
+
+	if impact := quotaImpact(nil, []byte(`{"spec":{"hard":{"pods":"1"}}}`)); impact != nil {
+		t.Errorf("Expected no impact for a new quota, got %+v", impact)
+	}
+	if impact := quotaImpact([]byte(newQuota), nil); impact != nil {
+		t.Errorf("Expected no impact for a deleted quota, got %+v", impact)
+	}
+}
+
+func testPod(name string, phase corev1.PodPhase, requests, limits corev1.ResourceList) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "payments"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}},
+		}},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestLimitRangeChecker_Check(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		testPod("api-1", corev1.PodRunning, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")}, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}),
+		testPod("api-2", corev1.PodRunning, nil, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}),
+		testPod("job-1", corev1.PodSucceeded, nil, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}),
+	)
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetLimitRangeImpact(clientset)
+
+	oldRange := `{"kind":"LimitRange","spec":{"limits":[{"type":"Container","max":{"memory":"4Gi"},"min":{"cpu":"100m"}}]}}`
+	newRange := `{"kind":"LimitRange","spec":{"limits":[{"type":"Container","max":{"memory":"1Gi"},"min":{"cpu":"100m"}},{"type":"Pod","max":{"memory":"256Mi"}}]}}`
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "LimitRange", Namespace: "payments", Name: "defaults", Allowed: true}
+	handler.limitRanges.check(context.Background(), event, []byte(oldRange), []byte(newRange))
+
+	// api-1 already requested less CPU than the minimum; job-1 terminated
+	impact := event.QuotaImpact
+	if impact == nil {
+		t.Fatal("Expected a LimitRange impact")
+	}
+	want := model.LimitRangeImpact{Pod: "api-1", Container: "app", Resource: "memory", Constraint: "max", Value: "2Gi", Bound: "1Gi"}
+	if len(impact.Containers) != 1 || impact.Containers[0] != want {
+		t.Errorf("Containers = %+v, want %+v", impact.Containers, want)
+	}
+	if impact.Summary != "1 container of 1 pod outside the limits" {
+		t.Errorf("Summary = %q", impact.Summary)
+	}
+
+	event = &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "LimitRange", Namespace: "payments", Allowed: true}
+	handler.limitRanges.check(context.Background(), event, []byte(oldRange), []byte(oldRange))
+	if event.QuotaImpact != nil {
+		t.Errorf("Expected no impact for an unchanged LimitRange, got %+v", event.QuotaImpact)
+	}
+}
@@ -0,0 +1,295 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Issue severities. A config with errors should not be applied.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// protectedNamespace is checked by LintPatterns: blocking changes in it can break the cluster.
+const protectedNamespace = "kube-system"
+
+// knownOperations are the operations an admission request can have.
+var knownOperations = []string{"CREATE", "UPDATE", "DELETE", "CONNECT"}
+
+// PatternIssue is a problem found in an ignore or block config.
+type PatternIssue struct {
+	Severity string `json:"severity"`
+	Config   string `json:"config"` // "ignore" or "block"
+	Field    string `json:"field"`  // e.g. "namespace_patterns"
+	Pattern  string `json:"pattern,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PatternMatches is the number of events a single pattern matched.
+type PatternMatches struct {
+	Config  string `json:"config"`
+	Field   string `json:"field"`
+	Pattern string `json:"pattern"`
+	Events  int    `json:"events"`
+}
+
+// patternField is one pattern list of a config, with the event value it is matched against.
+type patternField struct {
+	config   string
+	name     string
+	patterns []string
+	value    func(event *model.ChangeEvent) string
+}
+
+// ignoreFields returns the pattern lists of an ignore config.
+func ignoreFields(cfg *config.IgnoreConfig) []patternField {
+	if cfg == nil {
+		return nil
+	}
+	return []patternField{
+		{"ignore", "namespace_patterns", cfg.NamespacePatterns, func(e *model.ChangeEvent) string { return e.Namespace }},
+		{"ignore", "name_patterns", cfg.NamePatterns, func(e *model.ChangeEvent) string { return e.Name }},
+		{"ignore", "resource_kind_patterns", cfg.ResourceKindPatterns, func(e *model.ChangeEvent) string { return e.ResourceKind }},
+	}
+}
+
+// blockFields returns the pattern lists of a block config.
+func blockFields(cfg *config.BlockConfig) []patternField {
+	if cfg == nil {
+		return nil
+	}
+	return []patternField{
+		{"block", "namespace_patterns", cfg.NamespacePatterns, func(e *model.ChangeEvent) string { return e.Namespace }},
+		{"block", "name_patterns", cfg.NamePatterns, func(e *model.ChangeEvent) string { return e.Name }},
+		{"block", "resource_kind_patterns", cfg.ResourceKindPatterns, func(e *model.ChangeEvent) string { return e.ResourceKind }},
+	}
+}
+
+// LintPatterns checks ignore and block configs for invalid patterns, overlapping
+// rules, and block rules that would affect kube-system.
+func LintPatterns(ignoreConfig *config.IgnoreConfig, blockConfig *config.BlockConfig) []PatternIssue {
+	issues := []PatternIssue{}
+
+	for _, field := range ignoreFields(ignoreConfig) {
+		issues = append(issues, lintField(field)...)
+		for _, p := range field.patterns {
+			if strings.Trim(p, "*") == "" && p != "" {
+				issues = append(issues, PatternIssue{SeverityWarning, field.config, field.name, p, "pattern matches everything, so every event is ignored"})
+			}
+		}
+	}
+
+	for _, field := range blockFields(blockConfig) {
+		issues = append(issues, lintField(field)...)
+
+		// Block rules match on any field, so name and kind patterns apply in every namespace
+		if field.name == "namespace_patterns" {
+			for _, p := range field.patterns {
+				if compilePattern(p).match(protectedNamespace) {
+					issues = append(issues, PatternIssue{SeverityError, field.config, field.name, p, fmt.Sprintf("pattern would block changes in %s", protectedNamespace)})
+				}
+			}
+		} else if len(field.patterns) > 0 {
+			issues = append(issues, PatternIssue{SeverityWarning, field.config, field.name, "", fmt.Sprintf("patterns apply in every namespace, including %s", protectedNamespace)})
+		}
+
+		// Block rules are checked before ignore rules, so overlapping ignore patterns have no effect
+		for _, ignoreField := range ignoreFields(ignoreConfig) {
+			if ignoreField.name != field.name {
+				continue
+			}
+			for _, p := range field.patterns {
+				for _, ip := range ignoreField.patterns {
+					if covers(p, ip) || covers(ip, p) {
+						issues = append(issues, PatternIssue{SeverityWarning, field.config, field.name, p, fmt.Sprintf("overlaps ignore pattern %q; block rules are checked first, so matching events are blocked", ip)})
+					}
+				}
+			}
+		}
+	}
+
+	if blockConfig != nil {
+		for _, op := range blockConfig.OperationPatterns {
+			if !isKnownOperation(op) {
+				issues = append(issues, PatternIssue{SeverityError, "block", "operation_patterns", op, fmt.Sprintf("unknown operation, must be one of %s", strings.Join(knownOperations, ", "))})
+			}
+		}
+		for i, rule := range blockConfig.Rules {
+			issues = append(issues, lintBlockRule(i, rule)...)
+		}
+	}
+
+	return issues
+}
+
+// blockRuleFields returns the pattern lists of a structured block rule, named e.g. "rules[0].name_patterns".
+func blockRuleFields(i int, rule config.BlockRule) []patternField {
+	prefix := fmt.Sprintf("rules[%d].", i)
+	return []patternField{
+		{"block", prefix + "namespace_patterns", rule.NamespacePatterns, func(e *model.ChangeEvent) string { return e.Namespace }},
+		{"block", prefix + "name_patterns", rule.NamePatterns, func(e *model.ChangeEvent) string { return e.Name }},
+		{"block", prefix + "resource_kind_patterns", rule.ResourceKindPatterns, func(e *model.ChangeEvent) string { return e.ResourceKind }},
+	}
+}
+
+// lintBlockRule checks a structured block rule. Rule patterns must all match, so only
+// the namespace patterns decide whether the rule can affect kube-system.
+func lintBlockRule(i int, rule config.BlockRule) []PatternIssue {
+	var issues []PatternIssue
+	field := fmt.Sprintf("rules[%d]", i)
+	issue := func(severity, message string) {
+		issues = append(issues, PatternIssue{severity, "block", field, "", message})
+	}
+
+	fields := blockRuleFields(i, rule)
+	hasPatterns := false
+	for _, f := range fields {
+		issues = append(issues, lintField(f)...)
+		hasPatterns = hasPatterns || len(f.patterns) > 0
+	}
+	if !hasPatterns {
+		issue(SeverityError, "rule has no patterns and never matches")
+		return issues
+	}
+
+	if len(rule.NamespacePatterns) == 0 {
+		issue(SeverityWarning, fmt.Sprintf("rule applies in every namespace, including %s", protectedNamespace))
+	}
+	for _, p := range rule.NamespacePatterns {
+		if compilePattern(p).match(protectedNamespace) {
+			issues = append(issues, PatternIssue{SeverityError, "block", fields[0].name, p, fmt.Sprintf("pattern would block changes in %s", protectedNamespace)})
+		}
+	}
+
+	for _, op := range rule.OperationPatterns {
+		if !isKnownOperation(op) {
+			issues = append(issues, PatternIssue{SeverityError, "block", field + ".operation_patterns", op, fmt.Sprintf("unknown operation, must be one of %s", strings.Join(knownOperations, ", "))})
+		}
+	}
+
+	if rule.Owner == "" {
+		issue(SeverityWarning, "rule has no owner")
+	}
+	if rule.Expired(time.Now()) {
+		issue(SeverityWarning, fmt.Sprintf("rule expired at %s and no longer blocks anything", rule.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+	if rule.StartsAt != nil && rule.ExpiresAt != nil && !rule.StartsAt.Before(*rule.ExpiresAt) {
+		issue(SeverityError, "rule starts at or after it expires and never blocks anything")
+	}
+	return issues
+}
+
+// lintField checks a single pattern list for invalid and overlapping patterns.
+func lintField(field patternField) []PatternIssue {
+	var issues []PatternIssue
+	for i, p := range field.patterns {
+		issue := func(severity, message string) {
+			issues = append(issues, PatternIssue{severity, field.config, field.name, p, message})
+		}
+
+		switch {
+		case strings.TrimSpace(p) == "":
+			issue(SeverityError, "empty pattern")
+			continue
+		case strings.TrimSpace(p) != p:
+			issue(SeverityError, "pattern has leading or trailing whitespace and will not match")
+		}
+		if strings.ContainsAny(p, "?[]") {
+			issue(SeverityWarning, "only * is a wildcard; ?, [ and ] are matched literally")
+		}
+		if strings.Contains(p, "**") {
+			issue(SeverityWarning, "consecutive wildcards are redundant")
+		}
+
+		for j, other := range field.patterns {
+			if i == j {
+				continue
+			}
+			if p == other {
+				if j < i {
+					issue(SeverityWarning, "duplicate pattern")
+				}
+			} else if covers(other, p) {
+				issue(SeverityWarning, fmt.Sprintf("already covered by pattern %q", other))
+			}
+		}
+	}
+	return issues
+}
+
+// covers reports whether every value matched by pattern b is also matched by pattern a.
+// This holds if a matches b with b's wildcards taken literally.
+func covers(a, b string) bool {
+	return compilePattern(a).match(b)
+}
+
+// isKnownOperation reports whether op is an admission operation (case-insensitive).
+func isKnownOperation(op string) bool {
+	for _, known := range knownOperations {
+		if strings.EqualFold(op, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountMatches counts, for each pattern, how many of the events it matches.
+// Block patterns only count events whose operation matches the block config's operations.
+func CountMatches(ignoreConfig *config.IgnoreConfig, blockConfig *config.BlockConfig, events []*model.ChangeEvent) []PatternMatches {
+	matches := []PatternMatches{}
+
+	fields := ignoreFields(ignoreConfig)
+	fields = append(fields, blockFields(blockConfig)...)
+	for _, field := range fields {
+		for _, p := range field.patterns {
+			compiled := compilePattern(p)
+			count := 0
+			for _, event := range events {
+				if field.config == "block" && !matchesOperation(blockConfig.OperationPatterns, event.Operation) {
+					continue
+				}
+				if compiled.match(field.value(event)) {
+					count++
+				}
+			}
+			matches = append(matches, PatternMatches{field.config, field.name, p, count})
+		}
+	}
+
+	if blockConfig != nil {
+		for i, rule := range blockConfig.Rules {
+			for _, field := range blockRuleFields(i, rule) {
+				for _, p := range field.patterns {
+					compiled := compilePattern(p)
+					count := 0
+					for _, event := range events {
+						if matchesOperation(rule.OperationPatterns, event.Operation) && compiled.match(field.value(event)) {
+							count++
+						}
+					}
+					matches = append(matches, PatternMatches{field.config, field.name, p, count})
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// matchesOperation reports whether op is in operations. No operations matches everything.
+func matchesOperation(operations []string, op string) bool {
+	if len(operations) == 0 {
+		return true
+	}
+	for _, o := range operations {
+		if strings.EqualFold(o, op) {
+			return true
+		}
+	}
+	return false
+}
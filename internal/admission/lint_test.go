@@ -0,0 +1,171 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// hasIssue reports whether issues contain an issue for the given config, field, pattern and severity.
+func hasIssue(issues []PatternIssue, severity, cfg, field, pattern string) bool {
+	for _, issue := range issues {
+		if issue.Severity == severity && issue.Config == cfg && issue.Field == field && issue.Pattern == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLintPatterns(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name     string
+		ignore   *config.IgnoreConfig
+		block    *config.BlockConfig
+		severity string
+		cfg      string
+		field    string
+		pattern  string
+	}{
+		{
+			name:     "empty pattern",
+			ignore:   &config.IgnoreConfig{NamespacePatterns: []string{""}},
+			severity: SeverityError, cfg: "ignore", field: "namespace_patterns", pattern: "",
+		},
+		{
+			name:     "whitespace",
+			ignore:   &config.IgnoreConfig{NamePatterns: []string{"test-* "}},
+			severity: SeverityError, cfg: "ignore", field: "name_patterns", pattern: "test-* ",
+		},
+		{
+			name:     "unsupported wildcard",
+			ignore:   &config.IgnoreConfig{NamePatterns: []string{"test-?"}},
+			severity: SeverityWarning, cfg: "ignore", field: "name_patterns", pattern: "test-?",
+		},
+		{
+			name:     "redundant wildcards",
+			ignore:   &config.IgnoreConfig{NamePatterns: []string{"test-**"}},
+			severity: SeverityWarning, cfg: "ignore", field: "name_patterns", pattern: "test-**",
+		},
+		{
+			name:     "duplicate pattern",
+			ignore:   &config.IgnoreConfig{NamespacePatterns: []string{"default", "default"}},
+			severity: SeverityWarning, cfg: "ignore", field: "namespace_patterns", pattern: "default",
+		},
+		{
+			name:     "covered pattern",
+			ignore:   &config.IgnoreConfig{NamespacePatterns: []string{"kube-*", "kube-system"}},
+			severity: SeverityWarning, cfg: "ignore", field: "namespace_patterns", pattern: "kube-system",
+		},
+		{
+			name:     "ignore everything",
+			ignore:   &config.IgnoreConfig{ResourceKindPatterns: []string{"*"}},
+			severity: SeverityWarning, cfg: "ignore", field: "resource_kind_patterns", pattern: "*",
+		},
+		{
+			name:     "block kube-system",
+			block:    &config.BlockConfig{NamespacePatterns: []string{"kube-*"}},
+			severity: SeverityError, cfg: "block", field: "namespace_patterns", pattern: "kube-*",
+		},
+		{
+			name:     "block kind in all namespaces",
+			block:    &config.BlockConfig{ResourceKindPatterns: []string{"Secret"}},
+			severity: SeverityWarning, cfg: "block", field: "resource_kind_patterns", pattern: "",
+		},
+		{
+			name:     "block overlaps ignore",
+			ignore:   &config.IgnoreConfig{NamespacePatterns: []string{"prod-*"}},
+			block:    &config.BlockConfig{NamespacePatterns: []string{"prod-payments"}},
+			severity: SeverityWarning, cfg: "block", field: "namespace_patterns", pattern: "prod-payments",
+		},
+		{
+			name:     "unknown operation",
+			block:    &config.BlockConfig{NamespacePatterns: []string{"production"}, OperationPatterns: []string{"REMOVE"}},
+			severity: SeverityError, cfg: "block", field: "operation_patterns", pattern: "REMOVE",
+		},
+		{
+			name:     "rule without patterns",
+			block:    &config.BlockConfig{Rules: []config.BlockRule{{Name: "empty", Owner: "platform"}}},
+			severity: SeverityError, cfg: "block", field: "rules[0]", pattern: "",
+		},
+		{
+			name:     "rule blocks kube-system",
+			block:    &config.BlockConfig{Rules: []config.BlockRule{{NamespacePatterns: []string{"*"}, ResourceKindPatterns: []string{"Secret"}, Owner: "platform"}}},
+			severity: SeverityError, cfg: "block", field: "rules[0].namespace_patterns", pattern: "*",
+		},
+		{
+			name:     "rule without owner",
+			block:    &config.BlockConfig{Rules: []config.BlockRule{{NamespacePatterns: []string{"production"}}}},
+			severity: SeverityWarning, cfg: "block", field: "rules[0]", pattern: "",
+		},
+		{
+			name:     "expired rule",
+			block:    &config.BlockConfig{Rules: []config.BlockRule{{NamespacePatterns: []string{"production"}, Owner: "platform", ExpiresAt: &past}}},
+			severity: SeverityWarning, cfg: "block", field: "rules[0]", pattern: "",
+		},
+		{
+			name:     "rule starting after it expires",
+			block:    &config.BlockConfig{Rules: []config.BlockRule{{NamespacePatterns: []string{"production"}, Owner: "platform", StartsAt: &future, ExpiresAt: &past}}},
+			severity: SeverityError, cfg: "block", field: "rules[0]", pattern: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := LintPatterns(tt.ignore, tt.block)
+			if !hasIssue(issues, tt.severity, tt.cfg, tt.field, tt.pattern) {
+				t.Errorf("LintPatterns() = %+v, want %s issue for %s %s %q", issues, tt.severity, tt.cfg, tt.field, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestLintPatterns_Clean(t *testing.T) {
+	issues := LintPatterns(
+		&config.IgnoreConfig{NamespacePatterns: []string{"kube-*", "cert-manager"}, NamePatterns: []string{"*-controller"}},
+		&config.BlockConfig{
+			NamespacePatterns: []string{"production"},
+			OperationPatterns: []string{"delete"},
+			Rules:             []config.BlockRule{{NamespacePatterns: []string{"payments"}, ResourceKindPatterns: []string{"Secret"}, Owner: "payments-team"}},
+		},
+	)
+	if len(issues) != 0 {
+		t.Errorf("LintPatterns() = %+v, want no issues", issues)
+	}
+
+	if issues := LintPatterns(nil, nil); len(issues) != 0 {
+		t.Errorf("LintPatterns(nil, nil) = %+v, want no issues", issues)
+	}
+}
+
+func TestCountMatches(t *testing.T) {
+	events := []*model.ChangeEvent{
+		{Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "kube-system", Name: "coredns"},
+		{Operation: "DELETE", ResourceKind: "Deployment", Namespace: "production", Name: "api"},
+		{Operation: "UPDATE", ResourceKind: "ConfigMap", Namespace: "production", Name: "api-config"},
+		{Operation: "UPDATE", ResourceKind: "Lease", Namespace: "kube-node-lease", Name: "node-1"},
+	}
+
+	matches := CountMatches(
+		&config.IgnoreConfig{NamespacePatterns: []string{"kube-*"}, ResourceKindPatterns: []string{"Lease"}},
+		&config.BlockConfig{NamespacePatterns: []string{"production"}, OperationPatterns: []string{"DELETE"}},
+		events,
+	)
+
+	want := []PatternMatches{
+		{"ignore", "namespace_patterns", "kube-*", 2},
+		{"ignore", "resource_kind_patterns", "Lease", 1},
+		{"block", "namespace_patterns", "production", 1}, // Only the DELETE
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("CountMatches() = %+v, want %+v", matches, want)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("CountMatches()[%d] = %+v, want %+v", i, matches[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// defaultBlockMessage is returned when a block config has no message.
+const defaultBlockMessage = "Resource blocked by kubechronicle policy"
+
+// pattern is a wildcard pattern pre-split on '*' so matching needs no backtracking.
+type pattern struct {
+	raw      string
+	segments []string // Literal parts between wildcards; a single segment means no wildcard
+}
+
+// compilePattern compiles a pattern where * matches any sequence of characters.
+func compilePattern(raw string) pattern {
+	return pattern{
+		raw:      raw,
+		segments: strings.Split(raw, "*"),
+	}
+}
+
+// match reports whether s matches the pattern.
+// The first segment must be a prefix and the last a suffix; the segments in between
+// are matched greedily left to right, which is sufficient because * matches anything.
+func (p pattern) match(s string) bool {
+	if len(p.segments) == 1 {
+		return s == p.raw
+	}
+
+	first, last := p.segments[0], p.segments[len(p.segments)-1]
+	if len(s) < len(first)+len(last) || !strings.HasPrefix(s, first) || !strings.HasSuffix(s, last) {
+		return false
+	}
+
+	middle := s[len(first) : len(s)-len(last)]
+	for _, segment := range p.segments[1 : len(p.segments)-1] {
+		idx := strings.Index(middle, segment)
+		if idx < 0 {
+			return false
+		}
+		middle = middle[idx+len(segment):]
+	}
+	return true
+}
+
+// patternSet is an ordered list of compiled patterns.
+type patternSet []pattern
+
+// compilePatterns compiles a list of patterns, preserving their order.
+func compilePatterns(raw []string) patternSet {
+	set := make(patternSet, len(raw))
+	for i, p := range raw {
+		set[i] = compilePattern(p)
+	}
+	return set
+}
+
+// contains reports whether a scope includes the namespace. A nil scope includes every
+// namespace, including "" for cluster-scoped resources; an empty one includes none.
+func (ps patternSet) contains(namespace string) bool {
+	if ps == nil {
+		return true
+	}
+	_, ok := ps.match(namespace)
+	return ok
+}
+
+// match returns the first pattern that matches s.
+func (ps patternSet) match(s string) (string, bool) {
+	for _, p := range ps {
+		if p.match(s) {
+			return p.raw, true
+		}
+	}
+	return "", false
+}
+
+// ignoreMatcher is a pre-compiled IgnoreConfig.
+type ignoreMatcher struct {
+	namespaces    patternSet
+	names         patternSet
+	resourceKinds patternSet
+	scope         patternSet // Namespaces an overlay's patterns apply to; nil applies them everywhere
+}
+
+// newIgnoreMatcher compiles an ignore config. A nil config yields a nil matcher, which ignores nothing.
+func newIgnoreMatcher(cfg *config.IgnoreConfig) *ignoreMatcher {
+	if cfg == nil {
+		return nil
+	}
+	return &ignoreMatcher{
+		namespaces:    compilePatterns(cfg.NamespacePatterns),
+		names:         compilePatterns(cfg.NamePatterns),
+		resourceKinds: compilePatterns(cfg.ResourceKindPatterns),
+	}
+}
+
+// matches reports whether the event matches any ignore pattern.
+func (m *ignoreMatcher) matches(event *model.ChangeEvent) bool {
+	if m == nil || !m.scope.contains(event.Namespace) {
+		return false
+	}
+	if _, ok := m.namespaces.match(event.Namespace); ok {
+		return true
+	}
+	if _, ok := m.names.match(event.Name); ok {
+		return true
+	}
+	_, ok := m.resourceKinds.match(event.ResourceKind)
+	return ok
+}
+
+// ignoreMatchers are the ignore matchers of each pattern layer, base config first.
+type ignoreMatchers []*ignoreMatcher
+
+// matches reports whether any layer ignores the event.
+func (ms ignoreMatchers) matches(event *model.ChangeEvent) bool {
+	for _, m := range ms {
+		if m.matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockMatcher is a pre-compiled BlockConfig.
+type blockMatcher struct {
+	operations    []string
+	namespaces    patternSet
+	names         patternSet
+	resourceKinds patternSet
+	message       string
+	messages      map[string]string // Translations of message by locale
+	rules         []*blockRuleMatcher
+	scope         patternSet // Namespaces an overlay's patterns apply to; nil applies them everywhere
+}
+
+// blockRuleMatcher is a pre-compiled structured BlockRule.
+type blockRuleMatcher struct {
+	rule          config.BlockRule
+	namespaces    patternSet
+	names         patternSet
+	resourceKinds patternSet
+	message       string
+	messages      map[string]string // Translations of message by locale
+}
+
+// blockMatch is the result of a blocked event: the matching pattern, the denial message,
+// and the structured rule that matched, if any.
+type blockMatch struct {
+	pattern string
+	message string
+	rule    *model.BlockRule
+
+	// What the message is localized from: the configured message and its translations, and
+	// the rule whose metadata is appended. Unset for plugin denials, which aren't localized.
+	configured   string
+	translations map[string]string
+	blockRule    *config.BlockRule
+}
+
+// newBlockMatcher compiles a block config. A nil config yields a nil matcher, which blocks nothing.
+func newBlockMatcher(cfg *config.BlockConfig) *blockMatcher {
+	if cfg == nil {
+		return nil
+	}
+	message := cfg.Message
+	if message == "" {
+		message = defaultBlockMessage
+	}
+
+	rules := make([]*blockRuleMatcher, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		ruleMessage, ruleMessages := rule.Message, rule.Messages
+		if ruleMessage == "" {
+			ruleMessage = message
+			if len(ruleMessages) == 0 {
+				ruleMessages = cfg.Messages
+			}
+		}
+		rules = append(rules, &blockRuleMatcher{
+			rule:          rule,
+			namespaces:    compilePatterns(rule.NamespacePatterns),
+			names:         compilePatterns(rule.NamePatterns),
+			resourceKinds: compilePatterns(rule.ResourceKindPatterns),
+			message:       ruleMessage,
+			messages:      ruleMessages,
+		})
+	}
+
+	return &blockMatcher{
+		operations:    cfg.OperationPatterns,
+		namespaces:    compilePatterns(cfg.NamespacePatterns),
+		names:         compilePatterns(cfg.NamePatterns),
+		resourceKinds: compilePatterns(cfg.ResourceKindPatterns),
+		message:       message,
+		messages:      cfg.Messages,
+		rules:         rules,
+	}
+}
+
+// match returns how the event is blocked, or nil if it is not.
+// Patterns are checked in order: namespace, name, then resource kind, followed by the
+// structured rules that have not expired.
+func (m *blockMatcher) match(event *model.ChangeEvent) *blockMatch {
+	if m == nil || !m.scope.contains(event.Namespace) {
+		return nil
+	}
+
+	// If operation_patterns is empty, all operations are considered
+	if matchesOperation(m.operations, event.Operation) {
+		if p, ok := m.namespaces.match(event.Namespace); ok {
+			return m.patternMatch(p)
+		}
+		if p, ok := m.names.match(event.Name); ok {
+			return m.patternMatch(p)
+		}
+		if p, ok := m.resourceKinds.match(event.ResourceKind); ok {
+			return m.patternMatch(p)
+		}
+	}
+
+	now := time.Now()
+	for _, r := range m.rules {
+		if !r.rule.Active(now) {
+			continue
+		}
+		if p, ok := r.match(event); ok {
+			return &blockMatch{
+				pattern: p,
+				message: formatBlockMessage(nil, r.message, &r.rule),
+				rule: &model.BlockRule{
+					Name:      r.rule.Name,
+					Owner:     r.rule.Owner,
+					Reason:    r.rule.Reason,
+					TicketURL: r.rule.TicketURL,
+					ExpiresAt: r.rule.ExpiresAt,
+				},
+				configured:   r.message,
+				translations: r.messages,
+				blockRule:    &r.rule,
+			}
+		}
+	}
+	return nil
+}
+
+// patternMatch returns the result of an event blocked by pattern p.
+func (m *blockMatcher) patternMatch(p string) *blockMatch {
+	return &blockMatch{pattern: p, message: m.message, configured: m.message, translations: m.messages}
+}
+
+// localized returns the denial message in the locale of l: the translation of the configured
+// message for the locale, else the catalog's default message when none was configured, else
+// the configured message, followed by the rule's metadata with translated labels.
+func (b *blockMatch) localized(l *i18n.Localizer) string {
+	if b.configured == "" {
+		return b.message
+	}
+	message, ok := l.Select(b.translations)
+	if !ok {
+		message = b.configured
+		if message == defaultBlockMessage {
+			message = l.Message(i18n.BlockMessage)
+		}
+	}
+	if b.blockRule == nil {
+		return message
+	}
+	return formatBlockMessage(l, message, b.blockRule)
+}
+
+// match reports whether the event matches all of the rule's non-empty pattern lists and
+// operations, returning the first pattern that matched. A rule without patterns matches nothing.
+func (r *blockRuleMatcher) match(event *model.ChangeEvent) (string, bool) {
+	if !matchesOperation(r.rule.OperationPatterns, event.Operation) {
+		return "", false
+	}
+
+	matched := ""
+	for _, check := range []struct {
+		patterns patternSet
+		value    string
+	}{
+		{r.namespaces, event.Namespace},
+		{r.names, event.Name},
+		{r.resourceKinds, event.ResourceKind},
+	} {
+		if len(check.patterns) == 0 {
+			continue
+		}
+		p, ok := check.patterns.match(check.value)
+		if !ok {
+			return "", false
+		}
+		if matched == "" {
+			matched = p
+		}
+	}
+	return matched, matched != ""
+}
+
+// formatBlockMessage appends the rule's metadata to the denial message, e.g.
+// "Production is frozen (rule: freeze, owner: platform, ticket: https://...)", with the
+// labels of l's locale.
+func formatBlockMessage(l *i18n.Localizer, message string, rule *config.BlockRule) string {
+	var details []string
+	add := func(label, value string) {
+		if value != "" {
+			details = append(details, l.Message(label)+": "+value)
+		}
+	}
+	add(i18n.BlockRule, rule.Name)
+	add(i18n.BlockOwner, rule.Owner)
+	add(i18n.BlockReason, rule.Reason)
+	add(i18n.BlockTicket, rule.TicketURL)
+	if rule.ExpiresAt != nil {
+		add(i18n.BlockExpires, rule.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	if len(details) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s (%s)", message, strings.Join(details, ", "))
+}
+
+// blockMatchers are the block matchers of each pattern layer, base config first.
+type blockMatchers []*blockMatcher
+
+// match returns the result of the first layer that blocks the event, so the
+// base config's message takes precedence over overlays.
+func (ms blockMatchers) match(event *model.ChangeEvent) *blockMatch {
+	for _, m := range ms {
+		if result := m.match(event); result != nil {
+			return result
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestCompilePattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"default", "default", true},
+		{"default", "default2", false},
+		{"kube-*", "kube-system", true},
+		{"*-prod", "payments-prod", true},
+		{"*system*", "kube-system-x", true},
+		{"a*a", "a", false}, // Prefix and suffix must not overlap
+		{"a*a", "aa", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxcyyb", false},
+		{"**", "", true},
+		{"*", "", true},
+		{"", "", true},
+		{"", "x", false},
+	}
+
+	for _, tt := range tests {
+		if got := compilePattern(tt.pattern).match(tt.s); got != tt.want {
+			t.Errorf("compilePattern(%q).match(%q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestBlockMatcher_FirstPatternWins(t *testing.T) {
+	m := newBlockMatcher(&config.BlockConfig{
+		NamespacePatterns: []string{"prod*", "production"},
+	})
+
+	blocked := m.match(&model.ChangeEvent{Namespace: "production"})
+	if blocked == nil || blocked.pattern != "prod*" {
+		t.Fatalf("match() = %+v, want pattern %q", blocked, "prod*")
+	}
+	if blocked.message != defaultBlockMessage {
+		t.Errorf("message = %q, want default message", blocked.message)
+	}
+}
+
+func TestBlockMatcher_Rules(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	past := time.Now().Add(-time.Hour)
+	m := newBlockMatcher(&config.BlockConfig{
+		NamespacePatterns: []string{"legacy"},
+		Message:           "Blocked",
+		Rules: []config.BlockRule{
+			{
+				Name:                 "freeze-payments",
+				NamespacePatterns:    []string{"payments-*"},
+				ResourceKindPatterns: []string{"Deployment"},
+				OperationPatterns:    []string{"UPDATE"},
+				Message:              "Payments are frozen",
+				Owner:                "payments-team",
+				TicketURL:            "https://tickets.example.com/CHG-1",
+				ExpiresAt:            &expires,
+			},
+			{Name: "expired", NamespacePatterns: []string{"staging"}, ExpiresAt: &past},
+			{Name: "scheduled", NamespacePatterns: []string{"search"}, StartsAt: &expires},
+			{Name: "no-patterns"},
+		},
+	})
+
+	tests := []struct {
+		name            string
+		event           *model.ChangeEvent
+		expectedPattern string
+		expectedMessage string
+		expectedRule    string
+	}{
+		{"legacy patterns first", &model.ChangeEvent{Operation: "UPDATE", Namespace: "legacy"}, "legacy", "Blocked", ""},
+		{"rule matches all patterns", &model.ChangeEvent{Operation: "UPDATE", Namespace: "payments-eu", ResourceKind: "Deployment"}, "payments-*",
+			"Payments are frozen (rule: freeze-payments, owner: payments-team, ticket: https://tickets.example.com/CHG-1, expires: 2030-01-01T00:00:00Z)", "freeze-payments"},
+		{"rule needs every pattern list", &model.ChangeEvent{Operation: "UPDATE", Namespace: "payments-eu", ResourceKind: "Secret"}, "", "", ""},
+		{"rule operations", &model.ChangeEvent{Operation: "DELETE", Namespace: "payments-eu", ResourceKind: "Deployment"}, "", "", ""},
+		{"expired rule", &model.ChangeEvent{Operation: "UPDATE", Namespace: "staging"}, "", "", ""},
+		{"rule not started", &model.ChangeEvent{Operation: "UPDATE", Namespace: "search"}, "", "", ""},
+		{"rule without patterns", &model.ChangeEvent{Operation: "UPDATE", Namespace: "default"}, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocked := m.match(tt.event)
+			if tt.expectedPattern == "" {
+				if blocked != nil {
+					t.Errorf("match() = %+v, want not blocked", blocked)
+				}
+				return
+			}
+			if blocked == nil {
+				t.Fatal("match() = nil, want blocked")
+			}
+			if blocked.pattern != tt.expectedPattern || blocked.message != tt.expectedMessage {
+				t.Errorf("match() = %q, %q, want %q, %q", blocked.pattern, blocked.message, tt.expectedPattern, tt.expectedMessage)
+			}
+			ruleName := ""
+			if blocked.rule != nil {
+				ruleName = blocked.rule.Name
+			}
+			if ruleName != tt.expectedRule {
+				t.Errorf("rule = %q, want %q", ruleName, tt.expectedRule)
+			}
+		})
+	}
+}
+
+func TestBlockMatch_Localized(t *testing.T) {
+	m := newBlockMatcher(&config.BlockConfig{
+		NamespacePatterns: []string{"legacy"},
+		Rules: []config.BlockRule{
+			{Name: "freeze", NamespacePatterns: []string{"payments"}, Owner: "payments-team"},
+			{
+				Name:              "quarterly",
+				NamespacePatterns: []string{"billing"},
+				Message:           "Billing is frozen",
+				Messages:          map[string]string{"de": "Abrechnung ist eingefroren"},
+			},
+		},
+	})
+	catalog := &i18n.Catalog{Messages: map[string]map[string]string{
+		"de": {i18n.BlockMessage: "Durch Richtlinie gesperrt", i18n.BlockRule: "Regel", i18n.BlockOwner: "Verantwortlich"},
+	}}
+
+	tests := []struct {
+		namespace, locale, want string
+	}{
+		{"legacy", "de", "Durch Richtlinie gesperrt"},
+		{"legacy", "en", defaultBlockMessage},
+		{"payments", "de", "Durch Richtlinie gesperrt (Regel: freeze, Verantwortlich: payments-team)"},
+		{"billing", "de-CH", "Abrechnung ist eingefroren (Regel: quarterly)"},
+		{"billing", "fr", "Billing is frozen (rule: quarterly)"},
+	}
+	for _, tt := range tests {
+		blocked := m.match(&model.ChangeEvent{Namespace: tt.namespace})
+		if blocked == nil {
+			t.Fatalf("%s: not blocked", tt.namespace)
+		}
+		if got := blocked.localized(catalog.Localizer(tt.locale)); got != tt.want {
+			t.Errorf("localized(%s) in %s = %q, want %q", tt.namespace, tt.locale, got, tt.want)
+		}
+	}
+
+	plugin := &blockMatch{pattern: "plugin:policy", message: "Denied by policy"}
+	if got := plugin.localized(catalog.Localizer("de")); got != "Denied by policy" {
+		t.Errorf("plugin denial localized = %q", got)
+	}
+}
+
+func TestNilMatchers(t *testing.T) {
+	event := &model.ChangeEvent{Namespace: "default"}
+	if newIgnoreMatcher(nil).matches(event) {
+		t.Error("nil ignore matcher should not ignore events")
+	}
+	if newBlockMatcher(nil).match(event) != nil {
+		t.Error("nil block matcher should not block events")
+	}
+}
+
+func TestParseLatencyBudget(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 100 * time.Millisecond},
+		{"250ms", 250 * time.Millisecond},
+		{"invalid", 100 * time.Millisecond},
+		{"-1s", 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := parseLatencyBudget(tt.value); got != tt.want {
+			t.Errorf("parseLatencyBudget(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseMaxObjectSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 1024 * 1024},
+		{"2097152", 2097152},
+		{"0", 0},
+		{"invalid", 1024 * 1024},
+		{"-1", 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		if got := parseMaxObjectSize(tt.value); got != tt.want {
+			t.Errorf("parseMaxObjectSize(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
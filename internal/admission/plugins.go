@@ -0,0 +1,178 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// pluginErrors counts plugin calls that returned an error and were skipped.
+var pluginErrors = expvar.NewInt("webhook_plugin_errors_total")
+
+// budgetSkips counts the optional stages of the admission path, post-decision plugins and
+// warn rules, skipped because the latency budget was spent.
+var budgetSkips = expvar.NewInt("webhook_budget_skipped_stages_total")
+
+// pluginPipeline holds the configured plugins by stage, in configuration order.
+type pluginPipeline struct {
+	names        []string
+	preDecision  []plugin.PreDecider
+	postDecision []plugin.PostDecider
+	prePersist   []plugin.PrePersister
+	enrich       []plugin.Enricher
+}
+
+// newPluginPipeline creates the plugins of configs. A nil pipeline runs no plugins.
+func newPluginPipeline(configs []config.PluginConfig) (*pluginPipeline, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+	p := &pluginPipeline{}
+	for _, cfg := range configs {
+		created, err := plugin.New(cfg.Name, cfg.Config)
+		if err != nil {
+			return nil, err
+		}
+		p.names = append(p.names, cfg.Name)
+		if stage, ok := created.(plugin.PreDecider); ok {
+			p.preDecision = append(p.preDecision, stage)
+		}
+		if stage, ok := created.(plugin.PostDecider); ok {
+			p.postDecision = append(p.postDecision, stage)
+		}
+		if stage, ok := created.(plugin.PrePersister); ok {
+			p.prePersist = append(p.prePersist, stage)
+		}
+		if stage, ok := created.(plugin.Enricher); ok {
+			p.enrich = append(p.enrich, stage)
+		}
+	}
+	return p, nil
+}
+
+// runPreDecision runs the pre-decision plugins until one denies the request, returning the
+// denial, if any, and the warnings of the plugins that ran.
+func (p *pluginPipeline) runPreDecision(ctx context.Context, req *admissionv1.AdmissionRequest, event *model.ChangeEvent) (*blockMatch, []string) {
+	if p == nil {
+		return nil, nil
+	}
+	var warnings []string
+	for _, stage := range p.preDecision {
+		decision, err := stage.PreDecision(ctx, req, event)
+		if err != nil {
+			pluginErrors.Add(1)
+			klog.Errorf("Plugin %s failed before the decision on %s %s/%s, skipping it: %v",
+				stage.Name(), event.Operation, event.ResourceKind, event.Name, err)
+			continue
+		}
+		if decision == nil {
+			continue
+		}
+		warnings = append(warnings, decision.Warnings...)
+		if !decision.Allowed {
+			message := decision.Message
+			if message == "" {
+				message = fmt.Sprintf("Request denied by kubechronicle plugin %s", stage.Name())
+			}
+			return &blockMatch{pattern: "plugin:" + stage.Name(), message: message}, warnings
+		}
+	}
+	return nil, warnings
+}
+
+// runPostDecision runs the post-decision plugins on the response to a request, adding the
+// warnings they append. Once ctx is done, the latency budget is spent and the remaining
+// plugins are skipped rather than delaying the response.
+func (p *pluginPipeline) runPostDecision(ctx context.Context, req *admissionv1.AdmissionRequest, event *model.ChangeEvent, response *admissionv1.AdmissionResponse) {
+	if p == nil {
+		return
+	}
+	decision := &plugin.Decision{Allowed: response.Allowed, Warnings: response.Warnings}
+	if response.Result != nil {
+		decision.Message = response.Result.Message
+	}
+	for i, stage := range p.postDecision {
+		if ctx.Err() != nil {
+			budgetSkips.Add(int64(len(p.postDecision) - i))
+			klog.V(2).Infof("Latency budget spent, skipping %d post-decision plugin(s) on %s %s/%s",
+				len(p.postDecision)-i, event.Operation, event.ResourceKind, event.Name)
+			break
+		}
+		if err := stage.PostDecision(ctx, req, event, decision); err != nil {
+			pluginErrors.Add(1)
+			klog.Errorf("Plugin %s failed after the decision on %s %s/%s, skipping it: %v",
+				stage.Name(), event.Operation, event.ResourceKind, event.Name, err)
+		}
+	}
+	response.Warnings = decision.Warnings
+}
+
+// runPrePersist runs the pre-persist plugins, reporting whether the event should still be
+// saved. The first plugin dropping the event stops the pipeline.
+func (p *pluginPipeline) runPrePersist(ctx context.Context, event *model.ChangeEvent) bool {
+	if p == nil {
+		return true
+	}
+	for _, stage := range p.prePersist {
+		keep, err := stage.PrePersist(ctx, event)
+		if err != nil {
+			pluginErrors.Add(1)
+			klog.Errorf("Plugin %s failed before saving change event %s, skipping it: %v", stage.Name(), event.ID, err)
+			continue
+		}
+		if !keep {
+			klog.V(2).Infof("Plugin %s dropped change event %s", stage.Name(), event.ID)
+			return false
+		}
+	}
+	return true
+}
+
+// runEnrichers runs the enrichers on an event about to be saved. An enricher failing is
+// skipped; the context added by the others is kept.
+func (p *pluginPipeline) runEnrichers(ctx context.Context, event *model.ChangeEvent) {
+	if p == nil {
+		return
+	}
+	for _, stage := range p.enrich {
+		if err := stage.Enrich(ctx, event); err != nil {
+			pluginErrors.Add(1)
+			klog.Errorf("Enricher %s failed on change event %s, skipping it: %v", stage.Name(), event.ID, err)
+		}
+	}
+}
+
+// SetPlugins creates the admission plugins of configs, which must be registered with
+// plugin.Register. It must be called before Start.
+func (h *Handler) SetPlugins(configs []config.PluginConfig) error {
+	plugins, err := newPluginPipeline(configs)
+	if err != nil {
+		return err
+	}
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.plugins = plugins
+	return nil
+}
+
+// getPlugins returns the plugin pipeline (thread-safe).
+func (h *Handler) getPlugins() *pluginPipeline {
+	h.configMutex.RLock()
+	defer h.configMutex.RUnlock()
+	return h.plugins
+}
+
+// pluginNames returns the names of the plugins, in configuration order.
+func (p *pluginPipeline) pluginNames() []string {
+	if p == nil {
+		return nil
+	}
+	return p.names
+}
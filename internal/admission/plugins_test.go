@@ -0,0 +1,182 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// policyPlugin denies requests in the "frozen" namespace and tags the events it sees.
+type policyPlugin struct {
+	persisted int
+}
+
+func (p *policyPlugin) Name() string {
+	return "test-policy"
+}
+
+func (p *policyPlugin) PreDecision(ctx context.Context, req *plugin.Request, event *plugin.Event) (*plugin.Decision, error) {
+	if event.Namespace == "frozen" {
+		return &plugin.Decision{Message: "Namespace is frozen", Warnings: []string{"frozen until Monday"}}, nil
+	}
+	event.Source.Tool = "policy-checked"
+	return &plugin.Decision{Allowed: true, Warnings: []string{"checked by policy"}}, nil
+}
+
+func (p *policyPlugin) PostDecision(ctx context.Context, req *plugin.Request, event *plugin.Event, decision *plugin.Decision) error {
+	decision.Warnings = append(decision.Warnings, "decided")
+	decision.Allowed = true // Ignored
+	return nil
+}
+
+func (p *policyPlugin) PrePersist(ctx context.Context, event *plugin.Event) (bool, error) {
+	p.persisted++
+	return event.Name != "drop-me", nil
+}
+
+func (p *policyPlugin) Enrich(ctx context.Context, event *plugin.Event) error {
+	plugin.SetEnrichment(event, "team", "platform")
+	return nil
+}
+
+// failingPlugin fails at every stage.
+type failingPlugin struct{}
+
+func (failingPlugin) Name() string {
+	return "test-failing"
+}
+
+func (failingPlugin) PreDecision(ctx context.Context, req *plugin.Request, event *plugin.Event) (*plugin.Decision, error) {
+	return &plugin.Decision{}, errors.New("policy service unavailable")
+}
+
+func (failingPlugin) PrePersist(ctx context.Context, event *plugin.Event) (bool, error) {
+	return false, errors.New("enrichment service unavailable")
+}
+
+func (failingPlugin) Enrich(ctx context.Context, event *plugin.Event) error {
+	return errors.New("team directory unavailable")
+}
+
+var testPolicy = &policyPlugin{}
+
+func init() {
+	plugin.Register("test-policy", func(config json.RawMessage) (plugin.Plugin, error) {
+		return testPolicy, nil
+	})
+	plugin.Register("test-failing", func(config json.RawMessage) (plugin.Plugin, error) {
+		return failingPlugin{}, nil
+	})
+}
+
+// reviewPod sends an admission review for a pod and returns the response.
+func reviewPod(t *testing.T, handler *Handler, namespace, name string) *admissionv1.AdmissionResponse {
+	t.Helper()
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Create,
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	body, _ := json.Marshal(review)
+	w := httptest.NewRecorder()
+	handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+	var response admissionv1.AdmissionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.Response
+}
+
+func TestHandler_Plugins(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	if err := handler.SetPlugins([]config.PluginConfig{{Name: "test-failing"}, {Name: "test-policy"}}); err != nil {
+		t.Fatalf("SetPlugins() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	denied := reviewPod(t, handler, "frozen", "api")
+	if denied.Allowed || denied.Result.Message != "Namespace is frozen" {
+		t.Errorf("Expected the plugin to deny the request, got %+v", denied)
+	}
+	if len(denied.Warnings) != 2 || denied.Warnings[0] != "frozen until Monday" || denied.Warnings[1] != "decided" {
+		t.Errorf("Unexpected warnings: %v", denied.Warnings)
+	}
+
+	allowed := reviewPod(t, handler, "default", "api")
+	if !allowed.Allowed || len(allowed.Warnings) != 2 || allowed.Warnings[0] != "checked by policy" {
+		t.Errorf("Unexpected response: %+v", allowed)
+	}
+	reviewPod(t, handler, "default", "drop-me")
+
+	handler.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	// The failing plugin is skipped, and drop-me is dropped by the policy plugin
+	if len(mockStore.savedEvents) != 2 {
+		t.Fatalf("Expected 2 saved events, got %d", len(mockStore.savedEvents))
+	}
+	blocked, saved := mockStore.savedEvents[0], mockStore.savedEvents[1]
+	if blocked.Allowed || blocked.BlockPattern != "plugin:test-policy" {
+		t.Errorf("Expected the denied event to be recorded as blocked, got %+v", blocked)
+	}
+	if saved.Source.Tool != "policy-checked" {
+		t.Errorf("Expected the event to be enriched, got source %q", saved.Source.Tool)
+	}
+	if saved.Enrichment["team"] != "platform" || len(saved.Enrichment) != 1 {
+		t.Errorf("Expected the enricher to add the team, got %v", saved.Enrichment)
+	}
+	if testPolicy.persisted != 3 {
+		t.Errorf("Expected 3 pre-persist calls, got %d", testPolicy.persisted)
+	}
+	if names := handler.RuntimeConfig().Plugins; len(names) != 2 || names[1] != "test-policy" {
+		t.Errorf("Unexpected runtime plugins: %v", names)
+	}
+}
+
+func TestHandler_SetPlugins_Unknown(t *testing.T) {
+	handler := NewHandler(&mockStore{}, nil, nil, nil)
+	if err := handler.SetPlugins([]config.PluginConfig{{Name: "missing"}}); err == nil {
+		t.Error("Expected an error for an unregistered plugin")
+	}
+}
+
+func TestHandler_PluginsLatencyBudget(t *testing.T) {
+	handler := NewHandler(&mockStore{}, nil, nil, nil)
+	if err := handler.SetPlugins([]config.PluginConfig{{Name: "test-policy"}}); err != nil {
+		t.Fatalf("SetPlugins() error = %v", err)
+	}
+	handler.latencyBudget = time.Nanosecond
+	skipped := budgetSkips.Value()
+
+	// The pre-decision plugin still decides, but the post-decision plugin and warn rules are skipped
+	response := reviewPod(t, handler, "frozen", "api")
+	if response.Allowed || len(response.Warnings) != 1 || response.Warnings[0] != "frozen until Monday" {
+		t.Errorf("Expected the post-decision plugin to be skipped, got %+v", response)
+	}
+	response = reviewPod(t, handler, "default", "api")
+	if len(response.Warnings) != 1 || response.Warnings[0] != "checked by policy" {
+		t.Errorf("Unexpected warnings: %v", response.Warnings)
+	}
+	if got := budgetSkips.Value() - skipped; got != 3 {
+		t.Errorf("Expected 3 stages skipped, got %d", got)
+	}
+}
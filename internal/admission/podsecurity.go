@@ -0,0 +1,204 @@
+package admission
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// podSecurityRegressions counts the workload UPDATEs weakening the security of their pods.
+var podSecurityRegressions = expvar.NewInt("webhook_pod_security_regressions_total")
+
+// podSecurityRegression returns how the UPDATE of a workload of kind resourceKind, given its
+// raw old and new objects, weakens the security of its pods, or nil if it doesn't or the
+// kind has no pod spec. New containers are compared to a container without securityContext.
+func podSecurityRegression(resourceKind string, oldRaw, newRaw []byte) *model.PodSecurityRegression {
+	if oldRaw == nil || newRaw == nil {
+		return nil
+	}
+	oldSpec, err := decodePodSpec(resourceKind, oldRaw)
+	if err != nil || oldSpec == nil {
+		return nil
+	}
+	newSpec, err := decodePodSpec(resourceKind, newRaw)
+	if err != nil {
+		klog.V(2).Infof("Failed to decode %s to check its pod security: %v", resourceKind, err)
+		return nil
+	}
+
+	findings := podLevelRegressions(oldSpec, newSpec)
+	oldContainers := map[string]corev1.Container{}
+	for _, c := range podContainers(oldSpec) {
+		oldContainers[c.Name] = c
+	}
+	for _, c := range podContainers(newSpec) {
+		old := oldContainers[c.Name]
+		findings = append(findings, containerRegressions(oldSpec, newSpec, &old, &c)...)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	podSecurityRegressions.Add(1)
+	return &model.PodSecurityRegression{Findings: findings}
+}
+
+// podLevelRegressions returns the host namespaces and hostPath volumes newSpec uses that
+// oldSpec didn't.
+func podLevelRegressions(oldSpec, newSpec *corev1.PodSpec) []model.PodSecurityFinding {
+	var findings []model.PodSecurityFinding
+	for _, host := range []struct {
+		check, field string
+		old, new     bool
+	}{
+		{"host_network", "hostNetwork", oldSpec.HostNetwork, newSpec.HostNetwork},
+		{"host_pid", "hostPID", oldSpec.HostPID, newSpec.HostPID},
+		{"host_ipc", "hostIPC", oldSpec.HostIPC, newSpec.HostIPC},
+	} {
+		if host.new && !host.old {
+			findings = append(findings, model.PodSecurityFinding{Check: host.check, Message: host.field + " enabled"})
+		}
+	}
+
+	oldPaths := map[string]bool{}
+	for _, volume := range oldSpec.Volumes {
+		if volume.HostPath != nil {
+			oldPaths[volume.HostPath.Path] = true
+		}
+	}
+	for _, volume := range newSpec.Volumes {
+		if volume.HostPath != nil && !oldPaths[volume.HostPath.Path] {
+			findings = append(findings, model.PodSecurityFinding{Check: "host_path",
+				Message: fmt.Sprintf("hostPath volume %s mounting %s added", volume.Name, volume.HostPath.Path)})
+		}
+	}
+	return findings
+}
+
+// containerRegressions returns the privileges the container c of newSpec gained over old,
+// the same container in oldSpec, and the restrictions it dropped. Pod-level settings apply
+// to containers that don't override them.
+func containerRegressions(oldSpec, newSpec *corev1.PodSpec, old, c *corev1.Container) []model.PodSecurityFinding {
+	var findings []model.PodSecurityFinding
+	add := func(check, format string, args ...interface{}) {
+		findings = append(findings, model.PodSecurityFinding{Check: check, Container: c.Name,
+			Message: fmt.Sprintf("container %s: ", c.Name) + fmt.Sprintf(format, args...)})
+	}
+	oldContext, newContext := old.SecurityContext, c.SecurityContext
+	if oldContext == nil {
+		oldContext = &corev1.SecurityContext{}
+	}
+	if newContext == nil {
+		newContext = &corev1.SecurityContext{}
+	}
+
+	if isTrue(newContext.Privileged) && !isTrue(oldContext.Privileged) {
+		add("privileged", "made privileged")
+	}
+	// Privilege escalation is allowed unless explicitly disallowed
+	if isFalse(oldContext.AllowPrivilegeEscalation) && !isFalse(newContext.AllowPrivilegeEscalation) {
+		add("privilege_escalation", "allowPrivilegeEscalation no longer false")
+	}
+	if isTrue(oldContext.ReadOnlyRootFilesystem) && !isTrue(newContext.ReadOnlyRootFilesystem) {
+		add("read_only_root_filesystem_removed", "readOnlyRootFilesystem no longer true")
+	}
+	if isTrue(runAsNonRoot(oldSpec, old)) && !isTrue(runAsNonRoot(newSpec, c)) {
+		add("run_as_non_root_removed", "runAsNonRoot no longer true")
+	}
+	if user := runAsUser(newSpec, c); user != nil && *user == 0 {
+		if oldUser := runAsUser(oldSpec, old); oldUser == nil || *oldUser != 0 {
+			add("run_as_root", "runAsUser set to 0")
+		}
+	}
+	if oldProfile, newProfile := seccompProfile(oldSpec, old), seccompProfile(newSpec, c); oldProfile != "" &&
+		oldProfile != corev1.SeccompProfileTypeUnconfined && (newProfile == "" || newProfile == corev1.SeccompProfileTypeUnconfined) {
+		add("seccomp_removed", "seccomp profile changed from %s to %s", oldProfile, orDefault(string(newProfile), "none"))
+	}
+
+	var oldAdded, oldDropped, newAdded, newDropped []corev1.Capability
+	if oldContext.Capabilities != nil {
+		oldAdded, oldDropped = oldContext.Capabilities.Add, oldContext.Capabilities.Drop
+	}
+	if newContext.Capabilities != nil {
+		newAdded, newDropped = newContext.Capabilities.Add, newContext.Capabilities.Drop
+	}
+	var added []string
+	for _, capability := range newAdded {
+		if !hasCapability(oldAdded, capability) {
+			added = append(added, string(capability))
+		}
+	}
+	if len(added) > 0 {
+		add("capabilities_added", "capabilities %s added", strings.Join(added, ", "))
+	}
+	var restored []string
+	for _, capability := range oldDropped {
+		if !hasCapability(newDropped, capability) {
+			restored = append(restored, string(capability))
+		}
+	}
+	if len(restored) > 0 {
+		add("capabilities_drop_removed", "capabilities %s no longer dropped", strings.Join(restored, ", "))
+	}
+	return findings
+}
+
+// runAsNonRoot returns the runAsNonRoot of c in spec, its own or the pod's.
+func runAsNonRoot(spec *corev1.PodSpec, c *corev1.Container) *bool {
+	if c.SecurityContext != nil && c.SecurityContext.RunAsNonRoot != nil {
+		return c.SecurityContext.RunAsNonRoot
+	}
+	if spec.SecurityContext != nil {
+		return spec.SecurityContext.RunAsNonRoot
+	}
+	return nil
+}
+
+// runAsUser returns the runAsUser of c in spec, its own or the pod's.
+func runAsUser(spec *corev1.PodSpec, c *corev1.Container) *int64 {
+	if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil {
+		return c.SecurityContext.RunAsUser
+	}
+	if spec.SecurityContext != nil {
+		return spec.SecurityContext.RunAsUser
+	}
+	return nil
+}
+
+// seccompProfile returns the type of the seccomp profile of c in spec, its own or the
+// pod's, or "" if it has none.
+func seccompProfile(spec *corev1.PodSpec, c *corev1.Container) corev1.SeccompProfileType {
+	if c.SecurityContext != nil && c.SecurityContext.SeccompProfile != nil {
+		return c.SecurityContext.SeccompProfile.Type
+	}
+	if spec.SecurityContext != nil && spec.SecurityContext.SeccompProfile != nil {
+		return spec.SecurityContext.SeccompProfile.Type
+	}
+	return ""
+}
+
+// hasCapability reports whether capabilities has capability, ignoring case and the CAP_ prefix.
+func hasCapability(capabilities []corev1.Capability, capability corev1.Capability) bool {
+	name := strings.TrimPrefix(strings.ToUpper(string(capability)), "CAP_")
+	for _, c := range capabilities {
+		if strings.TrimPrefix(strings.ToUpper(string(c)), "CAP_") == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(b *bool) bool  { return b != nil && *b }
+func isFalse(b *bool) bool { return b != nil && !*b }
+
+// orDefault returns s, or def if s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package admission
+
+import "testing"
+
+func TestPodSecurityRegression_Deployment(t *testing.T) {
+	oldDeployment := `{"kind":"Deployment","spec":{"template":{"spec":{` +
+		`"securityContext":{"runAsNonRoot":true,"seccompProfile":{"type":"RuntimeDefault"}},` +
+		`"containers":[{"name":"app","image":"app:1","securityContext":{"allowPrivilegeEscalation":false,"readOnlyRootFilesystem":true,"capabilities":{"drop":["ALL"]}}}]}}}}`
+	newDeployment := `{"kind":"Deployment","spec":{"template":{"spec":{"hostNetwork":true,` +
+		`"securityContext":{"seccompProfile":{"type":"Unconfined"}},` +
+		`"volumes":[{"name":"docker","hostPath":{"path":"/var/run/docker.sock"}}],` +
+		`"containers":[{"name":"app","image":"app:1","securityContext":{"privileged":true,"runAsUser":0,"capabilities":{"add":["NET_ADMIN"]}}},` +
+		`{"name":"sidecar","image":"sidecar:1"}]}}}}`
+
+	regression := podSecurityRegression("Deployment", []byte(oldDeployment), []byte(newDeployment))
+	if regression == nil {
+		t.Fatal("Expected a pod security regression")
+	}
+	checks := []string{"host_network", "host_path", "privileged", "privilege_escalation", "read_only_root_filesystem_removed",
+		"run_as_non_root_removed", "run_as_root", "seccomp_removed", "capabilities_added", "capabilities_drop_removed",
+		"run_as_non_root_removed", "seccomp_removed"}
+	if len(regression.Findings) != len(checks) {
+		t.Fatalf("Expected findings %v, got %+v", checks, regression.Findings)
+	}
+	for i, finding := range regression.Findings {
+		if finding.Check != checks[i] {
+			t.Errorf("Finding %d = %+v, want %s", i, finding, checks[i])
+		}
+	}
+	if got := regression.Findings[2]; got.Container != "app" || got.Message != "container app: made privileged" {
+		t.Errorf("Unexpected privileged finding %+v", got)
+	}
+	if got := regression.Findings[1].Message; got != "hostPath volume docker mounting /var/run/docker.sock added" {
+		t.Errorf("Unexpected hostPath finding message %q", got)
+	}
+
+	// Restoring the restrictions is no regression
+	if regression := podSecurityRegression("Deployment", []byte(newDeployment), []byte(oldDeployment)); regression != nil {
+		t.Errorf("Expected no regression when hardening, got %+v", regression)
+	}
+}
+
+func TestPodSecurityRegression_InheritedSettings(t *testing.T) {
+	// The container keeps the pod's settings; a new container runs as root, overriding them
+	oldPod := `{"kind":"Pod","spec":{"securityContext":{"runAsNonRoot":true,"runAsUser":1000},"containers":[{"name":"app"}]}}`
+	newPod := `{"kind":"Pod","spec":{"securityContext":{"runAsNonRoot":true,"runAsUser":1000},"containers":[{"name":"app"}],` +
+		`"initContainers":[{"name":"setup","securityContext":{"runAsNonRoot":false,"runAsUser":0}}]}}`
+
+	regression := podSecurityRegression("Pod", []byte(oldPod), []byte(newPod))
+	if regression == nil || len(regression.Findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %+v", regression)
+	}
+	for _, finding := range regression.Findings {
+		if finding.Container != "setup" {
+			t.Errorf("Expected findings for container setup only, got %+v", finding)
+		}
+	}
+
+	if regression := podSecurityRegression("Pod", []byte(oldPod), []byte(oldPod)); regression != nil {
+		t.Errorf("Expected no regression for an unchanged pod, got %+v", regression)
+	}
+}
+
+func TestPodSecurityRegression_CronJob(t *testing.T) {
+	oldCronJob := `{"kind":"CronJob","spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"job"}]}}}}}}`
+	newCronJob := `{"kind":"CronJob","spec":{"jobTemplate":{"spec":{"template":{"spec":{"hostPID":true,"containers":[{"name":"job"}]}}}}}}`
+
+	regression := podSecurityRegression("CronJob", []byte(oldCronJob), []byte(newCronJob))
+	if regression == nil || len(regression.Findings) != 1 || regression.Findings[0].Check != "host_pid" {
+		t.Fatalf("Expected a host_pid finding, got %+v", regression)
+	}
+}
+
+func TestPodSecurityRegression_Ignored(t *testing.T) {
+	privileged := `{"kind":"Deployment","spec":{"template":{"spec":{"containers":[{"name":"app","securityContext":{"privileged":true}}]}}}}`
+
+	// New workloads have nothing to regress from
+	if regression := podSecurityRegression("Deployment", nil, []byte(privileged)); regression != nil {
+		t.Errorf("Expected no regression for a CREATE, got %+v", regression)
+	}
+	if regression := podSecurityRegression("ConfigMap", []byte(`{"data":{}}`), []byte(`{"data":{"a":"b"}}`)); regression != nil {
+		t.Errorf("Expected no regression for a ConfigMap, got %+v", regression)
+	}
+	if regression := podSecurityRegression("Deployment", []byte(privileged), []byte(`{"spec":"invalid"}`)); regression != nil {
+		t.Errorf("Expected no regression for an undecodable object, got %+v", regression)
+	}
+}
@@ -0,0 +1,150 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/registry"
+)
+
+// maxCachedImages is the number of image checks cached above which expired ones are pruned.
+const maxCachedImages = 1000
+
+// imageChecks counts the images checked, per status.
+var imageChecks = expvar.NewMap("webhook_image_provenance_checks_total")
+
+// imageRegistry looks up the provenance of images; implemented by registry.Client.
+type imageRegistry interface {
+	Provenance(ctx context.Context, image string) (digest string, signed bool, err error)
+}
+
+// provenanceChecker checks the provenance of the images deployed by workload changes,
+// caching the result per image reference.
+type provenanceChecker struct {
+	protected patternSet // Namespaces where unsigned images are violations
+	registry  imageRegistry
+	cacheTTL  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedImageCheck // By image reference
+}
+
+// cachedImageCheck is the result of checking an image whose registry could be queried.
+type cachedImageCheck struct {
+	digest    string
+	status    string
+	checkedAt time.Time
+}
+
+// SetImageProvenance makes the event workers check the digest and cosign signature of the
+// images deployed by the CREATEs and UPDATEs of workloads, recording them on the events and
+// flagging unsigned images deployed to protected namespaces. Checks delay the save of the
+// events by up to a few registry round trips. nil checks nothing. Must be called before Start.
+func (h *Handler) SetImageProvenance(cfg *config.ImageProvenanceConfig) {
+	if cfg == nil {
+		h.provenance = nil
+		return
+	}
+	timeout, _ := cfg.TimeoutDuration()
+	cacheTTL, _ := cfg.CacheTTLDuration()
+	h.provenance = &provenanceChecker{
+		protected: compilePatterns(cfg.ProtectedNamespaces),
+		registry:  registry.NewClient(timeout, cfg.InsecureRegistries),
+		cacheTTL:  cacheTTL,
+		cache:     map[string]cachedImageCheck{},
+	}
+}
+
+// check records on event the provenance of the images its objects' pod spec deploys, new
+// containers' and changed ones'. Blocked requests and other operations aren't checked.
+func (p *provenanceChecker) check(ctx context.Context, event *model.ChangeEvent, oldObject, object []byte) {
+	if !event.Allowed || (event.Operation != "CREATE" && event.Operation != "UPDATE") {
+		return
+	}
+	images := changedImages(event.ResourceKind, oldObject, object)
+	if len(images) == 0 {
+		return
+	}
+
+	provenance := &model.ImageProvenance{}
+	_, protected := p.protected.match(event.Namespace)
+	for _, image := range images {
+		p.checkImage(ctx, &image, time.Now())
+		imageChecks.Add(image.Status, 1)
+		if image.Status == model.ImageUnsigned && protected {
+			provenance.Violation = true
+			klog.Warningf("Unsigned image %s deployed to protected namespace %s by %s %s", image.Image, event.Namespace, event.ResourceKind, event.Name)
+		}
+		provenance.Images = append(provenance.Images, image)
+	}
+	event.ImageProvenance = provenance
+}
+
+// checkImage sets the digest and status of image, from the cache or the registry.
+func (p *provenanceChecker) checkImage(ctx context.Context, image *model.ImageCheck, now time.Time) {
+	p.mu.Lock()
+	cached, ok := p.cache[image.Image]
+	p.mu.Unlock()
+	if ok && now.Sub(cached.checkedAt) < p.cacheTTL {
+		image.Digest, image.Status = cached.digest, cached.status
+		return
+	}
+
+	digest, signed, err := p.registry.Provenance(ctx, image.Image)
+	if err != nil {
+		klog.V(2).Infof("Failed to check the provenance of image %s: %v", image.Image, err)
+		image.Digest, image.Status, image.Error = digest, model.ImageUnknown, err.Error()
+		return
+	}
+	image.Digest, image.Status = digest, model.ImageUnsigned
+	if signed {
+		image.Status = model.ImageSigned
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.cache) >= maxCachedImages {
+		for ref, c := range p.cache {
+			if now.Sub(c.checkedAt) >= p.cacheTTL {
+				delete(p.cache, ref)
+			}
+		}
+	}
+	p.cache[image.Image] = cachedImageCheck{digest: image.Digest, status: image.Status, checkedAt: now}
+}
+
+// changedImages returns the containers of object, a workload of kind resourceKind, whose
+// image isn't the one they had in oldObject, with their image and old image set. Containers
+// of objects that fail to decode are ignored.
+func changedImages(resourceKind string, oldObject, object []byte) []model.ImageCheck {
+	if len(object) == 0 {
+		return nil
+	}
+	spec, err := decodePodSpec(resourceKind, object)
+	if err != nil || spec == nil {
+		return nil
+	}
+	oldImages := map[string]string{}
+	if len(oldObject) > 0 {
+		if oldSpec, err := decodePodSpec(resourceKind, oldObject); err == nil {
+			for _, c := range podContainers(oldSpec) {
+				oldImages[c.Name] = c.Image
+			}
+		}
+	}
+
+	var images []model.ImageCheck
+	for _, c := range podContainers(spec) {
+		if c.Image == "" || c.Image == oldImages[c.Name] {
+			continue
+		}
+		images = append(images, model.ImageCheck{Container: c.Name, Image: c.Image, OldImage: oldImages[c.Name]})
+	}
+	return images
+}
@@ -0,0 +1,99 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// fakeImageRegistry is an imageRegistry of fixed digests, counting its lookups.
+type fakeImageRegistry struct {
+	digests map[string]string // By image; images missing fail
+	signed  map[string]bool   // By image
+	lookups int
+}
+
+func (f *fakeImageRegistry) Provenance(ctx context.Context, image string) (string, bool, error) {
+	f.lookups++
+	digest, ok := f.digests[image]
+	if !ok {
+		return "", false, errors.New("registry requires credentials")
+	}
+	return digest, f.signed[image], nil
+}
+
+func TestChangedImages(t *testing.T) {
+	oldDeployment := `{"kind":"Deployment","spec":{"template":{"spec":{"initContainers":[{"name":"migrate","image":"app:v1"}],"containers":[{"name":"app","image":"app:v1"},{"name":"proxy","image":"envoy:1.30"}]}}}}`
+	newDeployment := `{"kind":"Deployment","spec":{"template":{"spec":{"initContainers":[{"name":"migrate","image":"app:v2"}],"containers":[{"name":"app","image":"app:v2"},{"name":"proxy","image":"envoy:1.30"},{"name":"debug","image":"busybox"}]}}}}`
+
+	images := changedImages("Deployment", []byte(oldDeployment), []byte(newDeployment))
+	if len(images) != 3 {
+		t.Fatalf("Expected 3 changed images, got %+v", images)
+	}
+	if images[0] != (model.ImageCheck{Container: "migrate", Image: "app:v2", OldImage: "app:v1"}) ||
+		images[1] != (model.ImageCheck{Container: "app", Image: "app:v2", OldImage: "app:v1"}) ||
+		images[2] != (model.ImageCheck{Container: "debug", Image: "busybox"}) {
+		t.Errorf("Unexpected changed images: %+v", images)
+	}
+
+	cronJob := `{"kind":"CronJob","spec":{"jobTemplate":{"spec":{"template":{"spec":{"containers":[{"name":"backup","image":"backup:v1"}]}}}}}}`
+	if images := changedImages("CronJob", nil, []byte(cronJob)); len(images) != 1 || images[0].Image != "backup:v1" {
+		t.Errorf("Expected the image of a new CronJob, got %+v", images)
+	}
+	pod := `{"kind":"Pod","spec":{"containers":[{"name":"app","image":"app:v1"}]}}`
+	if images := changedImages("Pod", []byte(pod), []byte(pod)); len(images) != 0 {
+		t.Errorf("Expected no changed image for an unchanged Pod, got %+v", images)
+	}
+	if images := changedImages("ConfigMap", nil, []byte(`{"kind":"ConfigMap"}`)); images != nil {
+		t.Errorf("Expected no images for other kinds, got %+v", images)
+	}
+}
+
+func TestProvenanceChecker_Check(t *testing.T) {
+	fake := &fakeImageRegistry{
+		digests: map[string]string{"app:v2": "sha256:aaa", "signed:v1": "sha256:bbb"},
+		signed:  map[string]bool{"signed:v1": true},
+	}
+	checker := &provenanceChecker{
+		protected: compilePatterns([]string{"payments-*"}),
+		registry:  fake,
+		cacheTTL:  time.Hour,
+		cache:     map[string]cachedImageCheck{},
+	}
+	pod := `{"kind":"Pod","spec":{"containers":[{"name":"app","image":"app:v2"},{"name":"sidecar","image":"signed:v1"},{"name":"private","image":"private:v1"}]}}`
+
+	event := &model.ChangeEvent{Operation: "CREATE", ResourceKind: "Pod", Namespace: "payments-eu", Allowed: true}
+	checker.check(context.Background(), event, nil, []byte(pod))
+	if event.ImageProvenance == nil || !event.ImageProvenance.Violation {
+		t.Fatalf("Expected an unsigned image in a protected namespace to be a violation, got %+v", event.ImageProvenance)
+	}
+	want := []model.ImageCheck{
+		{Container: "app", Image: "app:v2", Digest: "sha256:aaa", Status: model.ImageUnsigned},
+		{Container: "sidecar", Image: "signed:v1", Digest: "sha256:bbb", Status: model.ImageSigned},
+		{Container: "private", Image: "private:v1", Status: model.ImageUnknown, Error: "registry requires credentials"},
+	}
+	for i, image := range event.ImageProvenance.Images {
+		if image != want[i] {
+			t.Errorf("Image %d = %+v, want %+v", i, image, want[i])
+		}
+	}
+
+	// Known results are cached, unknown ones are looked up again
+	event = &model.ChangeEvent{Operation: "CREATE", ResourceKind: "Pod", Namespace: "default", Allowed: true}
+	checker.check(context.Background(), event, nil, []byte(pod))
+	if fake.lookups != 4 {
+		t.Errorf("Expected 4 registry lookups, got %d", fake.lookups)
+	}
+	if event.ImageProvenance.Violation {
+		t.Error("Expected no violation outside protected namespaces")
+	}
+
+	blocked := &model.ChangeEvent{Operation: "CREATE", ResourceKind: "Pod", Namespace: "payments-eu"}
+	checker.check(context.Background(), blocked, nil, []byte(pod))
+	if blocked.ImageProvenance != nil {
+		t.Errorf("Expected blocked requests not to be checked, got %+v", blocked.ImageProvenance)
+	}
+}
@@ -0,0 +1,35 @@
+package admission
+
+import (
+	"expvar"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+)
+
+// unpseudonymizedEvents counts events dropped because their actor couldn't be pseudonymized.
+var unpseudonymizedEvents = expvar.NewInt("webhook_unpseudonymized_events_total")
+
+// SetPseudonymizer pseudonymizes the actors of events before they are saved, spooled,
+// dead-lettered or alerted on, so none of them sees the values even when the store is down.
+// Must be called before Start.
+func (h *Handler) SetPseudonymizer(p *pseudonym.Pseudonymizer) {
+	h.pseudonymizer = p
+}
+
+// pseudonymize pseudonymizes the actor of an event, reporting whether it can be kept. It
+// fails closed: an event whose pseudonyms can't be recorded is dropped rather than kept with
+// its actor.
+func (h *Handler) pseudonymize(event *model.ChangeEvent) bool {
+	if h.pseudonymizer == nil {
+		return true
+	}
+	if err := h.pseudonymizer.Apply(event); err != nil {
+		unpseudonymizedEvents.Add(1)
+		klog.Errorf("Failed to pseudonymize change event %s, dropping it: %v", event.ID, err)
+		return false
+	}
+	return true
+}
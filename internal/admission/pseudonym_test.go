@@ -0,0 +1,78 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeMappings records pseudonyms, or fails like an unreachable database.
+type fakeMappings struct {
+	err error
+}
+
+func (f *fakeMappings) SavePseudonyms(ctx context.Context, pseudonyms []*store.Pseudonym) error {
+	return f.err
+}
+
+func (f *fakeMappings) ResolvePseudonym(ctx context.Context, p string) (*store.Pseudonym, error) {
+	return nil, store.ErrNotFound
+}
+
+func TestHandler_PseudonymizesBeforeDeadLettering(t *testing.T) {
+	defer func(backoff time.Duration) { storeRetryBackoff = backoff }(storeRetryBackoff)
+	storeRetryBackoff = time.Millisecond
+
+	pseudonymizer, err := pseudonym.New("MDEyMzQ1Njc4OWFiY2RlZg==", &pseudonym.Config{Fields: []string{pseudonym.FieldUsername, pseudonym.FieldSourceIP}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pseudonymizer.SetMappingStore(&fakeMappings{})
+	handler := NewHandler(&mockStore{saveError: errors.New("connection reset")}, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	handler.SetDeadLetterStore(deadLetters)
+	handler.SetPseudonymizer(pseudonymizer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.Start(ctx)
+	handler.queue <- &queuedEvent{event: &model.ChangeEvent{ID: "e1", Actor: model.Actor{Username: "alice@example.com", SourceIP: "10.0.0.1"}}}
+
+	if !waitFor(t, func() bool { return deadLetters.addedCount() == 1 }) {
+		t.Fatal("Failed save should be dead-lettered")
+	}
+	if actor := deadLetters.added[0].Event.Actor; actor.Username != pseudonymizer.Pseudonym("alice@example.com") || actor.SourceIP != pseudonymizer.Pseudonym("10.0.0.1") {
+		t.Errorf("Dead-lettered actor = %+v, want pseudonyms", actor)
+	}
+}
+
+func TestHandler_UnpseudonymizedEventsAreDropped(t *testing.T) {
+	pseudonymizer, err := pseudonym.New("MDEyMzQ1Njc4OWFiY2RlZg==", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pseudonymizer.SetMappingStore(&fakeMappings{err: store.ErrUnavailable})
+	eventStore := &mockStore{}
+	handler := NewHandler(eventStore, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	handler.SetDeadLetterStore(deadLetters)
+	handler.SetPseudonymizer(pseudonymizer)
+
+	before := unpseudonymizedEvents.Value()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.Start(ctx)
+	handler.queue <- &queuedEvent{event: &model.ChangeEvent{ID: "e1", Actor: model.Actor{Username: "alice@example.com"}}}
+
+	if !waitFor(t, func() bool { return unpseudonymizedEvents.Value() == before+1 }) {
+		t.Fatal("Event should be dropped when its pseudonyms can't be recorded")
+	}
+	if len(eventStore.savedEvents) != 0 || deadLetters.addedCount() != 0 {
+		t.Errorf("Event kept with its actor: %d saved, %d dead-lettered", len(eventStore.savedEvents), deadLetters.addedCount())
+	}
+}
@@ -0,0 +1,154 @@
+package admission
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// quotaSampledOut counts events sampled out because their namespace was past its quota.
+var quotaSampledOut = expvar.NewInt("webhook_quota_sampled_out_total")
+
+// quotaRule is a pre-compiled QuotaRule with the events counted in the current window.
+type quotaRule struct {
+	name         string
+	namespaces   patternSet
+	perNamespace bool
+	maxEvents    uint64
+	rate         uint64
+
+	mu     sync.Mutex
+	counts map[string]uint64 // Events counted per namespace, or under "" when shared
+}
+
+// QuotaStatus is the usage of a quota in the current window, reported by RuntimeConfig.
+type QuotaStatus struct {
+	Rule      string `json:"rule"`
+	Namespace string `json:"namespace,omitempty"` // Set for per-namespace quotas
+	Events    uint64 `json:"events"`
+	MaxEvents uint64 `json:"max_events"`
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// quotaEnforcer samples the events of namespaces past their quota. Quotas are counted over
+// fixed windows, and reset when a window ends.
+type quotaEnforcer struct {
+	window time.Duration
+	rules  []*quotaRule
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+}
+
+// newQuotaEnforcer compiles a quota config. A nil config yields a nil enforcer, which limits nothing.
+func newQuotaEnforcer(cfg *config.QuotaConfig) *quotaEnforcer {
+	if cfg == nil || len(cfg.Rules) == 0 {
+		return nil
+	}
+	q := &quotaEnforcer{window: cfg.WindowDuration(), now: time.Now}
+	for _, rule := range cfg.Rules {
+		rate := rule.SampleRate
+		if rate < 1 {
+			rate = 10
+		}
+		q.rules = append(q.rules, &quotaRule{
+			name:         rule.Name,
+			namespaces:   compilePatterns(rule.NamespacePatterns),
+			perNamespace: rule.PerNamespace,
+			maxEvents:    uint64(rule.MaxEvents),
+			rate:         uint64(rate),
+			counts:       map[string]uint64{},
+		})
+	}
+	return q
+}
+
+// sample returns whether the event should be recorded and the sample rate it was recorded at.
+// The first rule matching the event's namespace counts it: events within the quota are
+// recorded with a rate of 1, and of the events past it the 1st, (rate+1)th, ... are recorded.
+func (q *quotaEnforcer) sample(event *model.ChangeEvent) (bool, int) {
+	if q == nil {
+		return true, 1
+	}
+	q.resetExpiredWindow()
+	for _, rule := range q.rules {
+		if _, ok := rule.namespaces.match(event.Namespace); !ok {
+			continue
+		}
+		key := ""
+		if rule.perNamespace {
+			key = event.Namespace
+		}
+
+		rule.mu.Lock()
+		rule.counts[key]++
+		n := rule.counts[key]
+		rule.mu.Unlock()
+
+		if n <= rule.maxEvents {
+			return true, 1
+		}
+		if n == rule.maxEvents+1 {
+			klog.Warningf("Quota %q exceeded in namespace %s (%d events per %s), recording 1 in %d events",
+				rule.name, event.Namespace, rule.maxEvents, q.window, rule.rate)
+		}
+		over := n - rule.maxEvents - 1
+		if over%rule.rate != 0 {
+			quotaSampledOut.Add(1)
+			return false, int(rule.rate)
+		}
+		return true, int(rule.rate)
+	}
+	return true, 1
+}
+
+// resetExpiredWindow starts a new window, resetting all counts, once the current one ends.
+func (q *quotaEnforcer) resetExpiredWindow() {
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if now.Sub(q.windowStart) < q.window {
+		return
+	}
+	q.windowStart = now
+	for _, rule := range q.rules {
+		rule.mu.Lock()
+		rule.counts = map[string]uint64{}
+		rule.mu.Unlock()
+	}
+}
+
+// status returns the usage of each quota in the current window, in rule order.
+func (q *quotaEnforcer) status() []QuotaStatus {
+	if q == nil {
+		return nil
+	}
+	statuses := []QuotaStatus{}
+	for _, rule := range q.rules {
+		rule.mu.Lock()
+		keys := make([]string, 0, len(rule.counts))
+		for key := range rule.counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			n := rule.counts[key]
+			statuses = append(statuses, QuotaStatus{
+				Rule:      rule.name,
+				Namespace: key,
+				Events:    n,
+				MaxEvents: rule.maxEvents,
+				Exceeded:  n > rule.maxEvents,
+			})
+		}
+		rule.mu.Unlock()
+	}
+	return statuses
+}
@@ -0,0 +1,105 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestQuotaEnforcer_Sample(t *testing.T) {
+	q := newQuotaEnforcer(&config.QuotaConfig{
+		Rules: []config.QuotaRule{{Name: "payments", NamespacePatterns: []string{"payments-*"}, MaxEvents: 2, SampleRate: 3}},
+	})
+	event := &model.ChangeEvent{Namespace: "payments-prod"}
+
+	var kept []bool
+	var rates []int
+	for i := 0; i < 7; i++ {
+		keep, rate := q.sample(event)
+		kept = append(kept, keep)
+		rates = append(rates, rate)
+	}
+	wantKept := []bool{true, true, true, false, false, true, false}
+	wantRates := []int{1, 1, 3, 3, 3, 3, 3}
+	for i := range wantKept {
+		if kept[i] != wantKept[i] || rates[i] != wantRates[i] {
+			t.Errorf("event %d: sample() = %v, %d, want %v, %d", i, kept[i], rates[i], wantKept[i], wantRates[i])
+		}
+	}
+
+	// Namespaces matching no rule are not limited
+	for i := 0; i < 5; i++ {
+		if keep, rate := q.sample(&model.ChangeEvent{Namespace: "search"}); !keep || rate != 1 {
+			t.Errorf("sample() = %v, %d for an unlimited namespace, want true, 1", keep, rate)
+		}
+	}
+}
+
+func TestQuotaEnforcer_SharedAndPerNamespace(t *testing.T) {
+	q := newQuotaEnforcer(&config.QuotaConfig{
+		Rules: []config.QuotaRule{
+			{Name: "team-a", NamespacePatterns: []string{"team-a-*"}, MaxEvents: 1},
+			{Name: "default", NamespacePatterns: []string{"*"}, PerNamespace: true, MaxEvents: 1},
+		},
+	})
+
+	// team-a's namespaces share a single quota
+	q.sample(&model.ChangeEvent{Namespace: "team-a-prod"})
+	if keep, rate := q.sample(&model.ChangeEvent{Namespace: "team-a-dev"}); !keep || rate != 10 {
+		t.Errorf("sample() = %v, %d past the shared quota, want true, 10", keep, rate)
+	}
+
+	// Other namespaces each have their own quota
+	for _, ns := range []string{"search", "billing"} {
+		if keep, rate := q.sample(&model.ChangeEvent{Namespace: ns}); !keep || rate != 1 {
+			t.Errorf("sample() = %v, %d for %s, want true, 1", keep, rate, ns)
+		}
+	}
+
+	status := q.status()
+	if len(status) != 3 {
+		t.Fatalf("Expected 3 quota statuses, got %+v", status)
+	}
+	if status[0].Rule != "team-a" || status[0].Events != 2 || !status[0].Exceeded {
+		t.Errorf("Unexpected team-a status: %+v", status[0])
+	}
+	if status[1].Namespace != "billing" || status[1].Exceeded || status[2].Namespace != "search" {
+		t.Errorf("Unexpected per-namespace statuses: %+v", status[1:])
+	}
+}
+
+func TestQuotaEnforcer_WindowReset(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := newQuotaEnforcer(&config.QuotaConfig{
+		Window: "10m",
+		Rules:  []config.QuotaRule{{Name: "all", NamespacePatterns: []string{"*"}, MaxEvents: 1, SampleRate: 100}},
+	})
+	q.now = func() time.Time { return now }
+	event := &model.ChangeEvent{Namespace: "default"}
+
+	q.sample(event)
+	q.sample(event)
+	if keep, _ := q.sample(event); keep {
+		t.Error("Expected the event to be sampled out past the quota")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if keep, rate := q.sample(event); !keep || rate != 1 {
+		t.Errorf("sample() = %v, %d in a new window, want true, 1", keep, rate)
+	}
+}
+
+func TestQuotaEnforcer_Nil(t *testing.T) {
+	if q := newQuotaEnforcer(nil); q != nil {
+		t.Error("newQuotaEnforcer(nil) should return nil")
+	}
+	var q *quotaEnforcer
+	if keep, rate := q.sample(&model.ChangeEvent{}); !keep || rate != 1 {
+		t.Errorf("nil enforcer sample() = %v, %d, want true, 1", keep, rate)
+	}
+	if q.status() != nil {
+		t.Error("nil enforcer should have no status")
+	}
+}
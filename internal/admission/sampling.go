@@ -0,0 +1,83 @@
+package admission
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// samplingRule is a pre-compiled SamplingRule with its own event counter.
+type samplingRule struct {
+	resourceKinds patternSet
+	namespaces    patternSet
+	operations    []string
+	rate          int
+	seen          atomic.Uint64 // Matching events seen so far
+}
+
+// matches reports whether the event matches all of the rule's non-empty pattern lists.
+func (r *samplingRule) matches(event *model.ChangeEvent) bool {
+	if len(r.resourceKinds) > 0 {
+		if _, ok := r.resourceKinds.match(event.ResourceKind); !ok {
+			return false
+		}
+	}
+	if len(r.namespaces) > 0 {
+		if _, ok := r.namespaces.match(event.Namespace); !ok {
+			return false
+		}
+	}
+	if len(r.operations) > 0 {
+		for _, op := range r.operations {
+			if strings.EqualFold(event.Operation, op) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// sampler decides which events of high-churn resources are recorded.
+type sampler struct {
+	rules []*samplingRule
+}
+
+// newSampler compiles a sampling config. A nil config yields a nil sampler, which records everything.
+func newSampler(cfg *config.SamplingConfig) *sampler {
+	if cfg == nil || len(cfg.Rules) == 0 {
+		return nil
+	}
+	s := &sampler{}
+	for _, rule := range cfg.Rules {
+		rate := rule.Rate
+		if rate < 1 {
+			rate = 1
+		}
+		s.rules = append(s.rules, &samplingRule{
+			resourceKinds: compilePatterns(rule.ResourceKindPatterns),
+			namespaces:    compilePatterns(rule.NamespacePatterns),
+			operations:    rule.OperationPatterns,
+			rate:          rate,
+		})
+	}
+	return s
+}
+
+// sample returns whether the event should be recorded and the sample rate it was recorded at.
+// The first matching rule applies: of its matching events, the 1st, (rate+1)th, ... are recorded.
+// Events matching no rule are always recorded with a rate of 1.
+func (s *sampler) sample(event *model.ChangeEvent) (bool, int) {
+	if s == nil {
+		return true, 1
+	}
+	for _, rule := range s.rules {
+		if rule.matches(event) {
+			n := rule.seen.Add(1)
+			return (n-1)%uint64(rule.rate) == 0, rule.rate
+		}
+	}
+	return true, 1
+}
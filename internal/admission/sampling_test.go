@@ -0,0 +1,151 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestSampler_Sample(t *testing.T) {
+	s := newSampler(&config.SamplingConfig{
+		Rules: []config.SamplingRule{
+			{ResourceKindPatterns: []string{"Endpoints"}, OperationPatterns: []string{"UPDATE"}, Rate: 100},
+			{ResourceKindPatterns: []string{"Lease"}, NamespacePatterns: []string{"kube-*"}, Rate: 10},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		event    *model.ChangeEvent
+		recorded int
+		rate     int
+	}{
+		{
+			name:     "endpoints updates are sampled 1 in 100",
+			event:    &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Endpoints", Namespace: "default"},
+			recorded: 3,
+			rate:     100,
+		},
+		{
+			name:     "operation not in rule is always recorded",
+			event:    &model.ChangeEvent{Operation: "DELETE", ResourceKind: "Endpoints", Namespace: "default"},
+			recorded: 250,
+			rate:     1,
+		},
+		{
+			name:     "namespace pattern is required",
+			event:    &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Lease", Namespace: "kube-node-lease"},
+			recorded: 25,
+			rate:     10,
+		},
+		{
+			name:     "namespace not matching is always recorded",
+			event:    &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Lease", Namespace: "default"},
+			recorded: 250,
+			rate:     1,
+		},
+		{
+			name:     "other kinds are always recorded",
+			event:    &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "default"},
+			recorded: 250,
+			rate:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded := 0
+			for i := 0; i < 250; i++ {
+				keep, rate := s.sample(tt.event)
+				if rate != tt.rate {
+					t.Fatalf("sample() rate = %d, want %d", rate, tt.rate)
+				}
+				if keep {
+					recorded++
+				}
+			}
+			if recorded != tt.recorded {
+				t.Errorf("recorded %d of 250 events, want %d", recorded, tt.recorded)
+			}
+		})
+	}
+}
+
+func TestSampler_Nil(t *testing.T) {
+	if s := newSampler(nil); s != nil {
+		t.Error("newSampler(nil) should return nil")
+	}
+	var s *sampler
+	if keep, rate := s.sample(&model.ChangeEvent{}); !keep || rate != 1 {
+		t.Errorf("nil sampler sample() = %v, %d, want true, 1", keep, rate)
+	}
+}
+
+func TestSampler_InvalidRate(t *testing.T) {
+	s := newSampler(&config.SamplingConfig{Rules: []config.SamplingRule{{ResourceKindPatterns: []string{"*"}, Rate: 0}}})
+	for i := 0; i < 3; i++ {
+		if keep, rate := s.sample(&model.ChangeEvent{ResourceKind: "Pod"}); !keep || rate != 1 {
+			t.Errorf("sample() = %v, %d, want true, 1", keep, rate)
+		}
+	}
+}
+
+func TestHandler_HandleAdmissionReview_Sampling(t *testing.T) {
+	mockStore := &mockStore{}
+	handler := NewHandler(mockStore, nil, nil, nil)
+	handler.SetSamplingConfig(&config.SamplingConfig{
+		Rules: []config.SamplingRule{{ResourceKindPatterns: []string{"Endpoints"}, Rate: 2}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Operation: admissionv1.Update,
+			Kind:      metav1.GroupVersionKind{Kind: "Endpoints"},
+			Namespace: "default",
+			Name:      "kubernetes",
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		handler.HandleAdmissionReview(w, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+		var response admissionv1.AdmissionReview
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !response.Response.Allowed {
+			t.Error("Sampled-out requests should still be allowed")
+		}
+	}
+
+	handler.Start(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if len(mockStore.savedEvents) != 2 {
+		t.Fatalf("Expected 2 saved events, got %d", len(mockStore.savedEvents))
+	}
+	for _, event := range mockStore.savedEvents {
+		if event.SampleRate != 2 {
+			t.Errorf("SampleRate = %d, want 2", event.SampleRate)
+		}
+	}
+}
@@ -0,0 +1,601 @@
+package admission
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"expvar"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/diff"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// OperationSelfMonitoring is the operation of events recording changes to kubechronicle's own
+// setup that may stop it from recording changes, such as its webhook being disabled.
+const OperationSelfMonitoring = "SELF_MONITORING"
+
+// Severities of self-monitoring findings, from least to most severe.
+const (
+	SelfMonitorInfo     = "info"
+	SelfMonitorWarning  = "warning"
+	SelfMonitorCritical = "critical"
+)
+
+// Self-monitoring checks, reported as the Check of findings.
+const (
+	CheckWebhookDeleted    = "webhook_deleted"    // The ValidatingWebhookConfiguration was deleted
+	CheckWebhookMissing    = "webhook_missing"    // The ValidatingWebhookConfiguration did not exist at startup
+	CheckWebhookCreated    = "webhook_created"    // The ValidatingWebhookConfiguration was (re)created
+	CheckWebhookNarrowed   = "webhook_narrowed"   // Webhooks, rules or selectors were removed or changed
+	CheckWebhookRedirected = "webhook_redirected" // Requests are sent elsewhere, or the CA bundle was removed
+	CheckWebhookChanged    = "webhook_changed"    // Any other change to the webhooks
+	CheckIgnoreWidened     = "ignore_widened"     // Ignore patterns were added to a patterns ConfigMap
+	CheckConfigChanged     = "config_changed"     // Any other change to a patterns ConfigMap
+	CheckConfigDeleted     = "config_deleted"     // A patterns ConfigMap was deleted
+	CheckCertExpiry        = "cert_expiry"        // The TLS certificate expires soon or has expired
+)
+
+// Certificate expiry thresholds: a warning when the served certificate expires within
+// certExpiryWarning, and a critical finding within certExpiryCritical.
+const (
+	certExpiryWarning  = 14 * 24 * time.Hour
+	certExpiryCritical = 3 * 24 * time.Hour
+	certCheckInterval  = time.Hour
+)
+
+// selfMonitorFindings counts self-monitoring events emitted.
+var selfMonitorFindings = expvar.NewInt("webhook_self_monitor_findings_total")
+
+// selfMonitor watches kubechronicle's own webhook configuration, patterns ConfigMaps and TLS
+// certificate.
+type selfMonitor struct {
+	clientset         kubernetes.Interface // nil only checks the certificate
+	webhookConfigName string
+	certPath          string // "" skips the certificate check
+
+	certNotAfter time.Time // Expiry of the last checked certificate
+	certLevel    int       // Most severe expiry threshold reported for that certificate
+}
+
+// selfMonitorIssue is a single problem found by a self-monitoring check.
+type selfMonitorIssue struct {
+	check    string
+	severity string
+	message  string
+}
+
+// SetSelfMonitor makes Start watch the named ValidatingWebhookConfiguration, the patterns
+// ConfigMaps set with SetConfigMapWatch and the TLS certificate at certPath, and record a
+// SELF_MONITORING event (saved and alerted like any other) when the webhook is deleted,
+// narrowed or redirected, ignore patterns are added, or the certificate nears expiry.
+// A nil clientset only checks the certificate. Must be called after SetConfigMapWatch and
+// before Start.
+func (h *Handler) SetSelfMonitor(clientset kubernetes.Interface, webhookConfigName, certPath string) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.selfMonitor = &selfMonitor{
+		clientset:         clientset,
+		webhookConfigName: webhookConfigName,
+		certPath:          certPath,
+	}
+}
+
+// runSelfMonitor watches the webhook configuration and patterns ConfigMaps, and checks the
+// certificate periodically, until ctx is done.
+func (h *Handler) runSelfMonitor(ctx context.Context) {
+	monitor := h.selfMonitor
+
+	if monitor.clientset != nil {
+		factories := []informers.SharedInformerFactory{h.watchWebhookConfiguration(ctx)}
+		if watch := h.configWatch; watch != nil {
+			factories = append(factories, h.watchPatternsConfigMap(ctx, watch.namespace, watch.name, "patterns ConfigMap, current patterns are kept"))
+			for _, layer := range h.overlays {
+				factories = append(factories, h.watchPatternsConfigMap(ctx, watch.namespace, layer.name, "overlay ConfigMap, its patterns were removed"))
+			}
+		}
+		defer func() {
+			for _, factory := range factories {
+				factory.Shutdown()
+			}
+		}()
+	}
+
+	if monitor.certPath == "" {
+		<-ctx.Done()
+		return
+	}
+	h.checkCertExpiry(time.Now())
+	ticker := time.NewTicker(certCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.checkCertExpiry(now)
+		}
+	}
+}
+
+// watchWebhookConfiguration starts an informer on the webhook's ValidatingWebhookConfiguration
+// and reports it missing once synced if it doesn't exist.
+func (h *Handler) watchWebhookConfiguration(ctx context.Context) informers.SharedInformerFactory {
+	name := h.selfMonitor.webhookConfigName
+	factory := informers.NewSharedInformerFactoryWithOptions(h.selfMonitor.clientset, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			// Only this configuration, which also lets RBAC restrict the webhook to it
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	informer := factory.Admissionregistration().V1().ValidatingWebhookConfigurations().Informer()
+
+	var synced atomic.Bool
+	informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			webhookConfig, ok := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			if !ok || isInInitialList || !synced.Load() {
+				return
+			}
+			h.recordSelfMonitor("ValidatingWebhookConfiguration", "", webhookConfig.Name, fieldManager(webhookConfig), nil, nil, []selfMonitorIssue{{
+				CheckWebhookCreated, SelfMonitorInfo, fmt.Sprintf("ValidatingWebhookConfiguration %s was created", webhookConfig.Name),
+			}})
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldConfig, ok1 := oldObj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			newConfig, ok2 := newObj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			if !ok1 || !ok2 || oldConfig.ResourceVersion == newConfig.ResourceVersion {
+				return
+			}
+			if issues := compareWebhookConfigurations(oldConfig, newConfig); len(issues) > 0 {
+				h.recordSelfMonitor("ValidatingWebhookConfiguration", "", newConfig.Name, fieldManager(newConfig),
+					objectDiff(oldConfig, newConfig, "ValidatingWebhookConfiguration"), nil, issues)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			webhookConfig, ok := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration)
+			if !ok {
+				return
+			}
+			h.recordSelfMonitor("ValidatingWebhookConfiguration", "", webhookConfig.Name, "", nil, h.snapshot(webhookConfig, "ValidatingWebhookConfiguration"), []selfMonitorIssue{{
+				CheckWebhookDeleted, SelfMonitorCritical,
+				fmt.Sprintf("ValidatingWebhookConfiguration %s was deleted, changes are no longer recorded", webhookConfig.Name),
+			}})
+		},
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		klog.Errorf("Failed to sync ValidatingWebhookConfiguration %s informer, the webhook will not be monitored", name)
+		return factory
+	}
+	synced.Store(true)
+	if _, exists, err := informer.GetStore().GetByKey(name); err == nil && !exists {
+		h.recordSelfMonitor("ValidatingWebhookConfiguration", "", name, "", nil, nil, []selfMonitorIssue{{
+			CheckWebhookMissing, SelfMonitorCritical,
+			fmt.Sprintf("ValidatingWebhookConfiguration %s does not exist, changes are not recorded", name),
+		}})
+	}
+	klog.Infof("Watching ValidatingWebhookConfiguration %s for misconfiguration", name)
+	return factory
+}
+
+// watchPatternsConfigMap starts an informer on a patterns ConfigMap, reporting added ignore
+// patterns, other changes and its deletion. deleted describes the ConfigMap and the effect of
+// deleting it.
+func (h *Handler) watchPatternsConfigMap(ctx context.Context, namespace, name, deleted string) informers.SharedInformerFactory {
+	factory := informers.NewSharedInformerFactoryWithOptions(h.selfMonitor.clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+	factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldCM, ok1 := oldObj.(*corev1.ConfigMap)
+			newCM, ok2 := newObj.(*corev1.ConfigMap)
+			if !ok1 || !ok2 || reflect.DeepEqual(oldCM.Data, newCM.Data) {
+				return
+			}
+			h.recordSelfMonitor("ConfigMap", newCM.Namespace, newCM.Name, fieldManager(newCM),
+				objectDiff(oldCM, newCM, "ConfigMap"), nil, compareIgnoreConfigs(newCM, oldCM.Data, newCM.Data))
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cm, ok := obj.(*corev1.ConfigMap); ok {
+				h.recordSelfMonitor("ConfigMap", cm.Namespace, cm.Name, "", nil, h.snapshot(cm, "ConfigMap"), []selfMonitorIssue{{
+					CheckConfigDeleted, SelfMonitorWarning, fmt.Sprintf("Deleted %s", deleted),
+				}})
+			}
+		},
+	})
+	factory.Start(ctx.Done())
+	return factory
+}
+
+// compareWebhookConfigurations returns the issues of a change to the webhook configuration:
+// webhooks or rules that were removed, selectors and match conditions that changed, and
+// requests sent to another endpoint. Changes to metadata only are not reported.
+func compareWebhookConfigurations(oldConfig, newConfig *admissionregistrationv1.ValidatingWebhookConfiguration) []selfMonitorIssue {
+	if reflect.DeepEqual(oldConfig.Webhooks, newConfig.Webhooks) {
+		return nil
+	}
+
+	var issues []selfMonitorIssue
+	for _, oldWebhook := range oldConfig.Webhooks {
+		var newWebhook *admissionregistrationv1.ValidatingWebhook
+		for i := range newConfig.Webhooks {
+			if newConfig.Webhooks[i].Name == oldWebhook.Name {
+				newWebhook = &newConfig.Webhooks[i]
+				break
+			}
+		}
+		if newWebhook == nil {
+			issues = append(issues, selfMonitorIssue{CheckWebhookNarrowed, SelfMonitorCritical,
+				fmt.Sprintf("Webhook %s was removed", oldWebhook.Name)})
+			continue
+		}
+		issues = append(issues, compareWebhooks(&oldWebhook, newWebhook)...)
+	}
+
+	if len(issues) == 0 {
+		issues = append(issues, selfMonitorIssue{CheckWebhookChanged, SelfMonitorInfo,
+			fmt.Sprintf("Webhooks of %s were changed", newConfig.Name)})
+	}
+	return issues
+}
+
+// compareWebhooks returns the issues of a change to a single webhook.
+func compareWebhooks(oldWebhook, newWebhook *admissionregistrationv1.ValidatingWebhook) []selfMonitorIssue {
+	var issues []selfMonitorIssue
+	name := oldWebhook.Name
+
+	if uncovered := uncoveredRules(oldWebhook.Rules, newWebhook.Rules); len(uncovered) > 0 {
+		message := fmt.Sprintf("Webhook %s no longer intercepts %s", name, strings.Join(uncovered, ", "))
+		if len(uncovered) > 5 {
+			message = fmt.Sprintf("Webhook %s no longer intercepts %s and %d more", name, strings.Join(uncovered[:5], ", "), len(uncovered)-5)
+		}
+		issues = append(issues, selfMonitorIssue{CheckWebhookNarrowed, SelfMonitorCritical, message})
+	}
+	if !reflect.DeepEqual(oldWebhook.NamespaceSelector, newWebhook.NamespaceSelector) {
+		issues = append(issues, selfMonitorIssue{CheckWebhookNarrowed, SelfMonitorWarning,
+			fmt.Sprintf("Namespace selector of webhook %s changed from %s to %s", name,
+				metav1.FormatLabelSelector(oldWebhook.NamespaceSelector), metav1.FormatLabelSelector(newWebhook.NamespaceSelector))})
+	}
+	if !reflect.DeepEqual(oldWebhook.ObjectSelector, newWebhook.ObjectSelector) {
+		issues = append(issues, selfMonitorIssue{CheckWebhookNarrowed, SelfMonitorWarning,
+			fmt.Sprintf("Object selector of webhook %s changed from %s to %s", name,
+				metav1.FormatLabelSelector(oldWebhook.ObjectSelector), metav1.FormatLabelSelector(newWebhook.ObjectSelector))})
+	}
+	if !reflect.DeepEqual(oldWebhook.MatchConditions, newWebhook.MatchConditions) {
+		issues = append(issues, selfMonitorIssue{CheckWebhookNarrowed, SelfMonitorWarning,
+			fmt.Sprintf("Match conditions of webhook %s changed", name)})
+	}
+
+	oldClient, newClient := oldWebhook.ClientConfig, newWebhook.ClientConfig
+	if !reflect.DeepEqual(oldClient.URL, newClient.URL) || !reflect.DeepEqual(oldClient.Service, newClient.Service) {
+		issues = append(issues, selfMonitorIssue{CheckWebhookRedirected, SelfMonitorCritical,
+			fmt.Sprintf("Webhook %s now sends requests to %s instead of %s", name, clientConfigTarget(newClient), clientConfigTarget(oldClient))})
+	}
+	if len(oldClient.CABundle) > 0 && len(newClient.CABundle) == 0 {
+		issues = append(issues, selfMonitorIssue{CheckWebhookRedirected, SelfMonitorCritical,
+			fmt.Sprintf("CA bundle of webhook %s was removed, the API server can no longer verify the webhook", name)})
+	}
+	return issues
+}
+
+// uncoveredRules returns the operations on resources matched by oldRules that newRules no
+// longer match, as "OPERATION group/version/resource".
+func uncoveredRules(oldRules, newRules []admissionregistrationv1.RuleWithOperations) []string {
+	var uncovered []string
+	for _, oldRule := range oldRules {
+		for _, op := range oldRule.Operations {
+			for _, group := range oldRule.APIGroups {
+				for _, version := range oldRule.APIVersions {
+					for _, resource := range oldRule.Resources {
+						covered := false
+						for _, newRule := range newRules {
+							if ruleMatches(newRule, op, group, version, resource, oldRule.Scope) {
+								covered = true
+								break
+							}
+						}
+						if !covered {
+							gvr := group + "/" + version + "/" + resource
+							if group == "" {
+								gvr = version + "/" + resource
+							}
+							uncovered = append(uncovered, fmt.Sprintf("%s %s", op, gvr))
+						}
+					}
+				}
+			}
+		}
+	}
+	return uncovered
+}
+
+// ruleMatches reports whether rule matches all requests of an old rule's operation, group,
+// version, resource and scope, any of which may be a wildcard.
+func ruleMatches(rule admissionregistrationv1.RuleWithOperations, op admissionregistrationv1.OperationType, group, version, resource string, scope *admissionregistrationv1.ScopeType) bool {
+	opMatches := false
+	for _, o := range rule.Operations {
+		if o == op || o == admissionregistrationv1.OperationAll {
+			opMatches = true
+			break
+		}
+	}
+	if !opMatches || !containsOrWildcard(rule.APIGroups, group) || !containsOrWildcard(rule.APIVersions, version) {
+		return false
+	}
+
+	resourceMatches := false
+	for _, r := range rule.Resources {
+		// "*" matches resources but not subresources, "*/*" matches both
+		if r == resource || r == "*/*" || (r == "*" && !strings.Contains(resource, "/")) {
+			resourceMatches = true
+			break
+		}
+		if strings.HasSuffix(r, "/*") && strings.HasPrefix(resource, strings.TrimSuffix(r, "*")) {
+			resourceMatches = true
+			break
+		}
+	}
+	if !resourceMatches {
+		return false
+	}
+
+	if rule.Scope == nil || *rule.Scope == admissionregistrationv1.AllScopes {
+		return true
+	}
+	return scope != nil && *scope == *rule.Scope
+}
+
+// containsOrWildcard reports whether values contains v or "*".
+func containsOrWildcard(values []string, v string) bool {
+	for _, value := range values {
+		if value == v || value == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// clientConfigTarget describes where a webhook sends requests.
+func clientConfigTarget(clientConfig admissionregistrationv1.WebhookClientConfig) string {
+	if clientConfig.URL != nil {
+		return *clientConfig.URL
+	}
+	if service := clientConfig.Service; service != nil {
+		target := "service " + service.Namespace + "/" + service.Name
+		if service.Port != nil {
+			target += fmt.Sprintf(":%d", *service.Port)
+		}
+		if service.Path != nil {
+			target += *service.Path
+		}
+		return target
+	}
+	return "nowhere"
+}
+
+// compareIgnoreConfigs returns the issues of a change to a patterns ConfigMap: a warning
+// listing the ignore patterns added, or an info finding for any other change.
+func compareIgnoreConfigs(cm *corev1.ConfigMap, oldData, newData map[string]string) []selfMonitorIssue {
+	oldIgnore, newIgnore := &config.IgnoreConfig{}, &config.IgnoreConfig{}
+	if raw := strings.TrimSpace(oldData["IGNORE_CONFIG"]); raw != "" {
+		if parsed, err := parseIgnoreConfig(raw); err == nil {
+			oldIgnore = parsed
+		}
+	}
+	if raw := strings.TrimSpace(newData["IGNORE_CONFIG"]); raw != "" {
+		if parsed, err := parseIgnoreConfig(raw); err == nil {
+			newIgnore = parsed
+		}
+	}
+
+	var added []string
+	for _, field := range []struct {
+		name     string
+		old, new []string
+	}{
+		{"namespace_patterns", oldIgnore.NamespacePatterns, newIgnore.NamespacePatterns},
+		{"name_patterns", oldIgnore.NamePatterns, newIgnore.NamePatterns},
+		{"resource_kind_patterns", oldIgnore.ResourceKindPatterns, newIgnore.ResourceKindPatterns},
+	} {
+		for _, pattern := range field.new {
+			if !containsString(field.old, pattern) {
+				added = append(added, fmt.Sprintf("%s %q", field.name, pattern))
+			}
+		}
+	}
+	if len(added) > 0 {
+		return []selfMonitorIssue{{CheckIgnoreWidened, SelfMonitorWarning,
+			fmt.Sprintf("ConfigMap %s/%s now ignores changes matching %s", cm.Namespace, cm.Name, strings.Join(added, ", "))}}
+	}
+	return []selfMonitorIssue{{CheckConfigChanged, SelfMonitorInfo,
+		fmt.Sprintf("ConfigMap %s/%s was changed", cm.Namespace, cm.Name)}}
+}
+
+// checkCertExpiry records a finding when the served certificate crosses an expiry threshold,
+// once per threshold and certificate, so a rotated certificate is checked afresh.
+func (h *Handler) checkCertExpiry(now time.Time) {
+	monitor := h.selfMonitor
+	notAfter, err := loadCertExpiry(monitor.certPath)
+	if err != nil {
+		klog.Warningf("Failed to check TLS certificate expiry: %v", err)
+		return
+	}
+	if !notAfter.Equal(monitor.certNotAfter) {
+		monitor.certNotAfter = notAfter
+		monitor.certLevel = 0
+		certExpiry.Set(notAfter.Unix())
+	}
+
+	// Each threshold is reported once: expiring soon, expiring very soon, then expired
+	remaining := notAfter.Sub(now)
+	var level int
+	var issue selfMonitorIssue
+	switch {
+	case remaining <= 0:
+		level, issue = 3, selfMonitorIssue{CheckCertExpiry, SelfMonitorCritical,
+			fmt.Sprintf("TLS certificate expired at %s, the API server can no longer call the webhook", notAfter.Format(time.RFC3339))}
+	case remaining <= certExpiryCritical:
+		level, issue = 2, selfMonitorIssue{CheckCertExpiry, SelfMonitorCritical,
+			fmt.Sprintf("TLS certificate expires at %s", notAfter.Format(time.RFC3339))}
+	case remaining <= certExpiryWarning:
+		level, issue = 1, selfMonitorIssue{CheckCertExpiry, SelfMonitorWarning,
+			fmt.Sprintf("TLS certificate expires at %s", notAfter.Format(time.RFC3339))}
+	}
+	if level <= monitor.certLevel {
+		return
+	}
+	monitor.certLevel = level
+	h.recordSelfMonitor("Certificate", "", monitor.certPath, "", nil, nil, []selfMonitorIssue{issue})
+}
+
+// loadCertExpiry returns the expiry of the first certificate in a PEM file.
+func loadCertExpiry(certPath string) (time.Time, error) {
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("no PEM certificate in %s", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+// severityRank orders severities from least to most severe.
+func severityRank(severity string) int {
+	switch severity {
+	case SelfMonitorWarning:
+		return 1
+	case SelfMonitorCritical:
+		return 2
+	}
+	return 0
+}
+
+// recordSelfMonitor queues a SELF_MONITORING event for the issues of a change, the most
+// severe first, so it is saved and alerted like any other event.
+func (h *Handler) recordSelfMonitor(kind, namespace, name, manager string, patches []model.PatchOp, snapshot map[string]interface{}, issues []selfMonitorIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return severityRank(issues[i].severity) > severityRank(issues[j].severity)
+	})
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.message
+	}
+
+	event := &model.ChangeEvent{
+		Timestamp:      time.Now().UTC(),
+		Operation:      OperationSelfMonitoring,
+		ResourceKind:   kind,
+		Namespace:      namespace,
+		Name:           name,
+		Source:         model.Source{Tool: "kubechronicle"},
+		Diff:           patches,
+		ObjectSnapshot: snapshot,
+		Allowed:        true,
+		SelfMonitor: &model.SelfMonitorFinding{
+			Check:        issues[0].check,
+			Severity:     issues[0].severity,
+			Message:      strings.Join(messages, "; "),
+			FieldManager: manager,
+		},
+	}
+	event.ID = model.NewEventID(event.Timestamp)
+
+	selfMonitorFindings.Add(1)
+	if issues[0].severity == SelfMonitorInfo {
+		klog.Infof("Self-monitoring: %s", event.SelfMonitor.Message)
+	} else {
+		klog.Warningf("Self-monitoring (%s): %s", issues[0].severity, event.SelfMonitor.Message)
+	}
+
+	select {
+	case h.queue <- &queuedEvent{event: event}:
+		queueLength.Set(int64(len(h.queue)))
+	default:
+		droppedEvents.Add(1)
+		klog.Warningf("Event queue full, dropping self-monitoring event: %s", event.ID)
+	}
+}
+
+// fieldManager returns the manager of the most recent managed fields entry of obj, which
+// names the tool that last changed it (e.g. "kubectl-edit"), or "" if unknown.
+func fieldManager(obj metav1.Object) string {
+	var manager string
+	var latest time.Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && !entry.Time.Time.Before(latest) {
+			latest = entry.Time.Time
+			manager = entry.Manager
+		}
+	}
+	return manager
+}
+
+// objectMap converts a typed object to its JSON map, or nil if it can't be converted.
+func objectMap(obj runtime.Object) map[string]interface{} {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		klog.V(2).Infof("Failed to convert %T for self-monitoring: %v", obj, err)
+		return nil
+	}
+	return m
+}
+
+// snapshot returns the snapshot of a deleted object, without noise fields.
+func (h *Handler) snapshot(obj runtime.Object, kind string) map[string]interface{} {
+	m := objectMap(obj)
+	if m == nil {
+		return nil
+	}
+	return h.decoder.filterSnapshot(m, kind)
+}
+
+// objectDiff returns the patch from oldObj to newObj, without noise fields.
+func objectDiff(oldObj, newObj runtime.Object, kind string) []model.PatchOp {
+	oldMap, newMap := objectMap(oldObj), objectMap(newObj)
+	if oldMap == nil || newMap == nil {
+		return nil
+	}
+	patches, err := diff.ComputeDiff(oldMap, newMap, kind)
+	if err != nil {
+		klog.V(2).Infof("Failed to compute diff of %s for self-monitoring: %v", kind, err)
+		return nil
+	}
+	return patches
+}
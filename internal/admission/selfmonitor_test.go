@@ -0,0 +1,309 @@
+package admission
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// testWebhookConfiguration returns a webhook configuration intercepting changes to
+// deployments and configmaps, sent to the kubechronicle service.
+func testWebhookConfiguration() *admissionregistrationv1.ValidatingWebhookConfiguration {
+	path := "/validate"
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubechronicle-webhook", ResourceVersion: "1"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name: "kubechronicle.k8s.io",
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service:  &admissionregistrationv1.ServiceReference{Namespace: "kubechronicle", Name: "kubechronicle-webhook", Path: &path},
+				CABundle: []byte("ca"),
+			},
+			Rules: []admissionregistrationv1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete},
+					Rule:       admissionregistrationv1.Rule{APIGroups: []string{"apps"}, APIVersions: []string{"v1"}, Resources: []string{"deployments"}},
+				},
+				{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete},
+					Rule:       admissionregistrationv1.Rule{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"configmaps"}},
+				},
+			},
+		}},
+	}
+}
+
+// nextSelfMonitorEvent returns the next queued self-monitoring event, or nil after a timeout.
+func nextSelfMonitorEvent(t *testing.T, handler *Handler) *model.ChangeEvent {
+	t.Helper()
+	select {
+	case item := <-handler.queue:
+		return item.event
+	case <-time.After(5 * time.Second):
+		return nil
+	}
+}
+
+func TestCompareWebhookConfigurations(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *admissionregistrationv1.ValidatingWebhookConfiguration)
+		check    string
+		severity string
+		message  string
+	}{
+		{
+			name: "metadata only",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				c.Labels = map[string]string{"team": "platform"}
+			},
+		},
+		{
+			name:     "webhook removed",
+			change:   func(c *admissionregistrationv1.ValidatingWebhookConfiguration) { c.Webhooks = nil },
+			check:    CheckWebhookNarrowed,
+			severity: SelfMonitorCritical,
+			message:  "Webhook kubechronicle.k8s.io was removed",
+		},
+		{
+			name: "operation removed",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				c.Webhooks[0].Rules[0].Operations = []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}
+			},
+			check:    CheckWebhookNarrowed,
+			severity: SelfMonitorCritical,
+			message:  "no longer intercepts DELETE apps/v1/deployments",
+		},
+		{
+			name: "rules broadened",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				c.Webhooks[0].Rules = []admissionregistrationv1.RuleWithOperations{{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.OperationAll},
+					Rule:       admissionregistrationv1.Rule{APIGroups: []string{"*"}, APIVersions: []string{"*"}, Resources: []string{"*"}},
+				}}
+			},
+			check:    CheckWebhookChanged,
+			severity: SelfMonitorInfo,
+		},
+		{
+			name: "namespace selector added",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				c.Webhooks[0].NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"audited": "true"}}
+			},
+			check:    CheckWebhookNarrowed,
+			severity: SelfMonitorWarning,
+			message:  "changed from <none> to audited=true",
+		},
+		{
+			name: "redirected",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				url := "https://elsewhere.example.com/validate"
+				c.Webhooks[0].ClientConfig.Service = nil
+				c.Webhooks[0].ClientConfig.URL = &url
+			},
+			check:    CheckWebhookRedirected,
+			severity: SelfMonitorCritical,
+			message:  "now sends requests to https://elsewhere.example.com/validate instead of service kubechronicle/kubechronicle-webhook/validate",
+		},
+		{
+			name: "CA bundle removed",
+			change: func(c *admissionregistrationv1.ValidatingWebhookConfiguration) {
+				c.Webhooks[0].ClientConfig.CABundle = nil
+			},
+			check:    CheckWebhookRedirected,
+			severity: SelfMonitorCritical,
+			message:  "CA bundle of webhook kubechronicle.k8s.io was removed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldConfig := testWebhookConfiguration()
+			newConfig := testWebhookConfiguration()
+			tt.change(newConfig)
+
+			issues := compareWebhookConfigurations(oldConfig, newConfig)
+			if tt.check == "" {
+				if len(issues) != 0 {
+					t.Errorf("Expected no issues, got %+v", issues)
+				}
+				return
+			}
+			if len(issues) != 1 {
+				t.Fatalf("Expected 1 issue, got %+v", issues)
+			}
+			if issues[0].check != tt.check || issues[0].severity != tt.severity || !strings.Contains(issues[0].message, tt.message) {
+				t.Errorf("Got %+v, want %s (%s) containing %q", issues[0], tt.check, tt.severity, tt.message)
+			}
+		})
+	}
+}
+
+func TestCompareIgnoreConfigs(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kubechronicle", Name: "kubechronicle-patterns"}}
+
+	issues := compareIgnoreConfigs(cm,
+		map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*"]}`},
+		map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*", "prod"], "resource_kind_patterns": ["Secret"]}`})
+	if len(issues) != 1 || issues[0].check != CheckIgnoreWidened || issues[0].severity != SelfMonitorWarning {
+		t.Fatalf("Expected an ignore_widened warning, got %+v", issues)
+	}
+	if want := `namespace_patterns "prod", resource_kind_patterns "Secret"`; !strings.Contains(issues[0].message, want) {
+		t.Errorf("Message %q should contain %q", issues[0].message, want)
+	}
+
+	// Removing ignore patterns records more, so it is only reported as a change
+	issues = compareIgnoreConfigs(cm,
+		map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*"]}`},
+		map[string]string{"BLOCK_CONFIG": `{"name_patterns": ["critical-*"]}`})
+	if len(issues) != 1 || issues[0].check != CheckConfigChanged || issues[0].severity != SelfMonitorInfo {
+		t.Errorf("Expected a config_changed finding, got %+v", issues)
+	}
+}
+
+func TestHandler_SelfMonitorWebhookConfiguration(t *testing.T) {
+	clientset := fake.NewSimpleClientset(testWebhookConfiguration())
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetSelfMonitor(clientset, "kubechronicle-webhook", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.runSelfMonitor(ctx)
+
+	// The existing configuration is not reported; wait for the informer to list it
+	if !waitFor(t, func() bool {
+		return len(clientset.Actions()) >= 2 // list and watch
+	}) {
+		t.Fatal("Informer did not start")
+	}
+
+	narrowed := testWebhookConfiguration()
+	narrowed.ResourceVersion = "2"
+	narrowed.Webhooks[0].Rules = narrowed.Webhooks[0].Rules[:1]
+	narrowed.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "helm", Time: &metav1.Time{Time: time.Now().Add(-time.Hour)}},
+		{Manager: "kubectl-edit", Time: &metav1.Time{Time: time.Now()}},
+	}
+	if _, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(ctx, narrowed, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update webhook configuration: %v", err)
+	}
+
+	event := nextSelfMonitorEvent(t, handler)
+	if event == nil {
+		t.Fatal("Expected a self-monitoring event for the narrowed webhook")
+	}
+	if event.Operation != OperationSelfMonitoring || event.ResourceKind != "ValidatingWebhookConfiguration" || event.Name != "kubechronicle-webhook" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	finding := event.SelfMonitor
+	if finding == nil || finding.Check != CheckWebhookNarrowed || finding.Severity != SelfMonitorCritical || finding.FieldManager != "kubectl-edit" {
+		t.Fatalf("Unexpected finding: %+v", finding)
+	}
+	if !strings.Contains(finding.Message, "DELETE v1/configmaps") {
+		t.Errorf("Message %q should name the removed rule", finding.Message)
+	}
+	if len(event.Diff) == 0 {
+		t.Error("Expected the diff of the webhook configuration")
+	}
+
+	if err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(ctx, "kubechronicle-webhook", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete webhook configuration: %v", err)
+	}
+	event = nextSelfMonitorEvent(t, handler)
+	if event == nil || event.SelfMonitor.Check != CheckWebhookDeleted || event.SelfMonitor.Severity != SelfMonitorCritical {
+		t.Fatalf("Expected a webhook_deleted event, got %+v", event)
+	}
+	if event.ObjectSnapshot == nil {
+		t.Error("Expected a snapshot of the deleted configuration")
+	}
+}
+
+func TestHandler_SelfMonitorMissingWebhookConfiguration(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetSelfMonitor(fake.NewSimpleClientset(), "kubechronicle-webhook", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.runSelfMonitor(ctx)
+
+	event := nextSelfMonitorEvent(t, handler)
+	if event == nil || event.SelfMonitor.Check != CheckWebhookMissing || event.SelfMonitor.Severity != SelfMonitorCritical {
+		t.Fatalf("Expected a webhook_missing event, got %+v", event)
+	}
+}
+
+func TestHandler_CheckCertExpiry(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	now := time.Now()
+	certPath := filepath.Join(t.TempDir(), "tls.crt")
+	writeCert := func(notAfter time.Time) {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "kubechronicle-webhook"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("Failed to create certificate: %v", err)
+		}
+		os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	}
+
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetSelfMonitor(nil, "", certPath)
+
+	// Far from expiry, nothing is reported
+	writeCert(now.Add(60 * 24 * time.Hour))
+	handler.checkCertExpiry(now)
+	if len(handler.queue) != 0 {
+		t.Fatalf("Expected no findings, got %d", len(handler.queue))
+	}
+
+	// Each threshold is reported once
+	writeCert(now.Add(10 * 24 * time.Hour))
+	handler.checkCertExpiry(now)
+	handler.checkCertExpiry(now.Add(time.Hour))
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(handler.queue))
+	}
+	if event := (<-handler.queue).event; event.SelfMonitor.Check != CheckCertExpiry || event.SelfMonitor.Severity != SelfMonitorWarning {
+		t.Errorf("Expected a cert_expiry warning, got %+v", event.SelfMonitor)
+	}
+
+	handler.checkCertExpiry(now.Add(8 * 24 * time.Hour))
+	handler.checkCertExpiry(now.Add(11 * 24 * time.Hour))
+	if len(handler.queue) != 2 {
+		t.Fatalf("Expected critical and expired findings, got %d", len(handler.queue))
+	}
+	<-handler.queue
+	if event := (<-handler.queue).event; event.SelfMonitor.Severity != SelfMonitorCritical || !strings.Contains(event.SelfMonitor.Message, "expired") {
+		t.Errorf("Expected an expired finding, got %+v", event.SelfMonitor)
+	}
+
+	// A rotated certificate is checked afresh
+	writeCert(now.Add(5 * 24 * time.Hour))
+	handler.checkCertExpiry(now)
+	if len(handler.queue) != 1 {
+		t.Errorf("Expected the rotated certificate to be reported, got %d findings", len(handler.queue))
+	}
+}
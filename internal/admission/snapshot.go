@@ -0,0 +1,113 @@
+package admission
+
+import (
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+// identityFields are the fields kept in every snapshot restricted to included fields, so
+// the object stays identifiable.
+var identityFields = [][]string{{"apiVersion"}, {"kind"}, {"metadata", "name"}, {"metadata", "namespace"}}
+
+// snapshotRule is a pre-compiled SnapshotRule.
+type snapshotRule struct {
+	resourceKinds patternSet
+	include       [][]string // Paths of the fields kept; every field when empty
+	exclude       [][]string // Paths of the fields removed
+	keepNoise     bool
+}
+
+// newSnapshotRules compiles a snapshot config. A nil config yields no rules.
+func newSnapshotRules(cfg *config.SnapshotConfig) []*snapshotRule {
+	if cfg == nil {
+		return nil
+	}
+	var rules []*snapshotRule
+	for _, rule := range cfg.Rules {
+		compiled := &snapshotRule{
+			resourceKinds: compilePatterns(rule.ResourceKindPatterns),
+			keepNoise:     rule.KeepNoise,
+		}
+		for _, path := range rule.IncludeFields {
+			compiled.include = append(compiled.include, strings.Split(path, "."))
+		}
+		for _, path := range rule.ExcludeFields {
+			compiled.exclude = append(compiled.exclude, strings.Split(path, "."))
+		}
+		rules = append(rules, compiled)
+	}
+	return rules
+}
+
+// matchSnapshotRule returns the first rule matching resourceKind, or nil.
+func matchSnapshotRule(rules []*snapshotRule, resourceKind string) *snapshotRule {
+	for _, rule := range rules {
+		if _, ok := rule.resourceKinds.match(resourceKind); ok {
+			return rule
+		}
+	}
+	return nil
+}
+
+// apply returns obj with only the rule's included fields, less its excluded fields. obj
+// isn't modified.
+func (r *snapshotRule) apply(obj map[string]interface{}) map[string]interface{} {
+	if len(r.include) > 0 {
+		included := map[string]interface{}{}
+		for _, path := range identityFields {
+			copyField(included, obj, path)
+		}
+		for _, path := range r.include {
+			copyField(included, obj, path)
+		}
+		obj = included
+	}
+	for _, path := range r.exclude {
+		obj = removeField(obj, path)
+	}
+	return obj
+}
+
+// copyField copies the field at path from src to dst, creating the maps along the path.
+func copyField(dst, src map[string]interface{}, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+	srcChild, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	dstChild, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		dstChild = map[string]interface{}{}
+		dst[path[0]] = dstChild
+	}
+	copyField(dstChild, srcChild, path[1:])
+}
+
+// removeField returns obj without the field at path, copying the maps along the path
+// instead of modifying them.
+func removeField(obj map[string]interface{}, path []string) map[string]interface{} {
+	value, ok := obj[path[0]]
+	if !ok {
+		return obj
+	}
+	result := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		result[k] = v
+	}
+	if len(path) == 1 {
+		delete(result, path[0])
+		return result
+	}
+	if child, ok := value.(map[string]interface{}); ok {
+		result[path[0]] = removeField(child, path[1:])
+	}
+	return result
+}
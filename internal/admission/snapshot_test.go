@@ -0,0 +1,108 @@
+package admission
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+func TestDecoder_FilterSnapshot_Rules(t *testing.T) {
+	decoder := NewDecoder()
+	decoder.SetSnapshotConfig(&config.SnapshotConfig{Rules: []config.SnapshotRule{
+		{ResourceKindPatterns: []string{"Deployment"}, IncludeFields: []string{"spec", "metadata.labels"}},
+		{ResourceKindPatterns: []string{"Widget"}, KeepNoise: true, ExcludeFields: []string{"status.conditions"}},
+		{ResourceKindPatterns: []string{"Secret"}, KeepNoise: true},
+	}})
+
+	object := func() map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Thing",
+			"metadata": map[string]interface{}{
+				"name":            "web",
+				"namespace":       "default",
+				"labels":          map[string]interface{}{"app": "web"},
+				"resourceVersion": "42",
+			},
+			"spec":   map[string]interface{}{"replicas": float64(3)},
+			"status": map[string]interface{}{"conditions": []interface{}{"Ready"}, "phase": "Running"},
+		}
+	}
+
+	tests := []struct {
+		name string
+		kind string
+		want map[string]interface{}
+	}{
+		{
+			name: "included fields only",
+			kind: "Deployment",
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Thing",
+				"metadata": map[string]interface{}{
+					"name":      "web",
+					"namespace": "default",
+					"labels":    map[string]interface{}{"app": "web"},
+				},
+				"spec": map[string]interface{}{"replicas": float64(3)},
+			},
+		},
+		{
+			name: "full object less excluded fields",
+			kind: "Widget",
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Thing",
+				"metadata": map[string]interface{}{
+					"name":            "web",
+					"namespace":       "default",
+					"labels":          map[string]interface{}{"app": "web"},
+					"resourceVersion": "42",
+				},
+				"spec":   map[string]interface{}{"replicas": float64(3)},
+				"status": map[string]interface{}{"phase": "Running"},
+			},
+		},
+		{
+			name: "no rule removes noise fields",
+			kind: "ConfigMap",
+			want: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Thing",
+				"metadata": map[string]interface{}{
+					"name":      "web",
+					"namespace": "default",
+					"labels":    map[string]interface{}{"app": "web"},
+				},
+				"spec": map[string]interface{}{"replicas": float64(3)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := object()
+			got := decoder.filterSnapshot(obj, tt.kind)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterSnapshot() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(obj, object()) {
+				t.Errorf("filterSnapshot() modified the object: %v", obj)
+			}
+		})
+	}
+
+	// Secret values are hashed even when the full object is kept
+	secret := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "db", "resourceVersion": "7"},
+		"data":     map[string]interface{}{"password": "aHVudGVyMg=="},
+	}
+	got := decoder.filterSnapshot(secret, "Secret")
+	if got["data"].(map[string]interface{})["password"] == "aHVudGVyMg==" {
+		t.Errorf("Expected the Secret value to be hashed, got %v", got)
+	}
+	if got["metadata"].(map[string]interface{})["resourceVersion"] != "7" {
+		t.Errorf("Expected the noise fields of the Secret to be kept, got %v", got)
+	}
+}
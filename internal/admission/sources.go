@@ -0,0 +1,94 @@
+package admission
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Self-monitoring checks of the event sources.
+const (
+	CheckSourceSilent  = "source_silent"  // An event source received no events for longer than allowed
+	CheckSourceResumed = "source_resumed" // A silent event source received events again
+)
+
+// sourceCheckInterval is how often the activity of the event sources is checked.
+const sourceCheckInterval = 5 * time.Minute
+
+// staleSources is the number of event sources found stale by the last check.
+var staleSources = expvar.NewInt("webhook_stale_sources")
+
+// sourceMonitor checks that the event sources keep receiving events.
+type sourceMonitor struct {
+	activity store.SourceStore
+	config   *sources.Config
+	silent   map[string]bool // Sources reported silent, by source and cluster
+}
+
+// SetSourceMonitor makes Start check the activity of the event sources recorded in activity
+// every 5 minutes, and record a SELF_MONITORING event when a source goes silent for longer
+// than cfg allows, and when it resumes. Must be called before Start.
+func (h *Handler) SetSourceMonitor(activity store.SourceStore, cfg *sources.Config) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.sourceMonitor = &sourceMonitor{activity: activity, config: cfg, silent: map[string]bool{}}
+}
+
+// runSourceMonitor checks the event sources periodically until ctx is done.
+func (h *Handler) runSourceMonitor(ctx context.Context) {
+	ticker := time.NewTicker(sourceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.checkSources(ctx, now)
+		}
+	}
+}
+
+// checkSources records a finding for each source that went silent or resumed since the
+// last check.
+func (h *Handler) checkSources(ctx context.Context, now time.Time) {
+	monitor := h.sourceMonitor
+	activity, err := monitor.activity.ListSourceActivity(ctx)
+	if err != nil {
+		klog.Warningf("Failed to check the activity of the event sources: %v", err)
+		return
+	}
+
+	var stale int64
+	for _, health := range sources.Evaluate(activity, monitor.config, now) {
+		key := health.Source + "/" + health.Cluster
+		switch {
+		case health.Status == sources.StatusStale:
+			stale++
+			if monitor.silent[key] {
+				continue
+			}
+			monitor.silent[key] = true
+			severity := SelfMonitorWarning
+			if health.Source == sources.Webhook {
+				severity = SelfMonitorCritical // Changes aren't recorded, nor blocked
+			}
+			h.recordSelfMonitor("EventSource", "", key, "", nil, nil, []selfMonitorIssue{{
+				CheckSourceSilent, severity,
+				fmt.Sprintf("Event source %s of cluster %s received no events for %s, since %s", health.Source, health.Cluster, health.Silence, health.LastEventAt.Format(time.RFC3339)),
+			}})
+		case monitor.silent[key]:
+			delete(monitor.silent, key)
+			h.recordSelfMonitor("EventSource", "", key, "", nil, nil, []selfMonitorIssue{{
+				CheckSourceResumed, SelfMonitorInfo,
+				fmt.Sprintf("Event source %s of cluster %s is receiving events again", health.Source, health.Cluster),
+			}})
+		}
+	}
+	staleSources.Set(stale)
+}
@@ -0,0 +1,62 @@
+package admission
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeSourceStore is a store.SourceStore of fixed activity.
+type fakeSourceStore struct {
+	activity []*store.SourceActivity
+}
+
+func (f *fakeSourceStore) RecordSourceActivity(ctx context.Context, activity []*store.SourceActivity) error {
+	return nil
+}
+
+func (f *fakeSourceStore) ListSourceActivity(ctx context.Context) ([]*store.SourceActivity, error) {
+	return f.activity, nil
+}
+
+func TestHandler_CheckSources(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	webhook := &store.SourceActivity{Source: sources.Webhook, Cluster: "prod", LastEventAt: now.Add(-2 * time.Hour)}
+	audit := &store.SourceActivity{Source: sources.AuditProcessor, Cluster: "prod", LastEventAt: now.Add(-90 * time.Minute)}
+	activity := &fakeSourceStore{activity: []*store.SourceActivity{webhook, audit}}
+
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetSourceMonitor(activity, &sources.Config{Sources: map[string]string{sources.AuditProcessor: "3h"}})
+
+	handler.checkSources(context.Background(), now)
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(handler.queue))
+	}
+	event := (<-handler.queue).event
+	if event.SelfMonitor.Check != CheckSourceSilent || event.SelfMonitor.Severity != SelfMonitorCritical || event.Name != "webhook/prod" {
+		t.Errorf("Expected a critical silent webhook finding, got %+v %+v", event, event.SelfMonitor)
+	}
+	if !strings.Contains(event.SelfMonitor.Message, "received no events for 2h0m0s") {
+		t.Errorf("Unexpected message: %s", event.SelfMonitor.Message)
+	}
+
+	// A silent source is reported once
+	handler.checkSources(context.Background(), now.Add(sourceCheckInterval))
+	if len(handler.queue) != 0 {
+		t.Fatalf("Expected no new finding, got %d", len(handler.queue))
+	}
+
+	// Until it resumes
+	webhook.LastEventAt = now.Add(sourceCheckInterval)
+	handler.checkSources(context.Background(), now.Add(2*sourceCheckInterval))
+	if len(handler.queue) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(handler.queue))
+	}
+	if event := (<-handler.queue).event; event.SelfMonitor.Check != CheckSourceResumed || event.SelfMonitor.Severity != SelfMonitorInfo {
+		t.Errorf("Expected a resumed finding, got %+v", event.SelfMonitor)
+	}
+}
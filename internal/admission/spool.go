@@ -0,0 +1,98 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/spool"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// spoolDrainInterval is how often spooled events are saved once the store is reachable.
+var spoolDrainInterval = 10 * time.Second
+
+// Spool metrics: events spooled to disk while the store was unavailable, spooled events
+// saved since, and events still waiting in the spool.
+var (
+	spooledEvents  = expvar.NewInt("webhook_spooled_events_total")
+	replayedEvents = expvar.NewInt("webhook_spool_replayed_total")
+	spoolLength    = expvar.NewInt("webhook_spool_length")
+)
+
+// SetSpool keeps events on disk in sp while the store is unavailable, instead of
+// dead-lettering them in the same unreachable database, and makes Start save them once
+// the store is reachable again. Must be called before Start.
+func (h *Handler) SetSpool(sp *spool.Spool) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.spool = sp
+}
+
+// spoolEvent writes an event the store couldn't save to the spool, reporting whether it was spooled.
+func (h *Handler) spoolEvent(event *model.ChangeEvent) bool {
+	if h.spool == nil {
+		return false
+	}
+	if err := h.spool.Put(event); err != nil {
+		klog.Errorf("Failed to spool change event %s: %v", event.ID, err)
+		return false
+	}
+	spooledEvents.Add(1)
+	spoolLength.Add(1)
+	klog.Warningf("Store unavailable, spooled change event %s to %s", event.ID, h.spool.Dir())
+	return true
+}
+
+// drainSpoolPeriodically saves the spooled events until ctx is cancelled.
+func (h *Handler) drainSpoolPeriodically(ctx context.Context) {
+	// Events spooled before a restart are counted, then drained on the first tick
+	if n, err := h.spool.Len(); err == nil {
+		spoolLength.Set(int64(n))
+	}
+
+	ticker := time.NewTicker(spoolDrainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.drainSpool()
+		}
+	}
+}
+
+// drainSpool saves the spooled events in order, stopping at the first failure so the rest
+// are retried on the next drain. Events already stored are dropped from the spool.
+func (h *Handler) drainSpool() {
+	saved, err := h.spool.Drain(func(event *model.ChangeEvent) error {
+		if err := h.store.Save(event); !errors.Is(err, store.ErrDuplicate) {
+			return err
+		}
+		return nil
+	})
+	if saved > 0 {
+		replayedEvents.Add(int64(saved))
+		klog.Infof("Saved %d spooled change events", saved)
+	}
+	if err != nil {
+		klog.V(2).Infof("Spooled change events not saved yet: %v", err)
+	}
+	if n, err := h.spool.Len(); err == nil {
+		spoolLength.Set(int64(n))
+	}
+}
+
+// spoolDir returns the directory of the spool, or empty if events aren't spooled.
+func (h *Handler) spoolDir() string {
+	if h.spool == nil {
+		return ""
+	}
+	return h.spool.Dir()
+}
@@ -0,0 +1,68 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/spool"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+func TestHandler_UnavailableStoreSpoolsEvents(t *testing.T) {
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("spool.New() error = %v", err)
+	}
+	handler := NewHandler(&mockStore{saveError: store.ErrUnavailable}, nil, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	handler.SetDeadLetterStore(deadLetters)
+	handler.SetSpool(sp)
+	spooled := spooledEvents.Value()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.Start(ctx)
+	handler.queue <- &queuedEvent{event: &model.ChangeEvent{ID: "e1"}}
+
+	if !waitFor(t, func() bool { n, _ := sp.Len(); return n == 1 }) {
+		t.Fatal("Event should be spooled while the store is unavailable")
+	}
+	if deadLetters.addedCount() != 0 {
+		t.Error("Spooled event should not be dead-lettered")
+	}
+	if spooledEvents.Value() != spooled+1 {
+		t.Errorf("webhook_spooled_events_total increased by %d, want 1", spooledEvents.Value()-spooled)
+	}
+	if handler.RuntimeConfig().SpoolDir != sp.Dir() {
+		t.Errorf("RuntimeConfig().SpoolDir = %q, want %q", handler.RuntimeConfig().SpoolDir, sp.Dir())
+	}
+}
+
+func TestHandler_DrainSpool(t *testing.T) {
+	sp, err := spool.New(t.TempDir())
+	if err != nil {
+		t.Fatalf("spool.New() error = %v", err)
+	}
+	for _, id := range []string{"e1", "e2"} {
+		if err := sp.Put(&model.ChangeEvent{ID: id}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+	mock := &mockStore{}
+	handler := NewHandler(mock, nil, nil, nil)
+	handler.SetSpool(sp)
+	replayed := replayedEvents.Value()
+
+	handler.drainSpool()
+
+	if len(mock.savedEvents) != 2 || mock.savedEvents[0].ID != "e1" || mock.savedEvents[1].ID != "e2" {
+		t.Errorf("Saved events = %v, want e1 and e2 in order", mock.savedEvents)
+	}
+	if n, _ := sp.Len(); n != 0 || spoolLength.Value() != 0 {
+		t.Errorf("Spool length = %d (gauge %d), want 0", n, spoolLength.Value())
+	}
+	if replayedEvents.Value() != replayed+2 {
+		t.Errorf("webhook_spool_replayed_total increased by %d, want 2", replayedEvents.Value()-replayed)
+	}
+}
@@ -0,0 +1,102 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// SetVolumeWatch makes Start watch the cluster's PersistentVolumes, so the DELETE of a
+// PersistentVolumeClaim records the reclaim policy of the volume bound to it. Without it, the
+// policy of claims is unknown. Must be called before Start.
+func (h *Handler) SetVolumeWatch(clientset kubernetes.Interface) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	h.decoder.SetVolumeLister(factory.Core().V1().PersistentVolumes().Lister())
+	h.volumeWatch = factory
+}
+
+// watchVolumes keeps the PersistentVolumes cache of the decoder up to date until ctx is done.
+func (h *Handler) watchVolumes(ctx context.Context) {
+	factory := h.volumeWatch
+	factory.Start(ctx.Done())
+	for informerType, ok := range factory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			klog.Errorf("Failed to sync %v informer, the reclaim policy of deleted claims is unknown", informerType)
+		}
+	}
+	<-ctx.Done()
+	factory.Shutdown()
+}
+
+// SetVolumeLister makes DecodePayload look up the reclaim policy of the volume bound to a
+// deleted PersistentVolumeClaim in volumes.
+func (d *Decoder) SetVolumeLister(volumes corelisters.PersistentVolumeLister) {
+	d.volumes = volumes
+}
+
+// volumeDeletion returns the storage affected by the DELETE of a PersistentVolume or
+// PersistentVolumeClaim, given the object deleted, or nil for other kinds. The deletion
+// risks losing data unless the volume's reclaim policy is Retain; deleting an unbound claim
+// risks nothing.
+func (d *Decoder) volumeDeletion(resourceKind string, raw []byte) *model.VolumeDeletion {
+	switch resourceKind {
+	case "PersistentVolume":
+		var volume corev1.PersistentVolume
+		if err := json.Unmarshal(raw, &volume); err != nil {
+			klog.V(2).Infof("Failed to decode deleted PersistentVolume: %v", err)
+			return nil
+		}
+		deletion := &model.VolumeDeletion{
+			Volume:        volume.Name,
+			StorageClass:  volume.Spec.StorageClassName,
+			ReclaimPolicy: string(volume.Spec.PersistentVolumeReclaimPolicy),
+		}
+		if claim := volume.Spec.ClaimRef; claim != nil {
+			deletion.Claim = claim.Namespace + "/" + claim.Name
+		}
+		if capacity, ok := volume.Spec.Capacity[corev1.ResourceStorage]; ok {
+			deletion.Capacity = capacity.String()
+		}
+		deletion.DataLossRisk = deletion.ReclaimPolicy != string(corev1.PersistentVolumeReclaimRetain)
+		return deletion
+
+	case "PersistentVolumeClaim":
+		var claim corev1.PersistentVolumeClaim
+		if err := json.Unmarshal(raw, &claim); err != nil {
+			klog.V(2).Infof("Failed to decode deleted PersistentVolumeClaim: %v", err)
+			return nil
+		}
+		deletion := &model.VolumeDeletion{
+			Volume: claim.Spec.VolumeName,
+			Claim:  claim.Namespace + "/" + claim.Name,
+		}
+		if claim.Spec.StorageClassName != nil {
+			deletion.StorageClass = *claim.Spec.StorageClassName
+		}
+		if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+			deletion.Capacity = capacity.String()
+		} else if requested, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			deletion.Capacity = requested.String()
+		}
+		if deletion.Volume == "" {
+			return deletion // Never bound, so it holds no data
+		}
+		if d.volumes != nil {
+			if volume, err := d.volumes.Get(deletion.Volume); err == nil {
+				deletion.ReclaimPolicy = string(volume.Spec.PersistentVolumeReclaimPolicy)
+			}
+		}
+		deletion.DataLossRisk = deletion.ReclaimPolicy != string(corev1.PersistentVolumeReclaimRetain)
+		return deletion
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package admission
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestDecoder_VolumeDeletion(t *testing.T) {
+	volumes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	volumes.Add(&corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-retained"},
+		Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain},
+	})
+	volumes.Add(&corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-deleted"},
+		Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete},
+	})
+	lister := corelisters.NewPersistentVolumeLister(volumes)
+
+	tests := []struct {
+		name   string
+		kind   string
+		object string
+		lister bool
+		want   *model.VolumeDeletion
+	}{
+		{
+			name:   "volume with Delete policy",
+			kind:   "PersistentVolume",
+			object: `{"metadata":{"name":"pv-1"},"spec":{"capacity":{"storage":"10Gi"},"storageClassName":"gp3","persistentVolumeReclaimPolicy":"Delete","claimRef":{"namespace":"shop","name":"data"}}}`,
+			want:   &model.VolumeDeletion{Volume: "pv-1", Claim: "shop/data", StorageClass: "gp3", Capacity: "10Gi", ReclaimPolicy: "Delete", DataLossRisk: true},
+		},
+		{
+			name:   "volume with Retain policy",
+			kind:   "PersistentVolume",
+			object: `{"metadata":{"name":"pv-2"},"spec":{"persistentVolumeReclaimPolicy":"Retain"}}`,
+			want:   &model.VolumeDeletion{Volume: "pv-2", ReclaimPolicy: "Retain"},
+		},
+		{
+			name:   "unbound claim",
+			kind:   "PersistentVolumeClaim",
+			object: `{"metadata":{"name":"data","namespace":"shop"},"spec":{"resources":{"requests":{"storage":"5Gi"}}}}`,
+			lister: true,
+			want:   &model.VolumeDeletion{Claim: "shop/data", Capacity: "5Gi"},
+		},
+		{
+			name:   "claim of a retained volume",
+			kind:   "PersistentVolumeClaim",
+			object: `{"metadata":{"name":"data","namespace":"shop"},"spec":{"volumeName":"pvc-retained","storageClassName":"gp3"},"status":{"capacity":{"storage":"10Gi"}}}`,
+			lister: true,
+			want:   &model.VolumeDeletion{Volume: "pvc-retained", Claim: "shop/data", StorageClass: "gp3", Capacity: "10Gi", ReclaimPolicy: "Retain"},
+		},
+		{
+			name:   "claim of a deleted volume",
+			kind:   "PersistentVolumeClaim",
+			object: `{"metadata":{"name":"data","namespace":"shop"},"spec":{"volumeName":"pvc-deleted"}}`,
+			lister: true,
+			want:   &model.VolumeDeletion{Volume: "pvc-deleted", Claim: "shop/data", ReclaimPolicy: "Delete", DataLossRisk: true},
+		},
+		{
+			name:   "claim without volume watch",
+			kind:   "PersistentVolumeClaim",
+			object: `{"metadata":{"name":"data","namespace":"shop"},"spec":{"volumeName":"pvc-retained"}}`,
+			want:   &model.VolumeDeletion{Volume: "pvc-retained", Claim: "shop/data", DataLossRisk: true},
+		},
+		{
+			name:   "other kind",
+			kind:   "ConfigMap",
+			object: `{"metadata":{"name":"settings","namespace":"shop"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewDecoder()
+			if tt.lister {
+				decoder.SetVolumeLister(lister)
+			}
+			event := &model.ChangeEvent{Operation: "DELETE", ResourceKind: tt.kind}
+			if err := decoder.DecodePayload(event, []byte(tt.object), nil); err != nil {
+				t.Fatalf("DecodePayload() error = %v", err)
+			}
+			got := event.VolumeDeletion
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("VolumeDeletion = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package admission
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// admissionWarnings counts warnings returned in admission responses.
+var admissionWarnings = expvar.NewInt("webhook_admission_warnings_total")
+
+// warnRuleMatcher is a pre-compiled WarnRule.
+type warnRuleMatcher struct {
+	rule          config.WarnRule
+	namespaces    patternSet
+	names         patternSet
+	resourceKinds patternSet
+}
+
+// matches reports whether the event matches all of the rule's non-empty pattern lists and operations.
+func (r *warnRuleMatcher) matches(event *model.ChangeEvent) bool {
+	if !matchesOperation(r.rule.OperationPatterns, event.Operation) {
+		return false
+	}
+	for _, check := range []struct {
+		patterns patternSet
+		value    string
+	}{
+		{r.namespaces, event.Namespace},
+		{r.names, event.Name},
+		{r.resourceKinds, event.ResourceKind},
+	} {
+		if len(check.patterns) == 0 {
+			continue
+		}
+		if _, ok := check.patterns.match(check.value); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// warnMatcher is a pre-compiled WarnConfig.
+type warnMatcher struct {
+	rules []*warnRuleMatcher
+}
+
+// newWarnMatcher compiles a warn config. A nil config yields a nil matcher, which warns about nothing.
+func newWarnMatcher(cfg *config.WarnConfig) *warnMatcher {
+	if cfg == nil || len(cfg.Rules) == 0 {
+		return nil
+	}
+	m := &warnMatcher{}
+	for _, rule := range cfg.Rules {
+		m.rules = append(m.rules, &warnRuleMatcher{
+			rule:          rule,
+			namespaces:    compilePatterns(rule.NamespacePatterns),
+			names:         compilePatterns(rule.NamePatterns),
+			resourceKinds: compilePatterns(rule.ResourceKindPatterns),
+		})
+	}
+	return m
+}
+
+// warnings returns the warnings of every unexpired rule matching the request, in rule order.
+// The object's labels are only decoded when a matching rule requires labels.
+func (m *warnMatcher) warnings(event *model.ChangeEvent, req *admissionv1.AdmissionRequest) []string {
+	if m == nil {
+		return nil
+	}
+
+	var (
+		warnings []string
+		labels   map[string]string
+		decoded  bool
+		decodeOK bool
+	)
+	now := time.Now()
+	for _, r := range m.rules {
+		if r.rule.Expired(now) || !r.matches(event) {
+			continue
+		}
+		if len(r.rule.RequiredLabels) == 0 {
+			warnings = append(warnings, formatWarning(&r.rule, nil))
+			continue
+		}
+
+		// Only requests with an object (CREATE, UPDATE) can be checked for labels
+		if req.Object.Raw == nil {
+			continue
+		}
+		if !decoded {
+			decoded = true
+			if meta, err := decodeObjectMeta(req.Object.Raw); err == nil {
+				labels, decodeOK = meta.Labels, true
+			} else {
+				klog.V(2).Infof("Failed to decode labels of %s/%s for warn rules: %v", event.ResourceKind, event.Name, err)
+			}
+		}
+		if !decodeOK {
+			continue
+		}
+		var missing []string
+		for _, label := range r.rule.RequiredLabels {
+			if _, ok := labels[label]; !ok {
+				missing = append(missing, label)
+			}
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, formatWarning(&r.rule, missing))
+		}
+	}
+
+	admissionWarnings.Add(int64(len(warnings)))
+	return warnings
+}
+
+// formatWarning returns the warning for a matching rule, e.g.
+// "Every workload needs an owner (missing labels: owner, team)".
+func formatWarning(rule *config.WarnRule, missing []string) string {
+	message := rule.Message
+	switch {
+	case message == "" && len(missing) > 0:
+		return "Missing required labels: " + strings.Join(missing, ", ")
+	case message == "" && rule.Name != "":
+		message = fmt.Sprintf("Matches kubechronicle warn rule %q", rule.Name)
+	case message == "":
+		message = "Matches a kubechronicle warn rule"
+	}
+	if len(missing) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s (missing labels: %s)", message, strings.Join(missing, ", "))
+}
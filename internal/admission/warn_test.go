@@ -0,0 +1,103 @@
+package admission
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestWarnMatcher_Warnings(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	m := newWarnMatcher(&config.WarnConfig{
+		Rules: []config.WarnRule{
+			{Name: "payments-freeze", NamespacePatterns: []string{"payments"}, OperationPatterns: []string{"UPDATE", "DELETE"}, Message: "payments is frozen starting Friday"},
+			{Name: "owner-label", ResourceKindPatterns: []string{"Deployment"}, RequiredLabels: []string{"owner", "team"}, Message: "Deployments need an owner"},
+			{Name: "old-notice", Message: "expired", ExpiresAt: &expired},
+			{Name: "legacy-namespace", NamespacePatterns: []string{"legacy-*"}},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		event    *model.ChangeEvent
+		object   string
+		expected []string
+	}{
+		{
+			name:     "namespace and operation match",
+			event:    &model.ChangeEvent{Operation: "UPDATE", Namespace: "payments", ResourceKind: "ConfigMap"},
+			object:   `{"metadata":{"name":"x"}}`,
+			expected: []string{"payments is frozen starting Friday"},
+		},
+		{
+			name:     "operation not in rule",
+			event:    &model.ChangeEvent{Operation: "CREATE", Namespace: "payments", ResourceKind: "ConfigMap"},
+			object:   `{"metadata":{"name":"x"}}`,
+			expected: nil,
+		},
+		{
+			name:     "missing labels are listed",
+			event:    &model.ChangeEvent{Operation: "CREATE", Namespace: "default", ResourceKind: "Deployment"},
+			object:   `{"metadata":{"name":"x","labels":{"team":"a"}}}`,
+			expected: []string{"Deployments need an owner (missing labels: owner)"},
+		},
+		{
+			name:     "required labels present",
+			event:    &model.ChangeEvent{Operation: "CREATE", Namespace: "default", ResourceKind: "Deployment"},
+			object:   `{"metadata":{"name":"x","labels":{"owner":"a","team":"a"}}}`,
+			expected: nil,
+		},
+		{
+			name:     "delete has no object to check labels on",
+			event:    &model.ChangeEvent{Operation: "DELETE", Namespace: "payments", ResourceKind: "Deployment"},
+			expected: []string{"payments is frozen starting Friday"},
+		},
+		{
+			name:     "default message names the rule",
+			event:    &model.ChangeEvent{Operation: "CREATE", Namespace: "legacy-billing", ResourceKind: "Service"},
+			object:   `{"metadata":{"name":"x"}}`,
+			expected: []string{`Matches kubechronicle warn rule "legacy-namespace"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &admissionv1.AdmissionRequest{}
+			if tt.object != "" {
+				req.Object = runtime.RawExtension{Raw: []byte(tt.object)}
+			}
+			if got := m.warnings(tt.event, req); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("warnings() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWarnMatcher_Nil(t *testing.T) {
+	var m *warnMatcher
+	if got := m.warnings(&model.ChangeEvent{}, &admissionv1.AdmissionRequest{}); got != nil {
+		t.Errorf("nil matcher warnings() = %q, want nil", got)
+	}
+	if newWarnMatcher(&config.WarnConfig{}) != nil {
+		t.Error("Config without rules should yield a nil matcher")
+	}
+}
+
+func TestHandler_ApplyConfigData_WarnConfig(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.applyConfigData(map[string]string{"WARN_CONFIG": `{"rules": [{"name": "notice", "message": "heads up"}]}`})
+
+	warnings := handler.getWarnMatcher().warnings(&model.ChangeEvent{Operation: "CREATE"}, &admissionv1.AdmissionRequest{})
+	if !reflect.DeepEqual(warnings, []string{"heads up"}) {
+		t.Errorf("warnings() = %q, want [heads up]", warnings)
+	}
+	if runtime := handler.RuntimeConfig(); runtime.WarnConfig == nil || runtime.WarnConfig.Rules[0].Name != "notice" {
+		t.Errorf("RuntimeConfig should report the warn config, got %+v", runtime.WarnConfig)
+	}
+}
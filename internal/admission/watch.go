@@ -0,0 +1,152 @@
+package admission
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// secretTimeout bounds reading the alert Secret while applying a config change.
+const secretTimeout = 10 * time.Second
+
+// configMapWatch identifies the patterns ConfigMap watched for config changes.
+type configMapWatch struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// String returns the watched ConfigMap as namespace/name, or "" if nil.
+func (w *configMapWatch) String() string {
+	if w == nil {
+		return ""
+	}
+	return w.namespace + "/" + w.name
+}
+
+// SetConfigMapWatch makes Start watch the patterns ConfigMap through the Kubernetes API
+// instead of polling the mounted files, so changes are applied within seconds.
+// Overlays are further ConfigMaps in the same namespace whose patterns are added to those
+// of the base ConfigMap, in order. overlayNamespaces declares the namespace patterns each
+// overlay's rules apply to; an overlay without any applies to no namespace. Must be called
+// before Start.
+func (h *Handler) SetConfigMapWatch(clientset kubernetes.Interface, namespace, name string, overlays []string, overlayNamespaces map[string][]string) {
+	h.configMutex.Lock()
+	defer h.configMutex.Unlock()
+	h.configWatch = &configMapWatch{
+		clientset: clientset,
+		namespace: namespace,
+		name:      name,
+	}
+	h.overlays = nil
+	for _, overlay := range overlays {
+		if len(overlayNamespaces[overlay]) == 0 {
+			klog.Warningf("Overlay ConfigMap %s/%s declares no namespaces, so its patterns apply to none", namespace, overlay)
+		}
+		h.overlays = append(h.overlays, &patternLayer{name: overlay, namespaces: overlayNamespaces[overlay]})
+	}
+}
+
+// watchConfigMap applies the patterns ConfigMap and its overlays whenever they are created
+// or updated. A deleted base ConfigMap keeps the current config, while a deleted overlay
+// removes its patterns.
+func (h *Handler) watchConfigMap(ctx context.Context) {
+	watch := h.configWatch
+
+	factories := []informers.SharedInformerFactory{
+		watch.newInformer(watch.name, h.applyConfigData, func() {
+			klog.Warningf("ConfigMap %s/%s was deleted, keeping current config", watch.namespace, watch.name)
+		}),
+	}
+	for _, layer := range h.overlays {
+		overlay := layer.name
+		factories = append(factories, watch.newInformer(overlay,
+			func(data map[string]string) { h.applyOverlayData(overlay, data) },
+			func() {
+				klog.Warningf("Overlay ConfigMap %s/%s was deleted, removing its patterns", watch.namespace, overlay)
+				h.applyOverlayData(overlay, nil)
+			}))
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+	}
+	for _, factory := range factories {
+		for informerType, ok := range factory.WaitForCacheSync(ctx.Done()) {
+			if !ok {
+				klog.Errorf("Failed to sync %v informer in %s, config will not be reloaded", informerType, watch.namespace)
+			}
+		}
+	}
+	<-ctx.Done()
+	for _, factory := range factories {
+		factory.Shutdown()
+	}
+}
+
+// newInformer returns an informer factory watching the named ConfigMap, calling apply with its
+// data when it is created or updated and remove when it is deleted.
+func (w *configMapWatch) newInformer(name string, apply func(data map[string]string), remove func()) informers.SharedInformerFactory {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.clientset, 0,
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			// Only this ConfigMap, which also lets RBAC restrict the webhook to it
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}),
+	)
+
+	factory.Core().V1().ConfigMaps().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == name {
+				apply(cm.Data)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == name {
+				klog.V(2).Infof("ConfigMap %s/%s changed (resourceVersion %s)", cm.Namespace, cm.Name, cm.ResourceVersion)
+				apply(cm.Data)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Name == name {
+				remove()
+			}
+		},
+	})
+
+	klog.Infof("Watching ConfigMap %s/%s for config changes", w.namespace, name)
+	return factory
+}
+
+// alertSecrets returns the config with secrets stored in the alert Secret named by ref: from
+// data when the Secret is projected next to the ConfigMap files, else read through the
+// Kubernetes API when watching the ConfigMap. It returns "" if they cannot be read, which
+// fails resolving the secrets. Must be called with configMutex held.
+func (h *Handler) alertSecrets(ref *alerting.SecretRef, data map[string]string) string {
+	if secrets, ok := data[alerting.SecretKey]; ok {
+		return secrets
+	}
+	if ref == nil || h.configWatch == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	secret, err := h.configWatch.clientset.CoreV1().Secrets(h.configWatch.namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Failed to read alert Secret %s/%s: %v", h.configWatch.namespace, ref.Name, err)
+		return ""
+	}
+	return string(secret.Data[alerting.SecretKey])
+}
@@ -0,0 +1,169 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestHandler_WatchConfigMap(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-patterns", Namespace: "default"},
+		Data: map[string]string{
+			"IGNORE_CONFIG": `{"namespace_patterns": ["initial-*"]}`,
+		},
+	})
+
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetConfigMapWatch(clientset, "default", "test-patterns", nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.watchConfigMap(ctx)
+
+	// Initial ConfigMap is applied on sync
+	if !waitFor(t, func() bool {
+		cfg := handler.getIgnoreConfig()
+		return cfg != nil && len(cfg.NamespacePatterns) == 1 && cfg.NamespacePatterns[0] == "initial-*"
+	}) {
+		t.Fatalf("Initial ignore config not applied, got %+v", handler.getIgnoreConfig())
+	}
+	reloads := configReloads.Value()
+
+	// Updates are applied without polling
+	_, err := clientset.CoreV1().ConfigMaps("default").Update(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-patterns", Namespace: "default"},
+		Data: map[string]string{
+			"IGNORE_CONFIG": `{"namespace_patterns": ["updated-*"]}`,
+			"BLOCK_CONFIG":  `{"name_patterns": ["critical-*"]}`,
+		},
+	}, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+
+	if !waitFor(t, func() bool {
+		cfg := handler.getBlockConfig()
+		return cfg != nil && len(cfg.NamePatterns) == 1
+	}) {
+		t.Fatal("Updated block config not applied")
+	}
+	if cfg := handler.getIgnoreConfig(); cfg.NamespacePatterns[0] != "updated-*" {
+		t.Errorf("Ignore config namespace_patterns = %v, want [updated-*]", cfg.NamespacePatterns)
+	}
+	if configReloads.Value() != reloads+1 {
+		t.Errorf("webhook_config_reloads_total increased by %d, want 1", configReloads.Value()-reloads)
+	}
+	if runtime := handler.RuntimeConfig(); runtime.ConfigMap != "default/test-patterns" {
+		t.Errorf("RuntimeConfig ConfigMap = %q, want %q", runtime.ConfigMap, "default/test-patterns")
+	}
+}
+
+func TestHandler_ApplyConfigData_Unchanged(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	data := map[string]string{"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*"]}`}
+
+	handler.applyConfigData(data)
+	reloads := configReloads.Value()
+	matcher, _ := handler.getMatchers()
+
+	// Re-applying the same content is a no-op
+	handler.applyConfigData(data)
+	if configReloads.Value() != reloads {
+		t.Error("Unchanged config should not count as a reload")
+	}
+	if current, _ := handler.getMatchers(); current[0] != matcher[0] {
+		t.Error("Unchanged config should not recompile matchers")
+	}
+
+	// Invalid content is rejected and counted
+	errors := configReloadErrors.Value()
+	handler.applyConfigData(map[string]string{"IGNORE_CONFIG": "not json"})
+	if configReloadErrors.Value() != errors+1 {
+		t.Error("Invalid config should count as a reload error")
+	}
+	if cfg := handler.getIgnoreConfig(); cfg == nil || cfg.NamespacePatterns[0] != "kube-*" {
+		t.Errorf("Invalid config should keep the current patterns, got %+v", cfg)
+	}
+}
+
+func TestHandler_ApplyConfigData_AlertSecrets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	handler := NewHandler(nil, nil, nil, nil)
+	handler.SetConfigMapWatch(clientset, "default", "test-patterns", nil, nil)
+
+	// Without the Secret, the redacted webhook URL is not applied
+	alertConfig := `{"slack": {"webhook_url": "xxxxx"}, "secret_ref": {"name": "test-patterns-alerts", "version": "1"}}`
+	handler.applyConfigData(map[string]string{"ALERT_CONFIG": alertConfig})
+	if handler.getAlertRouter() != nil {
+		t.Fatal("Alert config with missing secrets should not be applied")
+	}
+
+	// Once the Secret exists, a new version of the config resolves the webhook URL from it
+	clientset.CoreV1().Secrets("default").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-patterns-alerts", Namespace: "default"},
+		Data:       map[string][]byte{"ALERT_SECRETS": []byte(`{"slack": {"webhook_url": "https://hooks.slack.com/services/test"}}`)},
+	}, metav1.CreateOptions{})
+	alertConfig = `{"slack": {"webhook_url": "xxxxx"}, "secret_ref": {"name": "test-patterns-alerts", "version": "2"}}`
+	handler.applyConfigData(map[string]string{"ALERT_CONFIG": alertConfig})
+	if channels := handler.getAlertRouter().Channels(); len(channels) != 1 || channels[0] != "slack" {
+		t.Errorf("Alert channels = %v, want [slack]", channels)
+	}
+
+	// Secrets projected next to the ConfigMap files are used as they are
+	handler.applyConfigData(map[string]string{
+		"ALERT_CONFIG":  alertConfig,
+		"ALERT_SECRETS": `{}`,
+	})
+	if handler.getAlertRouter() == nil {
+		t.Error("Alert router should be kept when the projected secrets are incomplete")
+	}
+}
+
+// fakeEgressTarget records the egress controls applied to it.
+type fakeEgressTarget struct {
+	egress []*alerting.EgressConfig
+}
+
+func (f *fakeEgressTarget) SetEgress(cfg *alerting.EgressConfig) error {
+	f.egress = append(f.egress, cfg)
+	return nil
+}
+
+func TestHandler_ApplyConfigData_Egress(t *testing.T) {
+	handler := NewHandler(nil, nil, nil, nil)
+	target := &fakeEgressTarget{}
+	handler.SetEgressTarget(target)
+
+	handler.applyConfigData(map[string]string{
+		"ALERT_CONFIG": `{"webhook": {"url": "https://siem.example.com"}, "egress": {"proxy": "http://proxy.example.com:3128"}}`,
+	})
+	if len(target.egress) != 1 || target.egress[0] == nil || target.egress[0].Proxy != "http://proxy.example.com:3128" {
+		t.Fatalf("Expected the reloaded egress controls to be applied, got %+v", target.egress)
+	}
+
+	// Removing them from the config removes them from the target
+	handler.applyConfigData(map[string]string{"ALERT_CONFIG": `{"webhook": {"url": "https://siem.example.com"}}`})
+	if len(target.egress) != 2 || target.egress[1] != nil {
+		t.Errorf("Expected the egress controls to be removed, got %+v", target.egress)
+	}
+}
@@ -0,0 +1,57 @@
+package admission
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podTemplateObject is a workload keeping its pod spec in spec.template: a Deployment,
+// StatefulSet, DaemonSet, ReplicaSet or Job.
+type podTemplateObject struct {
+	Spec struct {
+		Template corev1.PodTemplateSpec `json:"template"`
+	} `json:"spec"`
+}
+
+// cronJobObject is a CronJob, keeping its pod spec in spec.jobTemplate.spec.template.
+type cronJobObject struct {
+	Spec struct {
+		JobTemplate struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+}
+
+// decodePodSpec returns the pod spec of raw, an object of kind resourceKind, or nil if the
+// kind has no pod spec.
+func decodePodSpec(resourceKind string, raw []byte) (*corev1.PodSpec, error) {
+	switch resourceKind {
+	case "Pod":
+		var pod corev1.Pod
+		if err := json.Unmarshal(raw, &pod); err != nil {
+			return nil, err
+		}
+		return &pod.Spec, nil
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		var workload podTemplateObject
+		if err := json.Unmarshal(raw, &workload); err != nil {
+			return nil, err
+		}
+		return &workload.Spec.Template.Spec, nil
+	case "CronJob":
+		var cronJob cronJobObject
+		if err := json.Unmarshal(raw, &cronJob); err != nil {
+			return nil, err
+		}
+		return &cronJob.Spec.JobTemplate.Spec.Template.Spec, nil
+	}
+	return nil, nil
+}
+
+// podContainers returns the init containers then the containers of spec.
+func podContainers(spec *corev1.PodSpec) []corev1.Container {
+	return append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// maxBatchIDs is the most events a batch lookup may ask for, the same as a page of events.
+const maxBatchIDs = 1000
+
+// BatchRequest is the body of a batch event lookup.
+type BatchRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// BatchResponse holds the events of a batch lookup in the order they were asked for, and
+// the IDs of those that weren't found (or are outside the caller's tenants).
+type BatchResponse struct {
+	Events  []*model.ChangeEvent `json:"events"`
+	Missing []string             `json:"missing"`
+}
+
+// SetBatchStore makes batch lookups read all their events in one query. Without it, each
+// event is read with GetEventByID.
+func (s *Server) SetBatchStore(batch store.BatchStore) {
+	s.batch = batch
+}
+
+// HandleBatchGetChanges handles POST /api/changes/batch, which returns the events with the
+// given IDs in one round trip.
+func (s *Server) HandleBatchGetChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	ids := make([]string, 0, len(req.IDs))
+	seen := map[string]bool{}
+	for _, id := range req.IDs {
+		if id == "" {
+			s.sendError(w, http.StatusBadRequest, "IDs must not be empty")
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		s.sendError(w, http.StatusBadRequest, "ids is required")
+		return
+	}
+	if len(ids) > maxBatchIDs {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("At most %d IDs may be looked up at once", maxBatchIDs))
+		return
+	}
+
+	found, err := s.getEventsByIDs(r, ids)
+	if err != nil {
+		klog.Errorf("Failed to get events by IDs: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get events: %v", err))
+		return
+	}
+	byID := make(map[string]*model.ChangeEvent, len(found))
+	for _, event := range found {
+		byID[event.ID] = event
+	}
+
+	response := BatchResponse{Events: []*model.ChangeEvent{}, Missing: []string{}}
+	for _, id := range ids {
+		if event, ok := byID[id]; ok {
+			response.Events = append(response.Events, event)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+	response.Events = s.redactEvents(r, response.Events)
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// getEventsByIDs returns the events with the given IDs that exist, with the batch store if
+// there is one. GetEventByID doesn't tell a missing event from a failed lookup, so every
+// error of the fallback counts as a miss.
+func (s *Server) getEventsByIDs(r *http.Request, ids []string) ([]*model.ChangeEvent, error) {
+	if s.batch != nil {
+		return s.batch.GetEventsByIDs(r.Context(), ids)
+	}
+	events := []*model.ChangeEvent{}
+	for _, id := range ids {
+		event, err := s.store.GetEventByID(r.Context(), id)
+		if err != nil {
+			klog.V(2).Infof("Batch lookup of event %s: %v", id, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
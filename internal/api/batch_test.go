@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// fakeBatchStore is a store.BatchStore returning the events it holds, in reverse order.
+type fakeBatchStore struct {
+	events map[string]*model.ChangeEvent
+	calls  [][]string
+}
+
+func (f *fakeBatchStore) GetEventsByIDs(ctx context.Context, ids []string) ([]*model.ChangeEvent, error) {
+	f.calls = append(f.calls, ids)
+	events := []*model.ChangeEvent{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if event, ok := f.events[ids[i]]; ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func postBatch(server *Server, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.HandleBatchGetChanges(rec, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/changes/batch", strings.NewReader(body)))
+	return rec
+}
+
+func TestHandleBatchGetChanges(t *testing.T) {
+	batch := &fakeBatchStore{events: map[string]*model.ChangeEvent{
+		"a": {ID: "a", Operation: "CREATE"},
+		"b": {ID: "b", Operation: "UPDATE"},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetBatchStore(batch)
+
+	rec := postBatch(server, `{"ids": ["b", "missing", "a", "b"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Events) != 2 || response.Events[0].ID != "b" || response.Events[1].ID != "a" {
+		t.Errorf("expected events b and a in request order, got %+v", response.Events)
+	}
+	if !reflect.DeepEqual(response.Missing, []string{"missing"}) {
+		t.Errorf("expected the missing ID to be reported, got %v", response.Missing)
+	}
+	if len(batch.calls) != 1 || len(batch.calls[0]) != 3 {
+		t.Errorf("expected one lookup of the 3 distinct IDs, got %v", batch.calls)
+	}
+}
+
+func TestHandleBatchGetChanges_WithoutBatchStore(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: sampleEvent()})
+	rec := postBatch(server, `{"ids": ["`+sampleEvent().ID+`", "other"]}`)
+
+	var response BatchResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if len(response.Events) != 1 || response.Events[0].ID != sampleEvent().ID || !reflect.DeepEqual(response.Missing, []string{"other"}) {
+		t.Errorf("expected one event found and one missing, got %+v", response)
+	}
+}
+
+func TestHandleBatchGetChanges_RedactsEncryptedPayload(t *testing.T) {
+	server := NewServer(&mockStore{})
+	server.SetBatchStore(&fakeBatchStore{events: map[string]*model.ChangeEvent{sampleEvent().ID: encryptedEvent()}})
+	server.SetDecryptRoles([]string{"admin"})
+
+	req := requestAs("/kubechronicle/api/changes/batch", "viewer")
+	req.Method = http.MethodPost
+	req.Body = io.NopCloser(strings.NewReader(`{"ids": ["` + sampleEvent().ID + `"]}`))
+	rec := httptest.NewRecorder()
+	server.HandleBatchGetChanges(rec, req)
+
+	var response BatchResponse
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if len(response.Events) != 1 || response.Events[0].Diff != nil {
+		t.Errorf("expected the encrypted diff to be redacted, got %+v", response.Events)
+	}
+}
+
+func TestHandleBatchGetChanges_Errors(t *testing.T) {
+	server := NewServer(&mockStore{})
+	ids := make([]string, maxBatchIDs+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("event-%d", i)
+	}
+	tooMany, _ := json.Marshal(BatchRequest{IDs: ids})
+	for _, body := range []string{`not json`, `{}`, `{"ids": []}`, `{"ids": [""]}`, string(tooMany)} {
+		if rec := postBatch(server, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %.40s, got %d", body, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.HandleBatchGetChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/batch", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,441 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Calendar feed defaults: how far back changes and how far ahead freezes are listed when
+// no since= or until= is given, and the number of events read per query.
+const (
+	defaultCalendarPast   = 30 * 24 * time.Hour
+	defaultCalendarFuture = 90 * 24 * time.Hour
+	calendarEventLimit    = 1000
+)
+
+// Calendar entry severities, from least to most severe.
+const (
+	CalendarInfo     = "info"
+	CalendarWarning  = "warning"
+	CalendarCritical = "critical"
+)
+
+// CalendarResponse is the JSON form of the calendar feed.
+type CalendarResponse struct {
+	Since   time.Time        `json:"since"`
+	Until   time.Time        `json:"until"`
+	Entries []*CalendarEntry `json:"entries"`
+}
+
+// CalendarEntry is a notable change or a change freeze in the calendar feed. Changes are
+// instants and have no end.
+type CalendarEntry struct {
+	UID         string     `json:"uid"`
+	Kind        string     `json:"kind"` // "change" or "freeze"
+	Severity    string     `json:"severity"`
+	Summary     string     `json:"summary"`
+	Description string     `json:"description,omitempty"`
+	Start       time.Time  `json:"start"`
+	End         *time.Time `json:"end,omitempty"`
+	Namespace   string     `json:"namespace,omitempty"`
+	EventID     string     `json:"event_id,omitempty"`
+}
+
+// SetBlockConfigSource makes the calendar feed list the freezes of the block config source
+// returns, the structured block rules with a start or expiry time.
+func (s *Server) SetBlockConfigSource(source func(ctx context.Context) (*config.BlockConfig, error)) {
+	s.blockConfig = source
+}
+
+// HandleCalendar handles GET /api/calendar?namespace={ns}&severity={min}&since={RFC3339}&until={RFC3339}&format={ics|json},
+// a feed of notable changes (blocked requests, deletions, deployments, node maintenance,
+// cloud changes, risky execs and self-monitoring findings) and change freezes, as iCalendar
+// (default) or JSON. JSON timestamps are in the time zone of tz= or the X-Timezone header,
+// else UTC; iCalendar ones are UTC, which calendar apps show in their own time zone.
+func (s *Server) HandleCalendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now().UTC()
+	since, until := now.Add(-defaultCalendarPast), now.Add(defaultCalendarFuture)
+	parsedSince, err := parseSince(query, now)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedSince != nil {
+		since = parsedSince.UTC()
+	}
+	parsedUntil, err := parseTimeParam(query, "until", now)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedUntil != nil {
+		until = parsedUntil.UTC()
+	}
+	if !since.Before(until) {
+		s.sendError(w, http.StatusBadRequest, "since must be before until")
+		return
+	}
+
+	minSeverity := query.Get("severity")
+	if minSeverity == "" {
+		minSeverity = CalendarInfo
+	}
+	if calendarSeverityRank(minSeverity) == 0 {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Unknown severity %q, must be info, warning or critical", minSeverity))
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "ics"
+	}
+	if format != "ics" && format != "json" {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Unknown format %q, must be ics or json", format))
+		return
+	}
+	location, err := requestLocation(r)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	namespace := query.Get("namespace")
+	entries, err := s.calendarChanges(r.Context(), namespace, since, until, minSeverity)
+	if err != nil {
+		klog.Errorf("Failed to query calendar changes: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to query events: %v", err))
+		return
+	}
+	if s.blockConfig != nil && calendarSeverityRank(CalendarWarning) >= calendarSeverityRank(minSeverity) {
+		blockConfig, err := s.blockConfig(r.Context())
+		if err != nil {
+			// Changes are still listed without the freezes
+			klog.Warningf("Failed to load block config for the calendar: %v", err)
+		} else {
+			entries = append(entries, calendarFreezes(blockConfig, namespace, since, until)...)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Start.Before(entries[j].Start)
+	})
+
+	if format == "json" {
+		for _, entry := range entries {
+			entry.Start = inLocation(entry.Start, location)
+			if entry.End != nil {
+				end := inLocation(*entry.End, location)
+				entry.End = &end
+			}
+		}
+		s.sendJSON(w, http.StatusOK, CalendarResponse{Since: inLocation(since, location), Until: inLocation(until, location), Entries: entries})
+		return
+	}
+	s.sendText(w, "text/calendar; charset=utf-8", formatICalendar(entries, now))
+}
+
+// calendarChanges returns the notable changes between since and until of at least
+// minSeverity. Notable operations and blocked requests are read separately, so frequent
+// updates don't crowd them out.
+func (s *Server) calendarChanges(ctx context.Context, namespace string, since, until time.Time, minSeverity string) ([]*CalendarEntry, error) {
+	blocked := false
+	queries := []store.QueryFilters{
+		{Operations: []string{"CREATE", "DELETE", "EXEC", "NODE_MAINTENANCE", "CLOUD_CHANGE", "DEPLOYMENT", "SELF_MONITORING"}},
+		{Allowed: &blocked},
+	}
+
+	entries := []*CalendarEntry{}
+	seen := map[string]bool{}
+	for _, filters := range queries {
+		filters.Namespace = namespace
+		filters.StartTime, filters.EndTime = &since, &until
+		filters.Fields = []string{"exec_metadata"} // Diffs and snapshots aren't shown
+		result, err := s.store.QueryEvents(ctx, filters, store.PaginationParams{Limit: calendarEventLimit}, store.SortOrderDesc)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range result.Events {
+			severity := calendarSeverity(event)
+			if seen[event.ID] || calendarSeverityRank(severity) < calendarSeverityRank(minSeverity) {
+				continue
+			}
+			seen[event.ID] = true
+			entries = append(entries, changeEntry(event, severity))
+		}
+	}
+	return entries, nil
+}
+
+// calendarSeverity returns the severity of a change in the calendar, or "" if it is not
+// notable: blocked requests, critical self-monitoring findings, high-risk execs,
+// deletions of volumes or claims that may lose data, unsigned images deployed to protected
+// namespaces and workload updates weakening pod security are critical; network exposure changes have their own severity; deletions, node
+// maintenance, cloud changes, failed deployments, medium-risk execs and quota or LimitRange
+// changes impacting their namespace are warnings; other deployments and new workloads are info.
+func calendarSeverity(event *model.ChangeEvent) string {
+	switch {
+	case !event.Allowed:
+		return CalendarCritical
+	case event.SelfMonitor != nil:
+		return event.SelfMonitor.Severity
+	case event.VolumeDeletion != nil && event.VolumeDeletion.DataLossRisk:
+		return CalendarCritical
+	case event.ImageProvenance != nil && event.ImageProvenance.Violation:
+		return CalendarCritical
+	case event.PodSecurityRegression != nil:
+		return CalendarCritical
+	case event.ExposureChange != nil:
+		return event.ExposureChange.Severity
+	case event.QuotaImpact != nil:
+		return CalendarWarning
+	case event.Operation == "EXEC":
+		if event.ExecMetadata == nil {
+			return ""
+		}
+		switch event.ExecMetadata.RiskSeverity {
+		case "high":
+			return CalendarCritical
+		case "medium":
+			return CalendarWarning
+		}
+		return ""
+	case event.Operation == "DELETE" || event.Operation == "NODE_MAINTENANCE" || event.Operation == "CLOUD_CHANGE":
+		return CalendarWarning
+	case event.Operation == "DEPLOYMENT":
+		if event.Deployment != nil && (event.Deployment.Status == "failure" || event.Deployment.Status == "failed" || event.Deployment.Status == "error") {
+			return CalendarWarning
+		}
+		return CalendarInfo
+	case event.Operation == "CREATE":
+		switch event.ResourceKind {
+		case "Deployment", "StatefulSet", "DaemonSet", "CronJob":
+			return CalendarInfo
+		}
+	}
+	return ""
+}
+
+// calendarSeverityRank orders severities from least to most severe; unknown severities rank 0.
+func calendarSeverityRank(severity string) int {
+	switch severity {
+	case CalendarInfo:
+		return 1
+	case CalendarWarning:
+		return 2
+	case CalendarCritical:
+		return 3
+	}
+	return 0
+}
+
+// changeEntry returns the calendar entry of a notable change.
+func changeEntry(event *model.ChangeEvent, severity string) *CalendarEntry {
+	resource := event.ResourceKind + " " + event.Name
+	if event.Namespace != "" {
+		resource = event.ResourceKind + " " + event.Namespace + "/" + event.Name
+	}
+
+	var summary string
+	switch {
+	case !event.Allowed:
+		summary = fmt.Sprintf("Blocked %s of %s", event.Operation, resource)
+	case event.SelfMonitor != nil:
+		summary = fmt.Sprintf("kubechronicle: %s", event.SelfMonitor.Check)
+	case event.Deployment != nil:
+		summary = fmt.Sprintf("Deployment of %s to %s", event.Name, event.Deployment.Environment)
+		if event.Deployment.Environment == "" {
+			summary = fmt.Sprintf("Deployment of %s", event.Name)
+		}
+		if event.Deployment.Status != "" {
+			summary += " (" + event.Deployment.Status + ")"
+		}
+	default:
+		summary = fmt.Sprintf("%s %s", event.Operation, resource)
+	}
+
+	lines := []string{fmt.Sprintf("%s by %s", event.Operation, event.Actor.Username)}
+	if event.BlockPattern != "" {
+		lines = append(lines, "Blocked by: "+event.BlockPattern)
+	}
+	if event.SelfMonitor != nil {
+		lines = append(lines, event.SelfMonitor.Message)
+	}
+	if event.ExecMetadata != nil && len(event.ExecMetadata.Command) > 0 {
+		lines = append(lines, "Command: "+strings.Join(event.ExecMetadata.Command, " "))
+	}
+	if event.Deployment != nil && event.Deployment.URL != "" {
+		lines = append(lines, event.Deployment.URL)
+	}
+	lines = append(lines, "Event: "+event.ID)
+
+	return &CalendarEntry{
+		UID:         event.ID + "@kubechronicle",
+		Kind:        "change",
+		Severity:    severity,
+		Summary:     summary,
+		Description: strings.Join(lines, "\n"),
+		Start:       event.Timestamp.UTC(),
+		Namespace:   event.Namespace,
+		EventID:     event.ID,
+	}
+}
+
+// calendarFreezes returns the freezes of a block config overlapping since to until: the
+// structured block rules with a start or expiry time. A freeze without a start is shown from
+// since, and one without an expiry until until. With a namespace, only the freezes that may
+// block changes in it are returned.
+func calendarFreezes(blockConfig *config.BlockConfig, namespace string, since, until time.Time) []*CalendarEntry {
+	var entries []*CalendarEntry
+	for i, rule := range blockConfig.Rules {
+		if rule.StartsAt == nil && rule.ExpiresAt == nil {
+			continue
+		}
+		start, end := since, until
+		if rule.StartsAt != nil {
+			start = rule.StartsAt.UTC()
+		}
+		if rule.ExpiresAt != nil {
+			end = rule.ExpiresAt.UTC()
+		}
+		if !start.Before(until) || !end.After(since) || !start.Before(end) {
+			continue
+		}
+		if namespace != "" && len(rule.NamespacePatterns) > 0 && !freezeCoversNamespace(rule.NamespacePatterns, namespace) {
+			continue
+		}
+
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i)
+		}
+		var lines []string
+		if rule.Reason != "" {
+			lines = append(lines, rule.Reason)
+		}
+		for _, field := range []struct {
+			label    string
+			patterns []string
+		}{
+			{"Namespaces", rule.NamespacePatterns},
+			{"Names", rule.NamePatterns},
+			{"Kinds", rule.ResourceKindPatterns},
+			{"Operations", rule.OperationPatterns},
+		} {
+			if len(field.patterns) > 0 {
+				lines = append(lines, field.label+": "+strings.Join(field.patterns, ", "))
+			}
+		}
+		if rule.Owner != "" {
+			lines = append(lines, "Owner: "+rule.Owner)
+		}
+		if rule.TicketURL != "" {
+			lines = append(lines, rule.TicketURL)
+		}
+
+		freezeEnd := end
+		entries = append(entries, &CalendarEntry{
+			UID:         "freeze-" + name + "@kubechronicle",
+			Kind:        "freeze",
+			Severity:    CalendarWarning,
+			Summary:     "Change freeze: " + name,
+			Description: strings.Join(lines, "\n"),
+			Start:       start,
+			End:         &freezeEnd,
+			Namespace:   strings.Join(rule.NamespacePatterns, ","),
+		})
+	}
+	return entries
+}
+
+// freezeCoversNamespace reports whether a rule with the given namespace patterns may block
+// changes in namespace, itself possibly a wildcard pattern.
+func freezeCoversNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if store.MatchWildcard(pattern, namespace) || store.MatchWildcard(namespace, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatICalendar formats entries as an RFC 5545 calendar.
+func formatICalendar(entries []*CalendarEntry, now time.Time) string {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeICalendarLine(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//kubechronicle//Change Calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", "kubechronicle changes")
+	for _, entry := range entries {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeICalendarText(entry.UID))
+		line("DTSTAMP", formatICalendarTime(now))
+		line("DTSTART", formatICalendarTime(entry.Start))
+		if entry.End != nil {
+			line("DTEND", formatICalendarTime(*entry.End))
+		}
+		line("SUMMARY", escapeICalendarText(entry.Summary))
+		if entry.Description != "" {
+			line("DESCRIPTION", escapeICalendarText(entry.Description))
+		}
+		line("CATEGORIES", escapeICalendarText(entry.Kind)+","+escapeICalendarText(entry.Severity))
+		if entry.Kind == "freeze" {
+			line("TRANSP", "OPAQUE")
+		} else {
+			line("TRANSP", "TRANSPARENT")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// formatICalendarTime formats a time as an RFC 5545 UTC date-time.
+func formatICalendarTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICalendarText escapes an RFC 5545 TEXT value.
+func escapeICalendarText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICalendarLine writes a content line, folded at 75 octets without splitting UTF-8
+// characters, with CRLF line endings.
+func writeICalendarLine(b *strings.Builder, content string) {
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut-- // Continuation byte, move to the start of the character
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
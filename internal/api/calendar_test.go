@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+func calendarTestServer() *Server {
+	now := time.Now().UTC()
+	events := []*model.ChangeEvent{
+		{ID: "blocked", Timestamp: now.Add(-time.Hour), Operation: "DELETE", ResourceKind: "Namespace", Name: "payments", Allowed: false, BlockPattern: "protect-namespaces", Actor: model.Actor{Username: "alice"}},
+		{ID: "deleted", Timestamp: now.Add(-2 * time.Hour), Operation: "DELETE", ResourceKind: "ConfigMap", Namespace: "payments", Name: "flags", Allowed: true, Actor: model.Actor{Username: "bob"}},
+		{ID: "created", Timestamp: now.Add(-3 * time.Hour), Operation: "CREATE", ResourceKind: "Deployment", Namespace: "payments", Name: "api", Allowed: true, Actor: model.Actor{Username: "bob"}},
+		{ID: "pod", Timestamp: now.Add(-4 * time.Hour), Operation: "CREATE", ResourceKind: "Pod", Namespace: "payments", Name: "api-1", Allowed: true, Actor: model.Actor{Username: "system:serviceaccount:kube-system:replicaset-controller"}},
+	}
+	server := NewServer(&mockStore{queryResult: &store.QueryResult{Events: events}})
+
+	starts, expires := now.Add(24*time.Hour), now.Add(48*time.Hour)
+	server.SetBlockConfigSource(func(ctx context.Context) (*config.BlockConfig, error) {
+		return &config.BlockConfig{Rules: []config.BlockRule{
+			{Name: "quarter-end", NamespacePatterns: []string{"payments"}, Reason: "Quarter-end freeze; no changes", StartsAt: &starts, ExpiresAt: &expires},
+			{Name: "search-freeze", NamespacePatterns: []string{"search"}, ExpiresAt: &expires},
+			{Name: "permanent", NamePatterns: []string{"prod-*"}},
+		}}, nil
+	})
+	return server
+}
+
+func TestHandleCalendar_JSON(t *testing.T) {
+	server := calendarTestServer()
+	w := httptest.NewRecorder()
+	server.HandleCalendar(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/calendar?format=json&namespace=payments", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response CalendarResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	// The pod isn't notable, the search freeze is in another namespace and the permanent rule isn't a freeze
+	got := map[string]string{}
+	for _, entry := range response.Entries {
+		got[entry.UID] = entry.Severity
+	}
+	want := map[string]string{
+		"blocked@kubechronicle":            CalendarCritical,
+		"deleted@kubechronicle":            CalendarWarning,
+		"created@kubechronicle":            CalendarInfo,
+		"freeze-quarter-end@kubechronicle": CalendarWarning,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected entries %v, got %v", want, got)
+	}
+	for uid, severity := range want {
+		if got[uid] != severity {
+			t.Errorf("Expected %s with severity %q, got %q", uid, severity, got[uid])
+		}
+	}
+	if last := response.Entries[len(response.Entries)-1]; last.Kind != "freeze" || last.End == nil {
+		t.Errorf("Expected the freeze last with an end, got %+v", last)
+	}
+}
+
+func TestHandleCalendar_Severity(t *testing.T) {
+	server := calendarTestServer()
+	w := httptest.NewRecorder()
+	server.HandleCalendar(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/calendar?format=json&severity=critical", nil))
+
+	var response CalendarResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Entries) != 1 || response.Entries[0].EventID != "blocked" {
+		t.Errorf("Expected only the blocked request, got %+v", response.Entries)
+	}
+}
+
+func TestHandleCalendar_ICalendar(t *testing.T) {
+	server := calendarTestServer()
+	w := httptest.NewRecorder()
+	server.HandleCalendar(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/calendar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+		t.Errorf("Expected an iCalendar content type, got %q", ct)
+	}
+
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Errorf("Expected a calendar, got %q", body)
+	}
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 5 {
+		t.Errorf("Expected 5 events, got %d", n)
+	}
+	if !strings.Contains(body, `DESCRIPTION:Quarter-end freeze\; no changes\nNamespaces: payments`+"\r\n") {
+		t.Errorf("Expected an escaped freeze description, got %q", body)
+	}
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines folded at 75 octets, got %q", line)
+		}
+	}
+}
+
+func TestHandleCalendar_Errors(t *testing.T) {
+	server := calendarTestServer()
+	for _, query := range []string{"since=yesterday", "until=tomorrow", "since=2030-01-01T00:00:00Z&until=2029-01-01T00:00:00Z", "severity=urgent", "format=csv"} {
+		w := httptest.NewRecorder()
+		server.HandleCalendar(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/calendar?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.HandleCalendar(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/calendar", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestWriteICalendarLine(t *testing.T) {
+	var b strings.Builder
+	writeICalendarLine(&b, "SUMMARY:"+strings.Repeat("é", 60))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 || len(lines[0]) > 75 || !strings.HasPrefix(lines[1], " ") {
+		t.Fatalf("Expected a folded line, got %q", lines)
+	}
+	if unfolded := lines[0] + lines[1][1:]; unfolded != "SUMMARY:"+strings.Repeat("é", 60) {
+		t.Errorf("Expected folding to keep characters whole, got %q", unfolded)
+	}
+}
+
+func TestCalendarSeverity_ExposureChange(t *testing.T) {
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Service", Allowed: true,
+		ExposureChange: &model.ExposureChange{Severity: CalendarCritical, Findings: []model.ExposureFinding{{Check: "service_type", Severity: CalendarCritical}}}}
+	if got := calendarSeverity(event); got != CalendarCritical {
+		t.Errorf("calendarSeverity() = %q, want the severity of the exposure change", got)
+	}
+	event.ExposureChange = nil
+	if got := calendarSeverity(event); got != "" {
+		t.Errorf("calendarSeverity() = %q, want an UPDATE not to be notable", got)
+	}
+}
+
+func TestCalendarSeverity_QuotaImpact(t *testing.T) {
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "ResourceQuota", Allowed: true,
+		QuotaImpact: &model.QuotaImpact{Summary: "pods over quota", Resources: []model.QuotaResourceImpact{{Resource: "pods", Hard: "10", Used: "12"}}}}
+	if got := calendarSeverity(event); got != CalendarWarning {
+		t.Errorf("calendarSeverity() = %q, want a quota lowered below its usage to be a warning", got)
+	}
+}
+
+func TestCalendarSeverity_PodSecurityRegression(t *testing.T) {
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment", Allowed: true,
+		PodSecurityRegression: &model.PodSecurityRegression{Findings: []model.PodSecurityFinding{{Check: "host_network"}}}}
+	if got := calendarSeverity(event); got != CalendarCritical {
+		t.Errorf("calendarSeverity() = %q, want a pod security regression to be critical", got)
+	}
+}
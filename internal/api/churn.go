@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Churn report defaults: the period rates are measured over when no since= is given, and
+// the number of resources reported when no limit= is given.
+const (
+	defaultChurnPeriod = 24 * time.Hour
+	defaultChurnLimit  = 100
+)
+
+// ChurnResponse lists the resources changing faster than their threshold, fastest first.
+type ChurnResponse struct {
+	Since     time.Time           `json:"since"`
+	Until     time.Time           `json:"until"`
+	Resources []*ChurningResource `json:"resources"`
+}
+
+// ChurningResource is a resource changing faster than its threshold.
+type ChurningResource struct {
+	*store.ResourceChurn
+	ChangesPerDay   float64 `json:"changes_per_day"`
+	ThresholdPerDay int     `json:"threshold_per_day"`
+	Rule            string  `json:"rule,omitempty"` // Churn rule that set the threshold, if any
+}
+
+// SetChurnStore enables the churn endpoint, flagging resources with the thresholds of
+// churnConfig (the default threshold when nil).
+func (s *Server) SetChurnStore(churn store.ChurnStore, churnConfig *config.ChurnConfig) {
+	s.churn = churn
+	s.churnConfig = churnConfig
+}
+
+// HandleChurn handles GET /api/churn?since={RFC3339}&threshold={n}&limit={n}, which lists the
+// resources changed more than their threshold of changes per day since the given time
+// (default: the last 24 hours). threshold= replaces the configured thresholds.
+func (s *Server) HandleChurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.churn == nil {
+		s.sendError(w, http.StatusNotImplemented, "Churn tracking is not supported by the store")
+		return
+	}
+
+	query := r.URL.Query()
+	until := time.Now()
+	since := until.Add(-defaultChurnPeriod)
+	parsed, err := parseSince(query, until)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsed != nil {
+		if !parsed.Before(until) {
+			s.sendError(w, http.StatusBadRequest, "since must be in the past")
+			return
+		}
+		since = *parsed
+	}
+
+	churnConfig := s.churnConfig
+	if thresholdStr := query.Get("threshold"); thresholdStr != "" {
+		threshold, err := strconv.Atoi(thresholdStr)
+		if err != nil || threshold <= 0 {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid threshold: %q", thresholdStr))
+			return
+		}
+		churnConfig = &config.ChurnConfig{ThresholdPerDay: threshold}
+	}
+
+	limit := defaultChurnLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: %q", limitStr))
+			return
+		}
+		limit = parsed
+	}
+
+	// Only resources over the lowest threshold are read; each is then checked against its own
+	days := until.Sub(since).Hours() / 24
+	lowest := churnConfig.DefaultThreshold()
+	if churnConfig != nil {
+		for _, rule := range churnConfig.Rules {
+			if rule.ThresholdPerDay < lowest {
+				lowest = rule.ThresholdPerDay
+			}
+		}
+	}
+	minChanges := int64(math.Floor(float64(lowest)*days)) + 1
+	storeLimit := limit
+	if churnConfig != nil && len(churnConfig.Rules) > 0 {
+		storeLimit = 0 // Resources under their rule's threshold are filtered out below
+	}
+
+	churn, err := s.churn.GetChurn(r.Context(), since, minChanges, storeLimit)
+	if err != nil {
+		klog.Errorf("Failed to get churn: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get churn: %v", err))
+		return
+	}
+
+	response := ChurnResponse{Since: since, Until: until, Resources: []*ChurningResource{}}
+	for _, c := range churn {
+		threshold, rule := churnThreshold(churnConfig, c)
+		perDay := float64(c.Changes) / days
+		if perDay <= float64(threshold) {
+			continue
+		}
+		response.Resources = append(response.Resources, &ChurningResource{
+			ResourceChurn:   c,
+			ChangesPerDay:   math.Round(perDay*10) / 10,
+			ThresholdPerDay: threshold,
+			Rule:            rule,
+		})
+		if len(response.Resources) == limit {
+			break
+		}
+	}
+
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// churnThreshold returns the threshold of changes per day of a resource, and the name of
+// the rule that set it, if any.
+func churnThreshold(churnConfig *config.ChurnConfig, c *store.ResourceChurn) (int, string) {
+	if churnConfig != nil {
+		for _, rule := range churnConfig.Rules {
+			if matchesAny(rule.NamespacePatterns, c.Namespace) && matchesAny(rule.ResourceKindPatterns, c.ResourceKind) {
+				return rule.ThresholdPerDay, rule.Name
+			}
+		}
+	}
+	return churnConfig.DefaultThreshold(), ""
+}
+
+// matchesAny reports whether s matches one of patterns; an empty list matches everything.
+func matchesAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if store.MatchWildcard(pattern, s) {
+			return true
+		}
+	}
+	return false
+}
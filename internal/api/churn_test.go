@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeChurnStore is a store.ChurnStore returning fixed churn, filtered like the store.
+type fakeChurnStore struct {
+	since      time.Time
+	minChanges int64
+	limit      int
+	churn      []*store.ResourceChurn
+}
+
+func (f *fakeChurnStore) GetChurn(ctx context.Context, since time.Time, minChanges int64, limit int) ([]*store.ResourceChurn, error) {
+	f.since, f.minChanges, f.limit = since, minChanges, limit
+	churn := []*store.ResourceChurn{}
+	for _, c := range f.churn {
+		if c.Changes >= minChanges && (limit == 0 || len(churn) < limit) {
+			churn = append(churn, c)
+		}
+	}
+	return churn, nil
+}
+
+func TestHandleChurn(t *testing.T) {
+	fake := &fakeChurnStore{churn: []*store.ResourceChurn{
+		{ResourceKind: "Lease", Namespace: "kube-system", Name: "controller-lock", Changes: 40000},
+		{ResourceKind: "ConfigMap", Namespace: "payments", Name: "feature-flags", Changes: 1400, TopActor: "system:serviceaccount:payments:flagger"},
+		{ResourceKind: "Deployment", Namespace: "payments", Name: "api", Changes: 600},
+		{ResourceKind: "ConfigMap", Namespace: "search", Name: "settings", Changes: 300},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetChurnStore(fake, &config.ChurnConfig{Rules: []config.ChurnRule{
+		{Name: "leases", ResourceKindPatterns: []string{"Lease"}, ThresholdPerDay: 100000},
+		{Name: "payments", NamespacePatterns: []string{"payments"}, ResourceKindPatterns: []string{"ConfigMap"}, ThresholdPerDay: 200},
+	}})
+
+	w := httptest.NewRecorder()
+	server.HandleChurn(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/churn", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.minChanges != 201 || fake.limit != 0 {
+		t.Errorf("Expected resources over the lowest threshold without a limit, got min %d, limit %d", fake.minChanges, fake.limit)
+	}
+
+	var response ChurnResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got := response.Until.Sub(response.Since); got != defaultChurnPeriod {
+		t.Errorf("Expected the default period, got %v", got)
+	}
+	// The lease is under its rule's threshold and the search ConfigMap under the default
+	if len(response.Resources) != 2 {
+		t.Fatalf("Expected 2 churning resources, got %+v", response.Resources)
+	}
+	flags := response.Resources[0]
+	if flags.Name != "feature-flags" || flags.Rule != "payments" || flags.ThresholdPerDay != 200 || flags.TopActor != "system:serviceaccount:payments:flagger" {
+		t.Errorf("Unexpected resource: %+v", flags)
+	}
+	if flags.ChangesPerDay < 1399 || flags.ChangesPerDay > 1401 {
+		t.Errorf("Expected about 1400 changes per day, got %v", flags.ChangesPerDay)
+	}
+	if api := response.Resources[1]; api.Name != "api" || api.Rule != "" || api.ThresholdPerDay != config.DefaultChurnThreshold {
+		t.Errorf("Unexpected resource: %+v", api)
+	}
+}
+
+func TestHandleChurn_ThresholdOverride(t *testing.T) {
+	fake := &fakeChurnStore{churn: []*store.ResourceChurn{
+		{ResourceKind: "Lease", Namespace: "kube-system", Name: "controller-lock", Changes: 40000},
+		{ResourceKind: "ConfigMap", Namespace: "search", Name: "settings", Changes: 300},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetChurnStore(fake, &config.ChurnConfig{Rules: []config.ChurnRule{
+		{Name: "leases", ResourceKindPatterns: []string{"Lease"}, ThresholdPerDay: 100000},
+	}})
+
+	since := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	server.HandleChurn(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/churn?since="+since+"&threshold=100&limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.minChanges < 200 || fake.minChanges > 202 || fake.limit != 1 {
+		t.Errorf("Expected 100 changes per day over 2 days and the limit, got min %d, limit %d", fake.minChanges, fake.limit)
+	}
+
+	var response ChurnResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Resources) != 1 || response.Resources[0].Name != "controller-lock" || response.Resources[0].ThresholdPerDay != 100 {
+		t.Errorf("Expected the lease over the given threshold, got %+v", response.Resources)
+	}
+}
+
+func TestHandleChurn_Errors(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer(&mockStore{}).HandleChurn(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/churn", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a churn store, got %d", w.Code)
+	}
+
+	server := NewServer(&mockStore{})
+	server.SetChurnStore(&fakeChurnStore{}, nil)
+	for _, query := range []string{"since=yesterday", "since=2999-01-01T00:00:00Z", "last=-1h", "since=1h&last=1h", "threshold=0", "threshold=many", "limit=-1"} {
+		w := httptest.NewRecorder()
+		server.HandleChurn(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/churn?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Compliance report defaults: the period checked when no since= is given, and the number of
+// violations listed when no limit= is given.
+const (
+	defaultCompliancePeriod = 30 * 24 * time.Hour
+	defaultComplianceLimit  = 100
+)
+
+// Kinds of change management violations.
+const (
+	ViolationFreeze        = "freeze"         // Change allowed during a freeze covering it
+	ViolationOutsideWindow = "outside_window" // Change outside the approved windows of its namespace
+	ViolationPodSecurity   = "pod_security"   // Workload update weakening the security of its pods
+)
+
+// complianceOperations are the operations of the changes checked.
+var complianceOperations = []string{"CREATE", "UPDATE", "DELETE", "DEPLOYMENT"}
+
+// ComplianceResponse summarizes the changes made during freezes, outside approved change
+// windows or weakening pod security, per team, and lists the violations oldest first.
+type ComplianceResponse struct {
+	Since      time.Time              `json:"since"`
+	Until      time.Time              `json:"until"`
+	Changes    int                    `json:"changes"`    // Changes checked
+	Violations int                    `json:"violations"` // Changes violating a freeze, window or pod security
+	Blocked    int                    `json:"blocked"`    // Changes blocked by a freeze, not violations
+	Teams      []*TeamCompliance      `json:"teams"`
+	Events     []*ComplianceViolation `json:"events"`
+	Truncated  bool                   `json:"truncated,omitempty"` // More violations than listed
+}
+
+// TeamCompliance counts the changes and violations of a team's namespaces. Team is empty
+// for changes without a team enrichment.
+type TeamCompliance struct {
+	Team             string `json:"team"`
+	Changes          int    `json:"changes"`
+	FreezeViolations int    `json:"freeze_violations"`
+	WindowViolations int    `json:"window_violations"`
+	PodSecurity      int    `json:"pod_security_regressions"`
+	Blocked          int    `json:"blocked"`
+}
+
+// ComplianceViolation is a change made during a freeze, outside the approved windows, or
+// weakening the security of its pods.
+type ComplianceViolation struct {
+	EventID      string    `json:"event_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Operation    string    `json:"operation"`
+	ResourceKind string    `json:"resource_kind"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Name         string    `json:"name"`
+	User         string    `json:"user"`
+	Team         string    `json:"team,omitempty"`
+	Violation    string    `json:"violation"`        // ViolationFreeze, ViolationOutsideWindow or ViolationPodSecurity
+	Rule         string    `json:"rule,omitempty"`   // Freeze the change violated
+	Checks       []string  `json:"checks,omitempty"` // Pod security checks the change failed
+}
+
+// SetComplianceStore enables the compliance endpoint, checking the changes events reads
+// against the freezes of the block config source and the approved windows of changeWindows
+// (freezes only when nil).
+func (s *Server) SetComplianceStore(events store.EventStreamer, changeWindows *config.ChangeWindowConfig) {
+	s.compliance = events
+	s.changeWindows = changeWindows
+}
+
+// HandleCompliance handles GET /api/compliance?since={time}&until={time}&namespace={ns}&team={team}&limit={n},
+// a change management report of the changes made during change freezes (block rules with a
+// start or expiry time), outside the approved change windows of their namespace, or weakening
+// the security of their pods (default: over the last 30 days), counted per team and listed up
+// to limit. Timestamps are in the
+// time zone of tz= or the X-Timezone header, else UTC.
+func (s *Server) HandleCompliance(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.compliance == nil {
+		s.sendError(w, http.StatusNotImplemented, "Compliance reports are not supported by the store")
+		return
+	}
+
+	query := r.URL.Query()
+	until := time.Now().UTC()
+	parsedUntil, err := parseTimeParam(query, "until", until)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedUntil != nil {
+		until = parsedUntil.UTC()
+	}
+	since := until.Add(-defaultCompliancePeriod)
+	parsedSince, err := parseSince(query, until)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedSince != nil {
+		since = parsedSince.UTC()
+	}
+	if !since.Before(until) {
+		s.sendError(w, http.StatusBadRequest, "since must be before until")
+		return
+	}
+
+	limit := defaultComplianceLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 0 {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: %q", limitStr))
+			return
+		}
+		limit = parsed
+	}
+	location, err := requestLocation(r)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var freezes []config.BlockRule
+	if s.blockConfig != nil {
+		blockConfig, err := s.blockConfig(r.Context())
+		if err != nil {
+			// Unlike the calendar, a report without the freezes would be wrong
+			klog.Errorf("Failed to load block config for the compliance report: %v", err)
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load the change freezes: %v", err))
+			return
+		}
+		freezes = complianceFreezes(blockConfig)
+	}
+
+	report := newComplianceReport(since, until, freezes, s.changeWindows, query.Get("team"), limit)
+	filters := store.QueryFilters{
+		Operations: complianceOperations,
+		Namespace:  query.Get("namespace"),
+		StartTime:  &since,
+		EndTime:    &until,
+		Fields:     []string{"exec_metadata"}, // Diffs and snapshots aren't read
+	}
+	if err := s.compliance.StreamEvents(r.Context(), filters, report.check); err != nil {
+		klog.Errorf("Failed to read changes for the compliance report: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to query events: %v", err))
+		return
+	}
+	response := report.response()
+	response.Since, response.Until = inLocation(response.Since, location), inLocation(response.Until, location)
+	for _, violation := range response.Events {
+		violation.Timestamp = inLocation(violation.Timestamp, location)
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// complianceFreezes returns the freezes of a block config: the structured block rules with
+// a start or expiry time, as in the calendar.
+func complianceFreezes(blockConfig *config.BlockConfig) []config.BlockRule {
+	var freezes []config.BlockRule
+	for _, rule := range blockConfig.Rules {
+		if rule.StartsAt != nil || rule.ExpiresAt != nil {
+			freezes = append(freezes, rule)
+		}
+	}
+	return freezes
+}
+
+// complianceReport accumulates the compliance report of the changes it checks.
+type complianceReport struct {
+	freezes []config.BlockRule
+	windows *config.ChangeWindowConfig
+	team    string // Only changes of this team are checked, if set
+	limit   int
+
+	result ComplianceResponse
+	teams  map[string]*TeamCompliance
+}
+
+func newComplianceReport(since, until time.Time, freezes []config.BlockRule, windows *config.ChangeWindowConfig, team string, limit int) *complianceReport {
+	return &complianceReport{
+		freezes: freezes,
+		windows: windows,
+		team:    team,
+		limit:   limit,
+		result:  ComplianceResponse{Since: since, Until: until, Events: []*ComplianceViolation{}},
+		teams:   map[string]*TeamCompliance{},
+	}
+}
+
+// check adds a change to the report. A change is reported as one violation only: a freeze
+// violation first, then outside the approved windows, then a pod security regression.
+func (c *complianceReport) check(event *model.ChangeEvent) error {
+	team := event.Enrichment["team"]
+	if (c.team != "" && team != c.team) || c.windows.ExcludesUser(event.Actor.Username) {
+		return nil
+	}
+	counts := c.teams[team]
+	if counts == nil {
+		counts = &TeamCompliance{Team: team}
+		c.teams[team] = counts
+	}
+
+	if !event.Allowed {
+		if c.freezeAt(event) != nil {
+			counts.Blocked++
+			c.result.Blocked++
+		}
+		return nil // Blocked requests are not changes
+	}
+	counts.Changes++
+	c.result.Changes++
+
+	violation, rule := "", ""
+	if freeze := c.freezeAt(event); freeze != nil {
+		violation, rule = ViolationFreeze, freeze.Name
+		counts.FreezeViolations++
+	} else if governed, approved := c.windows.CheckChange(event.Namespace, event.Timestamp); governed && !approved {
+		violation = ViolationOutsideWindow
+		counts.WindowViolations++
+	} else if event.PodSecurityRegression != nil {
+		violation = ViolationPodSecurity
+		counts.PodSecurity++
+	}
+	if violation == "" {
+		return nil
+	}
+
+	c.result.Violations++
+	if len(c.result.Events) == c.limit {
+		c.result.Truncated = true
+		return nil
+	}
+	c.result.Events = append(c.result.Events, &ComplianceViolation{
+		EventID:      event.ID,
+		Timestamp:    event.Timestamp,
+		Operation:    event.Operation,
+		ResourceKind: event.ResourceKind,
+		Namespace:    event.Namespace,
+		Name:         event.Name,
+		User:         event.Actor.Username,
+		Team:         team,
+		Violation:    violation,
+		Rule:         rule,
+		Checks:       podSecurityChecks(event.PodSecurityRegression),
+	})
+	return nil
+}
+
+// podSecurityChecks returns the distinct checks of the findings of regression, if any.
+func podSecurityChecks(regression *model.PodSecurityRegression) []string {
+	if regression == nil {
+		return nil
+	}
+	var checks []string
+	for _, finding := range regression.Findings {
+		if !containsFold(checks, finding.Check) {
+			checks = append(checks, finding.Check)
+		}
+	}
+	return checks
+}
+
+// freezeAt returns the first freeze in effect when the event happened that covers it, or nil.
+func (c *complianceReport) freezeAt(event *model.ChangeEvent) *config.BlockRule {
+	for i := range c.freezes {
+		freeze := &c.freezes[i]
+		if freeze.Active(event.Timestamp) && freezeCovers(freeze, event) {
+			return freeze
+		}
+	}
+	return nil
+}
+
+// freezeCovers reports whether the event matches all of the freeze's non-empty pattern
+// lists and operations, like the webhook's block rules. A freeze without patterns covers
+// nothing.
+func freezeCovers(freeze *config.BlockRule, event *model.ChangeEvent) bool {
+	if len(freeze.OperationPatterns) > 0 && !containsFold(freeze.OperationPatterns, event.Operation) {
+		return false
+	}
+	matched := false
+	for _, check := range []struct {
+		patterns []string
+		value    string
+	}{
+		{freeze.NamespacePatterns, event.Namespace},
+		{freeze.NamePatterns, event.Name},
+		{freeze.ResourceKindPatterns, event.ResourceKind},
+	} {
+		if len(check.patterns) == 0 {
+			continue
+		}
+		if !matchesAnyWildcard(check.patterns, check.value) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// matchesAnyWildcard reports whether value matches one of patterns.
+func matchesAnyWildcard(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if store.MatchWildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// response returns the report, with the teams with the most violations first.
+func (c *complianceReport) response() ComplianceResponse {
+	result := c.result
+	result.Teams = make([]*TeamCompliance, 0, len(c.teams))
+	for _, team := range c.teams {
+		result.Teams = append(result.Teams, team)
+	}
+	sort.Slice(result.Teams, func(i, j int) bool {
+		a, b := result.Teams[i], result.Teams[j]
+		if va, vb := a.FreezeViolations+a.WindowViolations+a.PodSecurity, b.FreezeViolations+b.WindowViolations+b.PodSecurity; va != vb {
+			return va > vb
+		}
+		return a.Team < b.Team
+	})
+	return result
+}
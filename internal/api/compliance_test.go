@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeEventStreamer is a store.EventStreamer of fixed events.
+type fakeEventStreamer struct {
+	filters store.QueryFilters
+	events  []*model.ChangeEvent
+}
+
+func (f *fakeEventStreamer) StreamEvents(ctx context.Context, filters store.QueryFilters, fn func(*model.ChangeEvent) error) error {
+	f.filters = filters
+	for _, event := range f.events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestHandleCompliance(t *testing.T) {
+	// January 5, 2026 is a Monday; the freeze covers payments that day
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	change := func(id string, timestamp time.Time, namespace, team, user string, allowed bool) *model.ChangeEvent {
+		event := &model.ChangeEvent{ID: id, Timestamp: timestamp, Operation: "UPDATE", ResourceKind: "Deployment", Namespace: namespace, Name: "api", Allowed: allowed, Actor: model.Actor{Username: user}}
+		if team != "" {
+			event.Enrichment = map[string]string{"team": team}
+		}
+		return event
+	}
+	streamer := &fakeEventStreamer{events: []*model.ChangeEvent{
+		change("in-window", at(5, 10), "prod-search", "search", "alice", true),
+		change("frozen", at(5, 11), "prod-payments", "payments", "bob", true),
+		change("blocked", at(5, 12), "prod-payments", "payments", "bob", false),
+		change("night", at(5, 22), "prod-search", "search", "carol", true),
+		change("controller", at(5, 23), "prod-search", "search", "system:serviceaccount:kube-system:deployment-controller", true),
+		change("dev", at(5, 23), "dev", "", "dave", true),
+	}}
+
+	server := NewServer(&mockStore{})
+	server.SetComplianceStore(streamer, &config.ChangeWindowConfig{
+		Windows:      []config.ChangeWindow{{Name: "business-hours", NamespacePatterns: []string{"prod-*"}, Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "18:00"}},
+		ExcludeUsers: []string{"system:*"},
+	})
+	starts, expires := at(5, 0), at(6, 0)
+	server.SetBlockConfigSource(func(ctx context.Context) (*config.BlockConfig, error) {
+		return &config.BlockConfig{Rules: []config.BlockRule{
+			{Name: "quarter-end", NamespacePatterns: []string{"prod-payments"}, StartsAt: &starts, ExpiresAt: &expires},
+			{Name: "permanent", NamespacePatterns: []string{"prod-*"}, NamePatterns: []string{"legacy-*"}},
+		}}, nil
+	})
+
+	w := httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !streamer.filters.StartTime.Equal(at(1, 0)) || len(streamer.filters.Operations) != len(complianceOperations) {
+		t.Errorf("Unexpected filters: %+v", streamer.filters)
+	}
+
+	var response ComplianceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Changes != 4 || response.Violations != 2 || response.Blocked != 1 {
+		t.Errorf("Got %d changes, %d violations and %d blocked, want 4, 2 and 1", response.Changes, response.Violations, response.Blocked)
+	}
+	if len(response.Events) != 2 {
+		t.Fatalf("Expected 2 violations, got %+v", response.Events)
+	}
+	if v := response.Events[0]; v.EventID != "frozen" || v.Violation != ViolationFreeze || v.Rule != "quarter-end" || v.Team != "payments" {
+		t.Errorf("Unexpected first violation: %+v", v)
+	}
+	if v := response.Events[1]; v.EventID != "night" || v.Violation != ViolationOutsideWindow || v.Rule != "" {
+		t.Errorf("Unexpected second violation: %+v", v)
+	}
+
+	want := []TeamCompliance{
+		{Team: "payments", Changes: 1, FreezeViolations: 1, Blocked: 1},
+		{Team: "search", Changes: 2, WindowViolations: 1},
+		{Team: "", Changes: 1},
+	}
+	if len(response.Teams) != len(want) {
+		t.Fatalf("Expected %d teams, got %+v", len(want), response.Teams)
+	}
+	for i := range want {
+		if *response.Teams[i] != want[i] {
+			t.Errorf("Team %d = %+v, want %+v", i, *response.Teams[i], want[i])
+		}
+	}
+
+	// Listing fewer violations than found
+	w = httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?since=2026-01-01T00:00:00Z&limit=1&team=search", nil))
+	response = ComplianceResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Violations != 1 || len(response.Events) != 1 || response.Events[0].EventID != "night" || len(response.Teams) != 1 {
+		t.Errorf("Unexpected report of the search team: %+v", response)
+	}
+}
+
+func TestHandleCompliance_PodSecurity(t *testing.T) {
+	regression := &model.PodSecurityRegression{Findings: []model.PodSecurityFinding{
+		{Check: "privileged", Container: "app"}, {Check: "host_path"}, {Check: "privileged", Container: "sidecar"},
+	}}
+	timestamp := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	streamer := &fakeEventStreamer{events: []*model.ChangeEvent{
+		{ID: "hardened", Timestamp: timestamp, Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "prod", Name: "api", Allowed: true},
+		{ID: "privileged", Timestamp: timestamp, Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "prod", Name: "agent", Allowed: true,
+			PodSecurityRegression: regression, Enrichment: map[string]string{"team": "infra"}},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetComplianceStore(streamer, nil)
+
+	w := httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z", nil))
+	var response ComplianceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Changes != 2 || response.Violations != 1 || len(response.Events) != 1 {
+		t.Fatalf("Expected 1 violation among 2 changes, got %+v", response)
+	}
+	v := response.Events[0]
+	if v.EventID != "privileged" || v.Violation != ViolationPodSecurity || len(v.Checks) != 2 || v.Checks[0] != "privileged" || v.Checks[1] != "host_path" {
+		t.Errorf("Unexpected violation: %+v", v)
+	}
+	if len(response.Teams) != 2 || *response.Teams[0] != (TeamCompliance{Team: "infra", Changes: 1, PodSecurity: 1}) {
+		t.Errorf("Unexpected teams: %+v", response.Teams)
+	}
+}
+
+func TestHandleCompliance_Errors(t *testing.T) {
+	server := NewServer(&mockStore{})
+	w := httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a compliance store, got %d", w.Code)
+	}
+
+	server.SetComplianceStore(&fakeEventStreamer{}, nil)
+	for _, query := range []string{"since=yesterday", "limit=-1", "since=2026-02-01T00:00:00Z&until=2026-01-01T00:00:00Z"} {
+		w = httptest.NewRecorder()
+		server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
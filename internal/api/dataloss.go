@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Data loss report defaults: the period checked when no since= is given, and the number of
+// deletions listed when no limit= is given.
+const (
+	defaultDataLossPeriod = 30 * 24 * time.Hour
+	defaultDataLossLimit  = 100
+)
+
+// volumeKinds are the kinds of the deletions checked by the data loss report.
+var volumeKinds = []string{"PersistentVolume", "PersistentVolumeClaim"}
+
+// DataLossResponse counts the deletions of volumes and claims, and lists those that may have
+// lost data oldest first.
+type DataLossResponse struct {
+	Since         time.Time        `json:"since"`
+	Until         time.Time        `json:"until"`
+	Deletions     int              `json:"deletions"`      // Volumes and claims deleted
+	AtRisk        int              `json:"at_risk"`        // Deletions that may have lost data
+	UnknownPolicy int              `json:"unknown_policy"` // At-risk deletions whose reclaim policy is unknown
+	Events        []*DataLossEvent `json:"events"`
+	Truncated     bool             `json:"truncated,omitempty"` // More at-risk deletions than listed
+}
+
+// DataLossEvent is the deletion of a volume or claim that may have lost data.
+type DataLossEvent struct {
+	EventID       string    `json:"event_id"`
+	Timestamp     time.Time `json:"timestamp"`
+	ResourceKind  string    `json:"resource_kind"`
+	Namespace     string    `json:"namespace,omitempty"`
+	Name          string    `json:"name"`
+	User          string    `json:"user"`
+	Volume        string    `json:"volume,omitempty"`
+	Claim         string    `json:"claim,omitempty"`
+	StorageClass  string    `json:"storage_class,omitempty"`
+	Capacity      string    `json:"capacity,omitempty"`
+	ReclaimPolicy string    `json:"reclaim_policy,omitempty"` // Empty if unknown
+}
+
+// SetDataLossStore enables the data loss endpoint, reading the deletions of volumes and
+// claims from events.
+func (s *Server) SetDataLossStore(events store.EventStreamer) {
+	s.dataLoss = events
+}
+
+// HandleDataLoss handles GET /api/data-loss?since={time}&until={time}&namespace={ns}&limit={n},
+// a report of the PersistentVolumes and PersistentVolumeClaims deleted (default: over the
+// last 30 days) whose reclaim policy isn't Retain, so their data may have been deleted with
+// them. A namespace only selects claims. Timestamps are in the time zone of tz= or the
+// X-Timezone header, else UTC.
+func (s *Server) HandleDataLoss(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.dataLoss == nil {
+		s.sendError(w, http.StatusNotImplemented, "Data loss reports are not supported by the store")
+		return
+	}
+
+	query := r.URL.Query()
+	until := time.Now().UTC()
+	parsedUntil, err := parseTimeParam(query, "until", until)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedUntil != nil {
+		until = parsedUntil.UTC()
+	}
+	since := until.Add(-defaultDataLossPeriod)
+	parsedSince, err := parseSince(query, until)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsedSince != nil {
+		since = parsedSince.UTC()
+	}
+	if !since.Before(until) {
+		s.sendError(w, http.StatusBadRequest, "since must be before until")
+		return
+	}
+
+	limit := defaultDataLossLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 0 {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: %q", limitStr))
+			return
+		}
+		limit = parsed
+	}
+	location, err := requestLocation(r)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	allowed := true
+	response := DataLossResponse{Since: since, Until: until, Events: []*DataLossEvent{}}
+	filters := store.QueryFilters{
+		Operation:     "DELETE",
+		ResourceKinds: volumeKinds,
+		Namespace:     query.Get("namespace"),
+		StartTime:     &since,
+		EndTime:       &until,
+		Allowed:       &allowed,
+		Fields:        []string{"volume_deletion"}, // Diffs and snapshots aren't read
+	}
+	err = s.dataLoss.StreamEvents(r.Context(), filters, func(event *model.ChangeEvent) error {
+		addDataLoss(&response, event, limit)
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("Failed to read deletions for the data loss report: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to query events: %v", err))
+		return
+	}
+	response.Since, response.Until = inLocation(response.Since, location), inLocation(response.Until, location)
+	for _, event := range response.Events {
+		event.Timestamp = inLocation(event.Timestamp, location)
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// addDataLoss adds the deletion of a volume or claim to the report, listing it up to limit
+// if it may have lost data. Deletions recorded before the webhook flagged them are counted
+// but not checked.
+func addDataLoss(report *DataLossResponse, event *model.ChangeEvent, limit int) {
+	report.Deletions++
+	deletion := event.VolumeDeletion
+	if deletion == nil || !deletion.DataLossRisk {
+		return
+	}
+	report.AtRisk++
+	if deletion.ReclaimPolicy == "" {
+		report.UnknownPolicy++
+	}
+	if len(report.Events) == limit {
+		report.Truncated = true
+		return
+	}
+	report.Events = append(report.Events, &DataLossEvent{
+		EventID:       event.ID,
+		Timestamp:     event.Timestamp,
+		ResourceKind:  event.ResourceKind,
+		Namespace:     event.Namespace,
+		Name:          event.Name,
+		User:          event.Actor.Username,
+		Volume:        deletion.Volume,
+		Claim:         deletion.Claim,
+		StorageClass:  deletion.StorageClass,
+		Capacity:      deletion.Capacity,
+		ReclaimPolicy: deletion.ReclaimPolicy,
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestHandleDataLoss(t *testing.T) {
+	at := time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC)
+	deletion := func(id, kind string, volume *model.VolumeDeletion) *model.ChangeEvent {
+		return &model.ChangeEvent{ID: id, Timestamp: at, Operation: "DELETE", ResourceKind: kind, Namespace: "shop", Name: id, Allowed: true,
+			Actor: model.Actor{Username: "alice"}, VolumeDeletion: volume}
+	}
+	streamer := &fakeEventStreamer{events: []*model.ChangeEvent{
+		deletion("deleted", "PersistentVolumeClaim", &model.VolumeDeletion{Volume: "pvc-1", Claim: "shop/deleted", ReclaimPolicy: "Delete", DataLossRisk: true}),
+		deletion("retained", "PersistentVolumeClaim", &model.VolumeDeletion{Volume: "pvc-2", Claim: "shop/retained", ReclaimPolicy: "Retain"}),
+		deletion("unknown", "PersistentVolumeClaim", &model.VolumeDeletion{Volume: "pvc-3", Claim: "shop/unknown", DataLossRisk: true}),
+		deletion("legacy", "PersistentVolume", nil),
+	}}
+
+	server := NewServer(&mockStore{})
+	server.SetDataLossStore(streamer)
+
+	w := httptest.NewRecorder()
+	server.HandleDataLoss(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/data-loss?since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z&limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if streamer.filters.Operation != "DELETE" || len(streamer.filters.ResourceKinds) != 2 || *streamer.filters.Allowed != true {
+		t.Errorf("Unexpected filters: %+v", streamer.filters)
+	}
+
+	var response DataLossResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Deletions != 4 || response.AtRisk != 2 || response.UnknownPolicy != 1 {
+		t.Errorf("Got %d deletions, %d at risk and %d unknown, want 4, 2 and 1", response.Deletions, response.AtRisk, response.UnknownPolicy)
+	}
+	if len(response.Events) != 1 || !response.Truncated {
+		t.Fatalf("Expected 1 deletion listed and the list truncated, got %+v", response.Events)
+	}
+	if event := response.Events[0]; event.EventID != "deleted" || event.Volume != "pvc-1" || event.ReclaimPolicy != "Delete" || event.User != "alice" {
+		t.Errorf("Unexpected deletion: %+v", event)
+	}
+}
+
+func TestHandleDataLoss_NotSupported(t *testing.T) {
+	server := NewServer(&mockStore{})
+	w := httptest.NewRecorder()
+	server.HandleDataLoss(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/data-loss", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/diff"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// DiffResponse is the JSON rendering of a change's diff.
+type DiffResponse struct {
+	ID           string           `json:"id"`
+	Operation    string           `json:"operation"`
+	ResourceKind string           `json:"resource_kind"`
+	Namespace    string           `json:"namespace,omitempty"`
+	Name         string           `json:"name"`
+	Hunks        []diff.Hunk      `json:"hunks"`
+	CRDChange    *model.CRDChange `json:"crd_change,omitempty"`    // Versions and schema fields changed, for CustomResourceDefinitions
+	SizeExceeded bool             `json:"size_exceeded,omitempty"` // The object was too large for its diff to be recorded
+}
+
+// handleChangeDiff handles GET /api/changes/{id}/diff?format={unified|json|yaml|html}, which
+// renders the stored patch and snapshot of a change as a unified diff (default), JSON or
+// YAML hunks, or an HTML fragment. The diff of a CustomResourceDefinition starts with the
+// summary of its versions and schema fields changed.
+func (s *Server) handleChangeDiff(w http.ResponseWriter, r *http.Request, id string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "unified"
+		if wantsYAML(r) {
+			format = "yaml"
+		}
+	}
+	if format != "unified" && format != "json" && format != "yaml" && format != "html" {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Unknown format %q, must be unified, json, yaml or html", format))
+		return
+	}
+
+	event, err := s.store.GetEventByID(r.Context(), id)
+	if err != nil {
+		klog.Errorf("Failed to get event by ID: %v", err)
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Change event not found: %v", err))
+		return
+	}
+	if event.Encrypted && !s.canDecrypt(r) {
+		s.sendError(w, http.StatusForbidden, "The diff of this change is encrypted and you may not decrypt it")
+		return
+	}
+
+	hunks := diff.Hunks(event.Diff, event.ObjectSnapshot)
+	switch format {
+	case "json", "yaml":
+		s.sendDetail(w, r, http.StatusOK, DiffResponse{
+			ID:           event.ID,
+			Operation:    event.Operation,
+			ResourceKind: event.ResourceKind,
+			Namespace:    event.Namespace,
+			Name:         event.Name,
+			Hunks:        hunks,
+			CRDChange:    event.CRDChange,
+			SizeExceeded: event.SizeExceeded,
+		})
+	case "html":
+		s.sendText(w, "text/html; charset=utf-8", diff.SummaryHTML(diff.CRDSummary(event.CRDChange))+diff.HTML(hunks))
+	default:
+		name := event.ResourceKind + "/" + event.Name
+		if event.Namespace != "" {
+			name = event.ResourceKind + "/" + event.Namespace + "/" + event.Name
+		}
+		s.sendText(w, "text/x-diff; charset=utf-8", diff.SummaryText(diff.CRDSummary(event.CRDChange))+diff.Unified(name, hunks))
+	}
+}
+
+// ObjectsResponse holds the filtered objects before and after a change, for showing them
+// side by side.
+type ObjectsResponse struct {
+	ID           string                 `json:"id"`
+	Operation    string                 `json:"operation"`
+	ResourceKind string                 `json:"resource_kind"`
+	Namespace    string                 `json:"namespace,omitempty"`
+	Name         string                 `json:"name"`
+	Old          map[string]interface{} `json:"old"`
+	New          map[string]interface{} `json:"new"` // Null when the object was deleted
+}
+
+// handleChangeObjects handles GET /api/changes/{id}/objects, which returns the objects
+// before and after a change. The old object is the change's snapshot, recorded for DELETEs
+// and, with SNAPSHOT_UPDATES, UPDATEs; the new object is rebuilt by applying the diff to it.
+func (s *Server) handleChangeObjects(w http.ResponseWriter, r *http.Request, id string) {
+	event, err := s.store.GetEventByID(r.Context(), id)
+	if err != nil {
+		klog.Errorf("Failed to get event by ID: %v", err)
+		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Change event not found: %v", err))
+		return
+	}
+	if event.Encrypted && !s.canDecrypt(r) {
+		s.sendError(w, http.StatusForbidden, "The objects of this change are encrypted and you may not decrypt them")
+		return
+	}
+	if event.ObjectSnapshot == nil {
+		s.sendError(w, http.StatusNotFound, "No snapshot was recorded for this change; the webhook records UPDATE snapshots with SNAPSHOT_UPDATES enabled")
+		return
+	}
+
+	response := ObjectsResponse{
+		ID:           event.ID,
+		Operation:    event.Operation,
+		ResourceKind: event.ResourceKind,
+		Namespace:    event.Namespace,
+		Name:         event.Name,
+		Old:          event.ObjectSnapshot,
+	}
+	if event.Operation == "UPDATE" {
+		response.New, err = diff.Apply(event.ObjectSnapshot, event.Diff)
+		if err != nil {
+			klog.Errorf("Failed to apply the diff of event %s to its snapshot: %v", event.ID, err)
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to rebuild the new object: %v", err))
+			return
+		}
+	}
+	s.sendDetail(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func diffTestEvent() *model.ChangeEvent {
+	return &model.ChangeEvent{
+		ID:           "UPDATE-Deployment-api-1",
+		Operation:    "UPDATE",
+		ResourceKind: "Deployment",
+		Namespace:    "payments",
+		Name:         "api",
+		Diff:         []model.PatchOp{{Op: "replace", Path: "/spec/replicas", Value: float64(3)}},
+		Allowed:      true,
+	}
+}
+
+func TestHandleChangeDiff_Unified(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: diffTestEvent()})
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/x-diff") {
+		t.Errorf("expected a diff content type, got %q", ct)
+	}
+	want := "--- a/Deployment/payments/api\n+++ b/Deployment/payments/api\n@@ -0,0 +1,1 @@ /spec/replicas\n+3\n"
+	if rec.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestHandleChangeDiff_Formats(t *testing.T) {
+	event := diffTestEvent()
+	event.Operation, event.Diff = "DELETE", nil
+	event.ObjectSnapshot = map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}}
+	server := NewServer(&mockStore{eventByID: event})
+
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=json", nil))
+	var response DiffResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Name != "api" || len(response.Hunks) != 1 || response.Hunks[0].Op != "remove" || len(response.Hunks[0].Old) != 5 {
+		t.Errorf("expected the removal of the snapshot, got %+v", response)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=html", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" || !strings.Contains(rec.Body.String(), `class="kc-diff-del"`) {
+		t.Errorf("expected an HTML fragment, got %q: %s", ct, rec.Body.String())
+	}
+}
+
+func TestHandleChangeDiff_CRDSummary(t *testing.T) {
+	event := diffTestEvent()
+	event.ResourceKind, event.Namespace, event.Name = "CustomResourceDefinition", "", "widgets.example.com"
+	event.CRDChange = &model.CRDChange{
+		AddedVersions: []string{"v2"},
+		Fields:        []model.CRDFieldChange{{Version: "v1", Op: "remove", Path: "spec.size", OldType: "integer"}},
+	}
+	server := NewServer(&mockStore{eventByID: event})
+
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff", nil))
+	want := "# version v2 added\n# v1: field spec.size removed\n--- a/CustomResourceDefinition/widgets.example.com\n"
+	if !strings.HasPrefix(rec.Body.String(), want) {
+		t.Errorf("expected the diff to start with %q, got %q", want, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=json", nil))
+	var response DiffResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.CRDChange == nil || response.CRDChange.AddedVersions[0] != "v2" {
+		t.Errorf("expected the CRD change in the response, got %+v", response.CRDChange)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=html", nil))
+	if !strings.HasPrefix(rec.Body.String(), `<ul class="kc-diff-summary">`) {
+		t.Errorf("expected the HTML diff to start with the summary, got %q", rec.Body.String())
+	}
+}
+
+func TestHandleChangeDiff_Errors(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: diffTestEvent()})
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=pdf", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+
+	encrypted := diffTestEvent()
+	encrypted.Encrypted = true
+	server = NewServer(&mockStore{eventByID: encrypted})
+	server.SetDecryptRoles([]string{"admin"})
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, requestAs("/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff", "viewer"))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an encrypted diff, got %d", rec.Code)
+	}
+}
+
+func TestHandleChangeObjects(t *testing.T) {
+	event := diffTestEvent()
+	event.ObjectSnapshot = map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}}
+	server := NewServer(&mockStore{eventByID: event})
+
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/objects", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response ObjectsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	oldReplicas := response.Old["spec"].(map[string]interface{})["replicas"]
+	newReplicas := response.New["spec"].(map[string]interface{})["replicas"]
+	if oldReplicas != float64(2) || newReplicas != float64(3) {
+		t.Errorf("expected 2 replicas before and 3 after, got %v and %v", oldReplicas, newReplicas)
+	}
+	if event.ObjectSnapshot["spec"].(map[string]interface{})["replicas"] != float64(2) {
+		t.Error("rebuilding the new object should not modify the stored snapshot")
+	}
+
+	event.Operation, event.Diff = "DELETE", nil
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/objects", nil))
+	response = ObjectsResponse{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	if response.Old == nil || response.New != nil {
+		t.Errorf("expected only the old object of a deletion, got %+v", response)
+	}
+}
+
+func TestHandleChangeObjects_NoSnapshot(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: diffTestEvent()})
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/objects", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a snapshot, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/unknown", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown subresource, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/search"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// ExportRequest is the body of a request creating an export job. Query is added to
+// Filters, like the query= parameter of the changes endpoint. Timezone is the time zone
+// of the exported timestamps; it defaults to that of tz= or the X-Timezone header, else UTC.
+type ExportRequest struct {
+	Filters  store.QueryFilters `json:"filters"`
+	Query    string             `json:"query,omitempty"`
+	Timezone string             `json:"timezone,omitempty"`
+}
+
+// ExportJobResponse reports an export job, with a link to download the export once it
+// has succeeded.
+type ExportJobResponse struct {
+	*store.ExportJob
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
+
+// SetExportJobs enables export jobs, which write large result sets to object storage in
+// the background; downloads are presigned by uploader. Without it the export endpoints
+// return 501 Not Implemented.
+func (s *Server) SetExportJobs(jobs store.ExportJobStore, uploader *export.S3Uploader, cfg *export.JobConfig) {
+	s.exportJobs = jobs
+	s.exportUploader = uploader
+	s.exportConfig = cfg
+}
+
+// HandleExports handles POST /api/exports, which creates an export job of the events
+// matching the request's filters and returns it with status 202 Accepted.
+func (s *Server) HandleExports(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.exportJobs == nil {
+		s.sendError(w, http.StatusNotImplemented, "Export jobs are not enabled")
+		return
+	}
+
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	filters := req.Filters
+	if req.Query != "" {
+		if err := search.Apply(req.Query, &filters, time.Now()); err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query: %v", err))
+			return
+		}
+	}
+	if filters.CountOnly || filters.GroupBy != "" || filters.CountLimit != 0 {
+		s.sendError(w, http.StatusBadRequest, "Exports can't be count-only; use the changes endpoint to count events")
+		return
+	}
+	if filters.StartTime != nil && filters.EndTime != nil && filters.EndTime.Before(*filters.StartTime) {
+		s.sendError(w, http.StatusBadRequest, "end_time must not be before start_time")
+		return
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		location, err := requestLocation(r)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if location != nil {
+			timezone = location.String()
+		}
+	} else if _, err := time.LoadLocation(timezone); err != nil {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid timezone %q", timezone))
+		return
+	}
+
+	id, err := newExportJobID()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create export job: %v", err))
+		return
+	}
+	job := &store.ExportJob{ID: id, Filters: filters, Redact: !s.canDecrypt(r), Timezone: timezone}
+	if user, ok := auth.GetUser(r); ok {
+		job.CreatedBy = user.Username
+	}
+	// The job runs with the caller's tenancy scope, not the runner's
+	if scope, ok := store.NamespaceScope(r.Context()); ok {
+		job.Scope = scope
+	}
+	if err := s.exportJobs.CreateExportJob(r.Context(), job); err != nil {
+		klog.Errorf("Failed to create export job: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create export job: %v", err))
+		return
+	}
+
+	klog.Infof("Export job %s created by %q", job.ID, job.CreatedBy)
+	w.Header().Set("Location", "/kubechronicle/api/exports/"+job.ID)
+	s.sendJSON(w, http.StatusAccepted, ExportJobResponse{ExportJob: job})
+}
+
+// HandleExport handles GET /api/exports/{id}, which reports the progress of an export job
+// and, once it has succeeded, a link to download the export. With authentication enabled,
+// only the job's creator can see it.
+func (s *Server) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.exportJobs == nil {
+		s.sendError(w, http.StatusNotImplemented, "Export jobs are not enabled")
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/exports/")
+	if id == "" || strings.Contains(id, "/") {
+		s.sendError(w, http.StatusBadRequest, "Missing or invalid export ID")
+		return
+	}
+
+	job, err := s.exportJobs.GetExportJob(r.Context(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		s.sendError(w, http.StatusNotFound, "Export job not found")
+		return
+	}
+	if err != nil {
+		klog.Errorf("Failed to get export job %s: %v", id, err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get export job: %v", err))
+		return
+	}
+	if user, ok := auth.GetUser(r); ok && user.Username != job.CreatedBy {
+		s.sendError(w, http.StatusNotFound, "Export job not found")
+		return
+	}
+
+	response := ExportJobResponse{ExportJob: job}
+	if job.Status == store.ExportJobSucceeded && s.exportUploader != nil {
+		expiry := s.exportConfig.LinkExpiry()
+		expiresAt := time.Now().Add(expiry).UTC()
+		response.DownloadURL = s.exportUploader.PresignGet(job.ObjectKey, expiry)
+		response.DownloadExpiresAt = &expiresAt
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// newExportJobID returns a random export job ID, which is hard to guess since the job's
+// download link is given to anyone who knows it when authentication is disabled.
+func newExportJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
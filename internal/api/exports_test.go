@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeExportJobStore is a store.ExportJobStore keeping jobs in memory.
+type fakeExportJobStore struct {
+	jobs map[string]*store.ExportJob
+}
+
+func (f *fakeExportJobStore) CreateExportJob(ctx context.Context, job *store.ExportJob) error {
+	job.Status = store.ExportJobPending
+	f.jobs[job.ID] = job
+	return nil
+}
+
+func (f *fakeExportJobStore) GetExportJob(ctx context.Context, id string) (*store.ExportJob, error) {
+	job, ok := f.jobs[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return job, nil
+}
+
+func (f *fakeExportJobStore) ClaimExportJob(ctx context.Context) (*store.ExportJob, error) {
+	return nil, nil
+}
+
+func (f *fakeExportJobStore) UpdateExportJobProgress(ctx context.Context, id string, exported, total int64) error {
+	return nil
+}
+
+func (f *fakeExportJobStore) FinishExportJob(ctx context.Context, job *store.ExportJob, jobErr error) error {
+	return nil
+}
+
+func (f *fakeExportJobStore) StreamEvents(ctx context.Context, filters store.QueryFilters, fn func(*model.ChangeEvent) error) error {
+	return nil
+}
+
+func exportsTestServer(t *testing.T) (*Server, *fakeExportJobStore) {
+	t.Helper()
+	uploader, err := export.NewS3Uploader(&export.S3Config{Bucket: "exports", Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewS3Uploader() error = %v", err)
+	}
+	jobs := &fakeExportJobStore{jobs: map[string]*store.ExportJob{}}
+	server := NewServer(&mockStore{})
+	server.SetExportJobs(jobs, uploader, &export.JobConfig{LinkExpiryMinutes: 15})
+	return server, jobs
+}
+
+func TestHandleExports_Create(t *testing.T) {
+	server, jobs := exportsTestServer(t)
+	server.SetDecryptRoles([]string{"admin"})
+
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(`{"filters": {"namespace": "payments", "operations": ["DELETE"]}}`))
+	ctx := context.WithValue(req.Context(), "user", &auth.User{Username: "alice", Roles: []string{"viewer"}})
+	req = req.WithContext(store.WithNamespaceScope(ctx, []string{"payments"}))
+	w := httptest.NewRecorder()
+	server.HandleExports(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	response := decodeResponse[ExportJobResponse](t, w)
+	job := jobs.jobs[response.ID]
+	if job == nil || len(response.ID) != 32 || response.Status != store.ExportJobPending {
+		t.Fatalf("Expected a pending job, got %+v", response.ExportJob)
+	}
+	if w.Header().Get("Location") != "/kubechronicle/api/exports/"+job.ID {
+		t.Errorf("Expected the job's location, got %q", w.Header().Get("Location"))
+	}
+	if job.CreatedBy != "alice" || !job.Redact || len(job.Scope) != 1 || job.Scope[0] != "payments" {
+		t.Errorf("Expected the job to keep the creator, redaction and scope, got %+v", job)
+	}
+	if job.Filters.Namespace != "payments" || len(job.Filters.Operations) != 1 {
+		t.Errorf("Expected the filters, got %+v", job.Filters)
+	}
+}
+
+func TestHandleExport_Progress(t *testing.T) {
+	server, jobs := exportsTestServer(t)
+	jobs.jobs["running"] = &store.ExportJob{ID: "running", CreatedBy: "alice", Status: store.ExportJobRunning, Exported: 500, Total: 2000}
+	jobs.jobs["done"] = &store.ExportJob{ID: "done", CreatedBy: "alice", Status: store.ExportJobSucceeded, Exported: 2000, Total: 2000, ObjectKey: "exports/done.ndjson"}
+
+	w := httptest.NewRecorder()
+	server.HandleExport(w, requestAs("/kubechronicle/api/exports/running"))
+	response := decodeResponse[ExportJobResponse](t, w)
+	if w.Code != http.StatusOK || response.Exported != 500 || response.Total != 2000 || response.DownloadURL != "" {
+		t.Errorf("Expected progress without a link, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleExport(w, requestAs("/kubechronicle/api/exports/done"))
+	response = decodeResponse[ExportJobResponse](t, w)
+	if !strings.HasPrefix(response.DownloadURL, "https://exports.s3.eu-west-1.amazonaws.com/exports/done.ndjson?") ||
+		!strings.Contains(response.DownloadURL, "X-Amz-Expires=900") || response.DownloadExpiresAt == nil {
+		t.Errorf("Expected a 15 minute download link, got %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "object_key") {
+		t.Errorf("Expected the object key to stay internal, got %s", w.Body.String())
+	}
+
+	// Other users' jobs are not found
+	jobs.jobs["done"].CreatedBy = "bob"
+	w = httptest.NewRecorder()
+	server.HandleExport(w, requestAs("/kubechronicle/api/exports/done"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for another user's job, got %d", w.Code)
+	}
+}
+
+func TestHandleExports_Errors(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer(&mockStore{}).HandleExports(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(`{}`)))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without export jobs, got %d", w.Code)
+	}
+
+	server, _ := exportsTestServer(t)
+	for _, body := range []string{
+		`not json`,
+		`{"filters": {"count_only": true}}`,
+		`{"filters": {"start_time": "2024-02-01T00:00:00Z", "end_time": "2024-01-01T00:00:00Z"}}`,
+	} {
+		w := httptest.NewRecorder()
+		server.HandleExports(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleExport(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/exports/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.HandleExports(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/exports", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// defaultHeartbeatPeriod is the period heartbeats are reported for when no since= is given.
+const defaultHeartbeatPeriod = 24 * time.Hour
+
+// HeartbeatsResponse measures the delivery of the heartbeat events of each producer
+// instance since a time.
+type HeartbeatsResponse struct {
+	Since     time.Time               `json:"since"`
+	Instances []*store.HeartbeatStats `json:"instances"`
+	Received  int64                   `json:"received"`
+	Lost      int64                   `json:"lost"`
+	LossRatio float64                 `json:"loss_ratio"` // Lost out of the heartbeats emitted
+}
+
+// SetHeartbeatStore enables the heartbeats endpoint.
+func (s *Server) SetHeartbeatStore(heartbeats store.HeartbeatStore) {
+	s.heartbeats = heartbeats
+}
+
+// HandleHeartbeats handles GET /api/heartbeats?since={RFC3339}, which reports the heartbeat
+// events stored since the given time (default: the last 24 hours) per producer instance,
+// with the heartbeats lost and their delivery latency.
+func (s *Server) HandleHeartbeats(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.heartbeats == nil {
+		s.sendError(w, http.StatusNotImplemented, "Heartbeats are not supported by the store")
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-defaultHeartbeatPeriod)
+	parsed, err := parseSince(r.URL.Query(), now)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsed != nil {
+		since = *parsed
+	}
+
+	instances, err := s.heartbeats.GetHeartbeatStats(r.Context(), since)
+	if err != nil {
+		klog.Errorf("Failed to get heartbeats: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get heartbeats: %v", err))
+		return
+	}
+
+	response := HeartbeatsResponse{Since: since, Instances: instances}
+	for _, instance := range instances {
+		response.Received += instance.Received
+		response.Lost += instance.Lost
+	}
+	if emitted := response.Received + response.Lost; emitted > 0 {
+		response.LossRatio = float64(response.Lost) / float64(emitted)
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
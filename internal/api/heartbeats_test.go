@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeHeartbeatStore is a store.HeartbeatStore of fixed stats.
+type fakeHeartbeatStore struct {
+	stats []*store.HeartbeatStats
+	since time.Time
+}
+
+func (f *fakeHeartbeatStore) GetHeartbeatStats(ctx context.Context, since time.Time) ([]*store.HeartbeatStats, error) {
+	f.since = since
+	return f.stats, nil
+}
+
+func TestHandleHeartbeats(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer(&mockStore{}).HandleHeartbeats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/heartbeats", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a heartbeat store, got %d", w.Code)
+	}
+
+	heartbeats := &fakeHeartbeatStore{stats: []*store.HeartbeatStats{
+		{Producer: "webhook", Cluster: "prod", Instance: "kubechronicle-0/1", Received: 57, FirstSequence: 1, LastSequence: 60, Lost: 3},
+		{Producer: "audit-processor", Cluster: "prod", Instance: "audit-0/1", Received: 40, FirstSequence: 1, LastSequence: 40},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetHeartbeatStore(heartbeats)
+
+	w = httptest.NewRecorder()
+	server.HandleHeartbeats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/heartbeats?since=2026-03-01T00:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeResponse[HeartbeatsResponse](t, w)
+	if len(response.Instances) != 2 || response.Received != 97 || response.Lost != 3 || response.LossRatio != 0.03 {
+		t.Errorf("Unexpected totals: %s", w.Body.String())
+	}
+	if !heartbeats.since.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the stats since the requested time, got %v", heartbeats.since)
+	}
+
+	// The last day by default
+	w = httptest.NewRecorder()
+	server.HandleHeartbeats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/heartbeats", nil))
+	if since := time.Since(heartbeats.since); since < defaultHeartbeatPeriod || since > defaultHeartbeatPeriod+time.Minute {
+		t.Errorf("Expected the stats of the last day, got since %v", heartbeats.since)
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleHeartbeats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/heartbeats?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid since, got %d", w.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// paginationParams are the query parameters of paginated list endpoints.
+var paginationParams = []string{"limit", "offset", "sort", "sort_by", "as_of", "strict"}
+
+// listChangesParams are the query parameters of the changes endpoint.
+var listChangesParams = append([]string{
+	"resource_kind", "resource_kind!", "namespace", "namespace!", "name", "name!", "user", "user!",
+	"operation", "operation!", "start_time", "end_time", "since", "until", "last", "allowed",
+	"min_risk_score", "node", "query", "fields", "count_only", "group_by", "count_limit",
+}, paginationParams...)
+
+// SetPageSizes sets the default and max page sizes of the list endpoints, which should
+// match the store's.
+func (s *Server) SetPageSizes(pageSizes store.PageSizes) {
+	s.pageSizes = pageSizes
+}
+
+// paramParser parses the query parameters of a request. For compatibility, invalid values
+// are ignored unless the request sets strict=true, in which case they are reported, as are
+// unknown parameters, so that a typo doesn't silently return unfiltered results.
+type paramParser struct {
+	query  url.Values
+	strict bool
+	err    error
+}
+
+// newParamParser returns a parser of the query parameters of r, which accepts the given
+// parameters. It fails if strict is not a boolean.
+func newParamParser(r *http.Request, known []string) (*paramParser, error) {
+	p := &paramParser{query: r.URL.Query()}
+	if value := p.query.Get("strict"); value != "" {
+		strict, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid strict %q: must be true or false", value)
+		}
+		p.strict = strict
+	}
+	if p.strict {
+		var unknown []string
+		for key := range p.query {
+			if !containsString(known, key) {
+				unknown = append(unknown, key)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			p.err = fmt.Errorf("unknown parameter %s; parameters are %s", strings.Join(unknown, ", "), strings.Join(known, ", "))
+		}
+	}
+	return p, nil
+}
+
+// invalid reports an invalid value of key in strict mode. Only the first error is kept.
+func (p *paramParser) invalid(key, reason string) {
+	if p.strict {
+		p.reject(key, reason)
+	}
+}
+
+// reject reports an invalid value of key, even outside strict mode, for parameters that
+// were always validated. Only the first error is kept.
+func (p *paramParser) reject(key, reason string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("invalid %s %q: %s", key, p.query.Get(key), reason)
+	}
+}
+
+// Err returns the first invalid or unknown parameter found in strict mode.
+func (p *paramParser) Err() error {
+	return p.err
+}
+
+// pagination parses the limit, offset, sort, sort_by and as_of parameters, defaulting to the
+// newest page of pageSizes' default size. Limits above the max page size are reduced to it.
+func (p *paramParser) pagination(pageSizes store.PageSizes) (store.PaginationParams, store.SortOrder) {
+	pagination := store.PaginationParams{
+		Limit:  pageSizes.DefaultLimit(),
+		Offset: 0,
+	}
+	sortOrder := store.SortOrderDesc
+
+	if limitStr := p.query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			pagination.Limit = pageSizes.Limit(limit)
+			if limit > pageSizes.MaxLimit() {
+				p.invalid("limit", fmt.Sprintf("must be at most %d", pageSizes.MaxLimit()))
+			}
+		} else {
+			p.invalid("limit", "must be a positive integer")
+		}
+	}
+
+	if offsetStr := p.query.Get("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			pagination.Offset = offset
+		} else {
+			p.invalid("offset", "must be a non-negative integer")
+		}
+	}
+
+	switch p.query.Get("sort") {
+	case "asc":
+		sortOrder = store.SortOrderAsc
+	case "", "desc":
+	default:
+		p.invalid("sort", "must be asc or desc")
+	}
+
+	if sortBy := p.query.Get("sort_by"); sortBy != "" {
+		if store.IsSortByField(sortBy) {
+			pagination.SortBy = sortBy
+		} else {
+			p.reject("sort_by", "must be one of "+strings.Join(store.SortByFields(), ", "))
+		}
+	}
+
+	if asOfStr := p.query.Get("as_of"); asOfStr != "" {
+		if asOf, err := time.Parse(time.RFC3339Nano, asOfStr); err == nil {
+			pagination.AsOf = &asOf
+		} else {
+			p.reject("as_of", "must be the as_of time of the first page")
+		}
+	}
+	return pagination, sortOrder
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// recordingMatchWindow is how far apart a session's start and its exec event may be.
+// Audit events are timestamped when the API server receives the request, slightly
+// before the recording agent sees the session start.
+const recordingMatchWindow = 2 * time.Minute
+
+// RegisterRecordingRequest registers a session recording for an exec event, identified
+// either by event_id or by the pod and the time the session started.
+type RegisterRecordingRequest struct {
+	URL   string `json:"url"`
+	Agent string `json:"agent,omitempty"`
+
+	EventID   string     `json:"event_id,omitempty"`
+	Namespace string     `json:"namespace,omitempty"`
+	Pod       string     `json:"pod,omitempty"`
+	Container string     `json:"container,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // Defaults to now
+}
+
+// SetRecordingStore enables the session recording endpoints. Only users with one of
+// roles may register recordings; with no roles, or when authentication is disabled,
+// everyone may.
+func (s *Server) SetRecordingStore(recordings store.RecordingStore, roles []string) {
+	s.recordings = recordings
+	s.recorderRoles = roles
+}
+
+// HandleRecordings handles POST /api/recordings, which links a session recording to its
+// exec event, and GET /api/recordings?event_id={id}, which lists the recordings of an event.
+func (s *Server) HandleRecordings(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if s.recordings == nil {
+		s.sendError(w, http.StatusNotImplemented, "Session recordings are not supported by the store")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		eventID := r.URL.Query().Get("event_id")
+		if eventID == "" {
+			s.sendError(w, http.StatusBadRequest, "event_id is required")
+			return
+		}
+		recordings, err := s.recordings.ListRecordings(r.Context(), eventID)
+		if err != nil {
+			klog.Errorf("Failed to list recordings of event %s: %v", eventID, err)
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list recordings: %v", err))
+			return
+		}
+		s.sendJSON(w, http.StatusOK, recordings)
+	case http.MethodPost:
+		s.handleRegisterRecording(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRegisterRecording correlates a recording with its exec event and stores it.
+func (s *Server) handleRegisterRecording(w http.ResponseWriter, r *http.Request) {
+	user, authenticated := auth.GetUser(r)
+	if authenticated && !hasAnyRole(user, s.recorderRoles) {
+		s.sendError(w, http.StatusForbidden, fmt.Sprintf("Registering recordings requires one of the roles %v", s.recorderRoles))
+		return
+	}
+
+	var req RegisterRecordingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+	if !strings.HasPrefix(req.URL, "https://") && !strings.HasPrefix(req.URL, "http://") {
+		s.sendError(w, http.StatusBadRequest, "url must be an http(s) URL")
+		return
+	}
+	target := store.ExecTarget{
+		EventID:   req.EventID,
+		Namespace: req.Namespace,
+		Pod:       req.Pod,
+		Container: req.Container,
+		StartedAt: time.Now(),
+		Window:    recordingMatchWindow,
+	}
+	if req.StartedAt != nil {
+		target.StartedAt = *req.StartedAt
+	}
+	if target.EventID == "" && (target.Namespace == "" || target.Pod == "") {
+		s.sendError(w, http.StatusBadRequest, "Either event_id or namespace and pod are required")
+		return
+	}
+
+	eventID, err := s.recordings.FindExecEvent(r.Context(), target)
+	if errors.Is(err, store.ErrNotFound) {
+		s.sendError(w, http.StatusNotFound, "No matching exec event found")
+		return
+	}
+	if err != nil {
+		klog.Errorf("Failed to find exec event for recording: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find exec event: %v", err))
+		return
+	}
+
+	recording := &store.Recording{EventID: eventID, URL: req.URL, Agent: req.Agent}
+	if authenticated {
+		recording.RegisteredBy = user.Username
+	}
+	if err := s.recordings.AddRecording(r.Context(), recording); err != nil {
+		klog.Errorf("Failed to add recording for event %s: %v", eventID, err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add recording: %v", err))
+		return
+	}
+
+	klog.Infof("Recording %s registered for exec event %s by %q", recording.URL, eventID, recording.RegisteredBy)
+	s.sendJSON(w, http.StatusCreated, recording)
+}
+
+// hasAnyRole reports whether user has one of roles. No roles allows every user.
+func hasAnyRole(user *auth.User, roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, role := range user.Roles {
+		for _, allowed := range roles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
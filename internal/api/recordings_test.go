@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeRecordingStore is an in-memory store.RecordingStore with a single exec event.
+type fakeRecordingStore struct {
+	eventID    string
+	namespace  string
+	pod        string
+	timestamp  time.Time
+	lastTarget store.ExecTarget
+	recordings []*store.Recording
+}
+
+func (f *fakeRecordingStore) FindExecEvent(ctx context.Context, target store.ExecTarget) (string, error) {
+	f.lastTarget = target
+	if target.EventID != "" {
+		if target.EventID == f.eventID {
+			return f.eventID, nil
+		}
+		return "", store.ErrNotFound
+	}
+	delta := target.StartedAt.Sub(f.timestamp)
+	if target.Namespace == f.namespace && target.Pod == f.pod && delta <= target.Window && delta >= -target.Window {
+		return f.eventID, nil
+	}
+	return "", store.ErrNotFound
+}
+
+func (f *fakeRecordingStore) AddRecording(ctx context.Context, recording *store.Recording) error {
+	recording.ID = int64(len(f.recordings) + 1)
+	recording.CreatedAt = time.Now()
+	f.recordings = append(f.recordings, recording)
+	return nil
+}
+
+func (f *fakeRecordingStore) ListRecordings(ctx context.Context, eventID string) ([]*store.Recording, error) {
+	recordings := []*store.Recording{}
+	for _, r := range f.recordings {
+		if r.EventID == eventID {
+			recordings = append(recordings, r)
+		}
+	}
+	return recordings, nil
+}
+
+func newRecordingServer() (*Server, *fakeRecordingStore) {
+	fake := &fakeRecordingStore{
+		eventID:   "EXEC-pods-api-0-alice-1",
+		namespace: "payments",
+		pod:       "api-0",
+		timestamp: time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC),
+	}
+	server := NewServer(&mockStore{})
+	server.SetRecordingStore(fake, []string{"admin", "recorder"})
+	return server, fake
+}
+
+func postRecording(server *Server, req RegisterRecordingRequest, user *auth.User) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/recordings", bytes.NewReader(body))
+	if user != nil {
+		httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), "user", user))
+	}
+	w := httptest.NewRecorder()
+	server.HandleRecordings(w, httpReq)
+	return w
+}
+
+func TestHandleRecordings_RegisterByPod(t *testing.T) {
+	server, fake := newRecordingServer()
+	startedAt := fake.timestamp.Add(3 * time.Second)
+
+	w := postRecording(server, RegisterRecordingRequest{
+		URL:       "https://recordings.example.com/sessions/42",
+		Agent:     "tlog",
+		Namespace: "payments",
+		Pod:       "api-0",
+		Container: "app",
+		StartedAt: &startedAt,
+	}, &auth.User{Username: "recorder-agent", Roles: []string{"recorder"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	var recording store.Recording
+	if err := json.NewDecoder(w.Body).Decode(&recording); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if recording.EventID != fake.eventID || recording.RegisteredBy != "recorder-agent" || recording.Agent != "tlog" {
+		t.Errorf("Unexpected recording: %+v", recording)
+	}
+	if fake.lastTarget.Container != "app" || fake.lastTarget.Window != recordingMatchWindow {
+		t.Errorf("Unexpected exec target: %+v", fake.lastTarget)
+	}
+
+	// The recording is listed with its event
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/recordings?event_id="+fake.eventID, nil)
+	w = httptest.NewRecorder()
+	server.HandleRecordings(w, req)
+	var recordings []store.Recording
+	if err := json.NewDecoder(w.Body).Decode(&recordings); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(recordings) != 1 || recordings[0].URL != "https://recordings.example.com/sessions/42" {
+		t.Errorf("Unexpected recordings: %+v", recordings)
+	}
+}
+
+func TestHandleRecordings_RegisterErrors(t *testing.T) {
+	admin := &auth.User{Username: "admin", Roles: []string{"admin"}}
+	tests := []struct {
+		name     string
+		req      RegisterRecordingRequest
+		user     *auth.User
+		wantCode int
+	}{
+		{
+			name:     "by event ID",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", EventID: "EXEC-pods-api-0-alice-1"},
+			user:     admin,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "unknown event ID",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", EventID: "CREATE-Pod-api-0"},
+			user:     admin,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "no exec in window",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", Namespace: "payments", Pod: "api-0"},
+			user:     admin,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "no target",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", Pod: "api-0"},
+			user:     admin,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid URL",
+			req:      RegisterRecordingRequest{URL: "javascript:alert(1)", EventID: "EXEC-pods-api-0-alice-1"},
+			user:     admin,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "missing role",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", EventID: "EXEC-pods-api-0-alice-1"},
+			user:     &auth.User{Username: "viewer", Roles: []string{"viewer"}},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "authentication disabled",
+			req:      RegisterRecordingRequest{URL: "https://recordings.example.com/1", EventID: "EXEC-pods-api-0-alice-1"},
+			wantCode: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newRecordingServer()
+			if w := postRecording(server, tt.req, tt.user); w.Code != tt.wantCode {
+				t.Errorf("POST status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleRecordings_NotSupported(t *testing.T) {
+	server := NewServer(&mockStore{})
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/recordings?event_id=x", nil)
+	w := httptest.NewRecorder()
+	server.HandleRecordings(w, req)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("GET status = %d, want 501", w.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// SetDecryptRoles restricts which roles may see diffs and snapshots that are
+// encrypted at rest. Other users receive such events without those fields.
+// With no roles set, or when authentication is disabled, everyone may see them.
+func (s *Server) SetDecryptRoles(roles []string) {
+	s.decryptRoles = roles
+}
+
+// canDecrypt reports whether the request's user may see encrypted payloads.
+func (s *Server) canDecrypt(r *http.Request) bool {
+	if len(s.decryptRoles) == 0 {
+		return true
+	}
+	user, ok := auth.GetUser(r)
+	if !ok {
+		return true // Authentication disabled
+	}
+	for _, role := range user.Roles {
+		for _, allowed := range s.decryptRoles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactEvents removes encrypted payloads from events the requester may not decrypt.
+func (s *Server) redactEvents(r *http.Request, events []*model.ChangeEvent) []*model.ChangeEvent {
+	if s.canDecrypt(r) {
+		return events
+	}
+	redacted := make([]*model.ChangeEvent, len(events))
+	for i, event := range events {
+		redacted[i] = redactEvent(event)
+	}
+	return redacted
+}
+
+// redactEvent returns a copy of an encrypted event without its diff and snapshot.
+// Events that were not encrypted at rest are returned unchanged.
+func redactEvent(event *model.ChangeEvent) *model.ChangeEvent {
+	if !event.Encrypted {
+		return event
+	}
+	copied := *event
+	copied.Diff = nil
+	copied.ObjectSnapshot = nil
+	return &copied
+}
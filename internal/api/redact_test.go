@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+func encryptedEvent() *model.ChangeEvent {
+	event := sampleEvent()
+	event.Encrypted = true
+	event.Diff = []model.PatchOp{{Op: "replace", Path: "/data/password", Value: "secret"}}
+	event.ObjectSnapshot = map[string]interface{}{"kind": "Secret"}
+	return event
+}
+
+func requestAs(path string, roles ...string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	user := &auth.User{Username: "alice", Roles: roles}
+	return req.WithContext(context.WithValue(req.Context(), "user", user))
+}
+
+func TestHandleGetChange_RedactsEncryptedPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []string
+		wantDiff bool
+	}{
+		{"admin can decrypt", []string{"admin"}, true},
+		{"viewer cannot decrypt", []string{"viewer"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockStore{eventByID: encryptedEvent()}
+			server := NewServer(mock)
+			server.SetDecryptRoles([]string{"admin"})
+
+			rec := httptest.NewRecorder()
+			server.HandleGetChange(rec, requestAs("/kubechronicle/api/changes/"+sampleEvent().ID, tt.roles...))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			event := decodeResponse[model.ChangeEvent](t, rec)
+			if got := len(event.Diff) > 0 && event.ObjectSnapshot != nil; got != tt.wantDiff {
+				t.Errorf("payload present = %v, want %v", got, tt.wantDiff)
+			}
+			if !event.Encrypted {
+				t.Error("expected encrypted flag to be set")
+			}
+			// The stored event must not be modified by redaction
+			if len(mock.eventByID.Diff) == 0 {
+				t.Error("redaction should not modify the original event")
+			}
+		})
+	}
+}
+
+func TestHandleListChanges_RedactsOnlyEncryptedEvents(t *testing.T) {
+	plain := sampleEvent()
+	plain.ID = "plain"
+	plain.Diff = []model.PatchOp{{Op: "add", Path: "/spec/replicas", Value: 2}}
+
+	server := NewServer(&mockStore{queryResult: &store.QueryResult{
+		Events: []*model.ChangeEvent{encryptedEvent(), plain},
+		Total:  2,
+	}})
+	server.SetDecryptRoles([]string{"admin"})
+
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, requestAs("/kubechronicle/api/changes", "viewer"))
+
+	resp := decodeResponse[ListChangesResponse](t, rec)
+	if len(resp.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(resp.Events))
+	}
+	if resp.Events[0].Diff != nil || resp.Events[0].ObjectSnapshot != nil {
+		t.Error("expected encrypted event payload to be redacted")
+	}
+	if len(resp.Events[1].Diff) != 1 {
+		t.Error("expected plaintext event to be returned unchanged")
+	}
+}
+
+func TestCanDecrypt_AuthDisabled(t *testing.T) {
+	server := NewServer(&mockStore{})
+	server.SetDecryptRoles([]string{"admin"})
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes", nil)
+	if !server.canDecrypt(req) {
+		t.Error("requests without an authenticated user should be allowed when auth is disabled")
+	}
+}
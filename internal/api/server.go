@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,28 +12,88 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/export"
 	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/search"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/tenancy"
 )
 
 // Server handles HTTP API requests for change events.
 type Server struct {
-	store store.Store
+	store        store.Store
+	broadcaster  *Broadcaster
+	decryptRoles []string // Roles allowed to see encrypted payloads; empty allows all
+
+	recordings    store.RecordingStore // Session recordings of exec events; nil disables the endpoints
+	recorderRoles []string             // Roles allowed to register recordings; empty allows all
+
+	usage   store.UsageStore  // Storage accounting per namespace; nil disables the usage endpoint
+	tenancy *tenancy.Resolver // Tenants usage is also reported for; nil reports namespaces only
+
+	churn       store.ChurnStore    // Change rates per resource; nil disables the churn endpoint
+	churnConfig *config.ChurnConfig // Thresholds resources are flagged above; nil uses the default
+
+	stats store.StatsStore // Hourly and daily event counts; nil disables the stats endpoint
+
+	blockConfig func(ctx context.Context) (*config.BlockConfig, error) // Source of the calendar's freezes; nil lists changes only
+
+	compliance    store.EventStreamer        // Reads the changes of the compliance report; nil disables it
+	changeWindows *config.ChangeWindowConfig // Approved change windows; nil checks freezes only
+
+	dataLoss store.EventStreamer // Reads the deletions of the data loss report; nil disables it
+
+	batch store.BatchStore // Reads batch lookups in one query; nil reads each event separately
+
+	exportJobs     store.ExportJobStore // Export jobs; nil disables the export endpoints
+	exportUploader *export.S3Uploader   // Presigns the download links of exports
+	exportConfig   *export.JobConfig
+
+	sources       store.SourceStore // Activity of the event sources; nil disables the sources endpoint
+	sourcesConfig *sources.Config   // When sources are stale; nil uses the defaults
+
+	heartbeats store.HeartbeatStore // Measures the delivery of heartbeat events; nil disables the heartbeats endpoint
+
+	pageSizes store.PageSizes // Default and max page sizes of list endpoints; zero values use the store's defaults
 }
 
 // NewServer creates a new API server.
 func NewServer(store store.Store) *Server {
 	return &Server{
-		store: store,
+		store:       store,
+		broadcaster: NewBroadcaster(),
 	}
 }
 
-// ListChangesResponse represents the response for listing changes.
+// ListChangesResponse represents the response for listing changes. Limit is the page size
+// applied, which may be smaller than the one requested. Passing AsOf back as as_of reads
+// the following pages without the events recorded since.
 type ListChangesResponse struct {
-	Events []*model.ChangeEvent `json:"events"`
-	Total  int                  `json:"total"`
-	Limit  int                  `json:"limit"`
-	Offset int                  `json:"offset"`
+	Events     []*model.ChangeEvent `json:"events"`
+	Total      int                  `json:"total"`
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+	TotalPages int                  `json:"total_pages"`
+	AsOf       *time.Time           `json:"as_of,omitempty"`
+}
+
+// SparseListChangesResponse represents a list response restricted to the fields
+// requested with the fields= query parameter.
+type SparseListChangesResponse struct {
+	Events     []map[string]interface{} `json:"events"`
+	Total      int                      `json:"total"`
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+	TotalPages int                      `json:"total_pages"`
+	AsOf       *time.Time               `json:"as_of,omitempty"`
+}
+
+// CountChangesResponse is the response to a count_only=true list request.
+type CountChangesResponse struct {
+	Total  int                `json:"total"`
+	Groups []store.GroupCount `json:"groups,omitempty"` // Counts per value of group_by, most first
 }
 
 // ErrorResponse represents an error response.
@@ -51,72 +112,131 @@ func (s *Server) HandleListChanges(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
-	filters := store.QueryFilters{}
-	pagination := store.PaginationParams{
-		Limit:  50, // Default limit
-		Offset: 0,
+	// Parse query parameters; invalid values are ignored unless strict=true
+	params, err := newParamParser(r, listChangesParams)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	sortOrder := store.SortOrderDesc // Default: newest first
+	query := params.query
+	filters := store.QueryFilters{}
 
-	if resourceKind := r.URL.Query().Get("resource_kind"); resourceKind != "" {
-		filters.ResourceKind = resourceKind
+	// Value filters support single values (namespace=default), multiple values
+	// (operation=CREATE,DELETE) and exclusions (namespace!=kube-system)
+	parseValueFilter(query, "resource_kind", &filters.ResourceKind, &filters.ResourceKinds, &filters.ExcludeResourceKinds)
+	parseValueFilter(query, "namespace", &filters.Namespace, &filters.Namespaces, &filters.ExcludeNamespaces)
+	parseValueFilter(query, "name", &filters.Name, &filters.Names, &filters.ExcludeNames)
+	parseValueFilter(query, "user", &filters.Username, &filters.Usernames, &filters.ExcludeUsernames)
+	parseValueFilter(query, "operation", &filters.Operation, &filters.Operations, &filters.ExcludeOperations)
+
+	// Parse time range: start_time and end_time, or since (or last) and until, which all
+	// take RFC 3339 times or durations before now (since=24h, last=7d)
+	now := time.Now()
+	if startTimeStr := query.Get("start_time"); startTimeStr != "" {
+		if startTime, err := search.ParseTime(startTimeStr, now); err == nil {
+			filters.StartTime = &startTime
+		} else {
+			params.invalid("start_time", "must be an RFC 3339 time or a duration such as 24h or 7d")
+		}
 	}
 
-	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
-		filters.Namespace = namespace
+	if endTimeStr := query.Get("end_time"); endTimeStr != "" {
+		if endTime, err := search.ParseTime(endTimeStr, now); err == nil {
+			filters.EndTime = &endTime
+		} else {
+			params.invalid("end_time", "must be an RFC 3339 time or a duration such as 24h or 7d")
+		}
 	}
 
-	if name := r.URL.Query().Get("name"); name != "" {
-		filters.Name = name
+	since, err := parseSince(query, now)
+	if err == nil && since != nil && filters.StartTime != nil {
+		err = fmt.Errorf("start_time can't be combined with since or last")
 	}
-
-	if username := r.URL.Query().Get("user"); username != "" {
-		filters.Username = username
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-
-	if operation := r.URL.Query().Get("operation"); operation != "" {
-		filters.Operation = operation
+	if since != nil {
+		filters.StartTime = since
 	}
-
-	// Parse time range
-	if startTimeStr := r.URL.Query().Get("start_time"); startTimeStr != "" {
-		if startTime, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
-			filters.StartTime = &startTime
-		}
+	until, err := parseTimeParam(query, "until", now)
+	if err == nil && until != nil && filters.EndTime != nil {
+		err = fmt.Errorf("end_time can't be combined with until")
 	}
-
-	if endTimeStr := r.URL.Query().Get("end_time"); endTimeStr != "" {
-		if endTime, err := time.Parse(time.RFC3339, endTimeStr); err == nil {
-			filters.EndTime = &endTime
-		}
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if until != nil {
+		filters.EndTime = until
 	}
 
 	// Parse allowed filter
-	if allowedStr := r.URL.Query().Get("allowed"); allowedStr != "" {
+	if allowedStr := query.Get("allowed"); allowedStr != "" {
 		if allowed, err := strconv.ParseBool(allowedStr); err == nil {
 			filters.Allowed = &allowed
+		} else {
+			params.invalid("allowed", "must be true or false")
 		}
 	}
 
-	// Parse pagination
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			pagination.Limit = limit
+	// Parse exec risk filter
+	if minRiskStr := query.Get("min_risk_score"); minRiskStr != "" {
+		if minRisk, err := strconv.Atoi(minRiskStr); err == nil && minRisk > 0 {
+			filters.MinRiskScore = minRisk
+		} else {
+			params.invalid("min_risk_score", "must be a positive integer")
 		}
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			pagination.Offset = offset
+	// Parse node maintenance filter
+	if node := query.Get("node"); node != "" {
+		filters.Node = node
+	}
+
+	// Parse the query language (e.g. query=kind:Secret AND namespace:prod* AND NOT user:system:*)
+	if q := query.Get("query"); q != "" {
+		if err := search.Apply(q, &filters, now); err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query: %v", err))
+			return
 		}
 	}
 
-	// Parse sort order
-	if sort := r.URL.Query().Get("sort"); sort != "" {
-		if sort == "asc" {
-			sortOrder = store.SortOrderAsc
+	// Parse field selection (e.g. fields=id,timestamp,operation,name)
+	if fieldsStr := query.Get("fields"); fieldsStr != "" {
+		filters.Fields = parseList(fieldsStr)
+	}
+
+	// Parse count-only mode (e.g. count_only=true&group_by=namespace, or count_limit=1 to check existence)
+	if countOnlyStr := query.Get("count_only"); countOnlyStr != "" {
+		countOnly, err := strconv.ParseBool(countOnlyStr)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid count_only: %q", countOnlyStr))
+			return
+		}
+		filters.CountOnly = countOnly
+	}
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		if !filters.CountOnly || !store.IsGroupByField(groupBy) {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("group_by requires count_only=true and one of %s", strings.Join(store.GroupByFields(), ", ")))
+			return
+		}
+		filters.GroupBy = groupBy
+	}
+	if countLimitStr := query.Get("count_limit"); countLimitStr != "" {
+		countLimit, err := strconv.Atoi(countLimitStr)
+		if err != nil || countLimit <= 0 || !filters.CountOnly {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid count_limit %q, it must be positive and requires count_only=true", countLimitStr))
+			return
 		}
+		filters.CountLimit = countLimit
+	}
+
+	// Parse pagination, then report invalid parameters in strict mode
+	pagination, sortOrder := params.pagination(s.pageSizes)
+	if err := params.Err(); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// Query events
@@ -128,18 +248,46 @@ func (s *Server) HandleListChanges(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if filters.CountOnly {
+		s.sendJSON(w, http.StatusOK, CountChangesResponse{Total: result.Total, Groups: result.Groups})
+		return
+	}
+
+	// Send sparse response if specific fields were requested
+	if len(filters.Fields) > 0 {
+		events, err := projectEvents(s.redactEvents(r, result.Events), filters.Fields)
+		if err != nil {
+			klog.Errorf("Failed to project events: %v", err)
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to project events: %v", err))
+			return
+		}
+		s.sendJSON(w, http.StatusOK, SparseListChangesResponse{
+			Events:     events,
+			Total:      result.Total,
+			Limit:      pagination.Limit,
+			Offset:     pagination.Offset,
+			TotalPages: totalPages(result.Total, pagination.Limit),
+			AsOf:       resultAsOf(result),
+		})
+		return
+	}
+
 	// Send response
 	response := ListChangesResponse{
-		Events: result.Events,
-		Total:  result.Total,
-		Limit:  pagination.Limit,
-		Offset: pagination.Offset,
+		Events:     s.redactEvents(r, result.Events),
+		Total:      result.Total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+		TotalPages: totalPages(result.Total, pagination.Limit),
+		AsOf:       resultAsOf(result),
 	}
 
 	s.sendJSON(w, http.StatusOK, response)
 }
 
-// HandleGetChange handles GET /api/changes/{id} requests.
+// HandleGetChange handles GET /api/changes/{id}, GET /api/changes/{id}/diff and
+// GET /api/changes/{id}/objects requests. Events and objects are sent as YAML when asked
+// for with format=yaml or an Accept header.
 func (s *Server) HandleGetChange(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		s.handleOptions(w, r)
@@ -152,6 +300,19 @@ func (s *Server) HandleGetChange(w http.ResponseWriter, r *http.Request) {
 
 	// Extract ID from path: /kubechronicle/api/changes/{id}
 	path := strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/changes/")
+	if idPath, subresource, ok := strings.Cut(path, "/"); ok && idPath != "" && (subresource == "diff" || subresource == "objects") {
+		id, err := url.PathUnescape(idPath)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid change ID: %v", err))
+			return
+		}
+		if subresource == "diff" {
+			s.handleChangeDiff(w, r, id)
+		} else {
+			s.handleChangeObjects(w, r, id)
+		}
+		return
+	}
 	if path == "" || strings.Contains(path, "/") {
 		s.sendError(w, http.StatusBadRequest, "Missing or invalid change ID")
 		return
@@ -171,8 +332,11 @@ func (s *Server) HandleGetChange(w http.ResponseWriter, r *http.Request) {
 		s.sendError(w, http.StatusNotFound, fmt.Sprintf("Change event not found: %v", err))
 		return
 	}
+	if !s.canDecrypt(r) {
+		event = redactEvent(event)
+	}
 
-	s.sendJSON(w, http.StatusOK, event)
+	s.sendDetail(w, r, http.StatusOK, event)
 }
 
 // HandleResourceHistory handles GET /api/resources/{kind}/{namespace}/{name}/history requests.
@@ -209,27 +373,16 @@ func (s *Server) HandleResourceHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse pagination
-	pagination := store.PaginationParams{
-		Limit:  50,
-		Offset: 0,
-	}
-	sortOrder := store.SortOrderDesc
-
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			pagination.Limit = limit
-		}
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			pagination.Offset = offset
-		}
+	// Parse pagination; invalid values are ignored unless strict=true
+	params, err := newParamParser(r, paginationParams)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-
-	if sort := r.URL.Query().Get("sort"); sort == "asc" {
-		sortOrder = store.SortOrderAsc
+	pagination, sortOrder := params.pagination(s.pageSizes)
+	if err := params.Err(); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// Get resource history
@@ -242,10 +395,12 @@ func (s *Server) HandleResourceHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := ListChangesResponse{
-		Events: result.Events,
-		Total:  result.Total,
-		Limit:  pagination.Limit,
-		Offset: pagination.Offset,
+		Events:     s.redactEvents(r, result.Events),
+		Total:      result.Total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+		TotalPages: totalPages(result.Total, pagination.Limit),
+		AsOf:       resultAsOf(result),
 	}
 
 	s.sendJSON(w, http.StatusOK, response)
@@ -281,65 +436,198 @@ func (s *Server) HandleUserActivity(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse pagination
-	pagination := store.PaginationParams{
-		Limit:  50,
-		Offset: 0,
+	// Parse pagination; invalid values are ignored unless strict=true
+	params, err := newParamParser(r, paginationParams)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pagination, sortOrder := params.pagination(s.pageSizes)
+	if err := params.Err(); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	sortOrder := store.SortOrderDesc
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			pagination.Limit = limit
-		}
+	// Get user activity
+	ctx := r.Context()
+	result, err := s.store.GetUserActivity(ctx, username, pagination, sortOrder)
+	if err != nil {
+		klog.Errorf("Failed to get user activity: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user activity: %v", err))
+		return
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			pagination.Offset = offset
-		}
+	response := ListChangesResponse{
+		Events:     s.redactEvents(r, result.Events),
+		Total:      result.Total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+		TotalPages: totalPages(result.Total, pagination.Limit),
+		AsOf:       resultAsOf(result),
 	}
 
-	if sort := r.URL.Query().Get("sort"); sort == "asc" {
-		sortOrder = store.SortOrderAsc
+	s.sendJSON(w, http.StatusOK, response)
+}
+
+// HandleGroupActivity handles GET /api/groups/{group}/activity requests.
+func (s *Server) HandleGroupActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Get user activity
+	// Extract group from path: /kubechronicle/api/groups/{group}/activity
+	path := strings.TrimPrefix(r.URL.Path, "/kubechronicle/api/groups/")
+	if !strings.HasSuffix(path, "/activity") {
+		s.sendError(w, http.StatusBadRequest, "Invalid group path. Expected: /kubechronicle/api/groups/{group}/activity")
+		return
+	}
+
+	path = strings.TrimSuffix(path, "/activity")
+	if path == "" {
+		s.sendError(w, http.StatusBadRequest, "Missing group")
+		return
+	}
+
+	group, err := url.PathUnescape(path)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, fmt.Sprintf("Invalid group encoding: %v", err))
+		return
+	}
+
+	// Parse pagination; invalid values are ignored unless strict=true
+	params, err := newParamParser(r, paginationParams)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pagination, sortOrder := params.pagination(s.pageSizes)
+	if err := params.Err(); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Get group activity
 	ctx := r.Context()
-	result, err := s.store.GetUserActivity(ctx, username, pagination, sortOrder)
+	result, err := s.store.GetGroupActivity(ctx, group, pagination, sortOrder)
 	if err != nil {
-		klog.Errorf("Failed to get user activity: %v", err)
-		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get user activity: %v", err))
+		klog.Errorf("Failed to get group activity: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get group activity: %v", err))
 		return
 	}
 
 	response := ListChangesResponse{
-		Events: result.Events,
-		Total:  result.Total,
-		Limit:  pagination.Limit,
-		Offset: pagination.Offset,
+		Events:     s.redactEvents(r, result.Events),
+		Total:      result.Total,
+		Limit:      pagination.Limit,
+		Offset:     pagination.Offset,
+		TotalPages: totalPages(result.Total, pagination.Limit),
+		AsOf:       resultAsOf(result),
 	}
 
 	s.sendJSON(w, http.StatusOK, response)
 }
 
+// totalPages returns the number of pages of limit events holding total events.
+func totalPages(total, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	return (total + limit - 1) / limit
+}
+
+// resultAsOf returns the time a page of events was read as of, or nil if the store didn't
+// report it.
+func resultAsOf(result *store.QueryResult) *time.Time {
+	if result.AsOf.IsZero() {
+		return nil
+	}
+	asOf := result.AsOf.UTC()
+	return &asOf
+}
+
+// projectEvents converts events to JSON objects containing only the given fields.
+func projectEvents(events []*model.ChangeEvent, fields []string) ([]map[string]interface{}, error) {
+	projected := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(data, &full); err != nil {
+			return nil, err
+		}
+		sparse := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, ok := full[field]; ok {
+				sparse[field] = value
+			}
+		}
+		projected = append(projected, sparse)
+	}
+	return projected, nil
+}
+
+// parseValueFilter parses a filter query parameter into its single-value,
+// multi-value and exclusion forms. "key=a" sets single, "key=a,b" sets multi
+// and "key!=a,b" (sent as parameter "key!") sets exclude.
+func parseValueFilter(query url.Values, key string, single *string, multi, exclude *[]string) {
+	if value := query.Get(key); value != "" {
+		values := parseList(value)
+		if len(values) == 1 {
+			*single = values[0]
+		} else if len(values) > 1 {
+			*multi = values
+		}
+	}
+
+	if value := query.Get(key + "!"); value != "" {
+		*exclude = parseList(value)
+	}
+}
+
+// parseList parses a comma-separated list of strings.
+func parseList(s string) []string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(s, ",") {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+	return parts
+}
+
 // sendJSON sends a JSON response.
 func (s *Server) sendJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+TimezoneHeader)
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		klog.Errorf("Failed to encode JSON response: %v", err)
 	}
 }
 
+// sendText sends a successful response with a body that isn't JSON.
+func (s *Server) sendText(w http.ResponseWriter, contentType, body string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
+
 // handleOptions handles CORS preflight requests.
 func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+TimezoneHeader)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -350,3 +638,39 @@ func (s *Server) sendError(w http.ResponseWriter, statusCode int, message string
 	}
 	s.sendJSON(w, statusCode, response)
 }
+
+// parseTimeParam parses a time parameter, which is an RFC 3339 time or a duration before
+// now such as 24h or 7d. It returns nil if the parameter is not set.
+func parseTimeParam(query url.Values, key string, now time.Time) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := search.ParseTime(value, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return &t, nil
+}
+
+// parseSince parses the start of a time range from since= (see parseTimeParam) or last=,
+// a duration before now such as 7d. It returns nil if neither is set.
+func parseSince(query url.Values, now time.Time) (*time.Time, error) {
+	since, err := parseTimeParam(query, "since", now)
+	if err != nil {
+		return nil, err
+	}
+	last := query.Get("last")
+	if last == "" {
+		return since, nil
+	}
+	if since != nil {
+		return nil, fmt.Errorf("since and last can't both be set")
+	}
+	d, err := search.ParseDuration(last)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last: %w", err)
+	}
+	t := now.Add(-d)
+	return &t, nil
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +30,8 @@ type mockStore struct {
 	resourceHistErr error
 	userActivity    *store.QueryResult
 	userActivityErr error
+	groupActivity   *store.QueryResult
+	groupActivErr   error
 }
 
 func (m *mockStore) Save(event *model.ChangeEvent) error { return nil }
@@ -58,6 +62,13 @@ func (m *mockStore) GetUserActivity(ctx context.Context, username string, pagina
 	return m.userActivity, m.userActivityErr
 }
 
+func (m *mockStore) GetGroupActivity(ctx context.Context, group string, pagination store.PaginationParams, sortOrder store.SortOrder) (*store.QueryResult, error) {
+	m.lastFilters = store.QueryFilters{Group: group}
+	m.lastPagination = pagination
+	m.lastSort = sortOrder
+	return m.groupActivity, m.groupActivErr
+}
+
 func sampleEvent() *model.ChangeEvent {
 	return &model.ChangeEvent{
 		ID:           "CREATE-Deployment-my-app-123",
@@ -322,6 +333,136 @@ func TestHandleListChanges_ValidAllowedParsing(t *testing.T) {
 	}
 }
 
+func TestHandleListChanges_MinRiskScoreParsing(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?operation=EXEC&min_risk_score=70", nil)
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if mock.lastFilters.MinRiskScore != 70 {
+		t.Errorf("min_risk_score filter = %d, want 70", mock.lastFilters.MinRiskScore)
+	}
+}
+
+func TestHandleListChanges_NodeParsing(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?operation=NODE_MAINTENANCE&node=node-1", nil)
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if mock.lastFilters.Node != "node-1" {
+		t.Errorf("node filter = %q, want %q", mock.lastFilters.Node, "node-1")
+	}
+}
+
+func TestHandleListChanges_CountOnly(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{
+		Events: []*model.ChangeEvent{},
+		Total:  7,
+		Groups: []store.GroupCount{{Value: "payments", Count: 5}, {Value: "search", Count: 2}},
+	}}
+	server := NewServer(mock)
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?operation=DELETE&count_only=true&group_by=namespace&count_limit=100", nil)
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if f := mock.lastFilters; !f.CountOnly || f.GroupBy != "namespace" || f.CountLimit != 100 || f.Operation != "DELETE" {
+		t.Errorf("unexpected filters: %+v", f)
+	}
+
+	resp := decodeResponse[CountChangesResponse](t, rec)
+	if resp.Total != 7 || len(resp.Groups) != 2 || resp.Groups[0].Value != "payments" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if strings.Contains(rec.Body.String(), "events") {
+		t.Errorf("expected no events in a count-only response: %s", rec.Body.String())
+	}
+}
+
+func TestHandleListChanges_CountOnlyErrors(t *testing.T) {
+	server := NewServer(&mockStore{queryResult: &store.QueryResult{}})
+	for _, query := range []string{"count_only=maybe", "group_by=namespace", "count_only=true&group_by=diff", "count_limit=1", "count_only=true&count_limit=0"} {
+		rec := httptest.NewRecorder()
+		server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestHandleListChanges_Query(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}}}
+	server := NewServer(mock)
+	query := url.QueryEscape("kind:Secret AND namespace:prod* AND NOT user:system:*")
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?operation=DELETE&query="+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	f := mock.lastFilters
+	if f.ResourceKind != "Secret" || f.Namespace != "prod*" || len(f.ExcludeUsernames) != 1 || f.ExcludeUsernames[0] != "system:*" || f.Operation != "DELETE" {
+		t.Errorf("unexpected filters: %+v", f)
+	}
+
+	for _, query := range []string{"secret", "kind:(Secret", "kind:Secret OR namespace:prod"} {
+		rec := httptest.NewRecorder()
+		server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?query="+url.QueryEscape(query), nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+
+	// A field can't be filtered on by both the query and a parameter
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?namespace=default&query="+url.QueryEscape("namespace:prod"), nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a conflicting query, got %d", rec.Code)
+	}
+}
+
+func TestHandleListChanges_RelativeTime(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}}}
+	server := NewServer(mock)
+	before := time.Now()
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?last=7d&until=1h", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	f := mock.lastFilters
+	if f.StartTime == nil || f.EndTime == nil {
+		t.Fatalf("expected a time range, got %+v", f)
+	}
+	if d := before.Sub(*f.StartTime) - 7*24*time.Hour; d < -time.Minute || d > time.Minute {
+		t.Errorf("expected the start 7 days ago, got %v", f.StartTime)
+	}
+	if d := before.Sub(*f.EndTime) - time.Hour; d < -time.Minute || d > time.Minute {
+		t.Errorf("expected the end an hour ago, got %v", f.EndTime)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?start_time=24h", nil))
+	if f := mock.lastFilters; rec.Code != http.StatusOK || f.StartTime == nil || before.Sub(*f.StartTime) < 23*time.Hour {
+		t.Errorf("expected start_time=24h to start a day ago, got %d: %+v", rec.Code, f)
+	}
+
+	for _, query := range []string{"since=yesterday", "last=2024-01-01T00:00:00Z", "since=1h&last=1h", "start_time=1h&since=1h", "end_time=1h&until=1h"} {
+		rec := httptest.NewRecorder()
+		server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+}
+
 func TestHandleListChanges_NegativeLimit(t *testing.T) {
 	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
 	server := NewServer(mock)
@@ -350,6 +491,33 @@ func TestHandleListChanges_ZeroLimit(t *testing.T) {
 	}
 }
 
+func TestHandleListChanges_PageSizes(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 45}}
+	server := NewServer(mock)
+	server.SetPageSizes(store.PageSizes{Default: 20, Max: 100})
+
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes", nil))
+	response := decodeResponse[ListChangesResponse](t, rec)
+	if mock.lastPagination.Limit != 20 || response.Limit != 20 || response.TotalPages != 3 {
+		t.Errorf("expected the configured default limit and 3 pages, got %d, %+v", mock.lastPagination.Limit, response)
+	}
+
+	// Limits above the max are reduced to it, and the applied limit is returned
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?limit=5000", nil))
+	response = decodeResponse[ListChangesResponse](t, rec)
+	if mock.lastPagination.Limit != 100 || response.Limit != 100 || response.TotalPages != 1 {
+		t.Errorf("expected the limit reduced to 100, got %d, %+v", mock.lastPagination.Limit, response)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?limit=5000&strict=true", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "at most 100") {
+		t.Errorf("expected 400 for a limit above the max in strict mode, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestHandleResourceHistory_InvalidURLEncoding_Namespace(t *testing.T) {
 	server := NewServer(&mockStore{})
 	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/resources/Deployment/default/my-app/history", nil)
@@ -430,3 +598,207 @@ func TestHandleUserActivity_InvalidSort(t *testing.T) {
 		t.Errorf("expected default desc sort, got %s", mock.lastSort)
 	}
 }
+
+func TestHandleListChanges_Strict(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?strict=true&namespace!=kube-system&allowed=false&start_time=24h&limit=10&sort=asc", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for valid parameters, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tests := map[string]string{
+		"strict=maybe":                     "invalid strict",
+		"strict=true&start_time=yesterday": "invalid start_time",
+		"strict=true&end_time=tomorrow":    "invalid end_time",
+		"strict=true&allowed=yes":          "invalid allowed",
+		"strict=true&min_risk_score=high":  "invalid min_risk_score",
+		"strict=true&limit=-5":             "invalid limit",
+		"strict=true&offset=x":             "invalid offset",
+		"strict=true&sort=newest":          "invalid sort",
+		"strict=true&namspace=default":     "unknown parameter namspace",
+	}
+	for query, want := range tests {
+		rec := httptest.NewRecorder()
+		server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?"+query, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected 400 containing %q for %s, got %d: %s", want, query, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Without strict, invalid values are still ignored
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?strict=false&allowed=yes&namspace=default", nil))
+	if rec.Code != http.StatusOK || mock.lastFilters.Allowed != nil {
+		t.Errorf("expected invalid values ignored without strict, got %d", rec.Code)
+	}
+}
+
+func TestHandleUserActivity_Strict(t *testing.T) {
+	server := NewServer(&mockStore{userActivity: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}})
+	for query, want := range map[string]int{
+		"strict=true&limit=20":           http.StatusOK,
+		"strict=true&sort=invalid":       http.StatusBadRequest,
+		"strict=true&namespace=default":  http.StatusBadRequest,
+		"sort=invalid&namespace=default": http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		server.HandleUserActivity(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/users/testuser/activity?"+query, nil))
+		if rec.Code != want {
+			t.Errorf("expected %d for %s, got %d", want, query, rec.Code)
+		}
+	}
+}
+
+func TestHandleListChanges_SortBy(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?sort_by=namespace&sort=asc", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if mock.lastPagination.SortBy != "namespace" || mock.lastSort != store.SortOrderAsc {
+		t.Errorf("expected ascending sort by namespace, got %q %s", mock.lastPagination.SortBy, mock.lastSort)
+	}
+
+	// Unknown fields are rejected even without strict=true
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?sort_by=diff", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "sort_by") {
+		t.Errorf("expected 400 for an unknown sort_by, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleUserActivity_SortBy(t *testing.T) {
+	mock := &mockStore{userActivity: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+	rec := httptest.NewRecorder()
+	server.HandleUserActivity(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/users/testuser/activity?sort_by=kind&strict=true", nil))
+	if rec.Code != http.StatusOK || mock.lastPagination.SortBy != "kind" || mock.lastSort != store.SortOrderDesc {
+		t.Errorf("expected descending sort by kind, got %d: %q %s", rec.Code, mock.lastPagination.SortBy, mock.lastSort)
+	}
+}
+
+func TestHandleListChanges_AsOf(t *testing.T) {
+	asOf := time.Date(2024, 1, 19, 10, 0, 0, 123456000, time.UTC)
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 120, AsOf: asOf}}
+	server := NewServer(mock)
+
+	// The first page reports the time it was read as of
+	rec := httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes", nil))
+	response := decodeResponse[ListChangesResponse](t, rec)
+	if mock.lastPagination.AsOf != nil || response.AsOf == nil || !response.AsOf.Equal(asOf) {
+		t.Fatalf("expected the first page's as_of, got %+v", response.AsOf)
+	}
+
+	// Following pages pass it back
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?offset=50&as_of="+url.QueryEscape(response.AsOf.Format(time.RFC3339Nano)), nil))
+	if rec.Code != http.StatusOK || mock.lastPagination.AsOf == nil || !mock.lastPagination.AsOf.Equal(asOf) {
+		t.Errorf("expected the page read as of %s, got %d: %v", asOf, rec.Code, mock.lastPagination.AsOf)
+	}
+
+	rec = httptest.NewRecorder()
+	server.HandleListChanges(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?as_of=yesterday", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "as_of") {
+		t.Errorf("expected 400 for an invalid as_of, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleListChanges_Fields(t *testing.T) {
+	event := sampleEvent()
+	event.Diff = []model.PatchOp{{Op: "replace", Path: "/spec/replicas", Value: 3}}
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{event}, Total: 1}}
+	server := NewServer(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?fields=id,operation,name", nil)
+	rec := httptest.NewRecorder()
+
+	server.HandleListChanges(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if len(mock.lastFilters.Fields) != 3 {
+		t.Fatalf("expected fields to be passed to store, got %v", mock.lastFilters.Fields)
+	}
+
+	resp := decodeResponse[SparseListChangesResponse](t, rec)
+	if resp.Total != 1 || len(resp.Events) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	got := resp.Events[0]
+	if len(got) != 3 || got["id"] != event.ID || got["operation"] != "CREATE" || got["name"] != "my-app" {
+		t.Fatalf("unexpected sparse event: %+v", got)
+	}
+	if _, ok := got["diff"]; ok {
+		t.Fatal("diff should be omitted from sparse response")
+	}
+}
+
+func TestHandleListChanges_MultiValueAndNegationFilters(t *testing.T) {
+	mock := &mockStore{queryResult: &store.QueryResult{Events: []*model.ChangeEvent{}, Total: 0}}
+	server := NewServer(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes?operation=CREATE,DELETE&namespace!=kube-system,kube-public&resource_kind=Secret,ConfigMap&user=alice", nil)
+	rec := httptest.NewRecorder()
+
+	server.HandleListChanges(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	f := mock.lastFilters
+	if f.Operation != "" || len(f.Operations) != 2 || f.Operations[0] != "CREATE" || f.Operations[1] != "DELETE" {
+		t.Errorf("unexpected operation filters: %q %v", f.Operation, f.Operations)
+	}
+	if f.Namespace != "" || len(f.ExcludeNamespaces) != 2 || f.ExcludeNamespaces[0] != "kube-system" {
+		t.Errorf("unexpected namespace filters: %q %v", f.Namespace, f.ExcludeNamespaces)
+	}
+	if len(f.ResourceKinds) != 2 || f.ResourceKinds[1] != "ConfigMap" {
+		t.Errorf("unexpected resource kind filters: %v", f.ResourceKinds)
+	}
+	if f.Username != "alice" || len(f.Usernames) != 0 {
+		t.Errorf("single value should use the scalar filter: %q %v", f.Username, f.Usernames)
+	}
+}
+
+func TestHandleGroupActivity_Success(t *testing.T) {
+	mock := &mockStore{
+		groupActivity: &store.QueryResult{
+			Events: []*model.ChangeEvent{sampleEvent()},
+			Total:  1,
+		},
+	}
+	server := NewServer(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/groups/platform%3Aadmins/activity?limit=5&sort=asc", nil)
+	rec := httptest.NewRecorder()
+
+	server.HandleGroupActivity(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if mock.lastFilters.Group != "platform:admins" {
+		t.Fatalf("unexpected group filter: %+v", mock.lastFilters)
+	}
+	if mock.lastPagination.Limit != 5 || mock.lastSort != store.SortOrderAsc {
+		t.Fatalf("unexpected pagination/sort: %+v %s", mock.lastPagination, mock.lastSort)
+	}
+}
+
+func TestHandleGroupActivity_BadPath(t *testing.T) {
+	server := NewServer(&mockStore{})
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/groups//activity", nil)
+	rec := httptest.NewRecorder()
+
+	server.HandleGroupActivity(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
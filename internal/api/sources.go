@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// SourcesResponse reports the health of the event sources, stale sources first.
+type SourcesResponse struct {
+	Sources []*sources.Health `json:"sources"`
+	Stale   int               `json:"stale"` // Sources without events for longer than allowed
+}
+
+// SetSourceStore enables the sources endpoint, reporting the activity of the event sources
+// recorded in activity, stale as set by cfg (the defaults when nil).
+func (s *Server) SetSourceStore(activity store.SourceStore, cfg *sources.Config) {
+	s.sources = activity
+	s.sourcesConfig = cfg
+}
+
+// HandleSources handles GET /api/sources?cluster={cluster}, which reports the last event
+// received by each event source (admission webhook, audit processor, ingest API) per
+// cluster, and whether it is stale.
+func (s *Server) HandleSources(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.sources == nil {
+		s.sendError(w, http.StatusNotImplemented, "Source health is not supported by the store")
+		return
+	}
+
+	activity, err := s.sources.ListSourceActivity(r.Context())
+	if err != nil {
+		klog.Errorf("Failed to list source activity: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list source activity: %v", err))
+		return
+	}
+	if cluster := r.URL.Query().Get("cluster"); cluster != "" {
+		filtered := []*store.SourceActivity{}
+		for _, a := range activity {
+			if a.Cluster == cluster {
+				filtered = append(filtered, a)
+			}
+		}
+		activity = filtered
+	}
+
+	response := SourcesResponse{Sources: sources.Evaluate(activity, s.sourcesConfig, time.Now())}
+	for _, health := range response.Sources {
+		if health.Status == sources.StatusStale {
+			response.Stale++
+		}
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
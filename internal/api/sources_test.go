@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeSourceStore is a store.SourceStore of fixed activity.
+type fakeSourceStore struct {
+	activity []*store.SourceActivity
+}
+
+func (f *fakeSourceStore) RecordSourceActivity(ctx context.Context, activity []*store.SourceActivity) error {
+	return nil
+}
+
+func (f *fakeSourceStore) ListSourceActivity(ctx context.Context) ([]*store.SourceActivity, error) {
+	return f.activity, nil
+}
+
+func TestHandleSources(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer(&mockStore{}).HandleSources(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/sources", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a source store, got %d", w.Code)
+	}
+
+	now := time.Now()
+	server := NewServer(&mockStore{})
+	server.SetSourceStore(&fakeSourceStore{activity: []*store.SourceActivity{
+		{Source: sources.AuditProcessor, Cluster: "prod", LastEventAt: now.Add(-time.Minute), Events: 1200},
+		{Source: sources.Webhook, Cluster: "prod", LastEventAt: now.Add(-3 * time.Hour), Events: 5400},
+		{Source: sources.Webhook, Cluster: "staging", LastEventAt: now.Add(-time.Minute), Events: 300},
+	}}, nil)
+
+	w = httptest.NewRecorder()
+	server.HandleSources(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/sources", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeResponse[SourcesResponse](t, w)
+	if response.Stale != 1 || len(response.Sources) != 3 {
+		t.Fatalf("Expected 3 sources, 1 stale, got %s", w.Body.String())
+	}
+	if first := response.Sources[0]; first.Source != sources.Webhook || first.Cluster != "prod" || first.Status != sources.StatusStale || first.Events != 5400 {
+		t.Errorf("Expected the stale webhook first, got %+v", first)
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleSources(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/sources?cluster=staging", nil))
+	response = decodeResponse[SourcesResponse](t, w)
+	if response.Stale != 0 || len(response.Sources) != 1 || response.Sources[0].Cluster != "staging" {
+		t.Errorf("Expected the staging webhook only, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleSources(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/sources", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// defaultStatsPeriods are the periods stats are reported for when no since= is given, per
+// granularity.
+var defaultStatsPeriods = map[string]time.Duration{
+	store.StatsHourly: 24 * time.Hour,
+	store.StatsDaily:  30 * 24 * time.Hour,
+}
+
+// StatsResponse is the number of events per hour or day, oldest first.
+type StatsResponse struct {
+	Granularity string               `json:"granularity"`
+	Since       time.Time            `json:"since"`
+	Until       *time.Time           `json:"until,omitempty"`
+	GroupBy     string               `json:"group_by,omitempty"`
+	Buckets     []*store.StatsBucket `json:"buckets"`
+	Total       int64                `json:"total"`
+}
+
+// SetStatsStore enables the stats endpoint.
+func (s *Server) SetStatsStore(stats store.StatsStore) {
+	s.stats = stats
+}
+
+// HandleStats handles GET /api/stats?granularity={hour|day}&since={time}&group_by={field},
+// which counts events per hour or day (default: per day over the last 30 days, or per hour
+// over the last 24 hours) from aggregates kept by the store, so dashboards don't scan the
+// events. namespace=, kind=, user= and operation= count only events with those values.
+func (s *Server) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.stats == nil {
+		s.sendError(w, http.StatusNotImplemented, "Stats are not supported by the store")
+		return
+	}
+
+	query := r.URL.Query()
+	statsQuery := store.StatsQuery{Granularity: store.StatsDaily, Filters: map[string]string{}}
+	if granularity := query.Get("granularity"); granularity != "" {
+		if _, ok := defaultStatsPeriods[granularity]; !ok {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid granularity %q: must be hour or day", granularity))
+			return
+		}
+		statsQuery.Granularity = granularity
+	}
+
+	now := time.Now()
+	statsQuery.Since = now.Add(-defaultStatsPeriods[statsQuery.Granularity])
+	since, err := parseSince(query, now)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if since != nil {
+		statsQuery.Since = *since
+	}
+	if statsQuery.Until, err = parseTimeParam(query, "until", now); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		if !store.IsStatsField(groupBy) {
+			s.sendError(w, http.StatusBadRequest, fmt.Sprintf("invalid group_by %q: must be one of %s", groupBy, strings.Join(store.StatsFields(), ", ")))
+			return
+		}
+		statsQuery.GroupBy = groupBy
+	}
+	for _, field := range store.StatsFields() {
+		if value := query.Get(field); value != "" {
+			statsQuery.Filters[field] = value
+		}
+	}
+
+	buckets, err := s.stats.GetStats(r.Context(), statsQuery)
+	if err != nil {
+		klog.Errorf("Failed to get stats: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get stats: %v", err))
+		return
+	}
+
+	response := StatsResponse{
+		Granularity: statsQuery.Granularity,
+		Since:       statsQuery.Since,
+		Until:       statsQuery.Until,
+		GroupBy:     statsQuery.GroupBy,
+		Buckets:     buckets,
+	}
+	for _, b := range buckets {
+		response.Total += b.Events
+	}
+	s.sendJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeStatsStore is a store.StatsStore returning fixed buckets.
+type fakeStatsStore struct {
+	query   store.StatsQuery
+	buckets []*store.StatsBucket
+}
+
+func (f *fakeStatsStore) GetStats(ctx context.Context, query store.StatsQuery) ([]*store.StatsBucket, error) {
+	f.query = query
+	return f.buckets, nil
+}
+
+func TestHandleStats(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	prod, dev := "prod", "dev"
+	fake := &fakeStatsStore{buckets: []*store.StatsBucket{
+		{Bucket: day, Value: &prod, Events: 7, SampledEvents: 70},
+		{Bucket: day, Value: &dev, Events: 3, SampledEvents: 3},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetStatsStore(fake)
+
+	w := httptest.NewRecorder()
+	server.HandleStats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/stats?since=2026-01-01T00:00:00Z&group_by=namespace&operation=DELETE", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if fake.query.Granularity != store.StatsDaily || !fake.query.Since.Equal(day) || fake.query.GroupBy != "namespace" {
+		t.Errorf("Unexpected query: %+v", fake.query)
+	}
+	if len(fake.query.Filters) != 1 || fake.query.Filters["operation"] != "DELETE" {
+		t.Errorf("Unexpected filters: %v", fake.query.Filters)
+	}
+
+	var response StatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != 10 || len(response.Buckets) != 2 || *response.Buckets[0].Value != "prod" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleStats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/stats?granularity=hour", nil))
+	if d := time.Since(fake.query.Since) - 24*time.Hour; w.Code != http.StatusOK || fake.query.Granularity != store.StatsHourly || d < -time.Minute || d > time.Minute {
+		t.Errorf("Expected hourly stats of the last 24 hours, got %d: %+v", w.Code, fake.query)
+	}
+}
+
+func TestHandleStats_Errors(t *testing.T) {
+	server := NewServer(&mockStore{})
+	w := httptest.NewRecorder()
+	server.HandleStats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/stats", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a stats store, got %d", w.Code)
+	}
+
+	server.SetStatsStore(&fakeStatsStore{})
+	for _, query := range []string{"granularity=week", "group_by=name", "since=yesterday", "until=soon"} {
+		w = httptest.NewRecorder()
+		server.HandleStats(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/stats?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
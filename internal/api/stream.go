@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Broadcaster fans out change events to all connected stream subscribers.
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan *model.ChangeEvent]struct{}
+}
+
+// NewBroadcaster creates a new broadcaster.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan *model.ChangeEvent]struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber and returns its event channel.
+func (b *Broadcaster) Subscribe() chan *model.ChangeEvent {
+	ch := make(chan *model.ChangeEvent, 100) // Buffered so a slow client doesn't stall others
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes a subscriber.
+func (b *Broadcaster) Unsubscribe(ch chan *model.ChangeEvent) {
+	b.mu.Lock()
+	delete(b.subscribers, ch)
+	b.mu.Unlock()
+}
+
+// Publish sends an event to all subscribers (non-blocking).
+func (b *Broadcaster) Publish(event *model.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			klog.V(2).Infof("Stream subscriber buffer full, dropping event: %s", event.ID)
+		}
+	}
+}
+
+// RunNotificationListener follows new events announced by the store and publishes
+// them to stream subscribers. It reconnects on failure and returns when ctx is cancelled.
+func (s *Server) RunNotificationListener(ctx context.Context, listener store.Listener) {
+	backoff := time.Second
+	for {
+		err := listener.Listen(ctx, func(id string) {
+			event, err := s.store.GetEventByID(ctx, id)
+			if err != nil {
+				klog.Errorf("Failed to load notified event %s: %v", id, err)
+				return
+			}
+			s.broadcaster.Publish(event)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		klog.Warningf("Change event listener stopped: %v, reconnecting in %v", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// HandleStream handles GET /api/changes/stream requests using Server-Sent Events.
+func (s *Server) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.sendError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	// Streams outlive the server's WriteTimeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		klog.V(2).Infof("Could not clear write deadline for stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := s.broadcaster.Subscribe()
+	defer s.broadcaster.Unsubscribe(events)
+	decrypt := s.canDecrypt(r)
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case event := <-events:
+			if event.Operation == model.OperationHeartbeat || !store.InNamespaceScope(r.Context(), event.Namespace) {
+				continue
+			}
+			if !decrypt {
+				event = redactEvent(event)
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				klog.Errorf("Failed to encode stream event: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", event.ID, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcaster_PublishSubscribe(t *testing.T) {
+	b := NewBroadcaster()
+	ch := b.Subscribe()
+
+	b.Publish(sampleEvent())
+
+	select {
+	case event := <-ch:
+		if event.ID != sampleEvent().ID {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("expected event to be delivered to subscriber")
+	}
+
+	b.Unsubscribe(ch)
+	b.Publish(sampleEvent())
+	select {
+	case <-ch:
+		t.Fatal("unsubscribed channel should not receive events")
+	default:
+	}
+}
+
+type fakeListener struct {
+	ids []string
+}
+
+func (f *fakeListener) Listen(ctx context.Context, fn func(id string)) error {
+	for _, id := range f.ids {
+		fn(id)
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestRunNotificationListener_PublishesEvents(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: sampleEvent()})
+	ch := server.broadcaster.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.RunNotificationListener(ctx, &fakeListener{ids: []string{sampleEvent().ID}})
+
+	select {
+	case event := <-ch:
+		if event.ID != sampleEvent().ID {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected notified event to be published")
+	}
+}
+
+func TestHandleStream_SendsEvents(t *testing.T) {
+	server := NewServer(&mockStore{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/stream", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		server.HandleStream(rec, req)
+		close(done)
+	}()
+
+	// Wait for the handler to subscribe before publishing
+	deadline := time.Now().Add(time.Second)
+	for {
+		server.broadcaster.mu.Lock()
+		n := len(server.broadcaster.subscribers)
+		server.broadcaster.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	server.broadcaster.Publish(sampleEvent())
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "event: change") || !strings.Contains(body, sampleEvent().ID) {
+		t.Fatalf("expected change event in stream, got %q", body)
+	}
+}
+
+func TestHandleStream_MethodNotAllowed(t *testing.T) {
+	server := NewServer(&mockStore{})
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/changes/stream", nil)
+	rec := httptest.NewRecorder()
+
+	server.HandleStream(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
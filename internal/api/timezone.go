@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// TimezoneHeader carries the caller's preferred time zone, e.g. the browser's, for the
+// requests without a tz= parameter.
+const TimezoneHeader = "X-Timezone"
+
+// requestLocation returns the time zone the timestamps of a response are shown in: that of
+// the tz= parameter, else of the X-Timezone header, else nil for UTC.
+func requestLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		name = r.Header.Get(TimezoneHeader)
+	}
+	if name == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+	return location, nil
+}
+
+// inLocation returns t in location, or t unchanged if location is nil.
+func inLocation(t time.Time, location *time.Location) time.Time {
+	if location == nil {
+		return t
+	}
+	return t.In(location)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestRequestLocation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance", nil)
+	if location, err := requestLocation(req); location != nil || err != nil {
+		t.Errorf("Expected no location without a preference, got %v, %v", location, err)
+	}
+
+	req.Header.Set(TimezoneHeader, "Europe/Paris")
+	if location, err := requestLocation(req); err != nil || location.String() != "Europe/Paris" {
+		t.Errorf("Expected the header's time zone, got %v, %v", location, err)
+	}
+
+	// tz= takes precedence over the header
+	req = httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?tz=America/Chicago", nil)
+	req.Header.Set(TimezoneHeader, "Europe/Paris")
+	if location, err := requestLocation(req); err != nil || location.String() != "America/Chicago" {
+		t.Errorf("Expected the parameter's time zone, got %v, %v", location, err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?tz=Mars/Olympus", nil)
+	if _, err := requestLocation(req); err == nil {
+		t.Error("Expected an unknown time zone to be rejected")
+	}
+}
+
+func TestHandleCompliance_Timezone(t *testing.T) {
+	streamer := &fakeEventStreamer{events: []*model.ChangeEvent{
+		{ID: "night", Timestamp: time.Date(2026, 1, 5, 22, 0, 0, 0, time.UTC), Operation: "UPDATE", Namespace: "prod-search", Allowed: true},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetComplianceStore(streamer, &config.ChangeWindowConfig{
+		Windows: []config.ChangeWindow{{Name: "business-hours", NamespacePatterns: []string{"prod-*"}, Start: "09:00", End: "18:00"}},
+	})
+
+	w := httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z&tz=Asia/Tokyo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, want := range []string{`"since":"2026-01-01T09:00:00+09:00"`, `"timestamp":"2026-01-06T07:00:00+09:00"`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected %s in the report, got %s", want, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleCompliance(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/compliance?tz=Nowhere", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown time zone, got %d", w.Code)
+	}
+}
+
+func TestHandleExports_Timezone(t *testing.T) {
+	server, jobs := exportsTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(`{}`))
+	req.Header.Set(TimezoneHeader, "Europe/Berlin")
+	w := httptest.NewRecorder()
+	server.HandleExports(w, req)
+	response := decodeResponse[ExportJobResponse](t, w)
+	if job := jobs.jobs[response.ID]; job == nil || job.Timezone != "Europe/Berlin" {
+		t.Errorf("Expected the caller's time zone, got %+v", job)
+	}
+
+	// The request's time zone takes precedence
+	req = httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(`{"timezone": "UTC"}`))
+	req.Header.Set(TimezoneHeader, "Europe/Berlin")
+	w = httptest.NewRecorder()
+	server.HandleExports(w, req)
+	response = decodeResponse[ExportJobResponse](t, w)
+	if job := jobs.jobs[response.ID]; job == nil || job.Timezone != "UTC" {
+		t.Errorf("Expected the request's time zone, got %+v", job)
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleExports(w, httptest.NewRequest(http.MethodPost, "/kubechronicle/api/exports", strings.NewReader(`{"timezone": "Nowhere"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown time zone, got %d", w.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/tenancy"
+)
+
+// defaultUsagePeriod is the period usage is reported for when no since= is given.
+const defaultUsagePeriod = 30 * 24 * time.Hour
+
+// UsageResponse is the storage used per namespace, and per tenant in multi-tenancy mode,
+// largest first.
+type UsageResponse struct {
+	Since      time.Time               `json:"since"`
+	Namespaces []*store.NamespaceUsage `json:"namespaces"`
+	Tenants    []*TenantUsage          `json:"tenants,omitempty"`
+	Total      UsageTotal              `json:"total"`
+}
+
+// UsageTotal sums the usage of a set of namespaces.
+type UsageTotal struct {
+	Events        int64 `json:"events"`
+	SampledEvents int64 `json:"sampled_events"`
+	Bytes         int64 `json:"bytes"`
+}
+
+// TenantUsage is the usage of the namespaces owned by a tenant.
+type TenantUsage struct {
+	Tenant     string   `json:"tenant"`
+	Namespaces []string `json:"namespaces"`
+	UsageTotal
+}
+
+// add adds the usage of a namespace to the total.
+func (t *UsageTotal) add(u *store.NamespaceUsage) {
+	t.Events += u.Events
+	t.SampledEvents += u.SampledEvents
+	t.Bytes += u.Bytes
+}
+
+// SetUsageStore enables the usage endpoint.
+func (s *Server) SetUsageStore(usage store.UsageStore) {
+	s.usage = usage
+}
+
+// SetTenancy makes the usage endpoint also report usage per tenant of resolver.
+func (s *Server) SetTenancy(resolver *tenancy.Resolver) {
+	s.tenancy = resolver
+}
+
+// HandleUsage handles GET /api/usage?since={RFC3339}, which reports the events and storage
+// of each namespace since the given time (default: the last 30 days).
+func (s *Server) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		s.handleOptions(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.usage == nil {
+		s.sendError(w, http.StatusNotImplemented, "Usage accounting is not supported by the store")
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-defaultUsagePeriod)
+	parsed, err := parseSince(r.URL.Query(), now)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if parsed != nil {
+		since = *parsed
+	}
+
+	namespaces, err := s.usage.GetUsage(r.Context(), since)
+	if err != nil {
+		klog.Errorf("Failed to get usage: %v", err)
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get usage: %v", err))
+		return
+	}
+
+	response := UsageResponse{Since: since, Namespaces: namespaces}
+	tenants := map[string]*TenantUsage{}
+	for _, u := range namespaces {
+		response.Total.add(u)
+		if s.tenancy == nil {
+			continue
+		}
+		for _, name := range s.tenancy.NamespaceTenants(u.Namespace) {
+			tenant, ok := tenants[name]
+			if !ok {
+				tenant = &TenantUsage{Tenant: name, Namespaces: []string{}}
+				tenants[name] = tenant
+				response.Tenants = append(response.Tenants, tenant)
+			}
+			tenant.Namespaces = append(tenant.Namespaces, u.Namespace)
+			tenant.add(u)
+		}
+	}
+	sort.SliceStable(response.Tenants, func(i, j int) bool {
+		return response.Tenants[i].Bytes > response.Tenants[j].Bytes
+	})
+
+	s.sendJSON(w, http.StatusOK, response)
+}
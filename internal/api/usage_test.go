@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/internal/tenancy"
+)
+
+// fakeUsageStore is a store.UsageStore returning fixed usage.
+type fakeUsageStore struct {
+	since time.Time
+	usage []*store.NamespaceUsage
+}
+
+func (f *fakeUsageStore) GetUsage(ctx context.Context, since time.Time) ([]*store.NamespaceUsage, error) {
+	f.since = since
+	return f.usage, nil
+}
+
+func TestHandleUsage(t *testing.T) {
+	fake := &fakeUsageStore{usage: []*store.NamespaceUsage{
+		{Namespace: "payments-prod", Events: 10, SampledEvents: 100, Bytes: 5000},
+		{Namespace: "search", Events: 4, SampledEvents: 4, Bytes: 3000},
+		{Namespace: "payments-dev", Events: 2, SampledEvents: 2, Bytes: 1000},
+		{Namespace: "", Events: 1, SampledEvents: 1, Bytes: 200},
+	}}
+	server := NewServer(&mockStore{})
+	server.SetUsageStore(fake)
+	server.SetTenancy(tenancy.NewResolver(&config.TenancyConfig{Tenants: map[string]config.TenantConfig{
+		"payments": {Namespaces: []string{"payments-*"}},
+		"search":   {Namespaces: []string{"search"}},
+	}}))
+
+	w := httptest.NewRecorder()
+	server.HandleUsage(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/usage?since=2026-01-01T00:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !fake.since.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected since: %v", fake.since)
+	}
+
+	var response UsageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != (UsageTotal{Events: 17, SampledEvents: 107, Bytes: 9200}) {
+		t.Errorf("Unexpected total: %+v", response.Total)
+	}
+	if len(response.Tenants) != 2 {
+		t.Fatalf("Expected 2 tenants, got %+v", response.Tenants)
+	}
+	payments := response.Tenants[0]
+	if payments.Tenant != "payments" || len(payments.Namespaces) != 2 || payments.Bytes != 6000 || payments.Events != 12 {
+		t.Errorf("Unexpected payments usage: %+v", payments)
+	}
+	if response.Tenants[1].Tenant != "search" || response.Tenants[1].Bytes != 3000 {
+		t.Errorf("Unexpected search usage: %+v", response.Tenants[1])
+	}
+}
+
+func TestHandleUsage_Errors(t *testing.T) {
+	server := NewServer(&mockStore{})
+	w := httptest.NewRecorder()
+	server.HandleUsage(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/usage", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a usage store, got %d", w.Code)
+	}
+
+	server.SetUsageStore(&fakeUsageStore{})
+	w = httptest.NewRecorder()
+	server.HandleUsage(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/usage?since=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid since, got %d", w.Code)
+	}
+
+	fake := &fakeUsageStore{}
+	server.SetUsageStore(fake)
+	w = httptest.NewRecorder()
+	server.HandleUsage(w, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/usage?last=30d", nil))
+	if d := time.Since(fake.since) - 30*24*time.Hour; w.Code != http.StatusOK || d < -time.Minute || d > time.Minute {
+		t.Errorf("Expected last=30d to start 30 days ago, got %d: %v", w.Code, fake.since)
+	}
+}
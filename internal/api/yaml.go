@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// wantsYAML reports whether a request asks for a YAML response, with format=yaml or an
+// Accept header listing a YAML media type before JSON.
+func wantsYAML(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "yaml"
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case "application/yaml", "application/x-yaml", "text/yaml":
+			return true
+		case "application/json":
+			return false
+		}
+	}
+	return false
+}
+
+// sendDetail sends a detail response as YAML if the request asks for it, JSON otherwise.
+// Field names are the same in both, since YAML is converted from the JSON encoding.
+func (s *Server) sendDetail(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	w.Header().Set("Vary", "Accept")
+	if !wantsYAML(r) {
+		s.sendJSON(w, statusCode, data)
+		return
+	}
+	body, err := yaml.Marshal(data)
+	if err != nil {
+		klog.Errorf("Failed to encode YAML response: %v", err)
+		s.sendError(w, http.StatusInternalServerError, "Failed to encode YAML response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestWantsYAML(t *testing.T) {
+	tests := []struct {
+		path   string
+		accept string
+		want   bool
+	}{
+		{"/kubechronicle/api/changes/1", "", false},
+		{"/kubechronicle/api/changes/1?format=yaml", "", true},
+		{"/kubechronicle/api/changes/1?format=json", "application/yaml", false},
+		{"/kubechronicle/api/changes/1", "application/yaml", true},
+		{"/kubechronicle/api/changes/1", "text/html, Application/X-YAML;q=0.9", true},
+		{"/kubechronicle/api/changes/1", "application/json, application/yaml", false},
+		{"/kubechronicle/api/changes/1", "*/*", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := wantsYAML(req); got != tt.want {
+			t.Errorf("wantsYAML(%s, Accept: %q) = %v, want %v", tt.path, tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestHandleGetChange_YAML(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: sampleEvent()})
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/CREATE-Deployment-my-app-123", nil)
+	req.Header.Set("Accept", "application/yaml")
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("expected a YAML content type, got %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "id: CREATE-Deployment-my-app-123\n") {
+		t.Errorf("expected YAML with JSON field names, got:\n%s", rec.Body.String())
+	}
+	var event model.ChangeEvent
+	if err := yaml.Unmarshal(rec.Body.Bytes(), &event); err != nil || event.ID != "CREATE-Deployment-my-app-123" {
+		t.Errorf("expected the event to round-trip, got %+v (%v)", event, err)
+	}
+}
+
+func TestHandleChangeDiff_YAML(t *testing.T) {
+	server := NewServer(&mockStore{eventByID: diffTestEvent()})
+	rec := httptest.NewRecorder()
+	server.HandleGetChange(rec, httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes/UPDATE-Deployment-api-1/diff?format=yaml", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Fatalf("expected a YAML content type, got %q: %s", ct, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "path: /spec/replicas\n") {
+		t.Errorf("expected YAML hunks, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// OperationCredentialIssuance is the operation of events recording certificate signing
+// requests being approved or denied, and service account tokens being requested.
+const OperationCredentialIssuance = "CREDENTIAL_ISSUANCE"
+
+// Credential types.
+const (
+	CredentialTypeCertificate = "certificate"
+	CredentialTypeToken       = "token"
+)
+
+// isCSRApproval checks if an audit event approves or denies a certificate signing request.
+func isCSRApproval(event *AuditEvent) bool {
+	ref := event.ObjectRef
+	return ref != nil && ref.Resource == "certificatesigningrequests" && ref.Subresource == "approval" &&
+		(event.Verb == "update" || event.Verb == "patch")
+}
+
+// isTokenRequest checks if an audit event requests a service account token. Tokens
+// requested by kubelets for projected volumes, and by the controller manager for its
+// controllers, are routine and not recorded.
+func isTokenRequest(event *AuditEvent) bool {
+	ref := event.ObjectRef
+	if ref == nil || ref.Resource != "serviceaccounts" || ref.Subresource != "token" || event.Verb != "create" {
+		return false
+	}
+	username := event.User.Username
+	return !strings.HasPrefix(username, "system:node:") && username != "system:kube-controller-manager"
+}
+
+// IsCredentialIssuanceOperation checks if an audit event approves or denies a certificate
+// signing request, or requests a service account token.
+func (p *Processor) IsCredentialIssuanceOperation(event *AuditEvent) bool {
+	return isCSRApproval(event) || isTokenRequest(event)
+}
+
+// ExtractCredentialIssuanceEvent converts an audit event to a ChangeEvent for credential
+// issuance. Details are read from the request body, logged at the Request audit level or
+// above; the issued token or certificate is never recorded.
+func (p *Processor) ExtractCredentialIssuanceEvent(event *AuditEvent) (*model.ChangeEvent, error) {
+	credentialEvent := &model.ChangeEvent{
+		Operation: OperationCredentialIssuance,
+		Timestamp: event.RequestReceivedTimestamp,
+		Allowed:   true,
+		Actor:     extractActor(event),
+		Source:    model.Source{Tool: p.detectSourceTool(event)},
+	}
+
+	switch {
+	case isCSRApproval(event):
+		credentialEvent.ResourceKind = "CertificateSigningRequest"
+		credentialEvent.Name = event.ObjectRef.Name
+		credentialEvent.CredentialIssuance = extractCertificateIssuance(event.RequestObject)
+	case isTokenRequest(event):
+		credentialEvent.ResourceKind = "ServiceAccount"
+		credentialEvent.Namespace = event.ObjectRef.Namespace
+		credentialEvent.Name = event.ObjectRef.Name
+		credentialEvent.CredentialIssuance = extractTokenIssuance(event.RequestObject)
+	default:
+		return nil, fmt.Errorf("not a credential issuance operation")
+	}
+
+	credentialEvent.ID = model.NewEventID(credentialEvent.Timestamp)
+	return credentialEvent, nil
+}
+
+// extractCertificateIssuance extracts the decision and requested certificate of a
+// CertificateSigningRequest.
+func extractCertificateIssuance(csr map[string]interface{}) *model.CredentialIssuance {
+	issuance := &model.CredentialIssuance{Type: CredentialTypeCertificate}
+
+	spec, _ := csr["spec"].(map[string]interface{})
+	issuance.SignerName, _ = spec["signerName"].(string)
+	issuance.Requester, _ = spec["username"].(string)
+	issuance.Usages = stringList(spec["usages"])
+
+	// The subject of the requested certificate is who it authenticates as
+	if request, ok := spec["request"].(string); ok {
+		if certificateRequest, err := parseCertificateRequest(request); err == nil {
+			issuance.Subject = certificateRequest.Subject.CommonName
+			issuance.Groups = certificateRequest.Subject.Organization
+		}
+	}
+
+	status, _ := csr["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+		conditionType, _ := condition["type"].(string)
+		if (conditionType == "Approved" || conditionType == "Denied") && condition["status"] != "False" {
+			issuance.Decision = conditionType
+		}
+	}
+	return issuance
+}
+
+// extractTokenIssuance extracts the audiences, lifetime and binding of a TokenRequest.
+func extractTokenIssuance(tokenRequest map[string]interface{}) *model.CredentialIssuance {
+	issuance := &model.CredentialIssuance{Type: CredentialTypeToken}
+
+	spec, _ := tokenRequest["spec"].(map[string]interface{})
+	issuance.Audiences = stringList(spec["audiences"])
+	if expiration, ok := spec["expirationSeconds"].(float64); ok {
+		issuance.ExpirationSeconds = int64(expiration)
+	}
+	if ref, ok := spec["boundObjectRef"].(map[string]interface{}); ok {
+		kind, _ := ref["kind"].(string)
+		name, _ := ref["name"].(string)
+		issuance.BoundObject = kind + "/" + name
+	}
+	return issuance
+}
+
+// parseCertificateRequest parses the base64-encoded PEM certificate request of a CSR.
+func parseCertificateRequest(request string) (*x509.CertificateRequest, error) {
+	data, err := base64.StdEncoding.DecodeString(request)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("no PEM certificate request")
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// stringList converts a JSON array of strings.
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func csrRequest(t *testing.T, subject pkix.Name) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: subject}, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate request: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func TestProcessor_ExtractCredentialIssuanceEvent_CSR(t *testing.T) {
+	p := NewProcessor()
+	event := &AuditEvent{
+		Verb:      "update",
+		ObjectRef: &AuditObjectRef{Resource: "certificatesigningrequests", Subresource: "approval", Name: "csr-mallory", APIGroup: "certificates.k8s.io"},
+		RequestObject: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"request":    csrRequest(t, pkix.Name{CommonName: "mallory", Organization: []string{"system:masters"}}),
+				"signerName": "kubernetes.io/kube-apiserver-client",
+				"username":   "mallory",
+				"usages":     []interface{}{"client auth"},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Approved", "status": "True", "reason": "KubectlApprove"},
+				},
+			},
+		},
+		RequestReceivedTimestamp: time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC),
+	}
+	event.User.Username = "alice"
+
+	if !p.IsCredentialIssuanceOperation(event) {
+		t.Fatal("CSR approval should be a credential issuance operation")
+	}
+	changeEvent, err := p.ExtractCredentialIssuanceEvent(event)
+	if err != nil {
+		t.Fatalf("ExtractCredentialIssuanceEvent() error = %v", err)
+	}
+	if changeEvent.Operation != OperationCredentialIssuance || changeEvent.ResourceKind != "CertificateSigningRequest" || changeEvent.Name != "csr-mallory" {
+		t.Errorf("Unexpected event: %+v", changeEvent)
+	}
+	issuance := changeEvent.CredentialIssuance
+	if issuance.Type != CredentialTypeCertificate || issuance.Decision != "Approved" || issuance.Requester != "mallory" ||
+		issuance.SignerName != "kubernetes.io/kube-apiserver-client" {
+		t.Errorf("Unexpected issuance: %+v", issuance)
+	}
+	if issuance.Subject != "mallory" || !reflect.DeepEqual(issuance.Groups, []string{"system:masters"}) {
+		t.Errorf("Subject = %q %v, want mallory [system:masters]", issuance.Subject, issuance.Groups)
+	}
+	if !reflect.DeepEqual(issuance.Usages, []string{"client auth"}) {
+		t.Errorf("Usages = %v", issuance.Usages)
+	}
+
+	// Denials are recorded too
+	event.RequestObject["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Denied", "status": "True"}},
+	}
+	changeEvent, _ = p.ExtractCredentialIssuanceEvent(event)
+	if changeEvent.CredentialIssuance.Decision != "Denied" {
+		t.Errorf("Decision = %q, want Denied", changeEvent.CredentialIssuance.Decision)
+	}
+}
+
+func TestProcessor_ExtractCredentialIssuanceEvent_Token(t *testing.T) {
+	p := NewProcessor()
+	event := &AuditEvent{
+		Verb:      "create",
+		ObjectRef: &AuditObjectRef{Resource: "serviceaccounts", Subresource: "token", Namespace: "ci", Name: "deployer"},
+		RequestObject: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"audiences":         []interface{}{"https://kubernetes.default.svc"},
+				"expirationSeconds": float64(86400),
+				"boundObjectRef":    map[string]interface{}{"kind": "Secret", "name": "deployer-token"},
+			},
+		},
+	}
+	event.User.Username = "alice"
+
+	changeEvent, err := p.ExtractCredentialIssuanceEvent(event)
+	if err != nil {
+		t.Fatalf("ExtractCredentialIssuanceEvent() error = %v", err)
+	}
+	if changeEvent.ResourceKind != "ServiceAccount" || changeEvent.Namespace != "ci" || changeEvent.Name != "deployer" {
+		t.Errorf("Unexpected event: %+v", changeEvent)
+	}
+	issuance := changeEvent.CredentialIssuance
+	if issuance.Type != CredentialTypeToken || issuance.ExpirationSeconds != 86400 || issuance.BoundObject != "Secret/deployer-token" ||
+		!reflect.DeepEqual(issuance.Audiences, []string{"https://kubernetes.default.svc"}) {
+		t.Errorf("Unexpected issuance: %+v", issuance)
+	}
+}
+
+func TestProcessor_IsCredentialIssuanceOperation(t *testing.T) {
+	tokenRequest := func(username string) *AuditEvent {
+		event := &AuditEvent{Verb: "create", ObjectRef: &AuditObjectRef{Resource: "serviceaccounts", Subresource: "token", Namespace: "ci", Name: "deployer"}}
+		event.User.Username = username
+		return event
+	}
+
+	tests := []struct {
+		name  string
+		event *AuditEvent
+		want  bool
+	}{
+		{name: "token by user", event: tokenRequest("alice"), want: true},
+		{name: "token by service account", event: tokenRequest("system:serviceaccount:ci:runner"), want: true},
+		{name: "token by kubelet", event: tokenRequest("system:node:node-1")},
+		{name: "token by controller manager", event: tokenRequest("system:kube-controller-manager")},
+		{name: "CSR create", event: &AuditEvent{Verb: "create", ObjectRef: &AuditObjectRef{Resource: "certificatesigningrequests", Name: "csr-1"}}},
+		{name: "service account create", event: &AuditEvent{Verb: "create", ObjectRef: &AuditObjectRef{Resource: "serviceaccounts", Name: "deployer"}}},
+	}
+
+	p := NewProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.IsCredentialIssuanceOperation(tt.event); got != tt.want {
+				t.Errorf("IsCredentialIssuanceOperation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
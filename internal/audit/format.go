@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats of audit logs, detected by ParseAuditEvents.
+const (
+	// FormatEvent is one audit event in JSON, as written by the log backend one per line
+	FormatEvent = "event"
+	// FormatEventList is an audit.k8s.io EventList, as sent by the webhook backend, or a
+	// JSON array of events
+	FormatEventList = "event_list"
+	// FormatLegacy is a line of the text audit log of Kubernetes before 1.8:
+	// <time> AUDIT: id="..." ip="..." method="..." user="..." groups="..." uri="..."
+	FormatLegacy = "legacy"
+)
+
+var (
+	parsedAuditEvents  = expvar.NewInt("audit_parsed_events_total")
+	unparseableLines   = expvar.NewInt("audit_unparseable_lines_total")
+	legacyAuditEvents  = expvar.NewInt("audit_legacy_events_total")
+	auditEventListDocs = expvar.NewInt("audit_event_lists_total")
+)
+
+// auditGroup is the API group of audit events; v1alpha1 and v1beta1 events are converted
+// to v1 when parsed.
+const auditGroup = "audit.k8s.io/"
+
+// supportedAuditVersions are the audit.k8s.io versions that can be parsed.
+var supportedAuditVersions = map[string]bool{"v1": true, "v1beta1": true, "v1alpha1": true}
+
+// legacyMarker separates the time of a legacy audit line from its fields.
+const legacyMarker = " AUDIT: "
+
+// ParseAuditEvents parses a line or document of an audit log in any of the supported
+// formats, and returns its events and format. Legacy lines reporting only the response to
+// a request return no events.
+func (p *Processor) ParseAuditEvents(data []byte) ([]*AuditEvent, string, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil, "", nil
+	case data[0] == '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, FormatEventList, fmt.Errorf("failed to unmarshal audit event array: %w", err)
+		}
+		events, err := p.parseItems(items)
+		return events, FormatEventList, err
+	case data[0] == '{':
+		var envelope struct {
+			Kind       string            `json:"kind"`
+			APIVersion string            `json:"apiVersion"`
+			Items      []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, FormatEvent, fmt.Errorf("failed to unmarshal audit log: %w", err)
+		}
+		if envelope.Kind != "EventList" {
+			event, err := p.ParseAuditLog(data)
+			if err != nil {
+				return nil, FormatEvent, err
+			}
+			return []*AuditEvent{event}, FormatEvent, nil
+		}
+		if err := checkAPIVersion(envelope.APIVersion); err != nil {
+			return nil, FormatEventList, err
+		}
+		events, err := p.parseItems(envelope.Items)
+		return events, FormatEventList, err
+	case bytes.Contains(data, []byte(legacyMarker)):
+		event, err := parseLegacyLine(string(data))
+		if err != nil || event == nil {
+			return nil, FormatLegacy, err
+		}
+		return []*AuditEvent{event}, FormatLegacy, nil
+	}
+	return nil, "", fmt.Errorf("not an audit log line")
+}
+
+// parseItems parses the events of an EventList or array.
+func (p *Processor) parseItems(items []json.RawMessage) ([]*AuditEvent, error) {
+	events := make([]*AuditEvent, 0, len(items))
+	for i, item := range items {
+		event, err := p.ParseAuditLog(item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// normalizeEvent checks the kind and version of a JSON audit event and converts events of
+// older versions to the current one.
+func normalizeEvent(event *AuditEvent) error {
+	if event.Kind != "" && event.Kind != "Event" {
+		return fmt.Errorf("unexpected kind %q, expected an audit Event", event.Kind)
+	}
+	if err := checkAPIVersion(event.APIVersion); err != nil {
+		return err
+	}
+	if event.Verb == "" && event.RequestURI == "" && event.AuditID == "" {
+		return fmt.Errorf("not an audit event")
+	}
+	if event.RequestReceivedTimestamp.IsZero() {
+		event.RequestReceivedTimestamp = event.Timestamp
+	}
+	return nil
+}
+
+// checkAPIVersion reports an error if apiVersion is not a supported audit.k8s.io version.
+// Events without one, as written by some log shippers, are assumed to be current.
+func checkAPIVersion(apiVersion string) error {
+	if apiVersion == "" {
+		return nil
+	}
+	version, ok := strings.CutPrefix(apiVersion, auditGroup)
+	if !ok || !supportedAuditVersions[version] {
+		return fmt.Errorf("unsupported audit apiVersion %q", apiVersion)
+	}
+	return nil
+}
+
+// legacyVerbs maps the HTTP methods of legacy audit lines to verbs. Lists and watches
+// can't be told apart from gets, and aren't recorded anyway.
+var legacyVerbs = map[string]string{
+	"GET":    "get",
+	"POST":   "create",
+	"PUT":    "update",
+	"PATCH":  "patch",
+	"DELETE": "delete",
+}
+
+// parseLegacyLine parses a line of the legacy text audit log. Requests and their responses
+// are logged on separate lines with the same id; response lines return no event, so
+// requests are assumed to have succeeded. The objects of requests aren't logged, so only
+// exec and attach requests, recognized by their URI, can be recorded from legacy logs.
+func parseLegacyLine(line string) (*AuditEvent, error) {
+	timestamp, rest, _ := strings.Cut(line, legacyMarker)
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(timestamp))
+	if err != nil {
+		return nil, fmt.Errorf("invalid legacy audit time: %w", err)
+	}
+	fields, err := parseLegacyFields(rest)
+	if err != nil {
+		return nil, err
+	}
+	if fields["uri"] == "" {
+		return nil, nil // The response line
+	}
+
+	event := &AuditEvent{
+		Level:                    "Metadata",
+		AuditID:                  fields["id"],
+		Stage:                    "ResponseComplete",
+		RequestURI:               fields["uri"],
+		Verb:                     legacyVerbs[fields["method"]],
+		User:                     AuditUser{Username: fields["user"]},
+		ObjectRef:                legacyObjectRef(fields["uri"]),
+		RequestReceivedTimestamp: t,
+	}
+	if ip := fields["ip"]; ip != "" {
+		event.SourceIPs = []string{ip}
+	}
+	if groups := fields["groups"]; groups != "" && groups != "<none>" {
+		// A comma-separated list of quoted groups
+		for _, group := range strings.Split(groups, ",") {
+			if unquoted, err := strconv.Unquote(group); err == nil {
+				group = unquoted
+			}
+			event.User.Groups = append(event.User.Groups, group)
+		}
+	}
+	// Requests impersonating another user are recorded as the impersonated user
+	if as := fields["as"]; as != "" && as != "<self>" {
+		event.User.Username = as
+	}
+	return event, nil
+}
+
+// legacyObjectRef returns the object a request URI refers to, as audit events of later
+// versions report it, or nil if the URI isn't a resource path:
+// /api/v1[/namespaces/{namespace}]/{resource}[/{name}[/{subresource}]], or the same under
+// /apis/{group}/{version}.
+func legacyObjectRef(uri string) *AuditObjectRef {
+	path, _, _ := strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	ref := &AuditObjectRef{}
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		ref.APIVersion, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		ref.APIGroup, ref.APIVersion, parts = parts[1], parts[2], parts[3:]
+	default:
+		return nil
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		ref.Namespace, parts = parts[1], parts[2:]
+	}
+	ref.Resource = parts[0]
+	if len(parts) > 1 {
+		ref.Name = parts[1]
+	}
+	if len(parts) > 2 {
+		ref.Subresource = parts[2]
+	}
+	return ref
+}
+
+// parseLegacyFields parses the key="value" fields of a legacy audit line, whose values are
+// quoted like Go strings.
+func parseLegacyFields(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok || !strings.HasPrefix(rest, `"`) {
+			return nil, fmt.Errorf("invalid legacy audit field %q", s)
+		}
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("unterminated legacy audit field %q", key)
+		}
+		value, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid legacy audit field %q: %w", key, err)
+		}
+		fields[key] = value
+		s = rest[end+1:]
+	}
+	return fields, nil
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const execEventJSON = `{"kind":"Event","apiVersion":"audit.k8s.io/v1","auditID":"a1","stage":"ResponseComplete",` +
+	`"requestURI":"/api/v1/namespaces/default/pods/web/exec?command=sh&container=app","verb":"create",` +
+	`"user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"default","name":"web","subresource":"exec"},` +
+	`"responseStatus":{"code":200},"requestReceivedTimestamp":"2024-01-20T10:30:00Z"}`
+
+func TestParseAuditEvents(t *testing.T) {
+	p := NewProcessor()
+	received := time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		events int
+	}{
+		{"event", execEventJSON, FormatEvent, 1},
+		{"event list", `{"kind":"EventList","apiVersion":"audit.k8s.io/v1","items":[` + execEventJSON + `,` + execEventJSON + `]}`, FormatEventList, 2},
+		{"array", `[` + execEventJSON + `]`, FormatEventList, 1},
+		{"v1beta1", `{"kind":"Event","apiVersion":"audit.k8s.io/v1beta1","auditID":"a2","verb":"create","timestamp":"2024-01-20T10:30:00Z"}`, FormatEvent, 1},
+		{"legacy request", `2024-01-20T10:30:00Z AUDIT: id="a3" ip="10.0.0.1" method="POST" user="alice" groups="\"system:authenticated\"" as="<self>" asgroups="<lookup>" namespace="default" uri="/api/v1/namespaces/default/pods/web/exec?command=sh"`, FormatLegacy, 1},
+		{"legacy response", `2024-01-20T10:30:00Z AUDIT: id="a3" response="200"`, FormatLegacy, 0},
+	}
+	for _, tt := range tests {
+		events, format, err := p.ParseAuditEvents([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: ParseAuditEvents() error = %v", tt.name, err)
+			continue
+		}
+		if format != tt.format || len(events) != tt.events {
+			t.Errorf("%s: ParseAuditEvents() = %d events in format %q, want %d in %q", tt.name, len(events), format, tt.events, tt.format)
+		}
+		for _, event := range events {
+			if !event.RequestReceivedTimestamp.Equal(received) {
+				t.Errorf("%s: event received at %v, want %v", tt.name, event.RequestReceivedTimestamp, received)
+			}
+		}
+	}
+}
+
+func TestParseAuditEvents_Legacy(t *testing.T) {
+	p := NewProcessor()
+	line := `2024-01-20T10:30:00.123456789-05:00 AUDIT: id="a3" ip="10.0.0.1" method="POST" user="admin" groups="\"system:masters\",\"system:authenticated\"" as="alice" asgroups="<lookup>" namespace="default" uri="/api/v1/namespaces/default/pods/web/exec?command=sh"`
+	events, _, err := p.ParseAuditEvents([]byte(line))
+	if err != nil || len(events) != 1 {
+		t.Fatalf("ParseAuditEvents() = %v, %v", events, err)
+	}
+	event := events[0]
+	if event.AuditID != "a3" || event.Verb != "create" || event.User.Username != "alice" || event.SourceIPs[0] != "10.0.0.1" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if len(event.User.Groups) != 2 || event.User.Groups[0] != "system:masters" {
+		t.Errorf("Groups = %q", event.User.Groups)
+	}
+
+	// Legacy exec requests are recorded from their URI
+	if !p.IsExecOperation(event) {
+		t.Fatal("Legacy exec request should be an exec operation")
+	}
+	execEvent, err := p.ExtractExecEvent(event)
+	if err != nil {
+		t.Fatalf("ExtractExecEvent() error = %v", err)
+	}
+	if execEvent.Namespace != "default" || execEvent.Name != "web" || execEvent.Actor.Username != "alice" {
+		t.Errorf("Unexpected exec event: %+v", execEvent)
+	}
+}
+
+func TestLegacyObjectRef(t *testing.T) {
+	tests := map[string]*AuditObjectRef{
+		"/api/v1/namespaces/default/pods/web/exec?command=sh": {APIVersion: "v1", Namespace: "default", Resource: "pods", Name: "web", Subresource: "exec"},
+		"/apis/apps/v1/namespaces/default/deployments/api":    {APIGroup: "apps", APIVersion: "v1", Namespace: "default", Resource: "deployments", Name: "api"},
+		"/api/v1/namespaces/default":                          {APIVersion: "v1", Resource: "namespaces", Name: "default"},
+		"/api/v1/nodes":                                       {APIVersion: "v1", Resource: "nodes"},
+		"/healthz":                                            nil,
+	}
+	for uri, want := range tests {
+		got := legacyObjectRef(uri)
+		if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+			t.Errorf("legacyObjectRef(%q) = %+v, want %+v", uri, got, want)
+		}
+	}
+}
+
+func TestParseAuditEvents_Invalid(t *testing.T) {
+	p := NewProcessor()
+	for _, data := range []string{
+		`not an audit log`,
+		`{"truncated":`,
+		`{"kind":"Event","apiVersion":"audit.k8s.io/v2","verb":"create"}`,
+		`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"web"}}`,
+		`{"message":"some other log line"}`,
+		`{"kind":"EventList","apiVersion":"audit.k8s.io/v1","items":[{"kind":"Pod"}]}`,
+		`2024-01-20T10:30:00Z AUDIT: id="a3" uri=/unquoted`,
+	} {
+		if events, _, err := p.ParseAuditEvents([]byte(data)); err == nil {
+			t.Errorf("ParseAuditEvents(%s) = %v, want an error", data, events)
+		}
+	}
+}
+
+func TestHandleAuditWebhook_EventList(t *testing.T) {
+	s := NewService(nil)
+	before := unparseableLines.Value()
+
+	body := `{"kind":"EventList","apiVersion":"audit.k8s.io/v1","items":[` + execEventJSON + `]}`
+	w := httptest.NewRecorder()
+	s.HandleAuditWebhook(w, httptest.NewRequest(http.MethodPost, "/audit", strings.NewReader(body)))
+	if w.Code != http.StatusOK || len(s.queue) != 1 {
+		t.Fatalf("Expected the exec event of the EventList to be queued, got %d with %d queued", w.Code, len(s.queue))
+	}
+
+	w = httptest.NewRecorder()
+	s.HandleAuditWebhook(w, httptest.NewRequest(http.MethodPost, "/audit", strings.NewReader(`{"message":"hello"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a body that isn't an audit log, got %d", w.Code)
+	}
+	if unparseableLines.Value() != before+1 {
+		t.Errorf("audit_unparseable_lines_total increased by %d, want 1", unparseableLines.Value()-before)
+	}
+}
+
+func TestProcessAuditLogFile_Formats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// JSON lines, with a line that can't be parsed
+		"lines.log": execEventJSON + "\n" + "garbage\n" + execEventJSON + "\n",
+		// An indented EventList
+		"list.json": "{\n  \"kind\": \"EventList\",\n  \"apiVersion\": \"audit.k8s.io/v1\",\n  \"items\": [\n" + execEventJSON + "\n  ]\n}\n",
+		// Legacy text, with the response line of the request
+		"legacy.log": `2024-01-20T10:30:00Z AUDIT: id="a3" ip="10.0.0.1" method="POST" user="alice" groups="<none>" as="<self>" asgroups="<lookup>" namespace="default" uri="/api/v1/namespaces/default/pods/web/exec?command=sh"` + "\n" +
+			`2024-01-20T10:30:00Z AUDIT: id="a3" response="200"` + "\n",
+	}
+	want := map[string]int{"lines.log": 2, "list.json": 1, "legacy.log": 1}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		s := NewService(nil)
+		if err := s.processAuditLogFile(t.Context(), path); err != nil {
+			t.Errorf("%s: processAuditLogFile() error = %v", name, err)
+		}
+		if len(s.queue) != want[name] {
+			t.Errorf("%s: %d events queued, want %d", name, len(s.queue), want[name])
+		}
+	}
+}
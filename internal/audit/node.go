@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// OperationNodeMaintenance is the operation of events recording nodes being cordoned,
+// uncordoned or drained.
+const OperationNodeMaintenance = "NODE_MAINTENANCE"
+
+// Node maintenance actions.
+const (
+	NodeActionCordon   = "cordon"
+	NodeActionUncordon = "uncordon"
+	NodeActionEvict    = "evict"
+)
+
+// drainWindow is how long after cordoning a node a user's evictions are attributed to
+// draining it, as kubectl drain cordons the node and then evicts its pods.
+const drainWindow = 30 * time.Minute
+
+// cordonTracker remembers the node each user last cordoned, so their evictions can be
+// attributed to the node being drained. Audit events of an eviction don't name the node.
+type cordonTracker struct {
+	mu      sync.Mutex
+	cordons map[string]cordon // By username
+}
+
+// cordon is a node cordoned by a user.
+type cordon struct {
+	node string
+	at   time.Time
+}
+
+// record remembers that username cordoned node at, forgetting cordons older than drainWindow.
+func (t *cordonTracker) record(username, node string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cordons == nil {
+		t.cordons = make(map[string]cordon)
+	}
+	for user, c := range t.cordons {
+		if at.Sub(c.at) > drainWindow {
+			delete(t.cordons, user)
+		}
+	}
+	t.cordons[username] = cordon{node: node, at: at}
+}
+
+// forget drops the cordon of node by username, once it is uncordoned.
+func (t *cordonTracker) forget(username, node string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.cordons[username]; ok && c.node == node {
+		delete(t.cordons, username)
+	}
+}
+
+// drainedNode returns the node username cordoned within drainWindow before at, if any.
+func (t *cordonTracker) drainedNode(username string, at time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.cordons[username]
+	if !ok || at.Before(c.at) || at.Sub(c.at) > drainWindow {
+		return ""
+	}
+	return c.node
+}
+
+// nodeMaintenanceAction returns the node maintenance action of an audit event: a patch of
+// a node's spec.unschedulable (kubectl cordon/uncordon) or a pod eviction (kubectl drain).
+// The request body is only logged at the Request audit level or above.
+func nodeMaintenanceAction(event *AuditEvent) (string, bool) {
+	ref := event.ObjectRef
+	if ref == nil {
+		return "", false
+	}
+
+	switch {
+	case ref.Resource == "pods" && ref.Subresource == "eviction" && event.Verb == "create":
+		return NodeActionEvict, true
+	case ref.Resource == "nodes" && ref.Subresource == "" && event.Verb == "patch":
+		spec, _ := event.RequestObject["spec"].(map[string]interface{})
+		unschedulable, ok := spec["unschedulable"]
+		if !ok {
+			return "", false // Not a cordon or uncordon
+		}
+		if unschedulable == true {
+			return NodeActionCordon, true
+		}
+		return NodeActionUncordon, true // kubectl uncordon patches unschedulable to null
+	}
+	return "", false
+}
+
+// IsNodeMaintenanceOperation checks if an audit event cordons or uncordons a node, or evicts a pod.
+func (p *Processor) IsNodeMaintenanceOperation(event *AuditEvent) bool {
+	_, ok := nodeMaintenanceAction(event)
+	return ok
+}
+
+// ExtractNodeMaintenanceEvent converts an audit event to a ChangeEvent for node maintenance.
+// Cordons and uncordons are recorded against the node, evictions against the pod.
+func (p *Processor) ExtractNodeMaintenanceEvent(event *AuditEvent) (*model.ChangeEvent, error) {
+	action, ok := nodeMaintenanceAction(event)
+	if !ok {
+		return nil, fmt.Errorf("not a node maintenance operation")
+	}
+
+	maintenanceEvent := &model.ChangeEvent{
+		Operation: OperationNodeMaintenance,
+		Timestamp: event.RequestReceivedTimestamp,
+		Allowed:   true,
+		Actor:     extractActor(event),
+		Source:    model.Source{Tool: p.detectSourceTool(event)},
+	}
+	maintenance := &model.NodeMaintenance{Action: action}
+
+	username := event.User.Username
+	switch action {
+	case NodeActionEvict:
+		maintenanceEvent.ResourceKind = "Pod"
+		maintenanceEvent.Namespace = event.ObjectRef.Namespace
+		maintenanceEvent.Name = event.ObjectRef.Name
+		maintenance.NodeName = p.cordons.drainedNode(username, event.RequestReceivedTimestamp)
+	case NodeActionCordon:
+		maintenanceEvent.ResourceKind = "Node"
+		maintenanceEvent.Name = event.ObjectRef.Name
+		maintenance.NodeName = event.ObjectRef.Name
+		p.cordons.record(username, event.ObjectRef.Name, event.RequestReceivedTimestamp)
+	case NodeActionUncordon:
+		maintenanceEvent.ResourceKind = "Node"
+		maintenanceEvent.Name = event.ObjectRef.Name
+		maintenance.NodeName = event.ObjectRef.Name
+		p.cordons.forget(username, event.ObjectRef.Name)
+	}
+
+	maintenanceEvent.NodeMaintenance = maintenance
+	maintenanceEvent.ID = model.NewEventID(maintenanceEvent.Timestamp)
+	return maintenanceEvent, nil
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func nodePatch(username, node string, unschedulable interface{}, at time.Time) *AuditEvent {
+	event := &AuditEvent{
+		Verb:                     "patch",
+		ObjectRef:                &AuditObjectRef{Resource: "nodes", Name: node},
+		RequestObject:            map[string]interface{}{"spec": map[string]interface{}{"unschedulable": unschedulable}},
+		RequestReceivedTimestamp: at,
+	}
+	event.User.Username = username
+	return event
+}
+
+func eviction(username, namespace, pod string, at time.Time) *AuditEvent {
+	event := &AuditEvent{
+		Verb:                     "create",
+		ObjectRef:                &AuditObjectRef{Resource: "pods", Subresource: "eviction", Namespace: namespace, Name: pod},
+		RequestReceivedTimestamp: at,
+	}
+	event.User.Username = username
+	return event
+}
+
+func TestProcessor_IsNodeMaintenanceOperation(t *testing.T) {
+	at := time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC)
+	labelPatch := nodePatch("alice", "node-1", nil, at)
+	labelPatch.RequestObject = map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"tier": "web"}}}
+
+	tests := []struct {
+		name  string
+		event *AuditEvent
+		want  bool
+	}{
+		{name: "cordon", event: nodePatch("alice", "node-1", true, at), want: true},
+		{name: "uncordon", event: nodePatch("alice", "node-1", nil, at), want: true},
+		{name: "eviction", event: eviction("alice", "default", "web-0", at), want: true},
+		{name: "node label patch", event: labelPatch},
+		{name: "pod delete", event: &AuditEvent{Verb: "delete", ObjectRef: &AuditObjectRef{Resource: "pods", Name: "web-0"}}},
+		{name: "no object", event: &AuditEvent{Verb: "patch"}},
+	}
+
+	p := NewProcessor()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.IsNodeMaintenanceOperation(tt.event); got != tt.want {
+				t.Errorf("IsNodeMaintenanceOperation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessor_ExtractNodeMaintenanceEvent_Drain(t *testing.T) {
+	p := NewProcessor()
+	at := time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC)
+
+	cordon, err := p.ExtractNodeMaintenanceEvent(nodePatch("alice", "node-1", true, at))
+	if err != nil {
+		t.Fatalf("ExtractNodeMaintenanceEvent() error = %v", err)
+	}
+	if cordon.Operation != OperationNodeMaintenance || cordon.ResourceKind != "Node" || cordon.Name != "node-1" {
+		t.Errorf("Unexpected cordon event: %+v", cordon)
+	}
+	if cordon.NodeMaintenance.Action != NodeActionCordon || cordon.NodeMaintenance.NodeName != "node-1" {
+		t.Errorf("Unexpected cordon: %+v", cordon.NodeMaintenance)
+	}
+
+	// Evictions by the same user are attributed to the drained node
+	evict, err := p.ExtractNodeMaintenanceEvent(eviction("alice", "default", "web-0", at.Add(5*time.Second)))
+	if err != nil {
+		t.Fatalf("ExtractNodeMaintenanceEvent() error = %v", err)
+	}
+	if evict.ResourceKind != "Pod" || evict.Namespace != "default" || evict.Name != "web-0" {
+		t.Errorf("Unexpected eviction event: %+v", evict)
+	}
+	if evict.NodeMaintenance.Action != NodeActionEvict || evict.NodeMaintenance.NodeName != "node-1" {
+		t.Errorf("Unexpected eviction: %+v", evict.NodeMaintenance)
+	}
+	if evict.ID == cordon.ID {
+		t.Errorf("Events share ID %s", evict.ID)
+	}
+
+	// Not by other users, or after the drain window
+	evict, _ = p.ExtractNodeMaintenanceEvent(eviction("bob", "default", "web-1", at.Add(5*time.Second)))
+	if evict.NodeMaintenance.NodeName != "" {
+		t.Errorf("Eviction by another user attributed to %s", evict.NodeMaintenance.NodeName)
+	}
+	evict, _ = p.ExtractNodeMaintenanceEvent(eviction("alice", "default", "web-1", at.Add(drainWindow+time.Second)))
+	if evict.NodeMaintenance.NodeName != "" {
+		t.Errorf("Eviction after the drain window attributed to %s", evict.NodeMaintenance.NodeName)
+	}
+
+	// Nor once the node is uncordoned
+	uncordon, _ := p.ExtractNodeMaintenanceEvent(nodePatch("alice", "node-1", nil, at.Add(time.Minute)))
+	if uncordon.NodeMaintenance.Action != NodeActionUncordon || uncordon.NodeMaintenance.NodeName != "node-1" {
+		t.Errorf("Unexpected uncordon: %+v", uncordon.NodeMaintenance)
+	}
+	evict, _ = p.ExtractNodeMaintenanceEvent(eviction("alice", "default", "web-2", at.Add(2*time.Minute)))
+	if evict.NodeMaintenance.NodeName != "" {
+		t.Errorf("Eviction after uncordon attributed to %s", evict.NodeMaintenance.NodeName)
+	}
+}
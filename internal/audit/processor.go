@@ -24,6 +24,14 @@ type AuditEvent struct {
 	RequestObject map[string]interface{} `json:"requestObject,omitempty"`
 	ResponseStatus *AuditResponseStatus `json:"responseStatus,omitempty"`
 	RequestReceivedTimestamp time.Time `json:"requestReceivedTimestamp"`
+
+	// Kind and APIVersion identify audit events that are self-describing (Kind "Event")
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Timestamp is the time of audit.k8s.io/v1alpha1 events, which have no
+	// requestReceivedTimestamp (and of v1beta1 events, which have both)
+	Timestamp time.Time `json:"timestamp,omitempty"`
 }
 
 // AuditUser represents user information in audit logs.
@@ -48,24 +56,32 @@ type AuditResponseStatus struct {
 	Code int `json:"code"`
 }
 
-// Processor processes Kubernetes audit logs and extracts exec operations.
-type Processor struct{}
+// Processor processes Kubernetes audit logs and extracts exec, node maintenance and
+// credential issuance operations.
+type Processor struct {
+	cordons cordonTracker
+}
 
 // NewProcessor creates a new audit log processor.
 func NewProcessor() *Processor {
 	return &Processor{}
 }
 
-// ParseAuditLog parses a single audit log line and returns an AuditEvent.
+// ParseAuditLog parses a single audit event in JSON and returns an AuditEvent. Events of
+// older audit.k8s.io versions are converted to the current one; see ParseAuditEvents for
+// the other formats.
 func (p *Processor) ParseAuditLog(line []byte) (*AuditEvent, error) {
 	var event AuditEvent
 	if err := json.Unmarshal(line, &event); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal audit log: %w", err)
 	}
+	if err := normalizeEvent(&event); err != nil {
+		return nil, err
+	}
 	return &event, nil
 }
 
-// IsExecOperation checks if an audit event represents an exec operation.
+// IsExecOperation checks if an audit event represents an exec or attach operation.
 func (p *Processor) IsExecOperation(event *AuditEvent) bool {
 	if event.ObjectRef == nil {
 		return false
@@ -73,12 +89,13 @@ func (p *Processor) IsExecOperation(event *AuditEvent) bool {
 
 	// Check for pod exec: /api/v1/namespaces/{namespace}/pods/{name}/exec
 	// Check for node exec: /api/v1/nodes/{name}/proxy/exec
-	if event.ObjectRef.Subresource == "exec" {
+	// Check for pod attach: /api/v1/namespaces/{namespace}/pods/{name}/attach
+	if event.ObjectRef.Subresource == "exec" || event.ObjectRef.Subresource == "attach" {
 		return true
 	}
 
 	// Also check requestURI for exec patterns
-	if strings.Contains(event.RequestURI, "/exec") {
+	if strings.Contains(event.RequestURI, "/exec") || strings.Contains(event.RequestURI, "/attach") {
 		return true
 	}
 
@@ -99,19 +116,7 @@ func (p *Processor) ExtractExecEvent(event *AuditEvent) (*model.ChangeEvent, err
 	}
 
 	// Extract actor information
-	execEvent.Actor = model.Actor{
-		Username: event.User.Username,
-		Groups:   event.User.Groups,
-	}
-
-	if len(event.SourceIPs) > 0 {
-		execEvent.Actor.SourceIP = event.SourceIPs[0]
-	}
-
-	// Check if username is a service account
-	if strings.HasPrefix(event.User.Username, "system:serviceaccount") {
-		execEvent.Actor.ServiceAccount = event.User.Username
-	}
+	execEvent.Actor = extractActor(event)
 
 	// Extract resource information
 	if event.ObjectRef != nil {
@@ -128,9 +133,13 @@ func (p *Processor) ExtractExecEvent(event *AuditEvent) (*model.ChangeEvent, err
 
 	// Extract exec metadata
 	execMetadata := &model.ExecMetadata{
-		TargetType: "pod",
-		Stdin:      false,
-		TTY:        false,
+		TargetType:  "pod",
+		Stdin:       false,
+		TTY:         false,
+		Subresource: "exec",
+	}
+	if (event.ObjectRef != nil && event.ObjectRef.Subresource == "attach") || strings.Contains(event.RequestURI, "/attach") {
+		execMetadata.Subresource = "attach"
 	}
 
 	// Determine if this is a node exec or pod exec
@@ -185,7 +194,7 @@ func (p *Processor) ExtractExecEvent(event *AuditEvent) (*model.ChangeEvent, err
 	}
 
 	// Generate event ID
-	execEvent.ID = p.generateEventID(execEvent)
+	execEvent.ID = model.NewEventID(execEvent.Timestamp)
 
 	return execEvent, nil
 }
@@ -273,12 +282,20 @@ func (p *Processor) detectSourceTool(event *AuditEvent) string {
 	return "unknown"
 }
 
-// generateEventID generates a unique ID for an exec event.
-func (p *Processor) generateEventID(event *model.ChangeEvent) string {
-	return fmt.Sprintf("EXEC-%s-%s-%s-%d",
-		event.ResourceKind,
-		event.Name,
-		event.Actor.Username,
-		event.Timestamp.UnixNano(),
-	)
+// extractActor extracts the user who made the request of an audit event.
+func extractActor(event *AuditEvent) model.Actor {
+	actor := model.Actor{
+		Username: event.User.Username,
+		Groups:   event.User.Groups,
+	}
+
+	if len(event.SourceIPs) > 0 {
+		actor.SourceIP = event.SourceIPs[0]
+	}
+
+	// Check if username is a service account
+	if strings.HasPrefix(event.User.Username, "system:serviceaccount") {
+		actor.ServiceAccount = event.User.Username
+	}
+	return actor
 }
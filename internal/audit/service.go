@@ -2,8 +2,11 @@ package audit
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,98 +17,225 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
 	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
 	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
 )
 
 // Service processes Kubernetes audit logs and stores exec events.
 type Service struct {
-	processor *Processor
-	store     store.Store
-	queue     chan *model.ChangeEvent
+	processor     *Processor
+	store         store.Store
+	alertRouter   *alerting.Router         // Alerts on exec events matching its exec rules; nil disables alerting
+	riskScorer    *RiskScorer              // Scores exec commands; nil leaves events unscored
+	heartbeat     *heartbeat.Emitter       // Heartbeats queued periodically; nil queues none
+	pseudonymizer *pseudonym.Pseudonymizer // Pseudonymizes actors before events are saved or alerted on; nil keeps them
+	queue         chan *model.ChangeEvent
+	maxBodySize   int64 // Webhook request bodies larger than this (bytes) are rejected with 413; 0 disables
 }
 
-// NewService creates a new audit log service.
+// DefaultMaxRequestSize is the default limit for audit webhook request bodies (32MiB).
+// The API server batches audit events, so batches are larger than admission requests.
+const DefaultMaxRequestSize = 32 * 1024 * 1024
+
+// oversizedRequests counts audit webhook requests rejected because their body exceeded the size limit.
+var oversizedRequests = expvar.NewInt("audit_oversized_requests_total")
+
+// unpseudonymizedEvents counts events dropped because their actor couldn't be pseudonymized.
+var unpseudonymizedEvents = expvar.NewInt("audit_unpseudonymized_events_total")
+
+// NewService creates a new audit log service. Exec commands are scored with the built-in risk rules.
 func NewService(store store.Store) *Service {
+	riskScorer, _ := NewRiskScorer(nil) // The built-in rules are valid
 	return &Service{
-		processor: NewProcessor(),
-		store:     store,
-		queue:     make(chan *model.ChangeEvent, 1000), // Buffered channel for async processing
+		processor:   NewProcessor(),
+		store:       store,
+		riskScorer:  riskScorer,
+		queue:       make(chan *model.ChangeEvent, 1000), // Buffered channel for async processing
+		maxBodySize: DefaultMaxRequestSize,
 	}
 }
 
-// Start starts the async event processing worker.
+// SetMaxRequestSize sets the maximum audit webhook request body size in bytes (0 disables the limit).
+func (s *Service) SetMaxRequestSize(size int64) {
+	s.maxBodySize = size
+}
+
+// SetAlertRouter sets the router exec events are alerted through.
+func (s *Service) SetAlertRouter(router *alerting.Router) {
+	s.alertRouter = router
+}
+
+// SetRiskScorer sets the scorer for exec commands, replacing the built-in rules.
+func (s *Service) SetRiskScorer(scorer *RiskScorer) {
+	s.riskScorer = scorer
+}
+
+// SetHeartbeat sets the emitter of the heartbeats queued with the events extracted from
+// audit logs, so their delivery is measured end to end. Must be called before Start.
+func (s *Service) SetHeartbeat(emitter *heartbeat.Emitter) {
+	s.heartbeat = emitter
+}
+
+// SetPseudonymizer pseudonymizes the actors of events before they are saved or alerted on,
+// so alerts don't see the values when the store fails. Must be called before Start.
+func (s *Service) SetPseudonymizer(p *pseudonym.Pseudonymizer) {
+	s.pseudonymizer = p
+}
+
+// Start starts the async event processing worker, and queues heartbeats if set.
 func (s *Service) Start(ctx context.Context) {
 	go s.processEvents(ctx)
+	s.heartbeat.Start(ctx, func(event *model.ChangeEvent) {
+		select {
+		case s.queue <- event:
+		default:
+			klog.Warningf("Event queue full, dropping heartbeat event: %s", event.ID)
+		}
+	})
 }
 
-// processEvents processes exec events asynchronously.
+// processEvents processes events extracted from audit logs asynchronously.
 func (s *Service) processEvents(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case event := <-s.queue:
+			// Events whose actor can't be pseudonymized are dropped rather than kept with it
+			if s.pseudonymizer != nil {
+				if err := s.pseudonymizer.Apply(event); err != nil {
+					unpseudonymizedEvents.Add(1)
+					klog.Errorf("Failed to pseudonymize event %s, dropping it: %v", event.ID, err)
+					continue
+				}
+			}
+
 			// Save to store
 			if s.store != nil {
-				if err := s.store.Save(event); err != nil {
-					klog.Errorf("Failed to save exec event %s: %v", event.ID, err)
+				err := s.store.Save(event)
+				if errors.Is(err, store.ErrDuplicate) {
+					// A line read again, already saved and alerted on
+					continue
+				}
+				if err != nil {
+					klog.Errorf("Failed to save event %s: %v", event.ID, err)
 				} else {
-					klog.Infof("Saved exec event %s: EXEC %s/%s in namespace %s (user: %s)",
-						event.ID, event.ResourceKind, event.Name, event.Namespace, event.Actor.Username)
+					klog.Infof("Saved event %s: %s %s/%s in namespace %s (user: %s)",
+						event.ID, event.Operation, event.ResourceKind, event.Name, event.Namespace, event.Actor.Username)
 				}
 			} else {
-				klog.V(2).Infof("Exec event (no store): %+v", event)
+				klog.V(2).Infof("Event (no store): %+v", event)
 			}
+
+			// Send alerts for exec events matching the exec rules, and other
+			// events matching the alerted operations
+			s.alertRouter.Send(event)
 		}
 	}
 }
 
-// ProcessAuditLogLine processes a single audit log line.
+// ProcessAuditLogLine processes a line of an audit log, which may hold an event, an
+// EventList or a legacy text event (see ParseAuditEvents). Lines that can't be parsed are
+// counted and skipped.
 func (s *Service) ProcessAuditLogLine(line []byte) error {
-	// Skip empty lines
-	lineStr := strings.TrimSpace(string(line))
-	if lineStr == "" {
+	if len(bytes.TrimSpace(line)) == 0 {
 		return nil
 	}
-
-	// Parse audit event
-	auditEvent, err := s.processor.ParseAuditLog(line)
+	events, err := s.parseAuditEvents(line)
 	if err != nil {
-		klog.V(4).Infof("Failed to parse audit log line: %v", err)
+		klog.V(2).Infof("Skipping unparseable audit log line: %v", err)
 		return nil // Skip invalid lines
 	}
+	for _, auditEvent := range events {
+		s.processAuditEvent(auditEvent)
+	}
+	return nil
+}
 
-	// Check if it's an exec operation
-	if !s.processor.IsExecOperation(auditEvent) {
-		return nil // Not an exec operation, skip
+// parseAuditEvents parses a line or document of an audit log, counting its events by
+// format, or counting it as unparseable.
+func (s *Service) parseAuditEvents(data []byte) ([]*AuditEvent, error) {
+	events, format, err := s.processor.ParseAuditEvents(data)
+	if err != nil {
+		unparseableLines.Add(1)
+		return nil, err
 	}
+	parsedAuditEvents.Add(int64(len(events)))
+	switch format {
+	case FormatEventList:
+		auditEventListDocs.Add(1)
+	case FormatLegacy:
+		legacyAuditEvents.Add(int64(len(events)))
+	}
+	return events, nil
+}
 
-	// Only process successful exec operations (response code 200-299)
-	if auditEvent.ResponseStatus != nil && auditEvent.ResponseStatus.Code < 200 || auditEvent.ResponseStatus.Code >= 300 {
-		klog.V(3).Infof("Skipping exec operation with non-success status code: %d", auditEvent.ResponseStatus.Code)
-		return nil
+// processAuditEvent queues the event an audit event is recorded as, if any.
+func (s *Service) processAuditEvent(auditEvent *AuditEvent) {
+	// Only exec, node maintenance and credential issuance operations are recorded
+	extract := s.extractor(auditEvent)
+	if extract == nil {
+		return
+	}
+
+	// Only process successful operations (response code 200-299)
+	if !succeeded(auditEvent) {
+		klog.V(3).Infof("Skipping %s %s with non-success status code: %d", auditEvent.Verb, auditEvent.RequestURI, auditEvent.ResponseStatus.Code)
+		return
 	}
 
-	// Extract exec event
-	execEvent, err := s.processor.ExtractExecEvent(auditEvent)
+	changeEvent, err := extract(auditEvent)
 	if err != nil {
-		klog.V(3).Infof("Failed to extract exec event: %v", err)
-		return nil
+		klog.V(3).Infof("Failed to extract event: %v", err)
+		return
 	}
 
 	// Queue for async processing (non-blocking)
 	select {
-	case s.queue <- execEvent:
+	case s.queue <- changeEvent:
 		// Successfully queued
 	default:
 		// Queue full, log warning but don't block
-		klog.Warningf("Event queue full, dropping exec event: %s", execEvent.ID)
+		klog.Warningf("Event queue full, dropping event: %s", changeEvent.ID)
 	}
+}
 
+// extractor returns the function converting an audit event to the event it is recorded
+// as, or nil if it isn't recorded.
+func (s *Service) extractor(event *AuditEvent) func(*AuditEvent) (*model.ChangeEvent, error) {
+	switch {
+	case s.processor.IsExecOperation(event):
+		return s.extractExecEvent
+	case s.processor.IsNodeMaintenanceOperation(event):
+		return s.processor.ExtractNodeMaintenanceEvent
+	case s.processor.IsCredentialIssuanceOperation(event):
+		return s.processor.ExtractCredentialIssuanceEvent
+	}
 	return nil
 }
 
+// extractExecEvent converts an audit event to an exec event scored for risk.
+func (s *Service) extractExecEvent(event *AuditEvent) (*model.ChangeEvent, error) {
+	execEvent, err := s.processor.ExtractExecEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	s.riskScorer.Score(execEvent)
+	return execEvent, nil
+}
+
+// succeeded reports whether the request of an audit event succeeded. Events without a
+// response status, logged before the response was sent, are assumed to have succeeded.
+func succeeded(event *AuditEvent) bool {
+	if event.ResponseStatus == nil {
+		return true
+	}
+	return event.ResponseStatus.Code >= 200 && event.ResponseStatus.Code < 300
+}
+
 // WatchAuditLogFile watches an audit log file and processes new lines.
 func (s *Service) WatchAuditLogFile(ctx context.Context, filePath string) error {
 	// Check if file exists
@@ -126,6 +256,7 @@ func (s *Service) WatchAuditLogFile(ctx context.Context, filePath string) error
 	}
 
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLineSize)
 	ticker := time.NewTicker(100 * time.Millisecond) // Check for new lines every 100ms
 	defer ticker.Stop()
 
@@ -200,7 +331,13 @@ func (s *Service) WatchAuditLogDirectory(ctx context.Context, dirPath string) er
 	}
 }
 
-// processAuditLogFile processes an entire audit log file.
+// maxAuditLineSize is the longest audit log line read; EventLists and events with request
+// objects exceed bufio's default.
+const maxAuditLineSize = 16 * 1024 * 1024
+
+// processAuditLogFile processes an entire audit log file: one event, EventList or legacy
+// text event per line, or JSON documents (such as a saved, indented EventList) spanning
+// several lines.
 func (s *Service) processAuditLogFile(ctx context.Context, filePath string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -208,7 +345,19 @@ func (s *Service) processAuditLogFile(ctx context.Context, filePath string) erro
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	// A first line that starts JSON but isn't complete JSON starts a multi-line document
+	reader := bufio.NewReader(file)
+	firstLine, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("error reading audit log file: %w", err)
+	}
+	content := io.MultiReader(bytes.NewReader(firstLine), reader)
+	if trimmed := bytes.TrimSpace(firstLine); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && !json.Valid(trimmed) {
+		return s.processAuditLogDocuments(ctx, filePath, content)
+	}
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLineSize)
 	lineCount := 0
 
 	klog.Infof("Processing audit log file: %s", filePath)
@@ -235,6 +384,40 @@ func (s *Service) processAuditLogFile(ctx context.Context, filePath string) erro
 	return nil
 }
 
+// processAuditLogDocuments processes an audit log file of JSON documents, each an event,
+// an EventList or an array of events. Processing stops at the first invalid document, as
+// the documents after it can't be found.
+func (s *Service) processAuditLogDocuments(ctx context.Context, filePath string, r io.Reader) error {
+	klog.Infof("Processing audit log file of JSON documents: %s", filePath)
+
+	decoder := json.NewDecoder(r)
+	documentCount := 0
+	for decoder.More() {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		var document json.RawMessage
+		if err := decoder.Decode(&document); err != nil {
+			unparseableLines.Add(1)
+			return fmt.Errorf("invalid JSON after %d documents: %w", documentCount, err)
+		}
+		events, err := s.parseAuditEvents(document)
+		if err != nil {
+			klog.Warningf("Skipping unparseable document %d of audit log file %s: %v", documentCount+1, filePath, err)
+		}
+		for _, event := range events {
+			s.processAuditEvent(event)
+		}
+		documentCount++
+	}
+
+	klog.Infof("Processed %d documents from audit log file: %s", documentCount, filePath)
+	return nil
+}
+
 // HandleAuditWebhook handles incoming audit log events via HTTP webhook.
 func (s *Service) HandleAuditWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -242,28 +425,34 @@ func (s *Service) HandleAuditWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Bound the body so oversized batches cannot exhaust the processor's memory
+	if s.maxBodySize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+	}
 	body, err := io.ReadAll(r.Body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		oversizedRequests.Add(1)
+		klog.Warningf("Rejecting audit webhook request from %s: body exceeds %d bytes", r.RemoteAddr, maxBytesErr.Limit)
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
 
-	// Parse as JSON array (audit logs can be batched)
-	var events []json.RawMessage
-	if err := json.Unmarshal(body, &events); err != nil {
-		// Try parsing as single event
-		if err := s.ProcessAuditLogLine(body); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to process audit log: %v", err), http.StatusBadRequest)
-			return
-		}
-	} else {
-		// Process each event in the batch
-		for _, eventData := range events {
-			if err := s.ProcessAuditLogLine(eventData); err != nil {
-				klog.V(3).Infof("Error processing audit log event: %v", err)
-			}
-		}
+	// The webhook backend sends batches of events as an EventList; a single event or a JSON
+	// array of events are accepted too
+	events, err := s.parseAuditEvents(body)
+	if err != nil {
+		klog.Warningf("Rejecting audit webhook request from %s: %v", r.RemoteAddr, err)
+		http.Error(w, fmt.Sprintf("Failed to process audit log: %v", err), http.StatusBadRequest)
+		return
+	}
+	for _, event := range events {
+		s.processAuditEvent(event)
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -127,8 +127,11 @@ func (a *Authenticator) ValidateToken(tokenString string) (*User, error) {
 func (a *Authenticator) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip auth for health check and login endpoints
-			if r.URL.Path == "/health" || r.URL.Path == "/kubechronicle/api/auth/login" {
+			// Skip auth for health check, version and login endpoints, and for CI/CD
+			// webhooks, which are authenticated by their own secrets
+			if r.URL.Path == "/health" || r.URL.Path == "/version" || r.URL.Path == "/kubechronicle/api/version" ||
+				r.URL.Path == "/kubechronicle/api/auth/login" ||
+				strings.HasPrefix(r.URL.Path, "/kubechronicle/api/webhooks/") {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -238,6 +238,16 @@ func TestMiddleware_SkipHealthAndLogin(t *testing.T) {
 		t.Errorf("Health endpoint should be accessible, got %d", w.Code)
 	}
 
+	// Test version endpoints
+	for _, path := range []string{"/version", "/kubechronicle/api/version"} {
+		req = httptest.NewRequest("GET", path, nil)
+		w = httptest.NewRecorder()
+		wrapped.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Version endpoint %s should be accessible, got %d", path, w.Code)
+		}
+	}
+
 	// Test login endpoint
 	req = httptest.NewRequest("POST", "/kubechronicle/api/auth/login", nil)
 	w = httptest.NewRecorder()
@@ -245,6 +255,14 @@ func TestMiddleware_SkipHealthAndLogin(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("Login endpoint should be accessible, got %d", w.Code)
 	}
+
+	// Test CI/CD webhook endpoints
+	req = httptest.NewRequest("POST", "/kubechronicle/api/webhooks/github", nil)
+	w = httptest.NewRecorder()
+	wrapped.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Webhook endpoint should be accessible, got %d", w.Code)
+	}
 }
 
 func TestRequireRole(t *testing.T) {
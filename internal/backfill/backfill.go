@@ -0,0 +1,108 @@
+// Package backfill imports change events from NDJSON exports, one JSON event per line, for
+// migrations from another cluster or an older kubechronicle and for disaster recovery.
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// DefaultBatchSize is the number of events loaded per batch when none is configured.
+const DefaultBatchSize = 1000
+
+// maxLineSize is the longest line read; events with large snapshots exceed bufio's default.
+const maxLineSize = 64 << 20
+
+// Store bulk loads events, skipping those already stored, and returns the number loaded.
+type Store interface {
+	ImportEvents(ctx context.Context, events []*model.ChangeEvent) (int, error)
+}
+
+// Options controls an import.
+type Options struct {
+	BatchSize int                  // Events loaded per batch; 0 uses DefaultBatchSize
+	Progress  func(result *Result) // Called after every batch, e.g. to report progress
+}
+
+// Result summarizes an import.
+type Result struct {
+	Lines      int           `json:"lines"`      // Non-empty lines read
+	Imported   int           `json:"imported"`   // Events loaded into the store
+	Duplicates int           `json:"duplicates"` // Events skipped because their ID was already stored
+	Duration   time.Duration `json:"-"`
+}
+
+// Import reads NDJSON events from r and loads them into s in batches. Events already stored
+// are counted as duplicates, so an interrupted import can be re-run. It stops at the first
+// invalid line or failed batch; the batches loaded before it stay loaded.
+func Import(ctx context.Context, s Store, r io.Reader, opts Options) (*Result, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	start := time.Now()
+	result := &Result{}
+	batch := make([]*model.ChangeEvent, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		imported, err := s.ImportEvents(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.Imported += imported
+		result.Duplicates += len(batch) - imported
+		result.Duration = time.Since(start)
+		batch = batch[:0]
+		if opts.Progress != nil {
+			opts.Progress(result)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.Lines++
+
+		var event model.ChangeEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return result, fmt.Errorf("line %d: invalid event: %w", lineNumber, err)
+		}
+		if event.ID == "" {
+			return result, fmt.Errorf("line %d: event has no id", lineNumber)
+		}
+		batch = append(batch, &event)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, fmt.Errorf("failed to import events up to line %d: %w", lineNumber, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("line %d: %w", lineNumber+1, err)
+	}
+	if err := flush(); err != nil {
+		return result, fmt.Errorf("failed to import events up to line %d: %w", lineNumber, err)
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
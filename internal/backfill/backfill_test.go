@@ -0,0 +1,141 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// memoryStore keeps imported events by ID, skipping IDs it already has.
+type memoryStore struct {
+	events  map[string]*model.ChangeEvent
+	batches []int
+	failAt  int // Fails the batch with this number (1-based) when set
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{events: map[string]*model.ChangeEvent{}}
+}
+
+func (s *memoryStore) ImportEvents(ctx context.Context, events []*model.ChangeEvent) (int, error) {
+	s.batches = append(s.batches, len(events))
+	if s.failAt == len(s.batches) {
+		return 0, fmt.Errorf("database unavailable")
+	}
+	imported := 0
+	for _, event := range events {
+		if _, ok := s.events[event.ID]; !ok {
+			s.events[event.ID] = event
+			imported++
+		}
+	}
+	return imported, nil
+}
+
+// ndjson returns n events with IDs e0, e1, ... one per line.
+func ndjson(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `{"id":"e%d","operation":"CREATE","resource_kind":"ConfigMap","namespace":"default","name":"cm%d"}`+"\n", i, i)
+	}
+	return b.String()
+}
+
+func TestImportBatches(t *testing.T) {
+	s := newMemoryStore()
+	var progress []int
+	result, err := Import(context.Background(), s, strings.NewReader(ndjson(5)), Options{
+		BatchSize: 2,
+		Progress:  func(r *Result) { progress = append(progress, r.Imported) },
+	})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Lines != 5 || result.Imported != 5 || result.Duplicates != 0 {
+		t.Errorf("result = %+v, want 5 lines, 5 imported", result)
+	}
+	if fmt.Sprint(s.batches) != "[2 2 1]" {
+		t.Errorf("batches = %v, want [2 2 1]", s.batches)
+	}
+	if fmt.Sprint(progress) != "[2 4 5]" {
+		t.Errorf("progress = %v, want [2 4 5]", progress)
+	}
+	if event := s.events["e3"]; event == nil || event.Name != "cm3" || event.ResourceKind != "ConfigMap" {
+		t.Errorf("event e3 = %+v, want the decoded event", event)
+	}
+}
+
+func TestImportCountsDuplicates(t *testing.T) {
+	s := newMemoryStore()
+	if _, err := Import(context.Background(), s, strings.NewReader(ndjson(3)), Options{}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	// Re-running an import, e.g. after an interruption, only loads the missing events
+	result, err := Import(context.Background(), s, strings.NewReader(ndjson(5)), Options{})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Imported != 2 || result.Duplicates != 3 {
+		t.Errorf("result = %+v, want 2 imported, 3 duplicates", result)
+	}
+}
+
+func TestImportSkipsBlankLines(t *testing.T) {
+	s := newMemoryStore()
+	input := "\n" + strings.ReplaceAll(ndjson(2), "\n", "\r\n") + "  \n"
+	result, err := Import(context.Background(), s, strings.NewReader(input), Options{})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Lines != 2 || result.Imported != 2 {
+		t.Errorf("result = %+v, want 2 lines, 2 imported", result)
+	}
+}
+
+func TestImportInvalidLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"invalid JSON", ndjson(2) + "{not json\n", "line 3: invalid event"},
+		{"missing id", ndjson(1) + `{"operation":"CREATE"}` + "\n", "line 2: event has no id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMemoryStore()
+			result, err := Import(context.Background(), s, strings.NewReader(tt.input), Options{BatchSize: 1})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Import() error = %v, want %q", err, tt.want)
+			}
+			// Batches before the invalid line are kept
+			if result.Imported != len(s.events) || result.Imported == 0 {
+				t.Errorf("result = %+v, want the events before the invalid line imported", result)
+			}
+		})
+	}
+}
+
+func TestImportStoreFailure(t *testing.T) {
+	s := newMemoryStore()
+	s.failAt = 2
+	result, err := Import(context.Background(), s, strings.NewReader(ndjson(5)), Options{BatchSize: 2})
+	if err == nil || !strings.Contains(err.Error(), "up to line 4") {
+		t.Fatalf("Import() error = %v, want failure up to line 4", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("Imported = %d, want 2", result.Imported)
+	}
+}
+
+func TestImportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Import(ctx, newMemoryStore(), strings.NewReader(ndjson(1)), Options{}); err != context.Canceled {
+		t.Errorf("Import() error = %v, want context.Canceled", err)
+	}
+}
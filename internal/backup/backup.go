@@ -0,0 +1,455 @@
+// Package backup dumps change events and the state kubechronicle keeps in the database (the
+// retention, legal holds, subscriptions, the pseudonyms of actors and the erasure audit
+// log) to a portable NDJSON file, and restores them,
+// independently of the database version and schema, unlike pg_dump. Restores can be
+// limited to a time range of events.
+//
+// A backup is one JSON record per line: a header, then the configuration, then the events
+// oldest first:
+//
+//	{"kind":"header","header":{"version":1,"created_at":"2026-01-01T00:00:00Z"}}
+//	{"kind":"retention","retention":{"days":90,"updated_at":"2025-12-01T00:00:00Z"}}
+//	{"kind":"legal_hold","legal_hold":{"reason":"INC-42","filters":{"namespace":"payments"}}}
+//	{"kind":"event","event":{"id":"019b7763-9400-7c3e-9a41-5f0e2d8b6c17", ...}}
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/backfill"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Version is the version of the backup format written.
+const Version = 1
+
+// maxLineSize is the longest record read; events with large snapshots exceed bufio's default.
+const maxLineSize = 64 << 20
+
+// Kinds of backup records.
+const (
+	KindHeader       = "header"
+	KindEvent        = "event"
+	KindLegalHold    = "legal_hold"
+	KindSubscription = "subscription"
+	KindRetention    = "retention"
+	KindPseudonym    = "pseudonym"
+	KindErasure      = "erasure"
+)
+
+// Record is a line of a backup. Exactly one of the fields other than Kind is set.
+type Record struct {
+	Kind         string                 `json:"kind"`
+	Header       *Header                `json:"header,omitempty"`
+	Event        *model.ChangeEvent     `json:"event,omitempty"`
+	LegalHold    *store.LegalHold       `json:"legal_hold,omitempty"`
+	Subscription *store.Subscription    `json:"subscription,omitempty"`
+	Retention    *store.RetentionPolicy `json:"retention,omitempty"`
+	Pseudonym    *store.Pseudonym       `json:"pseudonym,omitempty"`
+	Erasure      *store.ErasureRecord   `json:"erasure,omitempty"`
+}
+
+// Header describes a backup.
+type Header struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	Since     *time.Time `json:"since,omitempty"` // Events before Since were left out
+	Until     *time.Time `json:"until,omitempty"` // Events after Until were left out
+}
+
+// Source is a store backups are read from.
+type Source interface {
+	StreamEvents(ctx context.Context, filters store.QueryFilters, fn func(*model.ChangeEvent) error) error
+	ListLegalHolds(ctx context.Context, includeReleased bool) ([]*store.LegalHold, error)
+	ListSubscriptions(ctx context.Context) ([]*store.Subscription, error)
+	GetRetentionPolicy(ctx context.Context) (*store.RetentionPolicy, error)
+	ListPseudonyms(ctx context.Context) ([]*store.Pseudonym, error)
+	ListErasures(ctx context.Context) ([]*store.ErasureRecord, error)
+}
+
+// Target is a store backups are restored to.
+type Target interface {
+	backfill.Store
+	ListLegalHolds(ctx context.Context, includeReleased bool) ([]*store.LegalHold, error)
+	CreateLegalHold(ctx context.Context, hold *store.LegalHold) error
+	ListSubscriptions(ctx context.Context) ([]*store.Subscription, error)
+	CreateSubscription(ctx context.Context, sub *store.Subscription) error
+	GetRetentionPolicy(ctx context.Context) (*store.RetentionPolicy, error)
+	SetRetentionPolicy(ctx context.Context, policy *store.RetentionPolicy) error
+	ListPseudonyms(ctx context.Context) ([]*store.Pseudonym, error)
+	SavePseudonyms(ctx context.Context, pseudonyms []*store.Pseudonym) error
+	ListErasures(ctx context.Context) ([]*store.ErasureRecord, error)
+	RestoreErasure(ctx context.Context, record *store.ErasureRecord) error
+}
+
+// Options selects what is backed up or restored.
+type Options struct {
+	Since, Until *time.Time // Time range of the events; nil leaves it open
+	EventsOnly   bool       // Leave out the state other than events
+	BatchSize    int        // Events restored per batch; 0 uses backfill.DefaultBatchSize
+}
+
+// Result summarizes a backup or restore. Duplicates are records restored before.
+type Result struct {
+	Events        int `json:"events"`
+	LegalHolds    int `json:"legal_holds"`
+	Subscriptions int `json:"subscriptions"`
+	Retention     int `json:"retention"` // 1 if the retention set through the API was
+	Pseudonyms    int `json:"pseudonyms"`
+	Erasures      int `json:"erasures"`
+	Duplicates    int `json:"duplicates,omitempty"`
+	Skipped       int `json:"skipped,omitempty"` // Records outside the time range or not selected
+}
+
+// Backup writes the events of the time range of opts, and unless opts.EventsOnly the
+// retention, the active legal holds, the subscriptions, the pseudonyms and the erasure log,
+// to w. Subscriptions include their secrets and pseudonyms the values they replaced, which
+// are decrypted like the events' payloads.
+func Backup(ctx context.Context, s Source, w io.Writer, opts Options) (*Result, error) {
+	out := bufio.NewWriter(w)
+	encoder := json.NewEncoder(out)
+	result := &Result{}
+
+	header := &Header{Version: Version, CreatedAt: time.Now().UTC(), Since: opts.Since, Until: opts.Until}
+	if err := encoder.Encode(Record{Kind: KindHeader, Header: header}); err != nil {
+		return result, err
+	}
+
+	if !opts.EventsOnly {
+		policy, err := s.GetRetentionPolicy(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to get retention: %w", err)
+		}
+		if policy != nil {
+			if err := encoder.Encode(Record{Kind: KindRetention, Retention: policy}); err != nil {
+				return result, err
+			}
+			result.Retention++
+		}
+
+		holds, err := s.ListLegalHolds(ctx, false)
+		if err != nil {
+			return result, fmt.Errorf("failed to list legal holds: %w", err)
+		}
+		for _, hold := range holds {
+			if err := encoder.Encode(Record{Kind: KindLegalHold, LegalHold: hold}); err != nil {
+				return result, err
+			}
+			result.LegalHolds++
+		}
+
+		subs, err := s.ListSubscriptions(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+		for _, sub := range subs {
+			if err := encoder.Encode(Record{Kind: KindSubscription, Subscription: sub}); err != nil {
+				return result, err
+			}
+			result.Subscriptions++
+		}
+
+		pseudonyms, err := s.ListPseudonyms(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list pseudonyms: %w", err)
+		}
+		for _, p := range pseudonyms {
+			if err := encoder.Encode(Record{Kind: KindPseudonym, Pseudonym: p}); err != nil {
+				return result, err
+			}
+			result.Pseudonyms++
+		}
+
+		erasures, err := s.ListErasures(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to list erasures: %w", err)
+		}
+		// Oldest first, so they are restored in order
+		for n := len(erasures) - 1; n >= 0; n-- {
+			if err := encoder.Encode(Record{Kind: KindErasure, Erasure: erasures[n]}); err != nil {
+				return result, err
+			}
+			result.Erasures++
+		}
+	}
+
+	filters := store.QueryFilters{StartTime: opts.Since, EndTime: opts.Until}
+	err := s.StreamEvents(ctx, filters, func(event *model.ChangeEvent) error {
+		if err := encoder.Encode(Record{Kind: KindEvent, Event: event}); err != nil {
+			return err
+		}
+		result.Events++
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to back up events: %w", err)
+	}
+	return result, out.Flush()
+}
+
+// Restore reads a backup from r and restores the events of the time range of opts, and
+// unless opts.EventsOnly the rest of the state, to s. Records already restored are counted
+// as duplicates, so an interrupted restore can be re-run: events by ID, holds by reason and
+// filters, subscriptions by name and URL, pseudonyms by pseudonym and erasures by pseudonym
+// and time. The retention is only restored if s has none set. It stops at the
+// first invalid record or failed batch; what was restored before it stays restored.
+func Restore(ctx context.Context, s Target, r io.Reader, opts Options) (*Result, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = backfill.DefaultBatchSize
+	}
+	result := &Result{}
+	batch := make([]*model.ChangeEvent, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		imported, err := s.ImportEvents(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.Events += imported
+		result.Duplicates += len(batch) - imported
+		batch = batch[:0]
+		return nil
+	}
+
+	var existing *existingConfig
+	headerRead := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return result, fmt.Errorf("line %d: invalid record: %w", lineNumber, err)
+		}
+
+		if !headerRead {
+			if record.Kind != KindHeader || record.Header == nil {
+				return result, fmt.Errorf("line %d: not a kubechronicle backup", lineNumber)
+			}
+			if record.Header.Version > Version {
+				return result, fmt.Errorf("backup version %d is newer than supported (%d)", record.Header.Version, Version)
+			}
+			headerRead = true
+			continue
+		}
+
+		switch {
+		case record.Kind == KindEvent && record.Event != nil:
+			if record.Event.ID == "" {
+				return result, fmt.Errorf("line %d: event has no id", lineNumber)
+			}
+			if !inRange(record.Event.Timestamp, opts) {
+				result.Skipped++
+				continue
+			}
+			batch = append(batch, record.Event)
+			if len(batch) == batchSize {
+				if err := flush(); err != nil {
+					return result, fmt.Errorf("failed to restore events up to line %d: %w", lineNumber, err)
+				}
+			}
+		case record.Kind == KindLegalHold && record.LegalHold != nil,
+			record.Kind == KindSubscription && record.Subscription != nil,
+			record.Kind == KindRetention && record.Retention != nil,
+			record.Kind == KindPseudonym && record.Pseudonym != nil,
+			record.Kind == KindErasure && record.Erasure != nil:
+			if opts.EventsOnly {
+				result.Skipped++
+				continue
+			}
+			if existing == nil {
+				var err error
+				if existing, err = loadExistingConfig(ctx, s); err != nil {
+					return result, err
+				}
+			}
+			if err := existing.restore(ctx, s, record, result); err != nil {
+				return result, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		default:
+			return result, fmt.Errorf("line %d: unknown record kind %q", lineNumber, record.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("line %d: %w", lineNumber+1, err)
+	}
+	if !headerRead {
+		return result, fmt.Errorf("empty backup")
+	}
+	if err := flush(); err != nil {
+		return result, fmt.Errorf("failed to restore events up to line %d: %w", lineNumber, err)
+	}
+	return result, nil
+}
+
+// inRange reports whether t is in the time range of opts.
+func inRange(t time.Time, opts Options) bool {
+	if opts.Since != nil && t.Before(*opts.Since) {
+		return false
+	}
+	if opts.Until != nil && t.After(*opts.Until) {
+		return false
+	}
+	return true
+}
+
+// existingConfig identifies the state of a target, so restoring a backup twice doesn't
+// duplicate it.
+type existingConfig struct {
+	retention     bool            // Whether a retention is set
+	holds         map[string]bool // By reason and filters
+	subscriptions map[string]bool // By name and URL
+	pseudonyms    map[string]bool
+	erasures      map[string]bool // By pseudonym and time
+}
+
+// loadExistingConfig reads the retention and lists the active legal holds, subscriptions,
+// pseudonyms and erasures of s.
+func loadExistingConfig(ctx context.Context, s Target) (*existingConfig, error) {
+	existing := &existingConfig{
+		holds:         map[string]bool{},
+		subscriptions: map[string]bool{},
+		pseudonyms:    map[string]bool{},
+		erasures:      map[string]bool{},
+	}
+	policy, err := s.GetRetentionPolicy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention: %w", err)
+	}
+	existing.retention = policy != nil
+	holds, err := s.ListLegalHolds(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	for _, hold := range holds {
+		existing.holds[holdKey(hold)] = true
+	}
+	subs, err := s.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for _, sub := range subs {
+		existing.subscriptions[sub.Name+"\x00"+sub.URL] = true
+	}
+	pseudonyms, err := s.ListPseudonyms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pseudonyms: %w", err)
+	}
+	for _, p := range pseudonyms {
+		existing.pseudonyms[p.Pseudonym] = true
+	}
+	erasures, err := s.ListErasures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list erasures: %w", err)
+	}
+	for _, erasure := range erasures {
+		existing.erasures[erasureKey(erasure)] = true
+	}
+	return existing, nil
+}
+
+// restore creates the state of a record unless it exists. Legal holds, subscriptions and
+// pseudonyms get new IDs and creation times; their creators are kept. Erasures keep their
+// time, so the audit log reads as it did.
+func (e *existingConfig) restore(ctx context.Context, s Target, record Record, result *Result) error {
+	switch {
+	case record.Retention != nil:
+		if e.retention {
+			result.Duplicates++
+			return nil
+		}
+		restored := &store.RetentionPolicy{Days: record.Retention.Days, UpdatedBy: record.Retention.UpdatedBy}
+		if err := s.SetRetentionPolicy(ctx, restored); err != nil {
+			return fmt.Errorf("failed to restore retention: %w", err)
+		}
+		e.retention = true
+		result.Retention++
+		return nil
+	case record.Pseudonym != nil:
+		if e.pseudonyms[record.Pseudonym.Pseudonym] {
+			result.Duplicates++
+			return nil
+		}
+		if err := s.SavePseudonyms(ctx, []*store.Pseudonym{record.Pseudonym}); err != nil {
+			return fmt.Errorf("failed to restore pseudonym %s: %w", record.Pseudonym.Pseudonym, err)
+		}
+		e.pseudonyms[record.Pseudonym.Pseudonym] = true
+		result.Pseudonyms++
+		return nil
+	case record.Erasure != nil:
+		key := erasureKey(record.Erasure)
+		if e.erasures[key] {
+			result.Duplicates++
+			return nil
+		}
+		restored := *record.Erasure
+		if err := s.RestoreErasure(ctx, &restored); err != nil {
+			return fmt.Errorf("failed to restore erasure of %s: %w", record.Erasure.Pseudonym, err)
+		}
+		e.erasures[key] = true
+		result.Erasures++
+		return nil
+	}
+
+	if hold := record.LegalHold; hold != nil {
+		if !hold.Active() {
+			result.Skipped++
+			return nil
+		}
+		key := holdKey(hold)
+		if e.holds[key] {
+			result.Duplicates++
+			return nil
+		}
+		restored := &store.LegalHold{Reason: hold.Reason, Filters: hold.Filters, CreatedBy: hold.CreatedBy}
+		if err := s.CreateLegalHold(ctx, restored); err != nil {
+			return fmt.Errorf("failed to restore legal hold %q: %w", hold.Reason, err)
+		}
+		e.holds[key] = true
+		result.LegalHolds++
+		return nil
+	}
+
+	sub := record.Subscription
+	key := sub.Name + "\x00" + sub.URL
+	if e.subscriptions[key] {
+		result.Duplicates++
+		return nil
+	}
+	restored := &store.Subscription{Name: sub.Name, URL: sub.URL, Secret: sub.Secret, Format: sub.Format, Filter: sub.Filter, CreatedBy: sub.CreatedBy}
+	if err := s.CreateSubscription(ctx, restored); err != nil {
+		return fmt.Errorf("failed to restore subscription %q: %w", sub.Name, err)
+	}
+	e.subscriptions[key] = true
+	result.Subscriptions++
+	return nil
+}
+
+// erasureKey identifies an erasure by its pseudonym and time.
+func erasureKey(erasure *store.ErasureRecord) string {
+	return erasure.Pseudonym + "\x00" + erasure.CreatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// holdKey identifies a legal hold by its reason and filters.
+func holdKey(hold *store.LegalHold) string {
+	filters, _ := json.Marshal(hold.Filters)
+	return hold.Reason + "\x00" + string(filters)
+}
@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// memoryStore keeps events by ID and the rest of the state in memory.
+type memoryStore struct {
+	events        []*model.ChangeEvent
+	ids           map[string]bool
+	holds         []*store.LegalHold
+	subscriptions []*store.Subscription
+	retention     *store.RetentionPolicy
+	pseudonyms    []*store.Pseudonym
+	erasures      []*store.ErasureRecord // Oldest first
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{ids: map[string]bool{}}
+}
+
+func (s *memoryStore) StreamEvents(ctx context.Context, filters store.QueryFilters, fn func(*model.ChangeEvent) error) error {
+	for _, event := range s.events {
+		if (filters.StartTime != nil && event.Timestamp.Before(*filters.StartTime)) || (filters.EndTime != nil && event.Timestamp.After(*filters.EndTime)) {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) ImportEvents(ctx context.Context, events []*model.ChangeEvent) (int, error) {
+	imported := 0
+	for _, event := range events {
+		if !s.ids[event.ID] {
+			s.ids[event.ID] = true
+			s.events = append(s.events, event)
+			imported++
+		}
+	}
+	return imported, nil
+}
+
+func (s *memoryStore) ListLegalHolds(ctx context.Context, includeReleased bool) ([]*store.LegalHold, error) {
+	var holds []*store.LegalHold
+	for _, hold := range s.holds {
+		if includeReleased || hold.Active() {
+			holds = append(holds, hold)
+		}
+	}
+	return holds, nil
+}
+
+func (s *memoryStore) CreateLegalHold(ctx context.Context, hold *store.LegalHold) error {
+	hold.ID = int64(len(s.holds) + 1)
+	s.holds = append(s.holds, hold)
+	return nil
+}
+
+func (s *memoryStore) ListSubscriptions(ctx context.Context) ([]*store.Subscription, error) {
+	return s.subscriptions, nil
+}
+
+func (s *memoryStore) CreateSubscription(ctx context.Context, sub *store.Subscription) error {
+	sub.ID = int64(len(s.subscriptions) + 1)
+	s.subscriptions = append(s.subscriptions, sub)
+	return nil
+}
+
+func (s *memoryStore) GetRetentionPolicy(ctx context.Context) (*store.RetentionPolicy, error) {
+	return s.retention, nil
+}
+
+func (s *memoryStore) SetRetentionPolicy(ctx context.Context, policy *store.RetentionPolicy) error {
+	s.retention = policy
+	return nil
+}
+
+func (s *memoryStore) ListPseudonyms(ctx context.Context) ([]*store.Pseudonym, error) {
+	return s.pseudonyms, nil
+}
+
+func (s *memoryStore) SavePseudonyms(ctx context.Context, pseudonyms []*store.Pseudonym) error {
+	s.pseudonyms = append(s.pseudonyms, pseudonyms...)
+	return nil
+}
+
+func (s *memoryStore) ListErasures(ctx context.Context) ([]*store.ErasureRecord, error) {
+	var erasures []*store.ErasureRecord
+	for n := len(s.erasures) - 1; n >= 0; n-- {
+		erasures = append(erasures, s.erasures[n])
+	}
+	return erasures, nil
+}
+
+func (s *memoryStore) RestoreErasure(ctx context.Context, record *store.ErasureRecord) error {
+	record.ID = int64(len(s.erasures) + 1)
+	s.erasures = append(s.erasures, record)
+	return nil
+}
+
+// day returns midnight UTC of January d, 2026.
+func day(d int) time.Time {
+	return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newSourceStore() *memoryStore {
+	s := newMemoryStore()
+	for d := 1; d <= 4; d++ {
+		s.ImportEvents(context.Background(), []*model.ChangeEvent{{ID: fmt.Sprintf("e%d", d), Timestamp: day(d), Operation: "UPDATE"}})
+	}
+	released := day(2)
+	s.holds = []*store.LegalHold{
+		{ID: 1, Reason: "INC-42", Filters: store.QueryFilters{Namespace: "payments"}, CreatedBy: "alice"},
+		{ID: 2, Reason: "closed", ReleasedAt: &released},
+	}
+	s.subscriptions = []*store.Subscription{{ID: 1, Name: "soar", URL: "https://soar.example.com/hook", Secret: "s3cret"}}
+	s.retention = &store.RetentionPolicy{Days: 90, UpdatedBy: "alice"}
+	s.pseudonyms = []*store.Pseudonym{{Pseudonym: "user-7f3a", Field: "username", Value: "bob"}}
+	s.erasures = []*store.ErasureRecord{
+		{ID: 1, Pseudonym: "erased-user-01", EventsUpdated: 3, CreatedAt: day(2)},
+		{ID: 2, Pseudonym: "erased-user-02", EventsUpdated: 1, CreatedAt: day(3), PreviousHead: "abc"},
+	}
+	return s
+}
+
+func TestBackupRestore(t *testing.T) {
+	var buf bytes.Buffer
+	result, err := Backup(context.Background(), newSourceStore(), &buf, Options{})
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if *result != (Result{Events: 4, LegalHolds: 1, Subscriptions: 1, Retention: 1, Pseudonyms: 1, Erasures: 2}) {
+		t.Errorf("Backup() = %+v", result)
+	}
+	if !strings.HasPrefix(buf.String(), `{"kind":"header","header":{"version":1,`) {
+		t.Errorf("Backup should start with its header, got %q", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+
+	target := newMemoryStore()
+	result, err = Restore(context.Background(), target, bytes.NewReader(buf.Bytes()), Options{})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if *result != (Result{Events: 4, LegalHolds: 1, Subscriptions: 1, Retention: 1, Pseudonyms: 1, Erasures: 2}) {
+		t.Errorf("Restore() = %+v", result)
+	}
+	if hold := target.holds[0]; hold.Reason != "INC-42" || hold.Filters.Namespace != "payments" || hold.CreatedBy != "alice" {
+		t.Errorf("Restored hold = %+v", hold)
+	}
+	if sub := target.subscriptions[0]; sub.Secret != "s3cret" {
+		t.Errorf("Restored subscription = %+v, want its secret", sub)
+	}
+	if target.retention == nil || target.retention.Days != 90 || target.retention.UpdatedBy != "alice" {
+		t.Errorf("Restored retention = %+v", target.retention)
+	}
+	if p := target.pseudonyms[0]; p.Pseudonym != "user-7f3a" || p.Value != "bob" {
+		t.Errorf("Restored pseudonym = %+v, want its value", p)
+	}
+	if erasure := target.erasures[1]; erasure.Pseudonym != "erased-user-02" || !erasure.CreatedAt.Equal(day(3)) || erasure.PreviousHead != "abc" {
+		t.Errorf("Restored erasures = %+v, want them oldest first with their time", target.erasures)
+	}
+
+	// Restoring again only finds duplicates
+	result, err = Restore(context.Background(), target, bytes.NewReader(buf.Bytes()), Options{})
+	if err != nil {
+		t.Fatalf("Restore() again error = %v", err)
+	}
+	if *result != (Result{Duplicates: 10}) {
+		t.Errorf("Restore() again = %+v, want 10 duplicates", result)
+	}
+}
+
+func TestRestore_KeepsRetention(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Backup(context.Background(), newSourceStore(), &buf, Options{}); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	target := newMemoryStore()
+	target.retention = &store.RetentionPolicy{Days: 30}
+	if _, err := Restore(context.Background(), target, &buf, Options{}); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if target.retention.Days != 30 {
+		t.Errorf("Restore() replaced the target's retention with %+v", target.retention)
+	}
+}
+
+func TestRestore_TimeRange(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Backup(context.Background(), newSourceStore(), &buf, Options{}); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	since, until := day(2), day(3)
+	target := newMemoryStore()
+	result, err := Restore(context.Background(), target, &buf, Options{Since: &since, Until: &until, EventsOnly: true})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if *result != (Result{Events: 2, Skipped: 8}) {
+		t.Errorf("Restore() = %+v, want 2 events and the rest skipped", result)
+	}
+	if len(target.events) != 2 || target.events[0].ID != "e2" || len(target.holds) != 0 {
+		t.Errorf("Restored %v and holds %v", target.events, target.holds)
+	}
+}
+
+func TestBackup_TimeRange(t *testing.T) {
+	var buf bytes.Buffer
+	since := day(3)
+	result, err := Backup(context.Background(), newSourceStore(), &buf, Options{Since: &since, EventsOnly: true})
+	if err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+	if *result != (Result{Events: 2}) {
+		t.Errorf("Backup() = %+v, want the 2 events since day 3", result)
+	}
+}
+
+func TestRestore_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":        "",
+		"no header":    `{"kind":"event","event":{"id":"e1"}}`,
+		"newer":        `{"kind":"header","header":{"version":99}}`,
+		"unknown kind": `{"kind":"header","header":{"version":1}}` + "\n" + `{"kind":"view"}`,
+		"no event id":  `{"kind":"header","header":{"version":1}}` + "\n" + `{"kind":"event","event":{}}`,
+	}
+	for name, backup := range tests {
+		if _, err := Restore(context.Background(), newMemoryStore(), strings.NewReader(backup), Options{}); err == nil {
+			t.Errorf("%s: Restore() should fail", name)
+		}
+	}
+}
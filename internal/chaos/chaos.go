@@ -0,0 +1,108 @@
+package chaos
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// ErrInjected is returned by operations failed on purpose.
+var ErrInjected = errors.New("injected fault")
+
+// Faults injected so far, to tell them apart from real failures on /debug/vars.
+var (
+	storeFaults = expvar.NewInt("chaos_store_faults_total")
+	alertFaults = expvar.NewInt("chaos_alert_faults_total")
+)
+
+// Config describes the faults to inject. It is meant for resilience testing only: the
+// zero value injects nothing.
+type Config struct {
+	StoreLatency   time.Duration `json:"store_latency,omitempty"`    // Added to every store write
+	StoreErrorRate float64       `json:"store_error_rate,omitempty"` // Fraction of store writes that fail (0-1)
+	AlertErrorRate float64       `json:"alert_error_rate,omitempty"` // Fraction of alert sends that fail (0-1)
+	QueueCapacity  int           `json:"queue_capacity,omitempty"`   // Shrinks the event queue to this many events
+}
+
+// Enabled reports whether any fault is configured.
+func (c *Config) Enabled() bool {
+	return c != nil && (c.StoreLatency > 0 || c.StoreErrorRate > 0 || c.AlertErrorRate > 0 || c.QueueCapacity > 0)
+}
+
+// Validate checks that rates are fractions and durations and capacities are not negative.
+func (c *Config) Validate() error {
+	if c.StoreLatency < 0 {
+		return fmt.Errorf("store latency must not be negative")
+	}
+	if c.StoreErrorRate < 0 || c.StoreErrorRate > 1 {
+		return fmt.Errorf("store error rate must be between 0 and 1, got %v", c.StoreErrorRate)
+	}
+	if c.AlertErrorRate < 0 || c.AlertErrorRate > 1 {
+		return fmt.Errorf("alert error rate must be between 0 and 1, got %v", c.AlertErrorRate)
+	}
+	if c.QueueCapacity < 0 {
+		return fmt.Errorf("queue capacity must not be negative")
+	}
+	return nil
+}
+
+// fail reports whether an operation should fail at the given rate.
+func fail(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// faultyStore delays and fails writes of the wrapped store. Reads are passed through.
+type faultyStore struct {
+	store.Store
+	latency   time.Duration
+	errorRate float64
+}
+
+// WrapStore returns s with the store faults of cfg injected, or s itself if none are configured.
+func WrapStore(s store.Store, cfg *Config) store.Store {
+	if s == nil || cfg == nil || (cfg.StoreLatency <= 0 && cfg.StoreErrorRate <= 0) {
+		return s
+	}
+	return &faultyStore{Store: s, latency: cfg.StoreLatency, errorRate: cfg.StoreErrorRate}
+}
+
+// Save waits for the configured latency, then fails or persists the event.
+func (s *faultyStore) Save(event *model.ChangeEvent) error {
+	if s.latency > 0 {
+		time.Sleep(s.latency)
+	}
+	if fail(s.errorRate) {
+		storeFaults.Add(1)
+		return fmt.Errorf("failed to save event %s: %w", event.ID, ErrInjected)
+	}
+	return s.Store.Save(event)
+}
+
+// faultySender fails a fraction of the alerts of the wrapped sender.
+type faultySender struct {
+	alerting.Sender
+	errorRate float64
+}
+
+// WrapSender returns s with the alert faults of cfg injected, or s itself if none are configured.
+func WrapSender(s alerting.Sender, cfg *Config) alerting.Sender {
+	if cfg == nil || cfg.AlertErrorRate <= 0 {
+		return s
+	}
+	return &faultySender{Sender: s, errorRate: cfg.AlertErrorRate}
+}
+
+// Send fails or delivers the alert.
+func (s *faultySender) Send(event *model.ChangeEvent) error {
+	if fail(s.errorRate) {
+		alertFaults.Add(1)
+		return fmt.Errorf("failed to send alert for event %s: %w", event.ID, ErrInjected)
+	}
+	return s.Sender.Send(event)
+}
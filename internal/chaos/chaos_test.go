@@ -0,0 +1,113 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// recordingStore records saved events. Only Save is implemented.
+type recordingStore struct {
+	store.Store
+	saved int
+}
+
+func (s *recordingStore) Save(event *model.ChangeEvent) error {
+	s.saved++
+	return nil
+}
+
+// recordingSender counts sent alerts.
+type recordingSender struct {
+	sent int
+}
+
+func (s *recordingSender) Send(event *model.ChangeEvent) error {
+	s.sent++
+	return nil
+}
+
+func (s *recordingSender) Name() string {
+	return "recording"
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectError bool
+	}{
+		{"zero value", Config{}, false},
+		{"all faults", Config{StoreLatency: time.Second, StoreErrorRate: 0.5, AlertErrorRate: 1, QueueCapacity: 10}, false},
+		{"negative latency", Config{StoreLatency: -time.Second}, true},
+		{"store error rate above 1", Config{StoreErrorRate: 1.5}, true},
+		{"negative alert error rate", Config{AlertErrorRate: -0.1}, true},
+		{"negative queue capacity", Config{QueueCapacity: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.expectError {
+				t.Errorf("Validate() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	var nilConfig *Config
+	if nilConfig.Enabled() || (&Config{}).Enabled() {
+		t.Error("nil and zero configs should not be enabled")
+	}
+	if !(&Config{QueueCapacity: 1}).Enabled() {
+		t.Error("config with a queue capacity should be enabled")
+	}
+}
+
+func TestWrapStore(t *testing.T) {
+	s := &recordingStore{}
+	if WrapStore(s, &Config{AlertErrorRate: 1}) != store.Store(s) {
+		t.Error("store should not be wrapped without store faults")
+	}
+
+	failing := WrapStore(s, &Config{StoreErrorRate: 1})
+	if err := failing.Save(&model.ChangeEvent{ID: "e1"}); !errors.Is(err, ErrInjected) {
+		t.Errorf("Save() error = %v, want ErrInjected", err)
+	}
+	if s.saved != 0 {
+		t.Error("failed write should not reach the store")
+	}
+
+	slow := WrapStore(s, &Config{StoreLatency: 20 * time.Millisecond})
+	start := time.Now()
+	if err := slow.Save(&model.ChangeEvent{ID: "e2"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Save() took %s, want at least 20ms", elapsed)
+	}
+	if s.saved != 1 {
+		t.Errorf("saved = %d, want 1", s.saved)
+	}
+}
+
+func TestWrapSender(t *testing.T) {
+	s := &recordingSender{}
+	if WrapSender(s, nil) != s {
+		t.Error("sender should not be wrapped without alert faults")
+	}
+
+	failing := WrapSender(s, &Config{AlertErrorRate: 1})
+	if err := failing.Send(&model.ChangeEvent{ID: "e1"}); !errors.Is(err, ErrInjected) {
+		t.Errorf("Send() error = %v, want ErrInjected", err)
+	}
+	if failing.Name() != "recording" {
+		t.Errorf("Name() = %q, want the wrapped sender's name", failing.Name())
+	}
+	if s.sent != 0 {
+		t.Error("failed alert should not reach the sender")
+	}
+}
@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// aksActions maps the Azure Resource Manager operations on AKS clusters recorded to their action.
+var aksActions = map[string]string{
+	"Microsoft.ContainerService/managedClusters/agentPools/write":                          ActionUpdateNodePool,
+	"Microsoft.ContainerService/managedClusters/agentPools/delete":                         ActionDeleteNodePool,
+	"Microsoft.ContainerService/managedClusters/agentPools/upgradeNodeImageVersion/action": ActionUpgradeNodePool,
+	"Microsoft.ContainerService/managedClusters/write":                                     ActionUpdateCluster,
+	"Microsoft.ContainerService/managedClusters/delete":                                    ActionDeleteCluster,
+}
+
+// aksEventTypes are the Event Grid event types of operations that succeeded.
+var aksEventTypes = map[string]bool{
+	"Microsoft.Resources.ResourceWriteSuccess":  true,
+	"Microsoft.Resources.ResourceDeleteSuccess": true,
+	"Microsoft.Resources.ResourceActionSuccess": true,
+}
+
+// aksUPNClaim is the claim holding the user principal name of the caller.
+const aksUPNClaim = "http://schemas.xmlsoap.org/ws/2005/05/identity/claims/upn"
+
+// aksEvent holds the fields of an Event Grid resource event that are recorded.
+type aksEvent struct {
+	ID        string    `json:"id"`
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Data      struct {
+		ValidationCode string            `json:"validationCode"` // Subscription validation events only
+		OperationName  string            `json:"operationName"`
+		ResourceURI    string            `json:"resourceUri"`
+		Claims         map[string]string `json:"claims"`
+		HTTPRequest    struct {
+			ClientIPAddress string `json:"clientIpAddress"`
+		} `json:"httpRequest"`
+	} `json:"data"`
+}
+
+// aksValidationCode returns the code of the validation event Event Grid sends when a
+// subscription is created, to be echoed back.
+func aksValidationCode(body []byte) (string, bool) {
+	var events []aksEvent
+	if err := json.Unmarshal(body, &events); err != nil || len(events) == 0 {
+		return "", false
+	}
+	if events[0].EventType != "Microsoft.EventGrid.SubscriptionValidationEvent" {
+		return "", false
+	}
+	return events[0].Data.ValidationCode, true
+}
+
+// parseAKS converts the AKS resource events of an Event Grid delivery to CLOUD_CHANGE events.
+// Events that aren't recorded, other operations and failed ones, are nil.
+func parseAKS(body []byte) ([]*model.ChangeEvent, error) {
+	var events []aksEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*model.ChangeEvent, 0, len(events))
+	for _, event := range events {
+		changes = append(changes, parseAKSEvent(event))
+	}
+	return changes, nil
+}
+
+// parseAKSEvent converts an AKS resource event to a CLOUD_CHANGE event, or nil if it isn't recorded.
+func parseAKSEvent(event aksEvent) *model.ChangeEvent {
+	if !aksEventTypes[event.EventType] {
+		return nil
+	}
+	action, ok := aksActions[event.Data.OperationName]
+	if !ok {
+		return nil
+	}
+
+	// Resource URIs are /subscriptions/{id}/resourceGroups/{group}/providers/
+	// Microsoft.ContainerService/managedClusters/{cluster}[/agentPools/{pool}]
+	var cluster, nodePool string
+	segments := strings.Split(event.Data.ResourceURI, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		switch strings.ToLower(segments[i]) {
+		case "managedclusters":
+			cluster = segments[i+1]
+		case "agentpools":
+			nodePool = segments[i+1]
+		}
+	}
+
+	claims := event.Data.Claims
+	actor := claims[aksUPNClaim]
+	if actor == "" {
+		actor = claims["name"]
+	}
+	if actor == "" {
+		actor = claims["appid"]
+	}
+
+	change := &model.CloudChange{
+		Provider: "aks",
+		Cluster:  cluster,
+		Action:   action,
+		Method:   event.Data.OperationName,
+	}
+	changeEvent := newEvent(change, nodePool, model.Actor{Username: actor, SourceIP: event.Data.HTTPRequest.ClientIPAddress}, event.ID)
+	changeEvent.Timestamp = event.EventTime
+	return changeEvent
+}
@@ -0,0 +1,81 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// eksActions maps the EKS API calls recorded to their action.
+var eksActions = map[string]string{
+	"CreateNodegroup":        ActionCreateNodePool,
+	"DeleteNodegroup":        ActionDeleteNodePool,
+	"UpdateNodegroupConfig":  ActionUpdateNodePool,
+	"UpdateNodegroupVersion": ActionUpgradeNodePool,
+	"UpdateClusterVersion":   ActionUpgradeControlPlane,
+	"UpdateClusterConfig":    ActionUpdateCluster,
+	"DeleteCluster":          ActionDeleteCluster,
+}
+
+// eksEvent holds the fields of an EventBridge "AWS API Call via CloudTrail" event that are recorded.
+type eksEvent struct {
+	Detail *struct {
+		EventID      string    `json:"eventID"`
+		EventTime    time.Time `json:"eventTime"`
+		EventSource  string    `json:"eventSource"`
+		EventName    string    `json:"eventName"`
+		ErrorCode    string    `json:"errorCode"`
+		SourceIP     string    `json:"sourceIPAddress"`
+		UserIdentity struct {
+			ARN         string `json:"arn"`
+			PrincipalID string `json:"principalId"`
+		} `json:"userIdentity"`
+		RequestParameters map[string]interface{} `json:"requestParameters"`
+	} `json:"detail"`
+}
+
+// parseEKS converts an EKS CloudTrail event to a CLOUD_CHANGE event. Returns nil for calls
+// that aren't recorded: other calls, and calls that failed.
+func parseEKS(body []byte) (*model.ChangeEvent, error) {
+	var payload eksEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	detail := payload.Detail
+	if detail == nil {
+		return nil, fmt.Errorf("event without detail")
+	}
+	if detail.EventSource != "eks.amazonaws.com" || detail.ErrorCode != "" {
+		return nil, nil
+	}
+	action, ok := eksActions[detail.EventName]
+	if !ok {
+		return nil, nil
+	}
+
+	params := detail.RequestParameters
+	// Node group updates resizing the group are scaling
+	if _, resized := params["scalingConfig"]; resized && action == ActionUpdateNodePool {
+		action = ActionScaleNodePool
+	}
+
+	cluster, _ := params["name"].(string)
+	nodegroup, _ := params["nodegroupName"].(string)
+	actor := detail.UserIdentity.ARN
+	if actor == "" {
+		actor = detail.UserIdentity.PrincipalID
+	}
+
+	change := &model.CloudChange{
+		Provider:   "eks",
+		Cluster:    cluster,
+		Action:     action,
+		Method:     detail.EventName,
+		Parameters: params,
+	}
+	event := newEvent(change, nodegroup, model.Actor{Username: actor, SourceIP: detail.SourceIP}, detail.EventID)
+	event.Timestamp = detail.EventTime
+	return event, nil
+}
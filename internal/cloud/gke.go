@@ -0,0 +1,128 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// gkeActions maps the GKE ClusterManager methods recorded to their action.
+var gkeActions = map[string]string{
+	"CreateNodePool":         ActionCreateNodePool,
+	"DeleteNodePool":         ActionDeleteNodePool,
+	"SetNodePoolSize":        ActionScaleNodePool,
+	"SetNodePoolAutoscaling": ActionUpdateNodePool,
+	"UpdateNodePool":         ActionUpdateNodePool,
+	"UpdateMaster":           ActionUpgradeControlPlane,
+	"UpdateCluster":          ActionUpdateCluster,
+	"DeleteCluster":          ActionDeleteCluster,
+}
+
+// gkePushMessage is the body of a Pub/Sub push delivery.
+type gkePushMessage struct {
+	Message *struct {
+		Data      []byte `json:"data"` // A Cloud Logging LogEntry
+		MessageID string `json:"messageId"`
+	} `json:"message"`
+}
+
+// gkeLogEntry holds the fields of a Cloud Audit Logs entry that are recorded.
+type gkeLogEntry struct {
+	InsertID  string    `json:"insertId"`
+	Timestamp time.Time `json:"timestamp"`
+	Resource  struct {
+		Labels map[string]string `json:"labels"`
+	} `json:"resource"`
+	Operation *struct {
+		First bool `json:"first"`
+		Last  bool `json:"last"`
+	} `json:"operation"`
+	ProtoPayload struct {
+		MethodName   string `json:"methodName"`
+		ResourceName string `json:"resourceName"`
+		Status       *struct {
+			Code int `json:"code"`
+		} `json:"status"`
+		AuthenticationInfo struct {
+			PrincipalEmail string `json:"principalEmail"`
+		} `json:"authenticationInfo"`
+		RequestMetadata struct {
+			CallerIP string `json:"callerIp"`
+		} `json:"requestMetadata"`
+		Request map[string]interface{} `json:"request"`
+	} `json:"protoPayload"`
+}
+
+// parseGKE converts a GKE audit log entry pushed by Pub/Sub to a CLOUD_CHANGE event. Returns
+// nil for entries that aren't recorded: other methods, failed calls, and the entries logged
+// when a long-running operation completes.
+func parseGKE(body []byte) (*model.ChangeEvent, error) {
+	var push gkePushMessage
+	if err := json.Unmarshal(body, &push); err != nil {
+		return nil, err
+	}
+	if push.Message == nil {
+		return nil, fmt.Errorf("push without message")
+	}
+	var entry gkeLogEntry
+	if err := json.Unmarshal(push.Message.Data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid log entry: %w", err)
+	}
+
+	payload := entry.ProtoPayload
+	if !strings.Contains(payload.MethodName, ".ClusterManager.") {
+		return nil, nil
+	}
+	method := payload.MethodName[strings.LastIndex(payload.MethodName, ".")+1:]
+	action, ok := gkeActions[method]
+	if !ok {
+		return nil, nil
+	}
+	if (payload.Status != nil && payload.Status.Code != 0) || (entry.Operation != nil && !entry.Operation.First) {
+		return nil, nil
+	}
+
+	// Cluster updates changing the control plane version are upgrades, node pool updates
+	// changing the node version are too
+	switch method {
+	case "UpdateCluster":
+		if update, ok := payload.Request["update"].(map[string]interface{}); ok && update["desiredMasterVersion"] != nil {
+			action = ActionUpgradeControlPlane
+		}
+	case "UpdateNodePool":
+		if payload.Request["nodeVersion"] != nil {
+			action = ActionUpgradeNodePool
+		}
+	}
+
+	cluster := entry.Resource.Labels["cluster_name"]
+	nodePool := entry.Resource.Labels["nodepool_name"]
+	if _, name, ok := strings.Cut(payload.ResourceName, "/nodePools/"); ok && nodePool == "" {
+		nodePool = name
+	}
+	if nodePool == "" && method == "CreateNodePool" {
+		if pool, ok := payload.Request["nodePool"].(map[string]interface{}); ok {
+			nodePool, _ = pool["name"].(string)
+		}
+	}
+
+	id := entry.InsertID
+	if id == "" {
+		id = push.Message.MessageID
+	}
+
+	change := &model.CloudChange{
+		Provider:   "gke",
+		Cluster:    cluster,
+		Action:     action,
+		Method:     payload.MethodName,
+		Parameters: payload.Request,
+	}
+	actor := model.Actor{Username: payload.AuthenticationInfo.PrincipalEmail, SourceIP: payload.RequestMetadata.CallerIP}
+	event := newEvent(change, nodePool, actor, id)
+	event.Timestamp = entry.Timestamp
+	return event, nil
+}
@@ -0,0 +1,201 @@
+// Package cloud records changes made to the cluster through its cloud provider's API, such
+// as node pool scaling and control plane upgrades, as CLOUD_CHANGE events. The providers'
+// audit events are pushed to the API server: CloudTrail events from Amazon EventBridge (EKS),
+// Cloud Audit Logs from a Pub/Sub push subscription (GKE) and Azure Resource Manager events
+// from Event Grid (AKS).
+package cloud
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// OperationCloudChange is the operation of events recording changes made through a cloud provider's API.
+const OperationCloudChange = "CLOUD_CHANGE"
+
+// Resource kinds of CLOUD_CHANGE events.
+const (
+	KindCluster  = "Cluster"
+	KindNodePool = "NodePool"
+)
+
+// Actions of CLOUD_CHANGE events.
+const (
+	ActionCreateNodePool      = "create_node_pool"
+	ActionDeleteNodePool      = "delete_node_pool"
+	ActionScaleNodePool       = "scale_node_pool"
+	ActionUpdateNodePool      = "update_node_pool"
+	ActionUpgradeNodePool     = "upgrade_node_pool"
+	ActionUpgradeControlPlane = "upgrade_control_plane"
+	ActionUpdateCluster       = "update_cluster"
+	ActionDeleteCluster       = "delete_cluster"
+)
+
+// maxPayloadSize bounds the size of event payloads read.
+const maxPayloadSize = 5 << 20
+
+// Saver persists events; implemented by store.Store.
+type Saver interface {
+	Save(event *model.ChangeEvent) error
+}
+
+// Handler receives cloud provider audit events about the cluster.
+type Handler struct {
+	store    Saver
+	config   *config.CloudChangeConfig
+	clusters map[string]bool
+	now      func() time.Time
+}
+
+// NewHandler creates a new cloud change handler.
+func NewHandler(store Saver, cfg *config.CloudChangeConfig) *Handler {
+	if cfg == nil {
+		cfg = &config.CloudChangeConfig{}
+	}
+	clusters := make(map[string]bool, len(cfg.Clusters))
+	for _, cluster := range cfg.Clusters {
+		clusters[cluster] = true
+	}
+	return &Handler{
+		store:    store,
+		config:   cfg,
+		clusters: clusters,
+		now:      time.Now,
+	}
+}
+
+// HandleEKS handles POST /api/webhooks/eks, receiving EKS CloudTrail events delivered by an
+// EventBridge API destination.
+func (h *Handler) HandleEKS(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readRequest(w, r)
+	if !ok {
+		return
+	}
+	event, err := parseEKS(body)
+	h.record(w, []*model.ChangeEvent{event}, err)
+}
+
+// HandleGKE handles POST /api/webhooks/gke, receiving GKE audit log entries delivered by a
+// Pub/Sub push subscription of a log sink.
+func (h *Handler) HandleGKE(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readRequest(w, r)
+	if !ok {
+		return
+	}
+	event, err := parseGKE(body)
+	h.record(w, []*model.ChangeEvent{event}, err)
+}
+
+// HandleAKS handles POST /api/webhooks/aks, receiving AKS resource events delivered by an
+// Event Grid subscription, including its validation handshake.
+func (h *Handler) HandleAKS(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readRequest(w, r)
+	if !ok {
+		return
+	}
+	if code, ok := aksValidationCode(body); ok {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"validationResponse": code})
+		return
+	}
+	events, err := parseAKS(body)
+	h.record(w, events, err)
+}
+
+// readRequest authenticates a cloud event delivery and reads its payload, writing the
+// error response if it can't.
+func (h *Handler) readRequest(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if h.config.Token == "" {
+		http.Error(w, "Cloud change events are not enabled", http.StatusNotFound)
+		return nil, false
+	}
+	token := r.Header.Get("X-Kubechronicle-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if subtle.ConstantTimeCompare([]byte(h.config.Token), []byte(token)) != 1 {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read payload: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if len(body) > maxPayloadSize {
+		http.Error(w, fmt.Sprintf("payload exceeds %d bytes", maxPayloadSize), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// record saves the events parsed from a delivery that are of the configured clusters. Nil
+// events are deliveries that aren't recorded, e.g. calls that didn't change the cluster.
+func (h *Handler) record(w http.ResponseWriter, events []*model.ChangeEvent, err error) {
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var ids []string
+	for _, event := range events {
+		if event == nil || (len(h.clusters) > 0 && !h.clusters[event.CloudChange.Cluster]) {
+			continue
+		}
+		if event.Timestamp.IsZero() {
+			event.Timestamp = h.now()
+		}
+		if err := h.store.Save(event); err != nil && !errors.Is(err, store.ErrDuplicate) {
+			klog.Errorf("Failed to save cloud change event %s: %v", event.ID, err)
+			http.Error(w, fmt.Sprintf("Failed to save event: %v", err), http.StatusInternalServerError)
+			return
+		}
+		klog.Infof("Recorded %s %s of %s %s by %s", event.CloudChange.Provider, event.CloudChange.Action,
+			event.CloudChange.Cluster, event.Name, event.Actor.Username)
+		ids = append(ids, event.ID)
+	}
+
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string][]string{"ids": ids})
+}
+
+// newEvent creates a CLOUD_CHANGE event of a cluster or one of its node pools, identified
+// by the provider and the ID of the audit event, so redelivered events are recorded once.
+// Events of the cluster itself are named after it.
+func newEvent(change *model.CloudChange, nodePool string, actor model.Actor, id string) *model.ChangeEvent {
+	kind, name := KindCluster, change.Cluster
+	if nodePool != "" {
+		kind, name = KindNodePool, nodePool
+	}
+	return &model.ChangeEvent{
+		ID:           fmt.Sprintf("%s-%s-%s", OperationCloudChange, change.Provider, id),
+		Operation:    OperationCloudChange,
+		ResourceKind: kind,
+		Name:         name,
+		Actor:        actor,
+		Source:       model.Source{Tool: change.Provider},
+		Allowed:      true,
+		CloudChange:  change,
+	}
+}
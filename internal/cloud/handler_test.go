@@ -0,0 +1,266 @@
+package cloud
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// fakeSaver records saved events.
+type fakeSaver struct {
+	events []*model.ChangeEvent
+}
+
+func (f *fakeSaver) Save(event *model.ChangeEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+var receivedAt = time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC)
+
+func newTestHandler(clusters ...string) (*Handler, *fakeSaver) {
+	saver := &fakeSaver{}
+	handler := NewHandler(saver, &config.CloudChangeConfig{Token: "cloud-token", Clusters: clusters})
+	handler.now = func() time.Time { return receivedAt }
+	return handler, saver
+}
+
+func post(handle http.HandlerFunc, target, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("X-Kubechronicle-Token", token)
+	w := httptest.NewRecorder()
+	handle(w, req)
+	return w
+}
+
+const eksScaleEvent = `{
+	"id": "6a7e8feb-b491-4cf7-a9f1-bf3703467718",
+	"detail-type": "AWS API Call via CloudTrail",
+	"source": "aws.eks",
+	"detail": {
+		"eventID": "e1",
+		"eventTime": "2024-01-20T10:29:00Z",
+		"eventSource": "eks.amazonaws.com",
+		"eventName": "UpdateNodegroupConfig",
+		"sourceIPAddress": "203.0.113.7",
+		"userIdentity": {"arn": "arn:aws:iam::123456789012:user/alice", "principalId": "AIDAEXAMPLE"},
+		"requestParameters": {"name": "prod", "nodegroupName": "workers", "scalingConfig": {"desiredSize": 5}}
+	}
+}`
+
+func TestHandleEKS_ScaleNodegroup(t *testing.T) {
+	handler, saver := newTestHandler()
+	w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", eksScaleEvent, "cloud-token")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if len(saver.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(saver.events))
+	}
+	event := saver.events[0]
+	if event.ID != "CLOUD_CHANGE-eks-e1" || event.Operation != OperationCloudChange || event.ResourceKind != KindNodePool || event.Name != "workers" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if event.Source.Tool != "eks" || event.Actor.Username != "arn:aws:iam::123456789012:user/alice" || event.Actor.SourceIP != "203.0.113.7" {
+		t.Errorf("Unexpected source or actor: %+v %+v", event.Source, event.Actor)
+	}
+	if !event.Timestamp.Equal(time.Date(2024, 1, 20, 10, 29, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v", event.Timestamp)
+	}
+	change := event.CloudChange
+	if change.Provider != "eks" || change.Cluster != "prod" || change.Action != ActionScaleNodePool || change.Method != "UpdateNodegroupConfig" {
+		t.Errorf("Unexpected cloud change: %+v", change)
+	}
+	if _, ok := change.Parameters["scalingConfig"]; !ok {
+		t.Errorf("Parameters = %v, want the scaling config", change.Parameters)
+	}
+}
+
+func TestHandleEKS_NotRecorded(t *testing.T) {
+	handler, saver := newTestHandler()
+	failed := strings.Replace(eksScaleEvent, `"eventSource"`, `"errorCode": "AccessDenied", "eventSource"`, 1)
+	if w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", failed, "cloud-token"); w.Code != http.StatusAccepted {
+		t.Errorf("failed call: status = %d, want 202", w.Code)
+	}
+	describe := strings.Replace(eksScaleEvent, "UpdateNodegroupConfig", "DescribeNodegroup", 1)
+	if w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", describe, "cloud-token"); w.Code != http.StatusAccepted {
+		t.Errorf("read call: status = %d, want 202", w.Code)
+	}
+	if w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", `{"source": "aws.eks"}`, "cloud-token"); w.Code != http.StatusBadRequest {
+		t.Errorf("no detail: status = %d, want 400", w.Code)
+	}
+	if len(saver.events) != 0 {
+		t.Errorf("saved %d events, want 0", len(saver.events))
+	}
+}
+
+func TestHandleEKS_ClusterUpgrade(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `{"detail": {
+		"eventID": "e2", "eventSource": "eks.amazonaws.com", "eventName": "UpdateClusterVersion",
+		"userIdentity": {"principalId": "AROAEXAMPLE:terraform"},
+		"requestParameters": {"name": "prod", "version": "1.29"}
+	}}`
+	if w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", body, "cloud-token"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	event := saver.events[0]
+	if event.ResourceKind != KindCluster || event.Name != "prod" || event.CloudChange.Action != ActionUpgradeControlPlane || event.Actor.Username != "AROAEXAMPLE:terraform" {
+		t.Errorf("Unexpected event: %+v %+v", event, event.CloudChange)
+	}
+	// Received time is used without an event time
+	if !event.Timestamp.Equal(receivedAt) {
+		t.Errorf("Timestamp = %v, want %v", event.Timestamp, receivedAt)
+	}
+}
+
+func gkePush(entry string) string {
+	return `{"message": {"data": "` + base64.StdEncoding.EncodeToString([]byte(entry)) + `", "messageId": "m1"}, "subscription": "projects/acme/subscriptions/kubechronicle"}`
+}
+
+func TestHandleGKE_SetNodePoolSize(t *testing.T) {
+	handler, saver := newTestHandler()
+	entry := `{
+		"insertId": "abc",
+		"timestamp": "2024-01-20T10:29:00Z",
+		"resource": {"type": "gke_nodepool", "labels": {"cluster_name": "prod", "nodepool_name": "default-pool"}},
+		"operation": {"id": "op-1", "first": true},
+		"protoPayload": {
+			"methodName": "google.container.v1.ClusterManager.SetNodePoolSize",
+			"resourceName": "projects/acme/locations/europe-west1/clusters/prod/nodePools/default-pool",
+			"authenticationInfo": {"principalEmail": "alice@example.com"},
+			"requestMetadata": {"callerIp": "203.0.113.7"},
+			"request": {"nodeCount": 5}
+		}
+	}`
+	w := post(handler.HandleGKE, "/kubechronicle/api/webhooks/gke", gkePush(entry), "cloud-token")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	event := saver.events[0]
+	if event.ID != "CLOUD_CHANGE-gke-abc" || event.ResourceKind != KindNodePool || event.Name != "default-pool" || event.Actor.Username != "alice@example.com" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if change := event.CloudChange; change.Cluster != "prod" || change.Action != ActionScaleNodePool || change.Parameters["nodeCount"] != float64(5) {
+		t.Errorf("Unexpected cloud change: %+v", change)
+	}
+
+	// The entry logged when the operation completes isn't recorded again
+	last := strings.Replace(entry, `"first": true`, `"last": true`, 1)
+	if w := post(handler.HandleGKE, "/kubechronicle/api/webhooks/gke", gkePush(last), "cloud-token"); w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	if len(saver.events) != 1 {
+		t.Errorf("saved %d events, want 1", len(saver.events))
+	}
+}
+
+func TestHandleGKE_UpdateClusterUpgrade(t *testing.T) {
+	handler, saver := newTestHandler()
+	entry := `{
+		"insertId": "def",
+		"resource": {"type": "gke_cluster", "labels": {"cluster_name": "prod"}},
+		"protoPayload": {
+			"methodName": "google.container.v1.ClusterManager.UpdateCluster",
+			"authenticationInfo": {"principalEmail": "deployer@acme.iam.gserviceaccount.com"},
+			"request": {"update": {"desiredMasterVersion": "1.29.1-gke.100"}}
+		}
+	}`
+	if w := post(handler.HandleGKE, "/kubechronicle/api/webhooks/gke", gkePush(entry), "cloud-token"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	event := saver.events[0]
+	if event.ResourceKind != KindCluster || event.Name != "prod" || event.CloudChange.Action != ActionUpgradeControlPlane {
+		t.Errorf("Unexpected event: %+v %+v", event, event.CloudChange)
+	}
+
+	failed := strings.Replace(entry, `"request"`, `"status": {"code": 7}, "request"`, 1)
+	if w := post(handler.HandleGKE, "/kubechronicle/api/webhooks/gke", gkePush(failed), "cloud-token"); w.Code != http.StatusAccepted {
+		t.Errorf("failed call: status = %d, want 202", w.Code)
+	}
+}
+
+func TestHandleAKS_AgentPoolWrite(t *testing.T) {
+	handler, saver := newTestHandler("prod")
+	body := `[{
+		"id": "g1",
+		"eventType": "Microsoft.Resources.ResourceWriteSuccess",
+		"eventTime": "2024-01-20T10:29:00Z",
+		"data": {
+			"operationName": "Microsoft.ContainerService/managedClusters/agentPools/write",
+			"resourceUri": "/subscriptions/s1/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/prod/agentPools/nodepool1",
+			"claims": {"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/upn": "alice@example.com"},
+			"httpRequest": {"clientIpAddress": "203.0.113.7"}
+		}
+	}, {
+		"id": "g2",
+		"eventType": "Microsoft.Resources.ResourceWriteSuccess",
+		"data": {
+			"operationName": "Microsoft.ContainerService/managedClusters/write",
+			"resourceUri": "/subscriptions/s1/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/staging"
+		}
+	}, {
+		"id": "g3",
+		"eventType": "Microsoft.Resources.ResourceWriteFailure",
+		"data": {
+			"operationName": "Microsoft.ContainerService/managedClusters/write",
+			"resourceUri": "/subscriptions/s1/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/prod"
+		}
+	}]`
+	w := post(handler.HandleAKS, "/kubechronicle/api/webhooks/aks", body, "cloud-token")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	// Only the successful event of the configured cluster is recorded
+	if len(saver.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(saver.events))
+	}
+	event := saver.events[0]
+	if event.ID != "CLOUD_CHANGE-aks-g1" || event.ResourceKind != KindNodePool || event.Name != "nodepool1" || event.Actor.Username != "alice@example.com" || event.Actor.SourceIP != "203.0.113.7" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if change := event.CloudChange; change.Provider != "aks" || change.Cluster != "prod" || change.Action != ActionUpdateNodePool {
+		t.Errorf("Unexpected cloud change: %+v", change)
+	}
+}
+
+func TestHandleAKS_SubscriptionValidation(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `[{"id": "v1", "eventType": "Microsoft.EventGrid.SubscriptionValidationEvent", "data": {"validationCode": "512d38b6"}}]`
+	w := post(handler.HandleAKS, "/kubechronicle/api/webhooks/aks", body, "cloud-token")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"validationResponse":"512d38b6"`) {
+		t.Errorf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if len(saver.events) != 0 {
+		t.Errorf("saved %d events, want 0", len(saver.events))
+	}
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	handler, saver := newTestHandler()
+	if w := post(handler.HandleEKS, "/kubechronicle/api/webhooks/eks", eksScaleEvent, "wrong-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", w.Code)
+	}
+
+	// Pub/Sub push and Event Grid endpoints can carry the token in the URL
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/webhooks/eks?token=cloud-token", strings.NewReader(eksScaleEvent))
+	w := httptest.NewRecorder()
+	handler.HandleEKS(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("query token: status = %d, want 201", w.Code)
+	}
+	if len(saver.events) != 1 {
+		t.Errorf("saved %d events, want 1", len(saver.events))
+	}
+
+	disabled := NewHandler(saver, nil)
+	if w := post(disabled.HandleEKS, "/kubechronicle/api/webhooks/eks", eksScaleEvent, ""); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+}
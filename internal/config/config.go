@@ -2,13 +2,21 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/klog/v2"
 
-	"github.com/kubechronicle/kubechronicle/internal/alerting"
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/heartbeat"
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
 )
 
 // Config holds application configuration.
@@ -19,9 +27,180 @@ type Config struct {
 	DatabaseURL  string
 	LogLevel     string
 	AlertConfig  *alerting.Config
+	ExportConfig *export.Config
 	IgnoreConfig *IgnoreConfig
 	BlockConfig  *BlockConfig
 	AuthConfig   *AuthConfig
+
+	// ListenAddress is a comma-separated list of addresses the API or webhook server listens on,
+	// each host:port or unix:///path/to/socket. The server's -port is used when empty.
+	ListenAddress string
+
+	// TLS hardening for the webhook server, see ServerTLSConfig. Client certificates are
+	// verified against TLSClientCAPath when it is set.
+	TLSMinVersion   string
+	TLSCipherSuites []string
+	TLSClientAuth   string
+	TLSClientCAPath string
+
+	// SamplingConfig records only a fraction of events for high-churn resources.
+	// All events are recorded when nil.
+	SamplingConfig *SamplingConfig
+
+	// WarnConfig returns non-blocking admission warnings for matching requests.
+	WarnConfig *WarnConfig
+
+	// PluginConfigs enables compiled-in admission plugins, run in order (see pkg/plugin).
+	PluginConfigs []PluginConfig
+
+	// QuotaConfig samples the events of namespaces past their quota of recorded events.
+	// Events are not limited when nil.
+	QuotaConfig *QuotaConfig
+
+	// ChurnConfig sets the change rates above which the API flags resources as churning.
+	// The default threshold applies to every resource when nil.
+	ChurnConfig *ChurnConfig
+
+	// ChangeWindowConfig declares the approved change windows the API's change compliance
+	// report checks changes against. Only changes during freezes are reported when nil.
+	ChangeWindowConfig *ChangeWindowConfig
+
+	// MessageCatalog translates denial messages and alerts, in the locale of each namespace.
+	// They are in English when nil.
+	MessageCatalog *i18n.Catalog
+
+	// ExecRiskConfig adds to or replaces the rules scoring the commands of exec events.
+	// The built-in rules are used when nil.
+	ExecRiskConfig *ExecRiskConfig
+
+	// EncryptionKey is a base64-encoded 32-byte key used to encrypt diffs and
+	// object snapshots at rest. Encryption is disabled when empty.
+	EncryptionKey string
+
+	// DecryptRoles lists the roles allowed to see decrypted diffs and snapshots
+	// through the API (default: admin).
+	DecryptRoles []string
+
+	// PseudonymKey is a base64-encoded key of at least 16 bytes used to replace actor
+	// fields with pseudonyms at ingestion. Pseudonymization is disabled when empty.
+	PseudonymKey string
+
+	// PseudonymConfig selects the actor fields pseudonymized. The defaults apply when nil.
+	PseudonymConfig *pseudonym.Config
+
+	// SourceHealthConfig names the cluster the event sources record their activity under
+	// and sets when a source is stale. The defaults apply when nil.
+	SourceHealthConfig *sources.Config
+
+	// HeartbeatInterval is how often each producer emits a heartbeat event through its
+	// pipeline (HEARTBEAT_INTERVAL, default: 1m); 0 disables heartbeats.
+	HeartbeatInterval time.Duration
+
+	// RetentionDays is the age in days above which the API server purges events
+	// (RETENTION_DAYS); 0 keeps them forever. A retention set through the admin API overrides it.
+	RetentionDays int
+
+	// DeleteCollectionMinDeletes is the number of DELETEs of one kind in one namespace by the
+	// same actor, each within DeleteCollectionWindow of the previous one, that the webhook groups
+	// into a DELETE_COLLECTION event alerted in their place (DELETE_COLLECTION_MIN_DELETES,
+	// default: 10); 0 disables grouping.
+	DeleteCollectionMinDeletes int
+	DeleteCollectionWindow     time.Duration // DELETE_COLLECTION_WINDOW, default: 5s
+
+	// NamespaceCascadeWindow is how long after the last object deleted in a deleted namespace
+	// the webhook records the NAMESPACE_CASCADE event summarizing the objects deleted with it
+	// (NAMESPACE_CASCADE_WINDOW, default: 1m); 0 disables the summaries.
+	NamespaceCascadeWindow time.Duration
+
+	// RecorderRoles lists the roles allowed to register session recordings of exec
+	// events through the API (default: admin, recorder).
+	RecorderRoles []string
+
+	// DeploymentWebhookConfig enables the API endpoints receiving GitHub and GitLab
+	// deployment and pipeline webhooks. The endpoints are disabled when nil.
+	DeploymentWebhookConfig *DeploymentWebhookConfig
+
+	// CloudChangeConfig enables the API endpoints receiving EKS, GKE and AKS control
+	// plane audit events. The endpoints are disabled when nil.
+	CloudChangeConfig *CloudChangeConfig
+
+	// TenancyConfig scopes the API's event queries to the namespaces of the caller's tenants.
+	// Every caller sees every event when nil.
+	TenancyConfig *TenancyConfig
+
+	// RequirePersistence makes the webhook fail to start, and report unready, when the store
+	// can't be reached, instead of running without recording any history.
+	RequirePersistence bool
+
+	// ExportJobsConfig enables the API's export jobs, which write large result sets to
+	// object storage in the background. The export job endpoints are disabled when nil.
+	ExportJobsConfig *export.JobConfig
+
+	// SnapshotUpdates makes the webhook store the object before each UPDATE, as it does for
+	// DELETE, so the API can return the objects before and after the change.
+	SnapshotUpdates bool
+
+	// WatchVolumes makes the webhook watch PersistentVolumes, so the DELETE of a claim records
+	// the reclaim policy of its volume (WATCH_PERSISTENT_VOLUMES). The policy of claims is
+	// unknown otherwise, and their deletion is flagged as a potential data loss.
+	WatchVolumes bool
+
+	// LimitRangeImpact makes the webhook list the pods of the namespace of the LimitRanges
+	// created or updated, to record the containers outside the new limits
+	// (LIMIT_RANGE_IMPACT). ResourceQuota impacts are recorded whatever its value.
+	LimitRangeImpact bool
+
+	// SnapshotConfig selects the fields the webhook stores in the snapshots of matching kinds.
+	// Snapshots hold the object without noise fields when nil.
+	SnapshotConfig *SnapshotConfig
+
+	// ImageProvenance makes the webhook check the digest and cosign signature of the images
+	// deployed by workload changes (IMAGE_PROVENANCE_CONFIG). Images aren't checked when nil.
+	ImageProvenance *ImageProvenanceConfig
+
+	// SpillDir is the directory the webhook spools change events to while the store is
+	// unavailable, saving them once it is reachable again. Events are dead-lettered when empty.
+	SpillDir string
+
+	// DatabasePool sizes and tunes the PostgreSQL connection pool, from the DATABASE_MAX_CONNS,
+	// DATABASE_MIN_CONNS, DATABASE_MAX_CONN_LIFETIME, DATABASE_MAX_CONN_IDLE_TIME,
+	// DATABASE_STATEMENT_CACHE_MODE, DATABASE_STATEMENT_TIMEOUT, DATABASE_SAVE_TIMEOUT and
+	// DATABASE_SLOW_QUERY_THRESHOLD variables.
+	DatabasePool store.PoolConfig
+
+	// DatabaseReadURL is a read-only replica the API sends event queries to, falling back to
+	// DatabaseURL while the replica is down or more than DatabaseReplicaMaxLag behind.
+	DatabaseReadURL       string
+	DatabaseReplicaMaxLag time.Duration
+
+	// PageSizes sets the number of events the API returns per page when no limit is
+	// requested, and the largest limit allowed, from API_DEFAULT_PAGE_SIZE and API_MAX_PAGE_SIZE.
+	PageSizes store.PageSizes
+
+	// IndexAdvisor checks, every INDEX_ADVISOR_INTERVAL, that the JSONB fields filtered on by
+	// at least INDEX_ADVISOR_MIN_QUERIES API queries are indexed, logging the statements
+	// creating the missing indexes, or creating them if INDEX_ADVISOR_CREATE is true.
+	IndexAdvisor store.IndexAdvisorConfig
+
+	// LoadErrors lists the variables LoadConfig couldn't parse. They are logged and ignored,
+	// so a component starts with the rest of its configuration.
+	LoadErrors []LoadError
+}
+
+// LoadError is a configuration variable that couldn't be parsed and was ignored.
+type LoadError struct {
+	Key string // Environment variable, e.g. IGNORE_CONFIG
+	Err error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+// loadError records a variable that couldn't be parsed, logging it as an error.
+func (c *Config) loadError(key string, err error) {
+	klog.Errorf("Invalid %s, ignoring it: %v", key, err)
+	c.LoadErrors = append(c.LoadErrors, LoadError{Key: key, Err: err})
 }
 
 // AuthConfig holds authentication configuration.
@@ -83,6 +262,594 @@ type BlockConfig struct {
 	// Message is the error message returned when a request is blocked.
 	// Default: "Resource blocked by kubechronicle policy"
 	Message string `json:"message,omitempty"`
+
+	// Messages are translations of Message by locale, e.g. {"de": "Ressource gesperrt"},
+	// returned for the namespaces of that locale in the message catalog.
+	Messages map[string]string `json:"messages,omitempty"`
+
+	// Rules are structured block rules with ownership metadata, checked after the patterns above.
+	Rules []BlockRule `json:"rules,omitempty"`
+}
+
+// BlockRule blocks requests matching all of its non-empty pattern lists. At least one
+// pattern list must be set. Owner, reason and ticket are included in the denial message
+// and recorded on blocked events.
+type BlockRule struct {
+	// Name identifies the rule, e.g. "freeze-payments".
+	Name string `json:"name,omitempty"`
+
+	NamespacePatterns    []string `json:"namespace_patterns,omitempty"`
+	NamePatterns         []string `json:"name_patterns,omitempty"`
+	ResourceKindPatterns []string `json:"resource_kind_patterns,omitempty"`
+
+	// OperationPatterns limits the rule to these operations. If empty, all operations are blocked.
+	OperationPatterns []string `json:"operation_patterns,omitempty"`
+
+	// Message overrides the block config's message for this rule.
+	Message string `json:"message,omitempty"`
+
+	// Messages are translations of the rule's message by locale. Rules without a message
+	// use the block config's translations.
+	Messages map[string]string `json:"messages,omitempty"`
+
+	Owner     string `json:"owner,omitempty"`      // Team or person responsible for the rule
+	Reason    string `json:"reason,omitempty"`     // Why the rule exists
+	TicketURL string `json:"ticket_url,omitempty"` // Change ticket or issue tracking the rule
+
+	// ExpiresAt disables the rule after this time, e.g. at the end of a change freeze.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// StartsAt enables the rule from this time, e.g. at the start of a scheduled change freeze.
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+}
+
+// Expired reports whether the rule has an expiry time at or before now.
+func (r *BlockRule) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+// Active reports whether the rule blocks requests at now: it has started and not expired.
+func (r *BlockRule) Active(now time.Time) bool {
+	return (r.StartsAt == nil || !now.Before(*r.StartsAt)) && !r.Expired(now)
+}
+
+// WarnConfig holds warn rules, soft policy hints returned to the client as admission
+// warnings (shown by kubectl as "Warning: ...") without blocking the request.
+type WarnConfig struct {
+	// Rules are all evaluated; each matching rule adds one warning.
+	Rules []WarnRule `json:"rules,omitempty"`
+}
+
+// WarnRule warns about requests matching all of its non-empty pattern lists. A rule
+// without patterns matches every request.
+type WarnRule struct {
+	// Name identifies the rule, e.g. "payments-freeze-notice".
+	Name string `json:"name,omitempty"`
+
+	NamespacePatterns    []string `json:"namespace_patterns,omitempty"`
+	NamePatterns         []string `json:"name_patterns,omitempty"`
+	ResourceKindPatterns []string `json:"resource_kind_patterns,omitempty"`
+
+	// OperationPatterns limits the rule to these operations. If empty, all operations match.
+	OperationPatterns []string `json:"operation_patterns,omitempty"`
+
+	// RequiredLabels makes the rule warn only when the object is missing one of these labels.
+	// Only CREATE and UPDATE requests carry an object to check.
+	RequiredLabels []string `json:"required_labels,omitempty"`
+
+	// Message is the warning, e.g. "payments is frozen starting Friday".
+	Message string `json:"message,omitempty"`
+
+	// ExpiresAt disables the rule after this time.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the rule has an expiry time at or before now.
+func (r *WarnRule) Expired(now time.Time) bool {
+	return r.ExpiresAt != nil && !now.Before(*r.ExpiresAt)
+}
+
+// SamplingConfig holds sampling rules for high-churn resources.
+type SamplingConfig struct {
+	// Rules are evaluated in order; the first matching rule decides the event's sample rate.
+	// Events matching no rule are always recorded.
+	Rules []SamplingRule `json:"rules,omitempty"`
+}
+
+// SamplingRule records 1 in Rate events matching all of its non-empty pattern lists.
+type SamplingRule struct {
+	// ResourceKindPatterns is a list of patterns for resource kinds to sample.
+	// Supports wildcards: * matches any sequence.
+	// Examples: "Endpoints", "EndpointSlice", "Lease"
+	ResourceKindPatterns []string `json:"resource_kind_patterns,omitempty"`
+
+	// NamespacePatterns is a list of patterns for namespaces to sample.
+	// Supports wildcards: * matches any sequence.
+	NamespacePatterns []string `json:"namespace_patterns,omitempty"`
+
+	// OperationPatterns is a list of operations to sample (CREATE, UPDATE, DELETE).
+	// If empty, all operations are sampled.
+	OperationPatterns []string `json:"operation_patterns,omitempty"`
+
+	// Rate records one in every Rate matching events (e.g. 100). Values <= 1 record every event.
+	Rate int `json:"rate"`
+}
+
+// SnapshotConfig selects the fields of the objects stored in DELETE and UPDATE snapshots,
+// trading storage against what can be reconstructed after the fact.
+type SnapshotConfig struct {
+	// Rules are evaluated in order; the first rule matching the resource kind applies.
+	// Snapshots of kinds matching no rule hold the object without noise fields.
+	Rules []SnapshotRule `json:"rules,omitempty"`
+}
+
+// SnapshotRule selects the fields of the snapshots of the resource kinds it matches. Secret
+// values are hashed whatever the rule.
+type SnapshotRule struct {
+	// ResourceKindPatterns is a list of patterns for the resource kinds the rule applies to.
+	// Supports wildcards: * matches any sequence.
+	ResourceKindPatterns []string `json:"resource_kind_patterns"`
+
+	// IncludeFields keeps only these fields, as dot-separated paths (e.g. "spec",
+	// "metadata.labels"). apiVersion, kind, metadata.name and metadata.namespace are always
+	// kept. Every field is kept when empty.
+	IncludeFields []string `json:"include_fields,omitempty"`
+
+	// ExcludeFields removes these fields, as dot-separated paths (e.g. "status").
+	ExcludeFields []string `json:"exclude_fields,omitempty"`
+
+	// KeepNoise keeps the noise fields removed from diffs and snapshots by default, such as
+	// metadata.managedFields and metadata.resourceVersion, to store the full object.
+	KeepNoise bool `json:"keep_noise,omitempty"`
+}
+
+// Validate checks that every rule has resource kind patterns and valid field paths.
+func (c *SnapshotConfig) Validate() error {
+	for i, rule := range c.Rules {
+		if len(rule.ResourceKindPatterns) == 0 {
+			return fmt.Errorf("rule %d has no resource kind patterns", i)
+		}
+		for _, path := range append(append([]string{}, rule.IncludeFields...), rule.ExcludeFields...) {
+			if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+				return fmt.Errorf("rule %d has an invalid field path %q", i, path)
+			}
+		}
+	}
+	return nil
+}
+
+// ImageProvenanceConfig configures the provenance checks of the images deployed by the
+// CREATEs and UPDATEs of workloads. The registry of each new image is asked for its digest
+// and for the cosign signature tag of that digest; signatures aren't verified cryptographically.
+type ImageProvenanceConfig struct {
+	// ProtectedNamespaces is a list of patterns for the namespaces where deploying an
+	// unsigned image is alerted. Supports wildcards: * matches any sequence.
+	ProtectedNamespaces []string `json:"protected_namespaces,omitempty"`
+
+	// Timeout of the registry requests checking an image (default: 5s).
+	Timeout string `json:"timeout,omitempty"`
+
+	// CacheTTL is how long the result of checking an image is reused (default: 1h). Images
+	// whose registry couldn't be queried are checked again on their next change.
+	CacheTTL string `json:"cache_ttl,omitempty"`
+
+	// InsecureRegistries are the registry hosts queried over plain HTTP, e.g. "localhost:5000".
+	InsecureRegistries []string `json:"insecure_registries,omitempty"`
+}
+
+// Defaults of the image provenance checks.
+const (
+	DefaultImageProvenanceTimeout  = 5 * time.Second
+	DefaultImageProvenanceCacheTTL = time.Hour
+)
+
+// Validate checks that the timeout and cache TTL are positive durations.
+func (c *ImageProvenanceConfig) Validate() error {
+	if _, err := c.TimeoutDuration(); err != nil {
+		return err
+	}
+	_, err := c.CacheTTLDuration()
+	return err
+}
+
+// TimeoutDuration returns the timeout of registry requests, or its default.
+func (c *ImageProvenanceConfig) TimeoutDuration() (time.Duration, error) {
+	return positiveDuration("timeout", c.Timeout, DefaultImageProvenanceTimeout)
+}
+
+// CacheTTLDuration returns how long check results are reused, or its default.
+func (c *ImageProvenanceConfig) CacheTTLDuration() (time.Duration, error) {
+	return positiveDuration("cache_ttl", c.CacheTTL, DefaultImageProvenanceCacheTTL)
+}
+
+// positiveDuration parses value as a positive duration, returning def when it's empty.
+func positiveDuration(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s %q is not a positive duration", name, value)
+	}
+	return d, nil
+}
+
+// PluginConfig enables an admission plugin registered with plugin.Register.
+type PluginConfig struct {
+	// Name is the name the plugin is registered under.
+	Name string `json:"name"`
+
+	// Config is passed to the plugin's factory as is.
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
+// Defaults of the grouping of DELETE bursts into DELETE_COLLECTION events.
+const (
+	DefaultDeleteCollectionMinDeletes = 10
+	DefaultDeleteCollectionWindow     = 5 * time.Second
+)
+
+// DefaultNamespaceCascadeWindow is how long the cascade of a deleted namespace stays open
+// after its last deletion when no window is configured.
+const DefaultNamespaceCascadeWindow = time.Minute
+
+// DefaultChurnThreshold is the number of changes per day above which a resource is flagged
+// as churning when no threshold is configured.
+const DefaultChurnThreshold = 500
+
+// ChurnConfig holds the change rates above which the API flags a resource as churning, such
+// as a ConfigMap a misbehaving controller rewrites in a loop.
+type ChurnConfig struct {
+	// ThresholdPerDay is the number of changes per day above which a resource is flagged
+	// (default: DefaultChurnThreshold).
+	ThresholdPerDay int `json:"threshold_per_day,omitempty"`
+
+	// Rules set other thresholds for matching resources. Rules are evaluated in order; the
+	// first rule matching a resource applies.
+	Rules []ChurnRule `json:"rules,omitempty"`
+}
+
+// ChurnRule flags the resources matching all of its patterns when they change more than
+// ThresholdPerDay times per day.
+type ChurnRule struct {
+	// Name identifies the rule in the churn report.
+	Name string `json:"name"`
+
+	// NamespacePatterns and ResourceKindPatterns select the resources the rule applies to.
+	// Supports wildcards: * matches any sequence. An empty list matches every resource.
+	NamespacePatterns    []string `json:"namespace_patterns,omitempty"`
+	ResourceKindPatterns []string `json:"resource_kind_patterns,omitempty"`
+
+	ThresholdPerDay int `json:"threshold_per_day"`
+}
+
+// DefaultThreshold returns the threshold of resources matching no rule.
+func (c *ChurnConfig) DefaultThreshold() int {
+	if c == nil || c.ThresholdPerDay <= 0 {
+		return DefaultChurnThreshold
+	}
+	return c.ThresholdPerDay
+}
+
+// Validate checks that the thresholds are positive and every rule has patterns.
+func (c *ChurnConfig) Validate() error {
+	if c.ThresholdPerDay < 0 {
+		return fmt.Errorf("threshold_per_day must not be negative")
+	}
+	for i, rule := range c.Rules {
+		if len(rule.NamespacePatterns) == 0 && len(rule.ResourceKindPatterns) == 0 {
+			return fmt.Errorf("rule %d (%s) has no namespace or resource kind patterns", i, rule.Name)
+		}
+		if rule.ThresholdPerDay <= 0 {
+			return fmt.Errorf("rule %d (%s) must have a positive threshold_per_day", i, rule.Name)
+		}
+	}
+	return nil
+}
+
+// ChangeWindowConfig declares the approved change windows of namespaces, for change
+// management audits: the change compliance report flags the changes made outside them.
+type ChangeWindowConfig struct {
+	// Timezone is the IANA time zone of the windows' days and times (default: UTC).
+	Timezone string `json:"timezone,omitempty"`
+
+	// Windows are weekly periods in which changes are approved. Changes to a namespace
+	// matched by any window must fall in one of the windows matching it; namespaces matched
+	// by none can change at any time.
+	Windows []ChangeWindow `json:"windows,omitempty"`
+
+	// ExcludeUsers are patterns of users whose changes aren't checked, e.g. "system:*" for
+	// controllers. Supports wildcards: * matches any sequence.
+	ExcludeUsers []string `json:"exclude_users,omitempty"`
+}
+
+// ChangeWindow is a weekly period in which changes to matching namespaces are approved.
+type ChangeWindow struct {
+	// Name identifies the window, e.g. "business-hours".
+	Name string `json:"name"`
+
+	// NamespacePatterns selects the namespaces the window applies to. Supports wildcards:
+	// * matches any sequence. An empty list matches every namespace.
+	NamespacePatterns []string `json:"namespace_patterns,omitempty"`
+
+	// Days are the days the window opens, "mon" to "sun". An empty list is every day.
+	Days []string `json:"days,omitempty"`
+
+	// Start and End are the times the window opens and closes, as HH:MM. A window whose End
+	// is before its Start closes the next day, e.g. 22:00 to 02:00.
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// weekdays maps the day names of change windows to days.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// clockMinutes parses an HH:MM time of day into minutes after midnight.
+func clockMinutes(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate checks the time zone and that every window has valid days and times.
+func (c *ChangeWindowConfig) Validate() error {
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	for i, window := range c.Windows {
+		for _, day := range window.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("window %d (%s): invalid day %q, must be mon to sun", i, window.Name, day)
+			}
+		}
+		start, err := clockMinutes(window.Start)
+		if err != nil {
+			return fmt.Errorf("window %d (%s): start: %w", i, window.Name, err)
+		}
+		end, err := clockMinutes(window.End)
+		if err != nil {
+			return fmt.Errorf("window %d (%s): end: %w", i, window.Name, err)
+		}
+		if start == end {
+			return fmt.Errorf("window %d (%s): start and end must differ", i, window.Name)
+		}
+	}
+	return nil
+}
+
+// ExcludesUser reports whether the changes of username aren't checked.
+func (c *ChangeWindowConfig) ExcludesUser(username string) bool {
+	if c == nil {
+		return false
+	}
+	for _, pattern := range c.ExcludeUsers {
+		if store.MatchWildcard(pattern, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckChange reports whether any windows apply to namespace, and if so whether a change
+// at t falls in one of them.
+func (c *ChangeWindowConfig) CheckChange(namespace string, t time.Time) (governed, approved bool) {
+	if c == nil {
+		return false, false
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	for _, window := range c.Windows {
+		if !matchesAnyOrEmpty(window.NamespacePatterns, namespace) {
+			continue
+		}
+		governed = true
+		if window.contains(local) {
+			return true, true
+		}
+	}
+	return governed, false
+}
+
+// contains reports whether the window is open at t, in the windows' time zone.
+func (w *ChangeWindow) contains(t time.Time) bool {
+	start, err := clockMinutes(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := clockMinutes(w.End)
+	if err != nil {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	if start < end {
+		return w.opensOn(t.Weekday()) && minutes >= start && minutes < end
+	}
+	// Spans midnight: open from start on its days, and until end the day after
+	return (w.opensOn(t.Weekday()) && minutes >= start) || (w.opensOn((t.Weekday()+6)%7) && minutes < end)
+}
+
+// opensOn reports whether the window opens on day.
+func (w *ChangeWindow) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if d, ok := weekdays[strings.ToLower(name)]; ok && d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyOrEmpty reports whether value matches one of patterns, or patterns is empty.
+func matchesAnyOrEmpty(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if store.MatchWildcard(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaConfig holds soft limits on the events recorded per namespace or team, so one noisy
+// team can't use up the storage budget. Events past a quota are sampled instead of dropped.
+type QuotaConfig struct {
+	// Window is the period quotas are counted over, as a Go duration (default: 1h).
+	Window string `json:"window,omitempty"`
+
+	// Rules are evaluated in order; the first rule matching an event's namespace counts it.
+	// Events matching no rule are not limited.
+	Rules []QuotaRule `json:"rules"`
+}
+
+// QuotaRule records up to MaxEvents events per window in the namespaces matching
+// NamespacePatterns, then 1 in SampleRate events until the window ends.
+type QuotaRule struct {
+	// Name identifies the rule in logs and the webhook's runtime config, e.g. the team.
+	Name string `json:"name"`
+
+	// NamespacePatterns is a list of patterns for the namespaces the quota applies to.
+	// Supports wildcards: * matches any sequence.
+	NamespacePatterns []string `json:"namespace_patterns"`
+
+	// PerNamespace gives each matching namespace its own quota. The namespaces share the
+	// quota when false.
+	PerNamespace bool `json:"per_namespace,omitempty"`
+
+	// MaxEvents is the number of events recorded in full per window.
+	MaxEvents int `json:"max_events"`
+
+	// SampleRate records one in every SampleRate events past the quota (default: 10).
+	SampleRate int `json:"sample_rate,omitempty"`
+}
+
+// WindowDuration returns the period quotas are counted over.
+func (c *QuotaConfig) WindowDuration() time.Duration {
+	if window, err := time.ParseDuration(c.Window); err == nil && window > 0 {
+		return window
+	}
+	return time.Hour
+}
+
+// Validate checks the quota window and that every rule has namespaces and a quota.
+func (c *QuotaConfig) Validate() error {
+	if c.Window != "" {
+		window, err := time.ParseDuration(c.Window)
+		if err != nil {
+			return fmt.Errorf("invalid window: %w", err)
+		}
+		if window <= 0 {
+			return fmt.Errorf("window must be positive")
+		}
+	}
+	for i, rule := range c.Rules {
+		if len(rule.NamespacePatterns) == 0 {
+			return fmt.Errorf("rule %d (%s) has no namespace patterns", i, rule.Name)
+		}
+		if rule.MaxEvents <= 0 {
+			return fmt.Errorf("rule %d (%s) must have a positive max_events", i, rule.Name)
+		}
+		if rule.SampleRate < 0 {
+			return fmt.Errorf("rule %d (%s) has a negative sample_rate", i, rule.Name)
+		}
+	}
+	return nil
+}
+
+// ExecRiskConfig holds the rules scoring the commands of exec events.
+type ExecRiskConfig struct {
+	// Rules are added to the built-in rules. A rule with the name of a built-in rule replaces it.
+	Rules []ExecRiskRule `json:"rules,omitempty"`
+
+	// DisableDefaults drops the built-in rules, leaving only Rules.
+	DisableDefaults bool `json:"disable_defaults,omitempty"`
+}
+
+// ExecRiskRule adds Score to the risk score of exec events whose command matches Pattern.
+type ExecRiskRule struct {
+	// Name identifies the rule in the event's risk reasons, e.g. "pipe-to-shell".
+	Name string `json:"name"`
+
+	// Pattern is a regular expression matched against the command, its arguments joined by spaces.
+	Pattern string `json:"pattern"`
+
+	// Score is added to the event's risk score, which is capped at 100. A score of 0 disables the rule.
+	Score int `json:"score"`
+}
+
+// DeploymentWebhookConfig configures the endpoints receiving GitHub and GitLab deployment
+// and pipeline webhooks, recorded as DEPLOYMENT events.
+type DeploymentWebhookConfig struct {
+	// GitHubSecret is the secret GitHub signs webhook payloads with.
+	// GitHub webhooks are rejected when empty.
+	GitHubSecret string `json:"github_secret,omitempty"`
+
+	// GitLabToken is the secret token GitLab sends with webhooks.
+	// GitLab webhooks are rejected when empty.
+	GitLabToken string `json:"gitlab_token,omitempty"`
+
+	// Environments maps CI environment names to the namespace they deploy to,
+	// e.g. {"production": "prod"}. Other environments deploy to the namespace of the same name.
+	Environments map[string]string `json:"environments,omitempty"`
+}
+
+// CloudChangeConfig configures the endpoints receiving cloud provider audit events about
+// the cluster (node pool scaling, control plane upgrades), recorded as CLOUD_CHANGE events.
+type CloudChangeConfig struct {
+	// Token is the secret the provider's event delivery sends, in the X-Kubechronicle-Token
+	// header or the token query parameter. Cloud events are rejected when empty.
+	Token string `json:"token,omitempty"`
+
+	// Clusters lists the names of the clusters whose events are recorded, so events of other
+	// clusters in the same account or project are ignored. All clusters are recorded when empty.
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// TenancyConfig maps namespaces to tenants, so a single kubechronicle can serve several
+// teams or customers. API users see the events in the namespaces of their tenants only.
+type TenancyConfig struct {
+	// Tenants maps tenant names to the namespaces they own and their members.
+	Tenants map[string]TenantConfig `json:"tenants"`
+
+	// UnscopedRoles see the events of every tenant and of cluster-scoped resources
+	// (default: admin).
+	UnscopedRoles []string `json:"unscoped_roles,omitempty"`
+
+	// RowLevelSecurity also enforces the scope with PostgreSQL row-level security policies
+	// on change_events, so a query missing the tenant condition can't return other tenants' events.
+	RowLevelSecurity bool `json:"row_level_security,omitempty"`
+}
+
+// TenantConfig is a tenant: its namespaces and the users and roles that belong to it.
+type TenantConfig struct {
+	// Namespaces lists namespace patterns (* matches any sequence of characters).
+	Namespaces []string `json:"namespaces"`
+	Users      []string `json:"users,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+}
+
+// Validate checks that every tenant owns namespaces.
+func (c *TenancyConfig) Validate() error {
+	if len(c.Tenants) == 0 {
+		return fmt.Errorf("no tenants defined")
+	}
+	for name, tenant := range c.Tenants {
+		if len(tenant.Namespaces) == 0 {
+			return fmt.Errorf("tenant %q has no namespaces", name)
+		}
+	}
+	return nil
 }
 
 // LoadConfig loads configuration from environment variables and flags.
@@ -93,13 +860,127 @@ func LoadConfig() *Config {
 		TLSKeyPath:  getEnv("TLS_KEY_PATH", "/etc/webhook/certs/tls.key"),
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
+
+		ListenAddress: getEnv("LISTEN_ADDRESS", ""),
+
+		TLSMinVersion:   getEnv("TLS_MIN_VERSION", "1.2"),
+		TLSCipherSuites: parseList(getEnv("TLS_CIPHER_SUITES", "")),
+		TLSClientAuth:   getEnv("TLS_CLIENT_AUTH", ""),
+		TLSClientCAPath: getEnv("TLS_CLIENT_CA_PATH", ""),
+
+		EncryptionKey: getEnv("ENCRYPTION_KEY", ""),
+		DecryptRoles:  parseList(getEnv("DECRYPT_ROLES", "admin")),
+		PseudonymKey:  getEnv("PSEUDONYM_KEY", ""),
+		RecorderRoles: parseList(getEnv("RECORDER_ROLES", "admin,recorder")),
+		SpillDir:      getEnv("SPILL_DIR", ""),
+	}
+
+	cfg.loadDatabasePool()
+	cfg.loadPageSizes()
+	cfg.loadIndexAdvisor()
+	cfg.loadHeartbeatInterval()
+	cfg.loadRetention()
+	cfg.loadDeleteCollection()
+
+	cfg.NamespaceCascadeWindow = DefaultNamespaceCascadeWindow
+	if value := getEnv("NAMESPACE_CASCADE_WINDOW", ""); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			cfg.NamespaceCascadeWindow = d
+		} else {
+			cfg.loadError("NAMESPACE_CASCADE_WINDOW", fmt.Errorf("%q is not a duration, using %s", value, DefaultNamespaceCascadeWindow))
+		}
+	}
+
+	cfg.DatabaseReadURL = getEnv("DATABASE_READ_URL", "")
+	cfg.DatabaseReplicaMaxLag = 30 * time.Second
+	if maxLag := getEnv("DATABASE_REPLICA_MAX_LAG", ""); maxLag != "" {
+		if d, err := time.ParseDuration(maxLag); err == nil && d >= 0 {
+			cfg.DatabaseReplicaMaxLag = d
+		} else {
+			cfg.loadError("DATABASE_REPLICA_MAX_LAG", fmt.Errorf("%q is not a duration, using 30s", maxLag))
+		}
+	}
+
+	if requirePersistence := getEnv("REQUIRE_PERSISTENCE", ""); requirePersistence == "true" || requirePersistence == "1" {
+		cfg.RequirePersistence = true
+	}
+
+	if snapshotUpdates := getEnv("SNAPSHOT_UPDATES", ""); snapshotUpdates == "true" || snapshotUpdates == "1" {
+		cfg.SnapshotUpdates = true
+	}
+
+	if watchVolumes := getEnv("WATCH_PERSISTENT_VOLUMES", ""); watchVolumes == "true" || watchVolumes == "1" {
+		cfg.WatchVolumes = true
+	}
+
+	if limitRangeImpact := getEnv("LIMIT_RANGE_IMPACT", ""); limitRangeImpact == "true" || limitRangeImpact == "1" {
+		cfg.LimitRangeImpact = true
+	}
+
+	// Load snapshot configuration if provided
+	if snapshotJSON := getEnv("SNAPSHOT_CONFIG", ""); snapshotJSON != "" {
+		var snapshotConfig SnapshotConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(snapshotJSON)), &snapshotConfig)
+		if err == nil {
+			err = snapshotConfig.Validate()
+		}
+		if err == nil {
+			cfg.SnapshotConfig = &snapshotConfig
+			klog.Infof("Loaded snapshot config: %d rules", len(snapshotConfig.Rules))
+		} else {
+			cfg.loadError("SNAPSHOT_CONFIG", err)
+		}
+	}
+
+	// Load image provenance configuration if provided
+	if provenanceJSON := getEnv("IMAGE_PROVENANCE_CONFIG", ""); provenanceJSON != "" {
+		var provenanceConfig ImageProvenanceConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(provenanceJSON)), &provenanceConfig)
+		if err == nil {
+			err = provenanceConfig.Validate()
+		}
+		if err == nil {
+			cfg.ImageProvenance = &provenanceConfig
+			klog.Infof("Loaded image provenance config: %d protected namespace patterns", len(provenanceConfig.ProtectedNamespaces))
+		} else {
+			cfg.loadError("IMAGE_PROVENANCE_CONFIG", err)
+		}
 	}
 
 	// Load alerting configuration if provided
 	if alertJSON := getEnv("ALERT_CONFIG", ""); alertJSON != "" {
 		var alertConfig alerting.Config
-		if err := json.Unmarshal([]byte(alertJSON), &alertConfig); err == nil {
+		err := json.Unmarshal([]byte(alertJSON), &alertConfig)
+		if err == nil {
+			// Secrets referenced from the ConfigMap come from the Secret's ALERT_SECRETS key
+			err = alertConfig.ResolveSecrets(getEnv(alerting.SecretKey, ""))
+		}
+		if err == nil {
 			cfg.AlertConfig = &alertConfig
+		} else {
+			cfg.loadError("ALERT_CONFIG", err)
+		}
+	}
+
+	// Load export configuration if provided
+	if exportJSON := getEnv("EXPORT_CONFIG", ""); exportJSON != "" {
+		var exportConfig export.Config
+		if err := json.Unmarshal([]byte(strings.TrimSpace(exportJSON)), &exportConfig); err == nil {
+			cfg.ExportConfig = &exportConfig
+		} else {
+			cfg.loadError("EXPORT_CONFIG", err)
+		}
+	}
+
+	// Load export jobs configuration if provided
+	if exportJobsJSON := getEnv("EXPORT_JOBS_CONFIG", ""); exportJobsJSON != "" {
+		var exportJobsConfig export.JobConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(exportJobsJSON)), &exportJobsConfig); err != nil {
+			cfg.loadError("EXPORT_JOBS_CONFIG", err)
+		} else if exportJobsConfig.S3 == nil {
+			cfg.loadError("EXPORT_JOBS_CONFIG", fmt.Errorf("s3 is required"))
+		} else {
+			cfg.ExportJobsConfig = &exportJobsConfig
 		}
 	}
 
@@ -112,7 +993,7 @@ func LoadConfig() *Config {
 			klog.Infof("Loaded ignore config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v",
 				ignoreConfig.NamespacePatterns, ignoreConfig.NamePatterns, ignoreConfig.ResourceKindPatterns)
 		} else {
-			klog.Warningf("Failed to parse IGNORE_CONFIG JSON: %v, raw value: %q", err, ignoreJSON)
+			cfg.loadError("IGNORE_CONFIG", fmt.Errorf("%w, raw value: %q", err, ignoreJSON))
 		}
 	} else {
 		// Support comma-separated lists for backward compatibility
@@ -143,7 +1024,189 @@ func LoadConfig() *Config {
 			klog.Infof("Loaded block config: namespace_patterns=%v, name_patterns=%v, resource_kind_patterns=%v, operation_patterns=%v",
 				blockConfig.NamespacePatterns, blockConfig.NamePatterns, blockConfig.ResourceKindPatterns, blockConfig.OperationPatterns)
 		} else {
-			klog.Warningf("Failed to parse BLOCK_CONFIG JSON: %v, raw value: %q", err, blockJSON)
+			cfg.loadError("BLOCK_CONFIG", fmt.Errorf("%w, raw value: %q", err, blockJSON))
+		}
+	}
+
+	// Load sampling configuration if provided
+	if samplingJSON := getEnv("SAMPLING_CONFIG", ""); samplingJSON != "" {
+		var samplingConfig SamplingConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(samplingJSON)), &samplingConfig); err == nil {
+			cfg.SamplingConfig = &samplingConfig
+			klog.Infof("Loaded sampling config: %d rules", len(samplingConfig.Rules))
+		} else {
+			cfg.loadError("SAMPLING_CONFIG", err)
+		}
+	}
+
+	// Load admission plugins if provided
+	if pluginJSON := getEnv("PLUGIN_CONFIG", ""); pluginJSON != "" {
+		var pluginConfigs []PluginConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(pluginJSON)), &pluginConfigs)
+		for i, plugin := range pluginConfigs {
+			if err == nil && plugin.Name == "" {
+				err = fmt.Errorf("plugin %d has no name", i)
+			}
+		}
+		if err == nil {
+			cfg.PluginConfigs = pluginConfigs
+			klog.Infof("Loaded plugin config: %d plugins", len(pluginConfigs))
+		} else {
+			cfg.loadError("PLUGIN_CONFIG", err)
+		}
+	}
+
+	// Load quota configuration if provided
+	if quotaJSON := getEnv("QUOTA_CONFIG", ""); quotaJSON != "" {
+		var quotaConfig QuotaConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(quotaJSON)), &quotaConfig)
+		if err == nil {
+			err = quotaConfig.Validate()
+		}
+		if err == nil {
+			cfg.QuotaConfig = &quotaConfig
+			klog.Infof("Loaded quota config: %d rules per %s", len(quotaConfig.Rules), quotaConfig.WindowDuration())
+		} else {
+			cfg.loadError("QUOTA_CONFIG", err)
+		}
+	}
+
+	// Load churn configuration if provided
+	if churnJSON := getEnv("CHURN_CONFIG", ""); churnJSON != "" {
+		var churnConfig ChurnConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(churnJSON)), &churnConfig)
+		if err == nil {
+			err = churnConfig.Validate()
+		}
+		if err == nil {
+			cfg.ChurnConfig = &churnConfig
+			klog.Infof("Loaded churn config: %d changes per day, %d rules", churnConfig.DefaultThreshold(), len(churnConfig.Rules))
+		} else {
+			cfg.loadError("CHURN_CONFIG", err)
+		}
+	}
+
+	// Load change window configuration if provided
+	if windowsJSON := getEnv("CHANGE_WINDOW_CONFIG", ""); windowsJSON != "" {
+		var windowConfig ChangeWindowConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(windowsJSON)), &windowConfig)
+		if err == nil {
+			err = windowConfig.Validate()
+		}
+		if err == nil {
+			cfg.ChangeWindowConfig = &windowConfig
+			klog.Infof("Loaded change window config: %d windows", len(windowConfig.Windows))
+		} else {
+			cfg.loadError("CHANGE_WINDOW_CONFIG", err)
+		}
+	}
+
+	// Load pseudonymization configuration if provided
+	if pseudonymJSON := getEnv("PSEUDONYM_CONFIG", ""); pseudonymJSON != "" {
+		var pseudonymConfig pseudonym.Config
+		err := json.Unmarshal([]byte(strings.TrimSpace(pseudonymJSON)), &pseudonymConfig)
+		if err == nil {
+			err = pseudonymConfig.Validate()
+		}
+		if err == nil {
+			cfg.PseudonymConfig = &pseudonymConfig
+			klog.Infof("Loaded pseudonym config: fields=%v", pseudonymConfig.Fields)
+		} else {
+			cfg.loadError("PSEUDONYM_CONFIG", err)
+		}
+	}
+
+	// Load message catalog if provided
+	if catalogJSON := getEnv("MESSAGE_CATALOG", ""); catalogJSON != "" {
+		var catalog i18n.Catalog
+		err := json.Unmarshal([]byte(strings.TrimSpace(catalogJSON)), &catalog)
+		if err == nil {
+			err = catalog.Validate()
+		}
+		if err == nil {
+			cfg.MessageCatalog = &catalog
+			klog.Infof("Loaded message catalog: %d locales, %d namespace locales", len(catalog.Messages), len(catalog.NamespaceLocales))
+		} else {
+			cfg.loadError("MESSAGE_CATALOG", err)
+		}
+	}
+
+	// Load event source health configuration if provided
+	if sourcesJSON := getEnv("SOURCE_HEALTH_CONFIG", ""); sourcesJSON != "" {
+		var sourcesConfig sources.Config
+		err := json.Unmarshal([]byte(strings.TrimSpace(sourcesJSON)), &sourcesConfig)
+		if err == nil {
+			err = sourcesConfig.Validate()
+		}
+		if err == nil {
+			cfg.SourceHealthConfig = &sourcesConfig
+			klog.Infof("Loaded source health config: cluster=%s", sourcesConfig.ClusterName())
+		} else {
+			cfg.loadError("SOURCE_HEALTH_CONFIG", err)
+		}
+	}
+
+	// Load warn configuration if provided
+	if warnJSON := getEnv("WARN_CONFIG", ""); warnJSON != "" {
+		var warnConfig WarnConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(warnJSON)), &warnConfig); err == nil {
+			cfg.WarnConfig = &warnConfig
+			klog.Infof("Loaded warn config: %d rules", len(warnConfig.Rules))
+		} else {
+			cfg.loadError("WARN_CONFIG", err)
+		}
+	}
+
+	// Load exec risk configuration if provided
+	if riskJSON := getEnv("EXEC_RISK_CONFIG", ""); riskJSON != "" {
+		var riskConfig ExecRiskConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(riskJSON)), &riskConfig); err == nil {
+			cfg.ExecRiskConfig = &riskConfig
+			klog.Infof("Loaded exec risk config: %d rules", len(riskConfig.Rules))
+		} else {
+			cfg.loadError("EXEC_RISK_CONFIG", err)
+		}
+	}
+
+	// Load deployment webhook configuration if provided
+	if deploymentJSON := getEnv("DEPLOYMENT_WEBHOOK_CONFIG", ""); deploymentJSON != "" {
+		var deploymentConfig DeploymentWebhookConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(deploymentJSON)), &deploymentConfig); err == nil {
+			cfg.DeploymentWebhookConfig = &deploymentConfig
+			klog.Infof("Loaded deployment webhook config: github=%t, gitlab=%t",
+				deploymentConfig.GitHubSecret != "", deploymentConfig.GitLabToken != "")
+		} else {
+			cfg.loadError("DEPLOYMENT_WEBHOOK_CONFIG", err)
+		}
+	}
+
+	// Load cloud change configuration if provided
+	if cloudJSON := getEnv("CLOUD_CHANGE_CONFIG", ""); cloudJSON != "" {
+		var cloudConfig CloudChangeConfig
+		if err := json.Unmarshal([]byte(strings.TrimSpace(cloudJSON)), &cloudConfig); err == nil {
+			cfg.CloudChangeConfig = &cloudConfig
+			klog.Infof("Loaded cloud change config: %d clusters", len(cloudConfig.Clusters))
+		} else {
+			cfg.loadError("CLOUD_CHANGE_CONFIG", err)
+		}
+	}
+
+	// Load tenancy configuration if provided
+	if tenancyJSON := getEnv("TENANCY_CONFIG", ""); tenancyJSON != "" {
+		var tenancyConfig TenancyConfig
+		err := json.Unmarshal([]byte(strings.TrimSpace(tenancyJSON)), &tenancyConfig)
+		if err == nil {
+			err = tenancyConfig.Validate()
+		}
+		if err == nil {
+			if tenancyConfig.UnscopedRoles == nil {
+				tenancyConfig.UnscopedRoles = []string{"admin"}
+			}
+			cfg.TenancyConfig = &tenancyConfig
+			klog.Infof("Loaded tenancy config: %d tenants, row-level security=%t",
+				len(tenancyConfig.Tenants), tenancyConfig.RowLevelSecurity)
+		} else {
+			cfg.loadError("TENANCY_CONFIG", err)
 		}
 	}
 
@@ -156,7 +1219,7 @@ func LoadConfig() *Config {
 		// JWT Secret (required if auth is enabled)
 		authConfig.JWTSecret = getEnv("JWT_SECRET", "")
 		if authConfig.JWTSecret == "" {
-			klog.Warning("AUTH_ENABLED is true but JWT_SECRET is not set. Authentication may not work correctly.")
+			cfg.loadError("JWT_SECRET", fmt.Errorf("required when AUTH_ENABLED is true"))
 		}
 		
 		// JWT Expiration (default: 24 hours)
@@ -164,6 +1227,7 @@ func LoadConfig() *Config {
 		if hours, err := strconv.Atoi(expHours); err == nil && hours > 0 {
 			authConfig.JWTExpirationHours = hours
 		} else {
+			cfg.loadError("JWT_EXPIRATION_HOURS", fmt.Errorf("%q is not a positive number of hours, using 24", expHours))
 			authConfig.JWTExpirationHours = 24
 		}
 		
@@ -178,6 +1242,142 @@ func LoadConfig() *Config {
 }
 
 // parseList parses a comma-separated list of strings.
+// loadDatabasePool loads the connection pool settings. Unset variables keep the defaults.
+func (c *Config) loadDatabasePool() {
+	c.DatabasePool = store.PoolConfig{}
+	for _, v := range []struct {
+		key    string
+		target *int32
+	}{
+		{"DATABASE_MAX_CONNS", &c.DatabasePool.MaxConns},
+		{"DATABASE_MIN_CONNS", &c.DatabasePool.MinConns},
+	} {
+		if value := getEnv(v.key, ""); value != "" {
+			if n, err := strconv.ParseInt(value, 10, 32); err == nil && n >= 0 {
+				*v.target = int32(n)
+			} else {
+				c.loadError(v.key, fmt.Errorf("%q is not a number of connections", value))
+			}
+		}
+	}
+	for _, v := range []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"DATABASE_MAX_CONN_LIFETIME", &c.DatabasePool.MaxConnLifetime},
+		{"DATABASE_MAX_CONN_IDLE_TIME", &c.DatabasePool.MaxConnIdleTime},
+		{"DATABASE_STATEMENT_TIMEOUT", &c.DatabasePool.StatementTimeout},
+		{"DATABASE_SAVE_TIMEOUT", &c.DatabasePool.SaveTimeout},
+		{"DATABASE_SLOW_QUERY_THRESHOLD", &c.DatabasePool.SlowQueryThreshold},
+	} {
+		if value := getEnv(v.key, ""); value != "" {
+			if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+				*v.target = d
+			} else {
+				c.loadError(v.key, fmt.Errorf("%q is not a duration, e.g. 30s", value))
+			}
+		}
+	}
+	c.DatabasePool.StatementCacheMode = getEnv("DATABASE_STATEMENT_CACHE_MODE", "")
+
+	if err := c.DatabasePool.Validate(); err != nil {
+		c.loadError("DATABASE_POOL", fmt.Errorf("%v, using the defaults", err))
+		c.DatabasePool = store.PoolConfig{}
+	}
+	c.DatabasePool = c.DatabasePool.WithDefaults()
+}
+
+// loadPageSizes loads the default and max page sizes. Unset variables keep the defaults.
+func (c *Config) loadPageSizes() {
+	c.PageSizes = store.PageSizes{}
+	for _, v := range []struct {
+		key    string
+		target *int
+	}{
+		{"API_DEFAULT_PAGE_SIZE", &c.PageSizes.Default},
+		{"API_MAX_PAGE_SIZE", &c.PageSizes.Max},
+	} {
+		if value := getEnv(v.key, ""); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				*v.target = n
+			} else {
+				c.loadError(v.key, fmt.Errorf("%q is not a positive number of events", value))
+			}
+		}
+	}
+
+	if err := c.PageSizes.Validate(); err != nil {
+		c.loadError("API_PAGE_SIZES", fmt.Errorf("%v, using the defaults", err))
+		c.PageSizes = store.PageSizes{}
+	}
+	c.PageSizes = store.PageSizes{Default: c.PageSizes.DefaultLimit(), Max: c.PageSizes.MaxLimit()}
+}
+
+// loadHeartbeatInterval loads the interval of the heartbeat events, 0 disabling them.
+func (c *Config) loadHeartbeatInterval() {
+	c.HeartbeatInterval = heartbeat.DefaultInterval
+	if value := getEnv("HEARTBEAT_INTERVAL", ""); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			c.HeartbeatInterval = d
+		} else {
+			c.loadError("HEARTBEAT_INTERVAL", fmt.Errorf("%q is not a duration, using %s", value, heartbeat.DefaultInterval))
+		}
+	}
+}
+
+// loadRetention loads the retention of events, 0 keeping them forever.
+func (c *Config) loadRetention() {
+	if value := getEnv("RETENTION_DAYS", ""); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			c.RetentionDays = n
+		} else {
+			c.loadError("RETENTION_DAYS", fmt.Errorf("%q is not a number of days, keeping events forever", value))
+		}
+	}
+}
+
+// loadDeleteCollection loads the grouping of DELETE bursts, a minimum of 0 disabling it.
+func (c *Config) loadDeleteCollection() {
+	c.DeleteCollectionMinDeletes = DefaultDeleteCollectionMinDeletes
+	if value := getEnv("DELETE_COLLECTION_MIN_DELETES", ""); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && (n == 0 || n >= 2) {
+			c.DeleteCollectionMinDeletes = n
+		} else {
+			c.loadError("DELETE_COLLECTION_MIN_DELETES", fmt.Errorf("%q is neither 0 nor a number of at least 2, using %d", value, DefaultDeleteCollectionMinDeletes))
+		}
+	}
+	c.DeleteCollectionWindow = DefaultDeleteCollectionWindow
+	if value := getEnv("DELETE_COLLECTION_WINDOW", ""); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			c.DeleteCollectionWindow = d
+		} else {
+			c.loadError("DELETE_COLLECTION_WINDOW", fmt.Errorf("%q is not a positive duration, using %s", value, DefaultDeleteCollectionWindow))
+		}
+	}
+}
+
+// loadIndexAdvisor loads the index advisor settings. It is disabled unless an interval is set.
+func (c *Config) loadIndexAdvisor() {
+	c.IndexAdvisor = store.IndexAdvisorConfig{MinQueries: 100}
+	if value := getEnv("INDEX_ADVISOR_INTERVAL", ""); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			c.IndexAdvisor.Interval = d
+		} else {
+			c.loadError("INDEX_ADVISOR_INTERVAL", fmt.Errorf("%q is not a duration, e.g. 1h", value))
+		}
+	}
+	if value := getEnv("INDEX_ADVISOR_MIN_QUERIES", ""); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+			c.IndexAdvisor.MinQueries = n
+		} else {
+			c.loadError("INDEX_ADVISOR_MIN_QUERIES", fmt.Errorf("%q is not a number of queries", value))
+		}
+	}
+	if create := getEnv("INDEX_ADVISOR_CREATE", ""); create == "true" || create == "1" {
+		c.IndexAdvisor.Create = true
+	}
+}
+
 func parseList(s string) []string {
 	if s == "" {
 		return nil
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/kubechronicle/kubechronicle/internal/export"
+	"github.com/kubechronicle/kubechronicle/internal/pseudonym"
+	"github.com/kubechronicle/kubechronicle/internal/sources"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// redactedValue replaces secrets in the effective configuration.
+const redactedValue = "xxxxx"
+
+// dsnPassword matches the password in a keyword/value connection string (e.g. "host=db password=secret").
+var dsnPassword = regexp.MustCompile(`password=('[^']*'|\S+)`)
+
+// EffectiveConfig is the configuration a component is running with.
+// It is safe to expose: secrets are redacted or left out.
+type EffectiveConfig struct {
+	DatabaseURL        string                     `json:"database_url,omitempty"` // Password redacted
+	LogLevel           string                     `json:"log_level"`
+	IgnoreConfig       *IgnoreConfig              `json:"ignore_config,omitempty"`
+	BlockConfig        *BlockConfig               `json:"block_config,omitempty"`
+	SamplingConfig     *SamplingConfig            `json:"sampling_config,omitempty"`
+	WarnConfig         *WarnConfig                `json:"warn_config,omitempty"`
+	QuotaConfig        *QuotaConfig               `json:"quota_config,omitempty"`
+	ChurnConfig        *ChurnConfig               `json:"churn_config,omitempty"`
+	Plugins            []string                   `json:"plugins,omitempty"`            // Names only, plugin configs may hold secrets
+	ExportConfig       *export.Config             `json:"export_config,omitempty"`      // Credentials redacted
+	ExportJobsConfig   *export.JobConfig          `json:"export_jobs_config,omitempty"` // Credentials redacted
+	AuthEnabled        bool                       `json:"auth_enabled"`
+	JWTExpirationHours int                        `json:"jwt_expiration_hours,omitempty"`
+	EncryptionEnabled  bool                       `json:"encryption_enabled"`
+	Pseudonymization   bool                       `json:"pseudonymization"`
+	PseudonymConfig    *pseudonym.Config          `json:"pseudonym_config,omitempty"`
+	SourceHealthConfig *sources.Config            `json:"source_health_config,omitempty"`
+	DecryptRoles       []string                   `json:"decrypt_roles,omitempty"`
+	RecorderRoles      []string                   `json:"recorder_roles,omitempty"`
+	TLSMinVersion      string                     `json:"tls_min_version,omitempty"`
+	TLSCipherSuites    []string                   `json:"tls_cipher_suites,omitempty"`
+	TLSClientAuth      string                     `json:"tls_client_auth,omitempty"`
+	RequirePersistence bool                       `json:"require_persistence"`
+	SpillDir           string                     `json:"spill_dir,omitempty"`
+	SnapshotUpdates    bool                       `json:"snapshot_updates"`
+	SnapshotConfig     *SnapshotConfig            `json:"snapshot_config,omitempty"`
+	WatchVolumes       bool                       `json:"watch_persistent_volumes"`
+	LimitRangeImpact   bool                       `json:"limit_range_impact"`
+	ImageProvenance    *ImageProvenanceConfig     `json:"image_provenance,omitempty"`
+	HeartbeatInterval  string                     `json:"heartbeat_interval,omitempty"`       // Unset when heartbeats are disabled
+	RetentionDays      int                        `json:"retention_days,omitempty"`           // Unset when events are kept forever
+	DeleteCollection   *EffectiveDeleteCollection `json:"delete_collection,omitempty"`        // Unset when grouping is disabled
+	CascadeWindow      string                     `json:"namespace_cascade_window,omitempty"` // Unset when namespace cascade summaries are disabled
+	DatabasePool       *EffectivePool             `json:"database_pool,omitempty"`
+	DatabaseReadURL    string                     `json:"database_read_url,omitempty"` // Password redacted
+	ReplicaMaxLag      string                     `json:"replica_max_lag,omitempty"`
+	PageSizes          store.PageSizes            `json:"page_sizes"`
+
+	IndexAdvisor *EffectiveIndexAdvisor `json:"index_advisor,omitempty"`
+
+	DeploymentWebhookConfig *DeploymentWebhookConfig `json:"deployment_webhook_config,omitempty"` // Secrets redacted
+	CloudChangeConfig       *CloudChangeConfig       `json:"cloud_change_config,omitempty"`       // Token redacted
+	TenancyConfig           *TenancyConfig           `json:"tenancy_config,omitempty"`
+}
+
+// EffectiveDeleteCollection is the grouping of DELETE bursts into DELETE_COLLECTION events.
+type EffectiveDeleteCollection struct {
+	MinDeletes int    `json:"min_deletes"`
+	Window     string `json:"window"`
+}
+
+// EffectivePool is the connection pool configuration, with durations formatted as strings.
+type EffectivePool struct {
+	MaxConns           int32  `json:"max_conns"`
+	MinConns           int32  `json:"min_conns"`
+	MaxConnLifetime    string `json:"max_conn_lifetime"`
+	MaxConnIdleTime    string `json:"max_conn_idle_time"`
+	StatementCacheMode string `json:"statement_cache_mode,omitempty"`
+	StatementTimeout   string `json:"statement_timeout,omitempty"`
+	SaveTimeout        string `json:"save_timeout"`
+	SlowQueryThreshold string `json:"slow_query_threshold"`
+}
+
+// EffectiveIndexAdvisor is the index advisor configuration, with the interval formatted as
+// a string.
+type EffectiveIndexAdvisor struct {
+	Interval   string `json:"interval"`
+	MinQueries int64  `json:"min_queries"`
+	Create     bool   `json:"create"`
+}
+
+// Effective returns the configuration with secrets redacted. Alert channels are not
+// included since they are reported by the components that send alerts.
+func (c *Config) Effective() *EffectiveConfig {
+	effective := &EffectiveConfig{
+		DatabaseURL:       redactDatabaseURL(c.DatabaseURL),
+		LogLevel:          c.LogLevel,
+		IgnoreConfig:      c.IgnoreConfig,
+		BlockConfig:       c.BlockConfig,
+		SamplingConfig:    c.SamplingConfig,
+		WarnConfig:        c.WarnConfig,
+		QuotaConfig:       c.QuotaConfig,
+		ChurnConfig:       c.ChurnConfig,
+		EncryptionEnabled: c.EncryptionKey != "",
+		Pseudonymization:  c.PseudonymKey != "",
+		PseudonymConfig:   c.PseudonymConfig,
+		DecryptRoles:      c.DecryptRoles,
+		RecorderRoles:     c.RecorderRoles,
+		TLSMinVersion:     c.TLSMinVersion,
+		TLSCipherSuites:   c.TLSCipherSuites,
+
+		RequirePersistence: c.RequirePersistence,
+		SpillDir:           c.SpillDir,
+		SnapshotUpdates:    c.SnapshotUpdates,
+		SnapshotConfig:     c.SnapshotConfig,
+		WatchVolumes:       c.WatchVolumes,
+		LimitRangeImpact:   c.LimitRangeImpact,
+		RetentionDays:      c.RetentionDays,
+		ImageProvenance:    c.ImageProvenance,
+		SourceHealthConfig: c.SourceHealthConfig,
+
+		TenancyConfig: c.TenancyConfig,
+		PageSizes:     c.PageSizes,
+	}
+	for _, plugin := range c.PluginConfigs {
+		effective.Plugins = append(effective.Plugins, plugin.Name)
+	}
+	if c.TLSClientCAPath != "" {
+		effective.TLSClientAuth = c.tlsClientAuth()
+	}
+	if c.DatabaseURL != "" {
+		pool := c.DatabasePool
+		effective.DatabasePool = &EffectivePool{
+			MaxConns:           pool.MaxConns,
+			MinConns:           pool.MinConns,
+			MaxConnLifetime:    pool.MaxConnLifetime.String(),
+			MaxConnIdleTime:    pool.MaxConnIdleTime.String(),
+			StatementCacheMode: pool.StatementCacheMode,
+			SaveTimeout:        pool.SaveTimeout.String(),
+			SlowQueryThreshold: pool.SlowQueryThreshold.String(),
+		}
+		if pool.StatementTimeout > 0 {
+			effective.DatabasePool.StatementTimeout = pool.StatementTimeout.String()
+		}
+	}
+	if c.HeartbeatInterval > 0 {
+		effective.HeartbeatInterval = c.HeartbeatInterval.String()
+	}
+	if c.NamespaceCascadeWindow > 0 {
+		effective.CascadeWindow = c.NamespaceCascadeWindow.String()
+	}
+	if c.DeleteCollectionMinDeletes > 0 {
+		effective.DeleteCollection = &EffectiveDeleteCollection{
+			MinDeletes: c.DeleteCollectionMinDeletes,
+			Window:     c.DeleteCollectionWindow.String(),
+		}
+	}
+	if advisor := c.IndexAdvisor; advisor.Interval > 0 {
+		effective.IndexAdvisor = &EffectiveIndexAdvisor{
+			Interval:   advisor.Interval.String(),
+			MinQueries: advisor.MinQueries,
+			Create:     advisor.Create,
+		}
+	}
+	if c.DatabaseReadURL != "" {
+		effective.DatabaseReadURL = redactDatabaseURL(c.DatabaseReadURL)
+		effective.ReplicaMaxLag = c.DatabaseReplicaMaxLag.String()
+	}
+
+	if c.ExportConfig != nil {
+		exportConfig := *c.ExportConfig
+		exportConfig.S3 = redactS3Config(exportConfig.S3)
+		effective.ExportConfig = &exportConfig
+	}
+	if c.ExportJobsConfig != nil {
+		exportJobsConfig := *c.ExportJobsConfig
+		exportJobsConfig.S3 = redactS3Config(exportJobsConfig.S3)
+		effective.ExportJobsConfig = &exportJobsConfig
+	}
+
+	if c.DeploymentWebhookConfig != nil {
+		deploymentConfig := *c.DeploymentWebhookConfig
+		if deploymentConfig.GitHubSecret != "" {
+			deploymentConfig.GitHubSecret = redactedValue
+		}
+		if deploymentConfig.GitLabToken != "" {
+			deploymentConfig.GitLabToken = redactedValue
+		}
+		effective.DeploymentWebhookConfig = &deploymentConfig
+	}
+
+	if c.CloudChangeConfig != nil {
+		cloudConfig := *c.CloudChangeConfig
+		if cloudConfig.Token != "" {
+			cloudConfig.Token = redactedValue
+		}
+		effective.CloudChangeConfig = &cloudConfig
+	}
+
+	if c.AuthConfig != nil && c.AuthConfig.EnableAuth {
+		effective.AuthEnabled = true
+		effective.JWTExpirationHours = c.AuthConfig.JWTExpirationHours
+	}
+
+	return effective
+}
+
+// redactDatabaseURL redacts the password in a URL or keyword/value connection string.
+func redactDatabaseURL(databaseURL string) string {
+	if databaseURL == "" {
+		return ""
+	}
+	if u, err := url.Parse(databaseURL); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(databaseURL, "password="+redactedValue)
+}
+
+// redactS3Config returns a copy of an S3 configuration with its credentials redacted.
+func redactS3Config(cfg *export.S3Config) *export.S3Config {
+	if cfg == nil {
+		return nil
+	}
+	redacted := *cfg
+	if redacted.AccessKeyID != "" {
+		redacted.AccessKeyID = redactedValue
+	}
+	if redacted.SecretAccessKey != "" {
+		redacted.SecretAccessKey = redactedValue
+	}
+	if redacted.SessionToken != "" {
+		redacted.SessionToken = redactedValue
+	}
+	return &redacted
+}
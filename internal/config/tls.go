@@ -0,0 +1,86 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsVersions maps TLS_MIN_VERSION values to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Client certificate modes for TLS_CLIENT_AUTH.
+const (
+	TLSClientAuthRequire       = "require"         // Reject clients without a valid certificate
+	TLSClientAuthVerifyIfGiven = "verify-if-given" // Verify certificates that are sent, e.g. to keep plain HTTPS probes working
+)
+
+// ServerTLSConfig builds the TLS configuration of the webhook server from TLSMinVersion,
+// TLSCipherSuites and the client certificate settings. Cipher suites are given by their
+// IANA names (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256); only suites Go considers secure
+// are accepted, and they do not apply to TLS 1.3, whose suites are not configurable.
+func (c *Config) ServerTLSConfig() (*tls.Config, error) {
+	minVersion := c.TLSMinVersion
+	if minVersion == "" {
+		minVersion = "1.2"
+	}
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS_MIN_VERSION %q (supported: 1.2, 1.3)", c.TLSMinVersion)
+	}
+	tlsConfig := &tls.Config{MinVersion: version}
+
+	if len(c.TLSCipherSuites) > 0 {
+		secure := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			secure[suite.Name] = suite.ID
+		}
+		for _, name := range c.TLSCipherSuites {
+			id, ok := secure[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported or insecure cipher suite %q in TLS_CIPHER_SUITES", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	if c.TLSClientCAPath == "" {
+		if c.TLSClientAuth != "" {
+			return nil, fmt.Errorf("TLS_CLIENT_AUTH is set but TLS_CLIENT_CA_PATH is not")
+		}
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(c.TLSClientCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA %s", c.TLSClientCAPath)
+	}
+	tlsConfig.ClientCAs = clientCAs
+
+	switch c.tlsClientAuth() {
+	case TLSClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case TLSClientAuthVerifyIfGiven:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("unsupported TLS_CLIENT_AUTH %q (supported: %s, %s)",
+			c.TLSClientAuth, TLSClientAuthRequire, TLSClientAuthVerifyIfGiven)
+	}
+	return tlsConfig, nil
+}
+
+// tlsClientAuth returns the client certificate mode, defaulting to require.
+func (c *Config) tlsClientAuth() string {
+	if c.TLSClientAuth == "" {
+		return TLSClientAuthRequire
+	}
+	return c.TLSClientAuth
+}
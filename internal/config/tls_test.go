@@ -0,0 +1,144 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCA writes a self-signed CA certificate in PEM format and returns its path.
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write CA: %v", err)
+	}
+	return path
+}
+
+func TestConfig_ServerTLSConfig(t *testing.T) {
+	caPath := writeTestCA(t)
+
+	tests := []struct {
+		name               string
+		config             *Config
+		expectedMinVersion uint16
+		expectedSuites     []uint16
+		expectedClientAuth tls.ClientAuthType
+		expectError        bool
+	}{
+		{
+			name:               "defaults",
+			config:             &Config{},
+			expectedMinVersion: tls.VersionTLS12,
+			expectedClientAuth: tls.NoClientCert,
+		},
+		{
+			name:               "TLS 1.3",
+			config:             &Config{TLSMinVersion: "1.3"},
+			expectedMinVersion: tls.VersionTLS13,
+			expectedClientAuth: tls.NoClientCert,
+		},
+		{
+			name:        "unsupported version",
+			config:      &Config{TLSMinVersion: "1.0"},
+			expectError: true,
+		},
+		{
+			name:               "cipher suites",
+			config:             &Config{TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}},
+			expectedMinVersion: tls.VersionTLS12,
+			expectedSuites:     []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+			expectedClientAuth: tls.NoClientCert,
+		},
+		{
+			name:        "insecure cipher suite",
+			config:      &Config{TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			expectError: true,
+		},
+		{
+			name:               "client CA requires certificates by default",
+			config:             &Config{TLSClientCAPath: caPath},
+			expectedMinVersion: tls.VersionTLS12,
+			expectedClientAuth: tls.RequireAndVerifyClientCert,
+		},
+		{
+			name:               "verify client certificates if given",
+			config:             &Config{TLSClientCAPath: caPath, TLSClientAuth: TLSClientAuthVerifyIfGiven},
+			expectedMinVersion: tls.VersionTLS12,
+			expectedClientAuth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name:        "unknown client auth mode",
+			config:      &Config{TLSClientCAPath: caPath, TLSClientAuth: "optional"},
+			expectError: true,
+		},
+		{
+			name:        "client auth without CA",
+			config:      &Config{TLSClientAuth: TLSClientAuthRequire},
+			expectError: true,
+		},
+		{
+			name:        "missing CA file",
+			config:      &Config{TLSClientCAPath: filepath.Join(t.TempDir(), "missing.crt")},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := tt.config.ServerTLSConfig()
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tlsConfig.MinVersion != tt.expectedMinVersion {
+				t.Errorf("MinVersion = %x, want %x", tlsConfig.MinVersion, tt.expectedMinVersion)
+			}
+			if len(tlsConfig.CipherSuites) != len(tt.expectedSuites) {
+				t.Fatalf("CipherSuites = %v, want %v", tlsConfig.CipherSuites, tt.expectedSuites)
+			}
+			for i, suite := range tt.expectedSuites {
+				if tlsConfig.CipherSuites[i] != suite {
+					t.Errorf("CipherSuites[%d] = %x, want %x", i, tlsConfig.CipherSuites[i], suite)
+				}
+			}
+			if tlsConfig.ClientAuth != tt.expectedClientAuth {
+				t.Errorf("ClientAuth = %v, want %v", tlsConfig.ClientAuth, tt.expectedClientAuth)
+			}
+			if (tlsConfig.ClientCAs != nil) != (tt.config.TLSClientCAPath != "") {
+				t.Error("ClientCAs should be set only when a client CA is configured")
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package configcheck validates the configuration of the kubechronicle components: the
+// environment variables they load and the patterns ConfigMap the webhook reloads, reporting
+// every problem instead of ignoring the invalid parts as the components do when starting.
+package configcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/admission"
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// Problem severities. Components start despite errors, ignoring the invalid configuration.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is an invalid or questionable piece of configuration.
+type Problem struct {
+	Severity string `json:"severity"`
+	Source   string `json:"source"` // "env" or "configmap"
+	Key      string `json:"key"`    // Variable or ConfigMap key, e.g. IGNORE_CONFIG
+	Message  string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%-7s %s %s: %s", p.Severity, p.Source, p.Key, p.Message)
+}
+
+// patternKeys are the ConfigMap keys the webhook reloads.
+var patternKeys = []string{"IGNORE_CONFIG", "BLOCK_CONFIG", "WARN_CONFIG", "ALERT_CONFIG"}
+
+// Check validates the configuration loaded from the environment: the variables that couldn't
+// be parsed, and the parsed values the components would reject or misapply.
+func Check(cfg *config.Config) []Problem {
+	var problems []Problem
+	add := func(severity, key, message string) {
+		problems = append(problems, Problem{Severity: severity, Source: "env", Key: key, Message: message})
+	}
+
+	for _, loadErr := range cfg.LoadErrors {
+		add(SeverityError, loadErr.Key, loadErr.Err.Error())
+	}
+
+	if cfg.RequirePersistence && cfg.DatabaseURL == "" {
+		add(SeverityError, "REQUIRE_PERSISTENCE", "DATABASE_URL is empty, so the webhook fails to start")
+	}
+	if _, err := cfg.ServerTLSConfig(); err != nil {
+		add(SeverityError, "TLS", err.Error())
+	}
+	if cfg.EncryptionKey != "" {
+		if _, err := encryption.NewEncryptorFromKey(cfg.EncryptionKey); err != nil {
+			add(SeverityError, "ENCRYPTION_KEY", err.Error())
+		}
+	}
+	if cfg.AlertConfig != nil {
+		if _, err := alerting.NewRouter(cfg.AlertConfig); err != nil {
+			add(SeverityError, "ALERT_CONFIG", err.Error())
+		}
+	}
+	if cfg.AuthConfig != nil {
+		if _, err := auth.AuthConfigFromConfig(cfg.AuthConfig); err != nil {
+			add(SeverityError, "AUTH_USERS", err.Error())
+		}
+	}
+
+	for _, issue := range admission.LintPatterns(cfg.IgnoreConfig, cfg.BlockConfig) {
+		problems = append(problems, patternProblem("env", issue))
+	}
+	return problems
+}
+
+// CheckPatterns validates the data of the patterns ConfigMap, as the webhook parses it when
+// reloading. Keys the webhook doesn't read are reported as warnings, as they are likely typos.
+func CheckPatterns(data map[string]string) []Problem {
+	var problems []Problem
+	add := func(severity, key, message string) {
+		problems = append(problems, Problem{Severity: severity, Source: "configmap", Key: key, Message: message})
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !isPatternKey(key) {
+			add(SeverityWarning, key, fmt.Sprintf("unknown key, the webhook reads %s", strings.Join(patternKeys, ", ")))
+		}
+	}
+
+	var ignoreConfig *config.IgnoreConfig
+	if raw, ok := data["IGNORE_CONFIG"]; ok {
+		ignoreConfig = &config.IgnoreConfig{}
+		if err := json.Unmarshal([]byte(raw), ignoreConfig); err != nil {
+			add(SeverityError, "IGNORE_CONFIG", err.Error())
+			ignoreConfig = nil
+		}
+	}
+	var blockConfig *config.BlockConfig
+	if raw, ok := data["BLOCK_CONFIG"]; ok {
+		blockConfig = &config.BlockConfig{}
+		if err := json.Unmarshal([]byte(raw), blockConfig); err != nil {
+			add(SeverityError, "BLOCK_CONFIG", err.Error())
+			blockConfig = nil
+		}
+	}
+	if raw, ok := data["WARN_CONFIG"]; ok {
+		if err := json.Unmarshal([]byte(raw), &config.WarnConfig{}); err != nil {
+			add(SeverityError, "WARN_CONFIG", err.Error())
+		}
+	}
+	if raw := strings.TrimSpace(data["ALERT_CONFIG"]); raw != "" {
+		var alertConfig alerting.Config
+		if err := json.Unmarshal([]byte(raw), &alertConfig); err != nil {
+			add(SeverityError, "ALERT_CONFIG", err.Error())
+		} else if alertConfig.SecretRef == nil {
+			// Configs referencing a Secret are validated when applied, with its secrets
+			if _, err := alerting.NewRouter(&alertConfig); err != nil {
+				add(SeverityError, "ALERT_CONFIG", err.Error())
+			} else if secrets := alertConfig.Redacted().RedactedSecrets(); len(secrets) > 0 {
+				add(SeverityWarning, "ALERT_CONFIG", fmt.Sprintf("secrets %s are stored in the ConfigMap; save the config through the admin API to move them to a Secret", strings.Join(secrets, ", ")))
+			}
+		}
+	}
+
+	for _, issue := range admission.LintPatterns(ignoreConfig, blockConfig) {
+		problems = append(problems, patternProblem("configmap", issue))
+	}
+	return problems
+}
+
+// patternProblem converts a pattern lint issue to a problem of the ignore or block config.
+func patternProblem(source string, issue admission.PatternIssue) Problem {
+	message := fmt.Sprintf("%s: %s", issue.Field, issue.Message)
+	if issue.Pattern != "" {
+		message = fmt.Sprintf("%s %q: %s", issue.Field, issue.Pattern, issue.Message)
+	}
+	return Problem{
+		Severity: issue.Severity,
+		Source:   source,
+		Key:      strings.ToUpper(issue.Config) + "_CONFIG",
+		Message:  message,
+	}
+}
+
+// isPatternKey reports whether key is read by the webhook from the patterns ConfigMap.
+func isPatternKey(key string) bool {
+	for _, patternKey := range patternKeys {
+		if key == patternKey {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returns the number of error-severity problems.
+func Errors(problems []Problem) int {
+	count := 0
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,104 @@
+package configcheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+)
+
+// find returns the problems of the given key.
+func find(problems []Problem, key string) []Problem {
+	var found []Problem
+	for _, p := range problems {
+		if p.Key == key {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+func TestCheck(t *testing.T) {
+	cfg := &config.Config{
+		LoadErrors: []config.LoadError{
+			{Key: "IGNORE_CONFIG", Err: errors.New("invalid character 'i' looking for beginning of value")},
+		},
+		EncryptionKey:      "short",
+		BlockConfig:        &config.BlockConfig{NamespacePatterns: []string{"*"}},
+		RequirePersistence: true,
+	}
+
+	problems := Check(cfg)
+	if got := find(problems, "IGNORE_CONFIG"); len(got) != 1 || got[0].Severity != SeverityError || got[0].Source != "env" {
+		t.Errorf("IGNORE_CONFIG problems = %+v, want the load error", got)
+	}
+	if got := find(problems, "ENCRYPTION_KEY"); len(got) != 1 {
+		t.Errorf("ENCRYPTION_KEY problems = %+v, want the invalid key", got)
+	}
+	if got := find(problems, "REQUIRE_PERSISTENCE"); len(got) != 1 {
+		t.Errorf("REQUIRE_PERSISTENCE problems = %+v, want the missing DATABASE_URL", got)
+	}
+	if got := find(problems, "BLOCK_CONFIG"); len(got) == 0 || !strings.Contains(got[0].Message, "kube-system") {
+		t.Errorf("BLOCK_CONFIG problems = %+v, want the lint error", got)
+	}
+	if Errors(problems) < 4 {
+		t.Errorf("Errors() = %d, want at least 4: %+v", Errors(problems), problems)
+	}
+}
+
+func TestCheck_Valid(t *testing.T) {
+	cfg := &config.Config{
+		IgnoreConfig: &config.IgnoreConfig{NamespacePatterns: []string{"kube-*"}},
+	}
+	if problems := Check(cfg); len(problems) != 0 {
+		t.Errorf("Check() = %+v, want no problems", problems)
+	}
+}
+
+func TestCheckPatterns(t *testing.T) {
+	problems := CheckPatterns(map[string]string{
+		"IGNORE_CONFIG":  "invalid json",
+		"BLOCK_CONFIG":   `{"operation_patterns": ["REMOVE"]}`,
+		"WARN_CONFIG":    "[]",
+		"ALERT_CONFIG":   `{"enabled": true}`,
+		"IGNORE_CONFIGS": "{}",
+	})
+
+	for _, key := range []string{"IGNORE_CONFIG", "BLOCK_CONFIG", "WARN_CONFIG"} {
+		got := find(problems, key)
+		if len(got) != 1 || got[0].Severity != SeverityError || got[0].Source != "configmap" {
+			t.Errorf("%s problems = %+v, want one error", key, got)
+		}
+	}
+	if got := find(problems, "IGNORE_CONFIGS"); len(got) != 1 || got[0].Severity != SeverityWarning {
+		t.Errorf("IGNORE_CONFIGS problems = %+v, want an unknown key warning", got)
+	}
+}
+
+func TestCheckPatterns_Valid(t *testing.T) {
+	problems := CheckPatterns(map[string]string{
+		"IGNORE_CONFIG": `{"namespace_patterns": ["kube-*"]}`,
+		"BLOCK_CONFIG":  `{"operation_patterns": ["DELETE"]}`,
+		"ALERT_CONFIG":  "",
+	})
+	if Errors(problems) != 0 {
+		t.Errorf("CheckPatterns() = %+v, want no errors", problems)
+	}
+}
+
+func TestCheckPatterns_AlertSecrets(t *testing.T) {
+	problems := CheckPatterns(map[string]string{
+		"ALERT_CONFIG": `{"slack": {"webhook_url": "https://hooks.slack.com/services/test"}}`,
+	})
+	if got := find(problems, "ALERT_CONFIG"); len(got) != 1 || got[0].Severity != SeverityWarning || !strings.Contains(got[0].Message, "slack.webhook_url") {
+		t.Errorf("ALERT_CONFIG problems = %+v, want a warning about the webhook URL", got)
+	}
+
+	problems = CheckPatterns(map[string]string{
+		"ALERT_CONFIG": `{"slack": {"webhook_url": "xxxxx"}, "secret_ref": {"name": "kubechronicle-patterns-alerts"}}`,
+	})
+	if got := find(problems, "ALERT_CONFIG"); len(got) != 0 {
+		t.Errorf("ALERT_CONFIG problems = %+v, want none for secrets kept in a Secret", got)
+	}
+}
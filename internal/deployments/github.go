@@ -0,0 +1,157 @@
+package deployments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// githubPayload holds the fields of GitHub deployment, deployment_status and workflow_run
+// webhooks that are recorded.
+type githubPayload struct {
+	Action     string `json:"action"`
+	Repository struct {
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+
+	Deployment *struct {
+		ID          int64           `json:"id"`
+		SHA         string          `json:"sha"`
+		Ref         string          `json:"ref"`
+		Environment string          `json:"environment"`
+		Payload     json.RawMessage `json:"payload"` // Set by the deployer; may name the namespace and app
+		CreatedAt   time.Time       `json:"created_at"`
+	} `json:"deployment"`
+
+	DeploymentStatus *struct {
+		ID        int64     `json:"id"`
+		State     string    `json:"state"`
+		TargetURL string    `json:"target_url"`
+		LogURL    string    `json:"log_url"`
+		UpdatedAt time.Time `json:"updated_at"`
+	} `json:"deployment_status"`
+
+	WorkflowRun *struct {
+		ID         int64     `json:"id"`
+		RunAttempt int       `json:"run_attempt"`
+		Name       string    `json:"name"`
+		HeadBranch string    `json:"head_branch"`
+		HeadSHA    string    `json:"head_sha"`
+		Conclusion string    `json:"conclusion"`
+		HTMLURL    string    `json:"html_url"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	} `json:"workflow_run"`
+}
+
+// parseGitHub converts a GitHub webhook to a DEPLOYMENT event. Returns nil for events
+// that aren't recorded: other event types, and workflow runs that haven't completed.
+func parseGitHub(eventType string, body []byte) (*model.ChangeEvent, error) {
+	if eventType != "deployment" && eventType != "deployment_status" && eventType != "workflow_run" {
+		return nil, nil
+	}
+
+	var payload githubPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	deployment := &model.CIDeployment{
+		Provider:   "github",
+		Event:      eventType,
+		Repository: payload.Repository.FullName,
+	}
+	app := payload.Repository.Name
+
+	if eventType == "workflow_run" {
+		run := payload.WorkflowRun
+		if run == nil {
+			return nil, fmt.Errorf("workflow_run event without workflow_run")
+		}
+		if payload.Action != "completed" {
+			return nil, nil
+		}
+		deployment.Status = run.Conclusion
+		deployment.Ref = run.HeadBranch
+		deployment.SHA = run.HeadSHA
+		deployment.URL = run.HTMLURL
+		event := newEvent(KindPipeline, app, payload.Sender.Login, deployment,
+			fmt.Sprintf("%s-run-%d-%d", payload.Repository.FullName, run.ID, run.RunAttempt))
+		event.Timestamp = run.UpdatedAt
+		return event, nil
+	}
+
+	if payload.Deployment == nil {
+		return nil, fmt.Errorf("%s event without deployment", eventType)
+	}
+	deployment.Environment = payload.Deployment.Environment
+	deployment.Ref = payload.Deployment.Ref
+	deployment.SHA = payload.Deployment.SHA
+
+	labels := githubDeploymentLabels(payload.Deployment.Payload)
+	if labeled := labelString(labels, "app"); labeled != "" {
+		app = labeled
+	}
+
+	id := fmt.Sprintf("%s-deployment-%d", payload.Repository.FullName, payload.Deployment.ID)
+	timestamp := payload.Deployment.CreatedAt
+	if eventType == "deployment_status" {
+		status := payload.DeploymentStatus
+		if status == nil {
+			return nil, fmt.Errorf("deployment_status event without deployment_status")
+		}
+		deployment.Status = status.State
+		deployment.URL = status.LogURL
+		if deployment.URL == "" {
+			deployment.URL = status.TargetURL
+		}
+		id = fmt.Sprintf("%s-status-%d", id, status.ID)
+		timestamp = status.UpdatedAt
+	} else {
+		deployment.Status = "created"
+	}
+
+	event := newEvent(KindDeployment, app, payload.Sender.Login, deployment, id)
+	event.Namespace = labelString(labels, "namespace")
+	event.Timestamp = timestamp
+	return event, nil
+}
+
+// githubDeploymentLabels decodes the payload a deployment was created with, a JSON object
+// or a string holding one.
+func githubDeploymentLabels(raw json.RawMessage) map[string]interface{} {
+	var labels map[string]interface{}
+	if err := json.Unmarshal(raw, &labels); err == nil {
+		return labels
+	}
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		json.Unmarshal([]byte(encoded), &labels)
+	}
+	return labels
+}
+
+// validGitHubSignature checks the X-Hub-Signature-256 header of a GitHub webhook, the
+// HMAC-SHA256 of the payload with the webhook secret.
+func validGitHubSignature(secret string, body []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package deployments
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// gitlabTimeLayouts are the layouts of timestamps in GitLab webhooks.
+var gitlabTimeLayouts = []string{
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	time.RFC3339,
+}
+
+// gitlabFinishedStatuses are the pipeline statuses recorded; pipelines are reported on
+// every status change.
+var gitlabFinishedStatuses = map[string]bool{
+	"success":  true,
+	"failed":   true,
+	"canceled": true,
+}
+
+// gitlabPayload holds the fields of GitLab deployment and pipeline webhooks that are recorded.
+type gitlabPayload struct {
+	ObjectKind string `json:"object_kind"`
+	Project    struct {
+		Name              string `json:"name"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+
+	// Deployment hooks
+	DeploymentID    int64  `json:"deployment_id"`
+	Status          string `json:"status"`
+	StatusChangedAt string `json:"status_changed_at"`
+	Environment     string `json:"environment"`
+	DeployableURL   string `json:"deployable_url"`
+	Ref             string `json:"ref"`
+	ShortSHA        string `json:"short_sha"`
+
+	// Pipeline hooks
+	ObjectAttributes *struct {
+		ID         int64  `json:"id"`
+		Ref        string `json:"ref"`
+		SHA        string `json:"sha"`
+		Status     string `json:"status"`
+		FinishedAt string `json:"finished_at"`
+		URL        string `json:"url"`
+		Variables  []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"variables"`
+	} `json:"object_attributes"`
+}
+
+// parseGitLab converts a GitLab webhook to a DEPLOYMENT event. Returns nil for events
+// that aren't recorded: other event types, and pipelines that haven't finished.
+func parseGitLab(body []byte) (*model.ChangeEvent, error) {
+	var payload gitlabPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	deployment := &model.CIDeployment{
+		Provider:   "gitlab",
+		Event:      payload.ObjectKind,
+		Repository: payload.Project.PathWithNamespace,
+	}
+
+	switch payload.ObjectKind {
+	case "deployment":
+		deployment.Status = payload.Status
+		deployment.Environment = payload.Environment
+		deployment.Ref = payload.Ref
+		deployment.SHA = payload.ShortSHA
+		deployment.URL = payload.DeployableURL
+		event := newEvent(KindDeployment, payload.Project.Name, payload.User.Username, deployment,
+			fmt.Sprintf("%s-deployment-%d-%s", payload.Project.PathWithNamespace, payload.DeploymentID, payload.Status))
+		event.Timestamp = parseGitLabTime(payload.StatusChangedAt)
+		return event, nil
+
+	case "pipeline":
+		pipeline := payload.ObjectAttributes
+		if pipeline == nil {
+			return nil, fmt.Errorf("pipeline event without object_attributes")
+		}
+		if !gitlabFinishedStatuses[pipeline.Status] {
+			return nil, nil
+		}
+		deployment.Status = pipeline.Status
+		deployment.Ref = pipeline.Ref
+		deployment.SHA = pipeline.SHA
+		deployment.URL = pipeline.URL
+
+		// Pipelines name the namespace and app they deploy with variables
+		app := payload.Project.Name
+		var namespace string
+		for _, variable := range pipeline.Variables {
+			switch variable.Key {
+			case "KUBECHRONICLE_APP":
+				app = variable.Value
+			case "KUBECHRONICLE_NAMESPACE":
+				namespace = variable.Value
+			}
+		}
+
+		event := newEvent(KindPipeline, app, payload.User.Username, deployment,
+			fmt.Sprintf("%s-pipeline-%d-%s", payload.Project.PathWithNamespace, pipeline.ID, pipeline.Status))
+		event.Namespace = namespace
+		event.Timestamp = parseGitLabTime(pipeline.FinishedAt)
+		return event, nil
+	}
+	return nil, nil
+}
+
+// parseGitLabTime parses a GitLab webhook timestamp. Returns the zero time if it can't be
+// parsed, for the time the webhook was received to be used instead.
+func parseGitLabTime(value string) time.Time {
+	for _, layout := range gitlabTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// validGitLabToken checks the X-Gitlab-Token header of a GitLab webhook.
+func validGitLabToken(token, header string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(header)) == 1
+}
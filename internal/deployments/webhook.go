@@ -0,0 +1,178 @@
+// Package deployments records deployments and pipeline runs reported by CI/CD systems
+// through webhooks as DEPLOYMENT events, on the same timeline as cluster changes.
+package deployments
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// OperationDeployment is the operation of events recording CI/CD deployments and pipeline runs.
+const OperationDeployment = "DEPLOYMENT"
+
+// Resource kinds of DEPLOYMENT events.
+const (
+	KindDeployment = "Deployment"
+	KindPipeline   = "Pipeline"
+)
+
+// maxPayloadSize bounds the size of webhook payloads read.
+const maxPayloadSize = 5 << 20
+
+// Saver persists events; implemented by store.Store.
+type Saver interface {
+	Save(event *model.ChangeEvent) error
+}
+
+// WebhookHandler receives GitHub and GitLab deployment and pipeline webhooks.
+type WebhookHandler struct {
+	store  Saver
+	config *config.DeploymentWebhookConfig
+	now    func() time.Time
+}
+
+// NewWebhookHandler creates a new deployment webhook handler.
+func NewWebhookHandler(store Saver, cfg *config.DeploymentWebhookConfig) *WebhookHandler {
+	if cfg == nil {
+		cfg = &config.DeploymentWebhookConfig{}
+	}
+	return &WebhookHandler{
+		store:  store,
+		config: cfg,
+		now:    time.Now,
+	}
+}
+
+// HandleGitHub handles POST /api/webhooks/github, receiving deployment, deployment_status
+// and workflow_run events signed with the configured secret.
+func (h *WebhookHandler) HandleGitHub(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.config.GitHubSecret == "" {
+		http.Error(w, "GitHub webhooks are not enabled", http.StatusNotFound)
+		return
+	}
+
+	body, err := readPayload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validGitHubSignature(h.config.GitHubSecret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := parseGitHub(r.Header.Get("X-GitHub-Event"), body)
+	h.record(w, event, err)
+}
+
+// HandleGitLab handles POST /api/webhooks/gitlab, receiving deployment and pipeline
+// events carrying the configured secret token.
+func (h *WebhookHandler) HandleGitLab(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.config.GitLabToken == "" {
+		http.Error(w, "GitLab webhooks are not enabled", http.StatusNotFound)
+		return
+	}
+	if !validGitLabToken(h.config.GitLabToken, r.Header.Get("X-Gitlab-Token")) {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := readPayload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := parseGitLab(body)
+	h.record(w, event, err)
+}
+
+// record correlates and saves the event parsed from a webhook. A nil event is a webhook
+// that isn't recorded, e.g. a pipeline that hasn't finished yet.
+func (h *WebhookHandler) record(w http.ResponseWriter, event *model.ChangeEvent, err error) {
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if event == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	h.correlate(event)
+	if err := h.store.Save(event); err != nil && !errors.Is(err, store.ErrDuplicate) {
+		klog.Errorf("Failed to save deployment event %s: %v", event.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to save event: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	klog.Infof("Recorded %s %s of %s/%s (%s)", event.Deployment.Provider, event.Deployment.Event, event.Namespace, event.Name, event.Deployment.Status)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"id": event.ID})
+}
+
+// correlate fills in what the webhook didn't say about the event: the namespace the
+// CI environment deploys to and the time it was received.
+func (h *WebhookHandler) correlate(event *model.ChangeEvent) {
+	if event.Namespace == "" && event.Deployment.Environment != "" {
+		event.Namespace = event.Deployment.Environment
+		if namespace, ok := h.config.Environments[event.Deployment.Environment]; ok {
+			event.Namespace = namespace
+		}
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = h.now()
+	}
+}
+
+// readPayload reads the body of a webhook request.
+func readPayload(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %w", err)
+	}
+	if len(body) > maxPayloadSize {
+		return nil, fmt.Errorf("payload exceeds %d bytes", maxPayloadSize)
+	}
+	return body, nil
+}
+
+// newEvent creates a DEPLOYMENT event of app, identified by the provider and the ID of the
+// deployment or run in it, so redelivered webhooks are recorded once.
+func newEvent(kind, app, actor string, deployment *model.CIDeployment, id string) *model.ChangeEvent {
+	return &model.ChangeEvent{
+		ID:           fmt.Sprintf("%s-%s-%s", OperationDeployment, deployment.Provider, id),
+		Operation:    OperationDeployment,
+		ResourceKind: kind,
+		Name:         app,
+		Actor:        model.Actor{Username: actor},
+		Source:       model.Source{Tool: deployment.Provider},
+		Allowed:      true,
+		Deployment:   deployment,
+	}
+}
+
+// labelString returns the string value of key in labels, if any.
+func labelString(labels map[string]interface{}, key string) string {
+	value, _ := labels[key].(string)
+	return value
+}
@@ -0,0 +1,238 @@
+package deployments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// fakeSaver records saved events.
+type fakeSaver struct {
+	events []*model.ChangeEvent
+}
+
+func (f *fakeSaver) Save(event *model.ChangeEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+var receivedAt = time.Date(2024, 1, 20, 10, 30, 0, 0, time.UTC)
+
+func newTestHandler() (*WebhookHandler, *fakeSaver) {
+	saver := &fakeSaver{}
+	handler := NewWebhookHandler(saver, &config.DeploymentWebhookConfig{
+		GitHubSecret: "gh-secret",
+		GitLabToken:  "gl-token",
+		Environments: map[string]string{"production": "prod"},
+	})
+	handler.now = func() time.Time { return receivedAt }
+	return handler, saver
+}
+
+func postGitHub(handler *WebhookHandler, eventType, body, secret string) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/webhooks/github", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", eventType)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	handler.HandleGitHub(w, req)
+	return w
+}
+
+func postGitLab(handler *WebhookHandler, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/kubechronicle/api/webhooks/gitlab", strings.NewReader(body))
+	req.Header.Set("X-Gitlab-Token", token)
+	w := httptest.NewRecorder()
+	handler.HandleGitLab(w, req)
+	return w
+}
+
+func TestHandleGitHub_DeploymentStatus(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `{
+		"action": "created",
+		"deployment_status": {"id": 7, "state": "success", "log_url": "https://github.com/acme/web/actions/runs/1", "updated_at": "2024-01-20T10:29:00Z"},
+		"deployment": {"id": 42, "sha": "abc123", "ref": "main", "environment": "production", "payload": {"app": "web-frontend"}},
+		"repository": {"name": "web", "full_name": "acme/web"},
+		"sender": {"login": "octocat"}
+	}`
+
+	w := postGitHub(handler, "deployment_status", body, "gh-secret")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if len(saver.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(saver.events))
+	}
+	event := saver.events[0]
+	if event.Operation != OperationDeployment || event.ResourceKind != KindDeployment {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	// The environment is mapped to its namespace, the app taken from the deployment payload
+	if event.Namespace != "prod" || event.Name != "web-frontend" || event.Actor.Username != "octocat" {
+		t.Errorf("Event of %s/%s by %s, want prod/web-frontend by octocat", event.Namespace, event.Name, event.Actor.Username)
+	}
+	if event.ID != "DEPLOYMENT-github-acme/web-deployment-42-status-7" {
+		t.Errorf("ID = %q", event.ID)
+	}
+	if !event.Timestamp.Equal(time.Date(2024, 1, 20, 10, 29, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v", event.Timestamp)
+	}
+	want := model.CIDeployment{
+		Provider: "github", Event: "deployment_status", Status: "success", Environment: "production",
+		Repository: "acme/web", Ref: "main", SHA: "abc123", URL: "https://github.com/acme/web/actions/runs/1",
+	}
+	if *event.Deployment != want {
+		t.Errorf("Deployment = %+v, want %+v", *event.Deployment, want)
+	}
+}
+
+func TestHandleGitHub_Deployment(t *testing.T) {
+	handler, saver := newTestHandler()
+	// The payload may be a JSON string, and unmapped environments are namespaces
+	body := `{
+		"action": "created",
+		"deployment": {"id": 42, "environment": "staging", "payload": "{\"namespace\": \"web-staging\"}", "created_at": "2024-01-20T10:28:00Z"},
+		"repository": {"name": "web", "full_name": "acme/web"},
+		"sender": {"login": "octocat"}
+	}`
+	if w := postGitHub(handler, "deployment", body, "gh-secret"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	event := saver.events[0]
+	if event.Namespace != "web-staging" || event.Name != "web" || event.Deployment.Status != "created" {
+		t.Errorf("Unexpected event: %+v %+v", event, event.Deployment)
+	}
+
+	body = strings.Replace(body, `"payload": "{\"namespace\": \"web-staging\"}", `, "", 1)
+	postGitHub(handler, "deployment", body, "gh-secret")
+	if event := saver.events[1]; event.Namespace != "staging" {
+		t.Errorf("Namespace = %q, want staging", event.Namespace)
+	}
+}
+
+func TestHandleGitHub_WorkflowRun(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `{
+		"action": "%s",
+		"workflow_run": {"id": 9, "run_attempt": 2, "head_branch": "main", "head_sha": "abc123", "conclusion": "failure", "html_url": "https://github.com/acme/web/actions/runs/9"},
+		"repository": {"name": "web", "full_name": "acme/web"},
+		"sender": {"login": "octocat"}
+	}`
+
+	// Only completed runs are recorded
+	if w := postGitHub(handler, "workflow_run", strings.Replace(body, "%s", "in_progress", 1), "gh-secret"); w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	if w := postGitHub(handler, "workflow_run", strings.Replace(body, "%s", "completed", 1), "gh-secret"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if len(saver.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(saver.events))
+	}
+	event := saver.events[0]
+	if event.ResourceKind != KindPipeline || event.Namespace != "" || event.Deployment.Status != "failure" || event.ID != "DEPLOYMENT-github-acme/web-run-9-2" {
+		t.Errorf("Unexpected event: %+v %+v", event, event.Deployment)
+	}
+	// Received time is used without a timestamp
+	if !event.Timestamp.Equal(receivedAt) {
+		t.Errorf("Timestamp = %v, want %v", event.Timestamp, receivedAt)
+	}
+}
+
+func TestHandleGitHub_Rejected(t *testing.T) {
+	handler, saver := newTestHandler()
+	if w := postGitHub(handler, "deployment", `{}`, "wrong-secret"); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status = %d, want 401", w.Code)
+	}
+	if w := postGitHub(handler, "push", `{"ref": "main"}`, "gh-secret"); w.Code != http.StatusAccepted {
+		t.Errorf("push: status = %d, want 202", w.Code)
+	}
+	if w := postGitHub(handler, "deployment", `{"action": "created"}`, "gh-secret"); w.Code != http.StatusBadRequest {
+		t.Errorf("no deployment: status = %d, want 400", w.Code)
+	}
+	if len(saver.events) != 0 {
+		t.Errorf("saved %d events, want 0", len(saver.events))
+	}
+
+	disabled := NewWebhookHandler(saver, &config.DeploymentWebhookConfig{GitLabToken: "gl-token"})
+	if w := postGitHub(disabled, "deployment", `{}`, ""); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleGitLab_Deployment(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `{
+		"object_kind": "deployment",
+		"status": "success",
+		"status_changed_at": "2024-01-20 11:29:00 +0100",
+		"deployment_id": 15,
+		"environment": "production",
+		"deployable_url": "https://gitlab.example.com/acme/web/-/jobs/100",
+		"ref": "main",
+		"short_sha": "abc123",
+		"project": {"name": "web", "path_with_namespace": "acme/web"},
+		"user": {"username": "alice"}
+	}`
+	if w := postGitLab(handler, body, "gl-token"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	event := saver.events[0]
+	if event.Namespace != "prod" || event.Name != "web" || event.Actor.Username != "alice" || event.ID != "DEPLOYMENT-gitlab-acme/web-deployment-15-success" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+	if !event.Timestamp.Equal(time.Date(2024, 1, 20, 10, 29, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v", event.Timestamp)
+	}
+	if event.Deployment.Provider != "gitlab" || event.Deployment.Status != "success" || event.Deployment.URL != "https://gitlab.example.com/acme/web/-/jobs/100" {
+		t.Errorf("Unexpected deployment: %+v", event.Deployment)
+	}
+}
+
+func TestHandleGitLab_Pipeline(t *testing.T) {
+	handler, saver := newTestHandler()
+	body := `{
+		"object_kind": "pipeline",
+		"object_attributes": {
+			"id": 31, "ref": "main", "sha": "abc123", "status": "%s", "finished_at": "2024-01-20 10:29:00 UTC",
+			"variables": [{"key": "KUBECHRONICLE_NAMESPACE", "value": "payments"}, {"key": "KUBECHRONICLE_APP", "value": "api"}]
+		},
+		"project": {"name": "web", "path_with_namespace": "acme/web"},
+		"user": {"username": "alice"}
+	}`
+
+	// Only finished pipelines are recorded
+	if w := postGitLab(handler, strings.Replace(body, "%s", "running", 1), "gl-token"); w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	if w := postGitLab(handler, strings.Replace(body, "%s", "failed", 1), "gl-token"); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if len(saver.events) != 1 {
+		t.Fatalf("saved %d events, want 1", len(saver.events))
+	}
+	event := saver.events[0]
+	if event.ResourceKind != KindPipeline || event.Namespace != "payments" || event.Name != "api" || event.Deployment.Status != "failed" {
+		t.Errorf("Unexpected event: %+v %+v", event, event.Deployment)
+	}
+}
+
+func TestHandleGitLab_InvalidToken(t *testing.T) {
+	handler, saver := newTestHandler()
+	if w := postGitLab(handler, `{"object_kind": "deployment"}`, "wrong-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+	if len(saver.events) != 0 {
+		t.Errorf("saved %d events, want 0", len(saver.events))
+	}
+}
@@ -0,0 +1,134 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Apply applies patch operations to a copy of obj and returns it, e.g. to rebuild the
+// object after an UPDATE from its snapshot and diff. obj itself is not modified. Only the
+// add, replace and remove operations ComputeDiff produces are supported.
+func Apply(obj map[string]interface{}, patches []model.PatchOp) (map[string]interface{}, error) {
+	var doc interface{} = deepCopy(obj)
+	for _, patch := range patches {
+		var err error
+		doc, err = applyOp(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", patch.Op, patch.Path, err)
+		}
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	result, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patched document is a %T, not an object", doc)
+	}
+	return result, nil
+}
+
+// applyOp applies one patch operation to doc, which it may modify, and returns the result.
+func applyOp(doc interface{}, patch model.PatchOp) (interface{}, error) {
+	if patch.Op != "add" && patch.Op != "replace" && patch.Op != "remove" {
+		return nil, fmt.Errorf("unsupported operation")
+	}
+	if patch.Path == "" {
+		if patch.Op == "remove" {
+			return nil, nil
+		}
+		return deepCopy(patch.Value), nil
+	}
+	if !strings.HasPrefix(patch.Path, "/") {
+		return nil, fmt.Errorf("path must start with /")
+	}
+
+	tokens := strings.Split(patch.Path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return setPath(doc, tokens, patch)
+}
+
+// setPath applies patch at the path tokens below parent and returns the updated parent.
+// Arrays are returned as new slices, since elements may be added or removed.
+func setPath(parent interface{}, tokens []string, patch model.PatchOp) (interface{}, error) {
+	token, last := tokens[0], len(tokens) == 1
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		if last {
+			if _, exists := v[token]; !exists && patch.Op != "add" {
+				return nil, fmt.Errorf("field %q does not exist", token)
+			}
+			if patch.Op == "remove" {
+				delete(v, token)
+			} else {
+				v[token] = deepCopy(patch.Value)
+			}
+			return v, nil
+		}
+		child, exists := v[token]
+		if !exists {
+			return nil, fmt.Errorf("field %q does not exist", token)
+		}
+		updated, err := setPath(child, tokens[1:], patch)
+		if err != nil {
+			return nil, err
+		}
+		v[token] = updated
+		return v, nil
+	case []interface{}:
+		index := len(v)
+		if token != "-" {
+			parsed, err := strconv.Atoi(token)
+			if err != nil || parsed < 0 || parsed > len(v) {
+				return nil, fmt.Errorf("invalid index %q", token)
+			}
+			index = parsed
+		}
+		if last && patch.Op == "add" {
+			result := append(append(append([]interface{}{}, v[:index]...), deepCopy(patch.Value)), v[index:]...)
+			return result, nil
+		}
+		if index == len(v) {
+			return nil, fmt.Errorf("index %q out of range", token)
+		}
+		if last && patch.Op == "remove" {
+			return append(append([]interface{}{}, v[:index]...), v[index+1:]...), nil
+		}
+		if last {
+			v[index] = deepCopy(patch.Value)
+			return v, nil
+		}
+		updated, err := setPath(v[index], tokens[1:], patch)
+		if err != nil {
+			return nil, err
+		}
+		v[index] = updated
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse %T at %q", parent, token)
+	}
+}
+
+// deepCopy copies a decoded JSON value, so patching it doesn't modify the original.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
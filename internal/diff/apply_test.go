@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestApply_RoundTrip(t *testing.T) {
+	oldObj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "api",
+			"annotations": map[string]interface{}{"example.com/owner": "payments"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"ports":    []interface{}{float64(80), float64(443)},
+		},
+	}
+	newObj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "api",
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(3),
+			"ports":    []interface{}{float64(8080)},
+			"paused":   true,
+		},
+	}
+
+	patches, err := ComputeDiff(oldObj, newObj, "Deployment")
+	if err != nil {
+		t.Fatalf("ComputeDiff() error = %v", err)
+	}
+	got, err := Apply(oldObj, patches)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, newObj) {
+		t.Errorf("Apply() = %v, want %v", got, newObj)
+	}
+	if oldObj["spec"].(map[string]interface{})["replicas"] != float64(2) {
+		t.Error("Apply() modified the original object")
+	}
+}
+
+func TestApply_Arrays(t *testing.T) {
+	obj := map[string]interface{}{"items": []interface{}{"a", "b"}}
+	got, err := Apply(obj, []model.PatchOp{
+		{Op: "add", Path: "/items/-", Value: "c"},
+		{Op: "add", Path: "/items/0", Value: "z"},
+		{Op: "remove", Path: "/items/1"},
+		{Op: "replace", Path: "/items/1", Value: "y"},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	want := map[string]interface{}{"items": []interface{}{"z", "y", "c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	obj := map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(1)}, "items": []interface{}{"a"}}
+	for _, patch := range []model.PatchOp{
+		{Op: "move", Path: "/spec"},
+		{Op: "replace", Path: "/spec/paused", Value: true},
+		{Op: "add", Path: "/status/phase", Value: "Running"},
+		{Op: "remove", Path: "/items/1"},
+		{Op: "add", Path: "/spec/replicas/value", Value: 1},
+		{Op: "add", Path: "spec", Value: 1},
+	} {
+		if _, err := Apply(obj, []model.PatchOp{patch}); err == nil {
+			t.Errorf("Apply(%+v) succeeded, want an error", patch)
+		}
+	}
+}
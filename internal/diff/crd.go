@@ -0,0 +1,259 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// crdVersion is a version of a CustomResourceDefinition.
+type crdVersion struct {
+	name    string
+	served  bool
+	storage bool
+	schema  map[string]interface{} // openAPIV3Schema; nil without a schema
+}
+
+// CRDChanges compares the versions of a CustomResourceDefinition before and after an
+// UPDATE: the versions added, removed, served or no longer served, the storage version, and
+// the schema fields added, removed, retyped, made required or optional in the versions kept.
+// It returns nil if none of them changed.
+func CRDChanges(oldObj, newObj map[string]interface{}) *model.CRDChange {
+	oldVersions, newVersions := crdVersions(oldObj), crdVersions(newObj)
+	oldByName := make(map[string]*crdVersion, len(oldVersions))
+	for _, version := range oldVersions {
+		oldByName[version.name] = version
+	}
+	newByName := make(map[string]*crdVersion, len(newVersions))
+	for _, version := range newVersions {
+		newByName[version.name] = version
+	}
+
+	change := &model.CRDChange{}
+	for _, version := range oldVersions {
+		if newByName[version.name] == nil {
+			change.RemovedVersions = append(change.RemovedVersions, version.name)
+		}
+		if version.storage {
+			change.OldStorageVersion = version.name
+		}
+	}
+	fields := &crdFieldDiff{}
+	for _, version := range newVersions {
+		if version.storage {
+			change.NewStorageVersion = version.name
+		}
+		old := oldByName[version.name]
+		if old == nil {
+			change.AddedVersions = append(change.AddedVersions, version.name)
+			continue
+		}
+		switch {
+		case version.served && !old.served:
+			change.ServedVersions = append(change.ServedVersions, version.name)
+		case !version.served && old.served:
+			change.UnservedVersions = append(change.UnservedVersions, version.name)
+		}
+		fields.version = version.name
+		fields.compare(old.schema, version.schema, "")
+	}
+	if change.OldStorageVersion == change.NewStorageVersion {
+		change.OldStorageVersion, change.NewStorageVersion = "", ""
+	}
+	change.Fields, change.Truncated = fields.changes, fields.truncated
+
+	if len(change.AddedVersions) == 0 && len(change.RemovedVersions) == 0 && len(change.ServedVersions) == 0 &&
+		len(change.UnservedVersions) == 0 && change.NewStorageVersion == "" && len(change.Fields) == 0 {
+		return nil
+	}
+	return change
+}
+
+// crdVersions returns the versions of a CustomResourceDefinition, in their order.
+func crdVersions(obj map[string]interface{}) []*crdVersion {
+	spec, _ := obj["spec"].(map[string]interface{})
+	items, _ := spec["versions"].([]interface{})
+	versions := make([]*crdVersion, 0, len(items))
+	for _, item := range items {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		version := &crdVersion{}
+		version.name, _ = v["name"].(string)
+		version.served, _ = v["served"].(bool)
+		version.storage, _ = v["storage"].(bool)
+		if schema, ok := v["schema"].(map[string]interface{}); ok {
+			version.schema, _ = schema["openAPIV3Schema"].(map[string]interface{})
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// crdFieldDiff accumulates the schema field changes of the versions compared.
+type crdFieldDiff struct {
+	version   string
+	changes   []model.CRDFieldChange
+	truncated bool
+}
+
+// add records a field change, up to MaxCRDFieldChanges.
+func (d *crdFieldDiff) add(op, path, oldType, newType string) {
+	if len(d.changes) == model.MaxCRDFieldChanges {
+		d.truncated = true
+		return
+	}
+	d.changes = append(d.changes, model.CRDFieldChange{Version: d.version, Op: op, Path: path, OldType: oldType, NewType: newType})
+}
+
+// compare records the changes between the old and new schemas of the field at path ("" for
+// the root), recursing into their properties and array items.
+func (d *crdFieldDiff) compare(oldSchema, newSchema map[string]interface{}, path string) {
+	if oldType, newType := schemaType(oldSchema), schemaType(newSchema); oldType != newType && path != "" {
+		d.add("type", path, oldType, newType)
+	}
+
+	oldProps, _ := oldSchema["properties"].(map[string]interface{})
+	newProps, _ := newSchema["properties"].(map[string]interface{})
+	oldRequired, newRequired := requiredFields(oldSchema), requiredFields(newSchema)
+	for _, name := range sortedUnion(oldProps, newProps) {
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		oldProp, inOld := oldProps[name].(map[string]interface{})
+		newProp, inNew := newProps[name].(map[string]interface{})
+		switch {
+		case !inOld:
+			d.add("add", fieldPath, "", schemaType(newProp))
+			continue
+		case !inNew:
+			d.add("remove", fieldPath, schemaType(oldProp), "")
+			continue
+		}
+		switch {
+		case newRequired[name] && !oldRequired[name]:
+			d.add("required", fieldPath, "", "")
+		case oldRequired[name] && !newRequired[name]:
+			d.add("optional", fieldPath, "", "")
+		}
+		d.compare(oldProp, newProp, fieldPath)
+	}
+
+	oldItems, _ := oldSchema["items"].(map[string]interface{})
+	newItems, _ := newSchema["items"].(map[string]interface{})
+	if oldItems != nil && newItems != nil {
+		d.compare(oldItems, newItems, path+"[]")
+	}
+}
+
+// schemaType returns the type of a field schema, "" if unset.
+func schemaType(schema map[string]interface{}) string {
+	t, _ := schema["type"].(string)
+	return t
+}
+
+// requiredFields returns the set of properties a schema requires.
+func requiredFields(schema map[string]interface{}) map[string]bool {
+	required := map[string]bool{}
+	names, _ := schema["required"].([]interface{})
+	for _, name := range names {
+		if s, ok := name.(string); ok {
+			required[s] = true
+		}
+	}
+	return required
+}
+
+// sortedUnion returns the keys of a and b, sorted.
+func sortedUnion(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CRDSummary renders a CRD change as one line per change, for the header of its rendered
+// diff.
+func CRDSummary(change *model.CRDChange) []string {
+	if change == nil {
+		return nil
+	}
+	var lines []string
+	for _, version := range change.AddedVersions {
+		lines = append(lines, fmt.Sprintf("version %s added", version))
+	}
+	for _, version := range change.RemovedVersions {
+		lines = append(lines, fmt.Sprintf("version %s removed", version))
+	}
+	for _, version := range change.ServedVersions {
+		lines = append(lines, fmt.Sprintf("version %s served", version))
+	}
+	for _, version := range change.UnservedVersions {
+		lines = append(lines, fmt.Sprintf("version %s no longer served", version))
+	}
+	if change.NewStorageVersion != "" {
+		lines = append(lines, fmt.Sprintf("storage version changed from %s to %s", orNone(change.OldStorageVersion), change.NewStorageVersion))
+	}
+	for _, field := range change.Fields {
+		var line string
+		switch field.Op {
+		case "add":
+			line = fmt.Sprintf("field %s added", field.Path)
+		case "remove":
+			line = fmt.Sprintf("field %s removed", field.Path)
+		case "type":
+			line = fmt.Sprintf("field %s type changed from %s to %s", field.Path, orNone(field.OldType), orNone(field.NewType))
+		default:
+			line = fmt.Sprintf("field %s made %s", field.Path, field.Op)
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Version, line))
+	}
+	if change.Truncated {
+		lines = append(lines, fmt.Sprintf("... more fields changed than the %d listed", model.MaxCRDFieldChanges))
+	}
+	return lines
+}
+
+// SummaryText renders summary lines as the comment lines preceding a unified diff.
+func SummaryText(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString("# " + line + "\n")
+	}
+	return b.String()
+}
+
+// SummaryHTML renders summary lines as an HTML list for the top of a rendered diff, with the
+// kc-diff-summary class, or "" without lines.
+func SummaryHTML(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`<ul class="kc-diff-summary">` + "\n")
+	for _, line := range lines {
+		fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(line))
+	}
+	b.WriteString("</ul>\n")
+	return b.String()
+}
+
+// orNone returns s, or "none" if it is empty.
+func orNone(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "none"
+	}
+	return s
+}
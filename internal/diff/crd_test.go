@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func crdObject(t *testing.T, versions string) map[string]interface{} {
+	t.Helper()
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"kind":"CustomResourceDefinition","spec":{"group":"example.com","versions":`+versions+`}}`), &obj); err != nil {
+		t.Fatalf("Failed to parse CRD: %v", err)
+	}
+	return obj
+}
+
+func TestCRDChanges(t *testing.T) {
+	oldObj := crdObject(t, `[
+		{"name":"v1alpha1","served":true,"storage":false},
+		{"name":"v1","served":true,"storage":true,"schema":{"openAPIV3Schema":{"type":"object","properties":{
+			"spec":{"type":"object","required":["size"],"properties":{
+				"size":{"type":"integer"},
+				"mode":{"type":"string"},
+				"ports":{"type":"array","items":{"type":"object","properties":{"port":{"type":"integer"}}}}
+			}}
+		}}}}
+	]`)
+	newObj := crdObject(t, `[
+		{"name":"v1alpha1","served":false,"storage":false},
+		{"name":"v1","served":true,"storage":false,"schema":{"openAPIV3Schema":{"type":"object","properties":{
+			"spec":{"type":"object","required":["mode"],"properties":{
+				"size":{"type":"string"},
+				"mode":{"type":"string"},
+				"ports":{"type":"array","items":{"type":"object","properties":{"port":{"type":"integer"},"name":{"type":"string"}}}}
+			}}
+		}}}},
+		{"name":"v2","served":true,"storage":true}
+	]`)
+
+	change := CRDChanges(oldObj, newObj)
+	if change == nil {
+		t.Fatal("Expected a CRD change")
+	}
+	if !reflect.DeepEqual(change.AddedVersions, []string{"v2"}) || change.RemovedVersions != nil || change.ServedVersions != nil ||
+		!reflect.DeepEqual(change.UnservedVersions, []string{"v1alpha1"}) {
+		t.Errorf("Unexpected version changes: %+v", change)
+	}
+	if change.OldStorageVersion != "v1" || change.NewStorageVersion != "v2" {
+		t.Errorf("Storage version = %q -> %q, want v1 -> v2", change.OldStorageVersion, change.NewStorageVersion)
+	}
+	wantFields := []model.CRDFieldChange{
+		{Version: "v1", Op: "required", Path: "spec.mode"},
+		{Version: "v1", Op: "add", Path: "spec.ports[].name", NewType: "string"},
+		{Version: "v1", Op: "optional", Path: "spec.size"},
+		{Version: "v1", Op: "type", Path: "spec.size", OldType: "integer", NewType: "string"},
+	}
+	if !reflect.DeepEqual(change.Fields, wantFields) {
+		t.Errorf("Fields = %+v, want %+v", change.Fields, wantFields)
+	}
+
+	summary := CRDSummary(change)
+	want := []string{
+		"version v2 added",
+		"version v1alpha1 no longer served",
+		"storage version changed from v1 to v2",
+		"v1: field spec.mode made required",
+		"v1: field spec.ports[].name added",
+		"v1: field spec.size made optional",
+		"v1: field spec.size type changed from integer to string",
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("CRDSummary() = %q, want %q", summary, want)
+	}
+}
+
+func TestCRDChanges_Unchanged(t *testing.T) {
+	versions := `[{"name":"v1","served":true,"storage":true,"schema":{"openAPIV3Schema":{"type":"object"}}}]`
+	oldObj, newObj := crdObject(t, versions), crdObject(t, versions)
+	newObj["metadata"] = map[string]interface{}{"labels": map[string]interface{}{"team": "platform"}}
+	if change := CRDChanges(oldObj, newObj); change != nil {
+		t.Errorf("Expected no CRD change, got %+v", change)
+	}
+}
+
+func TestCRDChanges_Truncated(t *testing.T) {
+	properties := map[string]interface{}{}
+	for i := 0; i < model.MaxCRDFieldChanges+10; i++ {
+		properties[string(rune('a'+i%26))+string(rune('a'+i/26))] = map[string]interface{}{"type": "string"}
+	}
+	version := func(props map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"versions": []interface{}{map[string]interface{}{
+			"name": "v1", "served": true, "storage": true,
+			"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{"type": "object", "properties": props}},
+		}}}}
+	}
+	change := CRDChanges(version(nil), version(properties))
+	if change == nil || len(change.Fields) != model.MaxCRDFieldChanges || !change.Truncated {
+		t.Fatalf("Expected %d fields and truncated, got %+v", model.MaxCRDFieldChanges, change)
+	}
+}
@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Hunk is one change of a rendered diff: the lines of the value at Path before and after
+// the change. Values are rendered as indented JSON, one line per element.
+type Hunk struct {
+	Op   string   `json:"op"`
+	Path string   `json:"path"`
+	Old  []string `json:"old,omitempty"`
+	New  []string `json:"new,omitempty"`
+}
+
+// Hunks renders patch operations as hunks, ordered by path. Old values are looked up in
+// snapshot, the object before the change, when there is one; a replaced value without a
+// snapshot has only new lines. A snapshot without patches (a deletion) is rendered as the
+// removal of the whole object.
+func Hunks(patches []model.PatchOp, snapshot map[string]interface{}) []Hunk {
+	if len(patches) == 0 && snapshot != nil {
+		return []Hunk{{Op: "remove", Path: "", Old: valueLines(snapshot)}}
+	}
+
+	hunks := make([]Hunk, 0, len(patches))
+	for _, patch := range patches {
+		hunk := Hunk{Op: patch.Op, Path: patch.Path}
+		if patch.Op == "remove" || patch.Op == "replace" {
+			if old, ok := lookupPointer(snapshot, patch.Path); ok {
+				hunk.Old = valueLines(old)
+			}
+		}
+		if patch.Op != "remove" {
+			hunk.New = valueLines(patch.Value)
+		}
+		hunks = append(hunks, hunk)
+	}
+	sort.SliceStable(hunks, func(i, j int) bool {
+		return hunks[i].Path < hunks[j].Path
+	})
+	return hunks
+}
+
+// Unified renders hunks as a unified diff of the resource called name. Each hunk's line
+// numbers are relative to the value at its path, which follows the @@ header.
+func Unified(name string, hunks []Hunk) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, "@@ -%s +%s @@ %s\n", hunkRange(len(hunk.Old)), hunkRange(len(hunk.New)), displayPath(hunk.Path))
+		for _, line := range hunk.Old {
+			b.WriteString("-" + line + "\n")
+		}
+		for _, line := range hunk.New {
+			b.WriteString("+" + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// HTML renders hunks as an HTML fragment for embedding in a page. Elements carry
+// kc-diff-* classes for styling: kc-diff-path for hunk headers, kc-diff-del and
+// kc-diff-add for removed and added lines.
+func HTML(hunks []Hunk) string {
+	var b strings.Builder
+	b.WriteString(`<div class="kc-diff">` + "\n")
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, `<div class="kc-diff-hunk kc-diff-%s">`+"\n", html.EscapeString(hunk.Op))
+		fmt.Fprintf(&b, `<div class="kc-diff-path">%s</div>`+"\n", html.EscapeString(displayPath(hunk.Path)))
+		b.WriteString("<pre>")
+		for _, line := range hunk.Old {
+			fmt.Fprintf(&b, `<span class="kc-diff-del">-%s</span>`+"\n", html.EscapeString(line))
+		}
+		for _, line := range hunk.New {
+			fmt.Fprintf(&b, `<span class="kc-diff-add">+%s</span>`+"\n", html.EscapeString(line))
+		}
+		b.WriteString("</pre>\n</div>\n")
+	}
+	b.WriteString("</div>\n")
+	return b.String()
+}
+
+// hunkRange formats the line range of one side of a unified diff hunk.
+func hunkRange(lines int) string {
+	if lines == 0 {
+		return "0,0"
+	}
+	return "1," + strconv.Itoa(lines)
+}
+
+// displayPath returns the path shown for a hunk; the empty path is the whole object.
+func displayPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// valueLines renders a patch value as indented JSON lines.
+func valueLines(value interface{}) []string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return []string{fmt.Sprintf("%v", value)}
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// lookupPointer returns the value at an RFC 6901 JSON Pointer in obj.
+func lookupPointer(obj map[string]interface{}, pointer string) (interface{}, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	if pointer == "" {
+		return obj, true
+	}
+	var current interface{} = obj
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,88 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestHunks(t *testing.T) {
+	snapshot := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"example.com/a/b": "old"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"ports":    []interface{}{float64(80), float64(443)},
+		},
+	}
+	patches := []model.PatchOp{
+		{Op: "replace", Path: "/spec/replicas", Value: float64(3)},
+		{Op: "remove", Path: "/metadata/annotations/example.com~1a~1b"},
+		{Op: "add", Path: "/spec/paused", Value: true},
+		{Op: "replace", Path: "/spec/ports", Value: []interface{}{float64(8080)}},
+	}
+
+	want := []Hunk{
+		{Op: "remove", Path: "/metadata/annotations/example.com~1a~1b", Old: []string{`"old"`}},
+		{Op: "add", Path: "/spec/paused", New: []string{"true"}},
+		{Op: "replace", Path: "/spec/ports", Old: []string{"[", "  80,", "  443", "]"}, New: []string{"[", "  8080", "]"}},
+		{Op: "replace", Path: "/spec/replicas", Old: []string{"2"}, New: []string{"3"}},
+	}
+	if got := Hunks(patches, snapshot); !reflect.DeepEqual(got, want) {
+		t.Errorf("Hunks() = %+v, want %+v", got, want)
+	}
+
+	// Without a snapshot, old values are unknown
+	got := Hunks(patches[:1], nil)
+	if len(got) != 1 || got[0].Old != nil || !reflect.DeepEqual(got[0].New, []string{"3"}) {
+		t.Errorf("Hunks() without snapshot = %+v", got)
+	}
+}
+
+func TestHunks_Deletion(t *testing.T) {
+	got := Hunks(nil, map[string]interface{}{"kind": "ConfigMap"})
+	want := []Hunk{{Op: "remove", Path: "", Old: []string{"{", `  "kind": "ConfigMap"`, "}"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hunks() = %+v, want %+v", got, want)
+	}
+	if got := Hunks(nil, nil); len(got) != 0 {
+		t.Errorf("Hunks() of nothing = %+v, want none", got)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	hunks := []Hunk{
+		{Op: "add", Path: "/spec/paused", New: []string{"true"}},
+		{Op: "replace", Path: "/spec/replicas", Old: []string{"2"}, New: []string{"3"}},
+	}
+	want := strings.Join([]string{
+		"--- a/Deployment/payments/api",
+		"+++ b/Deployment/payments/api",
+		"@@ -0,0 +1,1 @@ /spec/paused",
+		"+true",
+		"@@ -1,1 +1,1 @@ /spec/replicas",
+		"-2",
+		"+3",
+		"",
+	}, "\n")
+	if got := Unified("Deployment/payments/api", hunks); got != want {
+		t.Errorf("Unified() = %q, want %q", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := HTML([]Hunk{{Op: "replace", Path: "/data/page", Old: []string{`"<b>"`}, New: []string{`"&"`}}})
+	for _, want := range []string{
+		`<div class="kc-diff-hunk kc-diff-replace">`,
+		`<div class="kc-diff-path">/data/page</div>`,
+		`<span class="kc-diff-del">-&#34;&lt;b&gt;&#34;</span>`,
+		`<span class="kc-diff-add">+&#34;&amp;&#34;</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTML() = %q, want it to contain %q", got, want)
+		}
+	}
+}
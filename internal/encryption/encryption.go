@@ -0,0 +1,197 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// EnvelopeVersion identifies the envelope format stored in encrypted columns.
+const EnvelopeVersion = "aes-gcm-v1"
+
+// KeyProvider wraps and unwraps per-value data keys with a master key.
+// LocalKeyProvider keeps the master key in process memory; a KMS-backed
+// provider can implement the same interface.
+type KeyProvider interface {
+	// WrapKey encrypts a data key.
+	WrapKey(dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key produced by WrapKey.
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// Envelope is the JSON document stored in place of an encrypted value.
+type Envelope struct {
+	Version    string `json:"enc"`
+	WrappedKey string `json:"key"`   // base64 data key, wrapped by the KeyProvider
+	Nonce      string `json:"nonce"` // base64 AES-GCM nonce
+	Ciphertext string `json:"data"`  // base64 AES-GCM ciphertext
+}
+
+// Encryptor performs envelope encryption: each value is encrypted with a fresh
+// AES-256-GCM data key, which is itself wrapped by the KeyProvider.
+type Encryptor struct {
+	keys KeyProvider
+}
+
+// NewEncryptor creates a new encryptor using the given key provider.
+func NewEncryptor(keys KeyProvider) *Encryptor {
+	return &Encryptor{
+		keys: keys,
+	}
+}
+
+// NewEncryptorFromKey creates an encryptor backed by a local base64-encoded 32-byte master key.
+func NewEncryptorFromKey(base64Key string) (*Encryptor, error) {
+	key, err := base64.StdEncoding.DecodeString(base64Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+	}
+	provider, err := NewLocalKeyProvider(key)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptor(provider), nil
+}
+
+// Encrypt encrypts plaintext and returns the JSON-encoded envelope.
+func (e *Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	nonce, ciphertext, err := seal(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := e.keys.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return json.Marshal(Envelope{
+		Version:    EnvelopeVersion,
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+}
+
+// Decrypt decrypts a JSON-encoded envelope produced by Encrypt.
+func (e *Encryptor) Decrypt(data []byte) ([]byte, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+	}
+	if env.Version != EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version: %q", env.Version)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped key: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(env.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+
+	dataKey, err := e.keys.UnwrapKey(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return open(dataKey, nonce, ciphertext)
+}
+
+// IsEnvelope reports whether data is an encryption envelope rather than a plain value.
+func IsEnvelope(data []byte) bool {
+	var probe struct {
+		Version string `json:"enc"`
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.Version == EnvelopeVersion
+}
+
+// LocalKeyProvider wraps data keys with a local AES-256 master key.
+type LocalKeyProvider struct {
+	masterKey []byte
+}
+
+// NewLocalKeyProvider creates a key provider from a 32-byte master key.
+func NewLocalKeyProvider(masterKey []byte) (*LocalKeyProvider, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(masterKey))
+	}
+	return &LocalKeyProvider{
+		masterKey: masterKey,
+	}, nil
+}
+
+// WrapKey encrypts a data key with the master key.
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	nonce, ciphertext, err := seal(p.masterKey, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// UnwrapKey decrypts a data key with the master key.
+func (p *LocalKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	gcm, err := newGCM(p.masterKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	return open(p.masterKey, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():])
+}
+
+// seal encrypts plaintext with AES-GCM under key, returning a random nonce and the ciphertext.
+func seal(key, plaintext []byte) ([]byte, []byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// open decrypts AES-GCM ciphertext under key.
+func open(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+// newGCM creates an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,79 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{0x42}, 32)
+}
+
+func TestEncryptor_RoundTrip(t *testing.T) {
+	provider, err := NewLocalKeyProvider(testKey())
+	if err != nil {
+		t.Fatalf("NewLocalKeyProvider() error = %v", err)
+	}
+	enc := NewEncryptor(provider)
+
+	plaintext := []byte(`[{"op":"replace","path":"/spec/replicas","value":3}]`)
+	envelope, err := enc.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if bytes.Contains(envelope, []byte("replicas")) {
+		t.Error("Envelope should not contain plaintext")
+	}
+	if !IsEnvelope(envelope) {
+		t.Error("IsEnvelope() should recognize encrypted output")
+	}
+
+	decrypted, err := enc.Decrypt(envelope)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Decrypt() = %s, want %s", decrypted, plaintext)
+	}
+}
+
+func TestEncryptor_WrongKey(t *testing.T) {
+	enc, _ := NewEncryptorFromKey(base64.StdEncoding.EncodeToString(testKey()))
+	envelope, err := enc.Encrypt([]byte(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	other, _ := NewEncryptorFromKey(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x01}, 32)))
+	if _, err := other.Decrypt(envelope); err == nil {
+		t.Error("Decrypt() with wrong key should fail")
+	}
+}
+
+func TestNewEncryptorFromKey_Invalid(t *testing.T) {
+	if _, err := NewEncryptorFromKey("not-base64!"); err == nil {
+		t.Error("NewEncryptorFromKey() should reject invalid base64")
+	}
+	if _, err := NewEncryptorFromKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("NewEncryptorFromKey() should reject keys that are not 32 bytes")
+	}
+}
+
+func TestIsEnvelope(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want bool
+	}{
+		{nil, false},
+		{[]byte(`[{"op":"add"}]`), false},
+		{[]byte(`{"metadata":{"name":"x"}}`), false},
+		{[]byte(`{"enc":"aes-gcm-v1","key":"","nonce":"","data":""}`), true},
+	}
+
+	for _, tt := range tests {
+		if got := IsEnvelope(tt.data); got != tt.want {
+			t.Errorf("IsEnvelope(%s) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"context"
+	"time"
+)
+
+// Exporter ships change events to an external append-only target.
+type Exporter interface {
+	// Export writes the record of a single event. Exporting an event that was
+	// already exported must succeed without modifying the stored copy.
+	Export(ctx context.Context, record *Record) error
+	// Name returns the name of the exporter (e.g., "s3").
+	Name() string
+}
+
+// Config represents export configuration.
+type Config struct {
+	// Targets
+	S3 *S3Config `json:"s3,omitempty"`
+
+	// Filter configuration. Patterns support * wildcards; empty means all.
+	Namespaces    []string `json:"namespaces,omitempty"`
+	ResourceKinds []string `json:"resource_kinds,omitempty"`
+
+	// QueueSize is the number of events buffered for export (default: 1000).
+	QueueSize int `json:"queue_size,omitempty"`
+	// MaxAttempts is the number of export attempts per event (default: 5).
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
+
+// S3Config contains configuration for exporting to an S3 bucket with Object Lock enabled.
+type S3Config struct {
+	Bucket string `json:"bucket"`
+	Region string `json:"region"`
+	Prefix string `json:"prefix,omitempty"` // Optional key prefix
+
+	// Endpoint overrides the AWS endpoint for S3-compatible storage (e.g. MinIO).
+	// Requests to a custom endpoint use path-style addressing.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// RetentionMode is GOVERNANCE or COMPLIANCE (default: COMPLIANCE).
+	RetentionMode string `json:"retention_mode,omitempty"`
+	// RetentionDays is how long each object is locked (default: 2555, ~7 years).
+	RetentionDays int `json:"retention_days,omitempty"`
+
+	// Credentials default to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+}
+
+// JobConfig enables export jobs, which write the events matching a query to object storage
+// in the background, for result sets too large to download in one request.
+type JobConfig struct {
+	// S3 is the bucket the exports are written to. Its retention settings are not used:
+	// the bucket's lifecycle rules should expire exports instead.
+	S3 *S3Config `json:"s3"`
+
+	// LinkExpiryMinutes is how long download links are valid (default: 60, at most 7 days).
+	LinkExpiryMinutes int `json:"link_expiry_minutes,omitempty"`
+
+	// MaxEvents is the most events a job exports (default: 10,000,000).
+	MaxEvents int64 `json:"max_events,omitempty"`
+}
+
+// Export job defaults.
+const (
+	DefaultLinkExpiry   = time.Hour
+	maxLinkExpiry       = 7 * 24 * time.Hour // The longest a presigned S3 URL may be valid
+	DefaultJobMaxEvents = 10000000
+)
+
+// LinkExpiry returns how long download links are valid.
+func (c *JobConfig) LinkExpiry() time.Duration {
+	if c == nil || c.LinkExpiryMinutes <= 0 {
+		return DefaultLinkExpiry
+	}
+	if expiry := time.Duration(c.LinkExpiryMinutes) * time.Minute; expiry < maxLinkExpiry {
+		return expiry
+	}
+	return maxLinkExpiry
+}
+
+// EventLimit returns the most events a job exports.
+func (c *JobConfig) EventLimit() int64 {
+	if c == nil || c.MaxEvents <= 0 {
+		return DefaultJobMaxEvents
+	}
+	return c.MaxEvents
+}
@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Job runner defaults.
+const (
+	defaultJobPollInterval     = 5 * time.Second
+	defaultJobProgressInterval = 5 * time.Second // Well under store.ExportJobStaleAfter
+)
+
+// ObjectUploader writes objects to object storage. S3Uploader implements it.
+type ObjectUploader interface {
+	// Key returns the object key of name, under the configured prefix.
+	Key(name string) string
+	// Upload writes everything read from body to the object key and returns its size.
+	Upload(ctx context.Context, key, contentType string, body io.Reader) (int64, error)
+}
+
+// JobRunner runs export jobs: it claims pending jobs from the store and writes the events
+// they match to object storage as NDJSON, one JSON event per line, the format the backfill
+// command imports. Replicas sharing a store each run a runner; a job is run by one of them.
+type JobRunner struct {
+	events           store.Store
+	jobs             store.ExportJobStore
+	uploader         ObjectUploader
+	maxEvents        int64
+	pollInterval     time.Duration
+	progressInterval time.Duration
+}
+
+// NewJobRunner creates a runner of the export jobs of jobs, counting their events in events.
+func NewJobRunner(events store.Store, jobs store.ExportJobStore, uploader ObjectUploader, cfg *JobConfig) *JobRunner {
+	return &JobRunner{
+		events:           events,
+		jobs:             jobs,
+		uploader:         uploader,
+		maxEvents:        cfg.EventLimit(),
+		pollInterval:     defaultJobPollInterval,
+		progressInterval: defaultJobProgressInterval,
+	}
+}
+
+// Start starts running jobs. It returns immediately; the runner stops when ctx is
+// cancelled, leaving the job it was running to be claimed again once stale.
+func (r *JobRunner) Start(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	go r.run(ctx)
+}
+
+// run polls for jobs until ctx is cancelled.
+func (r *JobRunner) run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		r.runPending(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runPending runs jobs until none is left to claim.
+func (r *JobRunner) runPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := r.jobs.ClaimExportJob(ctx)
+		if err != nil {
+			klog.Warningf("Failed to claim export job: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		r.runJob(ctx, job)
+	}
+}
+
+// runJob runs a claimed job and records its outcome.
+func (r *JobRunner) runJob(ctx context.Context, job *store.ExportJob) {
+	klog.Infof("Running export job %s created by %q", job.ID, job.CreatedBy)
+	start := time.Now()
+	err := r.export(ctx, job)
+	if ctx.Err() != nil {
+		klog.Warningf("Export job %s interrupted after %d events, it will be run again", job.ID, job.Exported)
+		return
+	}
+	if err := r.jobs.FinishExportJob(ctx, job, err); err != nil {
+		klog.Errorf("Failed to record the outcome of export job %s: %v", job.ID, err)
+		return
+	}
+	if err != nil {
+		klog.Errorf("Export job %s failed: %v", job.ID, err)
+		return
+	}
+	klog.Infof("Export job %s wrote %d events (%d bytes) to %s in %v", job.ID, job.Exported, job.Bytes, job.ObjectKey, time.Since(start).Round(time.Second))
+}
+
+// export writes the events of job to object storage, setting its Exported, Total,
+// ObjectKey and Bytes.
+func (r *JobRunner) export(ctx context.Context, job *store.ExportJob) error {
+	// Jobs read what their creator could read when they were created
+	if job.Scope != nil {
+		ctx = store.WithNamespaceScope(ctx, job.Scope)
+	}
+
+	var location *time.Location
+	if job.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(job.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", job.Timezone, err)
+		}
+	}
+
+	countFilters := job.Filters
+	countFilters.CountOnly = true
+	countFilters.GroupBy = ""
+	countFilters.CountLimit = int(r.maxEvents) + 1
+	result, err := r.events.QueryEvents(ctx, countFilters, store.PaginationParams{}, store.SortOrderAsc)
+	if err != nil {
+		return fmt.Errorf("failed to count events: %w", err)
+	}
+	job.Total = int64(result.Total)
+	if job.Total > r.maxEvents {
+		return fmt.Errorf("the filters match more than %d events, the most an export may write; narrow them down", r.maxEvents)
+	}
+	if err := r.jobs.UpdateExportJobProgress(ctx, job.ID, 0, job.Total); err != nil {
+		klog.Warningf("Failed to update the progress of export job %s: %v", job.ID, err)
+	}
+
+	// Events are encoded while the upload reads them, so only a part is held in memory
+	reader, writer := io.Pipe()
+	var (
+		exported  int64
+		streamErr error
+		done      = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		buf := bufio.NewWriter(writer)
+		encoder := json.NewEncoder(buf)
+		lastProgress := time.Now()
+		streamErr = r.jobs.StreamEvents(ctx, job.Filters, func(event *model.ChangeEvent) error {
+			if job.Redact && event.Encrypted {
+				event = redactEncrypted(event)
+			}
+			if location != nil {
+				localized := *event
+				localized.Timestamp = event.Timestamp.In(location)
+				event = &localized
+			}
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+			exported++
+			if time.Since(lastProgress) >= r.progressInterval {
+				lastProgress = time.Now()
+				if err := r.jobs.UpdateExportJobProgress(ctx, job.ID, exported, job.Total); err != nil {
+					klog.Warningf("Failed to update the progress of export job %s: %v", job.ID, err)
+				}
+			}
+			return nil
+		})
+		if streamErr == nil {
+			streamErr = buf.Flush()
+		}
+		writer.CloseWithError(streamErr)
+	}()
+
+	job.ObjectKey = r.uploader.Key("exports/" + job.ID + ".ndjson")
+	written, uploadErr := r.uploader.Upload(ctx, job.ObjectKey, "application/x-ndjson", reader)
+	reader.CloseWithError(errors.New("upload stopped")) // Unblocks the stream if the upload failed
+	<-done
+
+	// A failed upload also stops the stream, so its error comes first
+	job.Exported = exported
+	if uploadErr != nil {
+		return fmt.Errorf("failed to upload export: %w", uploadErr)
+	}
+	if streamErr != nil {
+		return fmt.Errorf("failed to read events: %w", streamErr)
+	}
+	job.Bytes = written
+	return nil
+}
+
+// redactEncrypted returns a copy of an event encrypted at rest without its diff and
+// snapshot, for jobs created by users who may not decrypt them.
+func redactEncrypted(event *model.ChangeEvent) *model.ChangeEvent {
+	copied := *event
+	copied.Diff = nil
+	copied.ObjectSnapshot = nil
+	return &copied
+}
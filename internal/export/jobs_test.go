@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeJobStore is a store.Store and store.ExportJobStore holding events in memory.
+type fakeJobStore struct {
+	store.Store
+	events   []*model.ChangeEvent
+	pending  []*store.ExportJob
+	finished map[string]error
+	scopes   [][]string // Namespace scope of each read
+}
+
+func (f *fakeJobStore) inScope(ctx context.Context) []*model.ChangeEvent {
+	scope, _ := store.NamespaceScope(ctx)
+	f.scopes = append(f.scopes, scope)
+	events := []*model.ChangeEvent{}
+	for _, event := range f.events {
+		if store.InNamespaceScope(ctx, event.Namespace) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (f *fakeJobStore) QueryEvents(ctx context.Context, filters store.QueryFilters, pagination store.PaginationParams, sortOrder store.SortOrder) (*store.QueryResult, error) {
+	return &store.QueryResult{Total: len(f.inScope(ctx))}, nil
+}
+
+func (f *fakeJobStore) CreateExportJob(ctx context.Context, job *store.ExportJob) error {
+	f.pending = append(f.pending, job)
+	return nil
+}
+
+func (f *fakeJobStore) GetExportJob(ctx context.Context, id string) (*store.ExportJob, error) {
+	return nil, store.ErrNotFound
+}
+
+func (f *fakeJobStore) ClaimExportJob(ctx context.Context) (*store.ExportJob, error) {
+	if len(f.pending) == 0 {
+		return nil, nil
+	}
+	job := f.pending[0]
+	f.pending = f.pending[1:]
+	job.Status = store.ExportJobRunning
+	return job, nil
+}
+
+func (f *fakeJobStore) UpdateExportJobProgress(ctx context.Context, id string, exported, total int64) error {
+	return nil
+}
+
+func (f *fakeJobStore) FinishExportJob(ctx context.Context, job *store.ExportJob, jobErr error) error {
+	f.finished[job.ID] = jobErr
+	return nil
+}
+
+func (f *fakeJobStore) StreamEvents(ctx context.Context, filters store.QueryFilters, fn func(*model.ChangeEvent) error) error {
+	for _, event := range f.inScope(ctx) {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fakeUploader keeps uploaded objects in memory.
+type fakeUploader struct {
+	objects map[string]string
+	err     error
+}
+
+func (f *fakeUploader) Key(name string) string {
+	return "kubechronicle/" + name
+}
+
+func (f *fakeUploader) Upload(ctx context.Context, key, contentType string, body io.Reader) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return 0, err
+	}
+	f.objects[key] = string(data)
+	return int64(len(data)), nil
+}
+
+func newTestJobStore() *fakeJobStore {
+	return &fakeJobStore{
+		events: []*model.ChangeEvent{
+			{ID: "a", Namespace: "payments", Operation: "CREATE", Diff: []model.PatchOp{{Op: "add", Path: "/data"}}},
+			{ID: "b", Namespace: "payments", Operation: "UPDATE", Encrypted: true, Diff: []model.PatchOp{{Op: "replace", Path: "/data"}}},
+			{ID: "c", Namespace: "search", Operation: "DELETE"},
+		},
+		finished: map[string]error{},
+	}
+}
+
+func TestJobRunner_Export(t *testing.T) {
+	jobs := newTestJobStore()
+	uploader := &fakeUploader{objects: map[string]string{}}
+	runner := NewJobRunner(jobs, jobs, uploader, nil)
+
+	job := &store.ExportJob{ID: "job-1", Scope: []string{"pay*"}, Redact: true}
+	jobs.CreateExportJob(context.Background(), job)
+	runner.runPending(context.Background())
+
+	if err, ok := jobs.finished["job-1"]; !ok || err != nil {
+		t.Fatalf("Expected the job to succeed, got %v (finished %v)", err, ok)
+	}
+	if job.Total != 2 || job.Exported != 2 || job.ObjectKey != "kubechronicle/exports/job-1.ndjson" {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+	object := uploader.objects[job.ObjectKey]
+	if job.Bytes != int64(len(object)) {
+		t.Errorf("Expected %d bytes, got %d", len(object), job.Bytes)
+	}
+	for _, scope := range jobs.scopes {
+		if len(scope) != 1 || scope[0] != "pay*" {
+			t.Errorf("Expected reads scoped to the creator's namespaces, got %v", scope)
+		}
+	}
+
+	var events []*model.ChangeEvent
+	scanner := bufio.NewScanner(strings.NewReader(object))
+	for scanner.Scan() {
+		var event model.ChangeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Expected NDJSON, got %q: %v", object, err)
+		}
+		events = append(events, &event)
+	}
+	if len(events) != 2 || events[0].ID != "a" || events[1].ID != "b" {
+		t.Fatalf("Expected events a and b, got %+v", events)
+	}
+	if len(events[0].Diff) != 1 || events[1].Diff != nil {
+		t.Errorf("Expected only the encrypted event redacted, got %+v and %+v", events[0].Diff, events[1].Diff)
+	}
+	if jobs.events[1].Diff == nil {
+		t.Error("Redaction should not modify the stored event")
+	}
+}
+
+func TestJobRunner_TooManyEvents(t *testing.T) {
+	jobs := newTestJobStore()
+	uploader := &fakeUploader{objects: map[string]string{}}
+	runner := NewJobRunner(jobs, jobs, uploader, &JobConfig{MaxEvents: 2})
+
+	jobs.CreateExportJob(context.Background(), &store.ExportJob{ID: "job-1"})
+	runner.runPending(context.Background())
+
+	if err := jobs.finished["job-1"]; err == nil || !strings.Contains(err.Error(), "more than 2 events") {
+		t.Errorf("Expected the job to fail over the limit, got %v", err)
+	}
+	if len(uploader.objects) != 0 {
+		t.Errorf("Expected nothing uploaded, got %v", uploader.objects)
+	}
+}
+
+func TestJobRunner_UploadFails(t *testing.T) {
+	jobs := newTestJobStore()
+	uploader := &fakeUploader{err: errors.New("bucket not found")}
+	runner := NewJobRunner(jobs, jobs, uploader, nil)
+
+	jobs.CreateExportJob(context.Background(), &store.ExportJob{ID: "job-1"})
+	jobs.CreateExportJob(context.Background(), &store.ExportJob{ID: "job-2"})
+	runner.runPending(context.Background())
+
+	for _, id := range []string{"job-1", "job-2"} {
+		if err := jobs.finished[id]; err == nil || !strings.Contains(err.Error(), "bucket not found") {
+			t.Errorf("Expected %s to fail with the upload error, got %v", id, err)
+		}
+	}
+}
+
+func TestJobRunner_Timezone(t *testing.T) {
+	jobs := newTestJobStore()
+	jobs.events[0].Timestamp = time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC)
+	uploader := &fakeUploader{objects: map[string]string{}}
+	runner := NewJobRunner(jobs, jobs, uploader, nil)
+
+	jobs.CreateExportJob(context.Background(), &store.ExportJob{ID: "job-1", Timezone: "Asia/Tokyo"})
+	jobs.CreateExportJob(context.Background(), &store.ExportJob{ID: "job-2", Timezone: "Mars/Olympus"})
+	runner.runPending(context.Background())
+
+	if err := jobs.finished["job-1"]; err != nil {
+		t.Fatalf("Expected the job to succeed, got %v", err)
+	}
+	if object := uploader.objects["kubechronicle/exports/job-1.ndjson"]; !strings.Contains(object, `"timestamp":"2024-07-01T17:00:00+09:00"`) {
+		t.Errorf("Expected timestamps in Tokyo time, got %s", object)
+	}
+	if jobs.events[0].Timestamp.Location() != time.UTC {
+		t.Error("The time zone should not modify the stored event")
+	}
+	if err := jobs.finished["job-2"]; err == nil || !strings.Contains(err.Error(), "invalid timezone") {
+		t.Errorf("Expected an unknown timezone to fail the job, got %v", err)
+	}
+}
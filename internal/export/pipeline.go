@@ -0,0 +1,274 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"path"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// deadLetterTimeout bounds the time taken to dead-letter an event that was not exported.
+const deadLetterTimeout = 5 * time.Second
+
+// Export metrics.
+var (
+	exportsDroppedTotal = expvar.NewInt("export_events_dropped_total")
+	exportFailuresTotal = expvar.NewInt("export_failures_total")
+)
+
+// Record is the document exported for an event. With encryption at rest, the diff and
+// snapshot of the event are left out and Payload holds them, encrypted like in the database.
+type Record struct {
+	*model.ChangeEvent
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// recordPayload holds the event fields encrypted in Record.Payload.
+type recordPayload struct {
+	Diff           []model.PatchOp        `json:"diff,omitempty"`
+	ObjectSnapshot map[string]interface{} `json:"object_snapshot,omitempty"`
+}
+
+// Pipeline filters change events and exports them asynchronously, retrying failures.
+type Pipeline struct {
+	exporters     []Exporter
+	namespaces    []string
+	resourceKinds []string
+	queue         chan *model.ChangeEvent
+	maxAttempts   int
+	backoff       time.Duration // Initial retry delay, doubled after each attempt
+
+	encryptor   *encryption.Encryptor // Encrypts exported diffs and snapshots; nil exports them in plaintext
+	deadLetters store.DeadLetterStore // Keeps the events dropped or failing on every attempt; nil drops them
+}
+
+// NewPipeline creates a new export pipeline with the given configuration.
+func NewPipeline(cfg *Config) (*Pipeline, error) {
+	if cfg == nil {
+		return nil, nil // No export configured
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	p := &Pipeline{
+		namespaces:    cfg.Namespaces,
+		resourceKinds: cfg.ResourceKinds,
+		queue:         make(chan *model.ChangeEvent, queueSize),
+		maxAttempts:   maxAttempts,
+		backoff:       time.Second,
+	}
+
+	// Initialize S3 exporter
+	if cfg.S3 != nil && cfg.S3.Bucket != "" {
+		exporter, err := NewS3Exporter(cfg.S3)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 exporter: %w", err)
+		}
+		p.exporters = append(p.exporters, exporter)
+		klog.Infof("S3 export enabled: bucket=%s, retention=%s/%dd", exporter.bucket, exporter.retentionMode, exporter.retentionDays)
+	}
+
+	if len(p.exporters) == 0 {
+		return nil, nil // No exporters configured
+	}
+
+	return p, nil
+}
+
+// SetEncryptor encrypts the diffs and snapshots of exported events, as ENCRYPTION_KEY does
+// in the database.
+func (p *Pipeline) SetEncryptor(encryptor *encryption.Encryptor) {
+	if p == nil {
+		return
+	}
+	p.encryptor = encryptor
+}
+
+// SetDeadLetterStore keeps the events dropped because the queue was full, or whose export
+// failed on every attempt, in deadLetters. Must be called before Start.
+func (p *Pipeline) SetDeadLetterStore(deadLetters store.DeadLetterStore) {
+	if p == nil {
+		return
+	}
+	p.deadLetters = deadLetters
+}
+
+// ShouldExport checks if the event matches the namespace and resource kind filters.
+func (p *Pipeline) ShouldExport(event *model.ChangeEvent) bool {
+	if p == nil {
+		return false
+	}
+	return matchesAny(p.namespaces, event.Namespace) && matchesAny(p.resourceKinds, event.ResourceKind)
+}
+
+// Enqueue queues an event for export (non-blocking).
+func (p *Pipeline) Enqueue(event *model.ChangeEvent) {
+	if !p.ShouldExport(event) {
+		return
+	}
+
+	select {
+	case p.queue <- event:
+	default:
+		exportsDroppedTotal.Add(1)
+		klog.Errorf("Export queue full, event not exported: %s", event.ID)
+		p.addDeadLetter(event, "", fmt.Errorf("export queue full"), 0)
+	}
+}
+
+// Requeue queues a dead-lettered event for export again, whatever the filters. It returns
+// false if the queue is full.
+func (p *Pipeline) Requeue(event *model.ChangeEvent) bool {
+	select {
+	case p.queue <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start starts the export worker. It returns immediately; the worker stops when ctx is cancelled.
+func (p *Pipeline) Start(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	go p.run(ctx)
+}
+
+// run exports queued events in order until ctx is cancelled.
+func (p *Pipeline) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.queue:
+			for _, exporter := range p.exporters {
+				p.exportWithRetry(ctx, exporter, event)
+			}
+		}
+	}
+}
+
+// record returns the document exported for an event, encrypting its diff and snapshot if
+// an encryptor is set.
+func (p *Pipeline) record(event *model.ChangeEvent) (*Record, error) {
+	if p.encryptor == nil || (len(event.Diff) == 0 && event.ObjectSnapshot == nil) {
+		return &Record{ChangeEvent: event}, nil
+	}
+
+	plaintext, err := json.Marshal(recordPayload{Diff: event.Diff, ObjectSnapshot: event.ObjectSnapshot})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+	payload, err := p.encryptor.Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt event payload: %w", err)
+	}
+	metadata := *event
+	metadata.Diff = nil
+	metadata.ObjectSnapshot = nil
+	metadata.Encrypted = true
+	return &Record{ChangeEvent: &metadata, Payload: payload}, nil
+}
+
+// exportWithRetry exports an event, retrying with exponential backoff. An event that can't
+// be encrypted, or fails on every attempt, is dead-lettered.
+func (p *Pipeline) exportWithRetry(ctx context.Context, exporter Exporter, event *model.ChangeEvent) {
+	record, err := p.record(event)
+	if err != nil {
+		exportFailuresTotal.Add(1)
+		klog.Errorf("Failed to export event %s via %s: %v", event.ID, exporter.Name(), err)
+		p.addDeadLetter(event, exporter.Name(), err, 0)
+		return
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		err := exporter.Export(ctx, record)
+		if err == nil {
+			klog.V(2).Infof("Exported event %s via %s", event.ID, exporter.Name())
+			return
+		}
+		if attempt >= p.maxAttempts {
+			exportFailuresTotal.Add(1)
+			klog.Errorf("Failed to export event %s via %s after %d attempts: %v", event.ID, exporter.Name(), attempt, err)
+			p.addDeadLetter(event, exporter.Name(), err, attempt)
+			return
+		}
+		klog.Warningf("Failed to export event %s via %s (attempt %d/%d): %v", event.ID, exporter.Name(), attempt, p.maxAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// addDeadLetter keeps an event that was not exported, if a dead letter store is set.
+func (p *Pipeline) addDeadLetter(event *model.ChangeEvent, exporter string, err error, attempts int) {
+	if p.deadLetters == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+	dl := &store.DeadLetter{Kind: store.DeadLetterKindExport, Channel: exporter, Event: event, Error: err.Error(), Attempts: attempts}
+	if err := p.deadLetters.AddDeadLetter(ctx, dl); err != nil {
+		klog.Errorf("Failed to dead-letter export of event %s, dropping it: %v", event.ID, err)
+		return
+	}
+	klog.Warningf("Dead-lettered export of event %s as entry %d", event.ID, dl.ID)
+}
+
+// matchesAny reports whether value matches any of the patterns. No patterns matches everything.
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// exportingStore wraps a store and queues every successfully saved event for export.
+type exportingStore struct {
+	store.Store
+	pipeline *Pipeline
+}
+
+// WrapStore returns a store that exports events after they are saved.
+// If no pipeline is configured, the store is returned unchanged.
+func WrapStore(s store.Store, pipeline *Pipeline) store.Store {
+	if s == nil || pipeline == nil {
+		return s
+	}
+	return &exportingStore{Store: s, pipeline: pipeline}
+}
+
+// Save persists the event and then queues it for export.
+func (s *exportingStore) Save(event *model.ChangeEvent) error {
+	if err := s.Store.Save(event); err != nil {
+		return err
+	}
+	s.pipeline.Enqueue(event)
+	return nil
+}
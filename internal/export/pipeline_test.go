@@ -0,0 +1,239 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeExporter records exported events and fails the first failures attempts.
+type fakeExporter struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	exported []string
+	records  []*Record
+}
+
+func (f *fakeExporter) Export(ctx context.Context, record *Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.attempts <= f.failures {
+		return errors.New("unavailable")
+	}
+	f.exported = append(f.exported, record.ID)
+	f.records = append(f.records, record)
+	return nil
+}
+
+// fakeDeadLetterStore records dead letters.
+type fakeDeadLetterStore struct {
+	store.DeadLetterStore
+	added []*store.DeadLetter
+}
+
+func (f *fakeDeadLetterStore) AddDeadLetter(ctx context.Context, dl *store.DeadLetter) error {
+	f.added = append(f.added, dl)
+	return nil
+}
+
+func (f *fakeExporter) Name() string {
+	return "fake"
+}
+
+func newTestPipeline(exporter Exporter, namespaces, kinds []string) *Pipeline {
+	return &Pipeline{
+		exporters:     []Exporter{exporter},
+		namespaces:    namespaces,
+		resourceKinds: kinds,
+		queue:         make(chan *model.ChangeEvent, 10),
+		maxAttempts:   3,
+		backoff:       time.Millisecond,
+	}
+}
+
+func TestPipeline_ShouldExport(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		kinds      []string
+		event      *model.ChangeEvent
+		want       bool
+	}{
+		{"no filters", nil, nil, &model.ChangeEvent{Namespace: "dev", ResourceKind: "Pod"}, true},
+		{"namespace wildcard match", []string{"prod-*"}, nil, &model.ChangeEvent{Namespace: "prod-eu", ResourceKind: "Pod"}, true},
+		{"namespace mismatch", []string{"prod-*"}, nil, &model.ChangeEvent{Namespace: "dev", ResourceKind: "Pod"}, false},
+		{"kind match", nil, []string{"Secret", "ConfigMap"}, &model.ChangeEvent{Namespace: "dev", ResourceKind: "Secret"}, true},
+		{"namespace matches but kind does not", []string{"prod"}, []string{"Secret"}, &model.ChangeEvent{Namespace: "prod", ResourceKind: "Pod"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPipeline(&fakeExporter{}, tt.namespaces, tt.kinds)
+			if got := p.ShouldExport(tt.event); got != tt.want {
+				t.Errorf("ShouldExport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline_RetriesUntilExported(t *testing.T) {
+	exporter := &fakeExporter{failures: 2}
+	p := newTestPipeline(exporter, nil, nil)
+
+	p.exportWithRetry(context.Background(), exporter, testEvent())
+
+	if exporter.attempts != 3 || len(exporter.exported) != 1 {
+		t.Errorf("attempts = %d, exported = %v; want 3 attempts and 1 export", exporter.attempts, exporter.exported)
+	}
+}
+
+func TestPipeline_GivesUpAfterMaxAttempts(t *testing.T) {
+	exporter := &fakeExporter{failures: 10}
+	p := newTestPipeline(exporter, nil, nil)
+	deadLetters := &fakeDeadLetterStore{}
+	p.SetDeadLetterStore(deadLetters)
+
+	p.exportWithRetry(context.Background(), exporter, testEvent())
+
+	if exporter.attempts != 3 || len(exporter.exported) != 0 {
+		t.Errorf("attempts = %d, exported = %v; want 3 attempts and no export", exporter.attempts, exporter.exported)
+	}
+	if len(deadLetters.added) != 1 || deadLetters.added[0].Kind != store.DeadLetterKindExport ||
+		deadLetters.added[0].Channel != "fake" || deadLetters.added[0].Attempts != 3 {
+		t.Errorf("Expected the event to be dead-lettered after 3 attempts, got %+v", deadLetters.added)
+	}
+}
+
+func TestPipeline_DeadLettersDroppedEvents(t *testing.T) {
+	p := newTestPipeline(&fakeExporter{}, nil, nil)
+	p.queue = make(chan *model.ChangeEvent, 1)
+	deadLetters := &fakeDeadLetterStore{}
+	p.SetDeadLetterStore(deadLetters)
+	dropped := exportsDroppedTotal.Value()
+
+	p.Enqueue(testEvent())
+	p.Enqueue(testEvent())
+
+	if got := exportsDroppedTotal.Value() - dropped; got != 1 {
+		t.Errorf("Expected 1 dropped event to be counted, got %d", got)
+	}
+	if len(deadLetters.added) != 1 || !strings.Contains(deadLetters.added[0].Error, "queue full") {
+		t.Errorf("Expected the dropped event to be dead-lettered, got %+v", deadLetters.added)
+	}
+}
+
+func TestPipeline_EncryptsPayload(t *testing.T) {
+	encryptor, err := encryption.NewEncryptorFromKey(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x02}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exporter := &fakeExporter{}
+	p := newTestPipeline(exporter, nil, nil)
+	p.SetEncryptor(encryptor)
+
+	event := testEvent()
+	event.ObjectSnapshot = map[string]interface{}{"data": map[string]interface{}{"password": "hunter2"}}
+	p.exportWithRetry(context.Background(), exporter, event)
+
+	if len(exporter.records) != 1 {
+		t.Fatalf("Expected 1 export, got %d", len(exporter.records))
+	}
+	body, _ := json.Marshal(exporter.records[0])
+	if bytes.Contains(body, []byte("hunter2")) || !encryption.IsEnvelope(exporter.records[0].Payload) {
+		t.Fatalf("Expected the snapshot to be exported encrypted, got %s", body)
+	}
+	plaintext, err := encryptor.Decrypt(exporter.records[0].Payload)
+	if err != nil || !bytes.Contains(plaintext, []byte("hunter2")) {
+		t.Errorf("Expected the payload to decrypt to the snapshot, got %s, %v", plaintext, err)
+	}
+	if event.ObjectSnapshot == nil {
+		t.Error("The saved event must keep its snapshot")
+	}
+}
+
+// savingStore is a minimal store.Store that records saved events, skipping those whose
+// fingerprint is already stored like PostgreSQLStore.
+type savingStore struct {
+	store.Store
+	saved        []string
+	fingerprints map[string]bool
+	err          error
+}
+
+func (s *savingStore) Save(event *model.ChangeEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	if event.Fingerprint != "" {
+		if s.fingerprints[event.Fingerprint] {
+			return store.ErrDuplicate
+		}
+		if s.fingerprints == nil {
+			s.fingerprints = map[string]bool{}
+		}
+		s.fingerprints[event.Fingerprint] = true
+	}
+	s.saved = append(s.saved, event.ID)
+	return nil
+}
+
+func TestWrapStore(t *testing.T) {
+	exporter := &fakeExporter{}
+	p := newTestPipeline(exporter, []string{"default"}, nil)
+	inner := &savingStore{}
+	s := WrapStore(inner, p)
+
+	if err := s.Save(testEvent()); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if len(inner.saved) != 1 || len(p.queue) != 1 {
+		t.Errorf("saved = %v, queued = %d; want event saved and queued", inner.saved, len(p.queue))
+	}
+
+	// Events that fail to save are not exported
+	inner.err = errors.New("db down")
+	if err := s.Save(testEvent()); err == nil {
+		t.Error("Save() should return the store error")
+	}
+	if len(p.queue) != 1 {
+		t.Errorf("queued = %d, want 1", len(p.queue))
+	}
+}
+
+func TestWrapStore_Duplicates(t *testing.T) {
+	p := newTestPipeline(&fakeExporter{}, []string{"default"}, nil)
+	inner := &savingStore{}
+	s := WrapStore(inner, p)
+
+	// A retried admission request is saved once, and exported once
+	event, retry := testEvent(), testEvent()
+	event.Fingerprint, retry.Fingerprint = "f1", "f1"
+	if err := s.Save(event); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Save(retry); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("Save() of the retry error = %v, want ErrDuplicate", err)
+	}
+	if len(inner.saved) != 1 || len(p.queue) != 1 {
+		t.Errorf("saved = %v, queued = %d; want the event saved and queued once", inner.saved, len(p.queue))
+	}
+}
+
+func TestWrapStore_NoPipeline(t *testing.T) {
+	inner := &savingStore{}
+	if s := WrapStore(inner, nil); s != inner {
+		t.Error("WrapStore() without pipeline should return the store unchanged")
+	}
+}
@@ -0,0 +1,220 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// unsafeKeyChars matches characters that are replaced in object keys.
+var unsafeKeyChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// S3Exporter writes each event as a separate object into a bucket with Object Lock
+// enabled. Objects are created with a retention period, so they cannot be overwritten
+// or deleted until it expires, and are written with If-None-Match so an existing
+// object is never replaced.
+type S3Exporter struct {
+	bucket          string
+	region          string
+	prefix          string
+	endpoint        string
+	pathStyle       bool
+	retentionMode   string
+	retentionDays   int
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+	now             func() time.Time
+}
+
+// NewS3Exporter creates a new S3 exporter.
+func NewS3Exporter(cfg *S3Config) (*S3Exporter, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("bucket and region are required")
+	}
+
+	e := &S3Exporter{
+		bucket:          cfg.Bucket,
+		region:          cfg.Region,
+		prefix:          strings.Trim(cfg.Prefix, "/"),
+		endpoint:        strings.TrimSuffix(cfg.Endpoint, "/"),
+		pathStyle:       cfg.Endpoint != "",
+		retentionMode:   strings.ToUpper(cfg.RetentionMode),
+		retentionDays:   cfg.RetentionDays,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		now: time.Now,
+	}
+
+	if e.endpoint == "" {
+		e.endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", e.bucket, e.region)
+	}
+	if e.retentionMode == "" {
+		e.retentionMode = "COMPLIANCE"
+	}
+	if e.retentionMode != "COMPLIANCE" && e.retentionMode != "GOVERNANCE" {
+		return nil, fmt.Errorf("invalid retention mode %q, must be COMPLIANCE or GOVERNANCE", cfg.RetentionMode)
+	}
+	if e.retentionDays <= 0 {
+		e.retentionDays = 2555
+	}
+	if e.accessKeyID == "" {
+		e.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		e.secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		e.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if e.accessKeyID == "" || e.secretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+
+	return e, nil
+}
+
+// Name returns the exporter name.
+func (e *S3Exporter) Name() string {
+	return "s3"
+}
+
+// Export writes the record of an event to <prefix>/<yyyy>/<mm>/<dd>/<event-id>.json.
+func (e *S3Exporter) Export(ctx context.Context, record *Record) error {
+	event := record.ChangeEvent
+	body, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	url := e.endpoint + "/" + e.objectKey(event)
+	if e.pathStyle {
+		url = e.endpoint + "/" + e.bucket + "/" + e.objectKey(event)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	md5Sum := md5.Sum(body) // Required by S3 for PUTs with Object Lock
+	now := e.now().UTC()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+	req.Header.Set("If-None-Match", "*")
+	req.Header.Set("X-Amz-Object-Lock-Mode", e.retentionMode)
+	req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", now.AddDate(0, 0, e.retentionDays).Format(time.RFC3339))
+	if e.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", e.sessionToken)
+	}
+	signV4(req, body, e.accessKeyID, e.secretAccessKey, e.region, "s3", now)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send S3 request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 412 means the object already exists: the event was exported before
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// objectKey returns the object key for an event, partitioned by event date.
+func (e *S3Exporter) objectKey(event *model.ChangeEvent) string {
+	key := event.Timestamp.UTC().Format("2006/01/02") + "/" + unsafeKeyChars.ReplaceAllString(event.ID, "_") + ".json"
+	if e.prefix != "" {
+		key = e.prefix + "/" + key
+	}
+	return key
+}
+
+// signV4 signs req with AWS Signature Version 4, covering the host and all headers set on req.
+func signV4(req *http.Request, body []byte, accessKeyID, secretAccessKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey(secretAccessKey, date, region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature Version 4 key of a date, region and service.
+func signingKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,293 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uploadPartSize is the size of the parts of a multipart upload. S3 requires at least
+// 5 MiB for every part but the last, and allows 10,000 parts, so exports up to ~80 GiB fit.
+const uploadPartSize = 8 << 20
+
+// S3Uploader writes large objects, such as the results of export jobs, to a bucket with
+// multipart uploads, and creates presigned links to download them.
+type S3Uploader struct {
+	bucket          string
+	region          string
+	prefix          string
+	endpoint        string
+	pathStyle       bool
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+	now             func() time.Time
+	partSize        int
+}
+
+// NewS3Uploader creates a new S3 uploader.
+func NewS3Uploader(cfg *S3Config) (*S3Uploader, error) {
+	if cfg.Bucket == "" || cfg.Region == "" {
+		return nil, fmt.Errorf("bucket and region are required")
+	}
+
+	u := &S3Uploader{
+		bucket:          cfg.Bucket,
+		region:          cfg.Region,
+		prefix:          strings.Trim(cfg.Prefix, "/"),
+		endpoint:        strings.TrimSuffix(cfg.Endpoint, "/"),
+		pathStyle:       cfg.Endpoint != "",
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+		client: &http.Client{
+			Timeout: time.Minute, // Per request, i.e. per part
+		},
+		now:      time.Now,
+		partSize: uploadPartSize,
+	}
+
+	if u.endpoint == "" {
+		u.endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", u.bucket, u.region)
+	}
+	if u.accessKeyID == "" {
+		u.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		u.secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		u.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if u.accessKeyID == "" || u.secretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+
+	return u, nil
+}
+
+// Key returns the object key of name, under the configured prefix.
+func (u *S3Uploader) Key(name string) string {
+	if u.prefix == "" {
+		return name
+	}
+	return u.prefix + "/" + name
+}
+
+// Upload writes everything read from body to the object key with a multipart upload, and
+// returns the number of bytes written. The upload is aborted if reading or writing fails.
+func (u *S3Uploader) Upload(ctx context.Context, key, contentType string, body io.Reader) (int64, error) {
+	uploadID, err := u.createUpload(ctx, key, contentType)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := u.uploadParts(ctx, key, uploadID, body)
+	if err != nil {
+		// Abort without ctx, which may be what failed, so the parts don't linger in the bucket
+		abortCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, abortErr := u.do(abortCtx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil); abortErr != nil {
+			err = fmt.Errorf("%w (aborting the upload also failed: %v)", err, abortErr)
+		}
+		return 0, err
+	}
+	return written, nil
+}
+
+// createUpload starts a multipart upload and returns its ID.
+func (u *S3Uploader) createUpload(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := u.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return "", fmt.Errorf("failed to start upload: %w", err)
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to start upload: unexpected response %q", string(resp))
+	}
+	return result.UploadID, nil
+}
+
+// completedPart is a part of a multipart upload, as listed to complete it.
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts uploads body in parts and completes the upload.
+func (u *S3Uploader) uploadParts(ctx context.Context, key, uploadID string, body io.Reader) (int64, error) {
+	var (
+		parts   []completedPart
+		written int64
+		buf     = make([]byte, u.partSize)
+	)
+	for partNumber := 1; ; partNumber++ {
+		n, readErr := io.ReadFull(body, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("failed to read part %d: %w", partNumber, readErr)
+		}
+		// The first part is uploaded even if empty, since an upload needs at least one
+		if n > 0 || partNumber == 1 {
+			part := buf[:n]
+			md5Sum := md5.Sum(part) // Required for uploads to buckets with Object Lock
+			query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+			headers := map[string]string{"Content-MD5": base64.StdEncoding.EncodeToString(md5Sum[:])}
+			etag, err := u.putPart(ctx, key, query, part, headers)
+			if err != nil {
+				return 0, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+			}
+			parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+			written += int64(n)
+		}
+		if readErr != nil {
+			break // Last part
+		}
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete upload: %w", err)
+	}
+	resp, err := u.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, complete, map[string]string{"Content-Type": "application/xml"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete upload: %w", err)
+	}
+	// S3 may report a failure to complete with status 200 and an error body
+	if bytes.Contains(resp, []byte("<Error>")) {
+		return 0, fmt.Errorf("failed to complete upload: %s", strings.TrimSpace(string(resp)))
+	}
+	return written, nil
+}
+
+// putPart uploads a part and returns its ETag.
+func (u *S3Uploader) putPart(ctx context.Context, key string, query url.Values, part []byte, headers map[string]string) (string, error) {
+	req, err := u.newRequest(ctx, http.MethodPut, key, query, part, headers)
+	if err != nil {
+		return "", err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkS3Status(resp); err != nil {
+		return "", err
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("S3 returned no ETag")
+	}
+	return etag, nil
+}
+
+// do sends a signed request and returns the response body.
+func (u *S3Uploader) do(ctx context.Context, method, key string, query url.Values, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := u.newRequest(ctx, method, key, query, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send S3 request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkS3Status(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// newRequest creates a request for key, signed with Signature Version 4.
+func (u *S3Uploader) newRequest(ctx context.Context, method, key string, query url.Values, body []byte, headers map[string]string) (*http.Request, error) {
+	reqURL := u.objectURL(key)
+	if len(query) > 0 {
+		reqURL += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if u.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.sessionToken)
+	}
+	signV4(req, body, u.accessKeyID, u.secretAccessKey, u.region, "s3", u.now().UTC())
+	return req, nil
+}
+
+// PresignGet returns a link downloading the object key without credentials until expiry
+// has passed (at most 7 days), signed with Signature Version 4 query parameters.
+func (u *S3Uploader) PresignGet(key string, expiry time.Duration) string {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := date + "/" + u.region + "/s3/aws4_request"
+
+	objectURL, _ := url.Parse(u.objectURL(key))
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {u.accessKeyID + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(expiry.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	if u.sessionToken != "" {
+		query.Set("X-Amz-Security-Token", u.sessionToken)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(u.secretAccessKey, date, u.region, "s3"), stringToSign))
+
+	return objectURL.String() + "?" + canonicalQuery(query) + "&X-Amz-Signature=" + signature
+}
+
+// objectURL returns the URL of the object key.
+func (u *S3Uploader) objectURL(key string) string {
+	if u.pathStyle {
+		return u.endpoint + "/" + u.bucket + "/" + key
+	}
+	return u.endpoint + "/" + key
+}
+
+// canonicalQuery encodes query parameters sorted by name, with spaces as %20, as
+// Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+// checkS3Status returns an error with the start of the body of unsuccessful responses.
+func checkS3Status(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
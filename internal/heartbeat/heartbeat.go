@@ -0,0 +1,104 @@
+// Package heartbeat emits the synthetic events the producers (the admission webhook, the
+// audit processor and the ingest API) send through their pipeline periodically, so the
+// delivery of events to the store can be measured end to end: a lost heartbeat leaves a
+// gap in its producer's sequence, and a stored one records how long it took.
+package heartbeat
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// DefaultInterval is how often producers emit heartbeats when no interval is configured.
+const DefaultInterval = time.Minute
+
+// ResourceKind is the resource kind of heartbeat events.
+const ResourceKind = "Heartbeat"
+
+// emitted counts the heartbeats emitted, per producer.
+var emitted = expvar.NewMap("heartbeats_emitted_total")
+
+// Emitter numbers the heartbeats of a producer instance.
+type Emitter struct {
+	producer string
+	cluster  string
+	instance string
+	interval time.Duration
+
+	mu       sync.Mutex
+	sequence int64
+}
+
+// NewEmitter creates an emitter of the heartbeats of producer in cluster, every interval.
+// The instance is named after the host and the start time, so the sequence of a restarted
+// process isn't mistaken for that of the previous one. A non-positive interval returns nil,
+// which emits nothing.
+func NewEmitter(producer, cluster string, interval time.Duration) *Emitter {
+	if interval <= 0 {
+		return nil
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return &Emitter{
+		producer: producer,
+		cluster:  cluster,
+		instance: fmt.Sprintf("%s/%d", host, time.Now().Unix()),
+		interval: interval,
+	}
+}
+
+// Next returns the next heartbeat event of the sequence.
+func (e *Emitter) Next() *model.ChangeEvent {
+	e.mu.Lock()
+	e.sequence++
+	sequence := e.sequence
+	e.mu.Unlock()
+
+	event := &model.ChangeEvent{
+		Timestamp:    time.Now().UTC(),
+		Operation:    model.OperationHeartbeat,
+		ResourceKind: ResourceKind,
+		Name:         e.producer,
+		Source:       model.Source{Tool: "kubechronicle"},
+		Allowed:      true,
+		Heartbeat: &model.Heartbeat{
+			Producer: e.producer,
+			Cluster:  e.cluster,
+			Instance: e.instance,
+			Sequence: sequence,
+			Interval: e.interval.String(),
+		},
+	}
+	event.ID = model.NewEventID(event.Timestamp)
+	emitted.Add(e.producer, 1)
+	return event
+}
+
+// Start calls emit with a heartbeat every interval until ctx is cancelled. emit hands the
+// heartbeat to the producer's pipeline, the same way as the events it receives, and must
+// not block for long. A nil emitter does nothing.
+func (e *Emitter) Start(ctx context.Context, emit func(*model.ChangeEvent)) {
+	if e == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				emit(e.Next())
+			}
+		}
+	}()
+}
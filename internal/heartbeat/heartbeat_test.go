@@ -0,0 +1,51 @@
+package heartbeat
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestEmitter_Next(t *testing.T) {
+	if NewEmitter("webhook", "prod", 0) != nil {
+		t.Error("NewEmitter() with no interval should return nil")
+	}
+
+	emitter := NewEmitter("webhook", "prod", time.Minute)
+	first, second := emitter.Next(), emitter.Next()
+	if first.Operation != model.OperationHeartbeat || first.ResourceKind != ResourceKind || first.ID == "" || first.ID == second.ID {
+		t.Errorf("Unexpected heartbeat event: %+v", first)
+	}
+	hb := first.Heartbeat
+	if hb == nil || hb.Producer != "webhook" || hb.Cluster != "prod" || hb.Sequence != 1 || hb.Interval != "1m0s" || !strings.Contains(hb.Instance, "/") {
+		t.Fatalf("Unexpected heartbeat: %+v", hb)
+	}
+	if second.Heartbeat.Sequence != 2 || second.Heartbeat.Instance != hb.Instance {
+		t.Errorf("Expected the next heartbeat of the same instance, got %+v", second.Heartbeat)
+	}
+}
+
+func TestEmitter_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A nil emitter emits nothing
+	var disabled *Emitter
+	disabled.Start(ctx, func(*model.ChangeEvent) { t.Error("A nil emitter should not emit") })
+
+	events := make(chan *model.ChangeEvent, 10)
+	NewEmitter("ingest-api", "prod", 10*time.Millisecond).Start(ctx, func(event *model.ChangeEvent) { events <- event })
+	for sequence := int64(1); sequence <= 2; sequence++ {
+		select {
+		case event := <-events:
+			if event.Heartbeat.Sequence != sequence {
+				t.Errorf("Expected heartbeat %d, got %d", sequence, event.Heartbeat.Sequence)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for a heartbeat")
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package i18n is the message catalog of the text kubechronicle shows people: the denial
+// messages of blocked requests and the labels of alerts. Messages are looked up by key in
+// the messages of a locale, then of its language ("de" for "de-AT"), then in the built-in
+// English catalog. Messages may contain {{name}} placeholders, replaced when formatted.
+package i18n
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultLocale is the locale of the built-in messages.
+const DefaultLocale = "en"
+
+// Message keys of the denial messages of blocked requests.
+const (
+	BlockMessage = "block.message" // Denial message when the block config sets none
+	BlockRule    = "block.rule"
+	BlockOwner   = "block.owner"
+	BlockReason  = "block.reason"
+	BlockTicket  = "block.ticket"
+	BlockExpires = "block.expires"
+)
+
+// Message keys of alerts.
+const (
+	AlertTitle          = "alert.title"   // {{operation}}
+	AlertSummary        = "alert.summary" // {{operation}}, {{kind}}, {{namespace}}, {{name}}
+	AlertSubject        = "alert.subject" // {{operation}}, {{kind}}, {{namespace}}, {{name}}
+	AlertResource       = "alert.resource"
+	AlertNamespace      = "alert.namespace"
+	AlertOperation      = "alert.operation"
+	AlertTime           = "alert.time"
+	AlertTimestamp      = "alert.timestamp"
+	AlertActor          = "alert.actor"
+	AlertUser           = "alert.user"
+	AlertUsername       = "alert.username"
+	AlertGroups         = "alert.groups"
+	AlertServiceAccount = "alert.service_account"
+	AlertSourceIP       = "alert.source_ip"
+	AlertTool           = "alert.tool"
+	AlertSourceTool     = "alert.source_tool"
+	AlertTeam           = "alert.team"
+	AlertOnCall         = "alert.oncall"
+	AlertCommand        = "alert.command"
+	AlertRisk           = "alert.risk"
+	AlertChanges        = "alert.changes"
+	AlertChangeCount    = "alert.change_count" // {{count}}
+	AlertPatchCount     = "alert.patch_count"  // {{count}}
+)
+
+// english is the built-in catalog, and the fallback of every locale.
+var english = map[string]string{
+	BlockMessage: "Resource blocked by kubechronicle policy",
+	BlockRule:    "rule",
+	BlockOwner:   "owner",
+	BlockReason:  "reason",
+	BlockTicket:  "ticket",
+	BlockExpires: "expires",
+
+	AlertTitle:          "Kubernetes Resource {{operation}}",
+	AlertSummary:        "Kubernetes Resource {{operation}}: {{kind}}/{{namespace}}/{{name}}",
+	AlertSubject:        "[kubechronicle] {{operation}}: {{kind}}/{{namespace}}/{{name}}",
+	AlertResource:       "Resource",
+	AlertNamespace:      "Namespace",
+	AlertOperation:      "Operation",
+	AlertTime:           "Time",
+	AlertTimestamp:      "Timestamp",
+	AlertActor:          "Actor Information",
+	AlertUser:           "User",
+	AlertUsername:       "Username",
+	AlertGroups:         "Groups",
+	AlertServiceAccount: "Service Account",
+	AlertSourceIP:       "Source IP",
+	AlertTool:           "Tool",
+	AlertSourceTool:     "Source Tool",
+	AlertTeam:           "Team",
+	AlertOnCall:         "On-call",
+	AlertCommand:        "Command",
+	AlertRisk:           "Risk",
+	AlertChanges:        "Changes",
+	AlertChangeCount:    "{{count}} change(s)",
+	AlertPatchCount:     "{{count}} patch operation(s)",
+}
+
+// Catalog holds the translated messages and selects the locale of each namespace. The
+// locale of a namespace is that of the first NamespaceLocale matching it, else
+// DefaultLocale.
+type Catalog struct {
+	// DefaultLocale is the locale of namespaces without a NamespaceLocale (default: en).
+	DefaultLocale string `json:"default_locale,omitempty"`
+
+	// NamespaceLocales select the locale of namespaces, e.g. {"namespace": "team-fr-*", "locale": "fr"}.
+	NamespaceLocales []NamespaceLocale `json:"namespace_locales,omitempty"`
+
+	// Messages are the translated messages by locale and key. Keys missing from a locale
+	// fall back to its language, then to English.
+	Messages map[string]map[string]string `json:"messages,omitempty"`
+}
+
+// NamespaceLocale sets the locale of the namespaces matching a path.Match pattern.
+type NamespaceLocale struct {
+	Namespace string `json:"namespace"`
+	Locale    string `json:"locale"`
+}
+
+// Validate checks the namespace patterns, and that the messages have known keys.
+func (c *Catalog) Validate() error {
+	for i, nl := range c.NamespaceLocales {
+		if _, err := path.Match(nl.Namespace, ""); err != nil || nl.Namespace == "" {
+			return fmt.Errorf("namespace_locales[%d]: invalid namespace pattern %q", i, nl.Namespace)
+		}
+		if nl.Locale == "" {
+			return fmt.Errorf("namespace_locales[%d]: locale is required", i)
+		}
+	}
+	for locale, messages := range c.Messages {
+		if locale == "" {
+			return fmt.Errorf("messages: empty locale")
+		}
+		for key := range messages {
+			if _, ok := english[key]; !ok {
+				return fmt.Errorf("messages[%s]: unknown key %q", locale, key)
+			}
+		}
+	}
+	return nil
+}
+
+// LocaleFor returns the locale of a namespace. A nil catalog returns DefaultLocale.
+func (c *Catalog) LocaleFor(namespace string) string {
+	if c == nil {
+		return DefaultLocale
+	}
+	for _, nl := range c.NamespaceLocales {
+		if matched, _ := path.Match(nl.Namespace, namespace); matched {
+			return nl.Locale
+		}
+	}
+	if c.DefaultLocale != "" {
+		return c.DefaultLocale
+	}
+	return DefaultLocale
+}
+
+// Localizer returns the localizer of a locale.
+func (c *Catalog) Localizer(locale string) *Localizer {
+	l := &Localizer{locale: locale}
+	if c != nil {
+		for _, candidate := range fallbacks(locale) {
+			if messages, ok := c.Messages[candidate]; ok {
+				l.messages = append(l.messages, messages)
+			}
+		}
+	}
+	return l
+}
+
+// ForNamespace returns the localizer of a namespace's locale.
+func (c *Catalog) ForNamespace(namespace string) *Localizer {
+	return c.Localizer(c.LocaleFor(namespace))
+}
+
+// fallbacks returns a locale followed by its language, if it has a region or script.
+func fallbacks(locale string) []string {
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		return []string{locale, language}
+	}
+	return []string{locale}
+}
+
+// Localizer formats the messages of a locale. A nil Localizer formats English messages.
+type Localizer struct {
+	locale   string
+	messages []map[string]string // Of the locale, then of its language
+}
+
+// Locale returns the locale of the localizer.
+func (l *Localizer) Locale() string {
+	if l == nil {
+		return DefaultLocale
+	}
+	return l.locale
+}
+
+// Message returns the message of a key, with its placeholders replaced by args, given as
+// name and value pairs: Message(AlertTitle, "operation", "CREATE").
+func (l *Localizer) Message(key string, args ...string) string {
+	message, ok := "", false
+	if l != nil {
+		for _, messages := range l.messages {
+			if message, ok = messages[key]; ok && message != "" {
+				break
+			}
+		}
+	}
+	if !ok || message == "" {
+		if message, ok = english[key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return message
+	}
+	replacements := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		replacements = append(replacements, "{{"+args[i]+"}}", args[i+1])
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// Select returns the text of the localizer's locale, or its language, from texts by locale,
+// e.g. the translations of a block rule's message.
+func (l *Localizer) Select(texts map[string]string) (string, bool) {
+	if len(texts) == 0 {
+		return "", false
+	}
+	for _, candidate := range fallbacks(l.Locale()) {
+		if text, ok := texts[candidate]; ok && text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
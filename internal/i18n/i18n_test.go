@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCatalog_Localizer(t *testing.T) {
+	var catalog Catalog
+	raw := `{
+		"default_locale": "fr",
+		"namespace_locales": [{"namespace": "team-berlin-*", "locale": "de-AT"}],
+		"messages": {
+			"de": {"alert.title": "Kubernetes-Ressource {{operation}}", "alert.user": "Benutzer"},
+			"de-AT": {"alert.user": "Anwender"},
+			"fr": {"alert.user": "Utilisateur"}
+		}
+	}`
+	if err := json.Unmarshal([]byte(raw), &catalog); err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	tests := []struct {
+		namespace, key, want string
+	}{
+		{"team-berlin-api", AlertUser, "Anwender"},                     // Locale
+		{"team-berlin-api", AlertTitle, "Kubernetes-Ressource DELETE"}, // Language
+		{"team-berlin-api", AlertResource, "Resource"},                 // English
+		{"payments", AlertUser, "Utilisateur"},                         // Default locale
+		{"payments", AlertTitle, "Kubernetes Resource DELETE"},         // English
+		{"payments", "alert.unknown", "alert.unknown"},                 // Unknown key
+	}
+	for _, tt := range tests {
+		if got := catalog.ForNamespace(tt.namespace).Message(tt.key, "operation", "DELETE"); got != tt.want {
+			t.Errorf("Message(%s, %s) = %q, want %q", tt.namespace, tt.key, got, tt.want)
+		}
+	}
+
+	var nilCatalog *Catalog
+	if got := nilCatalog.ForNamespace("payments").Message(BlockMessage); got != "Resource blocked by kubechronicle policy" {
+		t.Errorf("nil catalog message = %q", got)
+	}
+	var nilLocalizer *Localizer
+	if got := nilLocalizer.Message(AlertChangeCount, "count", "2"); got != "2 change(s)" {
+		t.Errorf("nil localizer message = %q", got)
+	}
+}
+
+func TestLocalizer_Select(t *testing.T) {
+	texts := map[string]string{"de": "Gesperrt", "fr": ""}
+	for locale, want := range map[string]string{"de": "Gesperrt", "de_CH": "Gesperrt", "fr": "", "en": ""} {
+		got, ok := (&Catalog{}).Localizer(locale).Select(texts)
+		if got != want || ok != (want != "") {
+			t.Errorf("Select() in %s = %q, %v, want %q", locale, got, ok, want)
+		}
+	}
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog Catalog
+	}{
+		{"unknown key", Catalog{Messages: map[string]map[string]string{"de": {"alert.titel": "x"}}}},
+		{"empty locale", Catalog{Messages: map[string]map[string]string{"": {}}}},
+		{"invalid pattern", Catalog{NamespaceLocales: []NamespaceLocale{{Namespace: "[", Locale: "de"}}}},
+		{"missing locale", Catalog{NamespaceLocales: []NamespaceLocale{{Namespace: "*"}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.catalog.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want error", tt.name)
+		}
+	}
+}
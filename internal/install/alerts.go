@@ -0,0 +1,164 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// AlertConfig describes the PrometheusRule generated for a kubechronicle installation.
+type AlertConfig struct {
+	Namespace string            // Namespace of the installation, selecting its metrics and holding the rule
+	Name      string            // Name of the PrometheusRule
+	Labels    map[string]string // Extra labels of the PrometheusRule, e.g. the Prometheus Operator's ruleSelector
+
+	QueueSaturation float64       // Fraction of the event queue in use to alert at (0-1)
+	SlowResponses   float64       // Fraction of admission responses over the latency budget to alert at (0-1)
+	CertExpiry      time.Duration // Remaining validity of the webhook certificate to alert at
+}
+
+// DefaultAlertConfig returns the recommended alert thresholds.
+func DefaultAlertConfig() *AlertConfig {
+	return &AlertConfig{
+		Namespace:       "kubechronicle",
+		Name:            "kubechronicle",
+		QueueSaturation: 0.8,
+		SlowResponses:   0.05,
+		CertExpiry:      14 * 24 * time.Hour,
+	}
+}
+
+// validate checks the fields the rule can't be generated without.
+func (c *AlertConfig) validate() error {
+	if c.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if c.QueueSaturation <= 0 || c.QueueSaturation > 1 {
+		return fmt.Errorf("queue saturation must be between 0 and 1, got %v", c.QueueSaturation)
+	}
+	if c.SlowResponses <= 0 || c.SlowResponses > 1 {
+		return fmt.Errorf("slow responses must be between 0 and 1, got %v", c.SlowResponses)
+	}
+	if c.CertExpiry <= 0 {
+		return fmt.Errorf("certificate expiry must be positive, got %v", c.CertExpiry)
+	}
+	return nil
+}
+
+// alertRule is a Prometheus alerting rule.
+type alertRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// rule creates an alerting rule of the given severity.
+func rule(name, expr, duration, severity, summary, description string) alertRule {
+	return alertRule{
+		Alert:  name,
+		Expr:   expr,
+		For:    duration,
+		Labels: map[string]string{"severity": severity},
+		Annotations: map[string]string{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+}
+
+// alertRules returns the recommended alerting rules on the metrics kubechronicle serves at /metrics.
+func alertRules(cfg *AlertConfig) ([]alertRule, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	// Metrics are selected by the namespace label Prometheus adds when scraping the pods
+	selector := fmt.Sprintf(`{namespace=%q}`, cfg.Namespace)
+	return []alertRule{
+		rule("KubechronicleEventsDropped",
+			fmt.Sprintf("sum(increase(webhook_dropped_events_total%s[10m])) > 0", selector),
+			"", "critical",
+			"kubechronicle is dropping change events",
+			"The webhook's event queue was full and {{ $value }} change events were not recorded in the last 10 minutes."),
+		rule("KubechronicleQueueSaturated",
+			fmt.Sprintf("max by (pod) (webhook_queue_length%s / webhook_queue_capacity%s) > %v", selector, selector, cfg.QueueSaturation),
+			"5m", "warning",
+			"kubechronicle event queue is filling up",
+			"The event queue of {{ $labels.pod }} is {{ $value | humanizePercentage }} full; events are dropped once it is full. The store may be slow or unavailable."),
+		rule("KubechronicleStoreUnavailable",
+			fmt.Sprintf("max(store_circuit_open%s) > 0", selector),
+			"5m", "critical",
+			"kubechronicle can't reach the database",
+			"The store's circuit breaker has been open for 5 minutes, short-circuiting saves. Events are spooled to SPILL_DIR if set, and dead-lettered or lost otherwise."),
+		rule("KubechronicleStoreErrors",
+			fmt.Sprintf("sum(increase(webhook_store_errors_total%s[10m])) > 0", selector),
+			"", "warning",
+			"kubechronicle fails to save change events",
+			"{{ $value }} store writes failed in the last 10 minutes. Events that fail every retry are kept in the dead letter queue."),
+		rule("KubechronicleDeadLetters",
+			fmt.Sprintf("sum(increase(webhook_dead_letters_total%s[15m])) > 0", selector),
+			"", "warning",
+			"kubechronicle added events to the dead letter queue",
+			"{{ $value }} events or alerts failed on every attempt in the last 15 minutes. Inspect and requeue them through /api/admin/dlq."),
+		rule("KubechronicleWebhookSlow",
+			fmt.Sprintf("sum(rate(webhook_slow_responses_total%s[5m])) / sum(rate(webhook_responses_total%s[5m])) > %v", selector, selector, cfg.SlowResponses),
+			"10m", "warning",
+			"kubechronicle webhook responses are slow",
+			"{{ $value | humanizePercentage }} of admission responses exceed WEBHOOK_LATENCY_BUDGET, adding latency to every API request the webhook receives."),
+		rule("KubechronicleWebhookCertExpiring",
+			fmt.Sprintf("min(webhook_tls_cert_expiry_timestamp_seconds%s > 0) - time() < %d", selector, int64(cfg.CertExpiry.Seconds())),
+			"", "warning",
+			"kubechronicle webhook certificate is expiring",
+			"The webhook's TLS certificate expires in {{ $value | humanizeDuration }}. Renew it and restart the webhook before the API server fails to call it."),
+		rule("KubechronicleConfigReloadErrors",
+			fmt.Sprintf("sum(increase(webhook_config_reload_errors_total%s[15m])) > 0", selector),
+			"", "warning",
+			"kubechronicle rejected an invalid patterns config",
+			"The webhook rejected {{ $value }} invalid patterns ConfigMap changes in the last 15 minutes and keeps using the previous config."),
+	}, nil
+}
+
+// AlertManifest returns the alerting rules as a Prometheus Operator PrometheusRule manifest.
+func AlertManifest(cfg *AlertConfig) ([]byte, error) {
+	rules, err := alertRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"name":      cfg.Name,
+		"namespace": cfg.Namespace,
+	}
+	ruleLabels := labels("monitoring")
+	for key, value := range cfg.Labels {
+		ruleLabels[key] = value
+	}
+	metadata["labels"] = ruleLabels
+
+	content, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata":   metadata,
+		"spec": map[string]interface{}{
+			"groups": []map[string]interface{}{{
+				"name":  "kubechronicle",
+				"rules": rules,
+			}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal PrometheusRule: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by kubechronicle install alerts. Do not edit.\n")
+	buf.Write(content)
+	return buf.Bytes(), nil
+}
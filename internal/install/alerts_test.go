@@ -0,0 +1,118 @@
+package install
+
+import (
+	"expvar"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	// Registers the webhook's and store's metrics, which the rules must reference by their current names
+	_ "github.com/kubechronicle/kubechronicle/internal/admission"
+)
+
+func TestAlertManifest(t *testing.T) {
+	cfg := DefaultAlertConfig()
+	cfg.Namespace = "audit"
+	cfg.Labels = map[string]string{"release": "prometheus"}
+	cfg.CertExpiry = 7 * 24 * time.Hour
+
+	manifest, err := AlertManifest(cfg)
+	if err != nil {
+		t.Fatalf("AlertManifest() error = %v", err)
+	}
+
+	var rule struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string            `json:"name"`
+			Namespace string            `json:"namespace"`
+			Labels    map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			Groups []struct {
+				Rules []alertRule `json:"rules"`
+			} `json:"groups"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(manifest, &rule); err != nil {
+		t.Fatalf("Failed to parse manifest: %v\n%s", err, manifest)
+	}
+	if rule.Kind != "PrometheusRule" || rule.Metadata.Name != "kubechronicle" || rule.Metadata.Namespace != "audit" {
+		t.Errorf("Unexpected PrometheusRule: %+v", rule)
+	}
+	if rule.Metadata.Labels["release"] != "prometheus" || rule.Metadata.Labels["app.kubernetes.io/name"] != "kubechronicle" {
+		t.Errorf("Labels = %v", rule.Metadata.Labels)
+	}
+	if len(rule.Spec.Groups) != 1 {
+		t.Fatalf("Got %d groups, want 1", len(rule.Spec.Groups))
+	}
+
+	exprs := make(map[string]string)
+	for _, r := range rule.Spec.Groups[0].Rules {
+		exprs[r.Alert] = r.Expr
+		if r.Labels["severity"] == "" || r.Annotations["summary"] == "" || r.Annotations["description"] == "" {
+			t.Errorf("Rule %s should have a severity, summary and description: %+v", r.Alert, r)
+		}
+		if !strings.Contains(r.Expr, `{namespace="audit"}`) {
+			t.Errorf("Rule %s should select the installation's namespace: %s", r.Alert, r.Expr)
+		}
+	}
+	for alert, metric := range map[string]string{
+		"KubechronicleEventsDropped":       "webhook_dropped_events_total",
+		"KubechronicleQueueSaturated":      "webhook_queue_capacity",
+		"KubechronicleStoreUnavailable":    "store_circuit_open",
+		"KubechronicleStoreErrors":         "webhook_store_errors_total",
+		"KubechronicleWebhookSlow":         "webhook_slow_responses_total",
+		"KubechronicleWebhookCertExpiring": "webhook_tls_cert_expiry_timestamp_seconds",
+	} {
+		if !strings.Contains(exprs[alert], metric) {
+			t.Errorf("Rule %s = %q, want an expression on %s", alert, exprs[alert], metric)
+		}
+	}
+	if !strings.HasSuffix(exprs["KubechronicleWebhookCertExpiring"], "< 604800") {
+		t.Errorf("Certificate expiry threshold not applied: %s", exprs["KubechronicleWebhookCertExpiring"])
+	}
+	if !strings.HasSuffix(exprs["KubechronicleQueueSaturated"], "> 0.8") {
+		t.Errorf("Queue saturation threshold not applied: %s", exprs["KubechronicleQueueSaturated"])
+	}
+}
+
+func TestAlertManifest_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*AlertConfig)
+	}{
+		{"no namespace", func(c *AlertConfig) { c.Namespace = "" }},
+		{"no name", func(c *AlertConfig) { c.Name = "" }},
+		{"queue saturation above 1", func(c *AlertConfig) { c.QueueSaturation = 80 }},
+		{"no slow responses", func(c *AlertConfig) { c.SlowResponses = 0 }},
+		{"negative cert expiry", func(c *AlertConfig) { c.CertExpiry = -time.Hour }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultAlertConfig()
+			tt.modify(cfg)
+			if _, err := AlertManifest(cfg); err == nil {
+				t.Error("AlertManifest() should fail")
+			}
+		})
+	}
+}
+
+func TestAlertRules_MetricNames(t *testing.T) {
+	rules, err := alertRules(DefaultAlertConfig())
+	if err != nil {
+		t.Fatalf("alertRules() error = %v", err)
+	}
+	metric := regexp.MustCompile(`\b(webhook|store)_[a-z_]+`)
+	for _, r := range rules {
+		for _, name := range metric.FindAllString(r.Expr, -1) {
+			if expvar.Get(name) == nil {
+				t.Errorf("Rule %s references %s, which the webhook doesn't publish", r.Alert, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Config describes a kubechronicle installation to generate manifests for.
+type Config struct {
+	Namespace        string
+	ImageRegistry    string   // Prepended to image names, e.g. "registry.example.com/"
+	ImageTag         string   // Tag of the webhook, api and audit-processor images
+	ImagePullSecrets []string // Secrets used to pull the images
+	LogLevel         string
+
+	// DatabaseSecret is the Secret holding the PostgreSQL connection string under the "url" key.
+	DatabaseSecret string
+
+	// Webhook
+	WebhookReplicas  int32
+	WebhookTLSSecret string   // Secret with tls.crt and tls.key for the webhook server
+	CACert           []byte   // PEM CA of the webhook certificate, set as the webhook configuration's caBundle
+	FailurePolicy    string   // Ignore (observe-only, default) or Fail
+	PatternsName     string   // Patterns ConfigMap shared by the API and webhook
+	PatternsOverlays []string // Overlay pattern ConfigMaps watched by the webhook
+
+	// RequirePersistence makes the webhook fail to start, and report unready, without the database.
+	RequirePersistence bool
+
+	// API
+	APIReplicas           int32
+	AuthEnabled           bool // Reads JWT_SECRET and AUTH_USERS from the AuthSecret Secret
+	AuthSecret            string
+	PatternsAuthorization string // "kubernetes" authorizes pattern endpoints with SubjectAccessReviews
+
+	// Audit processor, receiving audit events from the API server's audit webhook backend
+	AuditProcessor bool
+}
+
+// DefaultConfig returns the configuration of the manifests in deploy/.
+func DefaultConfig() *Config {
+	return &Config{
+		Namespace:        "kubechronicle",
+		ImageRegistry:    "",
+		ImageTag:         "latest",
+		LogLevel:         "info",
+		DatabaseSecret:   "kubechronicle-database",
+		WebhookReplicas:  2,
+		WebhookTLSSecret: "kubechronicle-webhook-tls",
+		FailurePolicy:    "Ignore",
+		PatternsName:     "kubechronicle-patterns",
+		APIReplicas:      2,
+		AuthSecret:       "kubechronicle-auth",
+	}
+}
+
+// validate checks the fields the manifests can't be generated without.
+func (c *Config) validate() error {
+	if c.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if c.DatabaseSecret == "" {
+		return fmt.Errorf("database secret is required")
+	}
+	if c.PatternsName == "" {
+		return fmt.Errorf("patterns ConfigMap name is required")
+	}
+	if c.FailurePolicy != "Ignore" && c.FailurePolicy != "Fail" {
+		return fmt.Errorf("unsupported failure policy %q (supported: Ignore, Fail)", c.FailurePolicy)
+	}
+	if c.PatternsAuthorization != "" && c.PatternsAuthorization != "kubernetes" {
+		return fmt.Errorf("unsupported patterns authorization %q (supported: kubernetes)", c.PatternsAuthorization)
+	}
+	if c.PatternsAuthorization == "kubernetes" && !c.AuthEnabled {
+		return fmt.Errorf("kubernetes patterns authorization requires authentication to be enabled")
+	}
+	return nil
+}
+
+// Objects returns the Kubernetes objects of the installation, in the order they should be applied:
+// namespace, ConfigMaps, then each component's RBAC, workloads and services, and the webhook
+// configuration last so the API server only calls the webhook once it can be deployed.
+func Objects(cfg *Config) ([]runtime.Object, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	objects := []runtime.Object{namespace(cfg), patternsConfigMap(cfg)}
+	objects = append(objects, apiObjects(cfg)...)
+	if cfg.AuditProcessor {
+		objects = append(objects, auditProcessorObjects(cfg)...)
+	}
+	objects = append(objects, webhookObjects(cfg)...)
+	return objects, nil
+}
+
+// Manifest returns the installation as a multi-document YAML manifest.
+func Manifest(cfg *Config) ([]byte, error) {
+	objects, err := Objects(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by kubechronicle install manifest. Do not edit.\n")
+	for _, obj := range objects {
+		content, err := marshalObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(content)
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalObject converts an object to YAML, leaving out the empty status and
+// creation timestamp that typed objects always carry.
+func marshalObject(obj runtime.Object) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T: %w", obj, err)
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	removeCreationTimestamps(content)
+	return yaml.Marshal(content)
+}
+
+// removeCreationTimestamps removes the empty creation timestamp of pod templates.
+func removeCreationTimestamps(content map[string]interface{}) {
+	spec, ok := content["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	template, ok := spec["template"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+}
@@ -0,0 +1,258 @@
+package install
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// findObject returns the first object of type T named name.
+func findObject[T runtime.Object](objects []runtime.Object, name string) (T, bool) {
+	for _, obj := range objects {
+		if typed, ok := obj.(T); ok {
+			if accessor, ok := any(typed).(interface{ GetName() string }); ok && accessor.GetName() == name {
+				return typed, true
+			}
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// envValue returns the value of an environment variable of container, and whether it is set.
+func envValue(container corev1.Container, name string) (corev1.EnvVar, bool) {
+	for _, e := range container.Env {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return corev1.EnvVar{}, false
+}
+
+func TestObjects(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(*Config)
+		expectAudit    bool
+		expectAccessCR bool
+	}{
+		{"defaults", func(*Config) {}, false, false},
+		{"audit processor", func(c *Config) { c.AuditProcessor = true }, true, false},
+		{"kubernetes patterns authorization", func(c *Config) {
+			c.AuthEnabled = true
+			c.PatternsAuthorization = "kubernetes"
+		}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			objects, err := Objects(cfg)
+			if err != nil {
+				t.Fatalf("Objects() error = %v", err)
+			}
+
+			if _, ok := findObject[*appsv1.Deployment](objects, "kubechronicle-audit-processor"); ok != tt.expectAudit {
+				t.Errorf("audit processor deployment present = %v, want %v", ok, tt.expectAudit)
+			}
+			hasClusterRole := false
+			for _, obj := range objects {
+				if _, ok := obj.(*rbacv1.ClusterRole); ok {
+					if cr := obj.(*rbacv1.ClusterRole); strings.HasPrefix(cr.Name, "kubechronicle-api") {
+						hasClusterRole = true
+					}
+				}
+			}
+			if hasClusterRole != tt.expectAccessCR {
+				t.Errorf("API ClusterRole present = %v, want %v", hasClusterRole, tt.expectAccessCR)
+			}
+
+			if _, ok := objects[len(objects)-1].(*admissionregistrationv1.ValidatingWebhookConfiguration); !ok {
+				t.Errorf("last object = %T, want the ValidatingWebhookConfiguration", objects[len(objects)-1])
+			}
+		})
+	}
+}
+
+func TestObjects_ServicesTargetContainerPorts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AuditProcessor = true
+	objects, err := Objects(cfg)
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+
+	for _, name := range []string{"kubechronicle-webhook", "kubechronicle-api", "kubechronicle-audit-processor"} {
+		svc, ok := findObject[*corev1.Service](objects, name)
+		if !ok {
+			t.Errorf("service %s not found", name)
+			continue
+		}
+		deploy, ok := findObject[*appsv1.Deployment](objects, name)
+		if !ok {
+			t.Errorf("deployment %s not found", name)
+			continue
+		}
+		containerPort := deploy.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort
+		if got := svc.Spec.Ports[0].TargetPort.IntVal; got != containerPort {
+			t.Errorf("service %s targetPort = %d, want container port %d", name, got, containerPort)
+		}
+		for key, value := range svc.Spec.Selector {
+			if deploy.Spec.Template.Labels[key] != value {
+				t.Errorf("service %s selector %s=%s does not match the pod labels", name, key, value)
+			}
+		}
+	}
+}
+
+func TestObjects_WebhookSettings(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PatternsOverlays = []string{"team-a-patterns"}
+	cfg.CACert = []byte("-----BEGIN CERTIFICATE-----\n")
+	cfg.FailurePolicy = "Fail"
+	cfg.ImageRegistry = "registry.example.com/"
+	cfg.ImageTag = "v1.2.3"
+	cfg.RequirePersistence = true
+	objects, err := Objects(cfg)
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+
+	deploy, ok := findObject[*appsv1.Deployment](objects, "kubechronicle-webhook")
+	if !ok {
+		t.Fatal("webhook deployment not found")
+	}
+	container := deploy.Spec.Template.Spec.Containers[0]
+	if container.Image != "registry.example.com/kubechronicle/webhook:v1.2.3" {
+		t.Errorf("image = %q", container.Image)
+	}
+	if e, ok := envValue(container, "PATTERNS_CONFIGMAP_OVERLAYS"); !ok || e.Value != "team-a-patterns" {
+		t.Errorf("PATTERNS_CONFIGMAP_OVERLAYS = %+v, want team-a-patterns", e)
+	}
+	if e, ok := envValue(container, "REQUIRE_PERSISTENCE"); !ok || e.Value != "true" {
+		t.Errorf("REQUIRE_PERSISTENCE = %+v, want true", e)
+	}
+	if e, ok := envValue(container, "SPILL_DIR"); !ok || e.Value != "/var/spool/kubechronicle" {
+		t.Errorf("SPILL_DIR = %+v, want the spool volume", e)
+	}
+	if path := container.ReadinessProbe.HTTPGet.Path; path != "/ready" {
+		t.Errorf("readiness probe path = %s, want /ready", path)
+	}
+
+	foundOverlay := false
+	for _, obj := range objects {
+		if r, ok := obj.(*rbacv1.Role); ok && strings.HasPrefix(r.Name, "kubechronicle-webhook") {
+			for _, rule := range r.Rules {
+				for _, name := range rule.ResourceNames {
+					if name == "team-a-patterns" {
+						foundOverlay = true
+					}
+				}
+			}
+		}
+	}
+	if !foundOverlay {
+		t.Error("webhook Role should grant access to the overlay ConfigMap")
+	}
+
+	webhookConfig := objects[len(objects)-1].(*admissionregistrationv1.ValidatingWebhookConfiguration)
+	webhook := webhookConfig.Webhooks[0]
+	if *webhook.FailurePolicy != admissionregistrationv1.Fail {
+		t.Errorf("failurePolicy = %s, want Fail", *webhook.FailurePolicy)
+	}
+	if !bytes.Equal(webhook.ClientConfig.CABundle, cfg.CACert) {
+		t.Error("caBundle should be the configured CA certificate")
+	}
+	if webhook.ClientConfig.Service.Namespace != cfg.Namespace {
+		t.Errorf("service namespace = %s, want %s", webhook.ClientConfig.Service.Namespace, cfg.Namespace)
+	}
+}
+
+func TestObjects_AuthEnv(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AuthEnabled = true
+	objects, err := Objects(cfg)
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+
+	deploy, ok := findObject[*appsv1.Deployment](objects, "kubechronicle-api")
+	if !ok {
+		t.Fatal("api deployment not found")
+	}
+	container := deploy.Spec.Template.Spec.Containers[0]
+	for _, name := range []string{"JWT_SECRET", "AUTH_USERS"} {
+		e, ok := envValue(container, name)
+		if !ok || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef == nil || e.ValueFrom.SecretKeyRef.Name != cfg.AuthSecret {
+			t.Errorf("%s should be read from the %s Secret, got %+v", name, cfg.AuthSecret, e)
+		}
+	}
+}
+
+func TestObjects_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"missing namespace", func(c *Config) { c.Namespace = "" }},
+		{"missing database secret", func(c *Config) { c.DatabaseSecret = "" }},
+		{"missing patterns name", func(c *Config) { c.PatternsName = "" }},
+		{"unknown failure policy", func(c *Config) { c.FailurePolicy = "Retry" }},
+		{"unknown patterns authorization", func(c *Config) {
+			c.AuthEnabled = true
+			c.PatternsAuthorization = "rbac"
+		}},
+		{"kubernetes authorization without auth", func(c *Config) { c.PatternsAuthorization = "kubernetes" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if _, err := Objects(cfg); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestManifest(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AuditProcessor = true
+	manifest, err := Manifest(cfg)
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	objects, err := Objects(cfg)
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+
+	documents := strings.Split(string(manifest), "---\n")[1:]
+	if len(documents) != len(objects) {
+		t.Fatalf("manifest has %d documents, want %d", len(documents), len(objects))
+	}
+	for i, document := range documents {
+		var content map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &content); err != nil {
+			t.Fatalf("document %d is not valid YAML: %v", i, err)
+		}
+		if content["apiVersion"] == nil || content["kind"] == nil {
+			t.Errorf("document %d is missing apiVersion or kind", i)
+		}
+		if _, ok := content["status"]; ok {
+			t.Errorf("document %d (%v) should not have a status", i, content["kind"])
+		}
+	}
+	if strings.Contains(string(manifest), "creationTimestamp") {
+		t.Error("manifest should not contain creationTimestamp")
+	}
+}
@@ -0,0 +1,413 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Ports the components listen on by default (see each command's -port flag).
+const (
+	webhookPort        = 8443
+	apiPort            = 8080
+	auditProcessorPort = 8444
+)
+
+// trackedResources are the resources the webhook configuration sends admission requests for.
+var trackedResources = []struct {
+	group    string
+	resource string
+}{
+	{"apps", "deployments"},
+	{"apps", "statefulsets"},
+	{"apps", "daemonsets"},
+	{"", "services"},
+	{"", "configmaps"},
+	{"", "secrets"},
+	{"networking.k8s.io", "ingresses"},
+	{"apiextensions.k8s.io", "customresourcedefinitions"},
+}
+
+// labels returns the labels of a component's objects.
+func labels(component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "kubechronicle",
+		"app.kubernetes.io/component": component,
+	}
+}
+
+// objectMeta returns the metadata of a component's object; namespace is empty for cluster-scoped objects.
+func objectMeta(name, namespace, component string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels(component)}
+}
+
+// image returns the image reference of a component.
+func (c *Config) image(name string) string {
+	return fmt.Sprintf("%skubechronicle/%s:%s", c.ImageRegistry, name, c.ImageTag)
+}
+
+// namespace returns the namespace all namespaced objects are installed in.
+func namespace(cfg *Config) *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cfg.Namespace,
+			Labels: map[string]string{"name": cfg.Namespace, "app.kubernetes.io/name": "kubechronicle"},
+		},
+	}
+}
+
+// patternsConfigMap returns the patterns ConfigMap with empty ignore, block and warn configs.
+func patternsConfigMap(cfg *Config) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: objectMeta(cfg.PatternsName, cfg.Namespace, "patterns"),
+		Data: map[string]string{
+			"IGNORE_CONFIG": `{"namespace_patterns": [], "name_patterns": [], "resource_kind_patterns": []}`,
+			"BLOCK_CONFIG":  `{"namespace_patterns": [], "name_patterns": [], "resource_kind_patterns": [], "operation_patterns": [], "message": "Resource blocked by kubechronicle policy"}`,
+			"WARN_CONFIG":   `{"rules": []}`,
+		},
+	}
+}
+
+// serviceAccount returns the ServiceAccount a component runs as.
+func serviceAccount(name string, cfg *Config, component string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: objectMeta(name, cfg.Namespace, component),
+	}
+}
+
+// role returns a Role with the given rules and a RoleBinding granting it to the component's ServiceAccount.
+func role(name string, cfg *Config, component, serviceAccount string, rules []rbacv1.PolicyRule) []runtime.Object {
+	return []runtime.Object{
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+			ObjectMeta: objectMeta(name, cfg.Namespace, component),
+			Rules:      rules,
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+			ObjectMeta: objectMeta(name, cfg.Namespace, component),
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: serviceAccount, Namespace: cfg.Namespace}},
+		},
+	}
+}
+
+// clusterRole returns a ClusterRole with the given rules and a ClusterRoleBinding granting it
+// to the component's ServiceAccount.
+func clusterRole(name string, cfg *Config, component, serviceAccount string, rules []rbacv1.PolicyRule) []runtime.Object {
+	return []runtime.Object{
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+			ObjectMeta: objectMeta(name, "", component),
+			Rules:      rules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+			ObjectMeta: objectMeta(name, "", component),
+			RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: serviceAccount, Namespace: cfg.Namespace}},
+		},
+	}
+}
+
+// service returns a ClusterIP Service exposing a component's port.
+func service(name string, cfg *Config, component, portName string, port, targetPort int32) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: objectMeta(name, cfg.Namespace, component),
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels(component),
+			Ports: []corev1.ServicePort{{
+				Name:       portName,
+				Port:       port,
+				TargetPort: intstr.FromInt32(targetPort),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+// deployment returns a Deployment running a single container as a non-root user.
+func deployment(name string, cfg *Config, component, serviceAccount string, replicas int32, container corev1.Container, volumes []corev1.Volume) *appsv1.Deployment {
+	container.ImagePullPolicy = corev1.PullIfNotPresent
+	container.Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	}
+	runAsNonRoot, user := true, int64(65534)
+
+	var pullSecrets []corev1.LocalObjectReference
+	for _, secret := range cfg.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: objectMeta(name, cfg.Namespace, component),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels(component)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels(component)},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					ImagePullSecrets:   pullSecrets,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot:   &runAsNonRoot,
+						RunAsUser:      &user,
+						FSGroup:        &user,
+						SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+					},
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+}
+
+// healthProbe returns a probe of a health endpoint of the component, /health or /ready.
+func healthProbe(path string, port int32, scheme corev1.URIScheme, initialDelay, period, timeout int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromInt32(port), Scheme: scheme},
+		},
+		InitialDelaySeconds: initialDelay,
+		PeriodSeconds:       period,
+		TimeoutSeconds:      timeout,
+		FailureThreshold:    3,
+	}
+}
+
+// env returns a plain environment variable.
+func env(name, value string) corev1.EnvVar {
+	return corev1.EnvVar{Name: name, Value: value}
+}
+
+// secretEnv returns an environment variable read from a Secret key.
+func secretEnv(name, secret, key string, optional bool) corev1.EnvVar {
+	ref := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: key}
+	if optional {
+		ref.Optional = &optional
+	}
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref}}
+}
+
+// configMapEnv returns an optional environment variable read from a ConfigMap key.
+func configMapEnv(name, configMap, key string) corev1.EnvVar {
+	optional := true
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+		Key:                  key,
+		Optional:             &optional,
+	}}}
+}
+
+// webhookObjects returns the webhook's ServiceAccount, RBAC, Deployment, Service and
+// ValidatingWebhookConfiguration.
+func webhookObjects(cfg *Config) []runtime.Object {
+	const name = "kubechronicle-webhook"
+
+	envVars := []corev1.EnvVar{
+		secretEnv("DATABASE_URL", cfg.DatabaseSecret, "url", true),
+		env("TLS_CERT_PATH", "/etc/webhook/certs/tls.crt"),
+		env("TLS_KEY_PATH", "/etc/webhook/certs/tls.key"),
+		env("TLS_MIN_VERSION", "1.2"),
+		env("LOG_LEVEL", cfg.LogLevel),
+		configMapEnv("IGNORE_CONFIG", cfg.PatternsName, "IGNORE_CONFIG"),
+		configMapEnv("BLOCK_CONFIG", cfg.PatternsName, "BLOCK_CONFIG"),
+		configMapEnv("WARN_CONFIG", cfg.PatternsName, "WARN_CONFIG"),
+		env("PATTERNS_CONFIGMAP_PATH", "/etc/patterns"),
+		env("NAMESPACE", cfg.Namespace),
+		env("PATTERNS_CONFIGMAP_NAME", cfg.PatternsName),
+		env("SPILL_DIR", "/var/spool/kubechronicle"),
+		env("WEBHOOK_CONFIGURATION_NAME", name),
+	}
+	if len(cfg.PatternsOverlays) > 0 {
+		envVars = append(envVars, env("PATTERNS_CONFIGMAP_OVERLAYS", strings.Join(cfg.PatternsOverlays, ",")))
+	}
+	if cfg.RequirePersistence {
+		envVars = append(envVars, env("REQUIRE_PERSISTENCE", "true"))
+	}
+
+	container := corev1.Container{
+		Name:  "webhook",
+		Image: cfg.image("webhook"),
+		Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: webhookPort, Protocol: corev1.ProtocolTCP}},
+		Env:   envVars,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "webhook-certs", MountPath: "/etc/webhook/certs", ReadOnly: true},
+			{Name: "patterns-config", MountPath: "/etc/patterns", ReadOnly: true},
+			{Name: "spool", MountPath: "/var/spool/kubechronicle"},
+		},
+		LivenessProbe:  healthProbe("/health", webhookPort, corev1.URISchemeHTTPS, 10, 10, 5),
+		ReadinessProbe: healthProbe("/ready", webhookPort, corev1.URISchemeHTTPS, 5, 5, 3),
+	}
+	volumes := []corev1.Volume{
+		{Name: "webhook-certs", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: cfg.WebhookTLSSecret}}},
+		{Name: "patterns-config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: cfg.PatternsName},
+		}}},
+		// Events spooled while the database is unavailable survive container restarts
+		{Name: "spool", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+
+	objects := []runtime.Object{serviceAccount(name, cfg, "webhook")}
+	// Read its own ValidatingWebhookConfiguration, to alert when it is deleted or narrowed
+	objects = append(objects, clusterRole(name, cfg, "webhook", name, []rbacv1.PolicyRule{{
+		APIGroups:     []string{"admissionregistration.k8s.io"},
+		Resources:     []string{"validatingwebhookconfigurations"},
+		ResourceNames: []string{name},
+		Verbs:         []string{"get", "list", "watch"},
+	}})...)
+	objects = append(objects, role(name+"-patterns", cfg, "webhook", name, []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps"},
+		ResourceNames: append([]string{cfg.PatternsName}, cfg.PatternsOverlays...),
+		Verbs:         []string{"get", "list", "watch"},
+	}})...)
+	objects = append(objects,
+		deployment(name, cfg, "webhook", name, cfg.WebhookReplicas, container, volumes),
+		service(name, cfg, "webhook", "https", 443, webhookPort),
+		validatingWebhookConfiguration(name, cfg),
+	)
+	return objects
+}
+
+// validatingWebhookConfiguration sends admission requests for the tracked resources to the webhook.
+func validatingWebhookConfiguration(name string, cfg *Config) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	path := "/validate"
+	failurePolicy := admissionregistrationv1.FailurePolicyType(cfg.FailurePolicy)
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+
+	var rules []admissionregistrationv1.RuleWithOperations
+	for _, tracked := range trackedResources {
+		rules = append(rules, admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{
+				admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete,
+			},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{tracked.group},
+				APIVersions: []string{"v1"},
+				Resources:   []string{tracked.resource},
+			},
+		})
+	}
+
+	clientConfig := admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{Name: name, Namespace: cfg.Namespace, Path: &path},
+	}
+	if len(cfg.CACert) > 0 {
+		clientConfig.CABundle = cfg.CACert
+	}
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1", Kind: "ValidatingWebhookConfiguration"},
+		ObjectMeta: objectMeta(name, "", "webhook"),
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:                    "kubechronicle.k8s.io",
+			ClientConfig:            clientConfig,
+			Rules:                   rules,
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			AdmissionReviewVersions: []string{"v1"},
+		}},
+	}
+}
+
+// apiObjects returns the API server's ServiceAccount, RBAC, Deployment and Service.
+func apiObjects(cfg *Config) []runtime.Object {
+	const name = "kubechronicle-api"
+
+	envVars := []corev1.EnvVar{
+		secretEnv("DATABASE_URL", cfg.DatabaseSecret, "url", false),
+		env("LOG_LEVEL", cfg.LogLevel),
+		env("NAMESPACE", cfg.Namespace),
+		env("PATTERNS_CONFIGMAP_NAME", cfg.PatternsName),
+	}
+	if cfg.AuthEnabled {
+		envVars = append(envVars,
+			env("AUTH_ENABLED", "true"),
+			secretEnv("JWT_SECRET", cfg.AuthSecret, "jwt-secret", false),
+			env("JWT_EXPIRATION_HOURS", "24"),
+			secretEnv("AUTH_USERS", cfg.AuthSecret, "users", false),
+		)
+	}
+	if cfg.PatternsAuthorization != "" {
+		envVars = append(envVars, env("PATTERNS_AUTHORIZATION", cfg.PatternsAuthorization))
+	}
+
+	container := corev1.Container{
+		Name:           "api",
+		Image:          cfg.image("api"),
+		Ports:          []corev1.ContainerPort{{Name: "http", ContainerPort: apiPort, Protocol: corev1.ProtocolTCP}},
+		Env:            envVars,
+		LivenessProbe:  healthProbe("/health", apiPort, corev1.URISchemeHTTP, 10, 10, 5),
+		ReadinessProbe: healthProbe("/health", apiPort, corev1.URISchemeHTTP, 5, 5, 3),
+	}
+
+	objects := []runtime.Object{serviceAccount(name, cfg, "api")}
+	objects = append(objects, role(name+"-patterns", cfg, "api", name, []rbacv1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps"},
+		ResourceNames: []string{cfg.PatternsName},
+		Verbs:         []string{"get", "list", "create", "update", "patch"},
+	}})...)
+	if cfg.PatternsAuthorization == "kubernetes" {
+		objects = append(objects, clusterRole(name+"-access-review", cfg, "api", name, []rbacv1.PolicyRule{{
+			APIGroups: []string{"authorization.k8s.io"},
+			Resources: []string{"subjectaccessreviews"},
+			Verbs:     []string{"create"},
+		}})...)
+	}
+	objects = append(objects,
+		deployment(name, cfg, "api", name, cfg.APIReplicas, container, nil),
+		service(name, cfg, "api", "http", 80, apiPort),
+	)
+	return objects
+}
+
+// auditProcessorObjects returns the audit processor's ServiceAccount, Deployment and Service.
+// It receives audit events on /audit; the API server's audit webhook backend must point to the Service.
+func auditProcessorObjects(cfg *Config) []runtime.Object {
+	const name = "kubechronicle-audit-processor"
+
+	container := corev1.Container{
+		Name:  "audit-processor",
+		Image: cfg.image("audit-processor"),
+		Args: []string{
+			"-enable-webhook",
+			fmt.Sprintf("-webhook-port=%d", auditProcessorPort),
+		},
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: auditProcessorPort, Protocol: corev1.ProtocolTCP}},
+		Env: []corev1.EnvVar{
+			secretEnv("DATABASE_URL", cfg.DatabaseSecret, "url", false),
+		},
+		LivenessProbe:  healthProbe("/health", auditProcessorPort, corev1.URISchemeHTTP, 10, 10, 5),
+		ReadinessProbe: healthProbe("/health", auditProcessorPort, corev1.URISchemeHTTP, 5, 5, 3),
+	}
+
+	return []runtime.Object{
+		serviceAccount(name, cfg, "audit-processor"),
+		deployment(name, cfg, "audit-processor", name, 1, container, nil),
+		service(name, cfg, "audit-processor", "http", auditProcessorPort, auditProcessorPort),
+	}
+}
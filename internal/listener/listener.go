@@ -0,0 +1,89 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixPrefix marks a Unix domain socket address, e.g. unix:///var/run/kubechronicle/api.sock.
+const unixPrefix = "unix://"
+
+// socketMode is the permission of created Unix sockets: the owner and group (e.g. a sidecar
+// proxy sharing the pod's fsGroup) can connect.
+const socketMode = 0o660
+
+// Addresses splits a comma-separated list of listen addresses. An empty list listens on
+// port on all interfaces, which is dual-stack (IPv4 and IPv6) where the host supports it.
+func Addresses(list string, port int) []string {
+	var addresses []string
+	for _, address := range strings.Split(list, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		addresses = []string{fmt.Sprintf(":%d", port)}
+	}
+	return addresses
+}
+
+// Listen opens a listener for each address. An address is either host:port (e.g. ":8443",
+// "127.0.0.1:8080", "[::1]:8080") or unix:// followed by a socket path. A socket file left
+// behind by a previous run is replaced. If any address fails, the listeners opened so far
+// are closed.
+func Listen(addresses []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, address := range addresses {
+		l, err := listen(address)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listen opens a single TCP or Unix socket listener.
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("missing socket path")
+	}
+
+	// Only remove stale sockets, never a regular file at the configured path
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// String returns the addresses of listeners for logging, e.g. "[::]:8080, /run/api.sock".
+func String(listeners []net.Listener) string {
+	addresses := make([]string, len(listeners))
+	for i, l := range listeners {
+		addresses[i] = l.Addr().String()
+	}
+	return strings.Join(addresses, ", ")
+}
@@ -0,0 +1,108 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		list     string
+		port     int
+		expected []string
+	}{
+		{"empty uses port", "", 8080, []string{":8080"}},
+		{"single address", "127.0.0.1:9090", 8080, []string{"127.0.0.1:9090"}},
+		{"dual-stack and socket", " 0.0.0.0:8443, [::1]:8443 ,unix:///run/kubechronicle/webhook.sock", 8443,
+			[]string{"0.0.0.0:8443", "[::1]:8443", "unix:///run/kubechronicle/webhook.sock"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Addresses(tt.list, tt.port); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Addresses(%q, %d) = %v, want %v", tt.list, tt.port, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListen(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+
+	listeners, err := Listen([]string{"127.0.0.1:0", "unix://" + socketPath})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if len(listeners) != 2 {
+		t.Fatalf("Listen() returned %d listeners, want 2", len(listeners))
+	}
+	for _, l := range listeners {
+		conn, err := net.Dial(l.Addr().Network(), l.Addr().String())
+		if err != nil {
+			t.Errorf("Failed to connect to %s: %v", l.Addr(), err)
+			continue
+		}
+		conn.Close()
+	}
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("Socket not created: %v", err)
+	}
+	if info.Mode().Perm() != socketMode {
+		t.Errorf("Socket mode = %o, want %o", info.Mode().Perm(), socketMode)
+	}
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+
+	// A socket file left behind by a previous run, e.g. after a crash
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listeners, err := Listen([]string{"unix://" + socketPath})
+	if err != nil {
+		t.Fatalf("Listen() should replace a stale socket, got error: %v", err)
+	}
+	listeners[0].Close()
+}
+
+func TestListen_Errors(t *testing.T) {
+	regularFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(regularFile, []byte("keep me"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		addresses []string
+	}{
+		{"regular file is not replaced", []string{"unix://" + regularFile}},
+		{"missing socket path", []string{"unix://"}},
+		{"invalid address", []string{"127.0.0.1:0", "not-an-address"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Listen(tt.addresses); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	if content, err := os.ReadFile(regularFile); err != nil || string(content) != "keep me" {
+		t.Error("Regular file at the socket path should be left untouched")
+	}
+}
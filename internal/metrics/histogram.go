@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of histograms of request and query
+// durations.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram per value of a label, e.g. the durations of each kind of
+// query. It is published with expvar as JSON, and rendered by Render in the Prometheus text
+// format as a histogram with the _bucket, _sum and _count series.
+type HistogramVec struct {
+	label   string
+	buckets []float64 // Upper bounds, ascending; +Inf is implicit
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+// histogram counts the observations of one label value.
+type histogram struct {
+	counts []uint64 // Observations per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec creates a histogram vector with the given label and bucket upper bounds,
+// and publishes it with expvar under name. Like expvar.NewInt, it panics if name is taken.
+func NewHistogramVec(name, label string, buckets []float64) *HistogramVec {
+	h := &HistogramVec{label: label, buckets: buckets, series: map[string]*histogram{}}
+	expvar.Publish(name, h)
+	return h
+}
+
+// Observe adds an observation to the histogram of a label value.
+func (h *HistogramVec) Observe(labelValue string, value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series, ok := h.series[labelValue]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets)+1)}
+		h.series[labelValue] = series
+	}
+	series.counts[sort.SearchFloat64s(h.buckets, value)]++
+	series.sum += value
+	series.count++
+}
+
+// String returns the histograms as JSON, keyed by label value, for expvar.
+func (h *HistogramVec) String() string {
+	type jsonHistogram struct {
+		Buckets map[string]uint64 `json:"buckets"` // Cumulative, by upper bound
+		Sum     float64           `json:"sum"`
+		Count   uint64            `json:"count"`
+	}
+	out := map[string]jsonHistogram{}
+	h.each(func(labelValue string, bounds []string, cumulative []uint64, sum float64, count uint64) {
+		buckets := make(map[string]uint64, len(bounds))
+		for i, bound := range bounds {
+			buckets[bound] = cumulative[i]
+		}
+		out[labelValue] = jsonHistogram{Buckets: buckets, Sum: sum, Count: count}
+	})
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// render writes the histograms in the Prometheus text format, sorted by label value.
+func (h *HistogramVec) render(b *strings.Builder, name string) {
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	h.each(func(labelValue string, bounds []string, cumulative []uint64, sum float64, count uint64) {
+		label := fmt.Sprintf("%s=%q", h.label, labelValue)
+		for i, bound := range bounds {
+			fmt.Fprintf(b, "%s_bucket{%s,le=%q} %d\n", name, label, bound, cumulative[i])
+		}
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, label, formatFloat(sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, label, count)
+	})
+}
+
+// each calls fn with the bucket upper bounds and cumulative counts of each label value, sorted.
+func (h *HistogramVec) each(fn func(labelValue string, bounds []string, cumulative []uint64, sum float64, count uint64)) {
+	bounds := make([]string, 0, len(h.buckets)+1)
+	for _, bound := range h.buckets {
+		bounds = append(bounds, formatFloat(bound))
+	}
+	bounds = append(bounds, "+Inf")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	labelValues := make([]string, 0, len(h.series))
+	for labelValue := range h.series {
+		labelValues = append(labelValues, labelValue)
+	}
+	sort.Strings(labelValues)
+	for _, labelValue := range labelValues {
+		series := h.series[labelValue]
+		cumulative := make([]uint64, len(series.counts))
+		var total uint64
+		for i, n := range series.counts {
+			total += n
+			cumulative[i] = total
+		}
+		fn(labelValue, bounds, cumulative, series.sum, series.count)
+	}
+}
+
+// formatFloat formats a sample value or bucket bound.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
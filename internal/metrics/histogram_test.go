@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var testHistogram = NewHistogramVec("metrics_test_duration_seconds", "query", []float64{0.1, 1})
+
+func TestHistogramVec(t *testing.T) {
+	testHistogram.Observe("events", 0.05)
+	testHistogram.Observe("events", 0.1) // Bounds are inclusive
+	testHistogram.Observe("events", 0.5)
+	testHistogram.Observe("events", 30)
+	testHistogram.Observe("churn", 2)
+
+	rendered := Render()
+	want := `# TYPE metrics_test_duration_seconds histogram
+metrics_test_duration_seconds_bucket{query="churn",le="0.1"} 0
+metrics_test_duration_seconds_bucket{query="churn",le="1"} 0
+metrics_test_duration_seconds_bucket{query="churn",le="+Inf"} 1
+metrics_test_duration_seconds_sum{query="churn"} 2
+metrics_test_duration_seconds_count{query="churn"} 1
+metrics_test_duration_seconds_bucket{query="events",le="0.1"} 2
+metrics_test_duration_seconds_bucket{query="events",le="1"} 3
+metrics_test_duration_seconds_bucket{query="events",le="+Inf"} 4
+metrics_test_duration_seconds_sum{query="events"} 30.65
+metrics_test_duration_seconds_count{query="events"} 4
+`
+	if !strings.Contains(rendered, want) {
+		t.Errorf("Render() missing the histogram:\n%s", rendered)
+	}
+
+	var published map[string]struct {
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(testHistogram.String()), &published); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if events := published["events"]; events.Count != 4 || events.Buckets["1"] != 3 || events.Buckets["+Inf"] != 4 {
+		t.Errorf("String() = %s", testHistogram.String())
+	}
+}
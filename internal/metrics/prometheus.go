@@ -0,0 +1,61 @@
+// Package metrics serves the counters and gauges kubechronicle publishes with expvar in the
+// Prometheus text format, so they can be scraped without an exporter.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validName matches the metric names Prometheus accepts.
+var validName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Handler serves the numeric expvar variables at /metrics. Variables named *_total are
+// counters, the others gauges, and HistogramVecs histograms; other variables that aren't
+// numbers (e.g. memstats) are skipped.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(Render()))
+	})
+}
+
+// Render returns the numeric expvar variables, and the HistogramVecs, in the Prometheus text
+// format, sorted by name.
+func Render() string {
+	var names []string
+	values := make(map[string]string)
+	histograms := make(map[string]*HistogramVec)
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !validName.MatchString(kv.Key) {
+			return
+		}
+		switch v := kv.Value.(type) {
+		case *expvar.Int, *expvar.Float:
+			names = append(names, kv.Key)
+			values[kv.Key] = v.String()
+		case *HistogramVec:
+			names = append(names, kv.Key)
+			histograms[kv.Key] = v
+		}
+	})
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		if histogram, ok := histograms[name]; ok {
+			histogram.render(&b, name)
+			continue
+		}
+		metricType := "gauge"
+		if strings.HasSuffix(name, "_total") {
+			metricType = "counter"
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n%s %s\n", name, metricType, name, values[name])
+	}
+	return b.String()
+}
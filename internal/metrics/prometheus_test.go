@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testCounter = expvar.NewInt("metrics_test_events_total")
+	testGauge   = expvar.NewFloat("metrics_test_queue_length")
+	_           = expvar.NewString("metrics_test_version")
+)
+
+func TestRender(t *testing.T) {
+	testCounter.Set(3)
+	testGauge.Set(1.5)
+
+	rendered := Render()
+	for _, want := range []string{
+		"# TYPE metrics_test_events_total counter\nmetrics_test_events_total 3\n",
+		"# TYPE metrics_test_queue_length gauge\nmetrics_test_queue_length 1.5\n",
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Render() missing %q:\n%s", want, rendered)
+		}
+	}
+	// Non-numeric variables (and expvar's own cmdline and memstats) are skipped
+	for _, skipped := range []string{"metrics_test_version", "memstats", "cmdline"} {
+		if strings.Contains(rendered, skipped) {
+			t.Errorf("Render() should skip %s:\n%s", skipped, rendered)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "metrics_test_events_total") {
+		t.Errorf("Body missing metrics_test_events_total:\n%s", w.Body.String())
+	}
+}
@@ -2,31 +2,303 @@ package model
 
 import "time"
 
+// SchemaVersion is the version of the event model written by this release. It is
+// incremented when the meaning or shape of stored fields changes; the store upgrades events
+// read from rows written with older versions.
+const SchemaVersion = 2
+
 // ChangeEvent represents a single Kubernetes resource change or exec operation.
 type ChangeEvent struct {
 	ID          string    `json:"id"`
 	Timestamp   time.Time `json:"timestamp"`
-	Operation   string    `json:"operation"` // CREATE, UPDATE, DELETE, EXEC
+	Operation   string    `json:"operation"` // CREATE, UPDATE, DELETE, EXEC, NODE_MAINTENANCE, CREDENTIAL_ISSUANCE, DEPLOYMENT, CLOUD_CHANGE, SELF_MONITORING, HEARTBEAT, DELETE_COLLECTION, NAMESPACE_CASCADE
 	ResourceKind string   `json:"resource_kind"`
 	Namespace   string    `json:"namespace"`
 	Name        string    `json:"name"`
 	Actor       Actor     `json:"actor"`
 	Source      Source    `json:"source"`
 	Diff        []PatchOp `json:"diff,omitempty"`
-	ObjectSnapshot map[string]interface{} `json:"object_snapshot,omitempty"` // For DELETE only
+	ObjectSnapshot map[string]interface{} `json:"object_snapshot,omitempty"` // Object before a DELETE, or an UPDATE with SNAPSHOT_UPDATES
 	Allowed     bool      `json:"allowed"` // Whether the operation was allowed (true) or blocked (false)
 	BlockPattern string   `json:"block_pattern,omitempty"` // The pattern that blocked the request (if blocked)
 	ExecMetadata *ExecMetadata `json:"exec_metadata,omitempty"` // For EXEC operations only
+	Encrypted   bool      `json:"encrypted,omitempty"` // Diff and snapshot are encrypted at rest; omitted if the caller may not decrypt them
+	SizeExceeded bool     `json:"size_exceeded,omitempty"` // Object exceeded the size limit; diff and snapshot were not recorded
+	ObjectSize  int       `json:"object_size,omitempty"` // Size in bytes of the largest object, set when SizeExceeded
+	SampleRate  int       `json:"sample_rate,omitempty"` // Recorded 1 in SampleRate events; unset when every event is recorded
+	BlockRule   *BlockRule `json:"block_rule,omitempty"` // Structured block rule that blocked the request (if any)
+	NodeMaintenance *NodeMaintenance `json:"node_maintenance,omitempty"` // For NODE_MAINTENANCE operations only
+	CredentialIssuance *CredentialIssuance `json:"credential_issuance,omitempty"` // For CREDENTIAL_ISSUANCE operations only
+	Deployment  *CIDeployment `json:"deployment,omitempty"` // For DEPLOYMENT operations only
+	CloudChange *CloudChange `json:"cloud_change,omitempty"` // For CLOUD_CHANGE operations only
+	SelfMonitor *SelfMonitorFinding `json:"self_monitor,omitempty"` // For SELF_MONITORING operations only
+	Heartbeat   *Heartbeat `json:"heartbeat,omitempty"` // For HEARTBEAT operations only
+	DeleteCollection *DeleteCollection `json:"delete_collection,omitempty"` // For DELETE_COLLECTION operations only
+	NamespaceCascade *NamespaceCascade `json:"namespace_cascade,omitempty"` // For NAMESPACE_CASCADE operations only
+	VolumeDeletion *VolumeDeletion `json:"volume_deletion,omitempty"` // For DELETEs of PersistentVolumes and PersistentVolumeClaims only
+	CRDChange   *CRDChange `json:"crd_change,omitempty"` // For UPDATEs of CustomResourceDefinitions changing their versions or schemas only
+	ImageProvenance *ImageProvenance `json:"image_provenance,omitempty"` // For CREATEs and UPDATEs of workloads changing their images, when image provenance checks are enabled
+	ExposureChange *ExposureChange `json:"exposure_change,omitempty"` // For changes of Ingresses, NetworkPolicies and Services opening network exposure only
+	QuotaImpact *QuotaImpact `json:"quota_impact,omitempty"` // For CREATEs and UPDATEs of ResourceQuotas and LimitRanges impacting their namespace only
+	PodSecurityRegression *PodSecurityRegression `json:"pod_security_regression,omitempty"` // For UPDATEs of workloads weakening the security of their pods only
+	SchemaVersion int      `json:"schema_version,omitempty"` // Model version of the stored event; unset on events not read from the store
+	Enrichment  map[string]string `json:"enrichment,omitempty"` // Organizational context added by enrichers, such as the owning team or cost center
+	Fingerprint string     `json:"fingerprint,omitempty"` // Identifies the admission request, so the store records retries of it once, also after a restore
+}
+
+// OperationHeartbeat is the operation of the synthetic events producers emit periodically to
+// verify the pipeline. They are left out of queries, alerts and subscriptions unless asked for.
+const OperationHeartbeat = "HEARTBEAT"
+
+// Heartbeat identifies a heartbeat event in the sequence emitted by a producer, so lost
+// heartbeats show as gaps in the sequence, and the delay until they are stored as latency.
+type Heartbeat struct {
+	Producer string `json:"producer"` // "webhook", "audit-processor" or "ingest-api"
+	Cluster  string `json:"cluster"`
+	Instance string `json:"instance"` // Process that emitted the heartbeat; its sequence restarts with the process
+	Sequence int64  `json:"sequence"` // 1 for the first heartbeat of the instance
+	Interval string `json:"interval"` // Period the instance emits heartbeats at, e.g. "1m0s"
+}
+
+// OperationDeleteCollection is the operation of the events grouping a burst of DELETEs of
+// one kind in one namespace by the same actor, such as a delete collection request or a
+// namespace teardown. The DELETEs are recorded as well; the group is alerted in their place.
+const OperationDeleteCollection = "DELETE_COLLECTION"
+
+// DeleteCollection references the DELETE events grouped by a DELETE_COLLECTION event.
+type DeleteCollection struct {
+	Count          int       `json:"count"`               // DELETEs grouped
+	Children       []string  `json:"children"`            // IDs of the DELETE events, at most MaxDeleteCollectionChildren
+	Truncated      bool      `json:"truncated,omitempty"` // More DELETEs were grouped than Children holds
+	FirstDeletedAt time.Time `json:"first_deleted_at"`
+	LastDeletedAt  time.Time `json:"last_deleted_at"`
+}
+
+// MaxDeleteCollectionChildren is the number of DELETE events a DELETE_COLLECTION event
+// references at most.
+const MaxDeleteCollectionChildren = 1000
+
+// OperationNamespaceCascade is the operation of the events summarizing the objects deleted
+// with a namespace, recorded once the namespace's deletion cascade ends.
+const OperationNamespaceCascade = "NAMESPACE_CASCADE"
+
+// NamespaceCascade summarizes the objects deleted in a namespace after the namespace itself
+// was deleted.
+type NamespaceCascade struct {
+	DeletionID    string              `json:"deletion_id"`               // ID of the DELETE event of the namespace
+	Total         int                 `json:"total"`                     // Objects deleted in the cascade
+	Kinds         map[string]int      `json:"kinds"`                     // Objects deleted, by kind
+	Notable       map[string][]string `json:"notable,omitempty"`         // Names of the notable objects deleted, such as Secrets and PersistentVolumeClaims, by kind
+	Truncated     bool                `json:"truncated,omitempty"`       // More notable objects were deleted than Notable holds
+	DeletedAt     time.Time           `json:"deleted_at"`                // When the namespace was deleted
+	LastDeletedAt time.Time           `json:"last_deleted_at,omitempty"` // When the last object of the cascade was deleted
+}
+
+// MaxNamespaceCascadeNotable is the number of notable objects of each kind a
+// NAMESPACE_CASCADE event names at most.
+const MaxNamespaceCascadeNotable = 100
+
+// VolumeDeletion describes the storage affected by the DELETE of a PersistentVolume or
+// PersistentVolumeClaim, and whether the volume's data may be lost with it.
+type VolumeDeletion struct {
+	Volume        string `json:"volume,omitempty"` // Name of the PersistentVolume; empty for an unbound claim
+	Claim         string `json:"claim,omitempty"`  // namespace/name of the PersistentVolumeClaim bound to the volume
+	StorageClass  string `json:"storage_class,omitempty"`
+	Capacity      string `json:"capacity,omitempty"`       // e.g. "10Gi"
+	ReclaimPolicy string `json:"reclaim_policy,omitempty"` // Of the volume: "Retain", "Delete" or "Recycle"; empty if unknown
+	DataLossRisk  bool   `json:"data_loss_risk"`           // The volume's data may be deleted or scrubbed: its policy isn't Retain
+}
+
+// CRDChange summarizes the UPDATE of a CustomResourceDefinition: the versions added,
+// removed, served or no longer served, the storage version, and the schema fields changed in
+// the versions kept, which its JSON patch shows as whole replaced arrays.
+type CRDChange struct {
+	AddedVersions     []string         `json:"added_versions,omitempty"`
+	RemovedVersions   []string         `json:"removed_versions,omitempty"`
+	ServedVersions    []string         `json:"served_versions,omitempty"`     // Versions kept that are now served
+	UnservedVersions  []string         `json:"unserved_versions,omitempty"`   // Versions kept that are no longer served
+	OldStorageVersion string           `json:"old_storage_version,omitempty"` // Set when the storage version changed
+	NewStorageVersion string           `json:"new_storage_version,omitempty"`
+	Fields            []CRDFieldChange `json:"fields,omitempty"`    // At most MaxCRDFieldChanges
+	Truncated         bool             `json:"truncated,omitempty"` // More fields changed than Fields holds
+}
+
+// CRDFieldChange is the change of a field in the schema of a CustomResourceDefinition version.
+type CRDFieldChange struct {
+	Version string `json:"version"`
+	Op      string `json:"op"`   // "add", "remove", "type", "required" or "optional"
+	Path    string `json:"path"` // Dotted path of the field, with [] for array items, e.g. "spec.ports[].name"
+	OldType string `json:"old_type,omitempty"`
+	NewType string `json:"new_type,omitempty"`
+}
+
+// MaxCRDFieldChanges is the number of schema field changes a CRDChange lists at most.
+const MaxCRDFieldChanges = 500
+
+// ImageProvenance records the provenance checks of the images a CREATE or UPDATE of a
+// workload deploys: their digest, and whether a cosign signature is published for it.
+type ImageProvenance struct {
+	Images    []ImageCheck `json:"images"`
+	Violation bool         `json:"violation,omitempty"` // An unsigned image was deployed to a protected namespace
+}
+
+// ImageCheck is the provenance check of the image of a container.
+type ImageCheck struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`               // As referenced by the pod spec
+	OldImage  string `json:"old_image,omitempty"` // Before the change; empty for new containers
+	Digest    string `json:"digest,omitempty"`    // e.g. "sha256:..."; empty if the registry couldn't be reached
+	Status    string `json:"status"`              // ImageSigned, ImageUnsigned or ImageUnknown
+	Error     string `json:"error,omitempty"`     // Why the status is unknown
+}
+
+// Statuses of the provenance check of an image.
+const (
+	ImageSigned   = "signed"   // A cosign signature is published for the image's digest
+	ImageUnsigned = "unsigned" // The registry has no cosign signature for the image's digest
+	ImageUnknown  = "unknown"  // The registry couldn't be queried
+)
+
+// ExposureChange lists how a change of an Ingress, NetworkPolicy or Service opens the
+// cluster's network exposure, such as a new Ingress host, a relaxed NetworkPolicy or a
+// Service made reachable from outside the cluster.
+type ExposureChange struct {
+	Severity string            `json:"severity"` // Highest severity of the findings: "warning" or "critical"
+	Findings []ExposureFinding `json:"findings"`
+}
+
+// ExposureFinding is one way a change opens network exposure.
+type ExposureFinding struct {
+	Check    string `json:"check"`    // e.g. "ingress_host", "network_policy_allow_all", "service_type"
+	Severity string `json:"severity"` // "warning" or "critical"
+	Message  string `json:"message"`
+}
+
+// QuotaImpact summarizes what the CREATE or UPDATE of a ResourceQuota or LimitRange impacts
+// in its namespace: the resources whose new hard limit is below their current usage, or the
+// containers of running pods outside the new limits, which couldn't be created again as is.
+type QuotaImpact struct {
+	Summary    string                `json:"summary"`              // e.g. "requests.cpu over quota"
+	Resources  []QuotaResourceImpact `json:"resources,omitempty"`  // For ResourceQuotas
+	Containers []LimitRangeImpact    `json:"containers,omitempty"` // For LimitRanges; at most MaxLimitRangeImpacts
+	Truncated  bool                  `json:"truncated,omitempty"`  // More containers are impacted than Containers holds
+}
+
+// QuotaResourceImpact is a resource of a ResourceQuota whose new hard limit is below its usage.
+type QuotaResourceImpact struct {
+	Resource string `json:"resource"`           // e.g. "requests.cpu", "pods"
+	OldHard  string `json:"old_hard,omitempty"` // Empty if the resource wasn't limited
+	Hard     string `json:"hard"`
+	Used     string `json:"used"`
+}
+
+// LimitRangeImpact is a request or limit of a container outside the range of a LimitRange.
+type LimitRangeImpact struct {
+	Pod        string `json:"pod"`
+	Container  string `json:"container"`
+	Resource   string `json:"resource"`   // e.g. "cpu", "memory"
+	Constraint string `json:"constraint"` // "max" for limits above the maximum, "min" for requests below the minimum
+	Value      string `json:"value"`      // The container's limit or request
+	Bound      string `json:"bound"`      // The LimitRange's maximum or minimum
+}
+
+// MaxLimitRangeImpacts is the number of impacted containers a QuotaImpact lists at most.
+const MaxLimitRangeImpacts = 100
+
+// PodSecurityRegression lists how the UPDATE of a workload weakens the security of its pods,
+// such as a privileged container or a hostPath volume added, or a securityContext
+// restriction dropped.
+type PodSecurityRegression struct {
+	Findings []PodSecurityFinding `json:"findings"`
+}
+
+// PodSecurityFinding is one way a change weakens the security of a workload's pods.
+type PodSecurityFinding struct {
+	Check     string `json:"check"`               // e.g. "privileged", "host_path", "host_network", "run_as_non_root_removed"
+	Container string `json:"container,omitempty"` // Empty for pod-level findings
+	Message   string `json:"message"`
+}
+
+// SelfMonitorFinding describes a change to kubechronicle's own setup that may stop it from
+// recording changes, such as its webhook configuration being deleted or narrowed, or its TLS
+// certificate nearing expiry.
+type SelfMonitorFinding struct {
+	Check        string `json:"check"`                   // e.g. "webhook_deleted", "webhook_narrowed", "cert_expiry"
+	Severity     string `json:"severity"`                // "info", "warning" or "critical"
+	Message      string `json:"message"`
+	FieldManager string `json:"field_manager,omitempty"` // Field manager of the latest change to the object, if known
+}
+
+// CloudChange describes a change to the cluster made through its cloud provider's API,
+// such as a node pool being scaled or the control plane being upgraded.
+type CloudChange struct {
+	Provider   string                 `json:"provider"`             // "eks", "gke" or "aks"
+	Cluster    string                 `json:"cluster"`              // Name of the cluster at the provider
+	Action     string                 `json:"action"`               // e.g. "scale_node_pool", "upgrade_control_plane"
+	Method     string                 `json:"method"`               // Provider API method, e.g. "UpdateNodegroupConfig"
+	Parameters map[string]interface{} `json:"parameters,omitempty"` // Request parameters, if the provider reports them
+}
+
+// CIDeployment describes a deployment or pipeline run reported by a CI/CD system.
+// The event's namespace and name are the namespace and app it deployed.
+type CIDeployment struct {
+	Provider    string `json:"provider"`              // "github" or "gitlab"
+	Event       string `json:"event"`                 // Webhook event, e.g. "deployment_status" or "pipeline"
+	Status      string `json:"status,omitempty"`      // e.g. "success", "failure", "running"
+	Environment string `json:"environment,omitempty"` // CI environment, e.g. "production"
+	Repository  string `json:"repository,omitempty"`  // e.g. "acme/web"
+	Ref         string `json:"ref,omitempty"`
+	SHA         string `json:"sha,omitempty"`
+	URL         string `json:"url,omitempty"` // Link to the deployment or pipeline run
+}
+
+// CredentialIssuance describes a credential issued through the API server: a certificate
+// signing request being approved (or denied), or a service account token being requested.
+// The credential itself is never recorded.
+type CredentialIssuance struct {
+	Type string `json:"type"` // "certificate" or "token"
+
+	// Certificates
+	Decision   string   `json:"decision,omitempty"`    // "Approved" or "Denied"
+	SignerName string   `json:"signer_name,omitempty"` // e.g. "kubernetes.io/kube-apiserver-client"
+	Requester  string   `json:"requester,omitempty"`   // User who created the CSR
+	Subject    string   `json:"subject,omitempty"`     // Common name of the requested certificate, the user it authenticates as
+	Groups     []string `json:"groups,omitempty"`      // Organizations of the requested certificate, the groups it authenticates with
+	Usages     []string `json:"usages,omitempty"`
+
+	// Tokens
+	Audiences         []string `json:"audiences,omitempty"`
+	ExpirationSeconds int64    `json:"expiration_seconds,omitempty"`
+	BoundObject       string   `json:"bound_object,omitempty"` // "Kind/name" of the object the token is bound to, if any
+}
+
+// NodeMaintenance describes a node being cordoned, uncordoned or drained.
+type NodeMaintenance struct {
+	Action   string `json:"action"`              // "cordon", "uncordon" or "evict"
+	NodeName string `json:"node_name,omitempty"` // Target node; for evictions, the node the actor last cordoned, if recent
+}
+
+// BlockRule describes the structured block rule that blocked a request.
+type BlockRule struct {
+	Name      string     `json:"name,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	TicketURL string     `json:"ticket_url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // ExecMetadata contains information about exec operations.
 type ExecMetadata struct {
-	Command     []string `json:"command,omitempty"`     // Command executed (if available)
-	Container   string   `json:"container,omitempty"`   // Container name
-	Stdin       bool     `json:"stdin"`                 // Whether stdin was used
-	TTY         bool     `json:"tty"`                   // Whether TTY was allocated
-	TargetType  string   `json:"target_type"`          // "pod" or "node"
-	NodeName    string   `json:"node_name,omitempty"`   // Node name (for node exec)
+	Command      []string `json:"command,omitempty"`       // Command executed (if available)
+	Container    string   `json:"container,omitempty"`     // Container name
+	Stdin        bool     `json:"stdin"`                   // Whether stdin was used
+	TTY          bool     `json:"tty"`                     // Whether TTY was allocated
+	TargetType   string   `json:"target_type"`             // "pod" or "node"
+	NodeName     string   `json:"node_name,omitempty"`     // Node name (for node exec)
+	Subresource  string   `json:"subresource,omitempty"`   // "exec" or "attach"
+	RiskScore    int      `json:"risk_score,omitempty"`    // 0-100, from the risk rules matching Command
+	RiskSeverity string   `json:"risk_severity,omitempty"` // "low", "medium" or "high"; empty if no rule matched
+	RiskReasons  []string `json:"risk_reasons,omitempty"`  // Names of the matching risk rules
 }
 
 // Actor represents who made the change.
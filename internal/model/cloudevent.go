@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Output formats of event deliveries.
+const (
+	FormatJSON        = "json"        // The ChangeEvent JSON object (default)
+	FormatCloudEvents = "cloudevents" // A CloudEvents 1.0 structured-mode JSON envelope
+)
+
+// CloudEventsContentType is the content type of structured-mode CloudEvents.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventTypePrefix prefixes the operation in the type of change event CloudEvents,
+// e.g. "io.kubechronicle.change.delete".
+const CloudEventTypePrefix = "io.kubechronicle.change."
+
+// DefaultCloudEventSource is the source of change event CloudEvents when none is configured.
+const DefaultCloudEventSource = "kubechronicle"
+
+// CloudEvent is a change event in the CloudEvents 1.0 JSON format, with the event as data.
+type CloudEvent struct {
+	SpecVersion     string       `json:"specversion"`
+	ID              string       `json:"id"`
+	Source          string       `json:"source"`
+	Type            string       `json:"type"`
+	Subject         string       `json:"subject,omitempty"`
+	Time            string       `json:"time,omitempty"`
+	DataContentType string       `json:"datacontenttype"`
+	Data            *ChangeEvent `json:"data"`
+}
+
+// ValidFormat reports whether format is an output format; empty means FormatJSON.
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatJSON || format == FormatCloudEvents
+}
+
+// NewCloudEvent wraps an event in a CloudEvent from source (DefaultCloudEventSource if
+// empty). The subject is the changed resource as kind/namespace/name, or kind/name for
+// cluster-scoped resources.
+func NewCloudEvent(event *ChangeEvent, source string) *CloudEvent {
+	if source == "" {
+		source = DefaultCloudEventSource
+	}
+	ce := &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              event.ID,
+		Source:          source,
+		Type:            CloudEventTypePrefix + strings.ToLower(event.Operation),
+		DataContentType: "application/json",
+		Data:            event,
+	}
+	if event.ResourceKind != "" {
+		parts := []string{event.ResourceKind}
+		if event.Namespace != "" {
+			parts = append(parts, event.Namespace)
+		}
+		ce.Subject = strings.Join(append(parts, event.Name), "/")
+	}
+	if !event.Timestamp.IsZero() {
+		ce.Time = event.Timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	return ce
+}
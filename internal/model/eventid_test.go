@@ -0,0 +1,36 @@
+package model
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewEventID(t *testing.T) {
+	timestamp := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := NewEventID(timestamp)
+	if !uuidV7.MatchString(id) {
+		t.Fatalf("NewEventID() = %q, want a UUIDv7", id)
+	}
+	// 2026-01-01T12:00:00Z is 0x019b796dd600 milliseconds after the epoch
+	if id[:13] != "019b796d-d600" {
+		t.Errorf("NewEventID() = %q, want the timestamp in its first 48 bits", id)
+	}
+
+	// Events with the same timestamp get different IDs
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := NewEventID(timestamp)
+		if seen[id] {
+			t.Fatalf("NewEventID() returned %q twice", id)
+		}
+		seen[id] = true
+	}
+
+	// IDs sort by time
+	if later := NewEventID(timestamp.Add(time.Millisecond)); later <= id {
+		t.Errorf("NewEventID() of a later event = %q, want after %q", later, id)
+	}
+}
@@ -0,0 +1,949 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtobufContentType is the content type of events encoded with MarshalProto.
+const ProtobufContentType = "application/x-protobuf"
+
+// ProtoMessageName is the fully qualified name of the message in change_event.proto that
+// MarshalProto encodes. The package version changes with breaking schema changes only.
+const ProtoMessageName = "kubechronicle.v1.ChangeEvent"
+
+// MarshalProto encodes an event as a kubechronicle.v1.ChangeEvent Protobuf message.
+func MarshalProto(event *ChangeEvent) ([]byte, error) {
+	var b protoBuffer
+	b.string(1, event.ID)
+	b.timestamp(2, event.Timestamp)
+	b.string(3, event.Operation)
+	b.string(4, event.ResourceKind)
+	b.string(5, event.Namespace)
+	b.string(6, event.Name)
+	b.message(7, func(m *protoBuffer) {
+		m.string(1, event.Actor.Username)
+		m.strings(2, event.Actor.Groups)
+		m.string(3, event.Actor.ServiceAccount)
+		m.string(4, event.Actor.SourceIP)
+	})
+	b.message(8, func(m *protoBuffer) {
+		m.string(1, event.Source.Tool)
+	})
+	for _, op := range event.Diff {
+		value, err := marshalProtoJSON(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value of patch operation %s: %w", op.Path, err)
+		}
+		b.message(9, func(m *protoBuffer) {
+			m.string(1, op.Op)
+			m.string(2, op.Path)
+			m.bytes(3, value)
+		})
+	}
+	if event.ObjectSnapshot != nil {
+		snapshot, err := json.Marshal(event.ObjectSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal object snapshot: %w", err)
+		}
+		b.bytes(10, snapshot)
+	}
+	b.bool(11, event.Allowed)
+	b.string(12, event.BlockPattern)
+	if exec := event.ExecMetadata; exec != nil {
+		b.message(13, func(m *protoBuffer) {
+			m.strings(1, exec.Command)
+			m.string(2, exec.Container)
+			m.bool(3, exec.Stdin)
+			m.bool(4, exec.TTY)
+			m.string(5, exec.TargetType)
+			m.string(6, exec.NodeName)
+			m.string(7, exec.Subresource)
+			m.int(8, int64(exec.RiskScore))
+			m.string(9, exec.RiskSeverity)
+			m.strings(10, exec.RiskReasons)
+		})
+	}
+	b.bool(14, event.Encrypted)
+	b.bool(15, event.SizeExceeded)
+	b.int(16, int64(event.ObjectSize))
+	b.int(17, int64(event.SampleRate))
+	if rule := event.BlockRule; rule != nil {
+		b.message(18, func(m *protoBuffer) {
+			m.string(1, rule.Name)
+			m.string(2, rule.Owner)
+			m.string(3, rule.Reason)
+			m.string(4, rule.TicketURL)
+			if rule.ExpiresAt != nil {
+				m.timestamp(5, *rule.ExpiresAt)
+			}
+		})
+	}
+	if nm := event.NodeMaintenance; nm != nil {
+		b.message(19, func(m *protoBuffer) {
+			m.string(1, nm.Action)
+			m.string(2, nm.NodeName)
+		})
+	}
+	if ci := event.CredentialIssuance; ci != nil {
+		b.message(20, func(m *protoBuffer) {
+			m.string(1, ci.Type)
+			m.string(2, ci.Decision)
+			m.string(3, ci.SignerName)
+			m.string(4, ci.Requester)
+			m.string(5, ci.Subject)
+			m.strings(6, ci.Groups)
+			m.strings(7, ci.Usages)
+			m.strings(8, ci.Audiences)
+			m.int(9, ci.ExpirationSeconds)
+			m.string(10, ci.BoundObject)
+		})
+	}
+	if d := event.Deployment; d != nil {
+		b.message(21, func(m *protoBuffer) {
+			m.string(1, d.Provider)
+			m.string(2, d.Event)
+			m.string(3, d.Status)
+			m.string(4, d.Environment)
+			m.string(5, d.Repository)
+			m.string(6, d.Ref)
+			m.string(7, d.SHA)
+			m.string(8, d.URL)
+		})
+	}
+	if cc := event.CloudChange; cc != nil {
+		var parameters []byte
+		if cc.Parameters != nil {
+			var err error
+			if parameters, err = json.Marshal(cc.Parameters); err != nil {
+				return nil, fmt.Errorf("failed to marshal cloud change parameters: %w", err)
+			}
+		}
+		b.message(22, func(m *protoBuffer) {
+			m.string(1, cc.Provider)
+			m.string(2, cc.Cluster)
+			m.string(3, cc.Action)
+			m.string(4, cc.Method)
+			m.bytes(5, parameters)
+		})
+	}
+	b.int(23, int64(event.SchemaVersion))
+	if sm := event.SelfMonitor; sm != nil {
+		b.message(24, func(m *protoBuffer) {
+			m.string(1, sm.Check)
+			m.string(2, sm.Severity)
+			m.string(3, sm.Message)
+			m.string(4, sm.FieldManager)
+		})
+	}
+	if hb := event.Heartbeat; hb != nil {
+		b.message(25, func(m *protoBuffer) {
+			m.string(1, hb.Producer)
+			m.string(2, hb.Cluster)
+			m.string(3, hb.Instance)
+			m.int(4, hb.Sequence)
+			m.string(5, hb.Interval)
+		})
+	}
+	if dc := event.DeleteCollection; dc != nil {
+		b.message(26, func(m *protoBuffer) {
+			m.int(1, int64(dc.Count))
+			m.strings(2, dc.Children)
+			m.bool(3, dc.Truncated)
+			m.timestamp(4, dc.FirstDeletedAt)
+			m.timestamp(5, dc.LastDeletedAt)
+		})
+	}
+	if nc := event.NamespaceCascade; nc != nil {
+		b.message(27, func(m *protoBuffer) {
+			m.string(1, nc.DeletionID)
+			m.int(2, int64(nc.Total))
+			for _, kind := range slices.Sorted(maps.Keys(nc.Kinds)) {
+				m.message(3, func(k *protoBuffer) {
+					k.string(1, kind)
+					k.int(2, int64(nc.Kinds[kind]))
+				})
+			}
+			for _, kind := range slices.Sorted(maps.Keys(nc.Notable)) {
+				for _, name := range nc.Notable[kind] {
+					m.message(4, func(o *protoBuffer) {
+						o.string(1, kind)
+						o.string(2, name)
+					})
+				}
+			}
+			m.bool(5, nc.Truncated)
+			m.timestamp(6, nc.DeletedAt)
+			m.timestamp(7, nc.LastDeletedAt)
+		})
+	}
+	if vd := event.VolumeDeletion; vd != nil {
+		b.message(28, func(m *protoBuffer) {
+			m.string(1, vd.Volume)
+			m.string(2, vd.Claim)
+			m.string(3, vd.StorageClass)
+			m.string(4, vd.Capacity)
+			m.string(5, vd.ReclaimPolicy)
+			m.bool(6, vd.DataLossRisk)
+		})
+	}
+	if cc := event.CRDChange; cc != nil {
+		b.message(29, func(m *protoBuffer) {
+			m.strings(1, cc.AddedVersions)
+			m.strings(2, cc.RemovedVersions)
+			m.strings(3, cc.ServedVersions)
+			m.strings(4, cc.UnservedVersions)
+			m.string(5, cc.OldStorageVersion)
+			m.string(6, cc.NewStorageVersion)
+			for _, field := range cc.Fields {
+				m.message(7, func(f *protoBuffer) {
+					f.string(1, field.Version)
+					f.string(2, field.Op)
+					f.string(3, field.Path)
+					f.string(4, field.OldType)
+					f.string(5, field.NewType)
+				})
+			}
+			m.bool(8, cc.Truncated)
+		})
+	}
+	if ip := event.ImageProvenance; ip != nil {
+		b.message(30, func(m *protoBuffer) {
+			for _, image := range ip.Images {
+				m.message(1, func(i *protoBuffer) {
+					i.string(1, image.Container)
+					i.string(2, image.Image)
+					i.string(3, image.OldImage)
+					i.string(4, image.Digest)
+					i.string(5, image.Status)
+					i.string(6, image.Error)
+				})
+			}
+			m.bool(2, ip.Violation)
+		})
+	}
+	if ec := event.ExposureChange; ec != nil {
+		b.message(31, func(m *protoBuffer) {
+			m.string(1, ec.Severity)
+			for _, finding := range ec.Findings {
+				m.message(2, func(f *protoBuffer) {
+					f.string(1, finding.Check)
+					f.string(2, finding.Severity)
+					f.string(3, finding.Message)
+				})
+			}
+		})
+	}
+	if qi := event.QuotaImpact; qi != nil {
+		b.message(32, func(m *protoBuffer) {
+			m.string(1, qi.Summary)
+			for _, resource := range qi.Resources {
+				m.message(2, func(r *protoBuffer) {
+					r.string(1, resource.Resource)
+					r.string(2, resource.OldHard)
+					r.string(3, resource.Hard)
+					r.string(4, resource.Used)
+				})
+			}
+			for _, container := range qi.Containers {
+				m.message(3, func(c *protoBuffer) {
+					c.string(1, container.Pod)
+					c.string(2, container.Container)
+					c.string(3, container.Resource)
+					c.string(4, container.Constraint)
+					c.string(5, container.Value)
+					c.string(6, container.Bound)
+				})
+			}
+			m.bool(4, qi.Truncated)
+		})
+	}
+	if pr := event.PodSecurityRegression; pr != nil {
+		b.message(33, func(m *protoBuffer) {
+			for _, finding := range pr.Findings {
+				m.message(1, func(f *protoBuffer) {
+					f.string(1, finding.Check)
+					f.string(2, finding.Container)
+					f.string(3, finding.Message)
+				})
+			}
+		})
+	}
+	return b, nil
+}
+
+// UnmarshalProto decodes a kubechronicle.v1.ChangeEvent Protobuf message. Fields added
+// by newer versions of the schema are ignored.
+func UnmarshalProto(data []byte) (*ChangeEvent, error) {
+	event := &ChangeEvent{}
+	err := consumeProtoFields(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			event.ID = string(f.bytes)
+		case 2:
+			ts, err := consumeProtoTimestamp(f.bytes)
+			if err != nil {
+				return err
+			}
+			event.Timestamp = ts
+		case 3:
+			event.Operation = string(f.bytes)
+		case 4:
+			event.ResourceKind = string(f.bytes)
+		case 5:
+			event.Namespace = string(f.bytes)
+		case 6:
+			event.Name = string(f.bytes)
+		case 7:
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					event.Actor.Username = string(f.bytes)
+				case 2:
+					event.Actor.Groups = append(event.Actor.Groups, string(f.bytes))
+				case 3:
+					event.Actor.ServiceAccount = string(f.bytes)
+				case 4:
+					event.Actor.SourceIP = string(f.bytes)
+				}
+				return nil
+			})
+		case 8:
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				if f.num == 1 {
+					event.Source.Tool = string(f.bytes)
+				}
+				return nil
+			})
+		case 9:
+			var op PatchOp
+			err := consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					op.Op = string(f.bytes)
+				case 2:
+					op.Path = string(f.bytes)
+				case 3:
+					return json.Unmarshal(f.bytes, &op.Value)
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("invalid patch operation: %w", err)
+			}
+			event.Diff = append(event.Diff, op)
+		case 10:
+			if err := json.Unmarshal(f.bytes, &event.ObjectSnapshot); err != nil {
+				return fmt.Errorf("invalid object snapshot: %w", err)
+			}
+		case 11:
+			event.Allowed = f.varint != 0
+		case 12:
+			event.BlockPattern = string(f.bytes)
+		case 13:
+			exec := &ExecMetadata{}
+			event.ExecMetadata = exec
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					exec.Command = append(exec.Command, string(f.bytes))
+				case 2:
+					exec.Container = string(f.bytes)
+				case 3:
+					exec.Stdin = f.varint != 0
+				case 4:
+					exec.TTY = f.varint != 0
+				case 5:
+					exec.TargetType = string(f.bytes)
+				case 6:
+					exec.NodeName = string(f.bytes)
+				case 7:
+					exec.Subresource = string(f.bytes)
+				case 8:
+					exec.RiskScore = int(int32(f.varint))
+				case 9:
+					exec.RiskSeverity = string(f.bytes)
+				case 10:
+					exec.RiskReasons = append(exec.RiskReasons, string(f.bytes))
+				}
+				return nil
+			})
+		case 14:
+			event.Encrypted = f.varint != 0
+		case 15:
+			event.SizeExceeded = f.varint != 0
+		case 16:
+			event.ObjectSize = int(int64(f.varint))
+		case 17:
+			event.SampleRate = int(int32(f.varint))
+		case 18:
+			rule := &BlockRule{}
+			event.BlockRule = rule
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					rule.Name = string(f.bytes)
+				case 2:
+					rule.Owner = string(f.bytes)
+				case 3:
+					rule.Reason = string(f.bytes)
+				case 4:
+					rule.TicketURL = string(f.bytes)
+				case 5:
+					ts, err := consumeProtoTimestamp(f.bytes)
+					if err != nil {
+						return err
+					}
+					rule.ExpiresAt = &ts
+				}
+				return nil
+			})
+		case 19:
+			nm := &NodeMaintenance{}
+			event.NodeMaintenance = nm
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					nm.Action = string(f.bytes)
+				case 2:
+					nm.NodeName = string(f.bytes)
+				}
+				return nil
+			})
+		case 20:
+			ci := &CredentialIssuance{}
+			event.CredentialIssuance = ci
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					ci.Type = string(f.bytes)
+				case 2:
+					ci.Decision = string(f.bytes)
+				case 3:
+					ci.SignerName = string(f.bytes)
+				case 4:
+					ci.Requester = string(f.bytes)
+				case 5:
+					ci.Subject = string(f.bytes)
+				case 6:
+					ci.Groups = append(ci.Groups, string(f.bytes))
+				case 7:
+					ci.Usages = append(ci.Usages, string(f.bytes))
+				case 8:
+					ci.Audiences = append(ci.Audiences, string(f.bytes))
+				case 9:
+					ci.ExpirationSeconds = int64(f.varint)
+				case 10:
+					ci.BoundObject = string(f.bytes)
+				}
+				return nil
+			})
+		case 21:
+			d := &CIDeployment{}
+			event.Deployment = d
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					d.Provider = string(f.bytes)
+				case 2:
+					d.Event = string(f.bytes)
+				case 3:
+					d.Status = string(f.bytes)
+				case 4:
+					d.Environment = string(f.bytes)
+				case 5:
+					d.Repository = string(f.bytes)
+				case 6:
+					d.Ref = string(f.bytes)
+				case 7:
+					d.SHA = string(f.bytes)
+				case 8:
+					d.URL = string(f.bytes)
+				}
+				return nil
+			})
+		case 22:
+			cc := &CloudChange{}
+			event.CloudChange = cc
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					cc.Provider = string(f.bytes)
+				case 2:
+					cc.Cluster = string(f.bytes)
+				case 3:
+					cc.Action = string(f.bytes)
+				case 4:
+					cc.Method = string(f.bytes)
+				case 5:
+					if err := json.Unmarshal(f.bytes, &cc.Parameters); err != nil {
+						return fmt.Errorf("invalid cloud change parameters: %w", err)
+					}
+				}
+				return nil
+			})
+		case 23:
+			event.SchemaVersion = int(int32(f.varint))
+		case 24:
+			sm := &SelfMonitorFinding{}
+			event.SelfMonitor = sm
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					sm.Check = string(f.bytes)
+				case 2:
+					sm.Severity = string(f.bytes)
+				case 3:
+					sm.Message = string(f.bytes)
+				case 4:
+					sm.FieldManager = string(f.bytes)
+				}
+				return nil
+			})
+		case 25:
+			hb := &Heartbeat{}
+			event.Heartbeat = hb
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					hb.Producer = string(f.bytes)
+				case 2:
+					hb.Cluster = string(f.bytes)
+				case 3:
+					hb.Instance = string(f.bytes)
+				case 4:
+					hb.Sequence = int64(f.varint)
+				case 5:
+					hb.Interval = string(f.bytes)
+				}
+				return nil
+			})
+		case 26:
+			dc := &DeleteCollection{}
+			event.DeleteCollection = dc
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					dc.Count = int(int64(f.varint))
+				case 2:
+					dc.Children = append(dc.Children, string(f.bytes))
+				case 3:
+					dc.Truncated = f.varint != 0
+				case 4, 5:
+					ts, err := consumeProtoTimestamp(f.bytes)
+					if err != nil {
+						return err
+					}
+					if f.num == 4 {
+						dc.FirstDeletedAt = ts
+					} else {
+						dc.LastDeletedAt = ts
+					}
+				}
+				return nil
+			})
+		case 27:
+			nc := &NamespaceCascade{}
+			event.NamespaceCascade = nc
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					nc.DeletionID = string(f.bytes)
+				case 2:
+					nc.Total = int(int64(f.varint))
+				case 3:
+					var kind string
+					var count int
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							kind = string(f.bytes)
+						case 2:
+							count = int(int64(f.varint))
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					if nc.Kinds == nil {
+						nc.Kinds = map[string]int{}
+					}
+					nc.Kinds[kind] = count
+				case 4:
+					var kind, name string
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							kind = string(f.bytes)
+						case 2:
+							name = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					if nc.Notable == nil {
+						nc.Notable = map[string][]string{}
+					}
+					nc.Notable[kind] = append(nc.Notable[kind], name)
+				case 5:
+					nc.Truncated = f.varint != 0
+				case 6, 7:
+					ts, err := consumeProtoTimestamp(f.bytes)
+					if err != nil {
+						return err
+					}
+					if f.num == 6 {
+						nc.DeletedAt = ts
+					} else {
+						nc.LastDeletedAt = ts
+					}
+				}
+				return nil
+			})
+		case 28:
+			vd := &VolumeDeletion{}
+			event.VolumeDeletion = vd
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					vd.Volume = string(f.bytes)
+				case 2:
+					vd.Claim = string(f.bytes)
+				case 3:
+					vd.StorageClass = string(f.bytes)
+				case 4:
+					vd.Capacity = string(f.bytes)
+				case 5:
+					vd.ReclaimPolicy = string(f.bytes)
+				case 6:
+					vd.DataLossRisk = f.varint != 0
+				}
+				return nil
+			})
+		case 29:
+			cc := &CRDChange{}
+			event.CRDChange = cc
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					cc.AddedVersions = append(cc.AddedVersions, string(f.bytes))
+				case 2:
+					cc.RemovedVersions = append(cc.RemovedVersions, string(f.bytes))
+				case 3:
+					cc.ServedVersions = append(cc.ServedVersions, string(f.bytes))
+				case 4:
+					cc.UnservedVersions = append(cc.UnservedVersions, string(f.bytes))
+				case 5:
+					cc.OldStorageVersion = string(f.bytes)
+				case 6:
+					cc.NewStorageVersion = string(f.bytes)
+				case 7:
+					var field CRDFieldChange
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							field.Version = string(f.bytes)
+						case 2:
+							field.Op = string(f.bytes)
+						case 3:
+							field.Path = string(f.bytes)
+						case 4:
+							field.OldType = string(f.bytes)
+						case 5:
+							field.NewType = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					cc.Fields = append(cc.Fields, field)
+				case 8:
+					cc.Truncated = f.varint != 0
+				}
+				return nil
+			})
+		case 30:
+			ip := &ImageProvenance{}
+			event.ImageProvenance = ip
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					var image ImageCheck
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							image.Container = string(f.bytes)
+						case 2:
+							image.Image = string(f.bytes)
+						case 3:
+							image.OldImage = string(f.bytes)
+						case 4:
+							image.Digest = string(f.bytes)
+						case 5:
+							image.Status = string(f.bytes)
+						case 6:
+							image.Error = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					ip.Images = append(ip.Images, image)
+				case 2:
+					ip.Violation = f.varint != 0
+				}
+				return nil
+			})
+		case 31:
+			ec := &ExposureChange{}
+			event.ExposureChange = ec
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					ec.Severity = string(f.bytes)
+				case 2:
+					var finding ExposureFinding
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							finding.Check = string(f.bytes)
+						case 2:
+							finding.Severity = string(f.bytes)
+						case 3:
+							finding.Message = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					ec.Findings = append(ec.Findings, finding)
+				}
+				return nil
+			})
+		case 32:
+			qi := &QuotaImpact{}
+			event.QuotaImpact = qi
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				switch f.num {
+				case 1:
+					qi.Summary = string(f.bytes)
+				case 2:
+					var resource QuotaResourceImpact
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							resource.Resource = string(f.bytes)
+						case 2:
+							resource.OldHard = string(f.bytes)
+						case 3:
+							resource.Hard = string(f.bytes)
+						case 4:
+							resource.Used = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					qi.Resources = append(qi.Resources, resource)
+				case 3:
+					var container LimitRangeImpact
+					err := consumeProtoFields(f.bytes, func(f protoField) error {
+						switch f.num {
+						case 1:
+							container.Pod = string(f.bytes)
+						case 2:
+							container.Container = string(f.bytes)
+						case 3:
+							container.Resource = string(f.bytes)
+						case 4:
+							container.Constraint = string(f.bytes)
+						case 5:
+							container.Value = string(f.bytes)
+						case 6:
+							container.Bound = string(f.bytes)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					qi.Containers = append(qi.Containers, container)
+				case 4:
+					qi.Truncated = f.varint != 0
+				}
+				return nil
+			})
+		case 33:
+			pr := &PodSecurityRegression{}
+			event.PodSecurityRegression = pr
+			return consumeProtoFields(f.bytes, func(f protoField) error {
+				if f.num != 1 {
+					return nil
+				}
+				var finding PodSecurityFinding
+				err := consumeProtoFields(f.bytes, func(f protoField) error {
+					switch f.num {
+					case 1:
+						finding.Check = string(f.bytes)
+					case 2:
+						finding.Container = string(f.bytes)
+					case 3:
+						finding.Message = string(f.bytes)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				pr.Findings = append(pr.Findings, finding)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", ProtoMessageName, err)
+	}
+	return event, nil
+}
+
+// marshalProtoJSON encodes a free-form value as JSON; nil stays unset.
+func marshalProtoJSON(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// protoBuffer appends proto3 fields, omitting zero values like generated code does.
+type protoBuffer []byte
+
+func (b *protoBuffer) string(num protowire.Number, s string) {
+	if s == "" {
+		return
+	}
+	*b = protowire.AppendTag(*b, num, protowire.BytesType)
+	*b = protowire.AppendString(*b, s)
+}
+
+func (b *protoBuffer) strings(num protowire.Number, ss []string) {
+	for _, s := range ss {
+		*b = protowire.AppendTag(*b, num, protowire.BytesType)
+		*b = protowire.AppendString(*b, s)
+	}
+}
+
+func (b *protoBuffer) bytes(num protowire.Number, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	*b = protowire.AppendTag(*b, num, protowire.BytesType)
+	*b = protowire.AppendBytes(*b, v)
+}
+
+func (b *protoBuffer) bool(num protowire.Number, v bool) {
+	if !v {
+		return
+	}
+	*b = protowire.AppendTag(*b, num, protowire.VarintType)
+	*b = protowire.AppendVarint(*b, 1)
+}
+
+func (b *protoBuffer) int(num protowire.Number, v int64) {
+	if v == 0 {
+		return
+	}
+	*b = protowire.AppendTag(*b, num, protowire.VarintType)
+	*b = protowire.AppendVarint(*b, uint64(v))
+}
+
+// message appends an embedded message, even if it has no fields set.
+func (b *protoBuffer) message(num protowire.Number, fields func(m *protoBuffer)) {
+	var m protoBuffer
+	fields(&m)
+	*b = protowire.AppendTag(*b, num, protowire.BytesType)
+	*b = protowire.AppendBytes(*b, m)
+}
+
+// timestamp appends a google.protobuf.Timestamp; the zero time is omitted.
+func (b *protoBuffer) timestamp(num protowire.Number, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	b.message(num, func(m *protoBuffer) {
+		m.int(1, t.Unix())
+		m.int(2, int64(t.Nanosecond()))
+	})
+}
+
+// protoField is a decoded field: varint holds varint values, bytes length-delimited ones.
+type protoField struct {
+	num    protowire.Number
+	varint uint64
+	bytes  []byte
+}
+
+// consumeProtoFields calls fn for each varint and length-delimited field of a message.
+// Fields of other wire types are not used by the schema and are skipped.
+func consumeProtoFields(data []byte, fn func(f protoField) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		f := protoField{num: num}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := fn(f); err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+	}
+	return nil
+}
+
+// consumeProtoTimestamp decodes a google.protobuf.Timestamp as a UTC time.
+func consumeProtoTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := consumeProtoFields(data, func(f protoField) error {
+		switch f.num {
+		case 1:
+			seconds = int64(f.varint)
+		case 2:
+			nanos = int64(int32(f.varint))
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestProto_RoundTrip(t *testing.T) {
+	expires := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	events := []*ChangeEvent{
+		{
+			ID:           "UPDATE-Deployment-api-1705233600",
+			Timestamp:    time.Date(2024, 1, 14, 12, 0, 0, 123456789, time.UTC),
+			Operation:    "UPDATE",
+			ResourceKind: "Deployment",
+			Namespace:    "prod",
+			Name:         "api",
+			Actor:        Actor{Username: "alice", Groups: []string{"dev", "system:authenticated"}, SourceIP: "10.0.0.1"},
+			Source:       Source{Tool: "kubectl"},
+			Diff: []PatchOp{
+				{Op: "replace", Path: "/spec/replicas", Value: float64(3)},
+				{Op: "add", Path: "/metadata/labels", Value: map[string]interface{}{"tier": "web"}},
+				{Op: "remove", Path: "/spec/paused"},
+			},
+			BlockPattern:  "prod-*",
+			BlockRule:     &BlockRule{Name: "freeze", Owner: "sre", ExpiresAt: &expires},
+			SizeExceeded:  true,
+			ObjectSize:    2 << 20,
+			SampleRate:    10,
+			SchemaVersion: SchemaVersion,
+		},
+		{
+			ID:             "DELETE-ConfigMap-settings-1705233600",
+			Timestamp:      time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC),
+			Operation:      "DELETE",
+			ResourceKind:   "ConfigMap",
+			Name:           "settings",
+			Allowed:        true,
+			ObjectSnapshot: map[string]interface{}{"data": map[string]interface{}{"mode": "strict"}},
+		},
+		{
+			ID: "EXEC-Pod-api-0", Operation: "EXEC", Allowed: true,
+			ExecMetadata: &ExecMetadata{Command: []string{"sh", "-c", "id"}, Stdin: true, TTY: true, TargetType: "pod", RiskScore: 40, RiskReasons: []string{"shell"}},
+		},
+		{ID: "n", Operation: "NODE_MAINTENANCE", NodeMaintenance: &NodeMaintenance{Action: "cordon", NodeName: "node-1"}},
+		{ID: "c", Operation: "CREDENTIAL_ISSUANCE", CredentialIssuance: &CredentialIssuance{Type: "token", Audiences: []string{"vault"}, ExpirationSeconds: 3600}},
+		{ID: "d", Operation: "DEPLOYMENT", Deployment: &CIDeployment{Provider: "github", Event: "deployment_status", Status: "success", SHA: "abc123"}},
+		{ID: "g", Operation: "CLOUD_CHANGE", CloudChange: &CloudChange{Provider: "eks", Action: "scale_node_pool", Parameters: map[string]interface{}{"desiredSize": float64(5)}}},
+		{ID: "s", Operation: "SELF_MONITORING", SelfMonitor: &SelfMonitorFinding{Check: "webhook_narrowed", Severity: "critical", Message: "rules removed", FieldManager: "kubectl-edit"}},
+		{ID: "c", Operation: "DELETE_COLLECTION", DeleteCollection: &DeleteCollection{Count: 3, Children: []string{"a", "b", "d"}, FirstDeletedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), LastDeletedAt: time.Date(2024, 1, 1, 0, 0, 2, 0, time.UTC)}},
+		{ID: "n", Operation: "NAMESPACE_CASCADE", NamespaceCascade: &NamespaceCascade{DeletionID: "x", Total: 5, Kinds: map[string]int{"Pod": 3, "Secret": 2}, Notable: map[string][]string{"Secret": {"db", "tls"}}, DeletedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{ID: "v", Operation: "DELETE", ResourceKind: "PersistentVolumeClaim", VolumeDeletion: &VolumeDeletion{Volume: "pvc-1", Claim: "shop/data", StorageClass: "gp3", Capacity: "10Gi", ReclaimPolicy: "Delete", DataLossRisk: true}},
+		{ID: "r", Operation: "UPDATE", ResourceKind: "CustomResourceDefinition", CRDChange: &CRDChange{AddedVersions: []string{"v2"}, UnservedVersions: []string{"v1alpha1"}, OldStorageVersion: "v1", NewStorageVersion: "v2", Fields: []CRDFieldChange{{Version: "v1", Op: "type", Path: "spec.size", OldType: "integer", NewType: "string"}}}},
+		{ID: "i", Operation: "UPDATE", ResourceKind: "Deployment", ImageProvenance: &ImageProvenance{Images: []ImageCheck{{Container: "app", Image: "ghcr.io/org/app:v2", OldImage: "ghcr.io/org/app:v1", Digest: "sha256:abc", Status: ImageUnsigned}, {Container: "proxy", Image: "envoy:1.30", Status: ImageUnknown, Error: "registry requires credentials"}}, Violation: true}},
+		{ID: "x", Operation: "UPDATE", ResourceKind: "Service", ExposureChange: &ExposureChange{Severity: "critical", Findings: []ExposureFinding{{Check: "service_type", Severity: "critical", Message: "type changed from ClusterIP to LoadBalancer"}}}},
+		{ID: "q", Operation: "UPDATE", ResourceKind: "ResourceQuota", QuotaImpact: &QuotaImpact{Summary: "pods over quota", Resources: []QuotaResourceImpact{{Resource: "pods", OldHard: "20", Hard: "10", Used: "12"}}}},
+		{ID: "l", Operation: "UPDATE", ResourceKind: "LimitRange", QuotaImpact: &QuotaImpact{Summary: "1 container of 1 pod outside the limits", Containers: []LimitRangeImpact{{Pod: "api-1", Container: "app", Resource: "memory", Constraint: "max", Value: "2Gi", Bound: "1Gi"}}, Truncated: true}},
+		{ID: "p", Operation: "UPDATE", ResourceKind: "Deployment", PodSecurityRegression: &PodSecurityRegression{Findings: []PodSecurityFinding{{Check: "host_network", Message: "hostNetwork enabled"}, {Check: "privileged", Container: "app", Message: "container app made privileged"}}}},
+		{ID: "h", Operation: "HEARTBEAT", Heartbeat: &Heartbeat{Producer: "webhook", Cluster: "prod", Instance: "kubechronicle-0/1700000000", Sequence: 42, Interval: "1m0s"}},
+	}
+
+	for _, event := range events {
+		t.Run(event.Operation, func(t *testing.T) {
+			data, err := MarshalProto(event)
+			if err != nil {
+				t.Fatalf("MarshalProto() error = %v", err)
+			}
+			decoded, err := UnmarshalProto(data)
+			if err != nil {
+				t.Fatalf("UnmarshalProto() error = %v", err)
+			}
+
+			// The decoded event has the same JSON representation as the original
+			want, _ := json.Marshal(event)
+			got, _ := json.Marshal(decoded)
+			if string(got) != string(want) {
+				t.Errorf("Round trip mismatch:\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestProto_TimestampIsWellKnownType(t *testing.T) {
+	ts := time.Date(2024, 1, 14, 12, 0, 0, 500, time.UTC)
+	data, err := MarshalProto(&ChangeEvent{ID: "e1", Timestamp: ts})
+	if err != nil {
+		t.Fatalf("MarshalProto() error = %v", err)
+	}
+
+	var field []byte
+	consumeProtoFields(data, func(f protoField) error {
+		if f.num == 2 {
+			field = f.bytes
+		}
+		return nil
+	})
+	var decoded timestamppb.Timestamp
+	if err := proto.Unmarshal(field, &decoded); err != nil {
+		t.Fatalf("Timestamp is not a google.protobuf.Timestamp: %v", err)
+	}
+	if !decoded.AsTime().Equal(ts) {
+		t.Errorf("Timestamp = %v, want %v", decoded.AsTime(), ts)
+	}
+}
+
+func TestUnmarshalProto_IgnoresUnknownFields(t *testing.T) {
+	data, _ := MarshalProto(&ChangeEvent{ID: "e1", Operation: "CREATE"})
+
+	// Fields a newer schema might add
+	data = protowire.AppendTag(data, 100, protowire.BytesType)
+	data = protowire.AppendString(data, "future")
+	data = protowire.AppendTag(data, 101, protowire.Fixed64Type)
+	data = protowire.AppendFixed64(data, 42)
+
+	event, err := UnmarshalProto(data)
+	if err != nil {
+		t.Fatalf("UnmarshalProto() error = %v", err)
+	}
+	if event.ID != "e1" || event.Operation != "CREATE" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestUnmarshalProto_Invalid(t *testing.T) {
+	data, _ := MarshalProto(&ChangeEvent{ID: "e1"})
+	if _, err := UnmarshalProto(data[:len(data)-1]); err == nil {
+		t.Error("Expected an error for a truncated message")
+	}
+
+	var b protoBuffer
+	b.bytes(10, []byte("not json"))
+	if _, err := UnmarshalProto(b); err == nil {
+		t.Error("Expected an error for an invalid object snapshot")
+	}
+}
+
+func TestMarshalProto_OmitsZeroValues(t *testing.T) {
+	data, err := MarshalProto(&ChangeEvent{})
+	if err != nil {
+		t.Fatalf("MarshalProto() error = %v", err)
+	}
+	event, _ := UnmarshalProto(data)
+	if !reflect.DeepEqual(event, &ChangeEvent{}) {
+		t.Errorf("Expected an empty event, got %+v", event)
+	}
+	// Only the empty actor and source messages are written
+	if len(data) != 4 {
+		t.Errorf("len(data) = %d, want 4", len(data))
+	}
+}
@@ -0,0 +1,247 @@
+// Package pseudonym replaces the usernames (often email addresses) and source IPs of change
+// events with keyed pseudonyms at ingestion, before they are stored, exported or alerted on.
+// A value always gets the same pseudonym, so activity can still be grouped by actor, and the
+// store keeps the mapping back to the value for admins.
+package pseudonym
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Prefix starts every pseudonym, e.g. "pseudo-3f2a9c1e0b7d4a65".
+const Prefix = "pseudo-"
+
+// Fields that can be pseudonymized.
+const (
+	FieldUsername = "username"  // Actor username, and the requester and subject of certificate requests
+	FieldSourceIP = "source_ip" // Actor source IP
+)
+
+// minKeySize is the smallest key accepted, in bytes.
+const minKeySize = 16
+
+// maxRecorded bounds the pseudonyms remembered as recorded; past it they are forgotten
+// and recorded again, which is harmless.
+const maxRecorded = 100000
+
+// recordTimeout bounds recording the mappings of an event.
+const recordTimeout = 5 * time.Second
+
+// Config selects what is pseudonymized.
+type Config struct {
+	// Fields are the fields pseudonymized (default: username).
+	Fields []string `json:"fields,omitempty"`
+
+	// ExcludeUsers are wildcard patterns of usernames kept, e.g. service accounts and
+	// controllers, which aren't people (default: "system:*"). Their source IPs are kept too.
+	ExcludeUsers []string `json:"exclude_users,omitempty"`
+}
+
+// Validate checks the fields.
+func (c *Config) Validate() error {
+	for _, field := range c.Fields {
+		if field != FieldUsername && field != FieldSourceIP {
+			return fmt.Errorf("unknown field %q, must be %s or %s", field, FieldUsername, FieldSourceIP)
+		}
+	}
+	return nil
+}
+
+// Pseudonymizer pseudonymizes the actor fields of events.
+type Pseudonymizer struct {
+	key      []byte
+	username bool
+	sourceIP bool
+	exclude  []string
+	mappings store.PseudonymStore // Records the values of pseudonyms; nil makes them irreversible
+
+	mu       sync.Mutex
+	recorded map[string]bool // Pseudonyms already recorded
+}
+
+// New creates a pseudonymizer with a base64-encoded key of at least 16 bytes. cfg may be
+// nil for the defaults.
+func New(base64Key string, cfg *Config) (*Pseudonymizer, error) {
+	key, err := base64.StdEncoding.DecodeString(base64Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pseudonym key: %w", err)
+	}
+	if len(key) < minKeySize {
+		return nil, fmt.Errorf("pseudonym key must be at least %d bytes, got %d", minKeySize, len(key))
+	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Pseudonymizer{key: key, exclude: cfg.ExcludeUsers, recorded: map[string]bool{}}
+	if cfg.ExcludeUsers == nil {
+		p.exclude = []string{"system:*"}
+	}
+	for _, field := range cfg.Fields {
+		p.username = p.username || field == FieldUsername
+		p.sourceIP = p.sourceIP || field == FieldSourceIP
+	}
+	if len(cfg.Fields) == 0 {
+		p.username = true
+	}
+	return p, nil
+}
+
+// SetMappingStore records the value of each new pseudonym in mappings, so admins can
+// reverse it.
+func (p *Pseudonymizer) SetMappingStore(mappings store.PseudonymStore) {
+	p.mappings = mappings
+}
+
+// Pseudonym returns the pseudonym of a value. Pseudonyms are returned unchanged.
+func (p *Pseudonymizer) Pseudonym(value string) string {
+	if IsPseudonym(value) {
+		return value
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return Prefix + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// StoredUsername returns the username the events of a user are stored with: its pseudonym,
+// or the username itself if usernames aren't pseudonymized or it is excluded.
+func (p *Pseudonymizer) StoredUsername(username string) string {
+	if !p.username || p.excluded(username) {
+		return username
+	}
+	return p.Pseudonym(username)
+}
+
+// IsPseudonym reports whether a value is a pseudonym.
+func IsPseudonym(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Apply pseudonymizes the fields of an event, recording the values of new pseudonyms
+// first. If they can't be recorded, the event is left unchanged and an error returned, so
+// no pseudonym becomes irreversible. Applying it again changes nothing.
+func (p *Pseudonymizer) Apply(event *model.ChangeEvent) error {
+	if p.excluded(event.Actor.Username) {
+		return nil
+	}
+
+	var replacements []*store.Pseudonym
+	replace := func(field string, value *string) {
+		if *value == "" || IsPseudonym(*value) {
+			return
+		}
+		replacements = append(replacements, &store.Pseudonym{Pseudonym: p.Pseudonym(*value), Field: field, Value: *value})
+	}
+	var fields []*string
+	if p.username {
+		replace(FieldUsername, &event.Actor.Username)
+		fields = append(fields, &event.Actor.Username)
+		if ci := event.CredentialIssuance; ci != nil {
+			for _, value := range []*string{&ci.Requester, &ci.Subject} {
+				if !p.excluded(*value) {
+					replace(FieldUsername, value)
+					fields = append(fields, value)
+				}
+			}
+		}
+	}
+	if p.sourceIP {
+		replace(FieldSourceIP, &event.Actor.SourceIP)
+		fields = append(fields, &event.Actor.SourceIP)
+	}
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	if err := p.record(replacements); err != nil {
+		return err
+	}
+	for _, value := range fields {
+		if *value != "" {
+			*value = p.Pseudonym(*value)
+		}
+	}
+	return nil
+}
+
+// excluded reports whether a username is kept.
+func (p *Pseudonymizer) excluded(username string) bool {
+	for _, pattern := range p.exclude {
+		if store.MatchWildcard(pattern, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// record saves the mappings not recorded before.
+func (p *Pseudonymizer) record(replacements []*store.Pseudonym) error {
+	if p.mappings == nil {
+		return nil
+	}
+	p.mu.Lock()
+	var unrecorded []*store.Pseudonym
+	for _, r := range replacements {
+		if !p.recorded[r.Pseudonym] {
+			unrecorded = append(unrecorded, r)
+		}
+	}
+	p.mu.Unlock()
+	if len(unrecorded) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+	if err := p.mappings.SavePseudonyms(ctx, unrecorded); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.recorded)+len(unrecorded) > maxRecorded {
+		p.recorded = map[string]bool{}
+	}
+	for _, r := range unrecorded {
+		p.recorded[r.Pseudonym] = true
+	}
+	return nil
+}
+
+// pseudonymizingStore pseudonymizes events before saving them.
+type pseudonymizingStore struct {
+	store.Store
+	pseudonymizer *Pseudonymizer
+}
+
+// WrapStore returns a store pseudonymizing events before they are saved, so the stores,
+// exports and alerts after it only see pseudonyms. If no pseudonymizer is configured, the
+// store is returned unchanged.
+func WrapStore(s store.Store, p *Pseudonymizer) store.Store {
+	if s == nil || p == nil {
+		return s
+	}
+	return &pseudonymizingStore{Store: s, pseudonymizer: p}
+}
+
+// Save pseudonymizes the event in place, then persists it.
+func (s *pseudonymizingStore) Save(event *model.ChangeEvent) error {
+	if err := s.pseudonymizer.Apply(event); err != nil {
+		return fmt.Errorf("failed to pseudonymize event: %w", err)
+	}
+	return s.Store.Save(event)
+}
@@ -0,0 +1,164 @@
+package pseudonym
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+// fakeMappings is an in-memory store.PseudonymStore.
+type fakeMappings struct {
+	values map[string]string
+	saves  int
+	err    error
+}
+
+func (f *fakeMappings) SavePseudonyms(ctx context.Context, pseudonyms []*store.Pseudonym) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.saves++
+	for _, p := range pseudonyms {
+		f.values[p.Pseudonym] = p.Value
+	}
+	return nil
+}
+
+func (f *fakeMappings) ResolvePseudonym(ctx context.Context, pseudonym string) (*store.Pseudonym, error) {
+	value, ok := f.values[pseudonym]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &store.Pseudonym{Pseudonym: pseudonym, Value: value}, nil
+}
+
+func TestPseudonymizer_Apply(t *testing.T) {
+	p, err := New(testKey, &Config{Fields: []string{FieldUsername, FieldSourceIP}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	mappings := &fakeMappings{values: map[string]string{}}
+	p.SetMappingStore(mappings)
+
+	event := &model.ChangeEvent{Actor: model.Actor{Username: "alice@example.com", Groups: []string{"dev"}, SourceIP: "10.0.0.1"}}
+	if err := p.Apply(event); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !IsPseudonym(event.Actor.Username) || !IsPseudonym(event.Actor.SourceIP) || event.Actor.Groups[0] != "dev" {
+		t.Fatalf("Actor = %+v, want pseudonymized username and source IP", event.Actor)
+	}
+	if mappings.values[event.Actor.Username] != "alice@example.com" || mappings.values[event.Actor.SourceIP] != "10.0.0.1" {
+		t.Errorf("Recorded mappings = %v", mappings.values)
+	}
+
+	// The same user gets the same pseudonym, recorded once; applying again changes nothing
+	pseudonymized := event.Actor
+	again := &model.ChangeEvent{Actor: model.Actor{Username: "alice@example.com", SourceIP: "10.0.0.1"}}
+	if err := p.Apply(again); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if err := p.Apply(again); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if again.Actor.Username != pseudonymized.Username || again.Actor.SourceIP != pseudonymized.SourceIP {
+		t.Errorf("Actor = %+v, want %+v", again.Actor, pseudonymized)
+	}
+	if mappings.saves != 1 {
+		t.Errorf("Mappings were saved %d times, want once", mappings.saves)
+	}
+
+	// System users are kept
+	controller := &model.ChangeEvent{Actor: model.Actor{Username: "system:serviceaccount:kube-system:deployment-controller", SourceIP: "10.0.0.2"}}
+	if err := p.Apply(controller); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if IsPseudonym(controller.Actor.Username) || IsPseudonym(controller.Actor.SourceIP) {
+		t.Errorf("System user was pseudonymized: %+v", controller.Actor)
+	}
+}
+
+func TestPseudonymizer_RecordFailure(t *testing.T) {
+	p, err := New(testKey, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	p.SetMappingStore(&fakeMappings{err: errors.New("database down")})
+
+	event := &model.ChangeEvent{
+		Actor:              model.Actor{Username: "alice@example.com", SourceIP: "10.0.0.1"},
+		CredentialIssuance: &model.CredentialIssuance{Type: "certificate", Requester: "bob@example.com"},
+	}
+	if err := p.Apply(event); err == nil {
+		t.Fatal("Apply() should fail when the mappings can't be recorded")
+	}
+	if event.Actor.Username != "alice@example.com" || event.CredentialIssuance.Requester != "bob@example.com" {
+		t.Errorf("Event was changed without recording its mappings: %+v", event.Actor)
+	}
+
+	p.SetMappingStore(nil)
+	if err := p.Apply(event); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !IsPseudonym(event.Actor.Username) || !IsPseudonym(event.CredentialIssuance.Requester) {
+		t.Errorf("Username and requester should be pseudonymized: %+v", event)
+	}
+	if event.Actor.SourceIP != "10.0.0.1" {
+		t.Errorf("Source IP = %q, only usernames are pseudonymized by default", event.Actor.SourceIP)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	short := base64.StdEncoding.EncodeToString([]byte("short"))
+	for _, tt := range []struct {
+		key string
+		cfg *Config
+	}{
+		{"not base64!", nil},
+		{short, nil},
+		{testKey, &Config{Fields: []string{"groups"}}},
+	} {
+		if _, err := New(tt.key, tt.cfg); err == nil {
+			t.Errorf("New(%q, %+v) should fail", tt.key, tt.cfg)
+		}
+	}
+}
+
+func TestPseudonym_Keyed(t *testing.T) {
+	p, _ := New(testKey, nil)
+	other, _ := New(base64.StdEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210")), nil)
+	pseudonym := p.Pseudonym("alice@example.com")
+	if !strings.HasPrefix(pseudonym, Prefix) || len(pseudonym) != len(Prefix)+16 {
+		t.Errorf("Pseudonym() = %q", pseudonym)
+	}
+	if other.Pseudonym("alice@example.com") == pseudonym {
+		t.Error("Pseudonyms should depend on the key")
+	}
+}
+
+func TestPseudonymizer_StoredUsername(t *testing.T) {
+	p, _ := New(testKey, nil)
+	sourceIPOnly, _ := New(testKey, &Config{Fields: []string{FieldSourceIP}})
+
+	tests := []struct {
+		name          string
+		pseudonymizer *Pseudonymizer
+		username      string
+		want          string
+	}{
+		{"pseudonymized", p, "alice@example.com", p.Pseudonym("alice@example.com")},
+		{"excluded", p, "system:kube-scheduler", "system:kube-scheduler"},
+		{"usernames kept", sourceIPOnly, "alice@example.com", "alice@example.com"},
+	}
+	for _, tt := range tests {
+		if got := tt.pseudonymizer.StoredUsername(tt.username); got != tt.want {
+			t.Errorf("%s: StoredUsername(%q) = %q, want %q", tt.name, tt.username, got, tt.want)
+		}
+	}
+}
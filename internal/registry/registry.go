@@ -0,0 +1,260 @@
+// Package registry queries container registries over the OCI distribution API for the
+// provenance of images: the digest a reference resolves to, and whether a cosign signature
+// is published for that digest. Registries are queried anonymously, with the bearer token
+// flow of public registries; images of registries requiring credentials can't be checked.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Docker Hub references are short, e.g. "nginx:1.25" for "docker.io/library/nginx:1.25".
+const (
+	dockerHub     = "docker.io"
+	dockerHubHost = "registry-1.docker.io"
+)
+
+// manifestTypes are the manifest media types accepted when resolving a reference, so
+// multi-platform images resolve to the digest of their index.
+var manifestTypes = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// ErrNotFound is returned when the registry has no manifest for a reference.
+var ErrNotFound = errors.New("manifest not found")
+
+// Reference is a parsed image reference.
+type Reference struct {
+	Registry   string // e.g. "ghcr.io"; "docker.io" for short references
+	Repository string // e.g. "library/nginx"
+	Tag        string // "latest" when neither a tag nor a digest is given
+	Digest     string // e.g. "sha256:..."; empty unless the reference pins one
+}
+
+// ParseReference parses an image reference as found in pod specs, such as "nginx",
+// "ghcr.io/org/app:v1" or "registry:5000/app@sha256:...".
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+	if i := strings.IndexByte(name, '@'); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid digest in image %q", image)
+		}
+	}
+	if i := strings.LastIndexByte(name, ':'); i > strings.LastIndexByte(name, '/') {
+		if name, ref.Tag = name[:i], name[i+1:]; ref.Tag == "" {
+			return Reference{}, fmt.Errorf("invalid tag in image %q", image)
+		}
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid image %q", image)
+	}
+
+	ref.Registry, ref.Repository = dockerHub, name
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		if host := name[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry, ref.Repository = host, name[i+1:]
+		}
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = dockerHub
+	}
+	if ref.Registry == dockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// Client queries registries for the provenance of images.
+type Client struct {
+	http     *http.Client
+	insecure map[string]bool
+}
+
+// NewClient creates a client whose requests time out after timeout. The insecure registry
+// hosts are queried over plain HTTP.
+func NewClient(timeout time.Duration, insecure []string) *Client {
+	c := &Client{
+		http:     &http.Client{Timeout: timeout},
+		insecure: make(map[string]bool, len(insecure)),
+	}
+	for _, host := range insecure {
+		c.insecure[host] = true
+	}
+	return c
+}
+
+// Provenance returns the digest of image, and whether a cosign signature is published for
+// it under the "sha256-<hex>.sig" tag of its repository. The signature isn't verified.
+func (c *Client) Provenance(ctx context.Context, image string) (digest string, signed bool, err error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", false, err
+	}
+	session := &session{client: c, ref: ref}
+
+	digest = ref.Digest
+	if digest == "" {
+		if digest, err = session.resolve(ctx, ref.Tag); err != nil {
+			return "", false, err
+		}
+	}
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	if _, err := session.resolve(ctx, signatureTag); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return digest, false, nil
+		}
+		return digest, false, fmt.Errorf("failed to look up signature: %w", err)
+	}
+	return digest, true, nil
+}
+
+// session queries the manifests of a repository, reusing the token it was granted.
+type session struct {
+	client *Client
+	ref    Reference
+	token  string
+}
+
+// resolve returns the digest of the manifest of the repository tagged (or digested) reference.
+func (s *session) resolve(ctx context.Context, reference string) (string, error) {
+	host := s.ref.Registry
+	if host == dockerHub {
+		host = dockerHubHost
+	}
+	scheme := "https"
+	if s.client.insecure[s.ref.Registry] {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, s.ref.Repository, reference)
+
+	resp, err := s.head(ctx, manifestURL)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && s.token == "" {
+		if s.token, err = s.client.token(ctx, resp.Header.Get("WWW-Authenticate"), s.ref.Repository); err != nil {
+			return "", err
+		}
+		if resp, err = s.head(ctx, manifestURL); err != nil {
+			return "", err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			return "", fmt.Errorf("registry %s returned no digest for %s", s.ref.Registry, reference)
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", ErrNotFound
+	default:
+		return "", fmt.Errorf("registry %s returned status %d for %s", s.ref.Registry, resp.StatusCode, reference)
+	}
+}
+
+// head sends a HEAD request for a manifest, with the session's token if it has one.
+func (s *session) head(ctx context.Context, manifestURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestTypes)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// token requests an anonymous pull token for repository from the realm of a Bearer
+// challenge.
+func (c *Client) token(ctx context.Context, challenge, repository string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "Bearer") || params["realm"] == "" {
+		return "", fmt.Errorf("registry requires credentials")
+	}
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry denied anonymous access: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("registry returned no token")
+}
+
+// parseChallenge parses a WWW-Authenticate challenge such as
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1:], '"')
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key], rest = value[1:end+1], value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return scheme, params
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.25", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.25"}},
+		{"bitnami/redis:7", Reference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7"}},
+		{"ghcr.io/org/app:v1", Reference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1"}},
+		{"localhost:5000/app", Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"registry:5000/app@sha256:abc", Reference{Registry: "registry:5000", Repository: "app", Digest: "sha256:abc"}},
+		{"quay.io/org/app:v2@sha256:abc", Reference{Registry: "quay.io", Repository: "org/app", Tag: "v2", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil {
+			t.Errorf("ParseReference(%q) error = %v", tt.image, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.image, got, tt.want)
+		}
+	}
+
+	for _, image := range []string{"", "nginx:", "nginx@abc"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q) expected an error", image)
+		}
+	}
+}
+
+func TestClient_Provenance(t *testing.T) {
+	const signedDigest = "sha256:1111"
+	const unsignedDigest = "sha256:2222"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:org/app:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/v2/org/app/manifests/") {
+		case "signed":
+			w.Header().Set("Docker-Content-Digest", signedDigest)
+		case "unsigned":
+			w.Header().Set("Docker-Content-Digest", unsignedDigest)
+		case "sha256-1111.sig":
+			w.Header().Set("Docker-Content-Digest", "sha256:9999")
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(time.Second, []string{host})
+
+	digest, signed, err := client.Provenance(context.Background(), host+"/org/app:signed")
+	if err != nil || digest != signedDigest || !signed {
+		t.Errorf("Expected a signed image, got %q %v %v", digest, signed, err)
+	}
+	digest, signed, err = client.Provenance(context.Background(), host+"/org/app:unsigned")
+	if err != nil || digest != unsignedDigest || signed {
+		t.Errorf("Expected an unsigned image, got %q %v %v", digest, signed, err)
+	}
+	// A pinned digest isn't resolved
+	digest, signed, err = client.Provenance(context.Background(), host+"/org/app@"+signedDigest)
+	if err != nil || digest != signedDigest || !signed {
+		t.Errorf("Expected a signed pinned image, got %q %v %v", digest, signed, err)
+	}
+	if _, _, err := client.Provenance(context.Background(), host+"/org/app:missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a missing tag, got %v", err)
+	}
+	if _, _, err := client.Provenance(context.Background(), host+"/org/app:broken"); err == nil {
+		t.Error("Expected an error when the registry fails")
+	}
+	if _, _, err := client.Provenance(context.Background(), host+"/other/app:signed"); err == nil {
+		t.Error("Expected an error when the registry denies anonymous access")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:a/b:pull,push"`)
+	if scheme != "Bearer" || params["realm"] != "https://auth.docker.io/token" || params["service"] != "registry.docker.io" || params["scope"] != "repository:a/b:pull,push" {
+		t.Errorf("Unexpected challenge: %s %v", scheme, params)
+	}
+	if scheme, _ := parseChallenge(`Basic realm="registry"`); scheme != "Basic" {
+		t.Errorf("Expected a Basic challenge, got %s", scheme)
+	}
+}
@@ -0,0 +1,106 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// pageSize is the number of events fetched from the store at a time.
+const pageSize = 500
+
+// Options selects the stored events to replay and how to send them.
+type Options struct {
+	Filters  store.QueryFilters
+	Channels []string      // Alert channels to send to (e.g. "slack"); empty sends to all
+	Limit    int           // Maximum number of events to replay; 0 replays all matching events
+	Interval time.Duration // Pause between events, to stay below the channels' rate limits
+	DryRun   bool          // Count the events that would be sent without sending them
+}
+
+// Failure is an event that could not be delivered to one or more channels.
+type Failure struct {
+	EventID string `json:"event_id"`
+	Error   string `json:"error"`
+}
+
+// Result summarizes a replay.
+type Result struct {
+	Matched  int       `json:"matched"`  // Events read from the store
+	Sent     int       `json:"sent"`     // Events delivered to every selected channel
+	Skipped  int       `json:"skipped"`  // Events excluded by the alert config's operation filter
+	Failures []Failure `json:"failures"` // Events that failed on at least one channel
+}
+
+// Run re-sends stored events matching opts through router, oldest first. Each event is
+// delivered synchronously so failures are reported; a failed event does not stop the replay.
+func Run(ctx context.Context, s store.Store, router *alerting.Router, opts Options) (*Result, error) {
+	if router == nil {
+		return nil, fmt.Errorf("no alert channels configured")
+	}
+	active := router.Channels()
+	for _, channel := range opts.Channels {
+		if !contains(active, channel) {
+			return nil, fmt.Errorf("alert channel %q is not configured (configured: %v)", channel, active)
+		}
+	}
+
+	result := &Result{Failures: []Failure{}}
+	for offset := 0; opts.Limit == 0 || offset < opts.Limit; offset += pageSize {
+		limit := pageSize
+		if opts.Limit > 0 && opts.Limit-offset < limit {
+			limit = opts.Limit - offset
+		}
+		page, err := s.QueryEvents(ctx, opts.Filters, store.PaginationParams{Limit: limit, Offset: offset}, store.SortOrderAsc)
+		if err != nil {
+			return result, fmt.Errorf("failed to query events: %w", err)
+		}
+
+		for _, event := range page.Events {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			result.Matched++
+			replayEvent(router, event, opts, result)
+		}
+		if len(page.Events) < limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// replayEvent delivers a single event and records the outcome in result.
+func replayEvent(router *alerting.Router, event *model.ChangeEvent, opts Options, result *Result) {
+	if !router.ShouldAlert(event) {
+		result.Skipped++
+		return
+	}
+	if opts.DryRun {
+		result.Sent++
+		return
+	}
+
+	if err := router.Deliver(event, opts.Channels); err != nil {
+		result.Failures = append(result.Failures, Failure{EventID: event.ID, Error: err.Error()})
+	} else {
+		result.Sent++
+	}
+	if opts.Interval > 0 {
+		time.Sleep(opts.Interval)
+	}
+}
+
+// contains reports whether value is one of values.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
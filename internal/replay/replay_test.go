@@ -0,0 +1,162 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// pagedStore serves events from a slice, honouring pagination. Only QueryEvents is implemented.
+type pagedStore struct {
+	store.Store
+	events  []*model.ChangeEvent
+	queries int
+}
+
+func (s *pagedStore) QueryEvents(ctx context.Context, filters store.QueryFilters, pagination store.PaginationParams, sortOrder store.SortOrder) (*store.QueryResult, error) {
+	s.queries++
+	if sortOrder != store.SortOrderAsc {
+		return nil, fmt.Errorf("replay should read oldest events first")
+	}
+	start := min(pagination.Offset, len(s.events))
+	end := min(start+pagination.Limit, len(s.events))
+	return &store.QueryResult{Events: s.events[start:end], Total: len(s.events)}, nil
+}
+
+// newEvents returns n events with IDs e0, e1, ... and the given operation.
+func newEvents(n int, operation string) []*model.ChangeEvent {
+	events := make([]*model.ChangeEvent, n)
+	for i := range events {
+		events[i] = &model.ChangeEvent{ID: fmt.Sprintf("e%d", i), Operation: operation}
+	}
+	return events
+}
+
+// alertReceiver is a webhook alert channel that records received event IDs and
+// fails the events listed in fail.
+type alertReceiver struct {
+	mu       sync.Mutex
+	received []string
+	fail     map[string]bool
+}
+
+func (a *alertReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var event model.ChangeEvent
+	json.NewDecoder(r.Body).Decode(&event)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.received = append(a.received, event.ID)
+	if a.fail[event.ID] {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+}
+
+// newRouter returns a router with a webhook channel delivering to receiver.
+func newRouter(t *testing.T, receiver *alertReceiver, operations []string) *alerting.Router {
+	t.Helper()
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+	router, err := alerting.NewRouter(&alerting.Config{
+		Webhook:    &alerting.WebhookConfig{URL: server.URL},
+		Operations: operations,
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	return router
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		events          []*model.ChangeEvent
+		operations      []string
+		opts            Options
+		fail            map[string]bool
+		expectedMatched int
+		expectedSent    int
+		expectedSkipped int
+		expectedFailed  int
+		expectedAlerts  int
+	}{
+		{
+			name:            "all events",
+			events:          newEvents(3, "UPDATE"),
+			expectedMatched: 3, expectedSent: 3, expectedAlerts: 3,
+		},
+		{
+			name:            "spans several pages",
+			events:          newEvents(pageSize+10, "UPDATE"),
+			expectedMatched: pageSize + 10, expectedSent: pageSize + 10, expectedAlerts: pageSize + 10,
+		},
+		{
+			name:            "limit",
+			events:          newEvents(10, "UPDATE"),
+			opts:            Options{Limit: 4},
+			expectedMatched: 4, expectedSent: 4, expectedAlerts: 4,
+		},
+		{
+			name:            "operation filter of the alert config",
+			events:          newEvents(2, "UPDATE"),
+			operations:      []string{"DELETE"},
+			expectedMatched: 2, expectedSkipped: 2,
+		},
+		{
+			name:            "failures do not stop the replay",
+			events:          newEvents(3, "UPDATE"),
+			fail:            map[string]bool{"e1": true},
+			expectedMatched: 3, expectedSent: 2, expectedFailed: 1, expectedAlerts: 3,
+		},
+		{
+			name:            "dry run",
+			events:          newEvents(3, "UPDATE"),
+			opts:            Options{DryRun: true},
+			expectedMatched: 3, expectedSent: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &alertReceiver{fail: tt.fail}
+			router := newRouter(t, receiver, tt.operations)
+
+			result, err := Run(context.Background(), &pagedStore{events: tt.events}, router, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.Matched != tt.expectedMatched || result.Sent != tt.expectedSent ||
+				result.Skipped != tt.expectedSkipped || len(result.Failures) != tt.expectedFailed {
+				t.Errorf("Run() = matched %d, sent %d, skipped %d, failed %d; want %d, %d, %d, %d",
+					result.Matched, result.Sent, result.Skipped, len(result.Failures),
+					tt.expectedMatched, tt.expectedSent, tt.expectedSkipped, tt.expectedFailed)
+			}
+			if len(receiver.received) != tt.expectedAlerts {
+				t.Errorf("channel received %d alerts, want %d", len(receiver.received), tt.expectedAlerts)
+			}
+		})
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	s := &pagedStore{events: newEvents(1, "UPDATE")}
+
+	if _, err := Run(context.Background(), s, nil, Options{}); err == nil {
+		t.Error("Expected an error without alert channels")
+	}
+
+	router := newRouter(t, &alertReceiver{}, nil)
+	if _, err := Run(context.Background(), s, router, Options{Channels: []string{"slack"}}); err == nil {
+		t.Error("Expected an error for a channel that is not configured")
+	}
+	if s.queries != 0 {
+		t.Error("Store should not be queried when the options are invalid")
+	}
+}
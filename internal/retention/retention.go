@@ -0,0 +1,106 @@
+// Package retention purges the change events older than the retention, so the event table
+// doesn't grow unbounded. The retention is RETENTION_DAYS, unless one is set through the
+// admin API; events covered by an active legal hold are kept whatever their age.
+package retention
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+const (
+	// Interval is how often the events older than the retention are purged.
+	Interval = time.Hour
+
+	// batchSize is the number of events deleted per transaction, keeping locks short.
+	batchSize = 1000
+)
+
+var (
+	// purgedEvents counts the events purged by retention.
+	purgedEvents = expvar.NewInt("retention_purged_events_total")
+
+	// lastPurge is the Unix time of the last purge that succeeded.
+	lastPurge = expvar.NewInt("retention_last_purge_timestamp_seconds")
+)
+
+// Purger deletes the events older than the retention periodically.
+type Purger struct {
+	store       store.RetentionStore
+	defaultDays int // RETENTION_DAYS, used unless a retention is set through the admin API
+}
+
+// NewPurger creates a purger of the events of s, keeping them defaultDays unless a
+// retention is set through the admin API; 0 keeps them forever.
+func NewPurger(s store.RetentionStore, defaultDays int) *Purger {
+	return &Purger{store: s, defaultDays: defaultDays}
+}
+
+// Days returns the retention in effect: the one set through the admin API, if any, else
+// the default. 0 keeps events forever.
+func (p *Purger) Days(ctx context.Context) (int, error) {
+	policy, err := p.store.GetRetentionPolicy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if policy != nil {
+		return policy.Days, nil
+	}
+	return p.defaultDays, nil
+}
+
+// Start purges the events older than the retention now, then every Interval, until ctx is
+// cancelled.
+func (p *Purger) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(Interval)
+		defer ticker.Stop()
+		for {
+			if purged, err := p.Purge(ctx, time.Now()); err != nil {
+				klog.Warningf("Failed to purge events older than the retention: %v", err)
+			} else if purged > 0 {
+				klog.Infof("Purged %d events older than the retention", purged)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Purge deletes the events stored more than the retention before now, in batches, and
+// returns how many it deleted. It deletes nothing when events are kept forever.
+func (p *Purger) Purge(ctx context.Context, now time.Time) (int64, error) {
+	days, err := p.Days(ctx)
+	if err != nil || days == 0 {
+		return 0, err
+	}
+
+	before := now.AddDate(0, 0, -days)
+	var total int64
+	for ctx.Err() == nil {
+		purged, err := p.store.PurgeEvents(ctx, before, batchSize)
+		total += purged
+		purgedEvents.Add(purged)
+		if errors.Is(err, store.ErrPurgeInProgress) {
+			// Another replica is purging, so this one didn't purge
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+		if purged < batchSize {
+			lastPurge.Set(now.Unix())
+			break
+		}
+	}
+	return total, nil
+}
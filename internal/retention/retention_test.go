@@ -0,0 +1,117 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeRetentionStore is an in-memory store.RetentionStore of events stored at times.
+type fakeRetentionStore struct {
+	policy *store.RetentionPolicy
+	events []time.Time
+	purges int  // Calls of PurgeEvents
+	locked bool // Another process purges events
+	err    error
+}
+
+func (f *fakeRetentionStore) GetRetentionPolicy(ctx context.Context) (*store.RetentionPolicy, error) {
+	return f.policy, f.err
+}
+
+func (f *fakeRetentionStore) SetRetentionPolicy(ctx context.Context, policy *store.RetentionPolicy) error {
+	f.policy = policy
+	return nil
+}
+
+func (f *fakeRetentionStore) DeleteRetentionPolicy(ctx context.Context) error {
+	f.policy = nil
+	return nil
+}
+
+func (f *fakeRetentionStore) PurgeEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	f.purges++
+	if f.locked {
+		return 0, store.ErrPurgeInProgress
+	}
+	var kept []time.Time
+	var purged int64
+	for _, timestamp := range f.events {
+		if timestamp.Before(before) && purged < int64(limit) {
+			purged++
+			continue
+		}
+		kept = append(kept, timestamp)
+	}
+	f.events = kept
+	return purged, nil
+}
+
+func TestPurger_Purge(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeRetentionStore{}
+	for i := 0; i < batchSize+10; i++ {
+		fake.events = append(fake.events, now.AddDate(0, 0, -40))
+	}
+	fake.events = append(fake.events, now.AddDate(0, 0, -10))
+
+	// Events are kept forever without a retention
+	purger := NewPurger(fake, 0)
+	if purged, err := purger.Purge(context.Background(), now); err != nil || purged != 0 || fake.purges != 0 {
+		t.Fatalf("Purge() = %d, %v after %d purges, want nothing purged", purged, err, fake.purges)
+	}
+
+	purger = NewPurger(fake, 30)
+	purged, err := purger.Purge(context.Background(), now)
+	if err != nil || purged != batchSize+10 {
+		t.Fatalf("Purge() = %d, %v, want %d", purged, err, batchSize+10)
+	}
+	if fake.purges != 2 || len(fake.events) != 1 {
+		t.Errorf("Expected 2 batches keeping 1 event, got %d batches keeping %d", fake.purges, len(fake.events))
+	}
+}
+
+func TestPurger_PurgeInProgress(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	fake := &fakeRetentionStore{events: []time.Time{now.AddDate(0, 0, -40)}, locked: true}
+	lastPurge.Set(0)
+
+	purged, err := NewPurger(fake, 30).Purge(context.Background(), now)
+	if err != nil || purged != 0 {
+		t.Fatalf("Purge() = %d, %v, want nothing purged while another process purges", purged, err)
+	}
+	if lastPurge.Value() != 0 {
+		t.Errorf("Expected the last purge not to be updated, got %d", lastPurge.Value())
+	}
+
+	fake.locked = false
+	if _, err := NewPurger(fake, 30).Purge(context.Background(), now); err != nil || lastPurge.Value() != now.Unix() {
+		t.Errorf("Expected the last purge to be updated, got %d, %v", lastPurge.Value(), err)
+	}
+}
+
+func TestPurger_Days(t *testing.T) {
+	fake := &fakeRetentionStore{}
+	purger := NewPurger(fake, 90)
+	if days, err := purger.Days(context.Background()); err != nil || days != 90 {
+		t.Errorf("Days() = %d, %v, want the default 90", days, err)
+	}
+
+	// The retention set through the admin API overrides the default, even to keep events forever
+	fake.policy = &store.RetentionPolicy{Days: 0}
+	if days, err := purger.Days(context.Background()); err != nil || days != 0 {
+		t.Errorf("Days() = %d, %v, want 0", days, err)
+	}
+	fake.events = []time.Time{time.Now().AddDate(-1, 0, 0)}
+	if purged, err := purger.Purge(context.Background(), time.Now()); err != nil || purged != 0 {
+		t.Errorf("Purge() = %d, %v, want nothing purged", purged, err)
+	}
+
+	fake.err = errors.New("connection refused")
+	if _, err := purger.Purge(context.Background(), time.Now()); err == nil {
+		t.Error("Expected the error reading the retention to be returned")
+	}
+}
@@ -0,0 +1,225 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind is the kind of a query token.
+type tokenKind int
+
+const (
+	tokenWord   tokenKind = iota // A term, keyword or value
+	tokenQuoted                  // A quoted value
+	tokenOpen                    // (
+	tokenClose                   // )
+)
+
+// token is a token of a query, with its offset for error messages.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits a query into words, quoted values and parentheses. A quote starts a
+// quoted value at the start of a word or after field:, and \ escapes a character in it.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", pos: i})
+			i++
+		case c == '"':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(query) {
+					return nil, fmt.Errorf("unterminated quote at position %d", start)
+				}
+				if query[i] == '\\' && i+1 < len(query) {
+					i++
+				} else if query[i] == '"' {
+					i++
+					break
+				}
+				b.WriteByte(query[i])
+			}
+			tokens = append(tokens, token{kind: tokenQuoted, text: b.String(), pos: start})
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(" \t\n\r()", rune(query[i])) {
+				if query[i] == '"' && i > start && query[i-1] == ':' {
+					break // field:"quoted value"
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: query[start:i], pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+// parser parses the tokens of a query into clauses.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses a query into clauses, which are combined with AND.
+func parse(query string) ([]clause, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &parser{tokens: tokens}
+	var clauses []clause
+	for !p.done() {
+		if len(clauses) > 0 && p.keyword("AND") {
+			p.pos++
+		}
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, c)
+	}
+	return clauses, nil
+}
+
+// parseOr parses clauses combined with OR, which must be terms of the same field.
+func (p *parser) parseOr() (clause, error) {
+	c, err := p.parseClause()
+	if err != nil {
+		return clause{}, err
+	}
+	for p.keyword("OR") {
+		p.pos++
+		next, err := p.parseClause()
+		if err != nil {
+			return clause{}, err
+		}
+		if next.field != c.field || c.negated || next.negated {
+			return clause{}, fmt.Errorf("OR only combines values of the same field, e.g. kind:(Secret OR ConfigMap)")
+		}
+		c.values = append(c.values, next.values...)
+	}
+	return c, nil
+}
+
+// parseClause parses a possibly negated term, or clauses combined with OR in parentheses.
+func (p *parser) parseClause() (clause, error) {
+	negated := false
+	for p.keyword("NOT") {
+		negated = !negated
+		p.pos++
+	}
+	if p.done() {
+		return clause{}, fmt.Errorf("unexpected end of query")
+	}
+
+	tok := p.tokens[p.pos]
+	switch {
+	case tok.kind == tokenOpen:
+		p.pos++
+		c, err := p.parseOr()
+		if err != nil {
+			return clause{}, err
+		}
+		if err := p.expectClose(tok); err != nil {
+			return clause{}, err
+		}
+		c.negated = c.negated != negated
+		return c, nil
+	case tok.kind != tokenWord || p.keyword("AND") || p.keyword("OR"):
+		return clause{}, fmt.Errorf("expected field:value at position %d, got %q", tok.pos, tok.text)
+	}
+
+	text := tok.text
+	if strings.HasPrefix(text, "-") && len(text) > 1 {
+		negated = !negated
+		text = text[1:]
+	}
+	name, value, ok := strings.Cut(text, ":")
+	if !ok {
+		return clause{}, fmt.Errorf("expected field:value at position %d, got %q (free text search is not supported)", tok.pos, tok.text)
+	}
+	field, ok := canonicalField(name)
+	if !ok {
+		return clause{}, fmt.Errorf("unknown field %q; fields are %s", name, strings.Join(Fields, ", "))
+	}
+	p.pos++
+
+	c := clause{field: field, negated: negated}
+	if value != "" {
+		c.values = []string{value}
+		return c, nil
+	}
+	if p.done() {
+		return clause{}, fmt.Errorf("missing value of %s at position %d", name, tok.pos)
+	}
+	switch next := p.tokens[p.pos]; next.kind {
+	case tokenQuoted:
+		p.pos++
+		c.values = []string{next.text}
+	case tokenOpen:
+		p.pos++
+		values, err := p.parseValues(next)
+		if err != nil {
+			return clause{}, err
+		}
+		c.values = values
+	default:
+		return clause{}, fmt.Errorf("missing value of %s at position %d", name, tok.pos)
+	}
+	return c, nil
+}
+
+// parseValues parses values combined with OR, after the opening parenthesis open.
+func (p *parser) parseValues(open token) ([]string, error) {
+	var values []string
+	for {
+		if p.done() || (p.tokens[p.pos].kind != tokenWord && p.tokens[p.pos].kind != tokenQuoted) || p.keyword("OR") {
+			return nil, fmt.Errorf("expected a value in the parentheses at position %d", open.pos)
+		}
+		values = append(values, p.tokens[p.pos].text)
+		p.pos++
+		if !p.keyword("OR") {
+			break
+		}
+		p.pos++
+	}
+	if err := p.expectClose(open); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// expectClose consumes the parenthesis closing open.
+func (p *parser) expectClose(open token) error {
+	if p.done() || p.tokens[p.pos].kind != tokenClose {
+		return fmt.Errorf("unclosed parenthesis at position %d", open.pos)
+	}
+	p.pos++
+	return nil
+}
+
+// keyword reports whether the current token is the keyword (case-insensitive).
+func (p *parser) keyword(keyword string) bool {
+	return !p.done() && p.tokens[p.pos].kind == tokenWord && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+// done reports whether all tokens were parsed.
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
@@ -0,0 +1,203 @@
+// Package search parses the query language of the API's query= parameter, a small subset
+// of KQL, into store filters:
+//
+//	kind:Secret AND namespace:prod* AND NOT user:system:*
+//	op:(DELETE OR UPDATE) -namespace:kube-system since:24h
+//
+// A query is a list of terms, field:value, joined by AND (which may be left out). A term
+// is negated with NOT or a leading -. OR combines values of the same field, either in
+// parentheses after the field or between terms of the field, since the store filters
+// only combine different fields with AND. Values may be quoted and use * wildcards.
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Fields lists the fields a query can filter on, with their aliases.
+var Fields = []string{
+	"kind (resource_kind)", "namespace (ns)", "name", "user (username)", "op (operation)",
+	"group", "node", "allowed", "risk", "since", "until",
+}
+
+// valueFields are the fields supporting multiple values and negation, with their filters.
+var valueFields = map[string]func(f *store.QueryFilters) (single *string, multi, exclude *[]string){
+	"kind": func(f *store.QueryFilters) (*string, *[]string, *[]string) {
+		return &f.ResourceKind, &f.ResourceKinds, &f.ExcludeResourceKinds
+	},
+	"namespace": func(f *store.QueryFilters) (*string, *[]string, *[]string) {
+		return &f.Namespace, &f.Namespaces, &f.ExcludeNamespaces
+	},
+	"name": func(f *store.QueryFilters) (*string, *[]string, *[]string) {
+		return &f.Name, &f.Names, &f.ExcludeNames
+	},
+	"user": func(f *store.QueryFilters) (*string, *[]string, *[]string) {
+		return &f.Username, &f.Usernames, &f.ExcludeUsernames
+	},
+	"op": func(f *store.QueryFilters) (*string, *[]string, *[]string) {
+		return &f.Operation, &f.Operations, &f.ExcludeOperations
+	},
+}
+
+// aliases maps the alternative names of fields to their canonical name.
+var aliases = map[string]string{
+	"resource_kind": "kind",
+	"ns":            "namespace",
+	"username":      "user",
+	"operation":     "op",
+}
+
+// clause is a term, or terms of one field combined with OR, possibly negated.
+type clause struct {
+	field   string
+	values  []string
+	negated bool
+}
+
+// Apply parses query and adds its filters to filters. Relative times (since:24h, since:7d)
+// are relative to now. It fails if the query is invalid, or filters on a field filters
+// already filter on, e.g. through another query parameter.
+func Apply(query string, filters *store.QueryFilters, now time.Time) error {
+	clauses, err := parse(query)
+	if err != nil {
+		return err
+	}
+	for _, c := range clauses {
+		if err := apply(c, filters, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds the filter of a clause to filters.
+func apply(c clause, filters *store.QueryFilters, now time.Time) error {
+	if fieldFilters, ok := valueFields[c.field]; ok {
+		single, multi, exclude := fieldFilters(filters)
+		values := c.values
+		if c.field == "op" {
+			for i, value := range values {
+				values[i] = strings.ToUpper(value)
+			}
+		}
+		if c.negated {
+			*exclude = append(*exclude, values...)
+			return nil
+		}
+		if *single != "" || len(*multi) > 0 {
+			return fmt.Errorf("%s is filtered on more than once; combine the values with OR", c.field)
+		}
+		if len(values) == 1 {
+			*single = values[0]
+		} else {
+			*multi = values
+		}
+		return nil
+	}
+
+	if len(c.values) > 1 {
+		return fmt.Errorf("%s takes a single value", c.field)
+	}
+	value := c.values[0]
+	if c.negated && c.field != "allowed" {
+		return fmt.Errorf("%s can't be negated", c.field)
+	}
+	switch c.field {
+	case "group":
+		return setOnce(c.field, &filters.Group, value)
+	case "node":
+		return setOnce(c.field, &filters.Node, value)
+	case "allowed":
+		allowed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("allowed must be true or false, got %q", value)
+		}
+		if c.negated {
+			allowed = !allowed
+		}
+		if filters.Allowed != nil {
+			return fmt.Errorf("allowed is filtered on more than once")
+		}
+		filters.Allowed = &allowed
+	case "risk":
+		score, err := strconv.Atoi(strings.TrimPrefix(value, ">="))
+		if err != nil || score <= 0 {
+			return fmt.Errorf("risk must be a minimum score such as risk:>=70, got %q", value)
+		}
+		if filters.MinRiskScore != 0 {
+			return fmt.Errorf("risk is filtered on more than once")
+		}
+		filters.MinRiskScore = score
+	case "since", "until":
+		t, err := ParseTime(value, now)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", c.field, err)
+		}
+		target := &filters.StartTime
+		if c.field == "until" {
+			target = &filters.EndTime
+		}
+		if *target != nil {
+			return fmt.Errorf("%s is filtered on more than once", c.field)
+		}
+		*target = &t
+	}
+	return nil
+}
+
+// setOnce sets a single-value filter, failing if it is already set.
+func setOnce(field string, target *string, value string) error {
+	if *target != "" {
+		return fmt.Errorf("%s is filtered on more than once", field)
+	}
+	*target = value
+	return nil
+}
+
+// ParseTime parses an RFC 3339 time, or a duration before now such as 90m, 24h or 7d
+// (see ParseDuration).
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration such as 24h or 7d", value)
+	}
+	return now.Add(-d), nil
+}
+
+// ParseDuration parses a non-negative duration, in days (7d) or in Go's duration format
+// (90m, 24h, 1h30m).
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("%q is not a duration such as 24h or 7d", value)
+}
+
+// canonicalField returns the canonical name of a field, and false if it is unknown.
+func canonicalField(field string) (string, bool) {
+	field = strings.ToLower(field)
+	if canonical, ok := aliases[field]; ok {
+		field = canonical
+	}
+	if _, ok := valueFields[field]; ok {
+		return field, true
+	}
+	switch field {
+	case "group", "node", "allowed", "risk", "since", "until":
+		return field, true
+	}
+	return "", false
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+var testNow = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func TestApply(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	timePtr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		query string
+		want  store.QueryFilters
+	}{
+		{
+			query: "kind:Secret AND namespace:prod* AND NOT user:system:*",
+			want:  store.QueryFilters{ResourceKind: "Secret", Namespace: "prod*", ExcludeUsernames: []string{"system:*"}},
+		},
+		{
+			query: "op:(delete OR update) -ns:kube-system -ns:kube-public",
+			want:  store.QueryFilters{Operations: []string{"DELETE", "UPDATE"}, ExcludeNamespaces: []string{"kube-system", "kube-public"}},
+		},
+		{
+			query: "kind:Secret or kind:ConfigMap and name:\"my app\"",
+			want:  store.QueryFilters{ResourceKinds: []string{"Secret", "ConfigMap"}, Name: "my app"},
+		},
+		{
+			query: "NOT (namespace:a OR namespace:b) user:alice",
+			want:  store.QueryFilters{ExcludeNamespaces: []string{"a", "b"}, Username: "alice"},
+		},
+		{
+			query: "NOT allowed:true group:sre node:worker-* risk:>=70",
+			want:  store.QueryFilters{Allowed: boolPtr(false), Group: "sre", Node: "worker-*", MinRiskScore: 70},
+		},
+		{
+			query: "since:7d until:2024-01-15T00:00:00Z",
+			want:  store.QueryFilters{StartTime: timePtr(testNow.AddDate(0, 0, -7)), EndTime: timePtr(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))},
+		},
+		{
+			query: "since:90m name:(\"a b\" OR c\\d)",
+			want:  store.QueryFilters{StartTime: timePtr(testNow.Add(-90 * time.Minute)), Names: []string{"a b", `c\d`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got store.QueryFilters
+			if err := Apply(tt.query, &got, testNow); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "empty query"},
+		{"secret", "free text search is not supported"},
+		{"color:red", "unknown field"},
+		{"kind:Secret OR namespace:prod", "same field"},
+		{"NOT kind:Secret OR kind:ConfigMap", "same field"},
+		{"kind:Secret kind:ConfigMap", "more than once"},
+		{"kind:(Secret", "unclosed parenthesis"},
+		{"kind:()", "expected a value"},
+		{"kind:", "missing value"},
+		{`name:"open`, "unterminated quote"},
+		{"kind:Secret AND", "unexpected end"},
+		{"AND kind:Secret", "expected field:value"},
+		{"NOT since:1h", "can't be negated"},
+		{"group:(a OR b)", "single value"},
+		{"allowed:maybe", "true or false"},
+		{"risk:high", "minimum score"},
+		{"since:yesterday", "invalid since"},
+		{"kind:Secret)", "expected field:value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var filters store.QueryFilters
+			err := Apply(tt.query, &filters, testNow)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Apply() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_ConflictsWithFilters(t *testing.T) {
+	filters := store.QueryFilters{Namespace: "payments"}
+	if err := Apply("namespace:billing", &filters, testNow); err == nil {
+		t.Error("Expected an error when the query filters on a field already filtered on")
+	}
+
+	filters = store.QueryFilters{Namespace: "payments"}
+	if err := Apply("kind:Secret -namespace:payments-canary", &filters, testNow); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if filters.Namespace != "payments" || filters.ResourceKind != "Secret" || len(filters.ExcludeNamespaces) != 1 {
+		t.Errorf("Expected the query added to the filters, got %+v", filters)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"7d":    7 * 24 * time.Hour,
+		"0d":    0,
+		"24h":   24 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+	for value, want := range tests {
+		if got, err := ParseDuration(value); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "-1h", "-2d", "d", "1.5d", "week"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package sources tracks the latest event received from each event source (the admission
+// webhook, the audit processor and the ingest API) in each cluster, so a source that goes
+// silent, most often a webhook the API server stopped calling, is noticed.
+package sources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Event sources.
+const (
+	Webhook        = "webhook"         // Admission webhook
+	AuditProcessor = "audit-processor" // Audit log processor
+	IngestAPI      = "ingest-api"      // CI/CD and cloud provider webhooks received by the API server
+)
+
+// DefaultCluster is the cluster of sources when none is configured.
+const DefaultCluster = "default"
+
+// DefaultStaleAfter is how long a source may go without events before it is stale.
+const DefaultStaleAfter = time.Hour
+
+// Health statuses of sources.
+const (
+	StatusOK       = "ok"
+	StatusStale    = "stale"
+	StatusDisabled = "unmonitored" // Staleness isn't checked for the source
+)
+
+// flushInterval is how often the activity of a source is written to the store.
+const flushInterval = 30 * time.Second
+
+// flushTimeout bounds writing the activity of a source.
+const flushTimeout = 10 * time.Second
+
+// Config names the cluster of the sources and sets when they are stale.
+type Config struct {
+	// Cluster is the name of this cluster, recorded with the activity of its sources
+	// (default: "default").
+	Cluster string `json:"cluster,omitempty"`
+
+	// StaleAfter is how long a source may go without events before it is stale, e.g.
+	// "30m" (default: 1h).
+	StaleAfter string `json:"stale_after,omitempty"`
+
+	// Sources override StaleAfter per source, e.g. {"ingest-api": "24h"}; "0" doesn't
+	// check the source.
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// Validate checks the durations.
+func (c *Config) Validate() error {
+	if c.StaleAfter != "" {
+		if d, err := time.ParseDuration(c.StaleAfter); err != nil || d <= 0 {
+			return fmt.Errorf("invalid stale_after %q: must be a positive duration", c.StaleAfter)
+		}
+	}
+	for source, value := range c.Sources {
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("invalid stale_after %q of source %s: must be a duration", value, source)
+		}
+	}
+	return nil
+}
+
+// ClusterName returns the name of this cluster. A nil config returns DefaultCluster.
+func (c *Config) ClusterName() string {
+	if c == nil || c.Cluster == "" {
+		return DefaultCluster
+	}
+	return c.Cluster
+}
+
+// StaleAfterFor returns how long a source may go without events, or 0 if it isn't checked.
+// A nil config returns DefaultStaleAfter.
+func (c *Config) StaleAfterFor(source string) time.Duration {
+	if c == nil {
+		return DefaultStaleAfter
+	}
+	if value, ok := c.Sources[source]; ok {
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	if d, err := time.ParseDuration(c.StaleAfter); err == nil && d > 0 {
+		return d
+	}
+	return DefaultStaleAfter
+}
+
+// Health is the health of a source in a cluster.
+type Health struct {
+	*store.SourceActivity
+	Status     string `json:"status"`                // StatusOK, StatusStale or StatusDisabled
+	StaleAfter string `json:"stale_after,omitempty"` // Silence after which the source is stale
+	Silence    string `json:"silence"`               // Time since the latest event
+}
+
+// Evaluate returns the health of each source at now, stale sources first.
+func Evaluate(activity []*store.SourceActivity, cfg *Config, now time.Time) []*Health {
+	health := make([]*Health, 0, len(activity))
+	for _, a := range activity {
+		silence := now.Sub(a.LastEventAt)
+		if silence < 0 {
+			silence = 0
+		}
+		h := &Health{SourceActivity: a, Status: StatusOK, Silence: silence.Round(time.Second).String()}
+		staleAfter := cfg.StaleAfterFor(a.Source)
+		switch {
+		case staleAfter == 0:
+			h.Status = StatusDisabled
+		case silence > staleAfter:
+			h.Status, h.StaleAfter = StatusStale, staleAfter.String()
+		default:
+			h.StaleAfter = staleAfter.String()
+		}
+		health = append(health, h)
+	}
+	sort.SliceStable(health, func(i, j int) bool {
+		return health[i].Status == StatusStale && health[j].Status != StatusStale
+	})
+	return health
+}
+
+// Tracker counts the events a source receives and writes its activity to the store
+// periodically.
+type Tracker struct {
+	source  string
+	cluster string
+	store   store.SourceStore
+
+	mu      sync.Mutex
+	pending *store.SourceActivity // Activity since the last write; nil without events
+}
+
+// NewTracker creates a tracker of the events received by source in cluster.
+func NewTracker(source, cluster string, activity store.SourceStore) *Tracker {
+	return &Tracker{source: source, cluster: cluster, store: activity}
+}
+
+// Observe counts an event. Self-monitoring and heartbeat events are kubechronicle's own and
+// not counted, so a source receiving nothing else still goes stale.
+func (t *Tracker) Observe(event *model.ChangeEvent) {
+	if event.Operation == "SELF_MONITORING" || event.Operation == model.OperationHeartbeat {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending == nil {
+		t.pending = &store.SourceActivity{Source: t.source, Cluster: t.cluster}
+	}
+	t.pending.Events++
+	t.pending.LastEventAt = time.Now().UTC()
+	t.pending.LastEventID = event.ID
+}
+
+// Start writes the activity to the store every 30 seconds until ctx is cancelled, then
+// writes it a last time.
+func (t *Tracker) Start(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				t.flush()
+				return
+			case <-ticker.C:
+				t.flush()
+			}
+		}
+	}()
+}
+
+// flush writes the activity since the last write. If it fails, the activity is kept for
+// the next write.
+func (t *Tracker) flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if pending == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	if err := t.store.RecordSourceActivity(ctx, []*store.SourceActivity{pending}); err != nil {
+		klog.Warningf("Failed to record the activity of source %s: %v", t.source, err)
+		t.mu.Lock()
+		if t.pending == nil {
+			t.pending = pending
+		} else {
+			t.pending.Events += pending.Events
+			if pending.LastEventAt.After(t.pending.LastEventAt) {
+				t.pending.LastEventAt, t.pending.LastEventID = pending.LastEventAt, pending.LastEventID
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// trackingStore counts the events saved as received from a source.
+type trackingStore struct {
+	store.Store
+	tracker *Tracker
+}
+
+// WrapStore returns a store counting the events saved with tracker, whether or not saving
+// succeeds, as the source did receive them. If no tracker is configured, the store is
+// returned unchanged.
+func WrapStore(s store.Store, tracker *Tracker) store.Store {
+	if s == nil || tracker == nil {
+		return s
+	}
+	return &trackingStore{Store: s, tracker: tracker}
+}
+
+// Save counts the event, then persists it.
+func (s *trackingStore) Save(event *model.ChangeEvent) error {
+	s.tracker.Observe(event)
+	return s.Store.Save(event)
+}
@@ -0,0 +1,124 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// fakeSourceStore is a store.Store and store.SourceStore recording activity in memory.
+type fakeSourceStore struct {
+	store.Store
+	recorded []*store.SourceActivity
+	saved    int
+	err      error
+}
+
+func (f *fakeSourceStore) Save(event *model.ChangeEvent) error {
+	f.saved++
+	return nil
+}
+
+func (f *fakeSourceStore) RecordSourceActivity(ctx context.Context, activity []*store.SourceActivity) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.recorded = append(f.recorded, activity...)
+	return nil
+}
+
+func (f *fakeSourceStore) ListSourceActivity(ctx context.Context) ([]*store.SourceActivity, error) {
+	return f.recorded, nil
+}
+
+func TestConfig(t *testing.T) {
+	var unset *Config
+	if unset.ClusterName() != DefaultCluster || unset.StaleAfterFor(Webhook) != DefaultStaleAfter {
+		t.Errorf("Expected the defaults without a config")
+	}
+
+	cfg := &Config{Cluster: "prod-eu", StaleAfter: "30m", Sources: map[string]string{IngestAPI: "0", AuditProcessor: "2h"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for source, want := range map[string]time.Duration{Webhook: 30 * time.Minute, AuditProcessor: 2 * time.Hour, IngestAPI: 0} {
+		if got := cfg.StaleAfterFor(source); got != want {
+			t.Errorf("StaleAfterFor(%s) = %v, want %v", source, got, want)
+		}
+	}
+
+	for _, invalid := range []*Config{{StaleAfter: "soon"}, {StaleAfter: "0"}, {Sources: map[string]string{Webhook: "-1m"}}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", invalid)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	activity := []*store.SourceActivity{
+		{Source: AuditProcessor, Cluster: "prod", LastEventAt: now.Add(-10 * time.Minute)},
+		{Source: Webhook, Cluster: "prod", LastEventAt: now.Add(-2 * time.Hour)},
+		{Source: IngestAPI, Cluster: "prod", LastEventAt: now.Add(-72 * time.Hour)},
+	}
+	health := Evaluate(activity, &Config{Sources: map[string]string{IngestAPI: "0"}}, now)
+
+	if len(health) != 3 || health[0].Source != Webhook || health[0].Status != StatusStale {
+		t.Fatalf("Expected the stale webhook first, got %+v", health)
+	}
+	if health[0].Silence != "2h0m0s" || health[0].StaleAfter != "1h0m0s" {
+		t.Errorf("Unexpected silence of the webhook: %+v", health[0])
+	}
+	if health[1].Source != AuditProcessor || health[1].Status != StatusOK {
+		t.Errorf("Expected the audit processor ok, got %+v", health[1])
+	}
+	if health[2].Source != IngestAPI || health[2].Status != StatusDisabled || health[2].StaleAfter != "" {
+		t.Errorf("Expected the ingest API unmonitored, got %+v", health[2])
+	}
+}
+
+func TestTracker(t *testing.T) {
+	backend := &fakeSourceStore{err: errors.New("connection refused")}
+	tracker := NewTracker(Webhook, "prod", backend)
+	s := WrapStore(backend, tracker)
+
+	for _, event := range []*model.ChangeEvent{
+		{ID: "a", Operation: "CREATE"},
+		{ID: "monitor", Operation: "SELF_MONITORING"},
+		{ID: "heartbeat", Operation: model.OperationHeartbeat},
+		{ID: "b", Operation: "UPDATE"},
+	} {
+		if err := s.Save(event); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if backend.saved != 4 {
+		t.Errorf("Expected every event saved, got %d", backend.saved)
+	}
+
+	// Activity is kept while it can't be recorded, and added to later activity
+	tracker.flush()
+	s.Save(&model.ChangeEvent{ID: "c", Operation: "DELETE"})
+	backend.err = nil
+	tracker.flush()
+	if len(backend.recorded) != 1 {
+		t.Fatalf("Expected 1 record, got %+v", backend.recorded)
+	}
+	if a := backend.recorded[0]; a.Source != Webhook || a.Cluster != "prod" || a.Events != 3 || a.LastEventID != "c" || a.LastEventAt.IsZero() {
+		t.Errorf("Unexpected activity: %+v", a)
+	}
+
+	// Nothing is recorded without events
+	tracker.flush()
+	if len(backend.recorded) != 1 {
+		t.Errorf("Expected no record without events, got %d", len(backend.recorded))
+	}
+
+	if WrapStore(backend, nil) != store.Store(backend) {
+		t.Error("WrapStore() without a tracker should return the store")
+	}
+}
@@ -0,0 +1,136 @@
+// Package spool keeps change events on disk while the store is unavailable, so they can be
+// saved once it is reachable again instead of being lost.
+package spool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Spool is a directory of change events waiting to be saved, one JSON file per event.
+// Files are named after the time they were spooled, so they are drained in order.
+type Spool struct {
+	dir string
+	mu  sync.Mutex // Serializes drains, so an event is not saved twice
+}
+
+// New creates a spool in dir, creating the directory if needed. Events spooled before a
+// restart are kept.
+func New(dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return &Spool{dir: dir}, nil
+}
+
+// Dir returns the directory of the spool.
+func (s *Spool) Dir() string {
+	return s.dir
+}
+
+// Put writes an event to the spool. The file is written under a temporary name and then
+// renamed, so a crash never leaves a partial event to be drained.
+func (s *Spool) Put(event *model.ChangeEvent) error {
+	content, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	name := fmt.Sprintf("%020d-%s.json", time.Now().UnixNano(), sanitize(event.ID))
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of spooled events.
+func (s *Spool) Len() (int, error) {
+	files, err := s.files()
+	return len(files), err
+}
+
+// Drain saves the spooled events in order with save, removing each one saved. It stops at
+// the first failed save, leaving it and the later events spooled, and returns the number of
+// events saved. Files that aren't valid events are renamed with an .invalid suffix.
+func (s *Spool) Drain(save func(*model.ChangeEvent) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	for _, name := range files {
+		path := filepath.Join(s.dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return saved, fmt.Errorf("failed to read spool file: %w", err)
+		}
+		var event model.ChangeEvent
+		if err := json.Unmarshal(content, &event); err != nil {
+			klog.Errorf("Spool file %s is not a valid event, setting it aside: %v", name, err)
+			os.Rename(path, path+".invalid")
+			continue
+		}
+		if err := save(&event); err != nil {
+			return saved, err
+		}
+		if err := os.Remove(path); err != nil {
+			return saved, fmt.Errorf("failed to remove spool file: %w", err)
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// files returns the names of the spooled event files, oldest first.
+func (s *Spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") && !strings.HasPrefix(entry.Name(), ".") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// sanitize makes an event ID safe to use in a file name.
+func sanitize(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, id)
+}
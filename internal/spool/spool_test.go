@@ -0,0 +1,88 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestSpool_PutDrain(t *testing.T) {
+	s, err := New(filepath.Join(t.TempDir(), "spool"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	for _, id := range []string{"event-1", "event/2", "event-3"} {
+		event := &model.ChangeEvent{ID: id, Timestamp: time.Now(), Operation: "CREATE", ResourceKind: "Deployment", Namespace: "default", Name: "app"}
+		if err := s.Put(event); err != nil {
+			t.Fatalf("Put(%s) error = %v", id, err)
+		}
+	}
+	if n, err := s.Len(); err != nil || n != 3 {
+		t.Fatalf("Len() = %d, %v, want 3", n, err)
+	}
+
+	// A failed save stops the drain, keeping the event and the later ones
+	var saved []string
+	failing := errors.New("store unavailable")
+	n, err := s.Drain(func(event *model.ChangeEvent) error {
+		if event.ID == "event/2" {
+			return failing
+		}
+		saved = append(saved, event.ID)
+		return nil
+	})
+	if !errors.Is(err, failing) || n != 1 {
+		t.Fatalf("Drain() = %d, %v, want 1 and the save error", n, err)
+	}
+	if remaining, _ := s.Len(); remaining != 2 {
+		t.Errorf("Len() after failed drain = %d, want 2", remaining)
+	}
+
+	n, err = s.Drain(func(event *model.ChangeEvent) error {
+		saved = append(saved, event.ID)
+		return nil
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("Drain() = %d, %v, want 2", n, err)
+	}
+	want := []string{"event-1", "event/2", "event-3"}
+	if len(saved) != len(want) {
+		t.Fatalf("saved = %v, want %v", saved, want)
+	}
+	for i := range want {
+		if saved[i] != want[i] {
+			t.Errorf("saved = %v, want %v in spool order", saved, want)
+			break
+		}
+	}
+	if remaining, _ := s.Len(); remaining != 0 {
+		t.Errorf("Len() after drain = %d, want 0", remaining)
+	}
+}
+
+func TestSpool_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000001-bad.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.Drain(func(*model.ChangeEvent) error { return nil })
+	if err != nil || n != 0 {
+		t.Fatalf("Drain() = %d, %v, want 0", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00000000000000000001-bad.json.invalid")); err != nil {
+		t.Errorf("Invalid file should be set aside: %v", err)
+	}
+	if remaining, _ := s.Len(); remaining != 0 {
+		t.Errorf("Len() = %d, want 0", remaining)
+	}
+}
@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"k8s.io/klog/v2"
+)
+
+// ErrUnavailable is returned by saves short-circuited while the database is unreachable.
+var ErrUnavailable = errors.New("database unavailable, circuit breaker open")
+
+// ErrDuplicate is returned by saves of events already stored, such as retried admission
+// requests. Nothing was written, so the event mustn't be exported, delivered or alerted on.
+var ErrDuplicate = errors.New("event is already stored")
+
+// breakerThreshold is the number of consecutive failed saves that opens the circuit breaker.
+const breakerThreshold = 5
+
+var (
+	breakerCooldown       = 10 * time.Second // How long saves are short-circuited before one is let through
+	healthMonitorInterval = 5 * time.Second  // How often the health monitor pings the database
+)
+
+// Circuit breaker metrics: whether saves are short-circuited, how often the breaker opened,
+// the saves it short-circuited, and how often the database became reachable again.
+var (
+	circuitOpen         = expvar.NewInt("store_circuit_open")
+	circuitOpens        = expvar.NewInt("store_circuit_opens_total")
+	shortCircuitedSaves = expvar.NewInt("store_short_circuited_saves_total")
+	storeRecoveries     = expvar.NewInt("store_recoveries_total")
+)
+
+// circuitBreaker short-circuits saves while the database is unreachable, so they fail
+// immediately instead of each waiting for the save timeout. Once the cooldown has passed,
+// a single trial save is let through; its success closes the breaker again.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	failures int       // Consecutive failed saves
+	openedAt time.Time // Zero while closed
+	trial    bool      // A trial save is in flight
+}
+
+// allow reports whether a save may be attempted.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.trial || time.Since(b.openedAt) < breakerCooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record updates the breaker with the result of a save. Only errors reaching the database
+// count as failures: a rejected statement means the database is up, and errors before the
+// database is used (e.g. marshalling) say nothing about it.
+func (b *circuitBreaker) record(err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil, errors.Is(err, ErrDuplicate):
+		b.succeed()
+	case isUnavailable(err):
+		b.fail(err)
+	case errors.As(err, &pgErr):
+		b.succeed()
+	default:
+		b.mu.Lock()
+		b.trial = false
+		b.mu.Unlock()
+	}
+}
+
+// succeed closes the breaker.
+func (b *circuitBreaker) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if b.openedAt.IsZero() {
+		return
+	}
+	klog.Infof("Database reachable again after %s, closing circuit breaker", time.Since(b.openedAt).Round(time.Second))
+	b.openedAt = time.Time{}
+	b.trial = false
+	circuitOpen.Set(0)
+	storeRecoveries.Add(1)
+}
+
+// fail counts a failed save, opening the breaker after breakerThreshold consecutive
+// failures. A failed trial save keeps the breaker open for another cooldown.
+func (b *circuitBreaker) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if !b.openedAt.IsZero() {
+		b.openedAt = time.Now()
+		b.trial = false
+		return
+	}
+	if b.failures >= breakerThreshold {
+		b.open(err)
+	}
+}
+
+// trip opens the breaker immediately, e.g. when the health monitor can't reach the database.
+func (b *circuitBreaker) trip(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		b.open(err)
+	}
+}
+
+// open opens the breaker. Must be called with mu held.
+func (b *circuitBreaker) open(err error) {
+	klog.Errorf("Database unreachable, short-circuiting saves for %s: %v", breakerCooldown, err)
+	b.openedAt = time.Now()
+	b.trial = false
+	circuitOpen.Set(1)
+	circuitOpens.Add(1)
+}
+
+// isOpen reports whether saves are short-circuited.
+func (b *circuitBreaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// isUnavailable reports whether err means the database couldn't be reached, as opposed to
+// the database rejecting the statement.
+func isUnavailable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Connection exceptions, and the server shutting down or starting up
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		pgconn.Timeout(err)
+}
+
+// monitorHealth pings the database until ctx is cancelled. A failed ping opens the circuit
+// breaker and drops the pool's connections, so the next ones are dialled anew; a successful
+// ping closes the breaker, without waiting for a trial save.
+func (s *PostgreSQLStore) monitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, cancel := context.WithTimeout(ctx, healthMonitorInterval)
+			err := s.HealthCheck(pingCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if !s.breaker.isOpen() {
+					s.pool.Reset()
+				}
+				s.breaker.trip(err)
+			} else if s.breaker.isOpen() {
+				s.breaker.succeed()
+			}
+		}
+	}
+}
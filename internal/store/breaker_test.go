@@ -0,0 +1,99 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	defer func(cooldown time.Duration) { breakerCooldown = cooldown }(breakerCooldown)
+	breakerCooldown = 20 * time.Millisecond
+
+	var b circuitBreaker
+	unreachable := fmt.Errorf("failed to begin transaction: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")})
+	opens, recoveries := circuitOpens.Value(), storeRecoveries.Value()
+
+	for i := 0; i < breakerThreshold; i++ {
+		if !b.allow() {
+			t.Fatalf("Save %d should be allowed before the threshold is reached", i)
+		}
+		b.record(unreachable)
+	}
+	if b.allow() {
+		t.Fatal("Saves should be short-circuited once the breaker is open")
+	}
+	if circuitOpens.Value() != opens+1 || circuitOpen.Value() != 1 {
+		t.Errorf("circuit opens = %d, open = %d", circuitOpens.Value()-opens, circuitOpen.Value())
+	}
+
+	// After the cooldown a single trial save is let through; its failure keeps the breaker open
+	time.Sleep(breakerCooldown)
+	if !b.allow() {
+		t.Fatal("A trial save should be allowed after the cooldown")
+	}
+	if b.allow() {
+		t.Fatal("Only one trial save should be allowed")
+	}
+	b.record(unreachable)
+	if b.allow() {
+		t.Fatal("A failed trial should keep the breaker open")
+	}
+
+	time.Sleep(breakerCooldown)
+	if !b.allow() {
+		t.Fatal("A trial save should be allowed after the cooldown")
+	}
+	b.record(nil)
+	if !b.allow() || b.isOpen() {
+		t.Fatal("A successful trial should close the breaker")
+	}
+	if storeRecoveries.Value() != recoveries+1 || circuitOpen.Value() != 0 {
+		t.Errorf("recoveries = %d, open = %d", storeRecoveries.Value()-recoveries, circuitOpen.Value())
+	}
+}
+
+func TestCircuitBreaker_IgnoresRejectedStatements(t *testing.T) {
+	var b circuitBreaker
+	for i := 0; i < breakerThreshold*2; i++ {
+		b.record(fmt.Errorf("failed to insert change event: %w", &pgconn.PgError{Code: "23502"}))
+		b.record(errors.New("failed to marshal actor"))
+	}
+	if b.isOpen() {
+		t.Error("Errors of a reachable database should not open the breaker")
+	}
+}
+
+func TestCircuitBreaker_Trip(t *testing.T) {
+	var b circuitBreaker
+	b.trip(errors.New("ping failed"))
+	if b.allow() {
+		t.Fatal("A tripped breaker should short-circuit saves")
+	}
+	b.succeed()
+	if !b.allow() {
+		t.Fatal("A successful ping should close the breaker")
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("failed to commit: %w", &pgconn.PgError{Code: "57P01"}), true},
+		{&pgconn.PgError{Code: "08006"}, true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{errors.New("failed to marshal diff"), false},
+	}
+	for _, tt := range tests {
+		if got := isUnavailable(tt.err); got != tt.want {
+			t.Errorf("isUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
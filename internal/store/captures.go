@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+)
+
+// CaptureSession is a time-limited debug session during which the webhook stores a sample
+// of the admission requests it receives, redacted, to troubleshoot decoding and diffs.
+type CaptureSession struct {
+	ID          int64     `json:"id"`
+	Percent     float64   `json:"percent"`              // Of the admission requests captured
+	Namespaces  []string  `json:"namespaces,omitempty"` // Patterns of the namespaces captured; empty captures all
+	MaxCaptures int       `json:"max_captures"`         // Captures stored at most by the session
+	Reason      string    `json:"reason,omitempty"`
+	StartedBy   string    `json:"started_by,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	StoppedBy string     `json:"stopped_by,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	Captures  int64      `json:"captures"` // Captures stored so far
+}
+
+// Active reports whether the session captures requests at now.
+func (c *CaptureSession) Active(now time.Time) bool {
+	return c.StoppedAt == nil && now.Before(c.ExpiresAt) && int64(c.MaxCaptures) > c.Captures
+}
+
+// AdmissionCapture is an admission request captured by a session, with its objects and
+// user info redacted.
+type AdmissionCapture struct {
+	ID          int64           `json:"id"`
+	SessionID   int64           `json:"session_id"`
+	CapturedAt  time.Time       `json:"captured_at"`
+	UID         string          `json:"uid"`
+	Operation   string          `json:"operation"`
+	Kind        string          `json:"kind"`
+	Namespace   string          `json:"namespace,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Username    string          `json:"username,omitempty"`     // Requester, pseudonymized if actors are
+	DecodeError string          `json:"decode_error,omitempty"` // Why the request couldn't be decoded, if it couldn't
+	Request     json.RawMessage `json:"request"`                // The redacted AdmissionRequest; null if Encrypted
+	Encrypted   bool            `json:"encrypted,omitempty"`    // Request is encrypted and no encryption key is configured to read it
+}
+
+// initCaptureSchema creates the admission capture tables if they don't exist.
+func (s *PostgreSQLStore) initCaptureSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS capture_sessions (
+		id BIGSERIAL PRIMARY KEY,
+		percent DOUBLE PRECISION NOT NULL,
+		namespaces JSONB,
+		max_captures INTEGER NOT NULL,
+		reason TEXT,
+		started_by VARCHAR(255),
+		started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		stopped_by VARCHAR(255),
+		stopped_at TIMESTAMPTZ
+	);
+
+	CREATE TABLE IF NOT EXISTS admission_captures (
+		id BIGSERIAL PRIMARY KEY,
+		session_id BIGINT NOT NULL REFERENCES capture_sessions(id) ON DELETE CASCADE,
+		captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		uid VARCHAR(255) NOT NULL,
+		operation VARCHAR(50) NOT NULL,
+		kind VARCHAR(100) NOT NULL,
+		namespace VARCHAR(255),
+		name VARCHAR(255),
+		decode_error TEXT,
+		request JSONB NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_admission_captures_session ON admission_captures(session_id, id);
+
+	ALTER TABLE admission_captures ADD COLUMN IF NOT EXISTS username VARCHAR(255);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create admission capture tables: %w", err)
+	}
+	return nil
+}
+
+// captureSessionColumns are the columns read by scanCaptureSession.
+const captureSessionColumns = `
+	c.id, c.percent, c.namespaces, c.max_captures, COALESCE(c.reason, ''), COALESCE(c.started_by, ''),
+	c.started_at, c.expires_at, COALESCE(c.stopped_by, ''), c.stopped_at,
+	(SELECT COUNT(*) FROM admission_captures a WHERE a.session_id = c.id)
+`
+
+// scanCaptureSession scans a capture session read with captureSessionColumns.
+func scanCaptureSession(row pgx.Row) (*CaptureSession, error) {
+	var (
+		session        CaptureSession
+		namespacesJSON []byte
+	)
+	if err := row.Scan(&session.ID, &session.Percent, &namespacesJSON, &session.MaxCaptures, &session.Reason, &session.StartedBy,
+		&session.StartedAt, &session.ExpiresAt, &session.StoppedBy, &session.StoppedAt, &session.Captures); err != nil {
+		return nil, err
+	}
+	if len(namespacesJSON) > 0 {
+		if err := json.Unmarshal(namespacesJSON, &session.Namespaces); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal capture namespaces: %w", err)
+		}
+	}
+	return &session, nil
+}
+
+// StartCaptureSession stops the running session, if any, and starts session.
+func (s *PostgreSQLStore) StartCaptureSession(ctx context.Context, session *CaptureSession) error {
+	var namespacesJSON []byte
+	if len(session.Namespaces) > 0 {
+		var err error
+		if namespacesJSON, err = json.Marshal(session.Namespaces); err != nil {
+			return fmt.Errorf("failed to marshal capture namespaces: %w", err)
+		}
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE capture_sessions SET stopped_at = NOW(), stopped_by = $1
+		WHERE stopped_at IS NULL AND expires_at > NOW()
+	`, session.StartedBy); err != nil {
+		return fmt.Errorf("failed to stop the running capture session: %w", err)
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO capture_sessions (percent, namespaces, max_captures, reason, started_by, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING id, started_at
+	`, session.Percent, namespacesJSON, session.MaxCaptures, session.Reason, session.StartedBy, session.ExpiresAt).Scan(&session.ID, &session.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert capture session: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// StopCaptureSession stops the running session. It returns ErrNotFound if none is running.
+func (s *PostgreSQLStore) StopCaptureSession(ctx context.Context, stoppedBy string) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE capture_sessions SET stopped_at = NOW(), stopped_by = $1
+		WHERE stopped_at IS NULL AND expires_at > NOW()
+	`, stoppedBy)
+	if err != nil {
+		return fmt.Errorf("failed to stop capture session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetCaptureSession returns the latest session, running or not, or nil if none was started.
+func (s *PostgreSQLStore) GetCaptureSession(ctx context.Context) (*CaptureSession, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+captureSessionColumns+` FROM capture_sessions c ORDER BY c.id DESC LIMIT 1`)
+	session, err := scanCaptureSession(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get capture session: %w", err)
+	}
+	return session, nil
+}
+
+// SaveCapture stores a capture of its session, unless the session stopped, expired or
+// reached its maximum number of captures. It reports whether the capture was stored. The
+// request, which holds the objects, is encrypted if encryption at rest is enabled.
+func (s *PostgreSQLStore) SaveCapture(ctx context.Context, capture *AdmissionCapture) (bool, error) {
+	request := []byte(capture.Request)
+	if s.encryptor != nil {
+		var err error
+		if request, err = s.encryptColumn(request); err != nil {
+			return false, fmt.Errorf("failed to encrypt admission capture: %w", err)
+		}
+	}
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO admission_captures (session_id, uid, operation, kind, namespace, name, username, decode_error, request)
+		SELECT c.id, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9
+		FROM capture_sessions c
+		WHERE c.id = $1 AND c.stopped_at IS NULL AND c.expires_at > NOW()
+		  AND (SELECT COUNT(*) FROM admission_captures a WHERE a.session_id = c.id) < c.max_captures
+		RETURNING id, captured_at
+	`, capture.SessionID, capture.UID, capture.Operation, capture.Kind, capture.Namespace, capture.Name, capture.Username,
+		capture.DecodeError, request).Scan(&capture.ID, &capture.CapturedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert admission capture: %w", err)
+	}
+	return true, nil
+}
+
+// ListCaptures returns the captures of a session, oldest first, at most limit.
+func (s *PostgreSQLStore) ListCaptures(ctx context.Context, sessionID int64, limit int) ([]*AdmissionCapture, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, session_id, captured_at, uid, operation, kind, COALESCE(namespace, ''), COALESCE(name, ''),
+		       COALESCE(username, ''), COALESCE(decode_error, ''), request
+		FROM admission_captures
+		WHERE session_id = $1
+		ORDER BY id
+		LIMIT $2
+	`, sessionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query admission captures: %w", err)
+	}
+	defer rows.Close()
+
+	captures := []*AdmissionCapture{}
+	for rows.Next() {
+		var capture AdmissionCapture
+		var request []byte
+		if err := rows.Scan(&capture.ID, &capture.SessionID, &capture.CapturedAt, &capture.UID, &capture.Operation, &capture.Kind,
+			&capture.Namespace, &capture.Name, &capture.Username, &capture.DecodeError, &request); err != nil {
+			return nil, fmt.Errorf("failed to scan admission capture: %w", err)
+		}
+		if encryption.IsEnvelope(request) {
+			if s.encryptor == nil {
+				capture.Encrypted = true
+				request = nil
+			} else if request, err = s.encryptor.Decrypt(request); err != nil {
+				return nil, fmt.Errorf("failed to decrypt admission capture: %w", err)
+			}
+		}
+		capture.Request = request
+		captures = append(captures, &capture)
+	}
+	return captures, rows.Err()
+}
+
+// DeleteCaptures deletes the sessions that ended before the given time, with their
+// captures. It returns the number of captures deleted.
+func (s *PostgreSQLStore) DeleteCaptures(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := s.pool.QueryRow(ctx, `
+		WITH ended AS (
+			DELETE FROM capture_sessions
+			WHERE LEAST(COALESCE(stopped_at, expires_at), expires_at) < $1
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM admission_captures WHERE session_id IN (SELECT id FROM ended)
+	`, before).Scan(&deleted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete admission captures: %w", err)
+	}
+	return deleted, nil
+}
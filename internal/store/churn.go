@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ResourceChurn is how often a resource changed over a period. Cluster-scoped resources
+// have an empty namespace.
+type ResourceChurn struct {
+	ResourceKind string `json:"resource_kind"`
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+
+	// Changes is the number of creates, updates and deletes, counting each sampled event
+	// SampleRate times.
+	Changes int64 `json:"changes"`
+
+	// TopActor is the user who made the most changes, typically the controller at fault.
+	TopActor string `json:"top_actor"`
+
+	FirstChangeAt time.Time `json:"first_change_at"`
+	LastChangeAt  time.Time `json:"last_change_at"`
+}
+
+// GetChurn returns the resources changed at least minChanges times since the given time,
+// most changed first, and at most limit of them (0 for all). Like QueryEvents, it is
+// limited to the namespace scope of ctx and sent to the read replica if one is usable.
+func (s *PostgreSQLStore) GetChurn(ctx context.Context, since time.Time, minChanges int64, limit int) ([]*ResourceChurn, error) {
+	start := time.Now()
+	pool := s.readPool()
+	churn, err := s.getChurn(ctx, pool, since, minChanges, limit)
+	if err != nil && s.readFailed(pool, err) {
+		churn, err = s.getChurn(ctx, s.pool, since, minChanges, limit)
+	}
+	s.observeQuery("churn", start, len(churn), func() string {
+		return fmt.Sprintf("since %s, min changes %d, limit %d", since.Format(time.RFC3339), minChanges, limit)
+	})
+	return churn, err
+}
+
+func (s *PostgreSQLStore) getChurn(ctx context.Context, pool *pgxpool.Pool, since time.Time, minChanges int64, limit int) ([]*ResourceChurn, error) {
+	querySQL := `
+		SELECT resource_kind, COALESCE(namespace, ''), name, SUM(sample_rate),
+		       COALESCE(mode() WITHIN GROUP (ORDER BY actor->>'username'), ''), MIN(timestamp), MAX(timestamp)
+		FROM change_events
+		WHERE timestamp >= $1 AND operation IN ('CREATE', 'UPDATE', 'DELETE')
+		%s
+		GROUP BY resource_kind, COALESCE(namespace, ''), name
+		HAVING SUM(sample_rate) >= $2
+		ORDER BY 4 DESC, 1, 2, 3
+	`
+	args := []interface{}{since, minChanges}
+	scopeSQL := ""
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", len(args)+1); condition != "" {
+		scopeSQL = "AND " + condition
+		args = append(args, scopeArgs...)
+	}
+	if limit > 0 {
+		querySQL += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, limit)
+	}
+
+	churn := []*ResourceChurn{}
+	err := s.scoped(ctx, pool, func(q querier) error {
+		rows, err := q.Query(ctx, fmt.Sprintf(querySQL, scopeSQL), args...)
+		if err != nil {
+			return fmt.Errorf("failed to query churn: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c ResourceChurn
+			if err := rows.Scan(&c.ResourceKind, &c.Namespace, &c.Name, &c.Changes, &c.TopActor, &c.FirstChangeAt, &c.LastChangeAt); err != nil {
+				return fmt.Errorf("failed to scan churn: %w", err)
+			}
+			churn = append(churn, &c)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return churn, nil
+}
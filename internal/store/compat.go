@@ -0,0 +1,37 @@
+package store
+
+import "github.com/kubechronicle/kubechronicle/internal/model"
+
+// eventUpgrades upgrade an event read from a row written with schema version v (the key)
+// to version v+1. Rows are never rewritten: upgrades are applied on every read, so they
+// must be cheap and idempotent. Add an entry here when model.SchemaVersion is incremented.
+var eventUpgrades = map[int]func(event *model.ChangeEvent){
+	1: upgradeEventV1,
+}
+
+// upgradeEvent brings an event read from the store up to model.SchemaVersion. Events
+// written by a newer release keep their version; fields this release doesn't know were
+// already dropped when unmarshalling.
+func upgradeEvent(event *model.ChangeEvent) {
+	if event.SchemaVersion < 1 {
+		event.SchemaVersion = 1
+	}
+	for event.SchemaVersion < model.SchemaVersion {
+		if upgrade := eventUpgrades[event.SchemaVersion]; upgrade != nil {
+			upgrade(event)
+		}
+		event.SchemaVersion++
+	}
+}
+
+// upgradeEventV1 fills in fields that rows written before events were versioned may lack:
+// actors without groups were stored with null groups (version 2 always stores an array),
+// and exec events recorded before attach was tracked have no subresource.
+func upgradeEventV1(event *model.ChangeEvent) {
+	if event.Actor.Groups == nil {
+		event.Actor.Groups = []string{}
+	}
+	if event.ExecMetadata != nil && event.ExecMetadata.Subresource == "" {
+		event.ExecMetadata.Subresource = "exec"
+	}
+}
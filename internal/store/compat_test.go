@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestUpgradeEvent(t *testing.T) {
+	// A row written before events were versioned
+	event := &model.ChangeEvent{
+		ID:            "exec-1",
+		Operation:     "EXEC",
+		ExecMetadata:  &model.ExecMetadata{TargetType: "pod"},
+		SchemaVersion: 1,
+	}
+	upgradeEvent(event)
+
+	if event.SchemaVersion != model.SchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", event.SchemaVersion, model.SchemaVersion)
+	}
+	if event.Actor.Groups == nil {
+		t.Error("Expected null groups to be upgraded to an empty list")
+	}
+	if event.ExecMetadata.Subresource != "exec" {
+		t.Errorf("Subresource = %q, want exec", event.ExecMetadata.Subresource)
+	}
+}
+
+func TestUpgradeEvent_CurrentAndNewerVersionsUnchanged(t *testing.T) {
+	for _, version := range []int{model.SchemaVersion, model.SchemaVersion + 1} {
+		event := &model.ChangeEvent{ID: "e1", SchemaVersion: version}
+		upgradeEvent(event)
+		if event.SchemaVersion != version || event.Actor.Groups != nil {
+			t.Errorf("Event with version %d was modified: %+v", version, event)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// groupByColumns maps the fields counts can be grouped by to their SQL expressions.
+var groupByColumns = map[string]string{
+	"operation":     "operation",
+	"resource_kind": "resource_kind",
+	"namespace":     "namespace",
+	"name":          "name",
+	"user":          "actor->>'username'",
+	"allowed":       "allowed::text",
+}
+
+// GroupByFields returns the fields counts can be grouped by, sorted.
+func GroupByFields() []string {
+	fields := make([]string, 0, len(groupByColumns))
+	for field := range groupByColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// IsGroupByField reports whether counts can be grouped by field.
+func IsGroupByField(field string) bool {
+	_, ok := groupByColumns[field]
+	return ok
+}
+
+// buildCountQuery returns the query counting the events matching whereSQL, grouped by
+// the filters' GroupBy field if any. CountLimit is bound to placeholder argIdx.
+func buildCountQuery(whereSQL string, argIdx int, filters QueryFilters) (string, error) {
+	column := "NULL"
+	if filters.GroupBy != "" {
+		var ok bool
+		if column, ok = groupByColumns[filters.GroupBy]; !ok {
+			return "", fmt.Errorf("cannot group by %q", filters.GroupBy)
+		}
+	}
+
+	// Only the rows counted are read, up to the limit
+	inner := fmt.Sprintf("SELECT %s AS value FROM change_events %s", column, whereSQL)
+	if filters.CountLimit > 0 {
+		inner += fmt.Sprintf(" LIMIT $%d", argIdx)
+	}
+	if filters.GroupBy == "" {
+		return fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS counted", inner), nil
+	}
+	return fmt.Sprintf("SELECT COALESCE(value, ''), COUNT(*) FROM (%s) AS counted GROUP BY value ORDER BY COUNT(*) DESC, value", inner), nil
+}
+
+// countEvents counts the events matching whereSQL for a count-only query.
+func (s *PostgreSQLStore) countEvents(ctx context.Context, q querier, whereSQL string, args []interface{}, argIdx int, filters QueryFilters) (*QueryResult, error) {
+	countSQL, err := buildCountQuery(whereSQL, argIdx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if filters.CountLimit > 0 {
+		args = append(args, filters.CountLimit)
+	}
+
+	if filters.GroupBy == "" {
+		result := &QueryResult{Events: []*model.ChangeEvent{}}
+		if err := q.QueryRow(ctx, countSQL, args...).Scan(&result.Total); err != nil {
+			return nil, fmt.Errorf("failed to count events: %w", err)
+		}
+		return result, nil
+	}
+
+	rows, err := q.Query(ctx, countSQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer rows.Close()
+
+	result := &QueryResult{Events: []*model.ChangeEvent{}, Groups: []GroupCount{}}
+	for rows.Next() {
+		var group GroupCount
+		if err := rows.Scan(&group.Value, &group.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan count: %w", err)
+		}
+		result.Groups = append(result.Groups, group)
+		result.Total += group.Count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating counts: %w", err)
+	}
+	return result, nil
+}
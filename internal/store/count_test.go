@@ -0,0 +1,58 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildCountQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters QueryFilters
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "total",
+			filters: QueryFilters{CountOnly: true},
+			want:    "SELECT COUNT(*) FROM (SELECT NULL AS value FROM change_events WHERE namespace = $1) AS counted",
+		},
+		{
+			name:    "existence",
+			filters: QueryFilters{CountOnly: true, CountLimit: 1},
+			want:    "SELECT COUNT(*) FROM (SELECT NULL AS value FROM change_events WHERE namespace = $1 LIMIT $2) AS counted",
+		},
+		{
+			name:    "grouped by user",
+			filters: QueryFilters{CountOnly: true, GroupBy: "user"},
+			want:    "SELECT COALESCE(value, ''), COUNT(*) FROM (SELECT actor->>'username' AS value FROM change_events WHERE namespace = $1) AS counted GROUP BY value ORDER BY COUNT(*) DESC, value",
+		},
+		{
+			name:    "unknown group",
+			filters: QueryFilters{CountOnly: true, GroupBy: "diff"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildCountQuery("WHERE namespace = $1", 2, tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildCountQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("buildCountQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupByFields(t *testing.T) {
+	got := strings.Join(GroupByFields(), ",")
+	if got != "allowed,name,namespace,operation,resource_kind,user" {
+		t.Errorf("GroupByFields() = %s", got)
+	}
+	if !IsGroupByField("user") || IsGroupByField("actor") {
+		t.Error("IsGroupByField() should accept the fields of GroupByFields only")
+	}
+}
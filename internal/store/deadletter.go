@@ -0,0 +1,262 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Dead letter kinds: what failed for the event.
+const (
+	DeadLetterKindStore  = "store"  // Saving the event failed
+	DeadLetterKindAlert  = "alert"  // Sending an alert for the event to Channel failed
+	DeadLetterKindExport = "export" // Exporting the event with the exporter named Channel failed
+)
+
+// DeadLetter is an event that could not be saved or alerted on after all retries.
+type DeadLetter struct {
+	ID         int64              `json:"id"`
+	Kind       string             `json:"kind"`
+	Channel    string             `json:"channel,omitempty"` // Alert channel or exporter, for alert and export dead letters
+	Event      *model.ChangeEvent `json:"event"`
+	Error      string             `json:"error"`
+	Attempts   int                `json:"attempts"`
+	CreatedAt  time.Time          `json:"created_at"`
+	RequeuedAt *time.Time         `json:"requeued_at,omitempty"` // Set while waiting to be retried
+	RequeuedBy string             `json:"requeued_by,omitempty"`
+}
+
+// deadLetterPayload holds the event fields stored encrypted, like the diff and
+// object_snapshot columns of change_events.
+type deadLetterPayload struct {
+	Diff           []model.PatchOp        `json:"diff,omitempty"`
+	ObjectSnapshot map[string]interface{} `json:"object_snapshot,omitempty"`
+}
+
+// initDeadLetterSchema creates the dead_letters table if it doesn't exist.
+func (s *PostgreSQLStore) initDeadLetterSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS dead_letters (
+		id BIGSERIAL PRIMARY KEY,
+		kind VARCHAR(16) NOT NULL,
+		channel VARCHAR(64),
+		event_id VARCHAR(255) NOT NULL,
+		event JSONB NOT NULL,
+		payload JSONB,
+		error TEXT NOT NULL,
+		attempts INT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		requeued_at TIMESTAMPTZ,
+		requeued_by VARCHAR(255)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_dead_letters_requeued ON dead_letters(id) WHERE requeued_at IS NOT NULL;
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create dead_letters table: %w", err)
+	}
+	return nil
+}
+
+// AddDeadLetter persists a dead letter and sets its ID and CreatedAt. The event's
+// diff and snapshot are encrypted if encryption at rest is enabled.
+func (s *PostgreSQLStore) AddDeadLetter(ctx context.Context, dl *DeadLetter) error {
+	metadata := *dl.Event
+	metadata.Diff = nil
+	metadata.ObjectSnapshot = nil
+	eventJSON, err := json.Marshal(&metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var payloadJSON []byte
+	if len(dl.Event.Diff) > 0 || dl.Event.ObjectSnapshot != nil {
+		payloadJSON, err = json.Marshal(deadLetterPayload{Diff: dl.Event.Diff, ObjectSnapshot: dl.Event.ObjectSnapshot})
+		if err != nil {
+			return fmt.Errorf("failed to marshal event payload: %w", err)
+		}
+		if s.encryptor != nil {
+			if payloadJSON, err = s.encryptColumn(payloadJSON); err != nil {
+				return fmt.Errorf("failed to encrypt event payload: %w", err)
+			}
+		}
+	}
+
+	insertSQL := `
+		INSERT INTO dead_letters (kind, channel, event_id, event, payload, error, attempts)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	if err := s.pool.QueryRow(ctx, insertSQL, dl.Kind, dl.Channel, dl.Event.ID, eventJSON, payloadJSON, dl.Error, dl.Attempts).Scan(&dl.ID, &dl.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+	return nil
+}
+
+// deadLetterColumns are the columns read by scanDeadLetter, without the payload.
+const deadLetterColumns = "id, kind, channel, event, error, attempts, created_at, requeued_at, requeued_by"
+
+// scanDeadLetter scans a row of deadLetterColumns, followed by the payload if withPayload is set.
+func (s *PostgreSQLStore) scanDeadLetter(row pgx.Row, withPayload bool) (*DeadLetter, error) {
+	var (
+		dl          DeadLetter
+		channel     *string
+		eventJSON   []byte
+		payloadJSON []byte
+		requeuedBy  *string
+	)
+	dest := []interface{}{&dl.ID, &dl.Kind, &channel, &eventJSON, &dl.Error, &dl.Attempts, &dl.CreatedAt, &dl.RequeuedAt, &requeuedBy}
+	if withPayload {
+		dest = append(dest, &payloadJSON)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if channel != nil {
+		dl.Channel = *channel
+	}
+	if requeuedBy != nil {
+		dl.RequeuedBy = *requeuedBy
+	}
+
+	dl.Event = &model.ChangeEvent{}
+	if err := json.Unmarshal(eventJSON, dl.Event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter event: %w", err)
+	}
+	if len(payloadJSON) > 0 {
+		plaintext, err := s.decryptColumn(payloadJSON, dl.Event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt dead letter payload: %w", err)
+		}
+		if plaintext != nil {
+			var payload deadLetterPayload
+			if err := json.Unmarshal(plaintext, &payload); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal dead letter payload: %w", err)
+			}
+			dl.Event.Diff = payload.Diff
+			dl.Event.ObjectSnapshot = payload.ObjectSnapshot
+		}
+	}
+	return &dl, nil
+}
+
+// ListDeadLetters returns dead letters, newest first, without the events' diff and
+// snapshot. An empty kind lists all kinds; limit <= 0 defaults to 100.
+func (s *PostgreSQLStore) ListDeadLetters(ctx context.Context, kind string, limit int) ([]*DeadLetter, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	querySQL := `SELECT ` + deadLetterColumns + ` FROM dead_letters
+		WHERE ($1 = '' OR kind = $1)
+		ORDER BY id DESC
+		LIMIT $2`
+	rows, err := s.pool.Query(ctx, querySQL, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*DeadLetter{}
+	for rows.Next() {
+		dl, err := s.scanDeadLetter(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// GetDeadLetter returns a dead letter with its full event. Returns ErrNotFound if it doesn't exist.
+func (s *PostgreSQLStore) GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	querySQL := `SELECT ` + deadLetterColumns + `, payload FROM dead_letters WHERE id = $1`
+	dl, err := s.scanDeadLetter(s.pool.QueryRow(ctx, querySQL, id), true)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+	return dl, nil
+}
+
+// RequeueDeadLetter marks a dead letter to be retried by the webhook. Returns ErrNotFound
+// if it doesn't exist or is already waiting to be retried.
+func (s *PostgreSQLStore) RequeueDeadLetter(ctx context.Context, id int64, requeuedBy string) error {
+	updateSQL := `
+		UPDATE dead_letters
+		SET requeued_at = NOW(), requeued_by = $2
+		WHERE id = $1 AND requeued_at IS NULL
+	`
+	tag, err := s.pool.Exec(ctx, updateSQL, id, requeuedBy)
+	if err != nil {
+		return fmt.Errorf("failed to requeue dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimRequeuedDeadLetters removes and returns up to limit requeued dead letters, oldest
+// first, with their full events. Rows claimed by another replica are skipped.
+func (s *PostgreSQLStore) ClaimRequeuedDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error) {
+	claimSQL := `
+		DELETE FROM dead_letters
+		WHERE id IN (
+			SELECT id FROM dead_letters
+			WHERE requeued_at IS NOT NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deadLetterColumns + `, payload`
+	rows, err := s.pool.Query(ctx, claimSQL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []*DeadLetter{}
+	for rows.Next() {
+		dl, err := s.scanDeadLetter(rows, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return deadLetters, nil
+}
+
+// DeleteDeadLetter removes a dead letter. Returns ErrNotFound if it doesn't exist.
+func (s *PostgreSQLStore) DeleteDeadLetter(ctx context.Context, id int64) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM dead_letters WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeDeadLetters removes all dead letters of kind (all kinds if empty) and returns how many were removed.
+func (s *PostgreSQLStore) PurgeDeadLetters(ctx context.Context, kind string) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM dead_letters WHERE $1 = '' OR kind = $1`, kind)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead letters: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,35 @@
+package store
+
+import (
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// SetEncryptor enables encryption of the diff and object_snapshot columns.
+// Events saved afterwards are stored as encryption envelopes; events written
+// before encryption was enabled remain readable as plaintext.
+func (s *PostgreSQLStore) SetEncryptor(enc *encryption.Encryptor) {
+	s.encryptor = enc
+}
+
+// encryptColumn encrypts a marshaled JSONB value. Empty values are stored as NULL.
+func (s *PostgreSQLStore) encryptColumn(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return s.encryptor.Encrypt(data)
+}
+
+// decryptColumn returns the plaintext of a JSONB value, marking the event as
+// encrypted if the value was an envelope. Without an encryptor the payload
+// cannot be read, so nil is returned and the field stays empty.
+func (s *PostgreSQLStore) decryptColumn(data []byte, event *model.ChangeEvent) ([]byte, error) {
+	if !encryption.IsEnvelope(data) {
+		return data, nil
+	}
+	event.Encrypted = true
+	if s.encryptor == nil {
+		return nil, nil
+	}
+	return s.encryptor.Decrypt(data)
+}
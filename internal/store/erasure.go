@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"k8s.io/klog/v2"
+)
+
+// ErasureRecord is the audit entry recorded for a user-data erasure.
+// It deliberately does not contain the erased username.
+type ErasureRecord struct {
+	ID            int64     `json:"id"`
+	Pseudonym     string    `json:"pseudonym"`
+	EventsUpdated int64     `json:"events_updated"`
+	RequestedBy   string    `json:"requested_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	DeadLettersUpdated int64 `json:"dead_letters_updated"`
+
+	// The integrity chain is resealed from the first event updated, changing the hashes
+	// from there on; PreviousHead is the head hash the chain had before.
+	ResealedFromSeq int64  `json:"resealed_from_seq,omitempty"`
+	PreviousHead    string `json:"previous_head,omitempty"`
+}
+
+// initErasureSchema creates the erasure_log table if it doesn't exist.
+func (s *PostgreSQLStore) initErasureSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS erasure_log (
+		id BIGSERIAL PRIMARY KEY,
+		pseudonym VARCHAR(255) NOT NULL,
+		events_updated BIGINT NOT NULL,
+		dead_letters_updated BIGINT NOT NULL DEFAULT 0,
+		requested_by VARCHAR(255),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		resealed_from_seq BIGINT,
+		previous_head CHAR(64)
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create erasure_log table: %w", err)
+	}
+	return nil
+}
+
+// PseudonymizeUser replaces the usernames of a user with a random pseudonym across all
+// stored events and dead letters, as actor and as requester or subject of a credential
+// issuance, and clears the source IP of the events they made. The usernames are those the
+// user's events were stored with: their name, and its pseudonym if actors are pseudonymized
+// at ingestion. Event IDs, timestamps and resource data are left unchanged, so history
+// stays intact and the pseudonym still groups the user's activity. The integrity chain is resealed with the rewritten events. The
+// user's captured admission requests are deleted. The erasure and the audit entry are
+// written in a single transaction.
+func (s *PostgreSQLStore) PseudonymizeUser(ctx context.Context, usernames []string, requestedBy string) (*ErasureRecord, error) {
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pseudonym: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Credential issuances name the user as requester or subject, also when someone else
+	// made the change
+	updateSQL := `
+		UPDATE change_events
+		SET actor = CASE WHEN actor->>'username' = ANY($1)
+				THEN jsonb_set(jsonb_set(actor, '{username}', to_jsonb($2::text)), '{source_ip}', '""'::jsonb)
+				ELSE actor END,
+			credential_issuance = credential_issuance
+				|| CASE WHEN credential_issuance->>'requester' = ANY($1) THEN jsonb_build_object('requester', $2::text) ELSE '{}'::jsonb END
+				|| CASE WHEN credential_issuance->>'subject' = ANY($1) THEN jsonb_build_object('subject', $2::text) ELSE '{}'::jsonb END
+		WHERE actor->>'username' = ANY($1)
+			OR credential_issuance->>'requester' = ANY($1)
+			OR credential_issuance->>'subject' = ANY($1)
+		RETURNING id
+	`
+	updated, err := tx.Query(ctx, updateSQL, usernames, pseudonym)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pseudonymize events: %w", err)
+	}
+	ids, err := pgx.CollectRows(updated, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to pseudonymize events: %w", err)
+	}
+
+	// Events waiting in the dead letter queue carry the actor too
+	deadLettersSQL := `
+		UPDATE dead_letters
+		SET event = CASE WHEN event->'actor'->>'username' = ANY($1)
+				THEN jsonb_set(jsonb_set(event, '{actor,username}', to_jsonb($2::text)), '{actor,source_ip}', '""'::jsonb)
+				ELSE event END
+			|| CASE WHEN event->'credential_issuance' IS NULL THEN '{}'::jsonb
+				ELSE jsonb_build_object('credential_issuance', event->'credential_issuance'
+					|| CASE WHEN event->'credential_issuance'->>'requester' = ANY($1) THEN jsonb_build_object('requester', $2::text) ELSE '{}'::jsonb END
+					|| CASE WHEN event->'credential_issuance'->>'subject' = ANY($1) THEN jsonb_build_object('subject', $2::text) ELSE '{}'::jsonb END) END
+		WHERE event->'actor'->>'username' = ANY($1)
+			OR event->'credential_issuance'->>'requester' = ANY($1)
+			OR event->'credential_issuance'->>'subject' = ANY($1)
+	`
+	tag, err := tx.Exec(ctx, deadLettersSQL, usernames, pseudonym)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pseudonymize dead letters: %w", err)
+	}
+
+	// Captured admission requests may be encrypted, so they can't be rewritten: they are
+	// debugging data kept for days, and are deleted instead
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM admission_captures
+		WHERE username = ANY($1) OR request->'userInfo'->>'username' = ANY($1)
+	`, usernames); err != nil {
+		return nil, fmt.Errorf("failed to delete admission captures: %w", err)
+	}
+
+	// The pseudonym replaces the usernames in the stats too, merging their counts
+	if _, err := tx.Exec(ctx, `
+		WITH erased AS (
+			DELETE FROM change_event_stats WHERE username = ANY($1)
+			RETURNING granularity, bucket, namespace, resource_kind, operation, events, sampled_events
+		)
+		INSERT INTO change_event_stats (granularity, bucket, namespace, resource_kind, username, operation, events, sampled_events)
+		SELECT granularity, bucket, namespace, resource_kind, $2, operation, SUM(events), SUM(sampled_events)
+		FROM erased
+		GROUP BY granularity, bucket, namespace, resource_kind, operation
+	`, usernames, pseudonym); err != nil {
+		return nil, fmt.Errorf("failed to pseudonymize stats: %w", err)
+	}
+
+	// A username pseudonymized at ingestion can't be reversed anymore
+	if _, err := tx.Exec(ctx, "DELETE FROM actor_pseudonyms WHERE pseudonym = ANY($1)", usernames); err != nil {
+		return nil, fmt.Errorf("failed to delete pseudonym: %w", err)
+	}
+
+	record := &ErasureRecord{
+		Pseudonym:          pseudonym,
+		EventsUpdated:      int64(len(ids)),
+		DeadLettersUpdated: tag.RowsAffected(),
+		RequestedBy:        requestedBy,
+	}
+	if record.ResealedFromSeq, record.PreviousHead, err = resealChain(ctx, tx, ids); err != nil {
+		return nil, err
+	}
+
+	insertSQL := `
+		INSERT INTO erasure_log (pseudonym, events_updated, dead_letters_updated, requested_by, resealed_from_seq, previous_head)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''))
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, insertSQL, record.Pseudonym, record.EventsUpdated, record.DeadLettersUpdated, record.RequestedBy,
+		record.ResealedFromSeq, record.PreviousHead).Scan(&record.ID, &record.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to record erasure: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit erasure: %w", err)
+	}
+
+	klog.Infof("Pseudonymized %d event(s) and %d dead letter(s) as %s (requested by %s)",
+		record.EventsUpdated, record.DeadLettersUpdated, record.Pseudonym, requestedBy)
+	if record.ResealedFromSeq > 0 {
+		klog.Infof("Resealed the integrity chain from entry %d for erasure %d, replacing head %s", record.ResealedFromSeq, record.ID, record.PreviousHead)
+	}
+	return record, nil
+}
+
+// ListErasures returns recorded erasures, newest first.
+func (s *PostgreSQLStore) ListErasures(ctx context.Context) ([]*ErasureRecord, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, pseudonym, events_updated, dead_letters_updated, COALESCE(requested_by, ''), created_at,
+			COALESCE(resealed_from_seq, 0), COALESCE(previous_head, '')
+		FROM erasure_log
+		ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query erasures: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*ErasureRecord{}
+	for rows.Next() {
+		var record ErasureRecord
+		if err := rows.Scan(&record.ID, &record.Pseudonym, &record.EventsUpdated, &record.DeadLettersUpdated, &record.RequestedBy, &record.CreatedAt,
+			&record.ResealedFromSeq, &record.PreviousHead); err != nil {
+			return nil, fmt.Errorf("failed to scan erasure: %w", err)
+		}
+		records = append(records, &record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return records, nil
+}
+
+// RestoreErasure records an erasure audit entry from a backup, keeping its creation time.
+// The entry gets a new ID.
+func (s *PostgreSQLStore) RestoreErasure(ctx context.Context, record *ErasureRecord) error {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO erasure_log (pseudonym, events_updated, dead_letters_updated, requested_by, created_at, resealed_from_seq, previous_head)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''))
+		RETURNING id
+	`, record.Pseudonym, record.EventsUpdated, record.DeadLettersUpdated, record.RequestedBy, record.CreatedAt,
+		record.ResealedFromSeq, record.PreviousHead).Scan(&record.ID)
+	if err != nil {
+		return fmt.Errorf("failed to restore erasure: %w", err)
+	}
+	return nil
+}
+
+// newPseudonym generates a random, non-reversible replacement username.
+func newPseudonym() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "erased-user-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewPseudonym(t *testing.T) {
+	first, err := newPseudonym()
+	if err != nil {
+		t.Fatalf("newPseudonym() error = %v", err)
+	}
+	second, err := newPseudonym()
+	if err != nil {
+		t.Fatalf("newPseudonym() error = %v", err)
+	}
+
+	if !strings.HasPrefix(first, "erased-user-") {
+		t.Errorf("newPseudonym() = %q, want erased-user- prefix", first)
+	}
+	if first == second {
+		t.Errorf("newPseudonym() should be random, got %q twice", first)
+	}
+}
@@ -0,0 +1,265 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Export job statuses.
+const (
+	ExportJobPending   = "pending"   // Waiting for a runner to claim it
+	ExportJobRunning   = "running"   // Being written to object storage
+	ExportJobSucceeded = "succeeded" // Written to ObjectKey
+	ExportJobFailed    = "failed"    // Stopped with Error
+)
+
+// ExportJobStaleAfter is how long a running job may go without progress before another
+// runner claims it, e.g. after the replica running it was stopped.
+const ExportJobStaleAfter = 10 * time.Minute
+
+// ExportJob writes the events matching Filters to object storage in the background, for
+// result sets too large to page through the API.
+type ExportJob struct {
+	ID        string       `json:"id"`
+	CreatedBy string       `json:"created_by,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	Filters   QueryFilters `json:"filters"`
+
+	// Scope is the namespace scope of the creator, applied when the job runs (nil if
+	// unrestricted), and Redact whether encrypted events are written redacted.
+	Scope  []string `json:"-"`
+	Redact bool     `json:"-"`
+
+	// Timezone is the IANA time zone the timestamps of the exported events are written in
+	// (UTC if empty).
+	Timezone string `json:"timezone,omitempty"`
+
+	Status     string     `json:"status"`
+	Exported   int64      `json:"exported"`        // Events written so far
+	Total      int64      `json:"total,omitempty"` // Events matching the filters, once counted
+	ObjectKey  string     `json:"-"`
+	Bytes      int64      `json:"bytes,omitempty"` // Size of the object, once written
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// initExportJobSchema creates the export_jobs table if it doesn't exist.
+func (s *PostgreSQLStore) initExportJobSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS export_jobs (
+		id VARCHAR(64) PRIMARY KEY,
+		created_by VARCHAR(255),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		filters JSONB NOT NULL,
+		scope JSONB,
+		redact BOOLEAN NOT NULL DEFAULT false,
+		status VARCHAR(16) NOT NULL,
+		exported BIGINT NOT NULL DEFAULT 0,
+		total BIGINT NOT NULL DEFAULT 0,
+		object_key TEXT,
+		bytes BIGINT NOT NULL DEFAULT 0,
+		error TEXT,
+		started_at TIMESTAMPTZ,
+		finished_at TIMESTAMPTZ,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs(status, created_at) WHERE status IN ('pending', 'running');
+
+	ALTER TABLE export_jobs ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '';
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create export_jobs table: %w", err)
+	}
+	return nil
+}
+
+// CreateExportJob persists a new pending export job and sets its CreatedAt.
+func (s *PostgreSQLStore) CreateExportJob(ctx context.Context, job *ExportJob) error {
+	filtersJSON, err := json.Marshal(job.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export filters: %w", err)
+	}
+	var scopeJSON []byte
+	if job.Scope != nil {
+		if scopeJSON, err = json.Marshal(job.Scope); err != nil {
+			return fmt.Errorf("failed to marshal export scope: %w", err)
+		}
+	}
+
+	insertSQL := `
+		INSERT INTO export_jobs (id, created_by, filters, scope, redact, timezone, status)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at
+	`
+	job.Status = ExportJobPending
+	if err := s.pool.QueryRow(ctx, insertSQL, job.ID, job.CreatedBy, filtersJSON, scopeJSON, job.Redact, job.Timezone, job.Status).Scan(&job.CreatedAt, &job.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to insert export job: %w", err)
+	}
+	return nil
+}
+
+// exportJobColumns are the columns read by scanExportJob.
+const exportJobColumns = "id, created_by, created_at, filters, scope, redact, timezone, status, exported, total, object_key, bytes, error, started_at, finished_at, updated_at"
+
+// scanExportJob scans a row of exportJobColumns.
+func scanExportJob(row pgx.Row) (*ExportJob, error) {
+	var (
+		job         ExportJob
+		createdBy   *string
+		filtersJSON []byte
+		scopeJSON   []byte
+		objectKey   *string
+		jobErr      *string
+	)
+	if err := row.Scan(&job.ID, &createdBy, &job.CreatedAt, &filtersJSON, &scopeJSON, &job.Redact, &job.Timezone, &job.Status,
+		&job.Exported, &job.Total, &objectKey, &job.Bytes, &jobErr, &job.StartedAt, &job.FinishedAt, &job.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if createdBy != nil {
+		job.CreatedBy = *createdBy
+	}
+	if objectKey != nil {
+		job.ObjectKey = *objectKey
+	}
+	if jobErr != nil {
+		job.Error = *jobErr
+	}
+	if err := json.Unmarshal(filtersJSON, &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal export filters: %w", err)
+	}
+	if len(scopeJSON) > 0 {
+		if err := json.Unmarshal(scopeJSON, &job.Scope); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal export scope: %w", err)
+		}
+		if job.Scope == nil {
+			job.Scope = []string{} // Scoped to no namespaces, not unrestricted
+		}
+	}
+	return &job, nil
+}
+
+// GetExportJob returns an export job. Returns ErrNotFound if it doesn't exist.
+func (s *PostgreSQLStore) GetExportJob(ctx context.Context, id string) (*ExportJob, error) {
+	job, err := scanExportJob(s.pool.QueryRow(ctx, `SELECT `+exportJobColumns+` FROM export_jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimExportJob marks the oldest pending export job, or a running one without progress
+// for ExportJobStaleAfter, as running and returns it. Returns nil if there is none. Jobs
+// being claimed by another replica are skipped.
+func (s *PostgreSQLStore) ClaimExportJob(ctx context.Context) (*ExportJob, error) {
+	claimSQL := `
+		UPDATE export_jobs
+		SET status = 'running', exported = 0, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+			   OR (status = 'running' AND updated_at < NOW() - make_interval(secs => $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + exportJobColumns
+	job, err := scanExportJob(s.pool.QueryRow(ctx, claimSQL, ExportJobStaleAfter.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim export job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateExportJobProgress records the events written so far by a running export job, out
+// of total.
+func (s *PostgreSQLStore) UpdateExportJobProgress(ctx context.Context, id string, exported, total int64) error {
+	updateSQL := `
+		UPDATE export_jobs
+		SET exported = $2, total = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+	if _, err := s.pool.Exec(ctx, updateSQL, id, exported, total); err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	return nil
+}
+
+// FinishExportJob records the outcome of a running export job: succeeded with the object
+// written if jobErr is nil, failed otherwise.
+func (s *PostgreSQLStore) FinishExportJob(ctx context.Context, job *ExportJob, jobErr error) error {
+	status, errMsg := ExportJobSucceeded, ""
+	if jobErr != nil {
+		status, errMsg = ExportJobFailed, jobErr.Error()
+	}
+	updateSQL := `
+		UPDATE export_jobs
+		SET status = $2, exported = $3, total = $4, object_key = NULLIF($5, ''), bytes = $6,
+		    error = NULLIF($7, ''), finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING finished_at, updated_at
+	`
+	if err := s.pool.QueryRow(ctx, updateSQL, job.ID, status, job.Exported, job.Total, job.ObjectKey, job.Bytes, errMsg).Scan(&job.FinishedAt, &job.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to finish export job: %w", err)
+	}
+	job.Status, job.Error = status, errMsg
+	return nil
+}
+
+// StreamEvents calls fn with every event matching filters, oldest first, reading them from
+// a single query instead of pages. Fields limits the fields read, like for QueryEvents,
+// and reads are restricted to the namespace scope of ctx. It stops at the first error fn
+// returns. Events are read from the primary.
+func (s *PostgreSQLStore) StreamEvents(ctx context.Context, filters QueryFilters, fn func(*model.ChangeEvent) error) error {
+	whereSQL, args := buildWhereClause(filters)
+	whereSQL = excludeHeartbeats(whereSQL, filters)
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", len(args)+1); condition != "" {
+		if whereSQL == "" {
+			whereSQL = "WHERE " + condition
+		} else {
+			whereSQL += " AND " + condition
+		}
+		args = append(args, scopeArgs...)
+	}
+	querySQL := fmt.Sprintf(`SELECT %s FROM change_events %s ORDER BY timestamp, id`, selectColumns(filters.Fields), whereSQL)
+
+	start := time.Now()
+	streamed := 0
+	err := s.scoped(ctx, s.pool, func(q querier) error {
+		rows, err := q.Query(ctx, querySQL, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query events: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			event, err := s.scanEvent(rows)
+			if err != nil {
+				return fmt.Errorf("failed to scan event: %w", err)
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+			streamed++
+		}
+		return rows.Err()
+	})
+	s.observeQuery("stream_events", start, streamed, func() string {
+		return describeEventQuery(filters, PaginationParams{}, SortOrderAsc)
+	})
+	return err
+}
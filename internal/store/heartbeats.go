@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// heartbeatExclusion leaves heartbeat events out of queries.
+const heartbeatExclusion = "operation <> 'HEARTBEAT'"
+
+// HeartbeatStats measures the delivery of the heartbeats of a producer instance: how many
+// were lost, and how long the stored ones took from being emitted to being stored.
+type HeartbeatStats struct {
+	Producer string `json:"producer"`
+	Cluster  string `json:"cluster"`
+	Instance string `json:"instance"`
+
+	Received      int64 `json:"received"`       // Heartbeats stored
+	FirstSequence int64 `json:"first_sequence"` // Of the first heartbeat stored
+	LastSequence  int64 `json:"last_sequence"`  // Of the last heartbeat stored
+	Lost          int64 `json:"lost"`           // Gaps in the sequence between the first and last heartbeats
+
+	// Latency from the heartbeat's timestamp to it being stored, in seconds. It includes
+	// the time spent queued, retried or spooled, and the clock skew between the producer
+	// and the database.
+	LatencyP50 float64 `json:"latency_p50_seconds"`
+	LatencyP99 float64 `json:"latency_p99_seconds"`
+	LatencyMax float64 `json:"latency_max_seconds"`
+
+	LastEmittedAt  time.Time `json:"last_emitted_at"`
+	LastReceivedAt time.Time `json:"last_received_at"` // When the last heartbeat was stored
+}
+
+// selectsHeartbeats reports whether filters select the HEARTBEAT operation.
+func selectsHeartbeats(filters QueryFilters) bool {
+	if filters.Operation == model.OperationHeartbeat {
+		return true
+	}
+	for _, operation := range filters.Operations {
+		if operation == model.OperationHeartbeat {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeHeartbeats adds the condition leaving heartbeat events out to whereSQL, unless
+// filters select them.
+func excludeHeartbeats(whereSQL string, filters QueryFilters) string {
+	switch {
+	case selectsHeartbeats(filters):
+		return whereSQL
+	case whereSQL == "":
+		return "WHERE " + heartbeatExclusion
+	default:
+		return whereSQL + " AND " + heartbeatExclusion
+	}
+}
+
+// GetHeartbeatStats returns the heartbeats stored since the given time, per producer
+// instance. Heartbeats lost after the last one stored don't show as gaps; the time since
+// LastEmittedAt shows them.
+func (s *PostgreSQLStore) GetHeartbeatStats(ctx context.Context, since time.Time) ([]*HeartbeatStats, error) {
+	start := time.Now()
+	rows, err := s.pool.Query(ctx, `
+		SELECT heartbeat->>'producer', heartbeat->>'cluster', heartbeat->>'instance', COUNT(*),
+		       MIN((heartbeat->>'sequence')::bigint), MAX((heartbeat->>'sequence')::bigint),
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY latency),
+		       percentile_cont(0.99) WITHIN GROUP (ORDER BY latency),
+		       MAX(latency), MAX(timestamp), MAX(received_at)
+		FROM (
+			SELECT heartbeat, timestamp, COALESCE(created_at, timestamp) AS received_at,
+			       GREATEST(EXTRACT(EPOCH FROM COALESCE(created_at, timestamp) - timestamp)::float8, 0) AS latency
+			FROM change_events
+			WHERE operation = 'HEARTBEAT' AND heartbeat IS NOT NULL AND timestamp >= $1
+		) h
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heartbeats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []*HeartbeatStats{}
+	for rows.Next() {
+		h := &HeartbeatStats{}
+		if err := rows.Scan(&h.Producer, &h.Cluster, &h.Instance, &h.Received, &h.FirstSequence, &h.LastSequence,
+			&h.LatencyP50, &h.LatencyP99, &h.LatencyMax, &h.LastEmittedAt, &h.LastReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan heartbeats: %w", err)
+		}
+		if lost := h.LastSequence - h.FirstSequence + 1 - h.Received; lost > 0 {
+			h.Lost = lost
+		}
+		stats = append(stats, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.observeQuery("heartbeats", start, len(stats), func() string {
+		return "since " + since.Format(time.RFC3339)
+	})
+	return stats, nil
+}
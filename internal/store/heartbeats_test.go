@@ -0,0 +1,24 @@
+package store
+
+import "testing"
+
+func TestExcludeHeartbeats(t *testing.T) {
+	tests := []struct {
+		name     string
+		whereSQL string
+		filters  QueryFilters
+		want     string
+	}{
+		{"no filters", "", QueryFilters{}, "WHERE operation <> 'HEARTBEAT'"},
+		{"other filters", "WHERE namespace = $1", QueryFilters{Namespace: "default"}, "WHERE namespace = $1 AND operation <> 'HEARTBEAT'"},
+		{"operation", "WHERE operation = $1", QueryFilters{Operation: "HEARTBEAT"}, "WHERE operation = $1"},
+		{"operations", "WHERE operation = ANY($1)", QueryFilters{Operations: []string{"CREATE", "HEARTBEAT"}}, "WHERE operation = ANY($1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excludeHeartbeats(tt.whereSQL, tt.filters); got != tt.want {
+				t.Errorf("excludeHeartbeats() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
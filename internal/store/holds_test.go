@@ -0,0 +1,63 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildHoldExclusion(t *testing.T) {
+	released := time.Now()
+
+	tests := []struct {
+		name     string
+		holds    []*LegalHold
+		startIdx int
+		wantSQL  string
+		wantArgs int
+	}{
+		{
+			name:     "no holds",
+			holds:    nil,
+			startIdx: 1,
+			wantSQL:  "TRUE",
+		},
+		{
+			name: "released holds are ignored",
+			holds: []*LegalHold{
+				{Filters: QueryFilters{Namespace: "payments"}, ReleasedAt: &released},
+			},
+			startIdx: 1,
+			wantSQL:  "TRUE",
+		},
+		{
+			name: "active holds numbered from start index",
+			holds: []*LegalHold{
+				{Filters: QueryFilters{Namespace: "payments"}},
+				{Filters: QueryFilters{ResourceKind: "Secret", Name: "db-*"}},
+			},
+			startIdx: 2,
+			wantSQL:  "NOT (namespace = $2) AND NOT (resource_kind = $3 AND name LIKE $4)",
+			wantArgs: 3,
+		},
+		{
+			name: "hold without filters covers everything",
+			holds: []*LegalHold{
+				{Filters: QueryFilters{}},
+			},
+			startIdx: 1,
+			wantSQL:  "FALSE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs := buildHoldExclusion(tt.holds, tt.startIdx)
+			if gotSQL != tt.wantSQL {
+				t.Errorf("buildHoldExclusion() SQL = %q, want %q", gotSQL, tt.wantSQL)
+			}
+			if len(gotArgs) != tt.wantArgs {
+				t.Errorf("buildHoldExclusion() args = %d, want %d", len(gotArgs), tt.wantArgs)
+			}
+		})
+	}
+}
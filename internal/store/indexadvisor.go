@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// jsonbFilterQueries counts the event queries filtering on each field of a JSONB column,
+// e.g. actor.groups, which the index advisor recommends indexes from.
+var jsonbFilterQueries = expvar.NewMap("store_jsonb_filter_queries_total")
+
+// jsonbFilterIndex is the expression index serving filters on a field of a JSONB column.
+type jsonbFilterIndex struct {
+	field      string                    // e.g. actor.groups
+	name       string                    // Index name
+	definition string                    // Of the index after ON change_events
+	used       func(f QueryFilters) bool // Whether a query filters on the field
+}
+
+// jsonbFilterIndexes are the indexes of the JSONB fields event queries can filter on.
+// The username and group indexes are created by initSchema; the others are left to the
+// index advisor, since few deployments filter on them.
+var jsonbFilterIndexes = []jsonbFilterIndex{
+	{
+		field: "actor.username", name: "idx_change_events_actor_username",
+		definition: "((actor->>'username'))",
+		used: func(f QueryFilters) bool {
+			return f.Username != "" || len(f.Usernames) > 0 || len(f.ExcludeUsernames) > 0
+		},
+	},
+	{
+		field: "actor.groups", name: "idx_change_events_actor_groups_gin",
+		definition: "USING GIN ((actor->'groups'))",
+		used:       func(f QueryFilters) bool { return f.Group != "" },
+	},
+	{
+		field: "exec_metadata.risk_score", name: "idx_change_events_risk_score",
+		definition: "(((exec_metadata->>'risk_score')::int))",
+		used:       func(f QueryFilters) bool { return f.MinRiskScore > 0 },
+	},
+	{
+		field: "node_maintenance.node_name", name: "idx_change_events_node_name",
+		definition: "((node_maintenance->>'node_name'))",
+		used:       func(f QueryFilters) bool { return f.Node != "" },
+	},
+}
+
+// IndexAdvisorConfig configures the index advisor, which periodically checks that the
+// JSONB fields event queries often filter on are indexed. It recommends the missing
+// indexes in the log, or creates them if Create is set.
+type IndexAdvisorConfig struct {
+	Interval   time.Duration // Between checks; the advisor doesn't run when zero
+	MinQueries int64         // Queries filtering on a field before its index is recommended
+	Create     bool          // Create missing indexes instead of recommending them
+}
+
+// Index statuses of IndexAdvice.
+const (
+	IndexExists  = "exists"
+	IndexMissing = "missing"
+	IndexInvalid = "invalid" // A failed concurrent build left it unusable
+)
+
+// IndexAdvice is the index of a JSONB field event queries filter on.
+type IndexAdvice struct {
+	Field   string `json:"field"`
+	Index   string `json:"index"`
+	Queries int64  `json:"queries"` // Since the process started
+	Status  string `json:"status"`
+	SQL     string `json:"sql"` // Statement creating the index
+}
+
+// recordJSONBFilters counts the JSONB fields a query filters on.
+func recordJSONBFilters(filters QueryFilters) {
+	for _, index := range jsonbFilterIndexes {
+		if index.used(filters) {
+			jsonbFilterQueries.Add(index.field, 1)
+		}
+	}
+}
+
+// createSQL returns the statement creating the index without blocking writes.
+func (index jsonbFilterIndex) createSQL() string {
+	return fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON change_events %s", index.name, index.definition)
+}
+
+// AdviseIndexes returns the indexes of the JSONB fields filtered on by at least minQueries
+// event queries since the process started, most queried first, with whether they exist.
+func (s *PostgreSQLStore) AdviseIndexes(ctx context.Context, minQueries int64) ([]*IndexAdvice, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.relname, i.indisvalid
+		FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE i.indrelid = 'change_events'::regclass
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer rows.Close()
+	valid := map[string]bool{}
+	for rows.Next() {
+		var name string
+		var isValid bool
+		if err := rows.Scan(&name, &isValid); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		valid[name] = isValid
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	advice := []*IndexAdvice{}
+	for _, index := range jsonbFilterIndexes {
+		var queries int64
+		if n, ok := jsonbFilterQueries.Get(index.field).(*expvar.Int); ok {
+			queries = n.Value()
+		}
+		if queries < minQueries {
+			continue
+		}
+		status := IndexMissing
+		if isValid, ok := valid[index.name]; ok {
+			status = IndexExists
+			if !isValid {
+				status = IndexInvalid
+			}
+		}
+		advice = append(advice, &IndexAdvice{Field: index.field, Index: index.name, Queries: queries, Status: status, SQL: index.createSQL()})
+	}
+	sort.SliceStable(advice, func(i, j int) bool { return advice[i].Queries > advice[j].Queries })
+	return advice, nil
+}
+
+// CreateIndex creates the index of a JSONB field, concurrently so writes aren't blocked,
+// replacing it if a failed build left it invalid. Returns ErrNotFound for unknown fields.
+func (s *PostgreSQLStore) CreateIndex(ctx context.Context, field string) error {
+	for _, index := range jsonbFilterIndexes {
+		if index.field != field {
+			continue
+		}
+		var isValid bool
+		err := s.pool.QueryRow(ctx, `
+			SELECT i.indisvalid FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid
+			WHERE i.indrelid = 'change_events'::regclass AND c.relname = $1
+		`, index.name).Scan(&isValid)
+		if err == nil && !isValid {
+			if _, err := s.pool.Exec(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+index.name); err != nil {
+				return fmt.Errorf("failed to drop invalid index %s: %w", index.name, err)
+			}
+		}
+		if _, err := s.pool.Exec(ctx, index.createSQL()); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", index.name, err)
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+// StartIndexAdvisor runs the index advisor every cfg.Interval until ctx is cancelled. It
+// returns immediately, and does nothing if the interval is zero.
+func (s *PostgreSQLStore) StartIndexAdvisor(ctx context.Context, cfg IndexAdvisorConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		recommended := map[string]bool{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			s.adviseIndexes(ctx, cfg, recommended)
+		}
+	}()
+}
+
+// adviseIndexes creates or recommends the missing indexes of often filtered JSONB fields.
+// Each index is recommended once per process.
+func (s *PostgreSQLStore) adviseIndexes(ctx context.Context, cfg IndexAdvisorConfig, recommended map[string]bool) {
+	advice, err := s.AdviseIndexes(ctx, cfg.MinQueries)
+	if err != nil {
+		klog.Warningf("Index advisor failed: %v", err)
+		return
+	}
+	for _, a := range advice {
+		if a.Status == IndexExists {
+			continue
+		}
+		if !cfg.Create {
+			if !recommended[a.Index] {
+				klog.Infof("Index advisor: %d queries filtered on %s, which is not indexed; create its index with: %s", a.Queries, a.Field, a.SQL)
+				recommended[a.Index] = true
+			}
+			continue
+		}
+		klog.Infof("Index advisor: creating index %s, %d queries filtered on %s", a.Index, a.Queries, a.Field)
+		start := time.Now()
+		if err := s.CreateIndex(ctx, a.Field); err != nil {
+			klog.Warningf("Index advisor: %v", err)
+			continue
+		}
+		klog.Infof("Index advisor: created index %s in %v", a.Index, time.Since(start).Round(time.Second))
+	}
+}
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"expvar"
+	"strings"
+	"testing"
+)
+
+func TestRecordJSONBFilters(t *testing.T) {
+	queries := func(field string) int64 {
+		if n, ok := jsonbFilterQueries.Get(field).(*expvar.Int); ok {
+			return n.Value()
+		}
+		return 0
+	}
+	groupsBefore, usersBefore, nodesBefore := queries("actor.groups"), queries("actor.username"), queries("node_maintenance.node_name")
+
+	recordJSONBFilters(QueryFilters{Group: "sre", ExcludeUsernames: []string{"system:*"}})
+	recordJSONBFilters(QueryFilters{Namespace: "prod"})
+
+	if got := queries("actor.groups") - groupsBefore; got != 1 {
+		t.Errorf("actor.groups queries increased by %d, want 1", got)
+	}
+	if got := queries("actor.username") - usersBefore; got != 1 {
+		t.Errorf("actor.username queries increased by %d, want 1", got)
+	}
+	if got := queries("node_maintenance.node_name") - nodesBefore; got != 0 {
+		t.Errorf("node_maintenance.node_name queries increased by %d, want 0", got)
+	}
+}
+
+func TestJSONBFilterIndexes_CreateSQL(t *testing.T) {
+	for _, index := range jsonbFilterIndexes {
+		sql := index.createSQL()
+		if !strings.HasPrefix(sql, "CREATE INDEX CONCURRENTLY IF NOT EXISTS "+index.name+" ON change_events ") {
+			t.Errorf("%s: createSQL() = %q", index.field, sql)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GenesisHash is the prev_hash of the first entry in the integrity chain.
+var GenesisHash = strings.Repeat("0", 64)
+
+// chainLockID is the advisory lock key that serializes appends to the integrity chain.
+const chainLockID = 0x6b636861696e // "kchain"
+
+// eventTimeSQL renders a timestamp as the text linked into the chain, matching eventTimeLayout.
+const eventTimeSQL = `to_char(%s AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US')`
+
+// eventTimeLayout formats the time of an event like eventTimeSQL.
+const eventTimeLayout = "2006-01-02T15:04:05.000000"
+
+// eventHashSQL hashes every column of change_events row e, rendered as canonical JSONB
+// text. It is computed by PostgreSQL both when sealing and when verifying, so the hash is
+// independent of how the JSONB values were originally formatted. Timestamps are rendered
+// in UTC rather than the session time zone, and null columns are left out, so columns
+// added later don't change the hashes of the events stored before; they must therefore
+// be nullable, without a default.
+var eventHashSQL = `encode(sha256(convert_to(jsonb_strip_nulls(to_jsonb(e) || jsonb_build_object(
+	'timestamp', ` + fmt.Sprintf(eventTimeSQL, "e.timestamp") + `,
+	'created_at', ` + fmt.Sprintf(eventTimeSQL, "e.created_at") + `))::text, 'UTF8')), 'hex')`
+
+// ChainVerification is the result of verifying the integrity chain.
+type ChainVerification struct {
+	Valid         bool   `json:"valid"`
+	EventsChecked int64  `json:"events_checked"`
+	EventsPurged  int64  `json:"events_purged,omitempty"` // Entries of events purged by retention, whose links only are verified
+	HeadHash      string `json:"head_hash"`               // Hash of the last verified entry; record it externally to detect truncation
+	FailedSeq     int64  `json:"failed_seq,omitempty"`
+	FailedEventID string `json:"failed_event_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// chainEntry is a single entry of the integrity chain joined with the hash of its event
+// as currently stored.
+type chainEntry struct {
+	Seq       int64
+	EventID   string
+	EventTime time.Time
+	EventHash string // Hash of the event when it was sealed
+	PrevHash  string
+	Hash      string
+	Current   *string      // Hash of the event as stored, nil if the event row no longer exists
+	Purged    bool         // The entry is marked as purged by retention
+	Purge     *purgeRecord // Purge the entry is marked with in the purge log, nil if none is logged
+}
+
+// purgeRecord is an entry of the purge log, recorded by each purge of retention.
+type purgeRecord struct {
+	ID     int64
+	Before time.Time // Retention cutoff: only events older than it were purged
+	Events int64     // Events purged
+}
+
+// initIntegritySchema creates the event_chain table if it doesn't exist.
+func (s *PostgreSQLStore) initIntegritySchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS event_chain (
+		seq BIGSERIAL PRIMARY KEY,
+		event_id VARCHAR(255) NOT NULL UNIQUE,
+		event_time TIMESTAMPTZ NOT NULL,
+		event_hash CHAR(64) NOT NULL,
+		prev_hash CHAR(64) NOT NULL,
+		hash CHAR(64) NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		purged_at TIMESTAMPTZ
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create event_chain table: %w", err)
+	}
+	return nil
+}
+
+// appendToChain links a newly inserted event into the integrity chain.
+// The advisory lock serializes concurrent writers so every entry references
+// the hash of exactly one predecessor.
+func appendToChain(ctx context.Context, tx pgx.Tx, eventID string) error {
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", chainLockID); err != nil {
+		return fmt.Errorf("failed to lock integrity chain: %w", err)
+	}
+
+	appendSQL := `
+		INSERT INTO event_chain (event_id, event_time, event_hash, prev_hash, hash)
+		SELECT e.id, e.timestamp, h.event_hash, p.prev,
+			encode(sha256(convert_to(concat_ws('|', p.prev, e.id, ` + fmt.Sprintf(eventTimeSQL, "e.timestamp") + `, h.event_hash), 'UTF8')), 'hex')
+		FROM change_events e
+			CROSS JOIN LATERAL (SELECT ` + eventHashSQL + ` AS event_hash) h,
+			(SELECT coalesce((SELECT hash FROM event_chain ORDER BY seq DESC LIMIT 1), $2) AS prev) p
+		WHERE e.id = $1
+	`
+	if _, err := tx.Exec(ctx, appendSQL, eventID, GenesisHash); err != nil {
+		return fmt.Errorf("failed to append to integrity chain: %w", err)
+	}
+	return nil
+}
+
+// resealChain records the hashes of the given events as they are now stored, after
+// they were rewritten by an erasure, and relinks the chain from the first of them. It
+// returns the sequence number of the first entry resealed, 0 if none was, and the head
+// hash the chain had before.
+func resealChain(ctx context.Context, tx pgx.Tx, eventIDs []string) (int64, string, error) {
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", chainLockID); err != nil {
+		return 0, "", fmt.Errorf("failed to lock integrity chain: %w", err)
+	}
+
+	var fromSeq *int64
+	updateSQL := `
+		WITH resealed AS (
+			UPDATE event_chain c SET event_hash = ` + eventHashSQL + `
+			FROM change_events e
+			WHERE e.id = c.event_id AND c.event_id = ANY($1)
+			RETURNING c.seq
+		)
+		SELECT min(seq) FROM resealed
+	`
+	if err := tx.QueryRow(ctx, updateSQL, eventIDs).Scan(&fromSeq); err != nil {
+		return 0, "", fmt.Errorf("failed to reseal events: %w", err)
+	}
+	if fromSeq == nil {
+		return 0, "", nil
+	}
+
+	var previousHead, prev string
+	headSQL := `
+		SELECT
+			(SELECT hash FROM event_chain ORDER BY seq DESC LIMIT 1),
+			coalesce((SELECT hash FROM event_chain WHERE seq < $1 ORDER BY seq DESC LIMIT 1), $2)
+	`
+	if err := tx.QueryRow(ctx, headSQL, *fromSeq, GenesisHash).Scan(&previousHead, &prev); err != nil {
+		return 0, "", fmt.Errorf("failed to query integrity chain: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, "SELECT seq, event_id, event_time, event_hash FROM event_chain WHERE seq >= $1 ORDER BY seq", *fromSeq)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to query integrity chain: %w", err)
+	}
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (chainEntry, error) {
+		var entry chainEntry
+		err := row.Scan(&entry.Seq, &entry.EventID, &entry.EventTime, &entry.EventHash)
+		return entry, err
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to scan chain entry: %w", err)
+	}
+
+	relink(prev, entries)
+	seqs := make([]int64, len(entries))
+	prevHashes := make([]string, len(entries))
+	hashes := make([]string, len(entries))
+	for i, entry := range entries {
+		seqs[i], prevHashes[i], hashes[i] = entry.Seq, entry.PrevHash, entry.Hash
+	}
+	relinkSQL := `
+		UPDATE event_chain c SET prev_hash = v.prev_hash, hash = v.hash
+		FROM unnest($1::bigint[], $2::text[], $3::text[]) AS v(seq, prev_hash, hash)
+		WHERE c.seq = v.seq
+	`
+	if _, err := tx.Exec(ctx, relinkSQL, seqs, prevHashes, hashes); err != nil {
+		return 0, "", fmt.Errorf("failed to relink integrity chain: %w", err)
+	}
+	return *fromSeq, previousHead, nil
+}
+
+// relink recomputes the links of consecutive entries, the first following prev.
+func relink(prev string, entries []chainEntry) {
+	for i := range entries {
+		entries[i].PrevHash = prev
+		entries[i].Hash = chainHash(prev, entries[i])
+		prev = entries[i].Hash
+	}
+}
+
+// VerifyChain walks the integrity chain in order, recomputing the hash of each stored
+// event, and reports the first entry whose event was altered or removed or whose link
+// to its predecessor is broken. The entries of events purged by retention keep the
+// hashes of their events, so the chain still links through them; they are only accepted
+// if a purge of the purge log covers them.
+func (s *PostgreSQLStore) VerifyChain(ctx context.Context) (*ChainVerification, error) {
+	querySQL := `
+		SELECT c.seq, c.event_id, c.event_time, c.event_hash, c.prev_hash, c.hash,
+			CASE WHEN e.id IS NULL THEN NULL ELSE ` + eventHashSQL + ` END, c.purged_at IS NOT NULL,
+			p.id, p.purged_before, p.events_purged
+		FROM event_chain c
+		LEFT JOIN change_events e ON e.id = c.event_id
+		LEFT JOIN purge_log p ON p.id = c.purge_id
+		ORDER BY c.seq
+	`
+	rows, err := s.pool.Query(ctx, querySQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrity chain: %w", err)
+	}
+	defer rows.Close()
+
+	verifier := newChainVerifier()
+	for rows.Next() {
+		var (
+			entry        chainEntry
+			purgeID      *int64
+			purgedBefore *time.Time
+			eventsPurged *int64
+		)
+		if err := rows.Scan(&entry.Seq, &entry.EventID, &entry.EventTime, &entry.EventHash, &entry.PrevHash, &entry.Hash, &entry.Current, &entry.Purged,
+			&purgeID, &purgedBefore, &eventsPurged); err != nil {
+			return nil, fmt.Errorf("failed to scan chain entry: %w", err)
+		}
+		if purgeID != nil && purgedBefore != nil && eventsPurged != nil {
+			entry.Purge = &purgeRecord{ID: *purgeID, Before: *purgedBefore, Events: *eventsPurged}
+		}
+		if !verifier.check(entry) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chain entries: %w", err)
+	}
+
+	return verifier.result, nil
+}
+
+// chainVerifier checks chain entries one at a time, in sequence order.
+type chainVerifier struct {
+	result *ChainVerification
+	purged map[int64]int64 // Entries found purged by each purge of the purge log
+}
+
+func newChainVerifier() *chainVerifier {
+	return &chainVerifier{
+		result: &ChainVerification{Valid: true, HeadHash: GenesisHash},
+		purged: map[int64]int64{},
+	}
+}
+
+// check verifies the next entry and returns false once the chain is found to be invalid.
+func (v *chainVerifier) check(entry chainEntry) bool {
+	var reason string
+	switch {
+	case entry.PrevHash != v.result.HeadHash:
+		reason = "entry does not link to the previous entry's hash"
+	case chainHash(entry.PrevHash, entry) != entry.Hash:
+		reason = "entry does not match its recorded hash"
+	case entry.Current == nil && entry.Purged:
+		if reason = v.checkPurge(entry); reason == "" {
+			v.result.EventsPurged++
+			v.result.HeadHash = entry.Hash
+			return true
+		}
+	case entry.Current == nil:
+		reason = "event has been deleted"
+	case *entry.Current != entry.EventHash:
+		reason = "event contents do not match the recorded hash"
+	}
+
+	if reason != "" {
+		v.result.Valid = false
+		v.result.FailedSeq = entry.Seq
+		v.result.FailedEventID = entry.EventID
+		v.result.Reason = reason
+		return false
+	}
+
+	v.result.EventsChecked++
+	v.result.HeadHash = entry.Hash
+	return true
+}
+
+// checkPurge returns why the event of an entry marked as purged wasn't purged by retention,
+// or "" if it was: marking an entry as purged doesn't hide a deleted event unless a logged
+// purge with a later retention cutoff, and not already accounting for all its events,
+// covers it.
+func (v *chainVerifier) checkPurge(entry chainEntry) string {
+	switch {
+	case entry.Purge == nil:
+		return "event is marked as purged but no purge is logged"
+	case !entry.EventTime.Before(entry.Purge.Before):
+		return "event is marked as purged but is newer than the retention cutoff of its purge"
+	case v.purged[entry.Purge.ID] >= entry.Purge.Events:
+		return "event is marked as purged but its purge logged fewer events"
+	}
+	v.purged[entry.Purge.ID]++
+	return ""
+}
+
+// chainHash computes the hash of an entry linked to prevHash, matching the SQL used in
+// appendToChain.
+func chainHash(prevHash string, entry chainEntry) string {
+	link := strings.Join([]string{prevHash, entry.EventID, entry.EventTime.UTC().Format(eventTimeLayout), entry.EventHash}, "|")
+	sum := sha256.Sum256([]byte(link))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainContains reports whether hash is the hash of an entry in the chain, or the head
+// of the chain before an erasure resealed it. Auditors use it to confirm that a previously
+// recorded head hash has not been truncated away.
+func (s *PostgreSQLStore) ChainContains(ctx context.Context, hash string) (bool, error) {
+	var exists bool
+	existsSQL := `
+		SELECT EXISTS (SELECT 1 FROM event_chain WHERE hash = $1)
+			OR EXISTS (SELECT 1 FROM erasure_log WHERE previous_head = $1)
+	`
+	if err := s.pool.QueryRow(ctx, existsSQL, hash).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to look up chain hash: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildChain returns valid chain entries for events with the given hashes.
+func buildChain(eventHashes ...string) []chainEntry {
+	entries := make([]chainEntry, len(eventHashes))
+	for i := range eventHashes {
+		current := eventHashes[i]
+		entries[i] = chainEntry{
+			Seq:       int64(i + 1),
+			EventID:   "event-" + current,
+			EventTime: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC),
+			EventHash: current,
+			Current:   &current,
+		}
+	}
+	relink(GenesisHash, entries)
+	return entries
+}
+
+func verify(entries []chainEntry) *ChainVerification {
+	verifier := newChainVerifier()
+	for _, entry := range entries {
+		if !verifier.check(entry) {
+			break
+		}
+	}
+	return verifier.result
+}
+
+func TestChainVerifier(t *testing.T) {
+	tampered := "tampered"
+
+	tests := []struct {
+		name       string
+		mutate     func(entries []chainEntry) []chainEntry
+		wantValid  bool
+		wantFailed int64
+		wantCount  int64
+	}{
+		{
+			name:      "valid chain",
+			mutate:    func(e []chainEntry) []chainEntry { return e },
+			wantValid: true,
+			wantCount: 3,
+		},
+		{
+			name:      "empty chain",
+			mutate:    func(e []chainEntry) []chainEntry { return nil },
+			wantValid: true,
+		},
+		{
+			name: "modified event",
+			mutate: func(e []chainEntry) []chainEntry {
+				e[1].Current = &tampered
+				return e
+			},
+			wantFailed: 2,
+			wantCount:  1,
+		},
+		{
+			name: "modified entry",
+			mutate: func(e []chainEntry) []chainEntry {
+				e[1].EventTime = e[1].EventTime.Add(-time.Hour)
+				return e
+			},
+			wantFailed: 2,
+			wantCount:  1,
+		},
+		{
+			name: "deleted event",
+			mutate: func(e []chainEntry) []chainEntry {
+				e[2].Current = nil
+				return e
+			},
+			wantFailed: 3,
+			wantCount:  2,
+		},
+		{
+			name: "removed chain entry",
+			mutate: func(e []chainEntry) []chainEntry {
+				return append(e[:1], e[2:]...)
+			},
+			wantFailed: 3,
+			wantCount:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := verify(tt.mutate(buildChain("a", "b", "c")))
+			if result.Valid != tt.wantValid {
+				t.Errorf("Valid = %v, want %v (reason: %s)", result.Valid, tt.wantValid, result.Reason)
+			}
+			if result.FailedSeq != tt.wantFailed {
+				t.Errorf("FailedSeq = %d, want %d", result.FailedSeq, tt.wantFailed)
+			}
+			if result.EventsChecked != tt.wantCount {
+				t.Errorf("EventsChecked = %d, want %d", result.EventsChecked, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestChainVerifier_HeadHash(t *testing.T) {
+	entries := buildChain("a", "b")
+	result := verify(entries)
+	if result.HeadHash != entries[1].Hash {
+		t.Errorf("HeadHash = %s, want %s", result.HeadHash, entries[1].Hash)
+	}
+}
+
+func TestChainVerifier_PurgedEvents(t *testing.T) {
+	entries := buildChain("a", "b", "c")
+	purge := &purgeRecord{ID: 1, Before: entries[2].EventTime, Events: 2}
+	for i := range entries[:2] {
+		entries[i].Current, entries[i].Purged, entries[i].Purge = nil, true, purge
+	}
+	result := verify(entries)
+	if !result.Valid || result.EventsChecked != 1 || result.EventsPurged != 2 || result.HeadHash != entries[2].Hash {
+		t.Errorf("Expected a valid chain through the purged events, got %+v", result)
+	}
+
+	// A purged entry must still link to its predecessor
+	entries[1].PrevHash = GenesisHash
+	if result := verify(entries); result.Valid || result.FailedSeq != 2 {
+		t.Errorf("Expected the chain to fail at the relinked purged entry, got %+v", result)
+	}
+}
+
+func TestChainVerifier_UnloggedPurges(t *testing.T) {
+	tests := []struct {
+		name   string
+		purge  *purgeRecord
+		reason string
+	}{
+		{"no purge logged", nil, "no purge is logged"},
+		{"newer than the cutoff", &purgeRecord{ID: 1, Before: time.Date(2026, 1, 1, 0, 1, 0, 0, time.UTC), Events: 5}, "newer than the retention cutoff"},
+		{"more than the purge logged", &purgeRecord{ID: 1, Before: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Events: 1}, "fewer events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The events of the second and third entries are deleted and marked as purged
+			entries := buildChain("a", "b", "c")
+			for i := range entries[1:] {
+				entries[i+1].Current, entries[i+1].Purged, entries[i+1].Purge = nil, true, tt.purge
+			}
+			result := verify(entries)
+			if result.Valid || !strings.Contains(result.Reason, tt.reason) {
+				t.Errorf("Expected the chain to fail with %q, got %+v", tt.reason, result)
+			}
+		})
+	}
+}
+
+func TestRelink(t *testing.T) {
+	entries := buildChain("a", "b", "c")
+
+	// An erasure rewrites the second event: its entry no longer matches until resealed
+	erased := "b-erased"
+	entries[1].Current = &erased
+	if result := verify(entries); result.Valid || result.FailedSeq != 2 {
+		t.Fatalf("Expected the chain to fail at the erased event, got %+v", result)
+	}
+
+	entries[1].EventHash = erased
+	relink(entries[0].Hash, entries[1:])
+	if result := verify(entries); !result.Valid || result.EventsChecked != 3 {
+		t.Errorf("Expected the resealed chain to be valid, got %+v", result)
+	}
+}
@@ -2,27 +2,71 @@ package store
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/kubechronicle/kubechronicle/internal/model"
 )
 
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
 // QueryFilters represents filters for querying change events.
 type QueryFilters struct {
-	ResourceKind string
-	Namespace    string
-	Name         string
-	Username     string
-	Operation    string
-	StartTime    *time.Time
-	EndTime      *time.Time
-	Allowed      *bool // nil = all, true = allowed only, false = blocked only
+	ResourceKind string     `json:"resource_kind,omitempty"`
+	Namespace    string     `json:"namespace,omitempty"`
+	Name         string     `json:"name,omitempty"`
+	Username     string     `json:"username,omitempty"`
+	Group        string     `json:"group,omitempty"` // Matches events whose actor groups contain this group
+	Operation    string     `json:"operation,omitempty"`
+	StartTime    *time.Time `json:"start_time,omitempty"`
+	EndTime      *time.Time `json:"end_time,omitempty"`
+	Allowed      *bool      `json:"allowed,omitempty"`        // nil = all, true = allowed only, false = blocked only
+	MinRiskScore int        `json:"min_risk_score,omitempty"` // Only exec events with at least this risk score; 0 = all events
+	Node         string     `json:"node,omitempty"`           // Only node maintenance events targeting this node
+
+	// Multi-value filters match events having any of the listed values (SQL IN).
+	// They are combined (AND) with the single-value filters above.
+	Operations    []string `json:"operations,omitempty"`
+	Namespaces    []string `json:"namespaces,omitempty"`
+	ResourceKinds []string `json:"resource_kinds,omitempty"`
+	Names         []string `json:"names,omitempty"`
+	Usernames     []string `json:"usernames,omitempty"`
+
+	// Exclusion filters drop events having any of the listed values (SQL NOT IN).
+	ExcludeOperations    []string `json:"exclude_operations,omitempty"`
+	ExcludeNamespaces    []string `json:"exclude_namespaces,omitempty"`
+	ExcludeResourceKinds []string `json:"exclude_resource_kinds,omitempty"`
+	ExcludeNames         []string `json:"exclude_names,omitempty"`
+	ExcludeUsernames     []string `json:"exclude_usernames,omitempty"`
+
+	// Fields limits which optional heavy fields (diff, object_snapshot, exec_metadata)
+	// are fetched. Empty means all fields are fetched.
+	Fields []string `json:"fields,omitempty"`
+
+	// CountOnly makes QueryEvents return only the total, and Groups with GroupBy, without
+	// reading any events. Pagination and Fields are ignored.
+	CountOnly bool `json:"count_only,omitempty"`
+
+	// GroupBy also counts the events of a count-only query per value of a field (see
+	// GroupByFields).
+	GroupBy string `json:"group_by,omitempty"`
+
+	// CountLimit stops a count-only query after this many events, so checking whether any
+	// event matches (1) doesn't count them all. 0 counts every event.
+	CountLimit int `json:"count_limit,omitempty"`
 }
 
 // PaginationParams represents pagination parameters.
 type PaginationParams struct {
-	Limit  int // Number of results per page
-	Offset int // Offset for pagination
+	Limit  int    // Number of results per page
+	Offset int    // Offset for pagination
+	SortBy string // Field the events are sorted by (see SortByFields); empty sorts by timestamp
+
+	// AsOf leaves out events recorded after this time, so the pages of a query read with
+	// the same AsOf don't shift as events are added. Nil reads the events recorded until
+	// the query, whose time is returned in QueryResult.AsOf for the following pages.
+	AsOf *time.Time
 }
 
 // SortOrder represents sort order.
@@ -36,18 +80,250 @@ const (
 // QueryResult represents a paginated query result.
 type QueryResult struct {
 	Events []*model.ChangeEvent
-	Total  int // Total number of events matching the query (before pagination)
+	Total  int          // Total number of events matching the query (before pagination)
+	Groups []GroupCount // Events per value of QueryFilters.GroupBy, most first
+	AsOf   time.Time    // Events recorded after this time were left out (see PaginationParams.AsOf)
+}
+
+// GroupCount is the number of events with a value of the field a count is grouped by.
+type GroupCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Listener is implemented by stores that can announce newly saved events,
+// allowing readers in other processes to follow writes as they happen.
+type Listener interface {
+	// Listen blocks until ctx is cancelled, calling fn with the ID of each newly saved event.
+	Listen(ctx context.Context, fn func(id string)) error
+}
+
+// BatchStore is implemented by stores that can look up many events in one round trip.
+type BatchStore interface {
+	// GetEventsByIDs returns the events with the given IDs that exist, in no particular order.
+	GetEventsByIDs(ctx context.Context, ids []string) ([]*model.ChangeEvent, error)
+}
+
+// LegalHoldStore is implemented by stores that support legal holds.
+// Events matching an active hold must never be removed by retention.
+type LegalHoldStore interface {
+	// CreateLegalHold persists a new hold and sets its ID and CreatedAt.
+	CreateLegalHold(ctx context.Context, hold *LegalHold) error
+
+	// ListLegalHolds returns holds, newest first. Released holds are included only if requested.
+	ListLegalHolds(ctx context.Context, includeReleased bool) ([]*LegalHold, error)
+
+	// ReleaseLegalHold marks a hold as released. Returns ErrNotFound if no active hold has the ID.
+	ReleaseLegalHold(ctx context.Context, id int64, releasedBy string) error
+}
+
+// RetentionStore is implemented by stores that purge the events older than the retention.
+type RetentionStore interface {
+	// GetRetentionPolicy returns the retention set through the admin API, or nil if none is.
+	GetRetentionPolicy(ctx context.Context) (*RetentionPolicy, error)
+
+	// SetRetentionPolicy replaces the retention set through the admin API and sets its UpdatedAt.
+	SetRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error
+
+	// DeleteRetentionPolicy removes the retention set through the admin API, if any.
+	DeleteRetentionPolicy(ctx context.Context) error
+
+	// PurgeEvents deletes up to limit of the oldest events stored before the given time that
+	// no active legal hold covers, and returns how many it deleted. It returns
+	// ErrPurgeInProgress while another process purges events.
+	PurgeEvents(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// ErasureStore is implemented by stores that support user-data erasure.
+type ErasureStore interface {
+	// PseudonymizeUser replaces the usernames of a user, such as their name and its
+	// ingestion pseudonym, in all stored events with a pseudonym and records an erasure
+	// audit entry.
+	PseudonymizeUser(ctx context.Context, usernames []string, requestedBy string) (*ErasureRecord, error)
+
+	// ListErasures returns the erasure audit entries, newest first.
+	ListErasures(ctx context.Context) ([]*ErasureRecord, error)
+}
+
+// PseudonymStore is implemented by stores that keep the values of the pseudonyms replacing
+// actor fields at ingestion, so admins can reverse them.
+type PseudonymStore interface {
+	// SavePseudonyms records the values of pseudonyms. Recorded pseudonyms are left unchanged.
+	SavePseudonyms(ctx context.Context, pseudonyms []*Pseudonym) error
+
+	// ResolvePseudonym returns the value a pseudonym replaced. Returns ErrNotFound if unknown.
+	ResolvePseudonym(ctx context.Context, pseudonym string) (*Pseudonym, error)
+}
+
+// SourceStore is implemented by stores that keep the latest event received from each event
+// source, so silent sources can be detected.
+type SourceStore interface {
+	// RecordSourceActivity adds the events received from sources since they last reported.
+	RecordSourceActivity(ctx context.Context, activity []*SourceActivity) error
+
+	// ListSourceActivity returns the activity of every source seen.
+	ListSourceActivity(ctx context.Context) ([]*SourceActivity, error)
+}
+
+// CaptureStore is implemented by stores that keep the admission requests captured by debug
+// capture sessions.
+type CaptureStore interface {
+	// StartCaptureSession stops the running session, if any, and starts session.
+	StartCaptureSession(ctx context.Context, session *CaptureSession) error
+
+	// StopCaptureSession stops the running session, or returns ErrNotFound.
+	StopCaptureSession(ctx context.Context, stoppedBy string) error
+
+	// GetCaptureSession returns the latest session, or nil if none was started.
+	GetCaptureSession(ctx context.Context) (*CaptureSession, error)
+
+	// SaveCapture stores a capture if its session is still running, and reports whether it did.
+	SaveCapture(ctx context.Context, capture *AdmissionCapture) (bool, error)
+
+	// ListCaptures returns the captures of a session, oldest first.
+	ListCaptures(ctx context.Context, sessionID int64, limit int) ([]*AdmissionCapture, error)
+
+	// DeleteCaptures deletes the sessions that ended before the given time, and their captures.
+	DeleteCaptures(ctx context.Context, before time.Time) (int64, error)
+}
+
+// HeartbeatStore is implemented by stores that measure the delivery of the heartbeat events
+// the producers emit.
+type HeartbeatStore interface {
+	// GetHeartbeatStats returns the heartbeats stored since the given time, per producer
+	// instance.
+	GetHeartbeatStats(ctx context.Context, since time.Time) ([]*HeartbeatStats, error)
+}
+
+// IntegrityStore is implemented by stores that keep a tamper-evident hash chain of events.
+type IntegrityStore interface {
+	// VerifyChain recomputes the hash chain and reports the first broken entry, if any.
+	VerifyChain(ctx context.Context) (*ChainVerification, error)
+}
+
+// DeadLetterStore is implemented by stores that keep events whose save or alert
+// delivery failed after all retries, so they can be inspected and retried later.
+type DeadLetterStore interface {
+	// AddDeadLetter persists a dead letter and sets its ID and CreatedAt.
+	AddDeadLetter(ctx context.Context, dl *DeadLetter) error
+
+	// ListDeadLetters returns dead letters of kind (all if empty), newest first, without the events' diff and snapshot.
+	ListDeadLetters(ctx context.Context, kind string, limit int) ([]*DeadLetter, error)
+
+	// GetDeadLetter returns a dead letter with its full event. Returns ErrNotFound if it doesn't exist.
+	GetDeadLetter(ctx context.Context, id int64) (*DeadLetter, error)
+
+	// RequeueDeadLetter marks a dead letter to be retried. Returns ErrNotFound if none is waiting with the ID.
+	RequeueDeadLetter(ctx context.Context, id int64, requeuedBy string) error
+
+	// ClaimRequeuedDeadLetters removes and returns up to limit requeued dead letters, oldest first.
+	ClaimRequeuedDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+
+	// DeleteDeadLetter removes a dead letter. Returns ErrNotFound if it doesn't exist.
+	DeleteDeadLetter(ctx context.Context, id int64) error
+
+	// PurgeDeadLetters removes the dead letters of kind (all if empty) and returns how many were removed.
+	PurgeDeadLetters(ctx context.Context, kind string) (int64, error)
+}
+
+// SubscriptionStore is implemented by stores that keep subscriptions of external
+// automation to change events.
+type SubscriptionStore interface {
+	// CreateSubscription persists a new subscription and sets its ID and CreatedAt.
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+
+	// ListSubscriptions returns all subscriptions, oldest first, including their secrets.
+	ListSubscriptions(ctx context.Context) ([]*Subscription, error)
+
+	// DeleteSubscription removes a subscription. Returns ErrNotFound if it doesn't exist.
+	DeleteSubscription(ctx context.Context, id int64) error
+
+	// RecordSubscriptionDelivery records whether the latest delivery to a subscription failed.
+	RecordSubscriptionDelivery(ctx context.Context, id int64, deliveryErr error) error
+}
+
+// RecordingStore is implemented by stores that link exec events to session recordings
+// kept by external agents.
+type RecordingStore interface {
+	// FindExecEvent returns the ID of the EXEC event of target. Returns ErrNotFound if there is none.
+	FindExecEvent(ctx context.Context, target ExecTarget) (string, error)
+
+	// AddRecording links a recording to its event and sets its ID and CreatedAt.
+	AddRecording(ctx context.Context, recording *Recording) error
+
+	// ListRecordings returns the recordings of an event, oldest first.
+	ListRecordings(ctx context.Context, eventID string) ([]*Recording, error)
+}
+
+// UsageStore is implemented by stores that account for the storage used per namespace.
+type UsageStore interface {
+	// GetUsage returns the usage of each namespace with events since the given time, largest first.
+	GetUsage(ctx context.Context, since time.Time) ([]*NamespaceUsage, error)
+}
+
+// ChurnStore is implemented by stores that report how often resources change.
+type ChurnStore interface {
+	// GetChurn returns the resources changed at least minChanges times since the given
+	// time, most changed first, and at most limit of them (0 for all).
+	GetChurn(ctx context.Context, since time.Time, minChanges int64, limit int) ([]*ResourceChurn, error)
+}
+
+// StatsStore is implemented by stores that keep hourly and daily event counts.
+type StatsStore interface {
+	// GetStats returns the event counts selected by query, oldest first.
+	GetStats(ctx context.Context, query StatsQuery) ([]*StatsBucket, error)
+}
+
+// EventStreamer is implemented by stores that read every event matching filters without
+// paging, for reports over long periods.
+type EventStreamer interface {
+	// StreamEvents calls fn with every event matching filters, oldest first.
+	StreamEvents(ctx context.Context, filters QueryFilters, fn func(*model.ChangeEvent) error) error
+}
+
+// ExportJobStore is implemented by stores that run export jobs, writing large result sets
+// to object storage in the background.
+type ExportJobStore interface {
+	// CreateExportJob persists a new pending export job and sets its CreatedAt.
+	CreateExportJob(ctx context.Context, job *ExportJob) error
+
+	// GetExportJob returns an export job. Returns ErrNotFound if it doesn't exist.
+	GetExportJob(ctx context.Context, id string) (*ExportJob, error)
+
+	// ClaimExportJob marks the next export job to run as running and returns it, or nil if there is none.
+	ClaimExportJob(ctx context.Context) (*ExportJob, error)
+
+	// UpdateExportJobProgress records the events written so far by a running export job.
+	UpdateExportJobProgress(ctx context.Context, id string, exported, total int64) error
+
+	// FinishExportJob records the outcome of a running export job.
+	FinishExportJob(ctx context.Context, job *ExportJob, jobErr error) error
+
+	// StreamEvents calls fn with every event matching filters, oldest first.
+	StreamEvents(ctx context.Context, filters QueryFilters, fn func(*model.ChangeEvent) error) error
+}
+
+// IndexAdvisorStore is implemented by stores that recommend and create indexes of the
+// JSONB fields event queries filter on.
+type IndexAdvisorStore interface {
+	// AdviseIndexes returns the indexes of the fields filtered on by at least minQueries queries, most queried first.
+	AdviseIndexes(ctx context.Context, minQueries int64) ([]*IndexAdvice, error)
+
+	// CreateIndex creates the index of a field. Returns ErrNotFound for unknown fields.
+	CreateIndex(ctx context.Context, field string) error
 }
 
 // Store defines the interface for persisting and querying change events.
 type Store interface {
-	// Save persists a change event.
+	// Save persists a change event. It returns ErrDuplicate if the event is already stored,
+	// so wrappers and callers don't export, deliver or alert on it again.
 	Save(event *model.ChangeEvent) error
 	
 	// Close closes the store connection.
 	Close() error
 	
-	// QueryEvents queries change events with filters, pagination, and sorting.
+	// QueryEvents queries change events with filters, pagination, and sorting. Heartbeat
+	// events are left out unless the filters select the HEARTBEAT operation.
 	QueryEvents(ctx context.Context, filters QueryFilters, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error)
 	
 	// GetEventByID retrieves a single change event by ID.
@@ -58,4 +334,7 @@ type Store interface {
 	
 	// GetUserActivity retrieves change events for a specific user.
 	GetUserActivity(ctx context.Context, username string, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error)
+
+	// GetGroupActivity retrieves change events made by members of a specific group.
+	GetGroupActivity(ctx context.Context, group string, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error)
 }
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2"
+)
+
+// NotifyChannel is the PostgreSQL NOTIFY channel used to announce newly saved events.
+// The payload is the event ID; listeners fetch the full event with GetEventByID
+// because NOTIFY payloads are limited to 8000 bytes.
+const NotifyChannel = "kubechronicle_change_events"
+
+// Listen subscribes to NotifyChannel and calls fn with the ID of every newly saved event.
+// It blocks until ctx is cancelled (returning nil) or the connection fails (returning an error).
+func (s *PostgreSQLStore) Listen(ctx context.Context, fn func(id string)) error {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+NotifyChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", NotifyChannel, err)
+	}
+	klog.Infof("Listening for change event notifications on %s", NotifyChannel)
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// Don't hand a connection in an unknown LISTEN state back to the pool
+			conn.Conn().Close(context.Background())
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		fn(notification.Payload)
+	}
+}
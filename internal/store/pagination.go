@@ -0,0 +1,57 @@
+package store
+
+import "fmt"
+
+// Page sizes of event queries used when none are configured.
+const (
+	DefaultPageSize    = 50
+	DefaultMaxPageSize = 1000
+)
+
+// PageSizes sets the number of events a page of results holds when no limit is requested,
+// and the largest limit allowed. Zero values use DefaultPageSize and DefaultMaxPageSize.
+type PageSizes struct {
+	Default int `json:"default"`
+	Max     int `json:"max"`
+}
+
+// DefaultLimit returns the limit of a page when none is requested.
+func (p PageSizes) DefaultLimit() int {
+	if p.Default > 0 {
+		return min(p.Default, p.MaxLimit())
+	}
+	return min(DefaultPageSize, p.MaxLimit())
+}
+
+// MaxLimit returns the largest limit allowed.
+func (p PageSizes) MaxLimit() int {
+	if p.Max > 0 {
+		return p.Max
+	}
+	return DefaultMaxPageSize
+}
+
+// Limit returns the limit applied to a requested limit: the default when none (or a
+// non-positive one) is requested, and at most the maximum.
+func (p PageSizes) Limit(requested int) int {
+	if requested <= 0 {
+		return p.DefaultLimit()
+	}
+	return min(requested, p.MaxLimit())
+}
+
+// Validate checks the page sizes.
+func (p PageSizes) Validate() error {
+	if p.Default < 0 || p.Max < 0 {
+		return fmt.Errorf("page sizes must not be negative")
+	}
+	if p.Default > p.MaxLimit() {
+		return fmt.Errorf("default page size (%d) exceeds the max page size (%d)", p.Default, p.MaxLimit())
+	}
+	return nil
+}
+
+// SetPageSizes sets the default and max page sizes of event queries.
+func (s *PostgreSQLStore) SetPageSizes(pageSizes PageSizes) {
+	s.pageSizes = pageSizes
+}
@@ -0,0 +1,37 @@
+package store
+
+import "testing"
+
+func TestPageSizes_Limit(t *testing.T) {
+	tests := []struct {
+		pageSizes PageSizes
+		requested int
+		want      int
+	}{
+		{PageSizes{}, 0, DefaultPageSize},
+		{PageSizes{}, -5, DefaultPageSize},
+		{PageSizes{}, 200, 200},
+		{PageSizes{}, 5000, DefaultMaxPageSize},
+		{PageSizes{Default: 20, Max: 100}, 0, 20},
+		{PageSizes{Default: 20, Max: 100}, 500, 100},
+		{PageSizes{Max: 10}, 0, 10}, // The default is capped by a lower max
+	}
+	for _, tt := range tests {
+		if got := tt.pageSizes.Limit(tt.requested); got != tt.want {
+			t.Errorf("%+v.Limit(%d) = %d, want %d", tt.pageSizes, tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestPageSizes_Validate(t *testing.T) {
+	for _, valid := range []PageSizes{{}, {Default: 100}, {Default: 10, Max: 10}, {Max: 5000}} {
+		if err := valid.Validate(); err != nil {
+			t.Errorf("%+v.Validate() error = %v", valid, err)
+		}
+	}
+	for _, invalid := range []PageSizes{{Default: -1}, {Max: -1}, {Default: 2000}, {Default: 100, Max: 50}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("%+v.Validate() succeeded, want an error", invalid)
+		}
+	}
+}
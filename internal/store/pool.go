@@ -0,0 +1,112 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolConfig sizes and tunes the PostgreSQL connection pool. Zero values use the defaults
+// of DefaultPoolConfig.
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+
+	// StatementCacheMode is pgx's query execution mode: cache_statement (default),
+	// cache_describe, describe_exec, exec or simple_protocol. Connection poolers in
+	// transaction mode (e.g. PgBouncer) need exec or simple_protocol.
+	StatementCacheMode string
+
+	// StatementTimeout makes the database cancel statements running longer than this.
+	// Statements are not limited when zero.
+	StatementTimeout time.Duration
+
+	// SaveTimeout is how long a change event save may take.
+	SaveTimeout time.Duration
+
+	// SlowQueryThreshold is how long an event query may take before it is logged as slow.
+	SlowQueryThreshold time.Duration
+}
+
+// DefaultPoolConfig returns the pool settings used when none are configured.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxConns:        25,
+		MinConns:        5,
+		MaxConnLifetime: 5 * time.Minute,
+		MaxConnIdleTime: 1 * time.Minute,
+		SaveTimeout:     5 * time.Second,
+
+		SlowQueryThreshold: time.Second,
+	}
+}
+
+// queryExecModes maps the statement cache modes to pgx's query execution modes.
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// Validate checks the pool settings.
+func (c PoolConfig) Validate() error {
+	if c.MaxConns < 0 || c.MinConns < 0 {
+		return fmt.Errorf("connection counts must not be negative")
+	}
+	if c.MaxConns > 0 && c.MinConns > c.MaxConns {
+		return fmt.Errorf("min connections (%d) exceed max connections (%d)", c.MinConns, c.MaxConns)
+	}
+	if c.MaxConnLifetime < 0 || c.MaxConnIdleTime < 0 || c.StatementTimeout < 0 || c.SaveTimeout < 0 || c.SlowQueryThreshold < 0 {
+		return fmt.Errorf("durations must not be negative")
+	}
+	if _, ok := queryExecModes[c.StatementCacheMode]; c.StatementCacheMode != "" && !ok {
+		return fmt.Errorf("unknown statement cache mode %q, must be one of cache_statement, cache_describe, describe_exec, exec, simple_protocol", c.StatementCacheMode)
+	}
+	return nil
+}
+
+// WithDefaults returns the settings with zero values replaced by the defaults. The default
+// minimum is capped at the maximum, so a small pool keeps no more connections than it allows.
+func (c PoolConfig) WithDefaults() PoolConfig {
+	defaults := DefaultPoolConfig()
+	if c.MaxConns == 0 {
+		c.MaxConns = defaults.MaxConns
+	}
+	if c.MinConns == 0 {
+		c.MinConns = min(defaults.MinConns, c.MaxConns)
+	}
+	if c.MaxConnLifetime == 0 {
+		c.MaxConnLifetime = defaults.MaxConnLifetime
+	}
+	if c.MaxConnIdleTime == 0 {
+		c.MaxConnIdleTime = defaults.MaxConnIdleTime
+	}
+	if c.SaveTimeout == 0 {
+		c.SaveTimeout = defaults.SaveTimeout
+	}
+	if c.SlowQueryThreshold == 0 {
+		c.SlowQueryThreshold = defaults.SlowQueryThreshold
+	}
+	return c
+}
+
+// apply sets the pool settings on a parsed pool configuration.
+func (c PoolConfig) apply(config *pgxpool.Config) {
+	config.MaxConns = c.MaxConns
+	config.MinConns = c.MinConns
+	config.MaxConnLifetime = c.MaxConnLifetime
+	config.MaxConnIdleTime = c.MaxConnIdleTime
+	if mode, ok := queryExecModes[c.StatementCacheMode]; ok {
+		config.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if c.StatementTimeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10)
+	}
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"strings"
 	"time"
@@ -10,29 +11,51 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"k8s.io/klog/v2"
 
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
 	"github.com/kubechronicle/kubechronicle/internal/model"
 )
 
 // PostgreSQLStore implements the Store interface using PostgreSQL.
 type PostgreSQLStore struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	encryptor *encryption.Encryptor // Encrypts diff and object_snapshot at rest (optional)
+
+	poolConfig         PoolConfig
+	saveTimeout        time.Duration
+	slowQueryThreshold time.Duration // Queries taking longer are logged (see observeQuery)
+	replica     *replica           // Read-only database event queries are sent to (optional)
+	breaker     circuitBreaker     // Short-circuits saves while the database is unreachable
+	stopMonitor context.CancelFunc // Stops the health monitor
+
+	rowLevelSecurity bool // Scoped reads set the namespace scope of the row-level security policies
+
+	pageSizes PageSizes // Default and max page sizes of event queries
 }
 
-// NewPostgreSQLStore creates a new PostgreSQL store and initializes the database schema.
+// NewPostgreSQLStore creates a new PostgreSQL store with the default pool settings and
+// initializes the database schema.
 func NewPostgreSQLStore(connectionString string) (*PostgreSQLStore, error) {
+	return NewPostgreSQLStoreWithPool(connectionString, DefaultPoolConfig())
+}
+
+// NewPostgreSQLStoreWithPool creates a new PostgreSQL store with the given pool settings and
+// initializes the database schema.
+func NewPostgreSQLStoreWithPool(connectionString string, poolConfig PoolConfig) (*PostgreSQLStore, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if err := poolConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pool config: %w", err)
+	}
+	poolConfig = poolConfig.WithDefaults()
+
 	config, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
 	// Configure connection pool
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnLifetime = 5 * time.Minute
-	config.MaxConnIdleTime = 1 * time.Minute
+	poolConfig.apply(config)
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -45,7 +68,7 @@ func NewPostgreSQLStore(connectionString string) (*PostgreSQLStore, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	store := &PostgreSQLStore{pool: pool}
+	store := &PostgreSQLStore{pool: pool, poolConfig: poolConfig, saveTimeout: poolConfig.SaveTimeout, slowQueryThreshold: poolConfig.SlowQueryThreshold}
 
 	// Initialize schema
 	if err := store.initSchema(ctx); err != nil {
@@ -53,6 +76,10 @@ func NewPostgreSQLStore(connectionString string) (*PostgreSQLStore, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	monitorCtx, stopMonitor := context.WithCancel(context.Background())
+	store.stopMonitor = stopMonitor
+	go store.monitorHealth(monitorCtx)
+
 	klog.Info("PostgreSQL store initialized successfully")
 	return store, nil
 }
@@ -141,76 +168,665 @@ func (s *PostgreSQLStore) initSchema(ctx context.Context) error {
 		return fmt.Errorf("failed to migrate exec_metadata column: %w", err)
 	}
 
-	// Create indexes if they don't exist (after columns are added)
-	indexSQL := `
-	CREATE INDEX IF NOT EXISTS idx_change_events_allowed ON change_events(allowed);
-	CREATE INDEX IF NOT EXISTS idx_change_events_block_pattern ON change_events(block_pattern) WHERE block_pattern IS NOT NULL;
-	CREATE INDEX IF NOT EXISTS idx_change_events_exec_metadata_gin ON change_events USING GIN (exec_metadata) WHERE exec_metadata IS NOT NULL;
-	`
-	_, err = s.pool.Exec(ctx, indexSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create indexes: %w", err)
+	// Add size_exceeded and object_size columns if they don't exist
+	migrateSizeExceededSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='size_exceeded') THEN
+			ALTER TABLE change_events ADD COLUMN size_exceeded BOOLEAN NOT NULL DEFAULT false;
+			ALTER TABLE change_events ADD COLUMN object_size INTEGER;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateSizeExceededSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate size_exceeded column: %w", err)
+	}
+
+	// Add sample_rate column if it doesn't exist
+	migrateSampleRateSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='sample_rate') THEN
+			ALTER TABLE change_events ADD COLUMN sample_rate INTEGER NOT NULL DEFAULT 1;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateSampleRateSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate sample_rate column: %w", err)
+	}
+
+	// Add block_rule column if it doesn't exist
+	migrateBlockRuleSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='block_rule') THEN
+			ALTER TABLE change_events ADD COLUMN block_rule JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateBlockRuleSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate block_rule column: %w", err)
+	}
+
+	// Add node_maintenance column if it doesn't exist
+	migrateNodeMaintenanceSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='node_maintenance') THEN
+			ALTER TABLE change_events ADD COLUMN node_maintenance JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateNodeMaintenanceSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate node_maintenance column: %w", err)
+	}
+
+	// Add credential_issuance column if it doesn't exist
+	migrateCredentialIssuanceSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='credential_issuance') THEN
+			ALTER TABLE change_events ADD COLUMN credential_issuance JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateCredentialIssuanceSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate credential_issuance column: %w", err)
+	}
+
+	// Add deployment column if it doesn't exist
+	migrateDeploymentSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='deployment') THEN
+			ALTER TABLE change_events ADD COLUMN deployment JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateDeploymentSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate deployment column: %w", err)
+	}
+
+	// Add cloud_change column if it doesn't exist
+	migrateCloudChangeSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='cloud_change') THEN
+			ALTER TABLE change_events ADD COLUMN cloud_change JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateCloudChangeSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate cloud_change column: %w", err)
+	}
+
+	// Add self_monitor column if it doesn't exist
+	migrateSelfMonitorSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='self_monitor') THEN
+			ALTER TABLE change_events ADD COLUMN self_monitor JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateSelfMonitorSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate self_monitor column: %w", err)
+	}
+
+	// Add schema_version column if it doesn't exist. Rows written before events were
+	// versioned are version 1.
+	migrateSchemaVersionSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='schema_version') THEN
+			ALTER TABLE change_events ADD COLUMN schema_version SMALLINT NOT NULL DEFAULT 1;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateSchemaVersionSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate schema_version column: %w", err)
+	}
+
+	// Add fingerprint column if it doesn't exist. Its unique index makes inserts of retried
+	// admission requests conflict, like inserts of stored IDs.
+	migrateFingerprintSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='fingerprint') THEN
+			ALTER TABLE change_events ADD COLUMN fingerprint VARCHAR(64);
+		END IF;
+	END $$;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_change_events_fingerprint ON change_events(fingerprint) WHERE fingerprint IS NOT NULL;
+	`
+	_, err = s.pool.Exec(ctx, migrateFingerprintSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate fingerprint column: %w", err)
+	}
+
+	// Add enrichment column if it doesn't exist
+	migrateEnrichmentSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='enrichment') THEN
+			ALTER TABLE change_events ADD COLUMN enrichment JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateEnrichmentSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate enrichment column: %w", err)
+	}
+
+	// Add heartbeat column if it doesn't exist
+	migrateHeartbeatSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='heartbeat') THEN
+			ALTER TABLE change_events ADD COLUMN heartbeat JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateHeartbeatSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate heartbeat column: %w", err)
+	}
+
+	// Add delete_collection column if it doesn't exist
+	migrateDeleteCollectionSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='delete_collection') THEN
+			ALTER TABLE change_events ADD COLUMN delete_collection JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateDeleteCollectionSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate delete_collection column: %w", err)
+	}
+
+	// Add namespace_cascade column if it doesn't exist
+	migrateNamespaceCascadeSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='namespace_cascade') THEN
+			ALTER TABLE change_events ADD COLUMN namespace_cascade JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateNamespaceCascadeSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate namespace_cascade column: %w", err)
+	}
+
+	// Add volume_deletion column if it doesn't exist
+	migrateVolumeDeletionSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='volume_deletion') THEN
+			ALTER TABLE change_events ADD COLUMN volume_deletion JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateVolumeDeletionSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate volume_deletion column: %w", err)
+	}
+
+	// Add crd_change column if it doesn't exist
+	migrateCRDChangeSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='crd_change') THEN
+			ALTER TABLE change_events ADD COLUMN crd_change JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateCRDChangeSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate crd_change column: %w", err)
+	}
+
+	// Add image_provenance column if it doesn't exist
+	migrateImageProvenanceSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='image_provenance') THEN
+			ALTER TABLE change_events ADD COLUMN image_provenance JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateImageProvenanceSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate image_provenance column: %w", err)
+	}
+
+	// Add exposure_change column if it doesn't exist
+	migrateExposureChangeSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='exposure_change') THEN
+			ALTER TABLE change_events ADD COLUMN exposure_change JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateExposureChangeSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate exposure_change column: %w", err)
+	}
+
+	// Add quota_impact column if it doesn't exist
+	migrateQuotaImpactSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='quota_impact') THEN
+			ALTER TABLE change_events ADD COLUMN quota_impact JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migrateQuotaImpactSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate quota_impact column: %w", err)
+	}
+
+	// Add pod_security_regression column if it doesn't exist
+	migratePodSecurityRegressionSQL := `
+	DO $$ 
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.columns 
+		               WHERE table_name='change_events' AND column_name='pod_security_regression') THEN
+			ALTER TABLE change_events ADD COLUMN pod_security_regression JSONB;
+		END IF;
+	END $$;
+	`
+	_, err = s.pool.Exec(ctx, migratePodSecurityRegressionSQL)
+	if err != nil {
+		return fmt.Errorf("failed to migrate pod_security_regression column: %w", err)
+	}
+
+	// Create indexes if they don't exist (after columns are added)
+	indexSQL := `
+	CREATE INDEX IF NOT EXISTS idx_change_events_allowed ON change_events(allowed);
+	CREATE INDEX IF NOT EXISTS idx_change_events_block_pattern ON change_events(block_pattern) WHERE block_pattern IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_change_events_exec_metadata_gin ON change_events USING GIN (exec_metadata) WHERE exec_metadata IS NOT NULL;
+	CREATE INDEX IF NOT EXISTS idx_change_events_actor_groups_gin ON change_events USING GIN ((actor->'groups'));
+	CREATE INDEX IF NOT EXISTS idx_change_events_namespace_timestamp ON change_events(namespace, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_change_events_kind_timestamp ON change_events(resource_kind, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_change_events_username_timestamp ON change_events((actor->>'username'), timestamp);
+	CREATE INDEX IF NOT EXISTS idx_change_events_operation_timestamp ON change_events(operation, timestamp);
+	`
+	_, err = s.pool.Exec(ctx, indexSQL)
+	if err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+
+	// Trigram indexes speed up wildcard (LIKE) filters on namespace and name.
+	// pg_trgm may be unavailable or require privileges we don't have, so this is best-effort.
+	trigramSQL := `
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+	CREATE INDEX IF NOT EXISTS idx_change_events_namespace_trgm ON change_events USING GIN (namespace gin_trgm_ops);
+	CREATE INDEX IF NOT EXISTS idx_change_events_name_trgm ON change_events USING GIN (name gin_trgm_ops);
+	`
+	if _, err := s.pool.Exec(ctx, trigramSQL); err != nil {
+		klog.Warningf("Failed to create trigram indexes (wildcard filters will still work, but slower): %v", err)
+	}
+
+	if err := s.initLegalHoldSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initPseudonymSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initSourceSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initCaptureSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initErasureSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initIntegritySchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initRetentionSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initDeadLetterSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initRecordingSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initSubscriptionSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initExportJobSchema(ctx); err != nil {
+		return err
+	}
+
+	if err := s.initStatsSchema(ctx); err != nil {
+		return err
+	}
+
+	klog.V(2).Info("Database schema initialized")
+	return nil
+}
+
+// duplicateEvents counts saved events that were already stored, such as retried admission
+// requests.
+var duplicateEvents = expvar.NewInt("store_duplicate_events_total")
+
+// Save persists a change event to the database. While the database is unreachable, saves
+// are short-circuited and fail immediately with ErrUnavailable. Events already stored, with
+// the same ID or fingerprint, are skipped with ErrDuplicate.
+func (s *PostgreSQLStore) Save(event *model.ChangeEvent) error {
+	if !s.breaker.allow() {
+		shortCircuitedSaves.Add(1)
+		return ErrUnavailable
+	}
+	err := s.save(event)
+	s.breaker.record(err)
+	return err
+}
+
+// save persists a change event to the database.
+func (s *PostgreSQLStore) save(event *model.ChangeEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.saveTimeout)
+	defer cancel()
+
+	insertSQL := `
+		INSERT INTO change_events (
+			id, timestamp, operation, resource_kind, namespace, name,
+			actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata,
+			size_exceeded, object_size, sample_rate, block_rule, node_maintenance, credential_issuance, deployment,
+			cloud_change, schema_version, self_monitor, fingerprint, enrichment, heartbeat, delete_collection, namespace_cascade, volume_deletion, crd_change, image_provenance, exposure_change, quota_impact, pod_security_regression
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34
+		)
+		ON CONFLICT DO NOTHING
+	`
+
+	values, err := s.eventValues(event)
+	if err != nil {
+		return err
+	}
+
+	// The event and its integrity chain entry are written atomically
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, insertSQL, values...)
+	if err != nil {
+		return fmt.Errorf("failed to insert change event: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		// A retried admission request or an event saved before
+		duplicateEvents.Add(1)
+		klog.V(2).Infof("Change event %s is already stored, skipping it", event.ID)
+		return ErrDuplicate
+	}
+	if err := appendToChain(ctx, tx, event.ID); err != nil {
+		return err
+	}
+	if err := addToStats(ctx, tx, []string{event.ID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit change event: %w", err)
+	}
+
+	// Notify listeners (e.g. API servers in other processes) about the new event
+	if _, err := s.pool.Exec(ctx, "SELECT pg_notify($1, $2)", NotifyChannel, event.ID); err != nil {
+		klog.Warningf("Failed to notify listeners about change event %s: %v", event.ID, err)
+	}
+
+	return nil
+}
+
+// eventValues returns the change_events column values of an event, in the order of the
+// insert statement, marshalling (and encrypting) its JSONB fields.
+func (s *PostgreSQLStore) eventValues(event *model.ChangeEvent) ([]interface{}, error) {
+	// Marshal JSONB fields
+	actor := event.Actor
+	if actor.Groups == nil {
+		actor.Groups = []string{}
+	}
+	actorJSON, err := json.Marshal(actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal actor: %w", err)
+	}
+
+	sourceJSON, err := json.Marshal(event.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal source: %w", err)
+	}
+
+	var diffJSON []byte
+	if len(event.Diff) > 0 {
+		diffJSON, err = json.Marshal(event.Diff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal diff: %w", err)
+		}
+	}
+
+	var snapshotJSON []byte
+	if event.ObjectSnapshot != nil {
+		snapshotJSON, err = json.Marshal(event.ObjectSnapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal object snapshot: %w", err)
+		}
+	}
+
+	if s.encryptor != nil {
+		if diffJSON, err = s.encryptColumn(diffJSON); err != nil {
+			return nil, fmt.Errorf("failed to encrypt diff: %w", err)
+		}
+		if snapshotJSON, err = s.encryptColumn(snapshotJSON); err != nil {
+			return nil, fmt.Errorf("failed to encrypt object snapshot: %w", err)
+		}
+	}
+
+	var execMetadataJSON []byte
+	if event.ExecMetadata != nil {
+		execMetadataJSON, err = json.Marshal(event.ExecMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal exec metadata: %w", err)
+		}
+	}
+
+	var blockRuleJSON []byte
+	if event.BlockRule != nil {
+		blockRuleJSON, err = json.Marshal(event.BlockRule)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal block rule: %w", err)
+		}
+	}
+
+	var nodeMaintenanceJSON []byte
+	if event.NodeMaintenance != nil {
+		nodeMaintenanceJSON, err = json.Marshal(event.NodeMaintenance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal node maintenance: %w", err)
+		}
+	}
+
+	var credentialIssuanceJSON []byte
+	if event.CredentialIssuance != nil {
+		credentialIssuanceJSON, err = json.Marshal(event.CredentialIssuance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal credential issuance: %w", err)
+		}
+	}
+
+	var deploymentJSON []byte
+	if event.Deployment != nil {
+		deploymentJSON, err = json.Marshal(event.Deployment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal deployment: %w", err)
+		}
+	}
+
+	var cloudChangeJSON []byte
+	if event.CloudChange != nil {
+		cloudChangeJSON, err = json.Marshal(event.CloudChange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal cloud change: %w", err)
+		}
+	}
+
+	var selfMonitorJSON []byte
+	if event.SelfMonitor != nil {
+		selfMonitorJSON, err = json.Marshal(event.SelfMonitor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal self-monitoring finding: %w", err)
+		}
+	}
+
+	var enrichmentJSON []byte
+	if len(event.Enrichment) > 0 {
+		enrichmentJSON, err = json.Marshal(event.Enrichment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal enrichment: %w", err)
+		}
+	}
+
+	var heartbeatJSON []byte
+	if event.Heartbeat != nil {
+		heartbeatJSON, err = json.Marshal(event.Heartbeat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal heartbeat: %w", err)
+		}
+	}
+
+	var deleteCollectionJSON []byte
+	if event.DeleteCollection != nil {
+		deleteCollectionJSON, err = json.Marshal(event.DeleteCollection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal delete collection: %w", err)
+		}
 	}
 
-	klog.V(2).Info("Database schema initialized")
-	return nil
-}
-
-// Save persists a change event to the database.
-func (s *PostgreSQLStore) Save(event *model.ChangeEvent) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	var namespaceCascadeJSON []byte
+	if event.NamespaceCascade != nil {
+		namespaceCascadeJSON, err = json.Marshal(event.NamespaceCascade)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal namespace cascade: %w", err)
+		}
+	}
 
-	// Marshal JSONB fields
-	actorJSON, err := json.Marshal(event.Actor)
-	if err != nil {
-		return fmt.Errorf("failed to marshal actor: %w", err)
+	var volumeDeletionJSON []byte
+	if event.VolumeDeletion != nil {
+		volumeDeletionJSON, err = json.Marshal(event.VolumeDeletion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal volume deletion: %w", err)
+		}
 	}
 
-	sourceJSON, err := json.Marshal(event.Source)
-	if err != nil {
-		return fmt.Errorf("failed to marshal source: %w", err)
+	var crdChangeJSON []byte
+	if event.CRDChange != nil {
+		crdChangeJSON, err = json.Marshal(event.CRDChange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal CRD change: %w", err)
+		}
 	}
 
-	var diffJSON []byte
-	if len(event.Diff) > 0 {
-		diffJSON, err = json.Marshal(event.Diff)
+	var imageProvenanceJSON []byte
+	if event.ImageProvenance != nil {
+		imageProvenanceJSON, err = json.Marshal(event.ImageProvenance)
 		if err != nil {
-			return fmt.Errorf("failed to marshal diff: %w", err)
+			return nil, fmt.Errorf("failed to marshal image provenance: %w", err)
 		}
 	}
 
-	var snapshotJSON []byte
-	if event.ObjectSnapshot != nil {
-		snapshotJSON, err = json.Marshal(event.ObjectSnapshot)
+	var exposureChangeJSON []byte
+	if event.ExposureChange != nil {
+		exposureChangeJSON, err = json.Marshal(event.ExposureChange)
 		if err != nil {
-			return fmt.Errorf("failed to marshal object snapshot: %w", err)
+			return nil, fmt.Errorf("failed to marshal exposure change: %w", err)
 		}
 	}
 
-	var execMetadataJSON []byte
-	if event.ExecMetadata != nil {
-		execMetadataJSON, err = json.Marshal(event.ExecMetadata)
+	var quotaImpactJSON []byte
+	if event.QuotaImpact != nil {
+		quotaImpactJSON, err = json.Marshal(event.QuotaImpact)
 		if err != nil {
-			return fmt.Errorf("failed to marshal exec metadata: %w", err)
+			return nil, fmt.Errorf("failed to marshal quota impact: %w", err)
 		}
 	}
 
-	insertSQL := `
-		INSERT INTO change_events (
-			id, timestamp, operation, resource_kind, namespace, name,
-			actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
-		)
-		ON CONFLICT (id) DO NOTHING
-	`
+	var podSecurityRegressionJSON []byte
+	if event.PodSecurityRegression != nil {
+		podSecurityRegressionJSON, err = json.Marshal(event.PodSecurityRegression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal pod security regression: %w", err)
+		}
+	}
 
 	// Set default values if not set
 	allowed := event.Allowed
 	blockPattern := event.BlockPattern
+	var objectSize *int
+	if event.SizeExceeded {
+		objectSize = &event.ObjectSize
+	}
+	sampleRate := event.SampleRate
+	if sampleRate < 1 {
+		sampleRate = 1 // Every event recorded
+	}
+	schemaVersion := event.SchemaVersion
+	if schemaVersion == 0 {
+		schemaVersion = model.SchemaVersion // A new event
+	}
+	var fingerprint *string // NULL unless set, as it must be unique
+	if event.Fingerprint != "" {
+		fingerprint = &event.Fingerprint
+	}
 
-	_, err = s.pool.Exec(ctx, insertSQL,
+	return []interface{}{
 		event.ID,
 		event.Timestamp,
 		event.Operation,
@@ -224,17 +840,38 @@ func (s *PostgreSQLStore) Save(event *model.ChangeEvent) error {
 		allowed,
 		blockPattern,
 		execMetadataJSON,
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to insert change event: %w", err)
-	}
-
-	return nil
+		event.SizeExceeded,
+		objectSize,
+		sampleRate,
+		blockRuleJSON,
+		nodeMaintenanceJSON,
+		credentialIssuanceJSON,
+		deploymentJSON,
+		cloudChangeJSON,
+		schemaVersion,
+		selfMonitorJSON,
+		fingerprint,
+		enrichmentJSON,
+		heartbeatJSON,
+		deleteCollectionJSON,
+		namespaceCascadeJSON,
+		volumeDeletionJSON,
+		crdChangeJSON,
+		imageProvenanceJSON,
+		exposureChangeJSON,
+		quotaImpactJSON,
+		podSecurityRegressionJSON,
+	}, nil
 }
 
 // Close closes the database connection pool.
 func (s *PostgreSQLStore) Close() error {
+	if s.stopMonitor != nil {
+		s.stopMonitor()
+	}
+	if s.replica != nil {
+		s.replica.close()
+	}
 	if s.pool != nil {
 		s.pool.Close()
 		klog.Info("PostgreSQL store closed")
@@ -247,34 +884,173 @@ func (s *PostgreSQLStore) HealthCheck(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
 
-// QueryEvents queries change events with filters, pagination, and sorting.
+// QueryEvents queries change events with filters, pagination, and sorting. Queries are sent
+// to the read replica if one is set and usable, and retried on the primary if it fails.
 func (s *PostgreSQLStore) QueryEvents(ctx context.Context, filters QueryFilters, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error) {
-	// Build WHERE clause
+	start := time.Now()
+	recordJSONBFilters(filters)
+	pool := s.readPool()
+	result, err := s.queryEvents(ctx, pool, filters, pagination, sortOrder)
+	if err != nil && s.readFailed(pool, err) {
+		result, err = s.queryEvents(ctx, s.pool, filters, pagination, sortOrder)
+	}
+
+	name, rows := "events", 0
+	if filters.CountOnly {
+		name = "count"
+	}
+	if result != nil {
+		rows = len(result.Events) + len(result.Groups)
+	}
+	s.observeQuery(name, start, rows, func() string { return describeEventQuery(filters, pagination, sortOrder) })
+	return result, err
+}
+
+// queryEvents queries change events on pool.
+func (s *PostgreSQLStore) queryEvents(ctx context.Context, pool *pgxpool.Pool, filters QueryFilters, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error) {
+	whereSQL, args := buildWhereClause(filters)
+	whereSQL = excludeHeartbeats(whereSQL, filters)
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", len(args)+1); condition != "" {
+		if whereSQL == "" {
+			whereSQL = "WHERE " + condition
+		} else {
+			whereSQL += " AND " + condition
+		}
+		args = append(args, scopeArgs...)
+	}
+	// Pages leave out events recorded after AsOf, or after the count query when it is nil
+	asOfArg := -1
+	if !filters.CountOnly {
+		condition := fmt.Sprintf("created_at <= COALESCE($%d::timestamptz, now())", len(args)+1)
+		if whereSQL == "" {
+			whereSQL = "WHERE " + condition
+		} else {
+			whereSQL += " AND " + condition
+		}
+		asOfArg = len(args)
+		args = append(args, pagination.AsOf)
+	}
+	argIdx := len(args) + 1
+
+	// Determine sort order
+	orderSQL, err := buildOrderByClause(pagination.SortBy, sortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *QueryResult
+	err = s.scoped(ctx, pool, func(q querier) error {
+		var err error
+		if filters.CountOnly {
+			result, err = s.countEvents(ctx, q, whereSQL, args, argIdx, filters)
+		} else {
+			result, err = s.queryEventPage(ctx, q, whereSQL, args, argIdx, asOfArg, orderSQL, filters.Fields, pagination)
+		}
+		return err
+	})
+	return result, err
+}
+
+// queryEventPage counts the events matching whereSQL and returns a page of them. The
+// argument asOfArg is the pagination's AsOf, which is set to the time of the count query
+// when nil so the page is read as of the same time.
+func (s *PostgreSQLStore) queryEventPage(ctx context.Context, q querier, whereSQL string, args []interface{}, argIdx, asOfArg int, orderSQL string, fields []string, pagination PaginationParams) (*QueryResult, error) {
+	// Count total matching records
+	countSQL := fmt.Sprintf("SELECT COUNT(*), COALESCE($%d::timestamptz, now()) FROM change_events %s", asOfArg+1, whereSQL)
+	var total int
+	var asOf time.Time
+	err := q.QueryRow(ctx, countSQL, args...).Scan(&total, &asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	args[asOfArg] = asOf
+
+	// Query events with pagination
+	limit := s.pageSizes.Limit(pagination.Limit)
+
+	querySQL := fmt.Sprintf(`
+		SELECT %s
+		FROM change_events
+		%s
+		%s
+		LIMIT $%d OFFSET $%d
+	`, selectColumns(fields), whereSQL, orderSQL, argIdx, argIdx+1)
+
+	args = append(args, limit, pagination.Offset)
+
+	rows, err := q.Query(ctx, querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*model.ChangeEvent{}
+	for rows.Next() {
+		event, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return &QueryResult{
+		Events: events,
+		Total:  total,
+		AsOf:   asOf,
+	}, nil
+}
+
+// buildWhereClause builds the WHERE clause and positional arguments for the given filters.
+func buildWhereClause(filters QueryFilters) (string, []interface{}) {
+	whereClauses, args := buildConditions(filters, 1)
+
+	whereSQL := ""
+	if len(whereClauses) > 0 {
+		whereSQL = "WHERE " + strings.Join(whereClauses, " AND ")
+	}
+
+	return whereSQL, args
+}
+
+// buildConditions builds the individual filter conditions, numbering
+// positional placeholders from startIdx.
+func buildConditions(filters QueryFilters, startIdx int) ([]string, []interface{}) {
 	whereClauses := []string{}
 	args := []interface{}{}
-	argIdx := 1
+	argIdx := startIdx
 
 	if filters.ResourceKind != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("resource_kind = $%d", argIdx))
-		args = append(args, filters.ResourceKind)
+		whereClauses = append(whereClauses, fmt.Sprintf("resource_kind %s $%d", matchOperator(filters.ResourceKind), argIdx))
+		args = append(args, matchValue(filters.ResourceKind))
 		argIdx++
 	}
 
 	if filters.Namespace != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("namespace = $%d", argIdx))
-		args = append(args, filters.Namespace)
+		whereClauses = append(whereClauses, fmt.Sprintf("namespace %s $%d", matchOperator(filters.Namespace), argIdx))
+		args = append(args, matchValue(filters.Namespace))
 		argIdx++
 	}
 
 	if filters.Name != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("name = $%d", argIdx))
-		args = append(args, filters.Name)
+		whereClauses = append(whereClauses, fmt.Sprintf("name %s $%d", matchOperator(filters.Name), argIdx))
+		args = append(args, matchValue(filters.Name))
 		argIdx++
 	}
 
 	if filters.Username != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("actor->>'username' = $%d", argIdx))
-		args = append(args, filters.Username)
+		whereClauses = append(whereClauses, fmt.Sprintf("actor->>'username' %s $%d", matchOperator(filters.Username), argIdx))
+		args = append(args, matchValue(filters.Username))
+		argIdx++
+	}
+
+	if filters.Group != "" {
+		groupJSON, _ := json.Marshal([]string{filters.Group})
+		whereClauses = append(whereClauses, fmt.Sprintf("actor->'groups' @> $%d::jsonb", argIdx))
+		args = append(args, string(groupJSON))
 		argIdx++
 	}
 
@@ -302,81 +1078,146 @@ func (s *PostgreSQLStore) QueryEvents(ctx context.Context, filters QueryFilters,
 		argIdx++
 	}
 
-	whereSQL := ""
-	if len(whereClauses) > 0 {
-		whereSQL = "WHERE " + strings.Join(whereClauses, " AND ")
-	}
-
-	// Determine sort order
-	orderSQL := "DESC"
-	if sortOrder == SortOrderAsc {
-		orderSQL = "ASC"
+	if filters.MinRiskScore > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("(exec_metadata->>'risk_score')::int >= $%d", argIdx))
+		args = append(args, filters.MinRiskScore)
+		argIdx++
 	}
 
-	// Count total matching records
-	countSQL := fmt.Sprintf("SELECT COUNT(*) FROM change_events %s", whereSQL)
-	var total int
-	err := s.pool.QueryRow(ctx, countSQL, args...).Scan(&total)
-	if err != nil {
-		return nil, fmt.Errorf("failed to count events: %w", err)
+	if filters.Node != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("node_maintenance->>'node_name' %s $%d", matchOperator(filters.Node), argIdx))
+		args = append(args, matchValue(filters.Node))
+		argIdx++
 	}
 
-	// Query events with pagination
-	limit := pagination.Limit
-	if limit <= 0 {
-		limit = 50 // Default limit
+	// Multi-value and exclusion filters
+	valueFilters := []struct {
+		column  string
+		include []string
+		exclude []string
+	}{
+		{"operation", filters.Operations, filters.ExcludeOperations},
+		{"namespace", filters.Namespaces, filters.ExcludeNamespaces},
+		{"resource_kind", filters.ResourceKinds, filters.ExcludeResourceKinds},
+		{"name", filters.Names, filters.ExcludeNames},
+		{"actor->>'username'", filters.Usernames, filters.ExcludeUsernames},
 	}
-	if limit > 1000 {
-		limit = 1000 // Max limit
+	for _, vf := range valueFilters {
+		if len(vf.include) > 0 {
+			op, values := matchAnyOperator(vf.include)
+			whereClauses = append(whereClauses, fmt.Sprintf("%s %s ANY($%d)", vf.column, op, argIdx))
+			args = append(args, values)
+			argIdx++
+		}
+		if len(vf.exclude) > 0 {
+			op, values := matchAnyOperator(vf.exclude)
+			whereClauses = append(whereClauses, fmt.Sprintf("NOT (%s %s ANY($%d))", vf.column, op, argIdx))
+			args = append(args, values)
+			argIdx++
+		}
 	}
 
-	querySQL := fmt.Sprintf(`
-		SELECT id, timestamp, operation, resource_kind, namespace, name,
-		       actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata
-		FROM change_events
-		%s
-		ORDER BY timestamp %s
-		LIMIT $%d OFFSET $%d
-	`, whereSQL, orderSQL, argIdx, argIdx+1)
+	return whereClauses, args
+}
 
-	args = append(args, limit, pagination.Offset)
+// hasWildcard reports whether a filter value uses * wildcards, using the same
+// semantics as ignore/block patterns (* matches any sequence of characters).
+func hasWildcard(value string) bool {
+	return strings.Contains(value, "*")
+}
 
-	rows, err := s.pool.Query(ctx, querySQL, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query events: %w", err)
+// wildcardToLike converts a * wildcard pattern to a SQL LIKE pattern,
+// escaping LIKE metacharacters so they match literally.
+func wildcardToLike(pattern string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+	return strings.ReplaceAll(escaped, "*", "%")
+}
+
+// matchOperator returns the SQL operator for a single filter value.
+func matchOperator(value string) string {
+	if hasWildcard(value) {
+		return "LIKE"
 	}
-	defer rows.Close()
+	return "="
+}
 
-	events := []*model.ChangeEvent{}
-	for rows.Next() {
-		event, err := s.scanEvent(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+// matchValue returns the SQL argument for a single filter value.
+func matchValue(value string) string {
+	if hasWildcard(value) {
+		return wildcardToLike(value)
+	}
+	return value
+}
+
+// matchAnyOperator returns the SQL operator and arguments for a multi-value filter.
+// If any value has wildcards, all values are converted to LIKE patterns.
+func matchAnyOperator(values []string) (string, []string) {
+	wildcard := false
+	for _, v := range values {
+		if hasWildcard(v) {
+			wildcard = true
+			break
 		}
-		events = append(events, event)
+	}
+	if !wildcard {
+		return "=", values
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	patterns := make([]string, len(values))
+	for i, v := range values {
+		patterns[i] = wildcardToLike(v)
 	}
+	return "LIKE", patterns
+}
 
-	return &QueryResult{
-		Events: events,
-		Total:  total,
-	}, nil
+// selectColumns builds the SELECT column list for event queries.
+// Heavy JSONB columns (diff, object_snapshot, exec_metadata) not present in fields are replaced by NULL so scanEvent
+// can be reused unchanged. An empty fields list selects everything.
+func selectColumns(fields []string) string {
+	include := func(column string) string {
+		if len(fields) == 0 {
+			return column
+		}
+		for _, f := range fields {
+			if f == column {
+				return column
+			}
+		}
+		return "NULL"
+	}
+
+	return fmt.Sprintf("id, timestamp, operation, resource_kind, namespace, name, actor, source, %s, %s, allowed, block_pattern, %s, size_exceeded, object_size, sample_rate, block_rule, node_maintenance, credential_issuance, deployment, cloud_change, schema_version, self_monitor, enrichment, heartbeat, delete_collection, namespace_cascade, volume_deletion, crd_change, image_provenance, exposure_change, quota_impact, pod_security_regression, fingerprint",
+		include("diff"), include("object_snapshot"), include("exec_metadata"))
 }
 
 // GetEventByID retrieves a single change event by ID.
 func (s *PostgreSQLStore) GetEventByID(ctx context.Context, id string) (*model.ChangeEvent, error) {
 	querySQL := `
 		SELECT id, timestamp, operation, resource_kind, namespace, name,
-		       actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata
+		       actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata,
+		       size_exceeded, object_size, sample_rate, block_rule, node_maintenance, credential_issuance, deployment, cloud_change,
+		       schema_version, self_monitor, enrichment, heartbeat, delete_collection, namespace_cascade, volume_deletion, crd_change, image_provenance, exposure_change, quota_impact, pod_security_regression, fingerprint
 		FROM change_events
 		WHERE id = $1
 	`
+	args := []interface{}{id}
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", 2); condition != "" {
+		querySQL += " AND " + condition
+		args = append(args, scopeArgs...)
+	}
 
-	row := s.pool.QueryRow(ctx, querySQL, id)
-	event, err := s.scanEventRow(row)
+	start := time.Now()
+	var event *model.ChangeEvent
+	err := s.scoped(ctx, s.pool, func(q querier) error {
+		var err error
+		event, err = s.scanEventRow(q.QueryRow(ctx, querySQL, args...))
+		return err
+	})
+	rows := 0
+	if event != nil {
+		rows = 1
+	}
+	s.observeQuery("event", start, rows, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event by ID: %w", err)
 	}
@@ -384,6 +1225,49 @@ func (s *PostgreSQLStore) GetEventByID(ctx context.Context, id string) (*model.C
 	return event, nil
 }
 
+// GetEventsByIDs retrieves the change events with the given IDs in one query. Events that
+// don't exist, or are outside the caller's tenancy scope, are left out; the events found
+// are returned in no particular order.
+func (s *PostgreSQLStore) GetEventsByIDs(ctx context.Context, ids []string) ([]*model.ChangeEvent, error) {
+	querySQL := `
+		SELECT id, timestamp, operation, resource_kind, namespace, name,
+		       actor, source, diff, object_snapshot, allowed, block_pattern, exec_metadata,
+		       size_exceeded, object_size, sample_rate, block_rule, node_maintenance, credential_issuance, deployment, cloud_change,
+		       schema_version, self_monitor, enrichment, heartbeat, delete_collection, namespace_cascade, volume_deletion, crd_change, image_provenance, exposure_change, quota_impact, pod_security_regression, fingerprint
+		FROM change_events
+		WHERE id = ANY($1)
+	`
+	args := []interface{}{ids}
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", 2); condition != "" {
+		querySQL += " AND " + condition
+		args = append(args, scopeArgs...)
+	}
+
+	start := time.Now()
+	events := []*model.ChangeEvent{}
+	err := s.scoped(ctx, s.pool, func(q querier) error {
+		rows, err := q.Query(ctx, querySQL, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			event, err := s.scanEvent(rows)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return rows.Err()
+	})
+	s.observeQuery("events_by_ids", start, len(events), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events by IDs: %w", err)
+	}
+
+	return events, nil
+}
+
 // GetResourceHistory retrieves the change history for a specific resource.
 func (s *PostgreSQLStore) GetResourceHistory(ctx context.Context, kind, namespace, name string, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error) {
 	filters := QueryFilters{
@@ -402,6 +1286,14 @@ func (s *PostgreSQLStore) GetUserActivity(ctx context.Context, username string,
 	return s.QueryEvents(ctx, filters, pagination, sortOrder)
 }
 
+// GetGroupActivity retrieves change events made by members of a specific group.
+func (s *PostgreSQLStore) GetGroupActivity(ctx context.Context, group string, pagination PaginationParams, sortOrder SortOrder) (*QueryResult, error) {
+	filters := QueryFilters{
+		Group: group,
+	}
+	return s.QueryEvents(ctx, filters, pagination, sortOrder)
+}
+
 // scanEvent scans a single event from pgx.Rows.
 func (s *PostgreSQLStore) scanEvent(rows interface {
 	Scan(dest ...interface{}) error
@@ -420,11 +1312,34 @@ func (s *PostgreSQLStore) scanEvent(rows interface {
 		allowed        bool
 		blockPattern   *string
 		execMetadataJSON []byte
+		sizeExceeded   bool
+		objectSize     *int
+		sampleRate     int
+		blockRuleJSON  []byte
+		nodeMaintenanceJSON []byte
+		credentialIssuanceJSON []byte
+		deploymentJSON []byte
+		cloudChangeJSON []byte
+		schemaVersion  int
+		selfMonitorJSON []byte
+		enrichmentJSON []byte
+		heartbeatJSON  []byte
+		deleteCollectionJSON []byte
+		namespaceCascadeJSON []byte
+		volumeDeletionJSON   []byte
+		crdChangeJSON        []byte
+		imageProvenanceJSON  []byte
+		exposureChangeJSON   []byte
+		quotaImpactJSON      []byte
+		podSecurityRegressionJSON []byte
+		fingerprint    *string
 	)
 
 	err := rows.Scan(
 		&id, &timestamp, &operation, &resourceKind, &namespace, &name,
 		&actorJSON, &sourceJSON, &diffJSON, &snapshotJSON, &allowed, &blockPattern, &execMetadataJSON,
+		&sizeExceeded, &objectSize, &sampleRate, &blockRuleJSON, &nodeMaintenanceJSON, &credentialIssuanceJSON, &deploymentJSON, &cloudChangeJSON,
+		&schemaVersion, &selfMonitorJSON, &enrichmentJSON, &heartbeatJSON, &deleteCollectionJSON, &namespaceCascadeJSON, &volumeDeletionJSON, &crdChangeJSON, &imageProvenanceJSON, &exposureChangeJSON, &quotaImpactJSON, &podSecurityRegressionJSON, &fingerprint,
 	)
 	if err != nil {
 		return nil, err
@@ -438,11 +1353,22 @@ func (s *PostgreSQLStore) scanEvent(rows interface {
 		Namespace:    namespace,
 		Name:         name,
 		Allowed:      allowed,
+		SizeExceeded: sizeExceeded,
+		SchemaVersion: schemaVersion,
 	}
 
 	if blockPattern != nil {
 		event.BlockPattern = *blockPattern
 	}
+	if objectSize != nil {
+		event.ObjectSize = *objectSize
+	}
+	if fingerprint != nil {
+		event.Fingerprint = *fingerprint
+	}
+	if sampleRate > 1 {
+		event.SampleRate = sampleRate
+	}
 
 	// Unmarshal JSONB fields
 	if err := json.Unmarshal(actorJSON, &event.Actor); err != nil {
@@ -453,6 +1379,13 @@ func (s *PostgreSQLStore) scanEvent(rows interface {
 		return nil, fmt.Errorf("failed to unmarshal source: %w", err)
 	}
 
+	if diffJSON, err = s.decryptColumn(diffJSON, event); err != nil {
+		return nil, fmt.Errorf("failed to decrypt diff: %w", err)
+	}
+	if snapshotJSON, err = s.decryptColumn(snapshotJSON, event); err != nil {
+		return nil, fmt.Errorf("failed to decrypt object snapshot: %w", err)
+	}
+
 	if len(diffJSON) > 0 {
 		if err := json.Unmarshal(diffJSON, &event.Diff); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal diff: %w", err)
@@ -473,6 +1406,133 @@ func (s *PostgreSQLStore) scanEvent(rows interface {
 		event.ExecMetadata = &execMetadata
 	}
 
+	if len(blockRuleJSON) > 0 {
+		var blockRule model.BlockRule
+		if err := json.Unmarshal(blockRuleJSON, &blockRule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal block rule: %w", err)
+		}
+		event.BlockRule = &blockRule
+	}
+
+	if len(nodeMaintenanceJSON) > 0 {
+		var nodeMaintenance model.NodeMaintenance
+		if err := json.Unmarshal(nodeMaintenanceJSON, &nodeMaintenance); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node maintenance: %w", err)
+		}
+		event.NodeMaintenance = &nodeMaintenance
+	}
+
+	if len(credentialIssuanceJSON) > 0 {
+		var credentialIssuance model.CredentialIssuance
+		if err := json.Unmarshal(credentialIssuanceJSON, &credentialIssuance); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal credential issuance: %w", err)
+		}
+		event.CredentialIssuance = &credentialIssuance
+	}
+
+	if len(deploymentJSON) > 0 {
+		var deployment model.CIDeployment
+		if err := json.Unmarshal(deploymentJSON, &deployment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal deployment: %w", err)
+		}
+		event.Deployment = &deployment
+	}
+
+	if len(cloudChangeJSON) > 0 {
+		var cloudChange model.CloudChange
+		if err := json.Unmarshal(cloudChangeJSON, &cloudChange); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cloud change: %w", err)
+		}
+		event.CloudChange = &cloudChange
+	}
+
+	if len(selfMonitorJSON) > 0 {
+		var selfMonitor model.SelfMonitorFinding
+		if err := json.Unmarshal(selfMonitorJSON, &selfMonitor); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal self-monitoring finding: %w", err)
+		}
+		event.SelfMonitor = &selfMonitor
+	}
+
+	if len(enrichmentJSON) > 0 {
+		if err := json.Unmarshal(enrichmentJSON, &event.Enrichment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal enrichment: %w", err)
+		}
+	}
+
+	if len(heartbeatJSON) > 0 {
+		var heartbeat model.Heartbeat
+		if err := json.Unmarshal(heartbeatJSON, &heartbeat); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal heartbeat: %w", err)
+		}
+		event.Heartbeat = &heartbeat
+	}
+
+	if len(deleteCollectionJSON) > 0 {
+		var deleteCollection model.DeleteCollection
+		if err := json.Unmarshal(deleteCollectionJSON, &deleteCollection); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal delete collection: %w", err)
+		}
+		event.DeleteCollection = &deleteCollection
+	}
+
+	if len(namespaceCascadeJSON) > 0 {
+		var namespaceCascade model.NamespaceCascade
+		if err := json.Unmarshal(namespaceCascadeJSON, &namespaceCascade); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal namespace cascade: %w", err)
+		}
+		event.NamespaceCascade = &namespaceCascade
+	}
+
+	if len(volumeDeletionJSON) > 0 {
+		var volumeDeletion model.VolumeDeletion
+		if err := json.Unmarshal(volumeDeletionJSON, &volumeDeletion); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal volume deletion: %w", err)
+		}
+		event.VolumeDeletion = &volumeDeletion
+	}
+
+	if len(crdChangeJSON) > 0 {
+		var crdChange model.CRDChange
+		if err := json.Unmarshal(crdChangeJSON, &crdChange); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal CRD change: %w", err)
+		}
+		event.CRDChange = &crdChange
+	}
+
+	if len(imageProvenanceJSON) > 0 {
+		var imageProvenance model.ImageProvenance
+		if err := json.Unmarshal(imageProvenanceJSON, &imageProvenance); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal image provenance: %w", err)
+		}
+		event.ImageProvenance = &imageProvenance
+	}
+
+	if len(exposureChangeJSON) > 0 {
+		var exposureChange model.ExposureChange
+		if err := json.Unmarshal(exposureChangeJSON, &exposureChange); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal exposure change: %w", err)
+		}
+		event.ExposureChange = &exposureChange
+	}
+
+	if len(quotaImpactJSON) > 0 {
+		var quotaImpact model.QuotaImpact
+		if err := json.Unmarshal(quotaImpactJSON, &quotaImpact); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal quota impact: %w", err)
+		}
+		event.QuotaImpact = &quotaImpact
+	}
+
+	if len(podSecurityRegressionJSON) > 0 {
+		var podSecurityRegression model.PodSecurityRegression
+		if err := json.Unmarshal(podSecurityRegressionJSON, &podSecurityRegression); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pod security regression: %w", err)
+		}
+		event.PodSecurityRegression = &podSecurityRegression
+	}
+
+	upgradeEvent(event)
 	return event, nil
 }
 
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +12,14 @@ import (
 func TestPostgreSQLStore_Interface(t *testing.T) {
 	// Test that PostgreSQLStore implements Store interface
 	var _ Store = (*PostgreSQLStore)(nil)
+	var _ Listener = (*PostgreSQLStore)(nil)
+	var _ LegalHoldStore = (*PostgreSQLStore)(nil)
+	var _ ErasureStore = (*PostgreSQLStore)(nil)
+	var _ IntegrityStore = (*PostgreSQLStore)(nil)
+	var _ DeadLetterStore = (*PostgreSQLStore)(nil)
+	var _ RecordingStore = (*PostgreSQLStore)(nil)
+	var _ BatchStore = (*PostgreSQLStore)(nil)
+	var _ ExportJobStore = (*PostgreSQLStore)(nil)
 }
 
 func TestPostgreSQLStore_NewPostgreSQLStore_InvalidConnectionString(t *testing.T) {
@@ -105,3 +114,114 @@ func TestChangeEvent_Structure(t *testing.T) {
 		t.Error("ChangeEvent BlockPattern should default to empty string")
 	}
 }
+
+func TestSelectColumns(t *testing.T) {
+	all := selectColumns(nil)
+	if !strings.Contains(all, "diff") || !strings.Contains(all, "object_snapshot") || !strings.Contains(all, "exec_metadata") {
+		t.Errorf("selectColumns(nil) should include all columns, got %q", all)
+	}
+
+	sparse := selectColumns([]string{"id", "diff"})
+	if !strings.Contains(sparse, "diff") {
+		t.Errorf("selectColumns should include requested diff column, got %q", sparse)
+	}
+	if strings.Contains(sparse, "object_snapshot") || strings.Contains(sparse, "exec_metadata") {
+		t.Errorf("selectColumns should replace unrequested heavy columns with NULL, got %q", sparse)
+	}
+}
+
+func TestBuildWhereClause(t *testing.T) {
+	allowed := false
+	tests := []struct {
+		name     string
+		filters  QueryFilters
+		wantSQL  string
+		wantArgs int
+	}{
+		{
+			name:     "no filters",
+			filters:  QueryFilters{},
+			wantSQL:  "",
+			wantArgs: 0,
+		},
+		{
+			name:     "single values",
+			filters:  QueryFilters{Namespace: "default", Allowed: &allowed},
+			wantSQL:  "WHERE namespace = $1 AND allowed = $2",
+			wantArgs: 2,
+		},
+		{
+			name:     "multi-value",
+			filters:  QueryFilters{Operations: []string{"CREATE", "DELETE"}},
+			wantSQL:  "WHERE operation = ANY($1)",
+			wantArgs: 1,
+		},
+		{
+			name:     "group",
+			filters:  QueryFilters{Group: "platform-team"},
+			wantSQL:  "WHERE actor->'groups' @> $1::jsonb",
+			wantArgs: 1,
+		},
+		{
+			name:     "wildcard",
+			filters:  QueryFilters{Name: "web-*"},
+			wantSQL:  "WHERE name LIKE $1",
+			wantArgs: 1,
+		},
+		{
+			name:     "multi-value wildcard",
+			filters:  QueryFilters{ExcludeNamespaces: []string{"kube-*", "default"}},
+			wantSQL:  "WHERE NOT (namespace LIKE ANY($1))",
+			wantArgs: 1,
+		},
+		{
+			name:     "exclusion",
+			filters:  QueryFilters{Name: "app", ExcludeNamespaces: []string{"kube-system"}},
+			wantSQL:  "WHERE name = $1 AND NOT (namespace = ANY($2))",
+			wantArgs: 2,
+		},
+		{
+			name:     "min risk score",
+			filters:  QueryFilters{Operation: "EXEC", MinRiskScore: 70},
+			wantSQL:  "WHERE operation = $1 AND (exec_metadata->>'risk_score')::int >= $2",
+			wantArgs: 2,
+		},
+		{
+			name:     "node",
+			filters:  QueryFilters{Node: "worker-*"},
+			wantSQL:  "WHERE node_maintenance->>'node_name' LIKE $1",
+			wantArgs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs := buildWhereClause(tt.filters)
+			if gotSQL != tt.wantSQL {
+				t.Errorf("buildWhereClause() SQL = %q, want %q", gotSQL, tt.wantSQL)
+			}
+			if len(gotArgs) != tt.wantArgs {
+				t.Errorf("buildWhereClause() args = %d, want %d", len(gotArgs), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestWildcardToLike(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"web-*", "web-%"},
+		{"*-prod", "%-prod"},
+		{"team_a-*", `team\_a-%`},
+		{"100%", `100\%`},
+		{`back\slash*`, `back\\slash%`},
+	}
+
+	for _, tt := range tests {
+		if got := wildcardToLike(tt.pattern); got != tt.want {
+			t.Errorf("wildcardToLike(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/kubechronicle/kubechronicle/internal/encryption"
+)
+
+// Pseudonym maps a pseudonym replacing an actor field at ingestion back to its value.
+type Pseudonym struct {
+	Pseudonym string    `json:"pseudonym"`
+	Field     string    `json:"field"` // Kind of value, e.g. "username" or "source_ip"
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// initPseudonymSchema creates the actor_pseudonyms table if it doesn't exist.
+func (s *PostgreSQLStore) initPseudonymSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS actor_pseudonyms (
+		pseudonym VARCHAR(64) PRIMARY KEY,
+		field VARCHAR(32) NOT NULL,
+		value BYTEA NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create actor_pseudonyms table: %w", err)
+	}
+	return nil
+}
+
+// SavePseudonyms records the values of pseudonyms, encrypted when encryption at rest is
+// enabled. Pseudonyms recorded before are left unchanged.
+func (s *PostgreSQLStore) SavePseudonyms(ctx context.Context, pseudonyms []*Pseudonym) error {
+	for _, p := range pseudonyms {
+		value := []byte(p.Value)
+		if s.encryptor != nil {
+			encrypted, err := s.encryptor.Encrypt(value)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt pseudonym value: %w", err)
+			}
+			value = encrypted
+		}
+		_, err := s.pool.Exec(ctx, `
+			INSERT INTO actor_pseudonyms (pseudonym, field, value)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (pseudonym) DO NOTHING
+		`, p.Pseudonym, p.Field, value)
+		if err != nil {
+			return fmt.Errorf("failed to save pseudonym: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolvePseudonym returns the value a pseudonym replaced. Returns ErrNotFound if the
+// pseudonym isn't recorded, e.g. because it was erased.
+func (s *PostgreSQLStore) ResolvePseudonym(ctx context.Context, pseudonym string) (*Pseudonym, error) {
+	p := &Pseudonym{Pseudonym: pseudonym}
+	var value []byte
+	err := s.pool.QueryRow(ctx, `
+		SELECT field, value, created_at FROM actor_pseudonyms WHERE pseudonym = $1
+	`, pseudonym).Scan(&p.Field, &value, &p.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pseudonym: %w", err)
+	}
+	if p.Value, err = s.decryptPseudonymValue(pseudonym, value); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListPseudonyms returns all recorded pseudonyms with their values, oldest first, e.g. to
+// back them up.
+func (s *PostgreSQLStore) ListPseudonyms(ctx context.Context) ([]*Pseudonym, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT pseudonym, field, value, created_at FROM actor_pseudonyms ORDER BY created_at, pseudonym
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pseudonyms: %w", err)
+	}
+	defer rows.Close()
+
+	pseudonyms := []*Pseudonym{}
+	for rows.Next() {
+		var p Pseudonym
+		var value []byte
+		if err := rows.Scan(&p.Pseudonym, &p.Field, &value, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pseudonym: %w", err)
+		}
+		if p.Value, err = s.decryptPseudonymValue(p.Pseudonym, value); err != nil {
+			return nil, err
+		}
+		pseudonyms = append(pseudonyms, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return pseudonyms, nil
+}
+
+// decryptPseudonymValue decrypts the stored value of a pseudonym if it is encrypted.
+func (s *PostgreSQLStore) decryptPseudonymValue(pseudonym string, value []byte) (string, error) {
+	if !encryption.IsEnvelope(value) {
+		return string(value), nil
+	}
+	if s.encryptor == nil {
+		return "", fmt.Errorf("the value of pseudonym %s is encrypted and no encryption key is configured", pseudonym)
+	}
+	decrypted, err := s.encryptor.Decrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt pseudonym value: %w", err)
+	}
+	return string(decrypted), nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/metrics"
+)
+
+// Query metrics, per kind of read query (events, count, event, events_by_ids, stream_events,
+// usage, churn), so operators can see which API usage patterns are slow.
+var (
+	queryDuration = metrics.NewHistogramVec("store_query_duration_seconds", "query", metrics.DurationBuckets)
+	queryRows     = metrics.NewHistogramVec("store_query_rows", "query", []float64{0, 1, 10, 100, 1000, 10000})
+	slowQueries   = expvar.NewInt("store_slow_queries_total")
+)
+
+// observeQuery records the duration of a query started at start and the rows it returned,
+// and logs it if it took longer than the slow query threshold. describe returns the query's
+// parameters for the log, so they are only formatted for slow queries; it may be nil.
+func (s *PostgreSQLStore) observeQuery(name string, start time.Time, rows int, describe func() string) {
+	duration := time.Since(start)
+	queryDuration.Observe(name, duration.Seconds())
+	queryRows.Observe(name, float64(rows))
+
+	if s.slowQueryThreshold > 0 && duration > s.slowQueryThreshold {
+		slowQueries.Add(1)
+		params := ""
+		if describe != nil {
+			params = ": " + describe()
+		}
+		klog.Warningf("Slow %s query took %v (threshold %v) and returned %d rows%s", name, duration.Round(time.Millisecond), s.slowQueryThreshold, rows, params)
+	}
+}
+
+// describeEventQuery describes the parameters of an event query for the slow query log.
+func describeEventQuery(filters QueryFilters, pagination PaginationParams, sortOrder SortOrder) string {
+	data, err := json.Marshal(filters)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%+v", filters))
+	}
+	sortBy := pagination.SortBy
+	if sortBy == "" {
+		sortBy = "timestamp"
+	}
+	return fmt.Sprintf("filters %s, limit %d, offset %d, sort by %s %s", data, pagination.Limit, pagination.Offset, sortBy, sortOrder)
+}
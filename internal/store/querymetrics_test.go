@@ -0,0 +1,47 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveQuery(t *testing.T) {
+	s := &PostgreSQLStore{slowQueryThreshold: time.Hour}
+	slowBefore := slowQueries.Value()
+
+	s.observeQuery("test_fast", time.Now(), 3, func() string {
+		t.Error("describe should only be called for slow queries")
+		return ""
+	})
+	if got := slowQueries.Value() - slowBefore; got != 0 {
+		t.Errorf("store_slow_queries_total increased by %d for a fast query", got)
+	}
+
+	s.slowQueryThreshold = time.Millisecond
+	described := false
+	s.observeQuery("test_slow", time.Now().Add(-time.Second), 0, func() string {
+		described = true
+		return "filters {}"
+	})
+	if got := slowQueries.Value() - slowBefore; got != 1 || !described {
+		t.Errorf("store_slow_queries_total increased by %d, described = %v; want 1 and true", got, described)
+	}
+
+	durations := queryDuration.String()
+	if !strings.Contains(durations, `"test_fast"`) || !strings.Contains(durations, `"test_slow"`) {
+		t.Errorf("store_query_duration_seconds = %s, want both queries", durations)
+	}
+	if rows := queryRows.String(); !strings.Contains(rows, `"test_fast":{"buckets":{"+Inf":1,"0":0,"1":0,"10":1`) {
+		t.Errorf("store_query_rows = %s, want 3 rows in the 10 bucket", rows)
+	}
+}
+
+func TestDescribeEventQuery(t *testing.T) {
+	got := describeEventQuery(QueryFilters{Namespace: "prod"}, PaginationParams{Limit: 50, Offset: 100}, SortOrderDesc)
+	for _, want := range []string{`"prod"`, "limit 50", "offset 100", "sort by timestamp desc"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("describeEventQuery() = %q, want it to contain %q", got, want)
+		}
+	}
+}
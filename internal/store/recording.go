@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Recording links an exec event to a session recording kept by an external agent.
+type Recording struct {
+	ID           int64     `json:"id"`
+	EventID      string    `json:"event_id"`
+	URL          string    `json:"url"`
+	Agent        string    `json:"agent,omitempty"` // Recording agent, e.g. "tlog"
+	RegisteredBy string    `json:"registered_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ExecTarget identifies the exec session a recording belongs to: either the event ID,
+// or the pod and the time the session started.
+type ExecTarget struct {
+	EventID   string
+	Namespace string
+	Pod       string
+	Container string    // Optional; matches any container if empty
+	StartedAt time.Time // Matched against the event timestamp, within Window
+	Window    time.Duration
+}
+
+// initRecordingSchema creates the exec_recordings table if it doesn't exist.
+func (s *PostgreSQLStore) initRecordingSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS exec_recordings (
+		id BIGSERIAL PRIMARY KEY,
+		event_id VARCHAR(255) NOT NULL,
+		url TEXT NOT NULL,
+		agent VARCHAR(255),
+		registered_by VARCHAR(255),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		UNIQUE (event_id, url)
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create exec_recordings table: %w", err)
+	}
+	return nil
+}
+
+// FindExecEvent returns the ID of the EXEC event of target. Without an event ID, it is the
+// exec into the pod (and container, if set) closest to StartedAt within Window.
+// Returns ErrNotFound if there is no such event.
+func (s *PostgreSQLStore) FindExecEvent(ctx context.Context, target ExecTarget) (string, error) {
+	var (
+		eventID string
+		err     error
+	)
+	if target.EventID != "" {
+		findSQL := `SELECT id FROM change_events WHERE id = $1 AND operation = 'EXEC'`
+		args := []interface{}{target.EventID}
+		if condition, scopeArgs := scopeCondition(ctx, "namespace", 2); condition != "" {
+			findSQL += " AND " + condition
+			args = append(args, scopeArgs...)
+		}
+		err = s.pool.QueryRow(ctx, findSQL, args...).Scan(&eventID)
+	} else {
+		scopeSQL := ""
+		args := []interface{}{target.Namespace, target.Pod, target.Container,
+			target.StartedAt.Add(-target.Window), target.StartedAt.Add(target.Window), target.StartedAt}
+		if condition, scopeArgs := scopeCondition(ctx, "namespace", 7); condition != "" {
+			scopeSQL = "AND " + condition
+			args = append(args, scopeArgs...)
+		}
+		findSQL := `
+			SELECT id FROM change_events
+			WHERE operation = 'EXEC' AND namespace = $1 AND name = $2
+				AND ($3 = '' OR exec_metadata->>'container' = $3)
+				AND timestamp BETWEEN $4 AND $5
+				` + scopeSQL + `
+			ORDER BY ABS(EXTRACT(EPOCH FROM timestamp - $6::timestamptz))
+			LIMIT 1
+		`
+		err = s.pool.QueryRow(ctx, findSQL, args...).Scan(&eventID)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find exec event: %w", err)
+	}
+	return eventID, nil
+}
+
+// AddRecording links a recording to its event and sets its ID and CreatedAt. Registering
+// the same URL for an event again updates the agent and keeps the original ID.
+func (s *PostgreSQLStore) AddRecording(ctx context.Context, recording *Recording) error {
+	insertSQL := `
+		INSERT INTO exec_recordings (event_id, url, agent, registered_by)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (event_id, url) DO UPDATE SET agent = EXCLUDED.agent
+		RETURNING id, created_at
+	`
+	if err := s.pool.QueryRow(ctx, insertSQL, recording.EventID, recording.URL, recording.Agent, recording.RegisteredBy).Scan(&recording.ID, &recording.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert recording: %w", err)
+	}
+	return nil
+}
+
+// ListRecordings returns the recordings of an event, oldest first.
+func (s *PostgreSQLStore) ListRecordings(ctx context.Context, eventID string) ([]*Recording, error) {
+	querySQL := `
+		SELECT id, event_id, url, COALESCE(agent, ''), COALESCE(registered_by, ''), created_at
+		FROM exec_recordings
+		WHERE event_id = $1
+		%s
+		ORDER BY id
+	`
+	args := []interface{}{eventID}
+	scopeSQL := ""
+	if condition, scopeArgs := scopeCondition(ctx, "e.namespace", 2); condition != "" {
+		// Recordings of events outside the scope are hidden with their events
+		scopeSQL = "AND EXISTS (SELECT 1 FROM change_events e WHERE e.id = exec_recordings.event_id AND " + condition + ")"
+		args = append(args, scopeArgs...)
+	}
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(querySQL, scopeSQL), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recordings: %w", err)
+	}
+	defer rows.Close()
+
+	recordings := []*Recording{}
+	for rows.Next() {
+		var r Recording
+		if err := rows.Scan(&r.ID, &r.EventID, &r.URL, &r.Agent, &r.RegisteredBy, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recording: %w", err)
+		}
+		recordings = append(recordings, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return recordings, nil
+}
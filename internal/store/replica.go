@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"k8s.io/klog/v2"
+)
+
+// replicaLagSQL returns how far the replica is behind the primary in seconds: zero once it
+// replayed everything it received, so an idle primary doesn't make the replica look lagging.
+const replicaLagSQL = `
+	SELECT (CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END)::float8
+`
+
+// Replica metrics: whether event queries are sent to the replica, its replication lag,
+// and the queries sent to the primary because the replica was lagging or down.
+var (
+	replicaHealthy   = expvar.NewInt("store_replica_healthy")
+	replicaLag       = expvar.NewFloat("store_replica_lag_seconds")
+	replicaFallbacks = expvar.NewInt("store_replica_fallbacks_total")
+)
+
+// replica is a read-only database that event queries are sent to while it is reachable and
+// no more than maxLag behind the primary.
+type replica struct {
+	pool    *pgxpool.Pool
+	maxLag  time.Duration
+	healthy atomic.Bool
+	stop    context.CancelFunc
+}
+
+// SetReadReplica sends event queries (QueryEvents and the history and activity queries built
+// on it) to the read-only database at connectionString, so heavy dashboard queries don't load
+// the primary. Queries fall back to the primary while the replica is down or more than maxLag
+// behind it. The replica starts out unused if it can't be reached.
+func (s *PostgreSQLStore) SetReadReplica(connectionString string, maxLag time.Duration) error {
+	config, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to parse replica connection string: %w", err)
+	}
+	s.poolConfig.apply(config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to create replica connection pool: %w", err)
+	}
+
+	monitorCtx, stop := context.WithCancel(context.Background())
+	r := &replica{pool: pool, maxLag: maxLag, stop: stop}
+	r.check(ctx)
+	if !r.healthy.Load() {
+		klog.Warningf("Read replica not usable yet, sending event queries to the primary")
+	}
+	s.replica = r
+	go r.monitor(monitorCtx)
+	return nil
+}
+
+// readPool returns the pool to send event queries to: the replica if it is usable,
+// the primary otherwise.
+func (s *PostgreSQLStore) readPool() *pgxpool.Pool {
+	if s.replica == nil {
+		return s.pool
+	}
+	if !s.replica.healthy.Load() {
+		replicaFallbacks.Add(1)
+		return s.pool
+	}
+	return s.replica.pool
+}
+
+// readFailed marks the replica unusable after a query on it failed because it couldn't be
+// reached, reporting whether the query should be retried on the primary.
+func (s *PostgreSQLStore) readFailed(pool *pgxpool.Pool, err error) bool {
+	if s.replica == nil || pool != s.replica.pool || !isUnavailable(err) {
+		return false
+	}
+	s.replica.setHealthy(false, fmt.Sprintf("query failed: %v", err))
+	replicaFallbacks.Add(1)
+	return true
+}
+
+// monitor checks the replica until ctx is cancelled.
+func (r *replica) monitor(ctx context.Context) {
+	ticker := time.NewTicker(healthMonitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, healthMonitorInterval)
+			r.check(checkCtx)
+			cancel()
+		}
+	}
+}
+
+// check updates whether the replica is usable from its replication lag.
+func (r *replica) check(ctx context.Context) {
+	var lag float64
+	if err := r.pool.QueryRow(ctx, replicaLagSQL).Scan(&lag); err != nil {
+		r.setHealthy(false, fmt.Sprintf("unreachable: %v", err))
+		return
+	}
+	replicaLag.Set(lag)
+	if lagDuration := time.Duration(lag * float64(time.Second)); r.maxLag > 0 && lagDuration > r.maxLag {
+		r.setHealthy(false, fmt.Sprintf("%s behind the primary (max %s)", lagDuration.Round(time.Second), r.maxLag))
+		return
+	}
+	r.setHealthy(true, "")
+}
+
+// setHealthy switches event queries to or from the replica, logging the change.
+func (r *replica) setHealthy(healthy bool, reason string) {
+	if r.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		replicaHealthy.Set(1)
+		klog.Info("Read replica usable, sending event queries to it")
+	} else {
+		replicaHealthy.Set(0)
+		klog.Warningf("Read replica %s, sending event queries to the primary", reason)
+	}
+}
+
+// close stops monitoring the replica and closes its pool.
+func (r *replica) close() {
+	r.stop()
+	r.pool.Close()
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestPostgreSQLStore_ReadPool(t *testing.T) {
+	primary, replicaPool := &pgxpool.Pool{}, &pgxpool.Pool{}
+	s := &PostgreSQLStore{pool: primary}
+	if s.readPool() != primary {
+		t.Fatal("Queries should go to the primary without a replica")
+	}
+
+	s.replica = &replica{pool: replicaPool}
+	fallbacks := replicaFallbacks.Value()
+	if s.readPool() != primary || replicaFallbacks.Value() != fallbacks+1 {
+		t.Error("Queries should fall back to the primary while the replica is unusable")
+	}
+
+	s.replica.setHealthy(true, "")
+	if s.readPool() != replicaPool || replicaHealthy.Value() != 1 {
+		t.Fatal("Queries should go to a usable replica")
+	}
+
+	// A rejected query is not retried, an unreachable replica is marked unusable
+	if s.readFailed(replicaPool, &pgconn.PgError{Code: "42P01"}) {
+		t.Error("A query the replica rejected should not be retried on the primary")
+	}
+	if s.readFailed(primary, &net.OpError{Op: "read", Err: errors.New("connection reset")}) {
+		t.Error("A failed query on the primary should not be retried")
+	}
+	if !s.readFailed(replicaPool, &net.OpError{Op: "read", Err: errors.New("connection reset")}) {
+		t.Error("A query failing to reach the replica should be retried on the primary")
+	}
+	if s.readPool() != primary || replicaHealthy.Value() != 0 {
+		t.Error("The replica should be unusable after failing to reach it")
+	}
+}
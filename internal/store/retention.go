@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// retentionLockID is the advisory lock key that keeps a single process purging events.
+const retentionLockID = 0x6b726574656e // "kreten"
+
+// ErrPurgeInProgress is returned by PurgeEvents while another process purges events.
+var ErrPurgeInProgress = errors.New("another process is purging events")
+
+// RetentionPolicy is the retention set through the admin API, overriding RETENTION_DAYS.
+type RetentionPolicy struct {
+	Days      int       `json:"days"` // Events older than this many days are purged; 0 keeps them forever
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// initRetentionSchema creates the retention_policy table, of at most one row, and the
+// purge_log table, recording each purge the entries of the integrity chain are marked with,
+// if they don't exist.
+func (s *PostgreSQLStore) initRetentionSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS retention_policy (
+		id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+		days INTEGER NOT NULL CHECK (days >= 0),
+		updated_by VARCHAR(255),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS purge_log (
+		id BIGSERIAL PRIMARY KEY,
+		purged_before TIMESTAMPTZ NOT NULL,
+		events_purged BIGINT NOT NULL,
+		purged_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);
+
+	ALTER TABLE event_chain ADD COLUMN IF NOT EXISTS purge_id BIGINT;
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create retention tables: %w", err)
+	}
+
+	// Entries purged before the purge log existed are logged once, as a single purge
+	backfillSQL := `
+		WITH legacy AS (
+			SELECT seq, event_time, purged_at FROM event_chain WHERE purged_at IS NOT NULL AND purge_id IS NULL
+		), logged AS (
+			INSERT INTO purge_log (purged_before, events_purged, purged_at)
+			SELECT max(event_time) + interval '1 microsecond', COUNT(*), max(purged_at) FROM legacy
+			HAVING COUNT(*) > 0
+			RETURNING id
+		)
+		UPDATE event_chain SET purge_id = (SELECT id FROM logged)
+		WHERE seq IN (SELECT seq FROM legacy) AND purge_id IS NULL
+	`
+	if _, err := s.pool.Exec(ctx, backfillSQL); err != nil {
+		return fmt.Errorf("failed to log earlier purges: %w", err)
+	}
+	return nil
+}
+
+// GetRetentionPolicy returns the retention set through the admin API, or nil if none is.
+func (s *PostgreSQLStore) GetRetentionPolicy(ctx context.Context) (*RetentionPolicy, error) {
+	var (
+		policy    RetentionPolicy
+		updatedBy *string
+	)
+	err := s.pool.QueryRow(ctx, "SELECT days, updated_by, updated_at FROM retention_policy WHERE id = 1").
+		Scan(&policy.Days, &updatedBy, &policy.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policy: %w", err)
+	}
+	if updatedBy != nil {
+		policy.UpdatedBy = *updatedBy
+	}
+	return &policy, nil
+}
+
+// SetRetentionPolicy replaces the retention set through the admin API and sets its
+// UpdatedAt.
+func (s *PostgreSQLStore) SetRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error {
+	upsertSQL := `
+		INSERT INTO retention_policy (id, days, updated_by, updated_at)
+		VALUES (1, $1, $2, NOW())
+		ON CONFLICT (id) DO UPDATE SET days = EXCLUDED.days, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+	if err := s.pool.QueryRow(ctx, upsertSQL, policy.Days, policy.UpdatedBy).Scan(&policy.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	return nil
+}
+
+// DeleteRetentionPolicy removes the retention set through the admin API, if any.
+func (s *PostgreSQLStore) DeleteRetentionPolicy(ctx context.Context) error {
+	if _, err := s.pool.Exec(ctx, "DELETE FROM retention_policy WHERE id = 1"); err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+	return nil
+}
+
+// PurgeEvents deletes up to limit of the oldest events stored before the given time that no
+// active legal hold covers, logs the purge, and marks their entries of the integrity chain
+// with it. It deletes nothing and returns ErrPurgeInProgress while another process purges
+// events. The legal holds are read once the purge holds its locks, and can't be created or
+// released until it commits, so a hold created concurrently is never missed.
+func (s *PostgreSQLStore) PurgeEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", retentionLockID).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("failed to lock retention: %w", err)
+	}
+	if !locked {
+		return 0, ErrPurgeInProgress
+	}
+
+	// SHARE mode conflicts with the lock inserts and updates take: creating or releasing a
+	// hold waits for the purge, and the purge waits for holds being created to be committed
+	if _, err := tx.Exec(ctx, "LOCK TABLE legal_holds IN SHARE MODE"); err != nil {
+		return 0, fmt.Errorf("failed to lock legal holds: %w", err)
+	}
+	holds, err := listLegalHolds(ctx, tx, false)
+	if err != nil {
+		return 0, err
+	}
+	exclusion, holdArgs := buildHoldExclusion(holds, 3)
+
+	purgeSQL := `
+		WITH purged AS (
+			DELETE FROM change_events
+			WHERE id IN (
+				SELECT id FROM change_events
+				WHERE timestamp < $1 AND ` + exclusion + `
+				ORDER BY timestamp
+				LIMIT $2
+			)
+			RETURNING id
+		), logged AS (
+			INSERT INTO purge_log (purged_before, events_purged)
+			SELECT $1, COUNT(*) FROM purged
+			HAVING COUNT(*) > 0
+			RETURNING id
+		), sealed AS (
+			UPDATE event_chain SET purged_at = NOW(), purge_id = (SELECT id FROM logged)
+			WHERE event_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged
+	`
+	var purged int64
+	args := append([]interface{}{before, limit}, holdArgs...)
+	if err := tx.QueryRow(ctx, purgeSQL, args...).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	return purged, nil
+}
@@ -28,10 +28,23 @@ CREATE INDEX IF NOT EXISTS idx_change_events_source_tool ON change_events((sourc
 CREATE INDEX IF NOT EXISTS idx_change_events_allowed ON change_events(allowed);
 CREATE INDEX IF NOT EXISTS idx_change_events_block_pattern ON change_events(block_pattern) WHERE block_pattern IS NOT NULL;
 
+-- Indexes for sorting by fields other than timestamp (sort_by)
+CREATE INDEX IF NOT EXISTS idx_change_events_namespace_timestamp ON change_events(namespace, timestamp);
+CREATE INDEX IF NOT EXISTS idx_change_events_kind_timestamp ON change_events(resource_kind, timestamp);
+CREATE INDEX IF NOT EXISTS idx_change_events_username_timestamp ON change_events((actor->>'username'), timestamp);
+CREATE INDEX IF NOT EXISTS idx_change_events_operation_timestamp ON change_events(operation, timestamp);
+
 -- GIN indexes for JSONB fields to enable efficient queries
 CREATE INDEX IF NOT EXISTS idx_change_events_actor_gin ON change_events USING GIN (actor);
 CREATE INDEX IF NOT EXISTS idx_change_events_source_gin ON change_events USING GIN (source);
 CREATE INDEX IF NOT EXISTS idx_change_events_exec_metadata_gin ON change_events USING GIN (exec_metadata) WHERE exec_metadata IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_change_events_actor_groups_gin ON change_events USING GIN ((actor->'groups'));
+
+-- Trigram indexes for wildcard (LIKE) filters, e.g. name=web-*
+-- Requires the pg_trgm extension
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_change_events_namespace_trgm ON change_events USING GIN (namespace gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_change_events_name_trgm ON change_events USING GIN (name gin_trgm_ops);
 
 -- Example queries:
 -- 
@@ -45,6 +58,11 @@ CREATE INDEX IF NOT EXISTS idx_change_events_exec_metadata_gin ON change_events
 -- WHERE actor->>'username' = 'user@example.com'
 -- ORDER BY timestamp DESC;
 --
+-- Get all changes by members of a group:
+-- SELECT * FROM change_events 
+-- WHERE actor->'groups' @> '["platform-team"]'::jsonb
+-- ORDER BY timestamp DESC;
+--
 -- Get all changes made via kubectl:
 -- SELECT * FROM change_events 
 -- WHERE source->>'tool' = 'kubectl'
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// scopeSetting is the PostgreSQL setting holding the LIKE patterns of the namespaces the
+// current transaction may read, as a JSON array. It is unset outside scoped queries.
+const scopeSetting = "kubechronicle.namespaces"
+
+// namespaceScopeKey is the context key of the namespace scope.
+type namespaceScopeKey struct{}
+
+// WithNamespaceScope restricts the event reads made with the returned context (QueryEvents
+// and the queries built on it, GetEventByID, GetEventsByIDs, StreamEvents, FindExecEvent and
+// ListRecordings) to events in
+// namespaces matching one of patterns (* matches any sequence of characters). An empty list
+// matches no events; cluster-scoped events are only matched by "*".
+func WithNamespaceScope(ctx context.Context, patterns []string) context.Context {
+	if patterns == nil {
+		patterns = []string{}
+	}
+	return context.WithValue(ctx, namespaceScopeKey{}, patterns)
+}
+
+// NamespaceScope returns the namespace patterns reads made with ctx are restricted to, and
+// false if they are not restricted.
+func NamespaceScope(ctx context.Context) ([]string, bool) {
+	patterns, ok := ctx.Value(namespaceScopeKey{}).([]string)
+	return patterns, ok
+}
+
+// InNamespaceScope reports whether an event in namespace may be read with ctx.
+func InNamespaceScope(ctx context.Context, namespace string) bool {
+	patterns, ok := NamespaceScope(ctx)
+	if !ok {
+		return true
+	}
+	for _, pattern := range patterns {
+		if MatchWildcard(pattern, namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchWildcard reports whether s matches pattern, where * matches any sequence of
+// characters, the same as the LIKE patterns of wildcard filters.
+func MatchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// scopePatterns returns the LIKE patterns of a namespace scope. Patterns without wildcards
+// are escaped, so LIKE matches them exactly.
+func scopePatterns(patterns []string) []string {
+	like := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		like[i] = wildcardToLike(pattern)
+	}
+	return like
+}
+
+// scopeCondition returns the condition restricting change_events rows to the namespace scope
+// of ctx, with its argument numbered argIdx, or "" if ctx is not scoped. column is the
+// namespace column, qualified if needed.
+func scopeCondition(ctx context.Context, column string, argIdx int) (string, []interface{}) {
+	patterns, ok := NamespaceScope(ctx)
+	if !ok {
+		return "", nil
+	}
+	if len(patterns) == 0 {
+		return "FALSE", nil
+	}
+	return fmt.Sprintf("%s LIKE ANY($%d)", column, argIdx), []interface{}{scopePatterns(patterns)}
+}
+
+// querier runs queries on a pool or in a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// scoped runs fn on pool. With row-level security enabled and a namespace scope on ctx, fn
+// runs in a read-only transaction the policies limit to that scope, so the scope holds even
+// if a query misses its condition. fn must read all rows before returning.
+func (s *PostgreSQLStore) scoped(ctx context.Context, pool *pgxpool.Pool, fn func(q querier) error) error {
+	patterns, ok := NamespaceScope(ctx)
+	if !ok || !s.rowLevelSecurity {
+		return fn(pool)
+	}
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	scopeJSON, err := json.Marshal(scopePatterns(patterns))
+	if err != nil {
+		return fmt.Errorf("failed to marshal namespace scope: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", scopeSetting, string(scopeJSON)); err != nil {
+		return fmt.Errorf("failed to set namespace scope: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// EnableRowLevelSecurity adds PostgreSQL row-level security policies limiting reads of
+// change_events to the namespace scope of the transaction, and makes the store set the
+// scope of scoped reads. Unscoped sessions, such as the webhook's, are not restricted.
+// The policies are forced so they also apply to the table owner. Call it before serving
+// requests.
+func (s *PostgreSQLStore) EnableRowLevelSecurity(ctx context.Context) error {
+	policySQL := `
+	ALTER TABLE change_events ENABLE ROW LEVEL SECURITY;
+	ALTER TABLE change_events FORCE ROW LEVEL SECURITY;
+
+	DROP POLICY IF EXISTS kubechronicle_scoped_read ON change_events;
+	CREATE POLICY kubechronicle_scoped_read ON change_events FOR SELECT
+		USING (
+			COALESCE(current_setting('` + scopeSetting + `', true), '') = ''
+			OR namespace LIKE ANY (ARRAY(SELECT jsonb_array_elements_text(current_setting('` + scopeSetting + `', true)::jsonb)))
+		);
+
+	DROP POLICY IF EXISTS kubechronicle_insert ON change_events;
+	CREATE POLICY kubechronicle_insert ON change_events FOR INSERT WITH CHECK (true);
+	DROP POLICY IF EXISTS kubechronicle_update ON change_events;
+	CREATE POLICY kubechronicle_update ON change_events FOR UPDATE USING (true);
+	DROP POLICY IF EXISTS kubechronicle_delete ON change_events;
+	CREATE POLICY kubechronicle_delete ON change_events FOR DELETE USING (true);
+	`
+	if _, err := s.pool.Exec(ctx, policySQL); err != nil {
+		return fmt.Errorf("failed to enable row-level security: %w", err)
+	}
+	s.rowLevelSecurity = true
+	return nil
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"payments", "payments", true},
+		{"payments", "payments-prod", false},
+		{"payments-*", "payments-prod", true},
+		{"payments-*", "payments-", true},
+		{"payments-*", "search", false},
+		{"*-prod", "payments-prod", true},
+		{"*-prod", "payments-staging", false},
+		{"team-*-prod", "team-a-prod", true},
+		{"team-*-prod", "team-prod", false},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"a*b*c", "abbc", true},
+		{"a*b*c", "acb", false},
+	}
+	for _, tt := range tests {
+		if got := MatchWildcard(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestInNamespaceScope(t *testing.T) {
+	ctx := context.Background()
+	if !InNamespaceScope(ctx, "anything") {
+		t.Error("An unscoped context should read every namespace")
+	}
+
+	scoped := WithNamespaceScope(ctx, []string{"payments-*", "search"})
+	for ns, want := range map[string]bool{"payments-prod": true, "search": true, "search-prod": false, "": false} {
+		if got := InNamespaceScope(scoped, ns); got != want {
+			t.Errorf("InNamespaceScope(%q) = %v, want %v", ns, got, want)
+		}
+	}
+
+	if InNamespaceScope(WithNamespaceScope(ctx, nil), "payments") {
+		t.Error("An empty scope should read no namespace")
+	}
+}
+
+func TestScopeCondition(t *testing.T) {
+	if cond, args := scopeCondition(context.Background(), "namespace", 1); cond != "" || args != nil {
+		t.Errorf("Expected no condition for an unscoped context, got %q %v", cond, args)
+	}
+
+	cond, args := scopeCondition(WithNamespaceScope(context.Background(), nil), "namespace", 1)
+	if cond != "FALSE" || args != nil {
+		t.Errorf("Expected FALSE for an empty scope, got %q %v", cond, args)
+	}
+
+	cond, args = scopeCondition(WithNamespaceScope(context.Background(), []string{"payments-*", "a_b"}), "e.namespace", 3)
+	if cond != "e.namespace LIKE ANY($3)" {
+		t.Errorf("Unexpected condition: %q", cond)
+	}
+	if want := []interface{}{[]string{"payments-%", `a\_b`}}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Unexpected args: %#v, want %#v", args, want)
+	}
+}
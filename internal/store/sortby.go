@@ -0,0 +1,51 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// sortByColumns maps the fields events can be sorted by to their SQL expressions. Each
+// has an index on (expression, timestamp), see initSchema.
+var sortByColumns = map[string]string{
+	"timestamp": "timestamp",
+	"namespace": "namespace",
+	"kind":      "resource_kind",
+	"user":      "actor->>'username'",
+	"operation": "operation",
+}
+
+// SortByFields returns the fields events can be sorted by, sorted.
+func SortByFields() []string {
+	fields := make([]string, 0, len(sortByColumns))
+	for field := range sortByColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// IsSortByField reports whether events can be sorted by field.
+func IsSortByField(field string) bool {
+	_, ok := sortByColumns[field]
+	return ok
+}
+
+// buildOrderByClause returns the ORDER BY clause sorting events by the field sortBy (by
+// timestamp when empty) in order. Events with the same value are sorted by timestamp in
+// the same order, so an index on (field, timestamp) can be scanned either way, then by ID
+// so pages read with offsets don't overlap.
+func buildOrderByClause(sortBy string, order SortOrder) (string, error) {
+	direction := "DESC"
+	if order == SortOrderAsc {
+		direction = "ASC"
+	}
+	if sortBy == "" || sortBy == "timestamp" {
+		return fmt.Sprintf("ORDER BY timestamp %s, id %s", direction, direction), nil
+	}
+	column, ok := sortByColumns[sortBy]
+	if !ok {
+		return "", fmt.Errorf("cannot sort by %q", sortBy)
+	}
+	return fmt.Sprintf("ORDER BY %s %s, timestamp %s, id %s", column, direction, direction, direction), nil
+}
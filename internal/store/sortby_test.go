@@ -0,0 +1,41 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildOrderByClause(t *testing.T) {
+	tests := []struct {
+		sortBy  string
+		order   SortOrder
+		want    string
+		wantErr bool
+	}{
+		{sortBy: "", order: SortOrderDesc, want: "ORDER BY timestamp DESC, id DESC"},
+		{sortBy: "timestamp", order: SortOrderAsc, want: "ORDER BY timestamp ASC, id ASC"},
+		{sortBy: "namespace", order: SortOrderAsc, want: "ORDER BY namespace ASC, timestamp ASC, id ASC"},
+		{sortBy: "user", order: SortOrderDesc, want: "ORDER BY actor->>'username' DESC, timestamp DESC, id DESC"},
+		{sortBy: "kind", order: SortOrderDesc, want: "ORDER BY resource_kind DESC, timestamp DESC, id DESC"},
+		{sortBy: "diff", order: SortOrderAsc, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := buildOrderByClause(tt.sortBy, tt.order)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("buildOrderByClause(%q) error = %v, wantErr %v", tt.sortBy, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("buildOrderByClause(%q, %s) = %q, want %q", tt.sortBy, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestSortByFields(t *testing.T) {
+	want := []string{"kind", "namespace", "operation", "timestamp", "user"}
+	if got := SortByFields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SortByFields() = %v, want %v", got, want)
+	}
+	if !IsSortByField("operation") || IsSortByField("name") {
+		t.Error("IsSortByField() should only accept the sortable fields")
+	}
+}
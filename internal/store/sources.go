@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SourceActivity is the latest event received from an event source, such as the admission
+// webhook or the audit processor, in a cluster.
+type SourceActivity struct {
+	Source      string    `json:"source"`
+	Cluster     string    `json:"cluster"`
+	LastEventAt time.Time `json:"last_event_at"` // When the latest event was received
+	LastEventID string    `json:"last_event_id,omitempty"`
+	Events      int64     `json:"events"`      // Events received since the source was first seen
+	ReportedAt  time.Time `json:"reported_at"` // When the source last reported activity
+}
+
+// initSourceSchema creates the event_sources table if it doesn't exist.
+func (s *PostgreSQLStore) initSourceSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS event_sources (
+		source VARCHAR(64) NOT NULL,
+		cluster VARCHAR(255) NOT NULL,
+		last_event_at TIMESTAMPTZ NOT NULL,
+		last_event_id VARCHAR(255),
+		events BIGINT NOT NULL DEFAULT 0,
+		reported_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (source, cluster)
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create event_sources table: %w", err)
+	}
+	return nil
+}
+
+// RecordSourceActivity adds the events received from sources since they last reported,
+// keeping the latest event of each. Replicas of a source report to the same row.
+func (s *PostgreSQLStore) RecordSourceActivity(ctx context.Context, activity []*SourceActivity) error {
+	for _, a := range activity {
+		_, err := s.pool.Exec(ctx, `
+			INSERT INTO event_sources (source, cluster, last_event_at, last_event_id, events)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+			ON CONFLICT (source, cluster) DO UPDATE SET
+				last_event_id = CASE WHEN EXCLUDED.last_event_at >= event_sources.last_event_at
+					THEN EXCLUDED.last_event_id ELSE event_sources.last_event_id END,
+				last_event_at = GREATEST(event_sources.last_event_at, EXCLUDED.last_event_at),
+				events = event_sources.events + EXCLUDED.events,
+				reported_at = NOW()
+		`, a.Source, a.Cluster, a.LastEventAt, a.LastEventID, a.Events)
+		if err != nil {
+			return fmt.Errorf("failed to record source activity: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListSourceActivity returns the activity of every source seen, by source and cluster.
+func (s *PostgreSQLStore) ListSourceActivity(ctx context.Context) ([]*SourceActivity, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT source, cluster, last_event_at, COALESCE(last_event_id, ''), events, reported_at
+		FROM event_sources
+		ORDER BY source, cluster
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := []*SourceActivity{}
+	for rows.Next() {
+		a := &SourceActivity{}
+		if err := rows.Scan(&a.Source, &a.Cluster, &a.LastEventAt, &a.LastEventID, &a.Events, &a.ReportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan source activity: %w", err)
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
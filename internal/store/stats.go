@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"k8s.io/klog/v2"
+)
+
+// Granularities of the aggregated event counts.
+const (
+	StatsHourly = "hour"
+	StatsDaily  = "day"
+)
+
+// statsColumns maps the fields stats can be grouped and filtered by to their columns of
+// change_event_stats.
+var statsColumns = map[string]string{
+	"namespace": "namespace",
+	"kind":      "resource_kind",
+	"user":      "username",
+	"operation": "operation",
+}
+
+// StatsFields returns the fields stats can be grouped and filtered by, sorted.
+func StatsFields() []string {
+	fields := make([]string, 0, len(statsColumns))
+	for field := range statsColumns {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// IsStatsField reports whether stats can be grouped and filtered by field.
+func IsStatsField(field string) bool {
+	_, ok := statsColumns[field]
+	return ok
+}
+
+// StatsQuery selects aggregated event counts.
+type StatsQuery struct {
+	Granularity string    // StatsHourly or StatsDaily
+	Since       time.Time // Buckets starting at or after Since, truncated to the granularity
+	Until       *time.Time
+
+	// GroupBy counts the events of each bucket per value of a field (see StatsFields), or
+	// all together when empty.
+	GroupBy string
+
+	// Filters are exact values of fields (see StatsFields) counted events must have.
+	Filters map[string]string
+}
+
+// StatsBucket is the number of events in an hour or day, for one value of the field
+// counts are grouped by, if any.
+type StatsBucket struct {
+	Bucket time.Time `json:"bucket"`
+	Value  *string   `json:"value,omitempty"` // Of the field grouped by
+
+	// Events is the number of stored events; SampledEvents the number of changes they stand
+	// for, counting each sampled event SampleRate times.
+	Events        int64 `json:"events"`
+	SampledEvents int64 `json:"sampled_events"`
+}
+
+// initStatsSchema creates the change_event_stats table, which holds the hourly and daily
+// event counts per namespace, kind, user and operation, so stats don't scan change_events.
+// Writers update it in the transaction inserting events. Events stored before the table
+// existed are counted when it is created.
+func (s *PostgreSQLStore) initStatsSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS change_event_stats (
+		granularity VARCHAR(8) NOT NULL,
+		bucket TIMESTAMPTZ NOT NULL,
+		namespace VARCHAR(255) NOT NULL,
+		resource_kind VARCHAR(100) NOT NULL,
+		username TEXT NOT NULL,
+		operation VARCHAR(50) NOT NULL,
+		events BIGINT NOT NULL,
+		sampled_events BIGINT NOT NULL,
+		PRIMARY KEY (granularity, bucket, namespace, resource_kind, username, operation)
+	);
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create change_event_stats table: %w", err)
+	}
+
+	backfillSQL := fmt.Sprintf(statsUpsertSQL, "NOT EXISTS (SELECT 1 FROM change_event_stats)")
+	tag, err := s.pool.Exec(ctx, backfillSQL)
+	if err != nil {
+		return fmt.Errorf("failed to backfill change_event_stats: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		klog.Infof("Counted the stored events in %d change_event_stats rows", tag.RowsAffected())
+	}
+	return nil
+}
+
+// statsUpsertSQL adds the events matching a condition to their hourly and daily counts.
+// Buckets are UTC hours and days. Heartbeats aren't counted.
+const statsUpsertSQL = `
+	INSERT INTO change_event_stats (granularity, bucket, namespace, resource_kind, username, operation, events, sampled_events)
+	SELECT g.granularity, date_trunc(g.granularity, e.timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC',
+	       e.namespace, e.resource_kind, COALESCE(e.actor->>'username', ''), e.operation, COUNT(*), SUM(e.sample_rate)
+	FROM change_events e CROSS JOIN (VALUES ('hour'), ('day')) AS g(granularity)
+	WHERE (%s) AND e.operation <> 'HEARTBEAT'
+	GROUP BY 1, 2, 3, 4, 5, 6
+	ON CONFLICT (granularity, bucket, namespace, resource_kind, username, operation) DO UPDATE
+	SET events = change_event_stats.events + EXCLUDED.events,
+	    sampled_events = change_event_stats.sampled_events + EXCLUDED.sampled_events
+`
+
+// addToStats adds newly inserted events to the stats, in the transaction inserting them.
+func addToStats(ctx context.Context, tx pgx.Tx, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(statsUpsertSQL, "e.id = ANY($1)"), eventIDs); err != nil {
+		return fmt.Errorf("failed to update stats: %w", err)
+	}
+	return nil
+}
+
+// GetStats returns the hourly or daily event counts selected by query, oldest first, and
+// most events first within a bucket. Like QueryEvents, it is limited to the namespace
+// scope of ctx and sent to the read replica if one is usable.
+func (s *PostgreSQLStore) GetStats(ctx context.Context, query StatsQuery) ([]*StatsBucket, error) {
+	start := time.Now()
+	pool := s.readPool()
+	stats, err := s.getStats(ctx, pool, query)
+	if err != nil && s.readFailed(pool, err) {
+		stats, err = s.getStats(ctx, s.pool, query)
+	}
+	s.observeQuery("stats", start, len(stats), func() string {
+		return fmt.Sprintf("%+v", query)
+	})
+	return stats, err
+}
+
+func (s *PostgreSQLStore) getStats(ctx context.Context, pool *pgxpool.Pool, query StatsQuery) ([]*StatsBucket, error) {
+	if query.Granularity != StatsHourly && query.Granularity != StatsDaily {
+		return nil, fmt.Errorf("unknown granularity %q, must be hour or day", query.Granularity)
+	}
+	value := "NULL::text"
+	if query.GroupBy != "" {
+		column, ok := statsColumns[query.GroupBy]
+		if !ok {
+			return nil, fmt.Errorf("cannot group stats by %q", query.GroupBy)
+		}
+		value = column
+	}
+
+	// Buckets overlapping Since are counted whole
+	conditions := []string{"granularity = $1", "bucket >= date_trunc($1, $2::timestamptz AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'"}
+	args := []interface{}{query.Granularity, query.Since}
+	if query.Until != nil {
+		args = append(args, *query.Until)
+		conditions = append(conditions, fmt.Sprintf("bucket <= $%d", len(args)))
+	}
+	fields := make([]string, 0, len(query.Filters))
+	for field := range query.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		column, ok := statsColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("cannot filter stats by %q", field)
+		}
+		args = append(args, query.Filters[field])
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", len(args)+1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, scopeArgs...)
+	}
+
+	querySQL := fmt.Sprintf(`
+		SELECT bucket, %s AS value, SUM(events), SUM(sampled_events)
+		FROM change_event_stats
+		WHERE %s
+		GROUP BY 1, 2
+		ORDER BY 1, 3 DESC, 2
+	`, value, strings.Join(conditions, " AND "))
+
+	stats := []*StatsBucket{}
+	err := s.scoped(ctx, pool, func(q querier) error {
+		rows, err := q.Query(ctx, querySQL, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query stats: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var b StatsBucket
+			if err := rows.Scan(&b.Bucket, &b.Value, &b.Events, &b.SampledEvents); err != nil {
+				return fmt.Errorf("failed to scan stats: %w", err)
+			}
+			stats = append(stats, &b)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatsFields(t *testing.T) {
+	if got, want := StatsFields(), []string{"kind", "namespace", "operation", "user"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StatsFields() = %v, want %v", got, want)
+	}
+	if IsStatsField("name") {
+		t.Error("Stats should not be kept per resource name")
+	}
+}
+
+func TestStatsUpsertSQL(t *testing.T) {
+	// Both granularities are counted, and conflicting buckets are incremented
+	for _, want := range []string{"('hour'), ('day')", "events = change_event_stats.events + EXCLUDED.events"} {
+		if !strings.Contains(statsUpsertSQL, want) {
+			t.Errorf("statsUpsertSQL should contain %q", want)
+		}
+	}
+}
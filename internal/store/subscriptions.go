@@ -0,0 +1,155 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SubscriptionFilter selects the events delivered to a subscription. Each list holds
+// patterns where * matches any sequence of characters; an empty list matches every event.
+type SubscriptionFilter struct {
+	Namespaces    []string `json:"namespaces,omitempty"`
+	ResourceKinds []string `json:"resource_kinds,omitempty"`
+	Names         []string `json:"names,omitempty"`
+	Operations    []string `json:"operations,omitempty"`
+	Users         []string `json:"users,omitempty"`
+	BlockedOnly   bool     `json:"blocked_only,omitempty"` // Only requests blocked by a block pattern
+}
+
+// Subscription delivers the events matching Filter to a callback URL, signed with Secret.
+type Subscription struct {
+	ID        int64              `json:"id"`
+	Name      string             `json:"name"`
+	URL       string             `json:"url"`
+	Secret    string             `json:"secret,omitempty"`
+	Format    string             `json:"format,omitempty"` // "json" (default) or "cloudevents"
+	Filter    SubscriptionFilter `json:"filter"`
+	CreatedBy string             `json:"created_by,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+
+	// Outcome of the latest delivery. Failures counts the deliveries that failed in a row.
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Failures       int        `json:"failures"`
+}
+
+// initSubscriptionSchema creates the subscriptions table if it doesn't exist.
+func (s *PostgreSQLStore) initSubscriptionSchema(ctx context.Context) error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS subscriptions (
+		id BIGSERIAL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		filter JSONB NOT NULL,
+		created_by VARCHAR(255),
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		last_delivery_at TIMESTAMPTZ,
+		last_error TEXT,
+		failures INT NOT NULL DEFAULT 0
+	);
+
+	ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS format VARCHAR(16) NOT NULL DEFAULT '';
+	`
+	if _, err := s.pool.Exec(ctx, createTableSQL); err != nil {
+		return fmt.Errorf("failed to create subscriptions table: %w", err)
+	}
+	return nil
+}
+
+// CreateSubscription persists a new subscription.
+func (s *PostgreSQLStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	filterJSON, err := json.Marshal(sub.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription filter: %w", err)
+	}
+
+	insertSQL := `
+		INSERT INTO subscriptions (name, url, secret, format, filter, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	if err := s.pool.QueryRow(ctx, insertSQL, sub.Name, sub.URL, sub.Secret, sub.Format, filterJSON, sub.CreatedBy).Scan(&sub.ID, &sub.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert subscription: %w", err)
+	}
+	return nil
+}
+
+// ListSubscriptions returns all subscriptions, oldest first, with their secrets.
+func (s *PostgreSQLStore) ListSubscriptions(ctx context.Context) ([]*Subscription, error) {
+	querySQL := `
+		SELECT id, name, url, secret, format, filter, created_by, created_at, last_delivery_at, last_error, failures
+		FROM subscriptions
+		ORDER BY id
+	`
+	rows, err := s.pool.Query(ctx, querySQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []*Subscription{}
+	for rows.Next() {
+		var (
+			sub        Subscription
+			filterJSON []byte
+			createdBy  *string
+			lastError  *string
+		)
+		if err := rows.Scan(&sub.ID, &sub.Name, &sub.URL, &sub.Secret, &sub.Format, &filterJSON, &createdBy, &sub.CreatedAt, &sub.LastDeliveryAt, &lastError, &sub.Failures); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		if err := json.Unmarshal(filterJSON, &sub.Filter); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal subscription filter: %w", err)
+		}
+		if createdBy != nil {
+			sub.CreatedBy = *createdBy
+		}
+		if lastError != nil {
+			sub.LastError = *lastError
+		}
+		subs = append(subs, &sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes a subscription.
+func (s *PostgreSQLStore) DeleteSubscription(ctx context.Context, id int64) error {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM subscriptions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordSubscriptionDelivery records the outcome of a delivery: a nil deliveryErr resets the
+// failure count, a failed delivery increments it.
+func (s *PostgreSQLStore) RecordSubscriptionDelivery(ctx context.Context, id int64, deliveryErr error) error {
+	updateSQL := `
+		UPDATE subscriptions
+		SET last_delivery_at = NOW(), last_error = NULL, failures = 0
+		WHERE id = $1
+	`
+	args := []interface{}{id}
+	if deliveryErr != nil {
+		updateSQL = `
+			UPDATE subscriptions
+			SET last_delivery_at = NOW(), last_error = $2, failures = failures + 1
+			WHERE id = $1
+		`
+		args = append(args, deliveryErr.Error())
+	}
+	if _, err := s.pool.Exec(ctx, updateSQL, args...); err != nil {
+		return fmt.Errorf("failed to record subscription delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NamespaceUsage is the storage used by the events of a namespace. Cluster-scoped events
+// are counted under an empty namespace.
+type NamespaceUsage struct {
+	Namespace string `json:"namespace"`
+
+	// Events is the number of stored events; SampledEvents the number of changes they stand
+	// for, counting each sampled event SampleRate times.
+	Events        int64 `json:"events"`
+	SampledEvents int64 `json:"sampled_events"`
+
+	// Bytes is the storage size of the events' rows, excluding indexes.
+	Bytes int64 `json:"bytes"`
+
+	LastEventAt time.Time `json:"last_event_at"`
+}
+
+// GetUsage returns the event count and storage size of each namespace with events since
+// the given time, largest first. Like QueryEvents, it is limited to the namespace scope
+// of ctx and sent to the read replica if one is usable.
+func (s *PostgreSQLStore) GetUsage(ctx context.Context, since time.Time) ([]*NamespaceUsage, error) {
+	start := time.Now()
+	pool := s.readPool()
+	usage, err := s.getUsage(ctx, pool, since)
+	if err != nil && s.readFailed(pool, err) {
+		usage, err = s.getUsage(ctx, s.pool, since)
+	}
+	s.observeQuery("usage", start, len(usage), func() string {
+		return "since " + since.Format(time.RFC3339)
+	})
+	return usage, err
+}
+
+func (s *PostgreSQLStore) getUsage(ctx context.Context, pool *pgxpool.Pool, since time.Time) ([]*NamespaceUsage, error) {
+	querySQL := `
+		SELECT COALESCE(namespace, ''), COUNT(*), SUM(sample_rate), SUM(pg_column_size(change_events.*)), MAX(timestamp)
+		FROM change_events
+		WHERE timestamp >= $1 AND operation <> 'HEARTBEAT'
+		%s
+		GROUP BY COALESCE(namespace, '')
+		ORDER BY 4 DESC, 1
+	`
+	args := []interface{}{since}
+	scopeSQL := ""
+	if condition, scopeArgs := scopeCondition(ctx, "namespace", 2); condition != "" {
+		scopeSQL = "AND " + condition
+		args = append(args, scopeArgs...)
+	}
+
+	usage := []*NamespaceUsage{}
+	err := s.scoped(ctx, pool, func(q querier) error {
+		rows, err := q.Query(ctx, fmt.Sprintf(querySQL, scopeSQL), args...)
+		if err != nil {
+			return fmt.Errorf("failed to query usage: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var u NamespaceUsage
+			if err := rows.Scan(&u.Namespace, &u.Events, &u.SampledEvents, &u.Bytes, &u.LastEventAt); err != nil {
+				return fmt.Errorf("failed to scan usage: %w", err)
+			}
+			usage = append(usage, &u)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error iterating rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
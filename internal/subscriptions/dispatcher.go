@@ -0,0 +1,308 @@
+// Package subscriptions delivers saved change events to the callback URLs of subscriptions,
+// so external automation (e.g. opening an incident or restarting a canary analysis) can
+// react to changes. Deliveries are signed with the subscription's secret and retried.
+package subscriptions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// Defaults of a dispatcher.
+const (
+	defaultQueueSize       = 1000
+	defaultWorkers         = 4
+	defaultMaxAttempts     = 5
+	defaultBackoff         = time.Second
+	defaultRefreshInterval = 30 * time.Second
+	deliveryTimeout        = 10 * time.Second
+)
+
+// Delivery headers.
+const (
+	HeaderEvent        = "X-Kubechronicle-Event"        // ID of the delivered event
+	HeaderSubscription = "X-Kubechronicle-Subscription" // ID of the subscription
+	HeaderTimestamp    = "X-Kubechronicle-Timestamp"    // Unix time the delivery was signed at
+	HeaderSignature    = "X-Kubechronicle-Signature"    // "sha256=" and the hex HMAC, see Sign
+)
+
+// Delivery metrics.
+var (
+	deliveriesTotal        = expvar.NewInt("subscription_deliveries_total")
+	deliveryFailuresTotal  = expvar.NewInt("subscription_delivery_failures_total")
+	deliveriesDroppedTotal = expvar.NewInt("subscription_deliveries_dropped_total")
+)
+
+// delivery is an event waiting to be delivered to a subscription.
+type delivery struct {
+	sub   *store.Subscription
+	event *model.ChangeEvent
+}
+
+// Dispatcher delivers saved events to the subscriptions they match. Subscriptions are
+// reloaded from the store periodically, so changes made through the API of another
+// process are picked up without restarting.
+type Dispatcher struct {
+	store           store.SubscriptionStore
+	client          *http.Client // Guarded by mu, replaced by SetEgress
+	queue           chan delivery
+	workers         int
+	maxAttempts     int
+	backoff         time.Duration // Initial retry delay, doubled after each attempt
+	refreshInterval time.Duration
+
+	mu   sync.RWMutex
+	subs []*store.Subscription
+}
+
+// NewDispatcher creates a dispatcher delivering to the subscriptions of s.
+func NewDispatcher(s store.SubscriptionStore) *Dispatcher {
+	return &Dispatcher{
+		store:           s,
+		client:          &http.Client{Timeout: deliveryTimeout},
+		queue:           make(chan delivery, defaultQueueSize),
+		workers:         defaultWorkers,
+		maxAttempts:     defaultMaxAttempts,
+		backoff:         defaultBackoff,
+		refreshInterval: defaultRefreshInterval,
+	}
+}
+
+// SetEgress applies the egress controls of the alert configuration (proxy, allowed networks
+// and pinned hosts) to deliveries, like the alert senders. A nil config restores the
+// default, honoring the proxy environment variables. Deliveries in flight keep their
+// connection.
+func (d *Dispatcher) SetEgress(cfg *alerting.EgressConfig) error {
+	if d == nil {
+		return nil
+	}
+	transport, err := cfg.Transport()
+	if err != nil {
+		return fmt.Errorf("invalid egress config: %w", err)
+	}
+	d.mu.Lock()
+	d.client = &http.Client{Timeout: deliveryTimeout, Transport: transport}
+	d.mu.Unlock()
+	return nil
+}
+
+// Start loads the subscriptions and starts delivering. It returns immediately; delivery
+// stops when ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	d.refresh(ctx)
+	go d.refreshLoop(ctx)
+	for i := 0; i < d.workers; i++ {
+		go d.run(ctx)
+	}
+}
+
+// Enqueue queues an event for delivery to the subscriptions it matches (non-blocking).
+func (d *Dispatcher) Enqueue(event *model.ChangeEvent) {
+	if d == nil {
+		return
+	}
+	d.mu.RLock()
+	subs := d.subs
+	d.mu.RUnlock()
+
+	for _, sub := range subs {
+		if !Matches(&sub.Filter, event) {
+			continue
+		}
+		select {
+		case d.queue <- delivery{sub: sub, event: event}:
+		default:
+			deliveriesDroppedTotal.Add(1)
+			klog.Errorf("Subscription queue full, event %s not delivered to subscription %d", event.ID, sub.ID)
+		}
+	}
+}
+
+// refreshLoop reloads the subscriptions until ctx is cancelled.
+func (d *Dispatcher) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.refresh(ctx)
+		}
+	}
+}
+
+// refresh reloads the subscriptions, keeping the current ones if the store fails.
+func (d *Dispatcher) refresh(ctx context.Context) {
+	subs, err := d.store.ListSubscriptions(ctx)
+	if err != nil {
+		klog.Warningf("Failed to load subscriptions: %v", err)
+		return
+	}
+	d.mu.Lock()
+	d.subs = subs
+	d.mu.Unlock()
+}
+
+// run delivers queued events until ctx is cancelled.
+func (d *Dispatcher) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case next := <-d.queue:
+			d.deliverWithRetry(ctx, next)
+		}
+	}
+}
+
+// deliverWithRetry delivers an event, retrying with exponential backoff, and records the
+// outcome on the subscription.
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, next delivery) {
+	var payload interface{} = next.event
+	if next.sub.Format == model.FormatCloudEvents {
+		payload = model.NewCloudEvent(next.event, "")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		klog.Errorf("Failed to marshal event %s for subscription %d: %v", next.event.ID, next.sub.ID, err)
+		return
+	}
+
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.deliver(ctx, next, body)
+		if err == nil {
+			deliveriesTotal.Add(1)
+			klog.V(2).Infof("Delivered event %s to subscription %d", next.event.ID, next.sub.ID)
+			d.record(ctx, next.sub, nil)
+			return
+		}
+		if !retry || attempt >= d.maxAttempts {
+			deliveryFailuresTotal.Add(1)
+			klog.Errorf("Failed to deliver event %s to subscription %d after %d attempts: %v", next.event.ID, next.sub.ID, attempt, err)
+			d.record(ctx, next.sub, err)
+			return
+		}
+		klog.Warningf("Failed to deliver event %s to subscription %d (attempt %d/%d): %v", next.event.ID, next.sub.ID, attempt, d.maxAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// deliver posts the event to the subscription's URL once, reporting whether a failure is
+// worth retrying. Client errors other than timeouts and rate limiting are not.
+func (d *Dispatcher) deliver(ctx context.Context, next delivery, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, next.sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	contentType := "application/json"
+	if next.sub.Format == model.FormatCloudEvents {
+		contentType = model.CloudEventsContentType
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "kubechronicle")
+	req.Header.Set(HeaderEvent, next.event.ID)
+	req.Header.Set(HeaderSubscription, strconv.FormatInt(next.sub.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(next.sub.Secret, timestamp, body))
+
+	d.mu.RLock()
+	client := d.client
+	d.mu.RUnlock()
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// record stores the outcome of a delivery on the subscription.
+func (d *Dispatcher) record(ctx context.Context, sub *store.Subscription, deliveryErr error) {
+	if err := d.store.RecordSubscriptionDelivery(ctx, sub.ID, deliveryErr); err != nil {
+		klog.Warningf("Failed to record delivery to subscription %d: %v", sub.ID, err)
+	}
+}
+
+// Matches reports whether an event matches a subscription filter.
+func Matches(filter *store.SubscriptionFilter, event *model.ChangeEvent) bool {
+	if filter.BlockedOnly && event.Allowed {
+		return false
+	}
+	// Heartbeats are delivered only to subscriptions selecting them by operation
+	if event.Operation == model.OperationHeartbeat && len(filter.Operations) == 0 {
+		return false
+	}
+	return matchesAny(filter.Namespaces, event.Namespace) &&
+		matchesAny(filter.ResourceKinds, event.ResourceKind) &&
+		matchesAny(filter.Names, event.Name) &&
+		matchesAny(filter.Operations, event.Operation) &&
+		matchesAny(filter.Users, event.Actor.Username)
+}
+
+// matchesAny reports whether value matches any of the patterns. No patterns matches everything.
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribingStore wraps a store and queues every successfully saved event for delivery.
+type subscribingStore struct {
+	store.Store
+	dispatcher *Dispatcher
+}
+
+// WrapStore returns a store that delivers events to subscriptions after they are saved.
+// If no dispatcher is configured, the store is returned unchanged.
+func WrapStore(s store.Store, dispatcher *Dispatcher) store.Store {
+	if s == nil || dispatcher == nil {
+		return s
+	}
+	return &subscribingStore{Store: s, dispatcher: dispatcher}
+}
+
+// Save persists the event and then queues it for delivery.
+func (s *subscribingStore) Save(event *model.ChangeEvent) error {
+	if err := s.Store.Save(event); err != nil {
+		return err
+	}
+	s.dispatcher.Enqueue(event)
+	return nil
+}
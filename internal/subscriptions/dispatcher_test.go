@@ -0,0 +1,322 @@
+package subscriptions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+	"github.com/kubechronicle/kubechronicle/pkg/alerting"
+)
+
+// fakeSubscriptionStore is an in-memory store.SubscriptionStore recording delivery outcomes.
+type fakeSubscriptionStore struct {
+	mu       sync.Mutex
+	subs     []*store.Subscription
+	outcomes chan error
+}
+
+func newFakeSubscriptionStore(subs ...*store.Subscription) *fakeSubscriptionStore {
+	return &fakeSubscriptionStore{subs: subs, outcomes: make(chan error, 10)}
+}
+
+func (f *fakeSubscriptionStore) CreateSubscription(ctx context.Context, sub *store.Subscription) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs = append(f.subs, sub)
+	return nil
+}
+
+func (f *fakeSubscriptionStore) ListSubscriptions(ctx context.Context) ([]*store.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*store.Subscription{}, f.subs...), nil
+}
+
+func (f *fakeSubscriptionStore) DeleteSubscription(ctx context.Context, id int64) error {
+	return store.ErrNotFound
+}
+
+func (f *fakeSubscriptionStore) RecordSubscriptionDelivery(ctx context.Context, id int64, deliveryErr error) error {
+	f.outcomes <- deliveryErr
+	return nil
+}
+
+// outcome waits for the next recorded delivery outcome.
+func (f *fakeSubscriptionStore) outcome(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-f.outcomes:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("No delivery recorded")
+		return nil
+	}
+}
+
+func newTestDispatcher(s store.SubscriptionStore) *Dispatcher {
+	d := NewDispatcher(s)
+	d.backoff = time.Millisecond
+	d.workers = 1
+	return d
+}
+
+func TestDispatcher_DeliversSignedEvents(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header, body}
+	}))
+	defer server.Close()
+
+	fake := newFakeSubscriptionStore(&store.Subscription{
+		ID: 7, URL: server.URL, Secret: "s3cret",
+		Filter: store.SubscriptionFilter{Namespaces: []string{"prod-*"}, Operations: []string{"DELETE"}},
+	})
+	d := newTestDispatcher(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	// Only the event matching the filter is delivered
+	d.Enqueue(&model.ChangeEvent{ID: "e1", Namespace: "dev", Operation: "DELETE"})
+	d.Enqueue(&model.ChangeEvent{ID: "e2", Namespace: "prod-eu", Operation: "DELETE"})
+
+	if err := fake.outcome(t); err != nil {
+		t.Fatalf("Delivery failed: %v", err)
+	}
+	req := <-requests
+	if req.header.Get(HeaderEvent) != "e2" || req.header.Get(HeaderSubscription) != "7" {
+		t.Errorf("Unexpected delivery headers: %v", req.header)
+	}
+	if err := Verify("s3cret", req.header.Get(HeaderTimestamp), req.header.Get(HeaderSignature), req.body, time.Minute); err != nil {
+		t.Errorf("Signature does not verify: %v", err)
+	}
+	if err := Verify("other", req.header.Get(HeaderTimestamp), req.header.Get(HeaderSignature), req.body, time.Minute); err == nil {
+		t.Error("Signature verified with the wrong secret")
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	fake := newFakeSubscriptionStore(&store.Subscription{ID: 1, URL: server.URL, Secret: "s"})
+	d := newTestDispatcher(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+	d.Enqueue(&model.ChangeEvent{ID: "e1"})
+
+	if err := fake.outcome(t); err != nil {
+		t.Fatalf("Delivery failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestDispatcher_ClientErrorsNotRetried(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	fake := newFakeSubscriptionStore(&store.Subscription{ID: 1, URL: server.URL, Secret: "s"})
+	d := newTestDispatcher(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+	d.Enqueue(&model.ChangeEvent{ID: "e1"})
+
+	if err := fake.outcome(t); err == nil {
+		t.Fatal("Expected the delivery to fail")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestDispatcher_Egress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	fake := newFakeSubscriptionStore(&store.Subscription{ID: 1, URL: server.URL, Secret: "s"})
+	d := newTestDispatcher(fake)
+	d.maxAttempts = 1
+	// The test server listens on the loopback address, outside the allowed network
+	if err := d.SetEgress(&alerting.EgressConfig{AllowedNetworks: []string{"203.0.113.0/24"}}); err != nil {
+		t.Fatalf("SetEgress() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	d.Enqueue(&model.ChangeEvent{ID: "e1"})
+	if err := fake.outcome(t); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Fatalf("Expected the delivery to be refused, got %v", err)
+	}
+
+	// Removing the controls allows it again
+	if err := d.SetEgress(nil); err != nil {
+		t.Fatalf("SetEgress() error = %v", err)
+	}
+	d.Enqueue(&model.ChangeEvent{ID: "e2"})
+	if err := fake.outcome(t); err != nil {
+		t.Fatalf("Delivery failed: %v", err)
+	}
+
+	if err := d.SetEgress(&alerting.EgressConfig{Proxy: "://"}); err == nil {
+		t.Error("SetEgress() should reject an invalid proxy")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	event := &model.ChangeEvent{
+		Namespace: "payments", ResourceKind: "Deployment", Name: "api", Operation: "UPDATE", Allowed: true,
+		Actor: model.Actor{Username: "system:serviceaccount:argocd:argocd-application-controller"},
+	}
+	tests := []struct {
+		name   string
+		filter store.SubscriptionFilter
+		want   bool
+	}{
+		{"empty filter", store.SubscriptionFilter{}, true},
+		{"matching patterns", store.SubscriptionFilter{Namespaces: []string{"pay*"}, ResourceKinds: []string{"Deployment", "StatefulSet"}}, true},
+		{"user pattern", store.SubscriptionFilter{Users: []string{"system:serviceaccount:argocd:*"}}, true},
+		{"other operation", store.SubscriptionFilter{Operations: []string{"DELETE"}}, false},
+		{"other name", store.SubscriptionFilter{Names: []string{"web"}}, false},
+		{"blocked only", store.SubscriptionFilter{BlockedOnly: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Matches(&tt.filter, event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Heartbeats are delivered only to subscriptions selecting them
+	heartbeat := &model.ChangeEvent{Operation: model.OperationHeartbeat, ResourceKind: "Heartbeat", Name: "webhook", Allowed: true}
+	if Matches(&store.SubscriptionFilter{}, heartbeat) {
+		t.Error("Matches() should not deliver heartbeats to an empty filter")
+	}
+	if !Matches(&store.SubscriptionFilter{Operations: []string{"HEARTBEAT"}}, heartbeat) {
+		t.Error("Matches() should deliver heartbeats to a filter selecting them")
+	}
+}
+
+func TestVerify_RejectsOldDeliveries(t *testing.T) {
+	body := []byte(`{"id":"e1"}`)
+	timestamp := time.Now().Add(-time.Hour).Unix()
+	header := strconv.FormatInt(timestamp, 10)
+	signature := Sign("s3cret", timestamp, body)
+
+	if err := Verify("s3cret", header, signature, body, 5*time.Minute); err == nil {
+		t.Error("Verify() accepted a delivery signed an hour ago")
+	}
+	if err := Verify("s3cret", header, signature, body, 2*time.Hour); err != nil {
+		t.Errorf("Verify() = %v within maxAge", err)
+	}
+	if err := Verify("s3cret", "yesterday", signature, body, 5*time.Minute); err == nil {
+		t.Error("Verify() accepted an invalid timestamp")
+	}
+}
+
+func TestDispatcher_DeliversCloudEvents(t *testing.T) {
+	type received struct {
+		contentType string
+		body        []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header.Get("Content-Type"), body}
+	}))
+	defer server.Close()
+
+	fake := newFakeSubscriptionStore(&store.Subscription{ID: 1, URL: server.URL, Secret: "s", Format: model.FormatCloudEvents})
+	d := newTestDispatcher(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+	d.Enqueue(&model.ChangeEvent{ID: "e1", Operation: "DELETE", ResourceKind: "Deployment", Namespace: "prod", Name: "api"})
+
+	if err := fake.outcome(t); err != nil {
+		t.Fatalf("Delivery failed: %v", err)
+	}
+	req := <-requests
+	if req.contentType != model.CloudEventsContentType {
+		t.Errorf("Content-Type = %q, want %q", req.contentType, model.CloudEventsContentType)
+	}
+	var ce model.CloudEvent
+	if err := json.Unmarshal(req.body, &ce); err != nil {
+		t.Fatalf("Failed to decode CloudEvent: %v", err)
+	}
+	if ce.SpecVersion != "1.0" || ce.ID != "e1" || ce.Type != "io.kubechronicle.change.delete" || ce.Subject != "Deployment/prod/api" || ce.Data == nil {
+		t.Errorf("Unexpected CloudEvent: %+v", ce)
+	}
+}
+
+// dedupingStore is a minimal store.Store skipping events whose fingerprint is already
+// stored, like PostgreSQLStore.
+type dedupingStore struct {
+	store.Store
+	fingerprints map[string]bool
+}
+
+func (s *dedupingStore) Save(event *model.ChangeEvent) error {
+	if s.fingerprints[event.Fingerprint] {
+		return store.ErrDuplicate
+	}
+	s.fingerprints[event.Fingerprint] = true
+	return nil
+}
+
+func TestWrapStore_Duplicates(t *testing.T) {
+	d := newTestDispatcher(newFakeSubscriptionStore())
+	d.subs = []*store.Subscription{{ID: 1, URL: "http://example.invalid"}}
+	s := WrapStore(&dedupingStore{fingerprints: map[string]bool{}}, d)
+
+	// A retried admission request is delivered once
+	if err := s.Save(&model.ChangeEvent{ID: "e1", Fingerprint: "f1"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := s.Save(&model.ChangeEvent{ID: "e2", Fingerprint: "f1"}); !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("Save() of the retry error = %v, want ErrDuplicate", err)
+	}
+	if len(d.queue) != 1 {
+		t.Errorf("queued = %d, want the event queued once", len(d.queue))
+	}
+}
@@ -0,0 +1,40 @@
+package subscriptions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sign returns the signature header of a delivery: "sha256=" followed by the hex HMAC-SHA256,
+// keyed with the subscription's secret, of the timestamp, a dot and the body. Signing the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery, rejecting deliveries
+// signed more than maxAge ago. It is meant for receivers written in Go.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, maxAge time.Duration) error {
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestampHeader)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("timestamp is %s off", age.Round(time.Second))
+	}
+	if !strings.HasPrefix(signatureHeader, "sha256=") {
+		return fmt.Errorf("unsupported signature %q", signatureHeader)
+	}
+	if !hmac.Equal([]byte(signatureHeader), []byte(Sign(secret, timestamp, body))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package tenancy scopes API requests to the namespaces of the caller's tenants.
+package tenancy
+
+import (
+	"net/http"
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+// Resolver maps API users to the namespaces of their tenants.
+type Resolver struct {
+	config *config.TenancyConfig
+}
+
+// NewResolver creates a resolver for the tenants of cfg.
+func NewResolver(cfg *config.TenancyConfig) *Resolver {
+	return &Resolver{config: cfg}
+}
+
+// Tenants returns the names of the tenants user belongs to, by username or role.
+func (r *Resolver) Tenants(user *auth.User) []string {
+	tenants := []string{}
+	for name, tenant := range r.config.Tenants {
+		if contains(tenant.Users, user.Username) || containsAny(tenant.Roles, user.Roles) {
+			tenants = append(tenants, name)
+		}
+	}
+	return tenants
+}
+
+// NamespaceTenants returns the names of the tenants owning namespace, sorted.
+func (r *Resolver) NamespaceTenants(namespace string) []string {
+	tenants := []string{}
+	for name, tenant := range r.config.Tenants {
+		for _, pattern := range tenant.Namespaces {
+			if store.MatchWildcard(pattern, namespace) {
+				tenants = append(tenants, name)
+				break
+			}
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Scope returns the namespace patterns user may see, and false if it may see every event.
+// Users without a tenant see no events.
+func (r *Resolver) Scope(user *auth.User) ([]string, bool) {
+	if containsAny(r.config.UnscopedRoles, user.Roles) {
+		return nil, false
+	}
+	patterns := []string{}
+	for _, name := range r.Tenants(user) {
+		patterns = append(patterns, r.config.Tenants[name].Namespaces...)
+	}
+	return patterns, true
+}
+
+// Middleware scopes the store reads of each authenticated request to the caller's tenants.
+// It must run after the authentication middleware. Requests without a user, when
+// authentication is disabled, are not scoped.
+func (r *Resolver) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user, ok := auth.GetUser(req)
+			if !ok {
+				next.ServeHTTP(w, req)
+				return
+			}
+			patterns, scoped := r.Scope(user)
+			if !scoped {
+				next.ServeHTTP(w, req)
+				return
+			}
+			if len(patterns) == 0 {
+				klog.V(2).Infof("User %s belongs to no tenant, hiding all events", user.Username)
+			}
+			next.ServeHTTP(w, req.WithContext(store.WithNamespaceScope(req.Context(), patterns)))
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, candidate := range candidates {
+		if contains(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
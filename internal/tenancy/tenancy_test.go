@@ -0,0 +1,70 @@
+package tenancy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/auth"
+	"github.com/kubechronicle/kubechronicle/internal/config"
+	"github.com/kubechronicle/kubechronicle/internal/store"
+)
+
+func testResolver() *Resolver {
+	return NewResolver(&config.TenancyConfig{
+		Tenants: map[string]config.TenantConfig{
+			"payments": {Namespaces: []string{"payments-*"}, Users: []string{"alice"}, Roles: []string{"payments-team"}},
+			"search":   {Namespaces: []string{"search"}, Roles: []string{"search-team"}},
+		},
+		UnscopedRoles: []string{"admin"},
+	})
+}
+
+func TestResolver_Scope(t *testing.T) {
+	r := testResolver()
+	tests := []struct {
+		name     string
+		user     *auth.User
+		patterns []string
+		scoped   bool
+	}{
+		{"by username", &auth.User{Username: "alice"}, []string{"payments-*"}, true},
+		{"by role", &auth.User{Username: "bob", Roles: []string{"search-team"}}, []string{"search"}, true},
+		{"several tenants", &auth.User{Username: "alice", Roles: []string{"search-team"}}, []string{"payments-*", "search"}, true},
+		{"no tenant", &auth.User{Username: "mallory", Roles: []string{"viewer"}}, []string{}, true},
+		{"unscoped role", &auth.User{Username: "root", Roles: []string{"payments-team", "admin"}}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, scoped := r.Scope(tt.user)
+			sort.Strings(patterns)
+			if scoped != tt.scoped || !reflect.DeepEqual(patterns, tt.patterns) {
+				t.Errorf("Scope() = %v, %v, want %v, %v", patterns, scoped, tt.patterns, tt.scoped)
+			}
+		})
+	}
+}
+
+func TestResolver_Middleware(t *testing.T) {
+	var patterns []string
+	var scoped bool
+	handler := testResolver().Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		patterns, scoped = store.NamespaceScope(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user", &auth.User{Username: "alice"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !scoped || !reflect.DeepEqual(patterns, []string{"payments-*"}) {
+		t.Errorf("Expected the request to be scoped to payments, got %v, %v", patterns, scoped)
+	}
+
+	// Without authentication there is no user to scope
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/kubechronicle/api/changes", nil))
+	if scoped {
+		t.Errorf("Expected an anonymous request not to be scoped, got %v", patterns)
+	}
+}
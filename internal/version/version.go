@@ -0,0 +1,110 @@
+// Package version identifies the build of the running binary: its version, commit and build
+// date, injected at compile time with
+//
+//	-ldflags "-X github.com/kubechronicle/kubechronicle/internal/version.Version=v1.2.3
+//	          -X github.com/kubechronicle/kubechronicle/internal/version.Commit=abc1234
+//	          -X github.com/kubechronicle/kubechronicle/internal/version.BuildDate=2026-01-05T10:00:00Z"
+//
+// as `make build` and `make release` do.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at compile time. Binaries built without them report version "dev"
+// and the commit recorded by the Go toolchain, if built from a git checkout.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = "" // RFC 3339
+)
+
+// Header is the response header carrying the version of the server.
+const Header = "X-Kubechronicle-Version"
+
+// Info is the build of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"` // GOOS/GOARCH
+}
+
+// Get returns the build of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info.Commit == "" {
+		info.Commit = vcsRevision()
+	}
+	return info
+}
+
+// vcsRevision returns the commit the Go toolchain recorded the binary was built from,
+// suffixed with "-dirty" if the checkout had changes, or "" if it wasn't built from one.
+func vcsRevision() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision, modified string
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision != "" && modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// String returns the build on one line, e.g. "v1.2.3 (commit abc1234, built
+// 2026-01-05T10:00:00Z, go1.25.0 linux/amd64)".
+func (i Info) String() string {
+	s := i.Version + " ("
+	if i.Commit != "" {
+		s += "commit " + i.Commit + ", "
+	}
+	if i.BuildDate != "" {
+		s += "built " + i.BuildDate + ", "
+	}
+	return s + i.GoVersion + " " + i.Platform + ")"
+}
+
+// Print writes the build of the binary named name to stdout, for -version flags.
+func Print(name string) {
+	fmt.Printf("%s %s\n", name, Get())
+}
+
+// Handler serves GET /version, the build of the running binary as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Get())
+}
+
+// Middleware sets the Header of every response to the version of the server.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(Header, Version)
+		next.ServeHTTP(w, r)
+	})
+}
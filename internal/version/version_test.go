@@ -0,0 +1,67 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	defer func(version, commit, buildDate string) {
+		Version, Commit, BuildDate = version, commit, buildDate
+	}(Version, Commit, BuildDate)
+	Version, Commit, BuildDate = "v1.2.3", "abc1234", "2026-01-05T10:00:00Z"
+
+	info := Get()
+	if info.Version != "v1.2.3" || info.Commit != "abc1234" || info.BuildDate != "2026-01-05T10:00:00Z" {
+		t.Errorf("Unexpected build metadata: %+v", info)
+	}
+	if info.GoVersion != runtime.Version() || info.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("Unexpected toolchain: %+v", info)
+	}
+}
+
+func TestInfoString(t *testing.T) {
+	info := Info{Version: "v1.2.3", Commit: "abc1234", BuildDate: "2026-01-05T10:00:00Z", GoVersion: "go1.25.0", Platform: "linux/arm64"}
+	if got, want := info.String(), "v1.2.3 (commit abc1234, built 2026-01-05T10:00:00Z, go1.25.0 linux/arm64)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	info = Info{Version: "dev", GoVersion: "go1.25.0", Platform: "linux/amd64"}
+	if got, want := info.String(), "dev (go1.25.0 linux/amd64)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var info Info
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Version != Version || info.GoVersion == "" {
+		t.Errorf("Unexpected response %+v", info)
+	}
+
+	w = httptest.NewRecorder()
+	Handler(w, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for POST, got %d", w.Code)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if got := w.Header().Get(Header); got != Version {
+		t.Errorf("%s = %q, want %q", Header, got, Version)
+	}
+}
@@ -0,0 +1,145 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// EgressConfig controls the connections the HTTP senders (Slack, Telegram, webhook and its
+// token exchange) and the deliveries to subscriptions open, for corporate egress policies. Without it, or without Proxy, the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are honored.
+type EgressConfig struct {
+	// Proxy is the URL of the proxy requests are sent through, e.g.
+	// "http://proxy.corp.example.com:3128", overriding the environment.
+	Proxy string `json:"proxy,omitempty"`
+
+	// NoProxy lists the hosts, domains (".example.com") and networks reached directly
+	// rather than through Proxy, like NO_PROXY.
+	NoProxy []string `json:"no_proxy,omitempty"`
+
+	// AllowedNetworks are the networks (CIDRs or addresses) connections may be opened to.
+	// Connections to other addresses are refused; through a proxy, this is the proxy's
+	// address. Empty allows every address.
+	AllowedNetworks []string `json:"allowed_networks,omitempty"`
+
+	// Resolve pins hosts to addresses, bypassing DNS, e.g. {"hooks.slack.com": "203.0.113.10"}.
+	// TLS still verifies the certificate of the host name.
+	Resolve map[string]string `json:"resolve,omitempty"`
+}
+
+// egressDialer dials with the egress controls of an EgressConfig.
+type egressDialer struct {
+	dialer  *net.Dialer
+	allowed []netip.Prefix
+	resolve map[string]string
+}
+
+// Transport returns an HTTP transport applying the egress controls. A nil config returns the
+// default transport, honoring the proxy environment variables.
+func (c *EgressConfig) Transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c == nil {
+		return transport, nil
+	}
+
+	if c.Proxy != "" {
+		proxyURL, err := url.Parse(c.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", c.Proxy)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  c.Proxy,
+			HTTPSProxy: c.Proxy,
+			NoProxy:    strings.Join(c.NoProxy, ","),
+		}).ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	d := &egressDialer{
+		dialer:  &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		resolve: map[string]string{},
+	}
+	for _, network := range c.AllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(network)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid allowed network %q", network)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		d.allowed = append(d.allowed, prefix.Masked())
+	}
+	for host, address := range c.Resolve {
+		if _, err := netip.ParseAddr(address); err != nil {
+			return nil, fmt.Errorf("invalid address %q pinned for %s", address, host)
+		}
+		d.resolve[strings.ToLower(host)] = address
+	}
+	if len(d.allowed) > 0 {
+		d.dialer.Control = d.control
+	}
+	transport.DialContext = d.dialContext
+	return transport, nil
+}
+
+// dialContext connects to the pinned address of the host, if any, else resolves it.
+func (d *egressDialer) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if pinned, ok := d.resolve[strings.ToLower(host)]; ok {
+		address = net.JoinHostPort(pinned, port)
+	}
+	return d.dialer.DialContext(ctx, network, address)
+}
+
+// control refuses connections to addresses outside the allowed networks. It runs after
+// name resolution, for each address tried, so names resolving to other addresses can't
+// get around the allowlist.
+func (d *egressDialer) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("egress to %s refused: %w", address, err)
+	}
+	ip := addrPort.Addr().Unmap()
+	for _, prefix := range d.allowed {
+		if prefix.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("egress to %s refused: not in the allowed networks", ip)
+}
+
+// httpSender is implemented by the senders making HTTP requests, so the router can apply the
+// egress controls to them.
+type httpSender interface {
+	setTransport(transport http.RoundTripper)
+}
+
+func (s *SlackSender) setTransport(transport http.RoundTripper) {
+	s.client.Transport = transport
+}
+
+func (s *TelegramSender) setTransport(transport http.RoundTripper) {
+	s.client.Transport = transport
+}
+
+func (s *WebhookSender) setTransport(transport http.RoundTripper) {
+	s.client.Transport = transport
+	if exchanger, ok := s.tokens.(*TokenExchanger); ok {
+		exchanger.client.Transport = transport
+	}
+}
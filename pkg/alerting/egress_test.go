@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEgressConfig(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Host)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port := serverURL.Port()
+
+	tests := []struct {
+		name   string
+		url    string
+		egress *EgressConfig
+		wantOK bool
+	}{
+		{"allowed", server.URL, &EgressConfig{AllowedNetworks: []string{"127.0.0.0/8"}}, true},
+		{"allowed address", server.URL, &EgressConfig{AllowedNetworks: []string{"10.0.0.0/8", "127.0.0.1"}}, true},
+		{"refused", server.URL, &EgressConfig{AllowedNetworks: []string{"10.0.0.0/8"}}, false},
+		{"pinned", "http://alerts.example.invalid:" + port, &EgressConfig{Resolve: map[string]string{"alerts.example.invalid": "127.0.0.1"}}, true},
+		{"pinned and refused", "http://alerts.example.invalid:" + port, &EgressConfig{Resolve: map[string]string{"alerts.example.invalid": "127.0.0.1"}, AllowedNetworks: []string{"192.0.2.0/24"}}, false},
+	}
+	for _, tt := range tests {
+		router, err := NewRouter(&Config{Webhook: &WebhookConfig{URL: tt.url}, Egress: tt.egress})
+		if err != nil {
+			t.Fatalf("%s: NewRouter() error = %v", tt.name, err)
+		}
+		err = router.Deliver(teamEvent(""), nil)
+		if (err == nil) != tt.wantOK {
+			t.Errorf("%s: Deliver() error = %v, want success %v", tt.name, err, tt.wantOK)
+		}
+		if err != nil && !tt.wantOK && !strings.Contains(err.Error(), "not in the allowed networks") {
+			t.Errorf("%s: Deliver() error = %v, want egress refused", tt.name, err)
+		}
+	}
+	if len(received) != 3 || received[2] != "alerts.example.invalid:"+port {
+		t.Errorf("Requests received for hosts %v", received)
+	}
+}
+
+func TestEgressConfig_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String()) // Absolute URL of a proxied request
+	}))
+	defer proxy.Close()
+
+	egress := &EgressConfig{Proxy: proxy.URL, NoProxy: []string{".internal.example.com"}}
+	for _, target := range []string{"http://hooks.example.com/alert", "http://alerts.internal.example.com/alert"} {
+		router, err := NewRouter(&Config{Webhook: &WebhookConfig{URL: target}, Egress: egress})
+		if err != nil {
+			t.Fatalf("NewRouter() error = %v", err)
+		}
+		router.Deliver(teamEvent(""), nil)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://hooks.example.com/alert" {
+		t.Errorf("Proxied requests = %v, want only the one not in no_proxy", proxied)
+	}
+
+	for _, egress := range []*EgressConfig{
+		{Proxy: "not a url"},
+		{AllowedNetworks: []string{"10.0.0.0/33"}},
+		{Resolve: map[string]string{"hooks.slack.com": "hooks.example.com"}},
+	} {
+		if _, err := NewRouter(&Config{Webhook: &WebhookConfig{URL: "http://example.com"}, Egress: egress}); err == nil {
+			t.Errorf("NewRouter() should fail with egress %+v", egress)
+		}
+	}
+}
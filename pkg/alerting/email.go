@@ -0,0 +1,166 @@
+package alerting
+
+import (
+	"fmt"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// EmailSender sends alerts via email.
+type EmailSender struct {
+	config   *EmailConfig
+	teams    TeamRoutes
+	messages *i18n.Catalog
+	times    *timeFormatter
+}
+
+// NewEmailSender creates a new email alert sender.
+func NewEmailSender(cfg *EmailConfig) (*EmailSender, error) {
+	if cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.SMTPPort == 0 {
+		return nil, fmt.Errorf("SMTP port is required")
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("from address is required")
+	}
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	if err := cfg.TimeSettings.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &EmailSender{
+		config: cfg,
+		times:  cfg.TimeSettings.formatter(),
+	}, nil
+}
+
+// SetTeamRoutes sends the alerts of teams with recipients to them instead of To.
+func (s *EmailSender) SetTeamRoutes(teams TeamRoutes) {
+	s.teams = teams
+}
+
+// recipients returns the recipients of the alert for an event: those routed to its team,
+// else the configured ones.
+func (s *EmailSender) recipients(event *model.ChangeEvent) []string {
+	if route := s.teams.route(event); route != nil && len(route.EmailTo) > 0 {
+		return route.EmailTo
+	}
+	return s.config.To
+}
+
+// Name returns the sender name.
+func (s *EmailSender) Name() string {
+	return "email"
+}
+
+// Send sends an alert via email.
+func (s *EmailSender) Send(event *model.ChangeEvent) error {
+	l := s.messages.ForNamespace(event.Namespace)
+	subject := s.getSubject(l, event)
+	body := formatEmailBody(l, s.times, event)
+
+	// Build message
+	to := s.recipients(event)
+	message := s.buildEmailMessage(to, subject, body)
+
+	// SMTP address
+	addr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
+
+	// Authentication
+	var auth smtp.Auth
+	if s.config.SMTPUsername != "" && s.config.SMTPPassword != "" {
+		auth = smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
+	}
+
+	// Send email
+	err := smtp.SendMail(addr, auth, s.config.From, to, []byte(message))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EmailSender) getSubject(l *i18n.Localizer, event *model.ChangeEvent) string {
+	if s.config.Subject != "" {
+		// Simple template replacement
+		subject := s.config.Subject
+		subject = strings.ReplaceAll(subject, "{{operation}}", event.Operation)
+		subject = strings.ReplaceAll(subject, "{{resource}}", fmt.Sprintf("%s/%s", event.ResourceKind, event.Name))
+		subject = strings.ReplaceAll(subject, "{{namespace}}", event.Namespace)
+		return subject
+	}
+
+	// Default subject
+	return l.Message(i18n.AlertSubject,
+		"operation", event.Operation,
+		"kind", event.ResourceKind,
+		"namespace", event.Namespace,
+		"name", event.Name,
+	)
+}
+
+func formatEmailBody(l *i18n.Localizer, times *timeFormatter, event *model.ChangeEvent) string {
+	var sb strings.Builder
+
+	sb.WriteString(l.Message(i18n.AlertTitle, "operation", event.Operation) + "\n")
+	sb.WriteString(strings.Repeat("=", 60) + "\n\n")
+	sb.WriteString(fmt.Sprintf("%s: %s/%s\n", l.Message(i18n.AlertResource), event.ResourceKind, event.Name))
+	sb.WriteString(fmt.Sprintf("%s: %s\n", l.Message(i18n.AlertNamespace), event.Namespace))
+	sb.WriteString(fmt.Sprintf("%s: %s\n", l.Message(i18n.AlertOperation), event.Operation))
+	sb.WriteString(fmt.Sprintf("%s: %s\n\n", l.Message(i18n.AlertTimestamp), times.format(event.Timestamp)))
+
+	sb.WriteString(l.Message(i18n.AlertActor) + ":\n")
+	sb.WriteString(fmt.Sprintf("  %s: %s\n", l.Message(i18n.AlertUsername), event.Actor.Username))
+	if len(event.Actor.Groups) > 0 {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", l.Message(i18n.AlertGroups), strings.Join(event.Actor.Groups, ", ")))
+	}
+	if event.Actor.ServiceAccount != "" {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", l.Message(i18n.AlertServiceAccount), event.Actor.ServiceAccount))
+	}
+	if event.Actor.SourceIP != "" {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", l.Message(i18n.AlertSourceIP), event.Actor.SourceIP))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n%s: %s\n", l.Message(i18n.AlertSourceTool), event.Source.Tool))
+
+	if command := execCommand(event); command != "" {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", l.Message(i18n.AlertCommand), command))
+	}
+	if risk := execRisk(event); risk != "" {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", l.Message(i18n.AlertRisk), risk))
+	}
+
+	if len(event.Diff) > 0 {
+		sb.WriteString(fmt.Sprintf("\n%s: %s\n", l.Message(i18n.AlertChanges), l.Message(i18n.AlertPatchCount, "count", strconv.Itoa(len(event.Diff)))))
+		sb.WriteString(strings.Repeat("-", 60) + "\n")
+		for i, patch := range event.Diff {
+			sb.WriteString(fmt.Sprintf("%d. %s %s\n", i+1, patch.Op, patch.Path))
+		}
+	}
+
+	return sb.String()
+}
+
+func (s *EmailSender) buildEmailMessage(to []string, subject, body string) string {
+	var msg strings.Builder
+
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", s.config.From))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	msg.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+
+	return msg.String()
+}
@@ -101,7 +101,7 @@ func TestEmailSender_GetSubject_Default(t *testing.T) {
 		Name:         "test-app",
 	}
 
-	subject := sender.getSubject(event)
+	subject := sender.getSubject(nil, event)
 	if subject == "" {
 		t.Error("getSubject() should not return empty string")
 	}
@@ -127,7 +127,7 @@ func TestEmailSender_GetSubject_Template(t *testing.T) {
 		Name:         "test-app",
 	}
 
-	subject := sender.getSubject(event)
+	subject := sender.getSubject(nil, event)
 	if !strings.Contains(subject, "UPDATE") {
 		t.Error("getSubject() should replace {{operation}}")
 	}
@@ -156,7 +156,7 @@ func TestFormatEmailBody(t *testing.T) {
 		},
 	}
 
-	body := formatEmailBody(event)
+	body := formatEmailBody(nil, nil, event)
 	if body == "" {
 		t.Error("formatEmailBody() should not return empty string")
 	}
@@ -180,7 +180,7 @@ func TestFormatEmailBody_WithDiff(t *testing.T) {
 		},
 	}
 
-	body := formatEmailBody(event)
+	body := formatEmailBody(nil, nil, event)
 	if !strings.Contains(body, "2 patch operation") {
 		t.Error("formatEmailBody() should include diff count")
 	}
@@ -201,7 +201,7 @@ func TestEmailSender_BuildEmailMessage(t *testing.T) {
 	subject := "Test Subject"
 	body := "Test body content"
 
-	message := sender.buildEmailMessage(subject, body)
+	message := sender.buildEmailMessage(cfg.To, subject, body)
 	if message == "" {
 		t.Error("buildEmailMessage() should not return empty string")
 	}
@@ -0,0 +1,70 @@
+package alerting
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// ExecRules selects the EXEC events (exec into and attach to pods and nodes, recorded by
+// the audit processor) that are alerted. Patterns use path.Match syntax, e.g. "prod-*".
+type ExecRules struct {
+	Namespaces      []string `json:"namespaces,omitempty"`       // Namespace patterns; empty means all, including node execs
+	HumanOnly       bool     `json:"human_only,omitempty"`       // Skip service accounts and system: users
+	ExcludeUsers    []string `json:"exclude_users,omitempty"`    // Username patterns never alerted, e.g. debug tooling service accounts
+	InteractiveOnly bool     `json:"interactive_only,omitempty"` // Only alert on sessions with a TTY
+	MinRiskScore    int      `json:"min_risk_score,omitempty"`   // Only alert on commands with at least this risk score
+}
+
+// matches reports whether an EXEC event should be alerted.
+func (e *ExecRules) matches(event *model.ChangeEvent) bool {
+	if e == nil {
+		return false // EXEC events are only alerted if rules are configured
+	}
+	if len(e.Namespaces) > 0 && !matchesPattern(e.Namespaces, event.Namespace) {
+		return false
+	}
+	username := event.Actor.Username
+	if e.HumanOnly && (event.Actor.ServiceAccount != "" || strings.HasPrefix(username, "system:")) {
+		return false
+	}
+	if matchesPattern(e.ExcludeUsers, username) {
+		return false
+	}
+	if e.InteractiveOnly && (event.ExecMetadata == nil || !event.ExecMetadata.TTY) {
+		return false
+	}
+	if e.MinRiskScore > 0 && (event.ExecMetadata == nil || event.ExecMetadata.RiskScore < e.MinRiskScore) {
+		return false
+	}
+	return true
+}
+
+// execRisk summarizes the risk of an exec event's command, e.g. "high (80): pipe-to-shell",
+// or returns "" if it wasn't scored.
+func execRisk(event *model.ChangeEvent) string {
+	if event.ExecMetadata == nil || event.ExecMetadata.RiskScore == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d): %s", event.ExecMetadata.RiskSeverity, event.ExecMetadata.RiskScore, strings.Join(event.ExecMetadata.RiskReasons, ", "))
+}
+
+// execCommand returns the command of an exec event, or "" if it isn't known.
+func execCommand(event *model.ChangeEvent) string {
+	if event.ExecMetadata == nil {
+		return ""
+	}
+	return strings.Join(event.ExecMetadata.Command, " ")
+}
+
+// matchesPattern reports whether value matches any of the patterns.
+func matchesPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package alerting
+
+import (
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestRouter_ShouldAlert_Exec(t *testing.T) {
+	rules := &ExecRules{
+		Namespaces:   []string{"prod-*"},
+		HumanOnly:    true,
+		ExcludeUsers: []string{"oncall-bot@example.com"},
+	}
+	human := model.Actor{Username: "alice@example.com"}
+
+	tests := []struct {
+		name  string
+		rules *ExecRules
+		event *model.ChangeEvent
+		want  bool
+	}{
+		{
+			name:  "no exec rules",
+			rules: nil,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "prod-payments", Actor: human},
+			want:  false,
+		},
+		{
+			name:  "human exec into matching namespace",
+			rules: rules,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "prod-payments", Actor: human},
+			want:  true,
+		},
+		{
+			name:  "namespace not matched",
+			rules: rules,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "staging", Actor: human},
+			want:  false,
+		},
+		{
+			name:  "service account",
+			rules: rules,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "prod-payments", Actor: model.Actor{
+				Username:       "system:serviceaccount:debug:toolbox",
+				ServiceAccount: "system:serviceaccount:debug:toolbox",
+			}},
+			want: false,
+		},
+		{
+			name:  "system user",
+			rules: rules,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "prod-payments", Actor: model.Actor{Username: "system:admin"}},
+			want:  false,
+		},
+		{
+			name:  "excluded user",
+			rules: rules,
+			event: &model.ChangeEvent{Operation: "EXEC", Namespace: "prod-payments", Actor: model.Actor{Username: "oncall-bot@example.com"}},
+			want:  false,
+		},
+		{
+			name:  "excluded service account pattern",
+			rules: &ExecRules{ExcludeUsers: []string{"system:serviceaccount:debug:*"}},
+			event: &model.ChangeEvent{Operation: "EXEC", Actor: model.Actor{Username: "system:serviceaccount:debug:toolbox"}},
+			want:  false,
+		},
+		{
+			name:  "interactive only without TTY",
+			rules: &ExecRules{InteractiveOnly: true},
+			event: &model.ChangeEvent{Operation: "EXEC", Actor: human, ExecMetadata: &model.ExecMetadata{TTY: false}},
+			want:  false,
+		},
+		{
+			name:  "interactive only with TTY",
+			rules: &ExecRules{InteractiveOnly: true},
+			event: &model.ChangeEvent{Operation: "EXEC", Actor: human, ExecMetadata: &model.ExecMetadata{TTY: true, Subresource: "attach"}},
+			want:  true,
+		},
+		{
+			name:  "empty rules alert on all execs",
+			rules: &ExecRules{},
+			event: &model.ChangeEvent{Operation: "EXEC", Actor: model.Actor{Username: "system:node:worker-1"}},
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := &Router{operations: map[string]bool{}, exec: tt.rules}
+			if got := router.ShouldAlert(tt.event); got != tt.want {
+				t.Errorf("ShouldAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_ShouldAlert_ExecIgnoresOperations(t *testing.T) {
+	router := &Router{
+		operations: map[string]bool{"DELETE": true},
+		exec:       &ExecRules{},
+	}
+	if !router.ShouldAlert(&model.ChangeEvent{Operation: "EXEC"}) {
+		t.Error("EXEC events matching the exec rules should be alerted regardless of operations")
+	}
+	if router.ShouldAlert(&model.ChangeEvent{Operation: "CREATE"}) {
+		t.Error("CREATE events should still be filtered by operations")
+	}
+}
+
+func TestRouter_ShouldAlert_ExecMinRiskScore(t *testing.T) {
+	router := &Router{operations: map[string]bool{}, exec: &ExecRules{MinRiskScore: 70}}
+
+	risky := &model.ChangeEvent{Operation: "EXEC", ExecMetadata: &model.ExecMetadata{RiskScore: 80}}
+	if !router.ShouldAlert(risky) {
+		t.Error("exec with risk score 80 should be alerted")
+	}
+	benign := &model.ChangeEvent{Operation: "EXEC", ExecMetadata: &model.ExecMetadata{RiskScore: 20}}
+	if router.ShouldAlert(benign) {
+		t.Error("exec with risk score 20 should not be alerted")
+	}
+	if router.ShouldAlert(&model.ChangeEvent{Operation: "EXEC"}) {
+		t.Error("exec without metadata should not be alerted")
+	}
+}
@@ -0,0 +1,98 @@
+package alerting
+
+import (
+	"encoding/json"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Event is the change event passed to senders. It lets senders outside this module
+// implement Sender, whose methods are declared in terms of the internal model.
+type Event = model.ChangeEvent
+
+// Sender is the interface for alert senders.
+type Sender interface {
+	// Send sends an alert for a change event.
+	Send(event *model.ChangeEvent) error
+	// Name returns the name of the sender (e.g., "slack", "email").
+	Name() string
+}
+
+// Config represents alerting configuration.
+type Config struct {
+	// Enabled channels
+	Slack     *SlackConfig     `json:"slack,omitempty"`
+	Telegram  *TelegramConfig  `json:"telegram,omitempty"`
+	Email     *EmailConfig     `json:"email,omitempty"`
+	Webhook   *WebhookConfig   `json:"webhook,omitempty"`
+
+	// Senders added with Register, configured by their registered name
+	Custom map[string]json.RawMessage `json:"custom,omitempty"`
+
+	// Teams routes the Slack and email alerts of each team's namespaces to its own channel
+	// and recipients; other alerts go to the platform-wide ones above.
+	Teams TeamRoutes `json:"teams,omitempty"`
+
+	// Egress sets the proxy, allowed networks and pinned addresses of the Slack, Telegram
+	// and webhook senders. HTTPS_PROXY and NO_PROXY are honored without it.
+	Egress *EgressConfig `json:"egress,omitempty"`
+
+	// SecretRef names the Secret holding the secrets of this config, which are then
+	// RedactedValue here. The admin API keeps them there, out of the patterns ConfigMap.
+	SecretRef *SecretRef `json:"secret_ref,omitempty"`
+	
+	// Filter configuration
+	Operations []string `json:"operations,omitempty"` // Empty means all operations
+	Exec       *ExecRules `json:"exec,omitempty"`     // EXEC events are only alerted if set; Operations does not apply to them
+}
+
+// SlackConfig contains Slack alerting configuration.
+type SlackConfig struct {
+	WebhookURL string `json:"webhook_url"`
+	Channel    string `json:"channel,omitempty"` // Optional channel override
+	Username   string `json:"username,omitempty"` // Optional username override
+
+	// RouteToOwner sends alerts for events with a "slack_channel" enrichment, set by the
+	// ownership enricher, to that channel instead of Channel.
+	RouteToOwner bool `json:"route_to_owner,omitempty"`
+
+	// TimeSettings add the time of the change to alerts, in the channel's time zone and
+	// format; Slack otherwise only shows the attachment timestamp.
+	TimeSettings
+}
+
+// TelegramConfig contains Telegram alerting configuration.
+type TelegramConfig struct {
+	BotToken string   `json:"bot_token"`
+	ChatIDs  []string `json:"chat_ids"` // Multiple chat IDs supported
+
+	// TimeSettings show the time of the change in the chats' time zone and format.
+	TimeSettings
+}
+
+// EmailConfig contains email alerting configuration.
+type EmailConfig struct {
+	SMTPHost     string   `json:"smtp_host"`
+	SMTPPort     int      `json:"smtp_port"`
+	SMTPUsername string   `json:"smtp_username,omitempty"`
+	SMTPPassword string   `json:"smtp_password,omitempty"`
+	From         string   `json:"from"`
+	To           []string `json:"to"`
+	Subject      string   `json:"subject,omitempty"` // Optional subject template
+
+	// TimeSettings show the time of the change in the recipients' time zone and format.
+	TimeSettings
+}
+
+// WebhookConfig contains webhook alerting configuration.
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // Optional headers
+	Method  string            `json:"method,omitempty"`  // Default: POST
+	Format  string            `json:"format,omitempty"`  // "json" (default) or "cloudevents"
+	Source  string            `json:"source,omitempty"`  // CloudEvents source, default "kubechronicle"
+
+	// TokenExchange authenticates with access tokens exchanged for the pod's ServiceAccount
+	// token, e.g. when forwarding events to a central endpoint, instead of static headers.
+	TokenExchange *TokenExchangeConfig `json:"token_exchange,omitempty"`
+}
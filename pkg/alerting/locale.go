@@ -0,0 +1,34 @@
+package alerting
+
+import "github.com/kubechronicle/kubechronicle/internal/i18n"
+
+// localizedSender is implemented by the senders formatting alerts for people, so the router
+// can apply the message catalog to them.
+type localizedSender interface {
+	setMessageCatalog(catalog *i18n.Catalog)
+}
+
+// SetMessageCatalog formats the Slack, Telegram and email alerts of each namespace in its
+// locale of catalog, rather than in English. It must be called before WrapSenders.
+func (r *Router) SetMessageCatalog(catalog *i18n.Catalog) {
+	if r == nil {
+		return
+	}
+	for _, sender := range r.senders {
+		if s, ok := sender.(localizedSender); ok {
+			s.setMessageCatalog(catalog)
+		}
+	}
+}
+
+func (s *SlackSender) setMessageCatalog(catalog *i18n.Catalog) {
+	s.messages = catalog
+}
+
+func (s *TelegramSender) setMessageCatalog(catalog *i18n.Catalog) {
+	s.messages = catalog
+}
+
+func (s *EmailSender) setMessageCatalog(catalog *i18n.Catalog) {
+	s.messages = catalog
+}
@@ -0,0 +1,74 @@
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestRouter_SetMessageCatalog(t *testing.T) {
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	router, err := NewRouter(&Config{Slack: &SlackConfig{WebhookURL: server.URL}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.SetMessageCatalog(&i18n.Catalog{
+		NamespaceLocales: []i18n.NamespaceLocale{{Namespace: "team-berlin-*", Locale: "de"}},
+		Messages: map[string]map[string]string{"de": {
+			i18n.AlertSummary:  "{{kind}} {{namespace}}/{{name}}: {{operation}}",
+			i18n.AlertResource: "Ressource",
+		}},
+	})
+	for _, namespace := range []string{"team-berlin-api", "payments"} {
+		event := &model.ChangeEvent{Operation: "DELETE", ResourceKind: "Deployment", Namespace: namespace, Name: "api"}
+		if err := router.Deliver(event, nil); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+
+	if len(payloads) != 2 {
+		t.Fatalf("Got %d alerts, want 2", len(payloads))
+	}
+	wants := []struct{ text, resource string }{
+		{"Deployment team-berlin-api/api: DELETE", "Ressource"},
+		{"Kubernetes Resource DELETE: Deployment/payments/api", "Resource"},
+	}
+	for i, want := range wants {
+		if payloads[i]["text"] != want.text {
+			t.Errorf("Alert %d text = %q, want %q", i, payloads[i]["text"], want.text)
+		}
+		fields := payloads[i]["attachments"].([]interface{})[0].(map[string]interface{})["fields"].([]interface{})
+		if title := fields[0].(map[string]interface{})["title"]; title != want.resource {
+			t.Errorf("Alert %d first field = %q, want %q", i, title, want.resource)
+		}
+	}
+}
+
+func TestFormatTelegramMessage_Localized(t *testing.T) {
+	catalog := &i18n.Catalog{Messages: map[string]map[string]string{"es": {
+		i18n.AlertTitle:      "Recurso de Kubernetes {{operation}}",
+		i18n.AlertChanges:    "Cambios",
+		i18n.AlertPatchCount: "{{count}} operaciones",
+	}}}
+	event := teamEvent("")
+	event.Diff = []model.PatchOp{{Op: "replace", Path: "/spec/replicas"}}
+
+	message := formatTelegramMessage(catalog.Localizer("es"), nil, event)
+	for _, want := range []string{"Recurso de Kubernetes DELETE", "<b>Cambios:</b> 1 operaciones", "<b>User:</b>"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Message should contain %q:\n%s", want, message)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces secrets in a redacted config.
+const RedactedValue = "xxxxx"
+
+// Redacted returns a copy of the config with secrets (Slack webhook URL, Telegram bot
+// token, SMTP password, webhook header values, token exchange client secret and proxy URLs
+// with credentials) replaced by RedactedValue. The configuration of registered senders is opaque, so each is replaced
+// as a whole.
+func (c *Config) Redacted() *Config {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	if c.Slack != nil {
+		slack := *c.Slack
+		slack.WebhookURL = redact(slack.WebhookURL)
+		redacted.Slack = &slack
+	}
+	if c.Telegram != nil {
+		telegram := *c.Telegram
+		telegram.BotToken = redact(telegram.BotToken)
+		redacted.Telegram = &telegram
+	}
+	if c.Email != nil {
+		email := *c.Email
+		email.SMTPPassword = redact(email.SMTPPassword)
+		redacted.Email = &email
+	}
+	if c.Webhook != nil {
+		webhook := *c.Webhook
+		if c.Webhook.Headers != nil {
+			webhook.Headers = make(map[string]string, len(c.Webhook.Headers))
+			for name, value := range c.Webhook.Headers {
+				webhook.Headers[name] = redact(value)
+			}
+		}
+		if c.Webhook.TokenExchange != nil {
+			exchange := *c.Webhook.TokenExchange
+			exchange.ClientSecret = redact(exchange.ClientSecret)
+			webhook.TokenExchange = &exchange
+		}
+		redacted.Webhook = &webhook
+	}
+	if c.Egress != nil && proxyHasCredentials(c.Egress.Proxy) {
+		egress := *c.Egress
+		egress.Proxy = RedactedValue
+		redacted.Egress = &egress
+	}
+	if c.Custom != nil {
+		redacted.Custom = make(map[string]json.RawMessage, len(c.Custom))
+		for name := range c.Custom {
+			redacted.Custom[name] = redactedCustom
+		}
+	}
+	return &redacted
+}
+
+// redactedCustom replaces the configuration of a registered sender in a redacted config.
+var redactedCustom = json.RawMessage(`"` + RedactedValue + `"`)
+
+// RestoreRedacted replaces secrets equal to RedactedValue with the corresponding values
+// from current, so a config read in redacted form can be edited and written back.
+func (c *Config) RestoreRedacted(current *Config) {
+	if current == nil {
+		return
+	}
+	if c.Slack != nil && current.Slack != nil && c.Slack.WebhookURL == RedactedValue {
+		c.Slack.WebhookURL = current.Slack.WebhookURL
+	}
+	if c.Telegram != nil && current.Telegram != nil && c.Telegram.BotToken == RedactedValue {
+		c.Telegram.BotToken = current.Telegram.BotToken
+	}
+	if c.Email != nil && current.Email != nil && c.Email.SMTPPassword == RedactedValue {
+		c.Email.SMTPPassword = current.Email.SMTPPassword
+	}
+	if c.Webhook != nil && current.Webhook != nil {
+		for name, value := range c.Webhook.Headers {
+			if value == RedactedValue {
+				c.Webhook.Headers[name] = current.Webhook.Headers[name]
+			}
+		}
+		if exchange := c.Webhook.TokenExchange; exchange != nil && current.Webhook.TokenExchange != nil && exchange.ClientSecret == RedactedValue {
+			exchange.ClientSecret = current.Webhook.TokenExchange.ClientSecret
+		}
+	}
+	if c.Egress != nil && current.Egress != nil && c.Egress.Proxy == RedactedValue {
+		c.Egress.Proxy = current.Egress.Proxy
+	}
+	for name, value := range c.Custom {
+		if bytes.Equal(bytes.TrimSpace(value), redactedCustom) {
+			c.Custom[name] = current.Custom[name]
+		}
+	}
+}
+
+// RedactedSecrets returns the secrets still equal to RedactedValue, e.g. those RestoreRedacted
+// had no current value for, as dotted field names such as "slack.webhook_url".
+func (c *Config) RedactedSecrets() []string {
+	var secrets []string
+	if c.Slack != nil && c.Slack.WebhookURL == RedactedValue {
+		secrets = append(secrets, "slack.webhook_url")
+	}
+	if c.Telegram != nil && c.Telegram.BotToken == RedactedValue {
+		secrets = append(secrets, "telegram.bot_token")
+	}
+	if c.Email != nil && c.Email.SMTPPassword == RedactedValue {
+		secrets = append(secrets, "email.smtp_password")
+	}
+	if c.Webhook != nil {
+		for name, value := range c.Webhook.Headers {
+			if value == RedactedValue {
+				secrets = append(secrets, "webhook.headers."+name)
+			}
+		}
+		if c.Webhook.TokenExchange != nil && c.Webhook.TokenExchange.ClientSecret == RedactedValue {
+			secrets = append(secrets, "webhook.token_exchange.client_secret")
+		}
+	}
+	if c.Egress != nil && c.Egress.Proxy == RedactedValue {
+		secrets = append(secrets, "egress.proxy")
+	}
+	for name, value := range c.Custom {
+		if len(value) == 0 || bytes.Equal(bytes.TrimSpace(value), redactedCustom) {
+			secrets = append(secrets, name)
+		}
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// proxyHasCredentials reports whether a proxy URL includes a user name or password.
+func proxyHasCredentials(proxy string) bool {
+	u, err := url.Parse(proxy)
+	return err == nil && u.User != nil
+}
+
+// redactProxy returns a proxy URL with its credentials, if any, replaced by RedactedValue, so
+// that it can be logged.
+func redactProxy(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return RedactedValue
+	}
+	if u.User == nil {
+		return proxy
+	}
+	u.User = url.User(RedactedValue)
+	return u.String()
+}
+
+// redact replaces a non-empty secret with RedactedValue.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// SecretKey is the key, in the Secret named by Config.SecretRef, of the config with its secrets.
+const SecretKey = "ALERT_SECRETS"
+
+// SecretRef names the Secret holding the secrets of a config that carries them as RedactedValue.
+type SecretRef struct {
+	Name string `json:"name"`
+	// Version is the resourceVersion of the Secret when the config was written, so that
+	// changing only secrets still changes the config and reloads it.
+	Version string `json:"version,omitempty"`
+}
+
+// ResolveSecrets restores the secrets of a config that references a Secret from secretJSON,
+// the config stored under SecretKey in that Secret. It fails if secrets stay redacted, so a
+// config is never applied with RedactedValue in place of a secret. Configs without a
+// SecretRef are left unchanged.
+func (c *Config) ResolveSecrets(secretJSON string) error {
+	if c.SecretRef == nil {
+		return nil
+	}
+	if strings.TrimSpace(secretJSON) != "" {
+		var secrets Config
+		if err := json.Unmarshal([]byte(secretJSON), &secrets); err != nil {
+			return fmt.Errorf("failed to parse %s of Secret %s: %w", SecretKey, c.SecretRef.Name, err)
+		}
+		c.RestoreRedacted(&secrets)
+	}
+	if missing := c.RedactedSecrets(); len(missing) > 0 {
+		return fmt.Errorf("secrets %s are not set in %s of Secret %s", strings.Join(missing, ", "), SecretKey, c.SecretRef.Name)
+	}
+	return nil
+}
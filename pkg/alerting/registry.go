@@ -0,0 +1,83 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// Factory creates a sender from the JSON configuration under its name in the
+// "custom" section of the alert config.
+type Factory func(config json.RawMessage) (Sender, error)
+
+// builtinSenders are the names of the senders configured by the top-level config fields.
+var builtinSenders = map[string]bool{"slack": true, "telegram": true, "email": true, "webhook": true}
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{}
+)
+
+// Register makes a sender available under name, configured in the "custom" section of
+// the alert config:
+//
+//	{"custom": {"itsm": {"url": "https://itsm.example.com", "queue": "k8s"}}}
+//
+// It is meant to be called from the init function of the package implementing the
+// sender, which is then compiled in with a blank import. The sender's Name should
+// return name. Register panics if name is empty, a built-in sender, or already registered.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if name == "" || factory == nil {
+		panic("alerting: Register requires a name and a factory")
+	}
+	if builtinSenders[name] {
+		panic(fmt.Sprintf("alerting: %q is a built-in sender", name))
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("alerting: sender %q is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Registered returns the names of the registered senders, sorted.
+func Registered() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newCustomSenders creates the registered senders configured in custom, in name order.
+func newCustomSenders(custom map[string]json.RawMessage) ([]Sender, error) {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	senders := make([]Sender, 0, len(names))
+	for _, name := range names {
+		registryMutex.RLock()
+		factory, ok := registry[name]
+		registryMutex.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown alert sender %q (registered: %v)", name, Registered())
+		}
+		sender, err := factory(custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s sender: %w", name, err)
+		}
+		senders = append(senders, sender)
+		klog.Infof("%s alerting enabled", name)
+	}
+	return senders, nil
+}
@@ -0,0 +1,153 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// itsmSender is a custom sender registered by the tests.
+type itsmSender struct {
+	Queue string `json:"queue"`
+	sent  []string
+}
+
+func (s *itsmSender) Send(event *Event) error {
+	s.sent = append(s.sent, event.ID)
+	return nil
+}
+
+func (s *itsmSender) Name() string {
+	return "test-itsm"
+}
+
+func init() {
+	Register("test-itsm", func(config json.RawMessage) (Sender, error) {
+		sender := &itsmSender{}
+		if err := json.Unmarshal(config, sender); err != nil {
+			return nil, err
+		}
+		if sender.Queue == "" {
+			return nil, fmt.Errorf("queue is required")
+		}
+		return sender, nil
+	})
+}
+
+func TestNewRouter_CustomSender(t *testing.T) {
+	cfg := &Config{
+		Slack:  &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Custom: map[string]json.RawMessage{"test-itsm": json.RawMessage(`{"queue": "k8s"}`)},
+	}
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	if channels := router.Channels(); strings.Join(channels, ",") != "slack,test-itsm" {
+		t.Errorf("Channels() = %v, want [slack test-itsm]", channels)
+	}
+
+	event := &Event{ID: "event-1", Operation: "CREATE"}
+	if err := router.Deliver(event, []string{"test-itsm"}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	sender := router.senders[1].(*itsmSender)
+	if sender.Queue != "k8s" || len(sender.sent) != 1 || sender.sent[0] != "event-1" {
+		t.Errorf("Unexpected sender state: %+v", sender)
+	}
+}
+
+func TestNewRouter_CustomSenderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		custom  map[string]json.RawMessage
+		wantErr string
+	}{
+		{
+			name:    "unknown sender",
+			custom:  map[string]json.RawMessage{"pagerduty": json.RawMessage(`{}`)},
+			wantErr: `unknown alert sender "pagerduty"`,
+		},
+		{
+			name:    "invalid config",
+			custom:  map[string]json.RawMessage{"test-itsm": json.RawMessage(`{}`)},
+			wantErr: "failed to create test-itsm sender: queue is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRouter(&Config{Custom: tt.custom})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewRouter() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	factory := func(json.RawMessage) (Sender, error) { return &itsmSender{}, nil }
+	tests := []struct {
+		name    string
+		factory Factory
+	}{
+		{name: "", factory: factory},
+		{name: "slack", factory: factory},
+		{name: "test-itsm", factory: factory},
+		{name: "other", factory: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) should panic", tt.name)
+				}
+			}()
+			Register(tt.name, tt.factory)
+		})
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	names := Registered()
+	found := false
+	for _, name := range names {
+		found = found || name == "test-itsm"
+	}
+	if !found {
+		t.Errorf("Registered() = %v, want test-itsm included", names)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Errorf("Registered() = %v, want sorted", names)
+		}
+	}
+}
+
+func TestConfig_RedactedCustom(t *testing.T) {
+	cfg := &Config{Custom: map[string]json.RawMessage{"test-itsm": json.RawMessage(`{"queue": "k8s", "token": "secret"}`)}}
+
+	redacted := cfg.Redacted()
+	if got := string(redacted.Custom["test-itsm"]); got != `"`+RedactedValue+`"` {
+		t.Errorf("Redacted custom config = %s, want redacted", got)
+	}
+	if !strings.Contains(string(cfg.Custom["test-itsm"]), "secret") {
+		t.Error("Redacted should not modify the original config")
+	}
+
+	// A redacted config round-trips through JSON, as when edited via the admin API
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var updated Config
+	if err := json.Unmarshal(data, &updated); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	updated.RestoreRedacted(cfg)
+	if got := string(updated.Custom["test-itsm"]); got != string(cfg.Custom["test-itsm"]) {
+		t.Errorf("Restored custom config = %s, want %s", got, cfg.Custom["test-itsm"])
+	}
+}
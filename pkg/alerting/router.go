@@ -0,0 +1,274 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Router routes change events to configured alert senders.
+type Router struct {
+	senders     []Sender
+	operations  map[string]bool // Set of allowed operations (empty = all)
+	exec        *ExecRules      // Rules for EXEC events (nil = never alerted)
+	maxAttempts int             // Attempts per sender before an alert is given up
+	backoff     time.Duration   // Initial retry delay, doubled after each attempt
+	onFailure   FailureHandler  // Called for alerts given up; nil only logs them
+}
+
+// FailureHandler is called with an alert that could not be sent to channel after attempts tries.
+type FailureHandler func(event *model.ChangeEvent, channel string, err error, attempts int)
+
+// NewRouter creates a new alert router with the given configuration.
+func NewRouter(cfg *Config) (*Router, error) {
+	if cfg == nil {
+		return nil, nil // No alerting configured
+	}
+
+	r := &Router{
+		senders:     make([]Sender, 0),
+		operations:  make(map[string]bool),
+		maxAttempts: 3,
+		backoff:     time.Second,
+	}
+
+	// Build operation filter
+	if len(cfg.Operations) > 0 {
+		for _, op := range cfg.Operations {
+			r.operations[op] = true
+		}
+	}
+	// If empty, allow all operations (map stays empty)
+	r.exec = cfg.Exec
+
+	// Initialize Slack sender
+	if cfg.Slack != nil && cfg.Slack.WebhookURL != "" {
+		if err := cfg.Slack.TimeSettings.Validate(); err != nil {
+			return nil, fmt.Errorf("failed to create Slack sender: %w", err)
+		}
+		sender := NewSlackSender(cfg.Slack)
+		sender.SetTeamRoutes(cfg.Teams)
+		r.senders = append(r.senders, sender)
+		klog.Infof("Slack alerting enabled")
+	}
+
+	// Initialize Telegram sender
+	if cfg.Telegram != nil && cfg.Telegram.BotToken != "" && len(cfg.Telegram.ChatIDs) > 0 {
+		sender, err := NewTelegramSender(cfg.Telegram)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Telegram sender: %w", err)
+		}
+		r.senders = append(r.senders, sender)
+		klog.Infof("Telegram alerting enabled for %d chat(s)", len(cfg.Telegram.ChatIDs))
+	}
+
+	// Initialize Email sender
+	if cfg.Email != nil && cfg.Email.SMTPHost != "" && len(cfg.Email.To) > 0 {
+		sender, err := NewEmailSender(cfg.Email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Email sender: %w", err)
+		}
+		sender.SetTeamRoutes(cfg.Teams)
+		r.senders = append(r.senders, sender)
+		klog.Infof("Email alerting enabled to %d recipient(s)", len(cfg.Email.To))
+	}
+
+	// Initialize Webhook sender
+	if cfg.Webhook != nil && cfg.Webhook.URL != "" {
+		if !model.ValidFormat(cfg.Webhook.Format) {
+			return nil, fmt.Errorf("unknown webhook format %q, must be json or cloudevents", cfg.Webhook.Format)
+		}
+		sender := NewWebhookSender(cfg.Webhook)
+		if cfg.Webhook.TokenExchange != nil {
+			tokens, err := NewTokenExchanger(cfg.Webhook.TokenExchange)
+			if err != nil {
+				return nil, fmt.Errorf("invalid webhook token exchange: %w", err)
+			}
+			sender.SetTokenSource(tokens)
+		}
+		r.senders = append(r.senders, sender)
+		klog.Infof("Webhook alerting enabled: %s", cfg.Webhook.URL)
+	}
+
+	// Initialize registered senders, in name order
+	custom, err := newCustomSenders(cfg.Custom)
+	if err != nil {
+		return nil, err
+	}
+	r.senders = append(r.senders, custom...)
+
+	if len(r.senders) == 0 {
+		return nil, nil // No senders configured
+	}
+
+	// Apply the egress controls to the senders making HTTP requests
+	if cfg.Egress != nil {
+		transport, err := cfg.Egress.Transport()
+		if err != nil {
+			return nil, fmt.Errorf("invalid egress config: %w", err)
+		}
+		for _, sender := range r.senders {
+			if s, ok := sender.(httpSender); ok {
+				s.setTransport(transport)
+			}
+		}
+		klog.Infof("Alerting egress controls enabled: proxy=%q, %d allowed networks, %d pinned hosts", redactProxy(cfg.Egress.Proxy), len(cfg.Egress.AllowedNetworks), len(cfg.Egress.Resolve))
+	}
+
+	return r, nil
+}
+
+// Channels returns the names of the active alert senders.
+func (r *Router) Channels() []string {
+	if r == nil {
+		return nil
+	}
+	channels := make([]string, 0, len(r.senders))
+	for _, sender := range r.senders {
+		channels = append(channels, sender.Name())
+	}
+	return channels
+}
+
+// WrapSenders replaces each sender with wrap(sender), e.g. to inject faults for testing.
+func (r *Router) WrapSenders(wrap func(Sender) Sender) {
+	if r == nil {
+		return
+	}
+	for i, sender := range r.senders {
+		r.senders[i] = wrap(sender)
+	}
+}
+
+// SetFailureHandler sets the function called for alerts that failed on every attempt.
+func (r *Router) SetFailureHandler(onFailure FailureHandler) {
+	if r == nil {
+		return
+	}
+	r.onFailure = onFailure
+}
+
+// ShouldAlert checks if the event should trigger an alert based on operation filter,
+// or on the exec rules for EXEC events.
+func (r *Router) ShouldAlert(event *model.ChangeEvent) bool {
+	if r == nil {
+		return false
+	}
+
+	// Heartbeats verify the pipeline and are never alerted
+	if event.Operation == model.OperationHeartbeat {
+		return false
+	}
+
+	// EXEC events have their own rules
+	if event.Operation == "EXEC" {
+		return r.exec.matches(event)
+	}
+
+	// Deletions that may lose a volume's data are alerted whatever the operation filter
+	if event.VolumeDeletion != nil && event.VolumeDeletion.DataLossRisk && event.Allowed {
+		return true
+	}
+
+	// Unsigned images deployed to protected namespaces are alerted whatever the operation filter
+	if event.ImageProvenance != nil && event.ImageProvenance.Violation && event.Allowed {
+		return true
+	}
+
+	// Critical network exposure changes are alerted whatever the operation filter
+	if event.ExposureChange != nil && event.ExposureChange.Severity == "critical" && event.Allowed {
+		return true
+	}
+
+	// Workload updates weakening pod security are alerted whatever the operation filter
+	if event.PodSecurityRegression != nil && event.Allowed {
+		return true
+	}
+
+	// If no operations specified, alert on all
+	if len(r.operations) == 0 {
+		return true
+	}
+
+	// Delete collections group DELETEs, and are alerted in their place; namespace cascades
+	// summarize the DELETEs of a namespace's objects
+	if event.Operation == model.OperationDeleteCollection || event.Operation == model.OperationNamespaceCascade {
+		return r.operations["DELETE"] || r.operations[event.Operation]
+	}
+
+	// Check if operation is in allowed set
+	return r.operations[event.Operation]
+}
+
+// Send sends alerts for the given change event to all configured senders.
+func (r *Router) Send(event *model.ChangeEvent) {
+	if r == nil {
+		return
+	}
+
+	// Check if we should alert for this operation
+	if !r.ShouldAlert(event) {
+		return
+	}
+
+	// Send to all configured senders (async, non-blocking)
+	for _, sender := range r.senders {
+		go r.sendWithRetry(sender, event)
+	}
+}
+
+// sendWithRetry sends an alert, retrying with exponential backoff, and hands it to
+// the failure handler if every attempt failed.
+func (r *Router) sendWithRetry(sender Sender, event *model.ChangeEvent) {
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		err := sender.Send(event)
+		if err == nil {
+			return
+		}
+		if attempt >= r.maxAttempts {
+			klog.Errorf("Failed to send alert for event %s via %s after %d attempts: %v", event.ID, sender.Name(), attempt, err)
+			if r.onFailure != nil {
+				r.onFailure(event, sender.Name(), err, attempt)
+			}
+			return
+		}
+		klog.Warningf("Failed to send alert for event %s via %s (attempt %d/%d): %v", event.ID, sender.Name(), attempt, r.maxAttempts, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Deliver sends an alert for the event to the senders named in channels (all senders if empty)
+// and waits for them, unlike Send. It returns the joined errors of the senders that failed.
+// The operation filter is not applied; callers check ShouldAlert.
+func (r *Router) Deliver(event *model.ChangeEvent, channels []string) error {
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, sender := range r.senders {
+		if len(channels) > 0 && !containsChannel(channels, sender.Name()) {
+			continue
+		}
+		if err := sender.Send(event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sender.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// containsChannel reports whether name is one of channels.
+func containsChannel(channels []string, name string) bool {
+	for _, channel := range channels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
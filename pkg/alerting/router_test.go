@@ -0,0 +1,372 @@
+package alerting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestNewRouter_NilConfig(t *testing.T) {
+	router, err := NewRouter(nil)
+	if err != nil {
+		t.Fatalf("NewRouter(nil) error = %v, want nil", err)
+	}
+	if router != nil {
+		t.Error("NewRouter(nil) should return nil router")
+	}
+}
+
+func TestNewRouter_EmptyConfig(t *testing.T) {
+	cfg := &Config{}
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v, want nil", err)
+	}
+	if router != nil {
+		t.Error("NewRouter() with no senders should return nil")
+	}
+}
+
+func TestNewRouter_InvalidTelegramConfig(t *testing.T) {
+	cfg := &Config{
+		Telegram: &TelegramConfig{
+			BotToken: "", // Missing token
+			ChatIDs:  []string{"123"},
+		},
+	}
+	// Router will try to create sender but skip if invalid (no error, just no sender)
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() should not return error (invalid configs are skipped): %v", err)
+	}
+	// Router should be nil because no valid senders were created
+	if router != nil {
+		t.Error("NewRouter() with invalid Telegram config should return nil (no valid senders)")
+	}
+}
+
+func TestNewRouter_InvalidEmailConfig(t *testing.T) {
+	cfg := &Config{
+		Email: &EmailConfig{
+			SMTPHost: "", // Missing host
+			SMTPPort: 587,
+			From:     "from@example.com",
+			To:       []string{"to@example.com"},
+		},
+	}
+	// Router will try to create sender but skip if invalid (no error, just no sender)
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() should not return error (invalid configs are skipped): %v", err)
+	}
+	// Router should be nil because no valid senders were created
+	if router != nil {
+		t.Error("NewRouter() with invalid Email config should return nil (no valid senders)")
+	}
+}
+
+func TestNewRouter_InvalidWebhookFormat(t *testing.T) {
+	cfg := &Config{
+		Webhook: &WebhookConfig{URL: "https://example.com/webhook", Format: "xml"},
+	}
+	if _, err := NewRouter(cfg); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("NewRouter() error = %v, want unknown format error", err)
+	}
+}
+
+func TestRouter_ShouldAlert_AllOperations(t *testing.T) {
+	cfg := &Config{
+		Slack: &SlackConfig{
+			WebhookURL: "https://hooks.slack.com/services/test",
+		},
+		// No operations filter = all operations
+	}
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		want      bool
+	}{
+		{"CREATE operation", "CREATE", true},
+		{"UPDATE operation", "UPDATE", true},
+		{"DELETE operation", "DELETE", true},
+		{"HEARTBEAT operation", "HEARTBEAT", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &model.ChangeEvent{Operation: tt.operation}
+			got := router.ShouldAlert(event)
+			if got != tt.want {
+				t.Errorf("Router.ShouldAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_ShouldAlert_FilteredOperations(t *testing.T) {
+	cfg := &Config{
+		Slack: &SlackConfig{
+			WebhookURL: "https://hooks.slack.com/services/test",
+		},
+		Operations: []string{"CREATE", "DELETE"},
+	}
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		want      bool
+	}{
+		{"CREATE operation (allowed)", "CREATE", true},
+		{"UPDATE operation (filtered)", "UPDATE", false},
+		{"DELETE operation (allowed)", "DELETE", true},
+		{"DELETE_COLLECTION operation (allowed with DELETE)", "DELETE_COLLECTION", true},
+		{"NAMESPACE_CASCADE operation (allowed with DELETE)", "NAMESPACE_CASCADE", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &model.ChangeEvent{Operation: tt.operation}
+			got := router.ShouldAlert(event)
+			if got != tt.want {
+				t.Errorf("Router.ShouldAlert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_ShouldAlert_DataLossRisk(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Slack:      &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Operations: []string{"CREATE"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	event := &model.ChangeEvent{Operation: "DELETE", ResourceKind: "PersistentVolumeClaim", Allowed: true,
+		VolumeDeletion: &model.VolumeDeletion{Volume: "pvc-1", ReclaimPolicy: "Delete", DataLossRisk: true}}
+	if !router.ShouldAlert(event) {
+		t.Error("Expected a deletion that may lose data to be alerted whatever the operation filter")
+	}
+	event.VolumeDeletion = &model.VolumeDeletion{Volume: "pvc-1", ReclaimPolicy: "Retain"}
+	if router.ShouldAlert(event) {
+		t.Error("Expected a deletion of a retained volume to follow the operation filter")
+	}
+}
+
+func TestRouter_ShouldAlert_ImageProvenanceViolation(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Slack:      &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Operations: []string{"DELETE"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment", Namespace: "payments", Allowed: true,
+		ImageProvenance: &model.ImageProvenance{Images: []model.ImageCheck{{Container: "app", Image: "app:v2", Status: model.ImageUnsigned}}, Violation: true}}
+	if !router.ShouldAlert(event) {
+		t.Error("Expected an unsigned image in a protected namespace to be alerted whatever the operation filter")
+	}
+	event.ImageProvenance.Violation = false
+	if router.ShouldAlert(event) {
+		t.Error("Expected an image change without violation to follow the operation filter")
+	}
+}
+
+func TestRouter_ShouldAlert_ExposureChange(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Slack:      &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Operations: []string{"DELETE"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Service", Allowed: true,
+		ExposureChange: &model.ExposureChange{Severity: "critical", Findings: []model.ExposureFinding{{Check: "service_type", Severity: "critical"}}}}
+	if !router.ShouldAlert(event) {
+		t.Error("Expected a critical exposure change to be alerted whatever the operation filter")
+	}
+	event.ExposureChange.Severity = "warning"
+	if router.ShouldAlert(event) {
+		t.Error("Expected a warning exposure change to follow the operation filter")
+	}
+}
+
+func TestRouter_ShouldAlert_PodSecurityRegression(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Slack:      &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Operations: []string{"DELETE"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	event := &model.ChangeEvent{Operation: "UPDATE", ResourceKind: "Deployment", Allowed: true,
+		PodSecurityRegression: &model.PodSecurityRegression{Findings: []model.PodSecurityFinding{{Check: "privileged", Container: "app"}}}}
+	if !router.ShouldAlert(event) {
+		t.Error("Expected a pod security regression to be alerted whatever the operation filter")
+	}
+	event.Allowed = false
+	if router.ShouldAlert(event) {
+		t.Error("Expected a blocked pod security regression to follow the operation filter")
+	}
+}
+
+func TestRouter_ShouldAlert_NilRouter(t *testing.T) {
+	var router *Router
+	event := &model.ChangeEvent{Operation: "CREATE"}
+	got := router.ShouldAlert(event)
+	if got {
+		t.Error("Router.ShouldAlert() on nil router should return false")
+	}
+}
+
+func TestRouter_Send_NilRouter(t *testing.T) {
+	var router *Router
+	event := &model.ChangeEvent{Operation: "CREATE"}
+	// Should not panic
+	router.Send(event)
+}
+
+func TestRouter_Send_FilteredOut(t *testing.T) {
+	cfg := &Config{
+		Slack: &SlackConfig{
+			WebhookURL: "https://hooks.slack.com/services/test",
+		},
+		Operations: []string{"CREATE"}, // Only CREATE
+	}
+	router, err := NewRouter(cfg)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	// UPDATE event should be filtered out
+	event := &model.ChangeEvent{Operation: "UPDATE"}
+	// Should not panic (senders won't be called)
+	router.Send(event)
+}
+
+func TestRouter_Channels(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Slack:   &SlackConfig{WebhookURL: "https://hooks.slack.com/services/test"},
+		Webhook: &WebhookConfig{URL: "https://example.com/hook"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	channels := router.Channels()
+	if len(channels) != 2 || channels[0] != "slack" || channels[1] != "webhook" {
+		t.Errorf("Channels() = %v, want [slack webhook]", channels)
+	}
+
+	var nilRouter *Router
+	if channels := nilRouter.Channels(); channels != nil {
+		t.Errorf("nil router Channels() = %v, want nil", channels)
+	}
+}
+
+func TestRouter_WrapSenders(t *testing.T) {
+	router, err := NewRouter(&Config{
+		Webhook: &WebhookConfig{URL: "https://example.com/hook"},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	wrapped := 0
+	router.WrapSenders(func(s Sender) Sender {
+		wrapped++
+		return s
+	})
+	if wrapped != 1 {
+		t.Errorf("wrap called %d times, want 1", wrapped)
+	}
+
+	var nilRouter *Router
+	nilRouter.WrapSenders(func(s Sender) Sender { return s }) // Should not panic
+}
+
+func TestRouter_Deliver(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.URL.Path)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	router, err := NewRouter(&Config{Webhook: &WebhookConfig{URL: server.URL + "/ok"}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	event := &model.ChangeEvent{ID: "e1", Operation: "UPDATE"}
+
+	if err := router.Deliver(event, nil); err != nil {
+		t.Errorf("Deliver() error = %v", err)
+	}
+	if err := router.Deliver(event, []string{"slack"}); err != nil {
+		t.Errorf("Deliver() to another channel error = %v", err)
+	}
+	if len(received) != 1 {
+		t.Errorf("webhook received %d alerts, want 1 (only the unfiltered delivery)", len(received))
+	}
+
+	failing, _ := NewRouter(&Config{Webhook: &WebhookConfig{URL: server.URL + "/fail"}})
+	if err := failing.Deliver(event, nil); err == nil || !strings.Contains(err.Error(), "webhook") {
+		t.Errorf("Deliver() error = %v, want an error naming the webhook channel", err)
+	}
+}
+
+func TestRouter_Send_RetriesThenFails(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	router, err := NewRouter(&Config{Webhook: &WebhookConfig{URL: server.URL}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.backoff = time.Millisecond
+
+	failed := make(chan string, 1)
+	router.SetFailureHandler(func(event *model.ChangeEvent, channel string, err error, n int) {
+		if n != router.maxAttempts {
+			t.Errorf("failure handler attempts = %d, want %d", n, router.maxAttempts)
+		}
+		failed <- channel
+	})
+	router.Send(&model.ChangeEvent{ID: "e1", Operation: "UPDATE"})
+
+	select {
+	case channel := <-failed:
+		if channel != "webhook" {
+			t.Errorf("failed channel = %q, want webhook", channel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failure handler was not called")
+	}
+	if got := attempts.Load(); got != int32(router.maxAttempts) {
+		t.Errorf("webhook received %d attempts, want %d", got, router.maxAttempts)
+	}
+}
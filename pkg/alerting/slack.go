@@ -0,0 +1,212 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// SlackSender sends alerts to Slack via webhook.
+type SlackSender struct {
+	webhookURL string
+	channel    string
+	username   string
+	toOwner    bool
+	teams      TeamRoutes
+	messages   *i18n.Catalog
+	times      *timeFormatter
+	client     *http.Client
+}
+
+// NewSlackSender creates a new Slack alert sender.
+func NewSlackSender(cfg *SlackConfig) *SlackSender {
+	return &SlackSender{
+		webhookURL: cfg.WebhookURL,
+		channel:    cfg.Channel,
+		username:   cfg.Username,
+		toOwner:    cfg.RouteToOwner,
+		times:      cfg.TimeSettings.formatter(),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Name returns the sender name.
+func (s *SlackSender) Name() string {
+	return "slack"
+}
+
+// Send sends an alert to Slack.
+func (s *SlackSender) Send(event *model.ChangeEvent) error {
+	// Format message
+	l := s.messages.ForNamespace(event.Namespace)
+	message := formatSlackMessage(l, event)
+
+	// Build payload
+	payload := map[string]interface{}{
+		"text": message,
+	}
+
+	if channel := s.channelFor(event); channel != "" {
+		payload["channel"] = channel
+	}
+	if s.username != "" {
+		payload["username"] = s.username
+	}
+
+	// Add color based on operation
+	color := "#36a64f" // Green for CREATE
+	if event.Operation == "UPDATE" {
+		color = "#ffaa00" // Orange for UPDATE
+	} else if event.Operation == "DELETE" || event.Operation == model.OperationDeleteCollection ||
+		event.Operation == model.OperationNamespaceCascade {
+		color = "#ff0000" // Red for DELETE
+	}
+
+	// Use attachment for better formatting
+	attachment := map[string]interface{}{
+		"color":     color,
+		"title":     fmt.Sprintf("%s: %s/%s", event.Operation, event.ResourceKind, event.Name),
+		"fields":    buildSlackFields(l, s.times, event),
+		"timestamp": event.Timestamp.Unix(),
+	}
+
+	payload["attachments"] = []map[string]interface{}{attachment}
+
+	// Marshal to JSON
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack payload: %w", err)
+	}
+
+	// Send HTTP request
+	req, err := http.NewRequest("POST", s.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Slack API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SetTeamRoutes sends the alerts of teams with a Slack channel to that channel.
+func (s *SlackSender) SetTeamRoutes(teams TeamRoutes) {
+	s.teams = teams
+}
+
+// channelFor returns the channel of the alert for an event: the channel routed to its team,
+// else the owner's channel if alerts are routed to owners and the event has one, else the
+// configured channel.
+func (s *SlackSender) channelFor(event *model.ChangeEvent) string {
+	if route := s.teams.route(event); route != nil && route.SlackChannel != "" {
+		return route.SlackChannel
+	}
+	if s.toOwner && event.Enrichment["slack_channel"] != "" {
+		return event.Enrichment["slack_channel"]
+	}
+	return s.channel
+}
+
+func formatSlackMessage(l *i18n.Localizer, event *model.ChangeEvent) string {
+	return l.Message(i18n.AlertSummary,
+		"operation", event.Operation,
+		"kind", event.ResourceKind,
+		"namespace", event.Namespace,
+		"name", event.Name,
+	)
+}
+
+// buildSlackFields returns the fields of the alert attachment. The time of the change is
+// only a field with time settings, as Slack shows the attachment timestamp otherwise.
+func buildSlackFields(l *i18n.Localizer, times *timeFormatter, event *model.ChangeEvent) []map[string]interface{} {
+	fields := []map[string]interface{}{
+		{"title": l.Message(i18n.AlertResource), "value": fmt.Sprintf("%s/%s", event.ResourceKind, event.Name), "short": true},
+		{"title": l.Message(i18n.AlertNamespace), "value": event.Namespace, "short": true},
+		{"title": l.Message(i18n.AlertUser), "value": event.Actor.Username, "short": true},
+		{"title": l.Message(i18n.AlertTool), "value": event.Source.Tool, "short": true},
+	}
+
+	if times != nil {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertTime),
+			"value": times.format(event.Timestamp),
+			"short": true,
+		})
+	}
+
+	if event.Actor.ServiceAccount != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertServiceAccount),
+			"value": event.Actor.ServiceAccount,
+			"short": true,
+		})
+	}
+
+	if event.Actor.SourceIP != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertSourceIP),
+			"value": event.Actor.SourceIP,
+			"short": true,
+		})
+	}
+
+	if team := event.Enrichment["team"]; team != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertTeam),
+			"value": team,
+			"short": true,
+		})
+	}
+
+	if oncall := event.Enrichment["oncall"]; oncall != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertOnCall),
+			"value": oncall,
+			"short": true,
+		})
+	}
+
+	if command := execCommand(event); command != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertCommand),
+			"value": command,
+			"short": false,
+		})
+	}
+
+	if risk := execRisk(event); risk != "" {
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertRisk),
+			"value": risk,
+			"short": false,
+		})
+	}
+
+	if len(event.Diff) > 0 {
+		diffSummary := l.Message(i18n.AlertChangeCount, "count", strconv.Itoa(len(event.Diff)))
+		fields = append(fields, map[string]interface{}{
+			"title": l.Message(i18n.AlertChanges),
+			"value": diffSummary,
+			"short": false,
+		})
+	}
+
+	return fields
+}
@@ -191,7 +191,7 @@ func TestFormatSlackMessage(t *testing.T) {
 		Name:         "test-app",
 	}
 
-	message := formatSlackMessage(event)
+	message := formatSlackMessage(nil, event)
 	if message == "" {
 		t.Error("formatSlackMessage() should not return empty string")
 	}
@@ -220,7 +220,7 @@ func TestBuildSlackFields(t *testing.T) {
 		},
 	}
 
-	fields := buildSlackFields(event)
+	fields := buildSlackFields(nil, nil, event)
 	if len(fields) == 0 {
 		t.Error("buildSlackFields() should return fields")
 	}
@@ -237,3 +237,35 @@ func TestBuildSlackFields(t *testing.T) {
 		t.Error("buildSlackFields() should include Resource field")
 	}
 }
+
+func TestSlackSender_RouteToOwner(t *testing.T) {
+	var channels []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		channels = append(channels, payload["channel"])
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	owned := &model.ChangeEvent{Operation: "DELETE", Namespace: "payments", Enrichment: map[string]string{"slack_channel": "#payments-alerts"}}
+	unowned := &model.ChangeEvent{Operation: "DELETE", Namespace: "default"}
+
+	sender := NewSlackSender(&SlackConfig{WebhookURL: server.URL, Channel: "#alerts", RouteToOwner: true})
+	for _, event := range []*model.ChangeEvent{owned, unowned} {
+		if err := sender.Send(event); err != nil {
+			t.Fatalf("SlackSender.Send() error = %v", err)
+		}
+	}
+	sender = NewSlackSender(&SlackConfig{WebhookURL: server.URL, Channel: "#alerts"})
+	if err := sender.Send(owned); err != nil {
+		t.Fatalf("SlackSender.Send() error = %v", err)
+	}
+
+	want := []interface{}{"#payments-alerts", "#alerts", "#alerts"}
+	for i := range want {
+		if i >= len(channels) || channels[i] != want[i] {
+			t.Fatalf("Alerts were sent to %v, want %v", channels, want)
+		}
+	}
+}
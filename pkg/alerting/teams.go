@@ -0,0 +1,23 @@
+package alerting
+
+import "github.com/kubechronicle/kubechronicle/internal/model"
+
+// TeamRoute is where the alerts for a team's namespaces are sent instead of the
+// platform-wide Slack channel and email recipients. Empty fields fall back to those.
+type TeamRoute struct {
+	SlackChannel string   `json:"slack_channel,omitempty"` // Channel name or ID
+	EmailTo      []string `json:"email_to,omitempty"`
+}
+
+// TeamRoutes maps teams to their routes. The team of an event is its "team" enrichment,
+// set by the team or ownership enrichers.
+type TeamRoutes map[string]*TeamRoute
+
+// route returns the route of the team owning the event, or nil if it has none.
+func (t TeamRoutes) route(event *model.ChangeEvent) *TeamRoute {
+	team := event.Enrichment["team"]
+	if team == "" {
+		return nil
+	}
+	return t[team]
+}
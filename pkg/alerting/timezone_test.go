@@ -0,0 +1,91 @@
+package alerting
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+func TestTimeSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings TimeSettings
+		wantErr  bool
+	}{
+		{name: "empty", settings: TimeSettings{}},
+		{name: "timezone and named format", settings: TimeSettings{Timezone: "Europe/Berlin", TimeFormat: "rfc1123"}},
+		{name: "go layout", settings: TimeSettings{TimeFormat: "02.01.2006 15:04"}},
+		{name: "unknown timezone", settings: TimeSettings{Timezone: "Mars/Olympus"}, wantErr: true},
+		{name: "not a layout", settings: TimeSettings{TimeFormat: "local time"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeFormatter_Format(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+
+	var unset *timeFormatter
+	if got := unset.format(timestamp); got != "2024-03-01T14:30:00Z" {
+		t.Errorf("format() without settings = %q, want RFC 3339", got)
+	}
+	if f := (&TimeSettings{}).formatter(); f != nil {
+		t.Errorf("formatter() of empty settings = %v, want nil", f)
+	}
+
+	f := (&TimeSettings{Timezone: "Europe/Berlin", TimeFormat: "datetime"}).formatter()
+	if got := f.format(timestamp); got != "2024-03-01 15:30:00 CET" {
+		t.Errorf("format() = %q, want 2024-03-01 15:30:00 CET", got)
+	}
+	f = (&TimeSettings{Timezone: "America/New_York"}).formatter()
+	if got := f.format(timestamp); got != "2024-03-01T09:30:00-05:00" {
+		t.Errorf("format() = %q, want 2024-03-01T09:30:00-05:00", got)
+	}
+}
+
+func TestSenders_TimeSettings(t *testing.T) {
+	event := &model.ChangeEvent{
+		Operation:    "UPDATE",
+		ResourceKind: "Deployment",
+		Namespace:    "default",
+		Name:         "api",
+		Timestamp:    time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC),
+	}
+	times := (&TimeSettings{Timezone: "Asia/Tokyo", TimeFormat: "datetime"}).formatter()
+
+	if message := formatTelegramMessage(nil, times, event); !strings.Contains(message, "2024-07-01 17:00:00 JST") {
+		t.Errorf("formatTelegramMessage() = %q, want the time in Tokyo", message)
+	}
+	if body := formatEmailBody(nil, times, event); !strings.Contains(body, "Timestamp: 2024-07-01 17:00:00 JST") {
+		t.Errorf("formatEmailBody() = %q, want the time in Tokyo", body)
+	}
+
+	hasTime := func(fields []map[string]interface{}) bool {
+		for _, field := range fields {
+			if field["title"] == "Time" && field["value"] == "2024-07-01 17:00:00 JST" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasTime(buildSlackFields(nil, times, event)) {
+		t.Error("buildSlackFields() should include the time with time settings")
+	}
+	if hasTime(buildSlackFields(nil, nil, event)) {
+		t.Error("buildSlackFields() should not include the time without time settings")
+	}
+
+	if _, err := NewTelegramSender(&TelegramConfig{BotToken: "token", ChatIDs: []string{"1"}, TimeSettings: TimeSettings{Timezone: "Nowhere"}}); err == nil {
+		t.Error("NewTelegramSender() should reject an unknown timezone")
+	}
+	if _, err := NewRouter(&Config{Slack: &SlackConfig{WebhookURL: "https://hooks.slack.com/x", TimeSettings: TimeSettings{TimeFormat: "none"}}}); err == nil {
+		t.Error("NewRouter() should reject an invalid Slack time format")
+	}
+}
@@ -0,0 +1,194 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token exchange (RFC 8693) defaults.
+const (
+	DefaultSubjectTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	DefaultSubjectTokenType = "urn:ietf:params:oauth:token-type:jwt"
+
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// tokenExpiryMargin is how long before its expiry a token is exchanged again, so requests
+// don't carry a token expiring in flight.
+const tokenExpiryMargin = time.Minute
+
+// defaultTokenLifetime is how long a token is used when the exchange doesn't say.
+const defaultTokenLifetime = 5 * time.Minute
+
+// TokenSource provides the bearer tokens sent with webhook requests, so a webhook can
+// authenticate to the endpoint events are forwarded to without a static credential.
+type TokenSource interface {
+	// Token returns a valid token, fetching a new one when needed.
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenExchangeConfig exchanges a local token, by default the pod's ServiceAccount token,
+// for an access token of the endpoint with OAuth 2.0 token exchange (RFC 8693), as offered
+// by OIDC providers and cloud security token services.
+type TokenExchangeConfig struct {
+	// TokenURL is the token endpoint of the OIDC provider.
+	TokenURL string `json:"token_url"`
+
+	// SubjectTokenFile is read for the token exchanged on every exchange, as projected
+	// tokens rotate (default: the pod's ServiceAccount token).
+	SubjectTokenFile string `json:"subject_token_file,omitempty"`
+
+	// SubjectTokenType is the type of the exchanged token (default: JWT).
+	SubjectTokenType string `json:"subject_token_type,omitempty"`
+
+	// Audience, Resource and Scope describe the token requested, e.g. the central API.
+	Audience string `json:"audience,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
+	// ClientID and ClientSecret authenticate the webhook to the provider, if it requires it.
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// TokenExchanger is a TokenSource exchanging a local token for access tokens, caching
+// each until shortly before it expires.
+type TokenExchanger struct {
+	config TokenExchangeConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewTokenExchanger creates a token source exchanging tokens as configured by cfg.
+func NewTokenExchanger(cfg *TokenExchangeConfig) (*TokenExchanger, error) {
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("token_url is required")
+	}
+	if _, err := url.ParseRequestURI(cfg.TokenURL); err != nil {
+		return nil, fmt.Errorf("invalid token_url: %w", err)
+	}
+	config := *cfg
+	if config.SubjectTokenFile == "" {
+		config.SubjectTokenFile = DefaultSubjectTokenFile
+	}
+	if config.SubjectTokenType == "" {
+		config.SubjectTokenType = DefaultSubjectTokenType
+	}
+	return &TokenExchanger{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Token returns the cached access token, or exchanges the local token for a new one.
+func (e *TokenExchanger) Token(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token != "" && time.Now().Before(e.expires) {
+		return e.token, nil
+	}
+
+	token, lifetime, err := e.exchange(ctx)
+	if err != nil {
+		return "", fmt.Errorf("token exchange failed: %w", err)
+	}
+	e.token = token
+	e.expires = time.Now().Add(lifetime - tokenExpiryMargin)
+	return token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the endpoint rejected it, so the next
+// request exchanges a new one.
+func (e *TokenExchanger) Invalidate() {
+	e.mu.Lock()
+	e.token = ""
+	e.mu.Unlock()
+}
+
+// tokenResponse is the response of a token exchange.
+type tokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+	Error           string `json:"error"`
+	ErrorDesc       string `json:"error_description"`
+}
+
+// exchange exchanges the local token for an access token, returning it and its lifetime.
+func (e *TokenExchanger) exchange(ctx context.Context) (string, time.Duration, error) {
+	subjectToken, err := os.ReadFile(e.config.SubjectTokenFile)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read subject token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {strings.TrimSpace(string(subjectToken))},
+		"subject_token_type":   {e.config.SubjectTokenType},
+		"requested_token_type": {accessTokenType},
+	}
+	for name, value := range map[string]string{"audience": e.config.Audience, "resource": e.config.Resource, "scope": e.config.Scope} {
+		if value != "" {
+			form.Set(name, value)
+		}
+	}
+	if e.config.ClientID != "" && e.config.ClientSecret == "" {
+		form.Set("client_id", e.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if e.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(e.config.ClientID), url.QueryEscape(e.config.ClientSecret))
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil && resp.StatusCode == http.StatusOK {
+		return "", 0, fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if token.Error != "" {
+			return "", 0, fmt.Errorf("provider returned status %d: %s %s", resp.StatusCode, token.Error, token.ErrorDesc)
+		}
+		return "", 0, fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+
+	lifetime := defaultTokenLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	if lifetime <= tokenExpiryMargin {
+		lifetime = tokenExpiryMargin + time.Second
+	}
+	return token.AccessToken, lifetime, nil
+}
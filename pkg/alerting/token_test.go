@@ -0,0 +1,102 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenExchanger(t *testing.T) {
+	subjectFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(subjectFile, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	exchanges := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		user, password, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != tokenExchangeGrantType || r.Form.Get("subject_token") != "sa-token" ||
+			r.Form.Get("subject_token_type") != DefaultSubjectTokenType || r.Form.Get("audience") != "central" ||
+			user != "cluster-a" || password != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_request", "error_description": "unexpected exchange"}`)
+			return
+		}
+		exchanges++
+		fmt.Fprintf(w, `{"access_token": "central-%d", "issued_token_type": "%s", "token_type": "Bearer", "expires_in": 3600}`, exchanges, accessTokenType)
+	}))
+	defer provider.Close()
+
+	var authorizations []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if len(authorizations) == 2 {
+			w.WriteHeader(http.StatusUnauthorized) // Revoked
+		}
+	}))
+	defer endpoint.Close()
+
+	router, err := NewRouter(&Config{Webhook: &WebhookConfig{
+		URL: endpoint.URL,
+		TokenExchange: &TokenExchangeConfig{
+			TokenURL:         provider.URL,
+			SubjectTokenFile: subjectFile,
+			Audience:         "central",
+			ClientID:         "cluster-a",
+			ClientSecret:     "secret",
+		},
+	}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	event := teamEvent("")
+	if err := router.Deliver(event, nil); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if err := router.Deliver(event, nil); err == nil {
+		t.Fatal("Deliver() should fail when the endpoint rejects the token")
+	}
+	if err := router.Deliver(event, nil); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// The token is cached until the endpoint rejects it
+	want := []string{"Bearer central-1", "Bearer central-1", "Bearer central-2"}
+	if fmt.Sprint(authorizations) != fmt.Sprint(want) {
+		t.Errorf("Authorization headers = %v, want %v", authorizations, want)
+	}
+}
+
+func TestTokenExchanger_Errors(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": "invalid_grant"}`)
+	}))
+	defer provider.Close()
+
+	subjectFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(subjectFile, []byte("sa-token"), 0600)
+	for name, cfg := range map[string]*TokenExchangeConfig{
+		"rejected":        {TokenURL: provider.URL, SubjectTokenFile: subjectFile},
+		"missing subject": {TokenURL: provider.URL, SubjectTokenFile: filepath.Join(t.TempDir(), "missing")},
+	} {
+		exchanger, err := NewTokenExchanger(cfg)
+		if err != nil {
+			t.Fatalf("%s: NewTokenExchanger() error = %v", name, err)
+		}
+		if _, err := exchanger.Token(context.Background()); err == nil {
+			t.Errorf("%s: Token() should fail", name)
+		}
+	}
+
+	for _, cfg := range []*TokenExchangeConfig{{}, {TokenURL: "not a url"}} {
+		if _, err := NewTokenExchanger(cfg); err == nil {
+			t.Errorf("NewTokenExchanger(%+v) should fail", cfg)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package alerting
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,6 +16,9 @@ type WebhookSender struct {
 	url     string
 	method  string
 	headers map[string]string
+	format  string
+	source  string
+	tokens  TokenSource // Bearer tokens sent with requests; nil sends none
 	client  *http.Client
 }
 
@@ -29,12 +33,20 @@ func NewWebhookSender(cfg *WebhookConfig) *WebhookSender {
 		url:     cfg.URL,
 		method:  method,
 		headers: cfg.Headers,
+		format:  cfg.Format,
+		source:  cfg.Source,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
 }
 
+// SetTokenSource makes the sender authenticate with bearer tokens from tokens, e.g. a
+// TokenExchanger, instead of a static Authorization header.
+func (s *WebhookSender) SetTokenSource(tokens TokenSource) {
+	s.tokens = tokens
+}
+
 // Name returns the sender name.
 func (s *WebhookSender) Name() string {
 	return "webhook"
@@ -42,8 +54,14 @@ func (s *WebhookSender) Name() string {
 
 // Send sends an alert to the webhook endpoint.
 func (s *WebhookSender) Send(event *model.ChangeEvent) error {
-	// Marshal event to JSON
-	jsonData, err := json.Marshal(event)
+	// Marshal event to JSON, wrapped in a CloudEvent if configured
+	contentType := "application/json"
+	var payload interface{} = event
+	if s.format == model.FormatCloudEvents {
+		contentType = model.CloudEventsContentType
+		payload = model.NewCloudEvent(event, s.source)
+	}
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
@@ -55,10 +73,17 @@ func (s *WebhookSender) Send(event *model.ChangeEvent) error {
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	for key, value := range s.headers {
 		req.Header.Set(key, value)
 	}
+	if s.tokens != nil {
+		token, err := s.tokens.Token(context.Background())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	// Send request
 	resp, err := s.client.Do(req)
@@ -67,6 +92,12 @@ func (s *WebhookSender) Send(event *model.ChangeEvent) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token may have been revoked before its expiry; the retry gets a new one
+		if invalidator, ok := s.tokens.(interface{ Invalidate() }); ok {
+			invalidator.Invalidate()
+		}
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
 	}
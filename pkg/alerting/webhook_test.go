@@ -207,3 +207,40 @@ func TestWebhookSender_Send_NoHeaders(t *testing.T) {
 		t.Error("Content-Type header should be set even without custom headers")
 	}
 }
+
+func TestWebhookSender_SendCloudEvents(t *testing.T) {
+	var received model.CloudEvent
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSender(&WebhookConfig{URL: server.URL, Format: model.FormatCloudEvents, Source: "/clusters/prod-eu"})
+	timestamp := time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC)
+	event := &model.ChangeEvent{
+		ID:           "DELETE-Deployment-test-app-1",
+		Timestamp:    timestamp,
+		Operation:    "DELETE",
+		ResourceKind: "Deployment",
+		Namespace:    "default",
+		Name:         "test-app",
+	}
+	if err := sender.Send(event); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if contentType != model.CloudEventsContentType {
+		t.Errorf("Content-Type = %q, want %q", contentType, model.CloudEventsContentType)
+	}
+	if received.SpecVersion != "1.0" || received.ID != event.ID || received.Source != "/clusters/prod-eu" {
+		t.Errorf("Unexpected CloudEvent attributes: %+v", received)
+	}
+	if received.Type != "io.kubechronicle.change.delete" || received.Subject != "Deployment/default/test-app" || received.Time != "2024-01-14T12:00:00Z" {
+		t.Errorf("Unexpected CloudEvent type, subject or time: %+v", received)
+	}
+	if received.Data == nil || received.Data.Name != "test-app" {
+		t.Errorf("CloudEvent data = %+v, want the change event", received.Data)
+	}
+}
@@ -0,0 +1,110 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// CostCenterRule assigns the namespaces matching a pattern to a cost center.
+type CostCenterRule struct {
+	// Namespace is a path.Match pattern, e.g. "payments-*".
+	Namespace string `json:"namespace"`
+
+	CostCenter string `json:"cost_center"`
+}
+
+// CostCenterConfig is the configuration of the cost-center enricher.
+type CostCenterConfig struct {
+	// Rules assign namespaces to cost centers; the first matching rule applies.
+	Rules []CostCenterRule `json:"rules,omitempty"`
+
+	// Annotation is a namespace annotation naming the cost center, which takes precedence
+	// over the rules, e.g. "example.com/cost-center". Namespaces are only read from the
+	// Kubernetes API when it is set.
+	Annotation string `json:"annotation,omitempty"`
+
+	// CacheTTL is how long namespaces are cached, as a Go duration (default: 5m).
+	CacheTTL string `json:"cache_ttl,omitempty"`
+}
+
+// CostCenter sets the "cost_center" of events to the cost center of their namespace.
+type CostCenter struct {
+	rules      []CostCenterRule
+	annotation string
+	namespaces *namespaceCache // nil unless the annotation is read
+}
+
+func init() {
+	plugin.Register("cost-center", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg CostCenterConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		var client kubernetes.Interface
+		if cfg.Annotation != "" {
+			var err error
+			if client, err = inClusterClient(); err != nil {
+				return nil, err
+			}
+		}
+		return NewCostCenter(client, cfg)
+	})
+}
+
+// NewCostCenter creates a cost-center enricher. client gets namespaces when cfg has an
+// annotation, and may be nil otherwise.
+func NewCostCenter(client kubernetes.Interface, cfg CostCenterConfig) (*CostCenter, error) {
+	if len(cfg.Rules) == 0 && cfg.Annotation == "" {
+		return nil, fmt.Errorf("rules or annotation is required")
+	}
+	for i, rule := range cfg.Rules {
+		if _, err := path.Match(rule.Namespace, ""); err != nil || rule.Namespace == "" || rule.CostCenter == "" {
+			return nil, fmt.Errorf("rule %d: needs a valid namespace pattern and a cost_center", i)
+		}
+	}
+	c := &CostCenter{rules: cfg.Rules, annotation: cfg.Annotation}
+	if cfg.Annotation != "" {
+		ttl, err := parseCacheTTL(cfg.CacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		c.namespaces = newNamespaceCache(client, ttl)
+	}
+	return c, nil
+}
+
+// Name returns "cost-center".
+func (c *CostCenter) Name() string {
+	return "cost-center"
+}
+
+// Enrich sets the "cost_center" of namespaced events whose namespace has one.
+func (c *CostCenter) Enrich(ctx context.Context, event *plugin.Event) error {
+	namespace := eventNamespace(event)
+	if namespace == "" {
+		return nil
+	}
+	if c.namespaces != nil {
+		_, annotations, err := c.namespaces.get(ctx, namespace)
+		if err != nil {
+			return err
+		}
+		if costCenter := annotations[c.annotation]; costCenter != "" {
+			plugin.SetEnrichment(event, "cost_center", costCenter)
+			return nil
+		}
+	}
+	for _, rule := range c.rules {
+		if matched, _ := path.Match(rule.Namespace, namespace); matched {
+			plugin.SetEnrichment(event, "cost_center", rule.CostCenter)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package enrich provides the built-in enrichers, which add organizational context to events
+// before they are saved:
+//
+//   - geoip, the country and city of the actor's source IP, from a table of networks
+//   - team, the team owning the event's namespace, from a namespace annotation
+//   - cost-center, the cost center of the event's namespace, from namespace patterns or a
+//     namespace annotation
+//   - ownership, the team, Slack channel and on-call of the namespace's owner, from a
+//     directory in a ConfigMap or served over HTTP
+//
+// They are enabled with PLUGIN_CONFIG:
+//
+//	[{"name": "team", "config": {"annotation": "example.com/team"}}]
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// defaultCacheTTL is how long namespace metadata is cached, so enrichers don't get the
+// namespace of every event from the API server.
+const defaultCacheTTL = 5 * time.Minute
+
+// parseCacheTTL parses the cache_ttl of an enricher's configuration.
+func parseCacheTTL(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid cache_ttl %q", value)
+	}
+	return ttl, nil
+}
+
+// inClusterClient returns a client of the cluster the webhook runs in.
+func inClusterClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("namespace metadata needs the in-cluster Kubernetes API: %w", err)
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// eventNamespace returns the namespace an event belongs to: its namespace, or the
+// namespace itself for changes to namespaces. It is empty for other cluster-scoped objects.
+func eventNamespace(event *plugin.Event) string {
+	if event.Namespace == "" && event.ResourceKind == "Namespace" {
+		return event.Name
+	}
+	return event.Namespace
+}
+
+// namespaceCache gets the labels and annotations of namespaces, caching them for ttl.
+type namespaceCache struct {
+	client kubernetes.Interface
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]namespaceEntry
+}
+
+type namespaceEntry struct {
+	labels      map[string]string
+	annotations map[string]string
+	expires     time.Time
+}
+
+func newNamespaceCache(client kubernetes.Interface, ttl time.Duration) *namespaceCache {
+	return &namespaceCache{client: client, ttl: ttl, entries: map[string]namespaceEntry{}}
+}
+
+// get returns the labels and annotations of a namespace. Namespaces that don't exist (any
+// more) have none.
+func (c *namespaceCache) get(ctx context.Context, name string) (labels, annotations map[string]string, err error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.labels, entry.annotations, nil
+	}
+
+	namespace, err := c.client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		entry = namespaceEntry{}
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	default:
+		entry = namespaceEntry{labels: namespace.Labels, annotations: namespace.Annotations}
+	}
+	entry.expires = time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	c.entries[name] = entry
+	c.mu.Unlock()
+	return entry.labels, entry.annotations, nil
+}
@@ -0,0 +1,231 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+func namespace(name string, labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+}
+
+func TestTeam(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		namespace("payments", nil, map[string]string{DefaultTeamAnnotation: "payments-team"}),
+		namespace("search", map[string]string{"team": "search-team"}, nil),
+	)
+	team, err := NewTeam(client, TeamConfig{Label: "team"})
+	if err != nil {
+		t.Fatalf("NewTeam() error = %v", err)
+	}
+
+	tests := []struct {
+		event *plugin.Event
+		want  string
+	}{
+		{&plugin.Event{Namespace: "payments", ResourceKind: "Deployment"}, "payments-team"},
+		{&plugin.Event{Namespace: "search", ResourceKind: "Deployment"}, "search-team"},
+		{&plugin.Event{Name: "payments", ResourceKind: "Namespace"}, "payments-team"},
+		{&plugin.Event{Namespace: "deleted", ResourceKind: "Deployment"}, ""},
+		{&plugin.Event{Name: "node-1", ResourceKind: "Node"}, ""},
+	}
+	for _, tt := range tests {
+		if err := team.Enrich(context.Background(), tt.event); err != nil {
+			t.Fatalf("Enrich() error = %v", err)
+		}
+		if got := tt.event.Enrichment["team"]; got != tt.want {
+			t.Errorf("Team of %s %s/%s = %q, want %q", tt.event.ResourceKind, tt.event.Namespace, tt.event.Name, got, tt.want)
+		}
+	}
+
+	// Namespaces are cached
+	gets := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "get" {
+			gets++
+		}
+	}
+	if gets != 3 {
+		t.Errorf("Expected 3 namespace gets, got %d", gets)
+	}
+}
+
+func TestCostCenter(t *testing.T) {
+	client := fake.NewSimpleClientset(namespace("payments-eu", nil, map[string]string{"example.com/cost-center": "CC-9"}))
+	costCenter, err := NewCostCenter(client, CostCenterConfig{
+		Rules: []CostCenterRule{
+			{Namespace: "payments-*", CostCenter: "CC-1001"},
+			{Namespace: "*", CostCenter: "CC-0000"},
+		},
+		Annotation: "example.com/cost-center",
+	})
+	if err != nil {
+		t.Fatalf("NewCostCenter() error = %v", err)
+	}
+	for namespace, want := range map[string]string{"payments-eu": "CC-9", "payments-us": "CC-1001", "search": "CC-0000"} {
+		event := &plugin.Event{Namespace: namespace}
+		if err := costCenter.Enrich(context.Background(), event); err != nil {
+			t.Fatalf("Enrich() error = %v", err)
+		}
+		if got := event.Enrichment["cost_center"]; got != want {
+			t.Errorf("Cost center of %s = %q, want %q", namespace, got, want)
+		}
+	}
+
+	// Without an annotation, no Kubernetes client is needed
+	if _, err := NewCostCenter(nil, CostCenterConfig{Rules: []CostCenterRule{{Namespace: "*", CostCenter: "CC-0000"}}}); err != nil {
+		t.Errorf("NewCostCenter() without annotation error = %v", err)
+	}
+	for _, cfg := range []CostCenterConfig{{}, {Rules: []CostCenterRule{{Namespace: "[", CostCenter: "CC-1"}}}, {Rules: []CostCenterRule{{Namespace: "*"}}}} {
+		if _, err := NewCostCenter(nil, cfg); err == nil {
+			t.Errorf("NewCostCenter(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestGeoIP(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "geoip.csv")
+	table := "network,country,city\n# Public ranges\n203.0.113.0/24,NL,Amsterdam\n2001:db8::/32,DE\n"
+	if err := os.WriteFile(file, []byte(table), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	geoIP, err := plugin.New("geoip", json.RawMessage(`{"file": "`+file+`", "networks": [{"network": "203.0.113.128/25", "country": "NL", "city": "Office"}, {"network": "10.0.0.0/8", "country": "internal"}]}`))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	enricher := geoIP.(plugin.Enricher)
+
+	tests := map[string]map[string]string{
+		"203.0.113.7":     {"geo_country": "NL", "geo_city": "Amsterdam"},
+		"203.0.113.200":   {"geo_country": "NL", "geo_city": "Office"}, // Most specific network
+		"10.1.2.3:51234":  {"geo_country": "internal"},
+		"::ffff:10.1.2.3": {"geo_country": "internal"},
+		"2001:db8::1":     {"geo_country": "DE"},
+		"198.51.100.1":    nil,
+		"not-an-address":  nil,
+	}
+	for sourceIP, want := range tests {
+		event := &plugin.Event{}
+		event.Actor.SourceIP = sourceIP
+		if err := enricher.Enrich(context.Background(), event); err != nil {
+			t.Fatalf("Enrich() error = %v", err)
+		}
+		if len(event.Enrichment) != len(want) {
+			t.Errorf("Enrichment of %s = %v, want %v", sourceIP, event.Enrichment, want)
+			continue
+		}
+		for key, value := range want {
+			if event.Enrichment[key] != value {
+				t.Errorf("Enrichment of %s = %v, want %v", sourceIP, event.Enrichment, want)
+			}
+		}
+	}
+
+	if _, ok := geoIP.(*GeoIP).Lookup(netip.MustParseAddr("192.0.2.1")); ok {
+		t.Error("Lookup() of an unknown address should fail")
+	}
+	for _, config := range []string{`{}`, `{"networks": [{"network": "10.0.0.0/33", "country": "x"}]}`, `{"file": "/missing.csv"}`} {
+		if _, err := plugin.New("geoip", json.RawMessage(config)); err == nil {
+			t.Errorf("New(geoip, %s) should fail", config)
+		}
+	}
+}
+
+func TestOwnership_ConfigMap(t *testing.T) {
+	directory := `[{"namespace": "payments-*", "team": "payments", "slack_channel": "#payments-alerts", "oncall": "payments-primary"}]`
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kubechronicle", Name: "owners"},
+		Data:       map[string]string{DefaultOwnershipKey: directory},
+	})
+	ownership, err := NewOwnership(client, OwnershipConfig{
+		ConfigMap: "kubechronicle/owners",
+		Owners:    []Owner{{Namespace: "*", Team: "platform"}},
+	})
+	if err != nil {
+		t.Fatalf("NewOwnership() error = %v", err)
+	}
+
+	tests := map[string]map[string]string{
+		"payments-eu": {"team": "payments", "slack_channel": "#payments-alerts", "oncall": "payments-primary"},
+		"search":      {"team": "platform"},
+	}
+	for namespace, want := range tests {
+		event := &plugin.Event{Namespace: namespace}
+		if err := ownership.Enrich(context.Background(), event); err != nil {
+			t.Fatalf("Enrich() error = %v", err)
+		}
+		if len(event.Enrichment) != len(want) {
+			t.Errorf("Enrichment of %s = %v, want %v", namespace, event.Enrichment, want)
+			continue
+		}
+		for key, value := range want {
+			if event.Enrichment[key] != value {
+				t.Errorf("Enrichment of %s = %v, want %v", namespace, event.Enrichment, want)
+			}
+		}
+	}
+	if len(client.Actions()) != 1 {
+		t.Errorf("Expected the ConfigMap to be read once, got %d actions", len(client.Actions()))
+	}
+}
+
+func TestOwnership_URL(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"namespace": "payments", "team": "payments", "slack_channel": "#payments"}]`))
+	}))
+	defer server.Close()
+
+	ownership, err := NewOwnership(nil, OwnershipConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}, RefreshInterval: "1ms"})
+	if err != nil {
+		t.Fatalf("NewOwnership() error = %v", err)
+	}
+	event := &plugin.Event{Namespace: "payments"}
+	if err := ownership.Enrich(context.Background(), event); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if event.Enrichment["slack_channel"] != "#payments" {
+		t.Errorf("Enrichment = %v, want the payments channel", event.Enrichment)
+	}
+
+	// The last directory read is used while the service fails
+	fail = true
+	time.Sleep(2 * time.Millisecond)
+	event = &plugin.Event{Namespace: "payments"}
+	if err := ownership.Enrich(context.Background(), event); err != nil || event.Enrichment["team"] != "payments" {
+		t.Errorf("Enrich() = %v, %v, want the last directory read", event.Enrichment, err)
+	}
+
+	unread, err := NewOwnership(nil, OwnershipConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewOwnership() error = %v", err)
+	}
+	if err := unread.Enrich(context.Background(), &plugin.Event{Namespace: "payments"}); err == nil {
+		t.Error("Enrich() should fail before the directory could be read")
+	}
+
+	for _, config := range []string{`{}`, `{"url": "http://x", "configmap": "a/b"}`, `{"configmap": "owners"}`, `{"owners": [{"namespace": "*"}]}`, `{"owners": [{"namespace": "[", "team": "x"}]}`, `{"url": "http://x", "refresh_interval": "soon"}`} {
+		var cfg OwnershipConfig
+		json.Unmarshal([]byte(config), &cfg)
+		if _, err := NewOwnership(nil, cfg); err == nil {
+			t.Errorf("NewOwnership(%s) should fail", config)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package enrich
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// Location is where the addresses of a network are.
+type Location struct {
+	Country string `json:"country"`
+	City    string `json:"city,omitempty"`
+}
+
+// GeoIPNetwork is a network of a GeoIP table, in CIDR notation, and its location.
+type GeoIPNetwork struct {
+	Network string `json:"network"`
+	Location
+}
+
+// GeoIPConfig is the configuration of the geoip enricher. Networks may overlap: the most
+// specific one applies.
+type GeoIPConfig struct {
+	// File is a CSV file of networks, one "network,country[,city]" per line, e.g. exported
+	// from a GeoIP database. Lines starting with # and a "network" header are skipped.
+	File string `json:"file,omitempty"`
+
+	// Networks are networks in addition to those of File, e.g. to name internal ranges.
+	Networks []GeoIPNetwork `json:"networks,omitempty"`
+}
+
+// GeoIP sets the "geo_country" and "geo_city" of events to the location of the actor's
+// source IP.
+type GeoIP struct {
+	// prefixes maps the networks by prefix length; lengths lists those lengths, longest first
+	prefixes map[int]map[netip.Prefix]Location
+	lengths  []int
+}
+
+func init() {
+	plugin.Register("geoip", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg GeoIPConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		return NewGeoIP(cfg)
+	})
+}
+
+// NewGeoIP creates a geoip enricher, loading the networks of cfg.
+func NewGeoIP(cfg GeoIPConfig) (*GeoIP, error) {
+	g := &GeoIP{prefixes: map[int]map[netip.Prefix]Location{}}
+	if cfg.File != "" {
+		file, err := os.Open(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		if err := g.load(file); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.File, err)
+		}
+	}
+	for _, network := range cfg.Networks {
+		if err := g.add(network.Network, network.Location); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.lengths) == 0 {
+		return nil, fmt.Errorf("file or networks is required")
+	}
+	return g, nil
+}
+
+// load adds the networks of a CSV table.
+func (g *GeoIP) load(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(record[0], "network") {
+			continue // Header
+		}
+		if len(record) < 2 {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: expected network,country[,city]", line)
+		}
+		location := Location{Country: record[1]}
+		if len(record) > 2 {
+			location.City = record[2]
+		}
+		if err := g.add(record[0], location); err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// add adds a network in CIDR notation.
+func (g *GeoIP) add(network string, location Location) error {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(network))
+	if err != nil {
+		return fmt.Errorf("invalid network %q: %w", network, err)
+	}
+	prefix = prefix.Masked()
+	if prefix.Addr().Is4() {
+		// Lengths of IPv4 and IPv6 prefixes are kept apart
+		prefix = netip.PrefixFrom(netip.AddrFrom16(prefix.Addr().As16()), prefix.Bits()+96)
+	}
+	bits := prefix.Bits()
+	if g.prefixes[bits] == nil {
+		g.prefixes[bits] = map[netip.Prefix]Location{}
+		g.lengths = append(g.lengths, bits)
+		sort.Sort(sort.Reverse(sort.IntSlice(g.lengths)))
+	}
+	g.prefixes[bits][prefix] = location
+	return nil
+}
+
+// Lookup returns the location of the most specific network containing an address.
+func (g *GeoIP) Lookup(addr netip.Addr) (Location, bool) {
+	addr = netip.AddrFrom16(addr.As16())
+	for _, bits := range g.lengths {
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			continue
+		}
+		if location, ok := g.prefixes[bits][prefix]; ok {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
+
+// Name returns "geoip".
+func (g *GeoIP) Name() string {
+	return "geoip"
+}
+
+// Enrich sets the location of events whose actor's source IP is in a known network.
+func (g *GeoIP) Enrich(ctx context.Context, event *plugin.Event) error {
+	addr, ok := parseSourceIP(event.Actor.SourceIP)
+	if !ok {
+		return nil
+	}
+	location, ok := g.Lookup(addr)
+	if !ok {
+		return nil
+	}
+	plugin.SetEnrichment(event, "geo_country", location.Country)
+	plugin.SetEnrichment(event, "geo_city", location.City)
+	return nil
+}
+
+// parseSourceIP parses a source IP, which the API server may report with a port.
+func parseSourceIP(value string) (netip.Addr, bool) {
+	if value == "" {
+		return netip.Addr{}, false
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	return netip.Addr{}, false
+}
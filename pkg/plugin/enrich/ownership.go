@@ -0,0 +1,248 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// DefaultOwnershipKey is the ConfigMap key holding the ownership directory.
+const DefaultOwnershipKey = "owners.json"
+
+// maxDirectorySize is the largest ownership directory read from a URL.
+const maxDirectorySize = 4 << 20
+
+// Owner is an entry of the ownership directory: who owns the namespaces matching a
+// pattern and how to reach them.
+type Owner struct {
+	// Namespace is a path.Match pattern, e.g. "payments-*".
+	Namespace string `json:"namespace"`
+
+	Team         string `json:"team,omitempty"`
+	SlackChannel string `json:"slack_channel,omitempty"`
+	OnCall       string `json:"oncall,omitempty"` // E.g. a PagerDuty schedule or an address
+}
+
+// OwnershipConfig is the configuration of the ownership enricher. The directory is a JSON
+// array of Owners, read from a ConfigMap or a URL, followed by Owners; the first entry
+// matching a namespace applies.
+type OwnershipConfig struct {
+	// ConfigMap is the "namespace/name" of a ConfigMap holding the directory.
+	ConfigMap string `json:"configmap,omitempty"`
+
+	// Key is the key of the ConfigMap holding the directory (default: owners.json).
+	Key string `json:"key,omitempty"`
+
+	// URL is an HTTP directory service returning the directory.
+	URL string `json:"url,omitempty"`
+
+	// Headers are sent with requests to URL, e.g. an Authorization header.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Owners are entries matched after those of the ConfigMap or URL, e.g. a default owner
+	// for "*".
+	Owners []Owner `json:"owners,omitempty"`
+
+	// RefreshInterval is how often the directory is read again, as a Go duration
+	// (default: 5m).
+	RefreshInterval string `json:"refresh_interval,omitempty"`
+}
+
+// Ownership sets the "team", "slack_channel" and "oncall" of events to those of the owner
+// of their namespace in the ownership directory.
+type Ownership struct {
+	client    kubernetes.Interface
+	configMap [2]string // Namespace and name; empty unless the directory is in a ConfigMap
+	key       string
+	url       string
+	headers   map[string]string
+	static    []Owner
+	refresh   time.Duration
+	http      *http.Client
+
+	mu      sync.Mutex
+	owners  []Owner // Of the last directory read, followed by static
+	expires time.Time
+	loaded  bool
+}
+
+func init() {
+	plugin.Register("ownership", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg OwnershipConfig
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		var client kubernetes.Interface
+		if cfg.ConfigMap != "" {
+			var err error
+			if client, err = inClusterClient(); err != nil {
+				return nil, err
+			}
+		}
+		return NewOwnership(client, cfg)
+	})
+}
+
+// NewOwnership creates an ownership enricher. client gets the ConfigMap of cfg, and may be
+// nil if it has none.
+func NewOwnership(client kubernetes.Interface, cfg OwnershipConfig) (*Ownership, error) {
+	if cfg.ConfigMap == "" && cfg.URL == "" && len(cfg.Owners) == 0 {
+		return nil, fmt.Errorf("configmap, url or owners is required")
+	}
+	if cfg.ConfigMap != "" && cfg.URL != "" {
+		return nil, fmt.Errorf("configmap and url are mutually exclusive")
+	}
+	if err := validateOwners(cfg.Owners); err != nil {
+		return nil, err
+	}
+	refresh, err := time.ParseDuration(cfg.RefreshInterval)
+	switch {
+	case cfg.RefreshInterval == "":
+		refresh = defaultCacheTTL
+	case err != nil || refresh <= 0:
+		return nil, fmt.Errorf("invalid refresh_interval %q", cfg.RefreshInterval)
+	}
+
+	o := &Ownership{
+		client:  client,
+		key:     cfg.Key,
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		static:  cfg.Owners,
+		refresh: refresh,
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+	if o.key == "" {
+		o.key = DefaultOwnershipKey
+	}
+	if cfg.ConfigMap != "" {
+		namespace, name, ok := strings.Cut(cfg.ConfigMap, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid configmap %q, must be namespace/name", cfg.ConfigMap)
+		}
+		o.configMap = [2]string{namespace, name}
+	}
+	return o, nil
+}
+
+// validateOwners checks that every entry has a valid namespace pattern and an owner.
+func validateOwners(owners []Owner) error {
+	for i, owner := range owners {
+		if _, err := path.Match(owner.Namespace, ""); err != nil || owner.Namespace == "" {
+			return fmt.Errorf("owner %d: invalid namespace pattern %q", i, owner.Namespace)
+		}
+		if owner.Team == "" && owner.SlackChannel == "" && owner.OnCall == "" {
+			return fmt.Errorf("owner %d: needs a team, slack_channel or oncall", i)
+		}
+	}
+	return nil
+}
+
+// Name returns "ownership".
+func (o *Ownership) Name() string {
+	return "ownership"
+}
+
+// Enrich sets the owner of namespaced events whose namespace is in the directory.
+func (o *Ownership) Enrich(ctx context.Context, event *plugin.Event) error {
+	namespace := eventNamespace(event)
+	if namespace == "" {
+		return nil
+	}
+	owners, err := o.directory(ctx)
+	if err != nil {
+		return err
+	}
+	for _, owner := range owners {
+		if matched, _ := path.Match(owner.Namespace, namespace); matched {
+			plugin.SetEnrichment(event, "team", owner.Team)
+			plugin.SetEnrichment(event, "slack_channel", owner.SlackChannel)
+			plugin.SetEnrichment(event, "oncall", owner.OnCall)
+			return nil
+		}
+	}
+	return nil
+}
+
+// directory returns the entries of the directory, reading it again every refresh interval.
+// When it can't be read, the last directory read is used until the next refresh.
+func (o *Ownership) directory(ctx context.Context) ([]Owner, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.loaded && time.Now().Before(o.expires) {
+		return o.owners, nil
+	}
+	o.expires = time.Now().Add(o.refresh)
+
+	owners, err := o.read(ctx)
+	if err == nil {
+		err = validateOwners(owners)
+	}
+	if err != nil {
+		if !o.loaded {
+			return nil, fmt.Errorf("failed to read ownership directory: %w", err)
+		}
+		klog.Warningf("Failed to read ownership directory, using the last one read: %v", err)
+		return o.owners, nil
+	}
+	o.owners = append(owners, o.static...)
+	o.loaded = true
+	return o.owners, nil
+}
+
+// read reads the directory from the ConfigMap or the URL, if any.
+func (o *Ownership) read(ctx context.Context) ([]Owner, error) {
+	var data []byte
+	switch {
+	case o.configMap[1] != "":
+		configMap, err := o.client.CoreV1().ConfigMaps(o.configMap[0]).Get(ctx, o.configMap[1], metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s/%s: %w", o.configMap[0], o.configMap[1], err)
+		}
+		value, ok := configMap.Data[o.key]
+		if !ok {
+			return nil, fmt.Errorf("ConfigMap %s/%s has no key %s", o.configMap[0], o.configMap[1], o.key)
+		}
+		data = []byte(value)
+	case o.url != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		for name, value := range o.headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := o.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s returned status %d", o.url, resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxDirectorySize)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	var owners []Owner
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return nil, fmt.Errorf("invalid directory: %w", err)
+	}
+	return owners, nil
+}
@@ -0,0 +1,87 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// DefaultTeamAnnotation is the namespace annotation naming the owning team.
+const DefaultTeamAnnotation = "kubechronicle.io/team"
+
+// TeamConfig is the configuration of the team enricher.
+type TeamConfig struct {
+	// Annotation is the namespace annotation naming the owning team (default:
+	// kubechronicle.io/team).
+	Annotation string `json:"annotation,omitempty"`
+
+	// Label is a namespace label used when the annotation isn't set, e.g. "team".
+	Label string `json:"label,omitempty"`
+
+	// CacheTTL is how long namespaces are cached, as a Go duration (default: 5m).
+	CacheTTL string `json:"cache_ttl,omitempty"`
+}
+
+// Team sets the "team" of events to the team owning their namespace.
+type Team struct {
+	annotation string
+	label      string
+	namespaces *namespaceCache
+}
+
+func init() {
+	plugin.Register("team", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg TeamConfig
+		if len(config) > 0 {
+			if err := json.Unmarshal(config, &cfg); err != nil {
+				return nil, fmt.Errorf("invalid config: %w", err)
+			}
+		}
+		client, err := inClusterClient()
+		if err != nil {
+			return nil, err
+		}
+		return NewTeam(client, cfg)
+	})
+}
+
+// NewTeam creates a team enricher getting namespaces with client. The webhook needs
+// permission to get namespaces.
+func NewTeam(client kubernetes.Interface, cfg TeamConfig) (*Team, error) {
+	ttl, err := parseCacheTTL(cfg.CacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	annotation := cfg.Annotation
+	if annotation == "" {
+		annotation = DefaultTeamAnnotation
+	}
+	return &Team{annotation: annotation, label: cfg.Label, namespaces: newNamespaceCache(client, ttl)}, nil
+}
+
+// Name returns "team".
+func (t *Team) Name() string {
+	return "team"
+}
+
+// Enrich sets the "team" of namespaced events whose namespace names one.
+func (t *Team) Enrich(ctx context.Context, event *plugin.Event) error {
+	namespace := eventNamespace(event)
+	if namespace == "" {
+		return nil
+	}
+	labels, annotations, err := t.namespaces.get(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	team := annotations[t.annotation]
+	if team == "" && t.label != "" {
+		team = labels[t.label]
+	}
+	plugin.SetEnrichment(event, "team", team)
+	return nil
+}
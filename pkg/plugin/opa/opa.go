@@ -0,0 +1,181 @@
+// Package opa is an admission plugin deciding whether requests are blocked with Rego
+// policies evaluated by an OPA server, typically a sidecar of the webhook.
+//
+// It is enabled with PLUGIN_CONFIG:
+//
+//	[{"name": "opa", "config": {"url": "http://localhost:8181/v1/data/kubechronicle/deny"}}]
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// defaultTimeout bounds OPA queries, which run in the admission path.
+const defaultTimeout = 50 * time.Millisecond
+
+// Config is the configuration of the opa plugin.
+type Config struct {
+	// URL is the OPA Data API URL of the policy decision, e.g.
+	// http://localhost:8181/v1/data/kubechronicle/deny.
+	URL string `json:"url"`
+
+	// Token is sent as a bearer token, if OPA requires authentication.
+	Token string `json:"token,omitempty"`
+
+	// Timeout bounds each query, as a Go duration (default: 50ms).
+	Timeout string `json:"timeout,omitempty"`
+
+	// FailClosed denies requests when OPA can't be queried. Requests are allowed by default,
+	// like the rest of the webhook.
+	FailClosed bool `json:"fail_closed,omitempty"`
+}
+
+// Input is the input document of the policy: the admission request, with the object and old
+// object as JSON, and the event kubechronicle decoded from it, without diff or snapshot.
+type Input struct {
+	Request *plugin.Request `json:"request"`
+	Event   *plugin.Event   `json:"event"`
+}
+
+// Plugin queries OPA before kubechronicle's block and ignore rules.
+type Plugin struct {
+	url        string
+	token      string
+	failClosed bool
+	client     *http.Client
+}
+
+func init() {
+	plugin.Register("opa", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg Config
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+		return New(&cfg)
+	})
+}
+
+// New creates the plugin.
+func New(cfg *Config) (*Plugin, error) {
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("url must be an http(s) URL")
+	}
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", cfg.Timeout)
+		}
+	}
+	return &Plugin{
+		url:        cfg.URL,
+		token:      cfg.Token,
+		failClosed: cfg.FailClosed,
+		client:     &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name returns the plugin name.
+func (p *Plugin) Name() string {
+	return "opa"
+}
+
+// PreDecision queries the policy decision for the request. See Decide for how results are read.
+func (p *Plugin) PreDecision(ctx context.Context, req *plugin.Request, event *plugin.Event) (*plugin.Decision, error) {
+	result, err := p.query(ctx, &Input{Request: req, Event: event})
+	if err != nil {
+		if p.failClosed {
+			return &plugin.Decision{Message: fmt.Sprintf("Policy evaluation failed: %v", err)}, nil
+		}
+		return nil, err
+	}
+	return Decide(result)
+}
+
+// query evaluates the policy on input with the OPA Data API, returning its undecoded result.
+func (p *Plugin) query(ctx context.Context, input *Input) (json.RawMessage, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OPA: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("OPA returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode OPA response: %w", err)
+	}
+	return response.Result, nil
+}
+
+// Decide reads a policy result, of an OPA query or a WASM policy. The request is denied if
+// the result is:
+//
+//   - true, e.g. a boolean deny rule
+//   - a non-empty list of messages, e.g. a deny[msg] set as used by Gatekeeper-style policies
+//   - an object with "allow": false, with an optional "message"
+//
+// Objects may also list "warnings", returned with the response whether or not the request
+// is denied. An undefined result (no rule matched) allows the request.
+func Decide(result json.RawMessage) (*plugin.Decision, error) {
+	if len(result) == 0 || string(result) == "null" {
+		return nil, nil
+	}
+
+	var deny bool
+	if err := json.Unmarshal(result, &deny); err == nil {
+		if deny {
+			return &plugin.Decision{Message: "Request denied by OPA policy"}, nil
+		}
+		return nil, nil
+	}
+
+	var messages []string
+	if err := json.Unmarshal(result, &messages); err == nil {
+		if len(messages) > 0 {
+			return &plugin.Decision{Message: strings.Join(messages, "; ")}, nil
+		}
+		return nil, nil
+	}
+
+	var decision struct {
+		Allow    *bool    `json:"allow"`
+		Message  string   `json:"message"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(result, &decision); err != nil {
+		return nil, fmt.Errorf("unsupported policy result %s", result)
+	}
+	if decision.Allow == nil {
+		return nil, fmt.Errorf("policy result has no allow field: %s", result)
+	}
+	return &plugin.Decision{Allowed: *decision.Allow, Message: decision.Message, Warnings: decision.Warnings}, nil
+}
@@ -0,0 +1,116 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   *plugin.Decision
+	}{
+		{"undefined", ``, nil},
+		{"null", `null`, nil},
+		{"deny true", `true`, &plugin.Decision{Message: "Request denied by OPA policy"}},
+		{"deny false", `false`, nil},
+		{"no messages", `[]`, nil},
+		{"messages", `["no latest tags", "missing owner"]`, &plugin.Decision{Message: "no latest tags; missing owner"}},
+		{"allow object", `{"allow": true, "warnings": ["deprecated API"]}`, &plugin.Decision{Allowed: true, Warnings: []string{"deprecated API"}}},
+		{"deny object", `{"allow": false, "message": "frozen"}`, &plugin.Decision{Message: "frozen"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decide(json.RawMessage(tt.result))
+			if err != nil {
+				t.Fatalf("Decide() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decide() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, result := range []string{`42`, `{"message": "no allow"}`} {
+		if _, err := Decide(json.RawMessage(result)); err == nil {
+			t.Errorf("Decide(%s) should fail", result)
+		}
+	}
+}
+
+func TestPlugin_PreDecision(t *testing.T) {
+	var input Input
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var body struct {
+			Input Input `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		input = body.Input
+		w.Write([]byte(`{"result": ["image tag latest is not allowed"]}`))
+	}))
+	defer server.Close()
+
+	p, err := plugin.New("opa", json.RawMessage(`{"url": "`+server.URL+`/v1/data/kubechronicle/deny", "token": "secret"}`))
+	if err != nil {
+		t.Fatalf("plugin.New() error = %v", err)
+	}
+	req := &admissionv1.AdmissionRequest{UID: "uid-1", Namespace: "payments"}
+	event := &plugin.Event{Operation: "CREATE", ResourceKind: "Pod", Namespace: "payments", Name: "api"}
+
+	decision, err := p.(plugin.PreDecider).PreDecision(context.Background(), req, event)
+	if err != nil {
+		t.Fatalf("PreDecision() error = %v", err)
+	}
+	if decision.Allowed || decision.Message != "image tag latest is not allowed" {
+		t.Errorf("Unexpected decision: %+v", decision)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Unexpected Authorization header: %q", auth)
+	}
+	if input.Request == nil || input.Request.UID != "uid-1" || input.Event == nil || input.Event.Name != "api" {
+		t.Errorf("Unexpected input: %+v", input)
+	}
+}
+
+func TestPlugin_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			time.Sleep(200 * time.Millisecond)
+		}
+		http.Error(w, "policy not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+	event := &plugin.Event{Namespace: "default"}
+
+	failOpen, _ := New(&Config{URL: server.URL + "/v1/data/missing"})
+	if _, err := failOpen.PreDecision(context.Background(), &admissionv1.AdmissionRequest{}, event); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Expected an error for a missing policy, got %v", err)
+	}
+
+	failClosed, _ := New(&Config{URL: server.URL + "/slow", Timeout: "20ms", FailClosed: true})
+	decision, err := failClosed.PreDecision(context.Background(), &admissionv1.AdmissionRequest{}, event)
+	if err != nil || decision == nil || decision.Allowed {
+		t.Errorf("Expected a fail-closed denial, got %+v, %v", decision, err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, cfg := range []Config{{URL: "localhost:8181"}, {URL: "http://localhost:8181", Timeout: "soon"}, {URL: "http://localhost:8181", Timeout: "-1s"}} {
+		if _, err := New(&cfg); err == nil {
+			t.Errorf("New(%+v) should fail", cfg)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package plugin lets deployers compile custom processing into the webhook's admission
+// pipeline, such as company-specific enrichment or policy, without forking it.
+//
+// A plugin implements one or more of the stage interfaces, each called at a registration
+// point of the pipeline:
+//
+//   - PreDecider, before the block, ignore and sampling rules are evaluated
+//   - PostDecider, once the admission decision is made, before it is returned
+//   - PrePersister, in the async worker, before the event is saved and alerted on
+//   - Enricher, in the async worker after the pre-persist plugins, adding organizational
+//     context such as the owning team to the event's Enrichment
+//
+// Plugins of a stage run in the order they are configured. A plugin that returns an error
+// is logged and skipped: like the rest of the webhook, the pipeline fails open.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/kubechronicle/kubechronicle/internal/model"
+)
+
+// Event is the change event passed to plugins. It lets plugins outside this module implement
+// the stage interfaces, whose methods are declared in terms of the internal model.
+type Event = model.ChangeEvent
+
+// Request is the admission request an event was decoded from.
+type Request = admissionv1.AdmissionRequest
+
+// Decision is the outcome of an admission request.
+type Decision struct {
+	// Allowed is false if the request is denied.
+	Allowed bool
+
+	// Message is the reason returned to the user when the request is denied.
+	Message string
+
+	// Warnings are returned to the user with the response, e.g. by kubectl.
+	Warnings []string
+}
+
+// Plugin is a compiled-in processing step. It must implement at least one stage interface.
+type Plugin interface {
+	// Name returns the name the plugin is registered under.
+	Name() string
+}
+
+// PreDecider is a plugin called before kubechronicle's own rules.
+type PreDecider interface {
+	Plugin
+
+	// PreDecision may modify the event's metadata, which the rules then match against.
+	// Returning a decision with Allowed false denies the request, which is recorded as blocked
+	// with the block pattern "plugin:<name>"; its warnings are returned either way. Returning
+	// nil leaves the decision to the rules.
+	PreDecision(ctx context.Context, req *Request, event *Event) (*Decision, error)
+}
+
+// PostDecider is a plugin called with the decision of every request, including ignored,
+// sampled-out and blocked ones.
+type PostDecider interface {
+	Plugin
+
+	// PostDecision may append to the decision's warnings. Changes to Allowed and Message are
+	// ignored: requests are denied in PreDecision.
+	PostDecision(ctx context.Context, req *Request, event *Event, decision *Decision) error
+}
+
+// PrePersister is a plugin called before an event is saved and alerted on, once its diff
+// and snapshot are decoded. It runs off the admission path, so it may call slow services.
+type PrePersister interface {
+	Plugin
+
+	// PrePersist may modify the event. Returning false drops it: it is neither saved nor alerted on.
+	PrePersist(ctx context.Context, event *Event) (bool, error)
+}
+
+// Enricher is a plugin adding organizational context to events, such as the location of the
+// actor's IP address or the team owning the namespace, once the pre-persist plugins have
+// kept them. It runs off the admission path, so it may call slow services, but should cache
+// their answers: it is called for every recorded event.
+type Enricher interface {
+	Plugin
+
+	// Enrich adds keys to the event's Enrichment with SetEnrichment. Keys are lower-case
+	// snake_case, prefixed by what they describe, e.g. "geo_country" or "team".
+	Enrich(ctx context.Context, event *Event) error
+}
+
+// SetEnrichment sets a key of the event's Enrichment, ignoring empty values.
+func SetEnrichment(event *Event, key, value string) {
+	if value == "" {
+		return
+	}
+	if event.Enrichment == nil {
+		event.Enrichment = map[string]string{}
+	}
+	event.Enrichment[key] = value
+}
+
+// Factory creates a plugin from the JSON configuration of its entry in PLUGIN_CONFIG.
+type Factory func(config json.RawMessage) (Plugin, error)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{}
+)
+
+// Register makes a plugin available under name, enabled by an entry in PLUGIN_CONFIG:
+//
+//	[{"name": "team-owner", "config": {"label": "team"}}]
+//
+// It is meant to be called from the init function of the package implementing the plugin,
+// which is then compiled into the webhook with a blank import. The plugin's Name should
+// return name. Register panics if name is empty or already registered.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	if name == "" || factory == nil {
+		panic("plugin: Register requires a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("plugin: %q is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Registered returns the names of the registered plugins, sorted.
+func Registered() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the plugin registered under name with its configuration.
+func New(name string, config json.RawMessage) (Plugin, error) {
+	registryMutex.RLock()
+	factory, ok := registry[name]
+	registryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown plugin %q (registered: %v)", name, Registered())
+	}
+	p, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin %s: %w", name, err)
+	}
+	switch p.(type) {
+	case PreDecider, PostDecider, PrePersister, Enricher:
+		return p, nil
+	default:
+		return nil, fmt.Errorf("plugin %s implements no stage interface", name)
+	}
+}
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ownerPlugin is a plugin registered by the tests.
+type ownerPlugin struct {
+	Team string `json:"team"`
+}
+
+func (p *ownerPlugin) Name() string {
+	return "test-owner"
+}
+
+func (p *ownerPlugin) PrePersist(ctx context.Context, event *Event) (bool, error) {
+	event.Source.Tool = p.Team
+	return true, nil
+}
+
+// namedPlugin implements no stage interface.
+type namedPlugin struct{}
+
+func (p namedPlugin) Name() string {
+	return "test-stageless"
+}
+
+func init() {
+	Register("test-owner", func(config json.RawMessage) (Plugin, error) {
+		p := &ownerPlugin{}
+		if err := json.Unmarshal(config, p); err != nil {
+			return nil, err
+		}
+		if p.Team == "" {
+			return nil, fmt.Errorf("team is required")
+		}
+		return p, nil
+	})
+	Register("test-stageless", func(config json.RawMessage) (Plugin, error) {
+		return namedPlugin{}, nil
+	})
+}
+
+func TestNew(t *testing.T) {
+	p, err := New("test-owner", json.RawMessage(`{"team": "payments"}`))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	event := &Event{}
+	if keep, err := p.(PrePersister).PrePersist(context.Background(), event); !keep || err != nil || event.Source.Tool != "payments" {
+		t.Errorf("PrePersist() = %v, %v, event source %q", keep, err, event.Source.Tool)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugin  string
+		config  string
+		wantErr string
+	}{
+		{"unknown plugin", "opa", `{}`, `unknown plugin "opa"`},
+		{"factory error", "test-owner", `{}`, "team is required"},
+		{"no stage", "test-stageless", `{}`, "implements no stage interface"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.plugin, json.RawMessage(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegister_Panics(t *testing.T) {
+	for _, name := range []string{"", "test-owner"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) should panic", name)
+				}
+			}()
+			Register(name, func(config json.RawMessage) (Plugin, error) { return nil, nil })
+		}()
+	}
+	if registered := strings.Join(Registered(), ","); registered != "test-owner,test-stageless" {
+		t.Errorf("Registered() = %v", registered)
+	}
+}
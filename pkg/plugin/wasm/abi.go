@@ -0,0 +1,208 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// instantiateOPAHost instantiates the "env" module OPA modules import their host functions
+// from. Built-in functions evaluated by the host, such as sprintf, aren't implemented:
+// modules using them are rejected by checkBuiltins.
+func instantiateOPAHost(ctx context.Context, runtime wazero.Runtime) error {
+	i32 := api.ValueTypeI32
+	builder := runtime.NewHostModuleBuilder("env")
+	builder.NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(func(ctx context.Context, module api.Module, stack []uint64) {
+			message, _ := (&instance{module: module}).readString(api.DecodeU32(stack[0]))
+			panic(fmt.Errorf("policy aborted: %s", message))
+		}), []api.ValueType{i32}, nil).
+		Export("opa_abort")
+	builder.NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(func(ctx context.Context, module api.Module, stack []uint64) {}), []api.ValueType{i32}, nil).
+		Export("opa_println")
+	for args := 0; args <= 4; args++ {
+		// opa_builtinN(id, ctx, args...) returns the address of the result
+		params := make([]api.ValueType, args+2)
+		for j := range params {
+			params[j] = i32
+		}
+		builder.NewFunctionBuilder().
+			WithGoModuleFunction(api.GoModuleFunc(func(ctx context.Context, module api.Module, stack []uint64) {
+				panic(fmt.Errorf("built-in function %d is not supported", api.DecodeU32(stack[0])))
+			}), params, []api.ValueType{i32}).
+			Export(fmt.Sprintf("opa_builtin%d", args))
+	}
+	if _, err := builder.Instantiate(ctx); err != nil {
+		return fmt.Errorf("failed to instantiate OPA host functions: %w", err)
+	}
+	return nil
+}
+
+// loadData loads an empty data document, policies only reading their input, and records the
+// start of the heap evaluations use.
+func (i *instance) loadData(ctx context.Context) error {
+	data := []byte("{}")
+	results, err := i.call(ctx, "opa_malloc", uint64(len(data)))
+	if err != nil {
+		return err
+	}
+	if err := i.write(api.DecodeU32(results[0]), data); err != nil {
+		return err
+	}
+	if results, err = i.call(ctx, "opa_json_parse", results[0], uint64(len(data))); err != nil {
+		return err
+	}
+	if i.dataAddr = api.DecodeU32(results[0]); i.dataAddr == 0 {
+		return fmt.Errorf("failed to load data document")
+	}
+	if results, err = i.call(ctx, "opa_heap_ptr_get"); err != nil {
+		return err
+	}
+	i.heapAddr = api.DecodeU32(results[0])
+	return nil
+}
+
+// dumpJSON decodes the OPA value returned by an exported function.
+func (i *instance) dumpJSON(ctx context.Context, name string, v interface{}) error {
+	results, err := i.call(ctx, name)
+	if err != nil {
+		return err
+	}
+	if results, err = i.call(ctx, "opa_json_dump", results[0]); err != nil {
+		return err
+	}
+	dumped, err := i.readString(api.DecodeU32(results[0]))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(dumped), v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// checkBuiltins rejects modules needing built-in functions evaluated by the host.
+func (i *instance) checkBuiltins(ctx context.Context) error {
+	var builtins map[string]int32
+	if err := i.dumpJSON(ctx, "builtins", &builtins); err != nil {
+		return err
+	}
+	if len(builtins) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("policy uses built-in functions that are not supported in WASM: %s", strings.Join(names, ", "))
+}
+
+// entrypoint returns the ID of the named entrypoint, or of the only one if name is empty.
+func (i *instance) entrypoint(ctx context.Context, name string) (int32, error) {
+	var entrypoints map[string]int32
+	if err := i.dumpJSON(ctx, "entrypoints", &entrypoints); err != nil {
+		return 0, err
+	}
+	if name == "" {
+		if len(entrypoints) == 1 {
+			for _, id := range entrypoints {
+				return id, nil
+			}
+		}
+		names := make([]string, 0, len(entrypoints))
+		for name := range entrypoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("entrypoint is required, the module has %d: %s", len(names), strings.Join(names, ", "))
+	}
+	id, ok := entrypoints[name]
+	if !ok {
+		return 0, fmt.Errorf("module has no entrypoint %q", name)
+	}
+	return id, nil
+}
+
+// evalOPA evaluates the entrypoint on input with opa_eval, writing the input at the start of
+// the heap so that each evaluation reuses the same memory.
+func (i *instance) evalOPA(ctx context.Context, entrypoint int32, input []byte) (json.RawMessage, error) {
+	if err := i.write(i.heapAddr, input); err != nil {
+		return nil, err
+	}
+	// opa_eval(reserved, entrypoint, data, input, input_len, heap, format) with a JSON result
+	results, err := i.call(ctx, "opa_eval", 0, api.EncodeI32(entrypoint), api.EncodeU32(i.dataAddr),
+		api.EncodeU32(i.heapAddr), uint64(len(input)), api.EncodeU32(i.heapAddr+uint32(len(input))), 0)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := i.readString(api.DecodeU32(results[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	// The result is a set of {"result": ...}, empty if the policy is undefined
+	var resultSet []struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &resultSet); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	if len(resultSet) == 0 {
+		return nil, nil
+	}
+	return resultSet[0].Result, nil
+}
+
+// checkJSONExports checks the module exports the functions of the json ABI.
+func (i *instance) checkJSONExports() error {
+	if i.module.Memory() == nil {
+		return fmt.Errorf("module has no memory")
+	}
+	for _, name := range []string{"alloc", "decide"} {
+		if i.module.ExportedFunction(name) == nil {
+			return fmt.Errorf("module doesn't export %s", name)
+		}
+	}
+	return nil
+}
+
+// evalJSON evaluates the policy on input with the json ABI's decide.
+func (i *instance) evalJSON(ctx context.Context, input []byte) (json.RawMessage, error) {
+	results, err := i.call(ctx, "alloc", uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	inputAddr := api.DecodeU32(results[0])
+	if err := i.write(inputAddr, input); err != nil {
+		return nil, err
+	}
+	if results, err = i.call(ctx, "decide", api.EncodeU32(inputAddr), uint64(len(input))); err != nil {
+		return nil, err
+	}
+	resultAddr, resultLen := uint32(results[0]>>32), uint32(results[0])
+	var result []byte
+	if resultLen > 0 {
+		if result, err = i.read(resultAddr, resultLen); err != nil {
+			return nil, err
+		}
+	}
+
+	if i.module.ExportedFunction("free") != nil {
+		if _, err := i.call(ctx, "free", api.EncodeU32(inputAddr), uint64(len(input))); err != nil {
+			return nil, err
+		}
+		if resultLen > 0 {
+			if _, err := i.call(ctx, "free", api.EncodeU32(resultAddr), uint64(resultLen)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Section IDs of the WebAssembly binary format.
+const (
+	sectionImport = 2
+	sectionMemory = 5
+)
+
+// Import kinds of the WebAssembly binary format.
+const (
+	importFunction = 0x00
+	importTable    = 0x01
+	importMemory   = 0x02
+	importGlobal   = 0x03
+)
+
+// defineImportedMemory rewrites a module importing its memory, as OPA modules do with
+// env.memory, into one defining a memory of the same limits. The memory index is unchanged,
+// so the code needs no rewriting. Modules not importing a memory are returned as is.
+func defineImportedMemory(module []byte) ([]byte, error) {
+	header := []byte("\x00asm\x01\x00\x00\x00")
+	if !bytes.HasPrefix(module, header) {
+		return nil, fmt.Errorf("not a WebAssembly module")
+	}
+
+	var sections [][]byte // Encoded sections, in order
+	var imports []byte    // Import section content, without the memory
+	var limits []byte     // Limits of the imported memory
+	r := &reader{data: module, offset: len(header)}
+	for r.offset < len(r.data) {
+		start := r.offset
+		id := r.byte()
+		size := r.uint32()
+		content := r.bytes(int(size))
+		if r.err != nil {
+			return nil, r.err
+		}
+		if id == sectionImport {
+			var err error
+			if imports, limits, err = removeMemoryImport(content); err != nil {
+				return nil, err
+			}
+			if limits != nil {
+				sections = append(sections, encodeSection(sectionImport, imports))
+				continue
+			}
+		}
+		sections = append(sections, r.data[start:r.offset])
+	}
+	if limits == nil {
+		return module, nil
+	}
+
+	// The memory section follows the import, function and table sections
+	out := append([]byte(nil), header...)
+	inserted := false
+	for _, section := range sections {
+		if id := section[0]; !inserted && id > sectionMemory {
+			out = append(out, encodeSection(sectionMemory, append([]byte{1}, limits...))...)
+			inserted = true
+		}
+		out = append(out, section...)
+	}
+	if !inserted {
+		out = append(out, encodeSection(sectionMemory, append([]byte{1}, limits...))...)
+	}
+	return out, nil
+}
+
+// removeMemoryImport returns the content of an import section without its memory import,
+// and the limits of the memory, or nil limits if it imports none.
+func removeMemoryImport(content []byte) ([]byte, []byte, error) {
+	r := &reader{data: content}
+	count := r.uint32()
+	var entries []byte
+	var limits []byte
+	for n := uint32(0); n < count && r.err == nil; n++ {
+		start := r.offset
+		r.bytes(int(r.uint32())) // Module name
+		r.bytes(int(r.uint32())) // Field name
+		switch kind := r.byte(); kind {
+		case importFunction:
+			r.uint32()
+		case importTable:
+			r.byte()
+			r.limits()
+		case importMemory:
+			limitsStart := r.offset
+			r.limits()
+			limits = r.data[limitsStart:r.offset]
+			continue
+		case importGlobal:
+			r.byte()
+			r.byte()
+		default:
+			return nil, nil, fmt.Errorf("invalid import kind %#x", kind)
+		}
+		entries = append(entries, r.data[start:r.offset]...)
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if limits == nil {
+		return content, nil, nil
+	}
+	return append(binary.AppendUvarint(nil, uint64(count-1)), entries...), limits, nil
+}
+
+// encodeSection encodes a section with its ID and size.
+func encodeSection(id byte, content []byte) []byte {
+	return append(binary.AppendUvarint([]byte{id}, uint64(len(content))), content...)
+}
+
+// reader reads the WebAssembly binary format, recording the first error.
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) byte() byte {
+	if r.err == nil && r.offset >= len(r.data) {
+		r.err = fmt.Errorf("unexpected end of module")
+	}
+	if r.err != nil {
+		return 0
+	}
+	r.offset++
+	return r.data[r.offset-1]
+}
+
+func (r *reader) uint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(r.data[r.offset:])
+	if n <= 0 || value > 1<<32-1 {
+		r.err = fmt.Errorf("invalid integer at offset %d", r.offset)
+		return 0
+	}
+	r.offset += n
+	return uint32(value)
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err == nil && (n < 0 || r.offset+n > len(r.data)) {
+		r.err = fmt.Errorf("unexpected end of module")
+	}
+	if r.err != nil {
+		return nil
+	}
+	r.offset += n
+	return r.data[r.offset-n : r.offset]
+}
+
+// limits reads the limits of a table or memory: a flag, the minimum and, if the flag's low
+// bit is set, the maximum.
+func (r *reader) limits() {
+	flag := r.byte()
+	r.uint32()
+	if flag&1 != 0 {
+		r.uint32()
+	}
+}
@@ -0,0 +1,322 @@
+// Package wasm is an admission plugin deciding whether requests are blocked with WebAssembly
+// policy modules evaluated in the webhook, either Rego policies compiled with
+// "opa build -t wasm" or modules exporting a small JSON interface.
+//
+// It is enabled with PLUGIN_CONFIG:
+//
+//	[{"name": "wasm", "config": {"module": "/etc/kubechronicle/policies/policy.wasm"}}]
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+	"github.com/kubechronicle/kubechronicle/pkg/plugin/opa"
+)
+
+const (
+	// defaultTimeout bounds evaluations, which run in the admission path.
+	defaultTimeout = 50 * time.Millisecond
+
+	// memoryLimitPages caps the memory of a module instance to 64 MiB.
+	memoryLimitPages = 1024
+
+	// maxInstances is the number of idle instances kept for concurrent requests.
+	maxInstances = 8
+)
+
+// ABIs of policy modules.
+const (
+	// ABIOPA is the ABI of Rego policies compiled with "opa build -t wasm" (OPA ABI 1.2 or later).
+	ABIOPA = "opa"
+
+	// ABIJSON is the ABI of other modules, e.g. built with TinyGo or Rust. The module exports
+	// its memory, alloc(size i32) i32 returning a buffer the input is written to, and
+	// decide(ptr i32, len i32) i64 returning the address of the JSON result in its high
+	// 32 bits and its length in the low ones. If it exports free(ptr i32, len i32), the input
+	// and result are freed after each evaluation. WASI modules are instantiated as reactors and
+	// get no files, network or environment.
+	ABIJSON = "json"
+)
+
+// Config is the configuration of the wasm plugin.
+type Config struct {
+	// Module is the path of the .wasm file, e.g. mounted from a ConfigMap.
+	Module string `json:"module"`
+
+	// ABI is the module's interface: "opa" (default) or "json".
+	ABI string `json:"abi,omitempty"`
+
+	// Entrypoint is the OPA entrypoint evaluated, e.g. "kubechronicle/deny". It is required
+	// if the module has several.
+	Entrypoint string `json:"entrypoint,omitempty"`
+
+	// Timeout bounds each evaluation, as a Go duration (default: 50ms).
+	Timeout string `json:"timeout,omitempty"`
+
+	// FailClosed denies requests when the policy can't be evaluated. Requests are allowed by
+	// default, like the rest of the webhook.
+	FailClosed bool `json:"fail_closed,omitempty"`
+}
+
+// Plugin evaluates a WebAssembly policy before kubechronicle's block and ignore rules.
+type Plugin struct {
+	runtime    wazero.Runtime
+	compiled   wazero.CompiledModule
+	abi        string
+	entrypoint int32
+	timeout    time.Duration
+	failClosed bool
+	instances  chan *instance
+}
+
+func init() {
+	plugin.Register("wasm", func(config json.RawMessage) (plugin.Plugin, error) {
+		var cfg Config
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+		return New(&cfg)
+	})
+}
+
+// New creates the plugin, compiling the module and checking it can be evaluated.
+func New(cfg *Config) (*Plugin, error) {
+	if cfg.Module == "" {
+		return nil, fmt.Errorf("module is required")
+	}
+	binary, err := os.ReadFile(cfg.Module)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %w", err)
+	}
+	return newPlugin(binary, cfg)
+}
+
+// newPlugin creates the plugin from the module's binary.
+func newPlugin(binary []byte, cfg *Config) (*Plugin, error) {
+	p := &Plugin{
+		abi:        cfg.ABI,
+		timeout:    defaultTimeout,
+		failClosed: cfg.FailClosed,
+		instances:  make(chan *instance, maxInstances),
+	}
+	if p.abi == "" {
+		p.abi = ABIOPA
+	}
+	if p.abi != ABIOPA && p.abi != ABIJSON {
+		return nil, fmt.Errorf("invalid abi %q, must be %q or %q", cfg.ABI, ABIOPA, ABIJSON)
+	}
+	if cfg.Timeout != "" {
+		var err error
+		p.timeout, err = time.ParseDuration(cfg.Timeout)
+		if err != nil || p.timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", cfg.Timeout)
+		}
+	}
+
+	ctx := context.Background()
+	p.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(memoryLimitPages))
+	if err := p.load(ctx, binary, cfg.Entrypoint); err != nil {
+		p.runtime.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// load compiles the module with the host functions of its ABI and creates a first instance,
+// which checks the module implements the ABI.
+func (p *Plugin) load(ctx context.Context, binary []byte, entrypoint string) error {
+	var err error
+	if p.abi == ABIOPA {
+		// OPA modules import their memory from the host, which wazero can't export: each
+		// instance defines its own instead.
+		if binary, err = defineImportedMemory(binary); err != nil {
+			return fmt.Errorf("invalid module: %w", err)
+		}
+		if err := instantiateOPAHost(ctx, p.runtime); err != nil {
+			return err
+		}
+	} else if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	if p.compiled, err = p.runtime.CompileModule(ctx, binary); err != nil {
+		return fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	inst, err := p.newInstance(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.release(ctx, inst)
+	if p.abi == ABIOPA {
+		if err := inst.checkBuiltins(ctx); err != nil {
+			return err
+		}
+		if p.entrypoint, err = inst.entrypoint(ctx, entrypoint); err != nil {
+			return err
+		}
+	} else if entrypoint != "" {
+		return fmt.Errorf("entrypoint is only supported by the %q abi", ABIOPA)
+	}
+	return nil
+}
+
+// Name returns the plugin name.
+func (p *Plugin) Name() string {
+	return "wasm"
+}
+
+// PreDecision evaluates the policy on the request. Results are read like OPA's, see opa.Decide.
+func (p *Plugin) PreDecision(ctx context.Context, req *plugin.Request, event *plugin.Event) (*plugin.Decision, error) {
+	result, err := p.evaluate(ctx, &opa.Input{Request: req, Event: event})
+	if err != nil {
+		if p.failClosed {
+			return &plugin.Decision{Message: fmt.Sprintf("Policy evaluation failed: %v", err)}, nil
+		}
+		return nil, err
+	}
+	return opa.Decide(result)
+}
+
+// evaluate evaluates the policy on input with an idle instance, returning its undecoded result.
+func (p *Plugin) evaluate(ctx context.Context, input *opa.Input) (json.RawMessage, error) {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal input: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var inst *instance
+	select {
+	case inst = <-p.instances:
+	default:
+		if inst, err = p.newInstance(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var result json.RawMessage
+	if p.abi == ABIOPA {
+		result, err = inst.evalOPA(ctx, p.entrypoint, encoded)
+	} else {
+		result, err = inst.evalJSON(ctx, encoded)
+	}
+	if err != nil {
+		// The instance may be left in any state, or closed by the timeout
+		inst.module.Close(ctx)
+		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+	p.release(ctx, inst)
+	return result, nil
+}
+
+// newInstance instantiates the module, with its own memory.
+func (p *Plugin) newInstance(ctx context.Context) (*instance, error) {
+	// Instances are anonymous so that the module can be instantiated several times
+	config := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	inst := &instance{module: module}
+	if p.abi == ABIOPA {
+		err = inst.loadData(ctx)
+	} else {
+		err = inst.checkJSONExports()
+	}
+	if err != nil {
+		module.Close(ctx)
+		return nil, err
+	}
+	return inst, nil
+}
+
+// release keeps an instance for the next evaluations, or closes it if enough are idle.
+func (p *Plugin) release(ctx context.Context, inst *instance) {
+	select {
+	case p.instances <- inst:
+	default:
+		inst.module.Close(ctx)
+	}
+}
+
+// instance is an instance of the module, evaluating one request at a time.
+type instance struct {
+	module api.Module
+
+	// dataAddr and heapAddr are the address of the OPA data document and the start of the
+	// heap evaluations use, reset on each evaluation.
+	dataAddr uint32
+	heapAddr uint32
+}
+
+// call calls an exported function, failing if the module doesn't export it.
+func (i *instance) call(ctx context.Context, name string, params ...uint64) ([]uint64, error) {
+	fn := i.module.ExportedFunction(name)
+	if fn == nil {
+		return nil, fmt.Errorf("module doesn't export %s", name)
+	}
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return results, nil
+}
+
+// write writes data at addr, growing the memory if needed.
+func (i *instance) write(addr uint32, data []byte) error {
+	memory := i.module.Memory()
+	if memory == nil {
+		return fmt.Errorf("module has no memory")
+	}
+	if end := uint64(addr) + uint64(len(data)); end > uint64(memory.Size()) {
+		pages := (end - uint64(memory.Size()) + 65535) / 65536
+		if _, ok := memory.Grow(uint32(pages)); !ok {
+			return fmt.Errorf("input of %d bytes exceeds the module's memory limit", len(data))
+		}
+	}
+	if !memory.Write(addr, data) {
+		return fmt.Errorf("failed to write %d bytes at %#x", len(data), addr)
+	}
+	return nil
+}
+
+// read reads length bytes at addr.
+func (i *instance) read(addr, length uint32) ([]byte, error) {
+	memory := i.module.Memory()
+	if memory == nil {
+		return nil, fmt.Errorf("module has no memory")
+	}
+	data, ok := memory.Read(addr, length)
+	if !ok {
+		return nil, fmt.Errorf("failed to read %d bytes at %#x", length, addr)
+	}
+	// The memory may be reused by the next evaluation
+	return append([]byte(nil), data...), nil
+}
+
+// readString reads the NUL-terminated string at addr.
+func (i *instance) readString(addr uint32) (string, error) {
+	memory := i.module.Memory()
+	if memory == nil {
+		return "", fmt.Errorf("module has no memory")
+	}
+	for end := addr; end < memory.Size(); end++ {
+		if b, _ := memory.ReadByte(end); b == 0 {
+			data, err := i.read(addr, end-addr)
+			return string(data), err
+		}
+	}
+	return "", fmt.Errorf("unterminated string at %#x", addr)
+}
@@ -0,0 +1,254 @@
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/kubechronicle/kubechronicle/pkg/plugin"
+)
+
+// Instructions and types of the WebAssembly binary format used by the test modules.
+const (
+	typeI32     = 0x7f
+	typeI64     = 0x7e
+	opLocalGet  = 0x20
+	opI32Const  = 0x41
+	opI64Const  = 0x42
+	opLoop      = 0x03
+	opBr        = 0x0c
+	opEnd       = 0x0b
+	opBlockVoid = 0x40
+)
+
+// testFunc is an exported function of a test module.
+type testFunc struct {
+	name    string
+	params  []byte
+	results []byte
+	code    []byte // Body, without locals and the final end
+}
+
+// testModule assembles a module with the functions and data, at address 1024 on, that
+// imports its memory from env.memory, as OPA modules do, if memoryImported is set, and
+// defines and exports it otherwise.
+func testModule(memoryImported bool, imports []testFunc, funcs []testFunc, data string) []byte {
+	vec := func(items ...[]byte) []byte {
+		out := binary.AppendUvarint(nil, uint64(len(items)))
+		for _, item := range items {
+			out = append(out, item...)
+		}
+		return out
+	}
+	name := func(s string) []byte { return append(binary.AppendUvarint(nil, uint64(len(s))), s...) }
+	funcType := func(f testFunc) []byte {
+		out := append([]byte{0x60}, binary.AppendUvarint(nil, uint64(len(f.params)))...)
+		out = append(out, f.params...)
+		out = append(out, binary.AppendUvarint(nil, uint64(len(f.results)))...)
+		return append(out, f.results...)
+	}
+
+	var types, importEntries, functions, exports, bodies [][]byte
+	if memoryImported {
+		importEntries = append(importEntries, append(append(name("env"), name("memory")...), importMemory, 0x00, 0x01))
+	}
+	for i, f := range imports {
+		types = append(types, funcType(f))
+		importEntries = append(importEntries, append(append(name("env"), name(f.name)...), importFunction, byte(i)))
+	}
+	for i, f := range funcs {
+		index := len(imports) + i
+		types = append(types, funcType(f))
+		functions = append(functions, []byte{byte(index)})
+		exports = append(exports, append(name(f.name), 0x00, byte(index)))
+		body := append([]byte{0x00}, f.code...) // No locals
+		body = append(body, opEnd)
+		bodies = append(bodies, append(binary.AppendUvarint(nil, uint64(len(body))), body...))
+	}
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, encodeSection(1, vec(types...))...)
+	if len(importEntries) > 0 {
+		module = append(module, encodeSection(sectionImport, vec(importEntries...))...)
+	}
+	module = append(module, encodeSection(3, vec(functions...))...)
+	if !memoryImported {
+		module = append(module, encodeSection(sectionMemory, vec([]byte{0x00, 0x01}))...)
+		exports = append(exports, append(name("memory"), 0x02, 0x00))
+	}
+	module = append(module, encodeSection(7, vec(exports...))...)
+	module = append(module, encodeSection(10, vec(bodies...))...)
+	segment := append([]byte{0x00, opI32Const}, sleb(1024)...)
+	segment = append(segment, opEnd)
+	segment = append(segment, name(data)...)
+	return append(module, encodeSection(11, vec(segment))...)
+}
+
+// sleb encodes a signed LEB128 integer, as used by constants.
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// i32 returns the code of a function returning a constant.
+func i32(v int64) []byte { return append([]byte{opI32Const}, sleb(v)...) }
+
+// fakeOPAModule assembles a module implementing the OPA ABI whose policy always evaluates to
+// result, a JSON result set, with the builtins listed.
+func fakeOPAModule(builtins, entrypoints, result string) []byte {
+	// Strings are NUL-terminated, opa_json_dump returning its argument
+	data := builtins + "\x00" + entrypoints + "\x00" + result + "\x00"
+	builtinsAddr := int64(1024)
+	entrypointsAddr := builtinsAddr + int64(len(builtins)) + 1
+	resultAddr := entrypointsAddr + int64(len(entrypoints)) + 1
+	i := []byte{typeI32}
+	return testModule(true, []testFunc{{name: "opa_abort", params: i}}, []testFunc{
+		{name: "opa_malloc", params: i, results: i, code: i32(8192)},
+		{name: "opa_json_parse", params: []byte{typeI32, typeI32}, results: i, code: i32(4096)},
+		{name: "opa_heap_ptr_get", results: i, code: i32(16384)},
+		{name: "opa_json_dump", params: i, results: i, code: []byte{opLocalGet, 0}},
+		{name: "builtins", results: i, code: i32(builtinsAddr)},
+		{name: "entrypoints", results: i, code: i32(entrypointsAddr)},
+		{name: "opa_eval", params: []byte{typeI32, typeI32, typeI32, typeI32, typeI32, typeI32, typeI32}, results: i, code: i32(resultAddr)},
+	}, data)
+}
+
+// fakeJSONModule assembles a module implementing the json ABI whose decide returns result.
+func fakeJSONModule(result string) []byte {
+	i := []byte{typeI32}
+	decide := append([]byte{opI64Const}, sleb(1024<<32|int64(len(result)))...)
+	return testModule(false, nil, []testFunc{
+		{name: "alloc", params: i, results: i, code: i32(4096)},
+		{name: "decide", params: []byte{typeI32, typeI32}, results: []byte{typeI64}, code: decide},
+	}, result)
+}
+
+func TestDefineImportedMemory(t *testing.T) {
+	module := fakeOPAModule(`{}`, `{}`, `[]`)
+	rewritten, err := defineImportedMemory(module)
+	if err != nil {
+		t.Fatalf("defineImportedMemory() error = %v", err)
+	}
+	if strings.Contains(string(rewritten), "memory") {
+		t.Error("The memory import should be removed")
+	}
+	if !strings.Contains(string(rewritten), "opa_abort") {
+		t.Error("The function imports should be kept")
+	}
+
+	// Modules defining their memory are unchanged
+	defined := fakeJSONModule(`true`)
+	if got, err := defineImportedMemory(defined); err != nil || !reflect.DeepEqual(got, defined) {
+		t.Errorf("defineImportedMemory() changed a module defining its memory: %v", err)
+	}
+	if _, err := defineImportedMemory([]byte("not wasm")); err == nil {
+		t.Error("defineImportedMemory() should reject invalid modules")
+	}
+}
+
+func TestPlugin_PreDecision(t *testing.T) {
+	req := &plugin.Request{UID: "1", Operation: admissionv1.Create}
+	event := &plugin.Event{Namespace: "payments"}
+	tests := []struct {
+		name   string
+		module []byte
+		abi    string
+		want   *plugin.Decision
+	}{
+		{"opa deny", fakeOPAModule(`{}`, `{"kubechronicle/deny":0}`, `[{"result":["no latest tags"]}]`), "", &plugin.Decision{Message: "no latest tags"}},
+		{"opa undefined", fakeOPAModule(`{}`, `{"kubechronicle/deny":0}`, `[]`), "opa", nil},
+		{"json deny", fakeJSONModule(`{"allow": false, "message": "frozen"}`), "json", &plugin.Decision{Message: "frozen"}},
+		{"json allow", fakeJSONModule(`{"allow": true, "warnings": ["deprecated API"]}`), "json", &plugin.Decision{Allowed: true, Warnings: []string{"deprecated API"}}},
+		{"json undefined", fakeJSONModule(``), "json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPlugin(tt.module, &Config{ABI: tt.abi})
+			if err != nil {
+				t.Fatalf("newPlugin() error = %v", err)
+			}
+			// Twice, the second time with the instance of the first
+			for n := 0; n < 2; n++ {
+				got, err := p.PreDecision(context.Background(), req, event)
+				if err != nil {
+					t.Fatalf("PreDecision() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("PreDecision() = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlugin_Timeout(t *testing.T) {
+	// decide loops forever
+	i := []byte{typeI32}
+	loop := []byte{opLoop, opBlockVoid, opBr, 0, opEnd, opI64Const, 0}
+	module := testModule(false, nil, []testFunc{
+		{name: "alloc", params: i, results: i, code: i32(4096)},
+		{name: "decide", params: []byte{typeI32, typeI32}, results: []byte{typeI64}, code: loop},
+	}, "")
+
+	p, err := newPlugin(module, &Config{ABI: ABIJSON, Timeout: "10ms"})
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	start := time.Now()
+	if _, err := p.PreDecision(context.Background(), &plugin.Request{}, &plugin.Event{}); err == nil {
+		t.Error("PreDecision() should fail when the policy times out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("PreDecision() took %v, expected the timeout to stop it", elapsed)
+	}
+
+	p.failClosed = true
+	decision, err := p.PreDecision(context.Background(), &plugin.Request{}, &plugin.Event{})
+	if err != nil || decision == nil || decision.Allowed || !strings.Contains(decision.Message, "Policy evaluation failed") {
+		t.Errorf("Expected the request to be denied when failing closed, got %+v, %v", decision, err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		module  []byte
+		cfg     Config
+		wantErr string
+	}{
+		{"builtins", fakeOPAModule(`{"sprintf":0}`, `{"kubechronicle/deny":0}`, `[]`), Config{}, "sprintf"},
+		{"several entrypoints", fakeOPAModule(`{}`, `{"a":0,"b":1}`, `[]`), Config{}, "entrypoint is required"},
+		{"unknown entrypoint", fakeOPAModule(`{}`, `{"a":0}`, `[]`), Config{Entrypoint: "b"}, `no entrypoint "b"`},
+		{"not an OPA module", fakeJSONModule(`true`), Config{}, "opa_malloc"},
+		{"not a json module", fakeOPAModule(`{}`, `{"a":0}`, `[]`), Config{ABI: ABIJSON}, "failed to instantiate"},
+		{"abi", fakeJSONModule(`true`), Config{ABI: "rego"}, "invalid abi"},
+		{"timeout", fakeJSONModule(`true`), Config{ABI: ABIJSON, Timeout: "soon"}, "invalid timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPlugin(tt.module, &tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newPlugin() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := New(&Config{}); err == nil {
+		t.Error("New() should require a module")
+	}
+	if _, err := plugin.New("wasm", []byte(`{"module": "/nonexistent.wasm"}`)); err == nil {
+		t.Error("plugin.New() should fail for a missing module")
+	}
+}